@@ -0,0 +1,249 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/services"
+)
+
+// Severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a rule that is currently firing
+type Alert struct {
+	Name     string    `json:"name"`
+	Severity string    `json:"severity"`
+	Summary  string    `json:"summary"`
+	Detail   string    `json:"detail"`
+	Since    time.Time `json:"firing_since"`
+}
+
+// Rule is a named health check. Check reports whether the rule fires and a
+// detail line describing the current value.
+type Rule struct {
+	Name     string                                   `json:"name"`
+	Severity string                                   `json:"severity"`
+	Summary  string                                   `json:"summary"`
+	Check    func(ctx context.Context) (bool, string) `json:"-"`
+}
+
+// Notifier is called when an alert starts firing or resolves
+type Notifier func(a Alert, resolved bool)
+
+// Config holds rule thresholds and notification targets
+type Config struct {
+	Interval         time.Duration // how often rules are evaluated
+	MaxBlockAge      time.Duration // no_block_mined fires after this long without a block
+	MempoolThreshold int           // mempool_backlog fires above this many pending transactions
+	DBFailures       int           // database_circuit_open fires after this many failed pings in a row
+	WebhookURL       string        // operator webhook, empty to disable
+	Emails           []string      // operator email addresses
+}
+
+// DefaultConfig returns the default thresholds, with no operator webhook or
+// email addresses
+func DefaultConfig() Config {
+	return Config{
+		Interval:         time.Minute,
+		MaxBlockAge:      6 * time.Hour,
+		MempoolThreshold: 500,
+		DBFailures:       3,
+	}
+}
+
+// Manager evaluates rules on a schedule and tracks which ones are firing
+type Manager struct {
+	interval time.Duration
+	rules    []Rule
+	evalMu   sync.Mutex // serialises evaluations; rules may keep state
+
+	mu          sync.RWMutex
+	firing      map[string]Alert
+	evaluatedAt time.Time
+	notify      Notifier
+
+	done chan struct{}
+	loop sync.WaitGroup // the ticker goroutine; Stop waits for it
+}
+
+func NewManager(interval time.Duration, rules ...Rule) *Manager {
+	return &Manager{
+		interval: interval,
+		rules:    rules,
+		firing:   make(map[string]Alert),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetNotifier installs the callback for firing and resolved transitions
+func (m *Manager) SetNotifier(n Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notify = n
+}
+
+// Start evaluates the rules immediately and then every interval
+func (m *Manager) Start() {
+	m.loop.Add(1)
+	go func() {
+		defer m.loop.Done()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		m.Evaluate(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				m.Evaluate(context.Background())
+			case <-m.done:
+				return
+			}
+		}
+	}()
+	log.Printf("✅ Alert rules evaluated every %s", m.interval)
+}
+
+// Stop ends the evaluation loop, waiting for an evaluation in progress
+func (m *Manager) Stop() {
+	close(m.done)
+	m.loop.Wait()
+}
+
+// Evaluate runs every rule once and notifies on state changes
+func (m *Manager) Evaluate(ctx context.Context) {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+
+	now := time.Now()
+	type transition struct {
+		alert    Alert
+		resolved bool
+	}
+	var changes []transition
+
+	results := make(map[string]string, len(m.rules))
+	for _, rule := range m.rules {
+		if firing, detail := rule.Check(ctx); firing {
+			results[rule.Name] = detail
+		}
+	}
+
+	m.mu.Lock()
+	for _, rule := range m.rules {
+		detail, firing := results[rule.Name]
+		prev, wasFiring := m.firing[rule.Name]
+		switch {
+		case firing && wasFiring:
+			prev.Detail = detail
+			m.firing[rule.Name] = prev
+		case firing:
+			a := Alert{Name: rule.Name, Severity: rule.Severity, Summary: rule.Summary, Detail: detail, Since: now}
+			m.firing[rule.Name] = a
+			changes = append(changes, transition{a, false})
+		case wasFiring:
+			delete(m.firing, rule.Name)
+			changes = append(changes, transition{prev, true})
+		}
+	}
+	m.evaluatedAt = now
+	notify := m.notify
+	m.mu.Unlock()
+
+	for _, c := range changes {
+		state := "firing"
+		if c.resolved {
+			state = "resolved"
+		}
+		log.Printf("🚨 Alert %s %s: %s", c.alert.Name, state, c.alert.Detail)
+		if notify != nil {
+			notify(c.alert, c.resolved)
+		}
+	}
+}
+
+// Firing returns the firing alerts, critical first
+func (m *Manager) Firing() []Alert {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Alert, 0, len(m.firing))
+	for _, a := range m.firing {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Severity != list[j].Severity {
+			return list[i].Severity == SeverityCritical
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Rules returns the configured rules
+func (m *Manager) Rules() []Rule {
+	return m.rules
+}
+
+// EvaluatedAt returns when the rules last ran
+func (m *Manager) EvaluatedAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.evaluatedAt
+}
+
+// WritePrometheus writes the alert state in the Prometheus text exposition
+// format, one wallet_alert_firing gauge per rule, so alerts can be scraped
+// and routed through Alertmanager
+func (m *Manager) WritePrometheus(w io.Writer) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	fmt.Fprintln(w, "# HELP wallet_alert_firing Built-in operational alert state (1 = firing).")
+	fmt.Fprintln(w, "# TYPE wallet_alert_firing gauge")
+	for _, rule := range m.rules {
+		value := 0
+		if _, ok := m.firing[rule.Name]; ok {
+			value = 1
+		}
+		fmt.Fprintf(w, "wallet_alert_firing{alertname=%q,severity=%q} %d\n", rule.Name, rule.Severity, value)
+	}
+	fmt.Fprintln(w, "# HELP wallet_alerts_last_evaluation_timestamp_seconds Unix time of the last rule evaluation.")
+	fmt.Fprintln(w, "# TYPE wallet_alerts_last_evaluation_timestamp_seconds gauge")
+	fmt.Fprintf(w, "wallet_alerts_last_evaluation_timestamp_seconds %d\n", m.evaluatedAt.Unix())
+}
+
+// DeliveryNotifier sends alert transitions to the operator webhook and email
+// addresses through the delivery service, so failed notifications can be
+// inspected and redelivered like any other delivery
+func DeliveryNotifier(ds *services.DeliveryService, cfg Config) Notifier {
+	return func(a Alert, resolved bool) {
+		status := "firing"
+		if resolved {
+			status = "resolved"
+		}
+		eventType := "alert." + status
+		dedupKey := fmt.Sprintf("alert:%s:%s:%d", a.Name, status, a.Since.Unix())
+
+		if cfg.WebhookURL != "" {
+			payload, _ := json.Marshal(map[string]interface{}{"status": status, "alert": a})
+			ds.Enqueue(services.ChannelWebhook, cfg.WebhookURL, eventType, string(payload), dedupKey+":webhook")
+		}
+		if len(cfg.Emails) > 0 && ds.HasSender(services.ChannelEmail) {
+			subject := fmt.Sprintf("[%s] %s %s", strings.ToUpper(a.Severity), a.Name, status)
+			body := fmt.Sprintf("%s\n\n%s\nFiring since: %s\n", a.Summary, a.Detail, a.Since.Format(time.RFC3339))
+			for _, to := range cfg.Emails {
+				ds.EnqueueEmail(to, subject, body, eventType, dedupKey+":"+to)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/services"
+)
+
+// BuiltinRules returns the standard operational rules. db may be nil in
+// in-memory mode, in which case the database rule never fires.
+func BuiltinRules(cfg Config, bc *blockchain.Blockchain, db *database.DB, zakat *services.ZakatService) []Rule {
+	return []Rule{
+		noBlockMined(cfg, bc),
+		databaseCircuitOpen(cfg, db),
+		mempoolBacklog(cfg, bc),
+		zakatRunFailed(zakat),
+	}
+}
+
+func noBlockMined(cfg Config, bc *blockchain.Blockchain) Rule {
+	return Rule{
+		Name:     "no_block_mined",
+		Severity: SeverityWarning,
+		Summary:  fmt.Sprintf("No block has been mined for more than %s", cfg.MaxBlockAge),
+		Check: func(context.Context) (bool, string) {
+			last := bc.LastBlock()
+
+			age := time.Since(time.Unix(last.Timestamp, 0)).Truncate(time.Second)
+			return age > cfg.MaxBlockAge, fmt.Sprintf("last block #%d mined %s ago", last.Index, age)
+		},
+	}
+}
+
+// databaseCircuitOpen pings the database and fires once DBFailures pings in a
+// row have failed, i.e. when callers should treat the database as unavailable
+func databaseCircuitOpen(cfg Config, db *database.DB) Rule {
+	failures := 0
+	return Rule{
+		Name:     "database_circuit_open",
+		Severity: SeverityCritical,
+		Summary:  fmt.Sprintf("The database failed %d health checks in a row", cfg.DBFailures),
+		Check: func(ctx context.Context) (bool, string) {
+			if db == nil {
+				return false, "in-memory mode"
+			}
+			pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := db.Ping(pingCtx); err != nil {
+				failures++
+				return failures >= cfg.DBFailures, fmt.Sprintf("%d consecutive ping failures, last: %v", failures, err)
+			}
+			failures = 0
+			return false, "ping ok"
+		},
+	}
+}
+
+func mempoolBacklog(cfg Config, bc *blockchain.Blockchain) Rule {
+	return Rule{
+		Name:     "mempool_backlog",
+		Severity: SeverityWarning,
+		Summary:  fmt.Sprintf("More than %d transactions are waiting to be mined", cfg.MempoolThreshold),
+		Check: func(context.Context) (bool, string) {
+			pending := len(bc.GetPending())
+			return pending > cfg.MempoolThreshold, fmt.Sprintf("%d pending transactions", pending)
+		},
+	}
+}
+
+func zakatRunFailed(zakat *services.ZakatService) Rule {
+	return Rule{
+		Name:     "zakat_run_failed",
+		Severity: SeverityWarning,
+		Summary:  "The last zakat run could not process every eligible wallet",
+		Check: func(context.Context) (bool, string) {
+			run, ok := zakat.LastRun()
+			if !ok {
+				return false, "no zakat run yet"
+			}
+			if run.Failed > 0 {
+				return true, fmt.Sprintf("run at %s: %d failures, last error: %s", run.StartedAt.Format(time.RFC3339), run.Failed, run.LastError)
+			}
+			return false, fmt.Sprintf("run at %s: %d processed", run.StartedAt.Format(time.RFC3339), run.Processed)
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// A verified email is one user account, which may own several wallets, each
+// with an optional label such as "Savings". The routes below act on the
+// wallets of the email behind the bearer session.
+
+// requireSession returns the login session behind the bearer token, or
+// answers UNAUTHORIZED
+func (s *Server) requireSession(w http.ResponseWriter, r *http.Request) (services.Session, bool) {
+	sess, ok := s.sessions.Validate(bearerToken(r))
+	if !ok {
+		Error(w, r, CodeUnauthorized, "Missing, invalid or expired session token")
+		return services.Session{}, false
+	}
+	return sess, true
+}
+
+// handleAccountWallets lists the session email's wallets with their labels,
+// balances and total
+func (s *Server) handleAccountWallets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sess, ok := s.requireSession(w, r)
+	if !ok {
+		return
+	}
+	wallets, err := s.walletsByEmail(r.Context(), sess.Email)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to load wallets")
+		return
+	}
+
+	resp := AccountWalletsResponse{Email: sess.Email, Wallets: wallets}
+	for _, wlt := range wallets {
+		resp.TotalBalance += wlt.Balance
+		resp.PendingBalance += s.bc.GetPendingBalance(wlt.WalletID)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleCreateAccountWallet adds a wallet to the session's email
+func (s *Server) handleCreateAccountWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sess, ok := s.requireSession(w, r)
+	if !ok {
+		return
+	}
+	var req AccountWalletRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	wobj, err := s.createWallet(r.Context(), createWalletInput{
+		Public:       req.Public,
+		Private:      req.Private,
+		Name:         req.Name,
+		Email:        sess.Email,
+		CNIC:         req.CNIC,
+		Type:         req.Type,
+		SessionToken: bearerToken(r),
+		Additional:   true,
+	}, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	if req.Label != "" {
+		if err := s.setWalletLabel(r.Context(), wobj.WalletID, req.Label); err != nil {
+			Error(w, r, CodeInternal, "Failed to save the label")
+			return
+		}
+		wobj.Label = req.Label
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wobj)
+}
+
+// handleSetWalletLabel renames one of the session email's wallets
+func (s *Server) handleSetWalletLabel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	sess, ok := s.requireSession(w, r)
+	if !ok {
+		return
+	}
+	var req WalletLabelRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	// Wallets of other emails look missing, so IDs cannot be probed
+	wlt, ok := s.ws.Get(walletID)
+	if !ok || wlt.Email == "" || !strings.EqualFold(wlt.Email, sess.Email) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	if err := s.setWalletLabel(r.Context(), walletID, req.Label); err != nil {
+		Error(w, r, CodeInternal, "Failed to save the label")
+		return
+	}
+	wlt.Label = req.Label
+
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_labeled", walletID, r.RemoteAddr, fmt.Sprintf("Label set to %q", req.Label))
+	json.NewEncoder(w).Encode(s.loginWallet(wlt))
+}
+
+// setWalletLabel persists a label, then applies it in memory
+func (s *Server) setWalletLabel(ctx context.Context, walletID, label string) error {
+	if s.store != nil {
+		dbCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		if err := s.store.UpdateWalletLabel(dbCtx, walletID, label); err != nil {
+			return err
+		}
+	}
+	return s.ws.SetLabel(walletID, label)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleOperationalAlerts lists the firing operational alerts and the rules
+// behind them; ?format=prometheus returns the state as scrapeable gauges
+func (s *Server) handleOperationalAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("format") == "prometheus" {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.alerts.WritePrometheus(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"firing":       s.alerts.Firing(),
+		"rules":        s.alerts.Rules(),
+		"evaluated_at": s.alerts.EvaluatedAt(),
+	})
+}
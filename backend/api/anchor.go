@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/events"
+)
+
+// MaxAnchorDocumentBytes caps the documents POST /api/anchor/verify hashes
+const MaxAnchorDocumentBytes = 32 << 20
+
+// normalizeDocumentHash accepts a hex SHA-256 digest (optionally prefixed with "sha256:")
+func normalizeDocumentHash(h string) (string, error) {
+	h = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "sha256:"))
+	if b, err := hex.DecodeString(h); err != nil || len(b) != 32 {
+		return "", fmt.Errorf("hash must be a hex-encoded SHA-256 digest")
+	}
+	return h, nil
+}
+
+// handleCreateAnchor records a document hash on-chain in an anchor transaction
+func (s *Server) handleCreateAnchor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req AnchorRequest
+
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	docHash, err := normalizeDocumentHash(req.Hash)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, err.Error())
+		return
+	}
+
+	sender, exists := s.ws.Get(req.WalletID)
+	if !exists || !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	if existing, found := s.bc.FindAnchor(docHash); found {
+		Error(w, r, CodeAlreadyAnchored, "Hash already anchored in transaction "+existing.Transaction.ID)
+		return
+	}
+
+	privateKey, session, err := s.resolveSigner(r.Context(), sender, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	tx, err := s.txSvc.CreateAnchorTransaction(req.WalletID, docHash, sender.PublicKey, privateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "anchor_failed", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	// Anchor signatures cover the hash, so the usual validation applies unchanged
+	if err := s.txSvc.ValidateTransaction(r.Context(), tx); err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "transaction_validation_failed", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+		return
+	}
+
+	// The anchor fee counts against the session's spend limit
+	if session != nil {
+		if _, err := s.signing.Reserve(req.SigningToken, req.WalletID, tx.Fee); err != nil {
+			writeOpError(w, r, signingError(err))
+			return
+		}
+	}
+
+	if err := s.bc.AddPending(*tx); err != nil {
+		if session != nil {
+			s.signing.Release(req.SigningToken, tx.Fee)
+		}
+		s.logSvc.LogSystemCtx(r.Context(), "transaction_rejected_by_mempool", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), "Transaction rejected: "+err.Error())
+		return
+	}
+	s.logSvc.LogTransactionCtx(r.Context(), tx.ID, "created", req.WalletID, "", "pending", r.RemoteAddr)
+	s.logSvc.LogSystemCtx(r.Context(), "document_anchored", req.WalletID, r.RemoteAddr, "Anchor submitted for hash "+docHash)
+	s.feed.PublishTransaction(events.TxPending, *tx, nil)
+
+	if s.store != nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+		defer cancel()
+
+		if err := s.store.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "transaction_db_save_failed", req.WalletID, r.RemoteAddr, err.Error())
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"txid":    tx.ID,
+		"hash":    docHash,
+		"fee":     tx.Fee,
+		"message": "Anchor transaction added to pending pool",
+	})
+}
+
+// handleGetAnchor returns the block and timestamp proving a document hash existed
+func (s *Server) handleGetAnchor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	docHash, err := normalizeDocumentHash(mux.Vars(r)["hash"])
+	if err != nil {
+		Error(w, r, CodeValidationFailed, err.Error())
+		return
+	}
+
+	rec, found := s.bc.FindAnchor(docHash)
+	if !found {
+		Error(w, r, CodeNotFound, "Hash not anchored")
+		return
+	}
+	json.NewEncoder(w).Encode(s.anchorProof(docHash, rec))
+}
+
+// handleVerifyDocument hashes the document in the request body and reports
+// whether the chain anchors it, with the proof when it does. The document
+// never leaves this request; only its SHA-256 digest is looked up.
+func (s *Server) handleVerifyDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	h := sha256.New()
+	n, err := io.Copy(h, http.MaxBytesReader(w, r.Body, MaxAnchorDocumentBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			Error(w, r, CodeValidationFailed, fmt.Sprintf("Document must be at most %d bytes", MaxAnchorDocumentBytes))
+			return
+		}
+		Error(w, r, CodeInvalidRequest, "Failed to read the document")
+		return
+	}
+	if n == 0 {
+		Error(w, r, CodeValidationFailed, "Document is empty")
+		return
+	}
+	docHash := hex.EncodeToString(h.Sum(nil))
+
+	rec, found := s.bc.FindAnchor(docHash)
+	if !found {
+		json.NewEncoder(w).Encode(map[string]interface{}{"hash": docHash, "anchored": false})
+		return
+	}
+	proof := s.anchorProof(docHash, rec)
+	proof["anchored"] = true
+	json.NewEncoder(w).Encode(proof)
+}
+
+// anchorProof describes the transaction anchoring docHash and, once mined,
+// the block that confirms it
+func (s *Server) anchorProof(docHash string, rec blockchain.AnchorRecord) map[string]interface{} {
+	proof := map[string]interface{}{
+		"hash":         docHash,
+		"txid":         rec.Transaction.ID,
+		"wallet_id":    rec.Transaction.SenderID,
+		"pubkey":       rec.Transaction.PubKey,
+		"signature":    rec.Transaction.Signature,
+		"submitted_at": rec.Transaction.Timestamp,
+		"status":       "pending",
+	}
+	if rec.Confirmed {
+		proof["status"] = "confirmed"
+		proof["block_index"] = rec.Block.Index
+		proof["block_hash"] = rec.Block.Hash
+		proof["block_timestamp"] = rec.Block.Timestamp
+		proof["merkle_root"] = rec.Block.MerkleRoot
+		proof["confirmations"] = s.bc.Height() - rec.Block.Index + 1
+	}
+	return proof
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+// Admins define assets besides the coin and issue their units to an issuer
+// wallet. Holders send them with the usual send endpoints by naming the
+// asset_id; asset transfers pay no fee and do not count towards coin limits.
+
+// handleListAssets lists the assets the chain carries, oldest first
+func (s *Server) handleListAssets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.assets.List())
+}
+
+// handleGetAsset returns one asset and how much of it has been issued
+func (s *Server) handleGetAsset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	asset, ok := s.assets.Get(mux.Vars(r)["symbol"])
+	if !ok {
+		Error(w, r, CodeAssetNotFound, "Asset not found")
+		return
+	}
+	json.NewEncoder(w).Encode(asset)
+}
+
+// handleDefineAsset defines an asset and issues its initial supply
+func (s *Server) handleDefineAsset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req DefineAssetRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if !s.inOrg(r.Context(), req.Issuer) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	asset, tx, err := s.assets.Define(services.Asset{
+		Symbol:    req.Symbol,
+		Name:      req.Name,
+		Issuer:    req.Issuer,
+		MaxSupply: req.MaxSupply,
+		Mintable:  req.Mintable,
+	}, req.InitialSupply, s.adminActor(r))
+	if err != nil && asset.Symbol == "" {
+		s.writeAssetError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "asset_defined", req.Issuer, r.RemoteAddr,
+		fmt.Sprintf("%s (%s) with %s issued by %s", asset.Symbol, asset.Name, blockchain.FormatAmount(asset.Issued), s.adminActor(r)))
+	if err != nil {
+		// The asset is registered; only its initial issuance failed
+		Error(w, r, CodeInternal, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AssetIssueResponse{Asset: asset, Transaction: tx})
+}
+
+// handleMintAsset issues more units of a mintable asset to its issuer
+func (s *Server) handleMintAsset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req MintAssetRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	asset, tx, err := s.assets.Mint(mux.Vars(r)["symbol"], req.Amount)
+	if err != nil {
+		s.writeAssetError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "asset_minted", asset.Issuer, r.RemoteAddr,
+		fmt.Sprintf("%s %s by %s (%s issued)", blockchain.FormatAmount(req.Amount), asset.Symbol, s.adminActor(r), blockchain.FormatAmount(asset.Issued)))
+	json.NewEncoder(w).Encode(AssetIssueResponse{Asset: asset, Transaction: tx})
+}
+
+func (s *Server) writeAssetError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownAsset):
+		Error(w, r, CodeAssetNotFound, "Asset not found")
+	case errors.Is(err, services.ErrAssetExists):
+		Error(w, r, CodeAssetExists, err.Error())
+	case errors.Is(err, services.ErrAssetSupplyCap), errors.Is(err, services.ErrAssetNotMintable):
+		Error(w, r, CodeAssetSupply, err.Error())
+	case errors.Is(err, services.ErrInvalidAssetIssuer):
+		Error(w, r, CodeWalletNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidAssetSymbol):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
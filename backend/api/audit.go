@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/database"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// auditBodyLimit is how much of a request body the audit log reads to find
+// the wallet a call acts on
+const auditBodyLimit = 64 << 10
+
+// auditWalletFields are the request body fields naming the wallet a call
+// acts on, in order of preference
+var auditWalletFields = []string{"wallet_id", "sender_id", "miner_wallet_id"}
+
+// Audit log query limits
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type auditKey struct{}
+
+// auditNote is what a handler tells the audit log about its call
+type auditNote struct {
+	walletID  string
+	errorCode ErrorCode
+}
+
+func auditNoteFrom(r *http.Request) *auditNote {
+	note, _ := r.Context().Value(auditKey{}).(*auditNote)
+	return note
+}
+
+// auditWallet names the wallet a call acted on when neither the path nor the
+// request body does, e.g. a wallet the call created
+func auditWallet(r *http.Request, walletID string) {
+	if note := auditNoteFrom(r); note != nil {
+		note.walletID = walletID
+	}
+}
+
+// bodyCapture keeps the start of a request body as the handler reads it
+type bodyCapture struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := auditBodyLimit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+// walletID returns the first wallet field of a JSON body
+func (b *bodyCapture) walletID() string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(b.buf.Bytes(), &fields) != nil {
+		return ""
+	}
+	for _, name := range auditWalletFields {
+		var id string
+		if json.Unmarshal(fields[name], &id) == nil && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func auditedMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditCalls records every state-changing API call: who made it, on which
+// wallet, and how it ended. The wallet is the one in the path, else the one
+// the request body names, else the one the handler reports.
+func (s *Server) auditCalls(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if !auditedMethod(r.Method) || route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		note := &auditNote{}
+		body := &bodyCapture{ReadCloser: r.Body}
+		r.Body = body
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), auditKey{}, note)))
+
+		vars := mux.Vars(r)
+		walletID := vars["wallet"]
+		if walletID == "" {
+			walletID = vars["user_id"]
+		}
+		if walletID == "" {
+			walletID = body.walletID()
+		}
+		if walletID == "" {
+			walletID = note.walletID
+		}
+		actor := s.callerWallet(r)
+		if actor == "" && s.adminKeyMatches(r) {
+			actor = "admin-key"
+		}
+
+		s.auditLog.Record(services.AuditEntry{
+			Method:    r.Method,
+			Path:      truncate(r.URL.Path, 500),
+			Route:     pathVarPattern.ReplaceAllString(tpl, "{$1}"),
+			WalletID:  truncate(walletID, 100),
+			Actor:     truncate(actor, 100),
+			OrgID:     truncate(r.Header.Get(orgIDHeader), 64),
+			IPAddress: r.RemoteAddr,
+			UserAgent: truncate(r.UserAgent(), 255),
+			Status:    rec.status,
+			Outcome:   services.AuditOutcome(rec.status),
+			ErrorCode: string(note.errorCode),
+			LatencyMS: time.Since(start).Milliseconds(),
+			RequestID: services.RequestIDFrom(r.Context()),
+			CreatedAt: start,
+		})
+	})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// handleAuditLog lists audited calls, newest first, filtered by wallet,
+// route, method and time range. Pass next_before as ?before= for the next page.
+func (s *Server) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	filter := database.AuditFilter{Route: q.Get("route"), Method: strings.ToUpper(q.Get("method"))}
+	if filter.WalletID = q.Get("wallet"); filter.WalletID != "" {
+		checkWalletID(&errs, "wallet", filter.WalletID)
+	}
+	if filter.Method != "" && !auditedMethod(filter.Method) {
+		errs.Add("method", "must be POST, PUT, PATCH or DELETE")
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			errs.Add("from", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.From = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			errs.Add("to", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.To = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		errs.Add("to", "must be after from")
+	}
+	if v := q.Get("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			errs.Add("before", "must be a positive integer")
+		}
+		filter.Before = id
+	}
+	limit := defaultAuditLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			errs.Add("limit", "must be an integer from 1 to "+strconv.Itoa(maxAuditLimit))
+		}
+		limit = n
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+
+	entries, err := s.auditLog.Query(r.Context(), filter, limit)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to read the audit log")
+		return
+	}
+	resp := AuditLogResponse{Entries: entries}
+	if len(entries) == limit {
+		resp.NextBefore = entries[len(entries)-1].ID
+	}
+	json.NewEncoder(w).Encode(resp)
+}
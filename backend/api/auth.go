@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockchain-backend/wallet"
+)
+
+// requireAdmin wraps admin-only handlers. Callers authenticate either with an
+// X-Admin-Key header matching ADMIN_API_KEY (for scripts and operators) or as
+// a wallet flagged as admin in the database: X-Wallet-ID names the wallet and
+// a login session for its email, sent as a bearer token, proves the caller
+// owns it.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if !s.isAdminRequest(r) {
+			s.logSvc.LogSystemCtx(r.Context(), "admin_access_denied", r.Header.Get("X-Wallet-ID"), r.RemoteAddr, r.Method+" "+r.URL.Path)
+			Error(w, r, CodeAdminRequired, "Admin access required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) isAdminRequest(r *http.Request) bool {
+	if s.adminKeyMatches(r) {
+		return true
+	}
+
+	walletID := s.callerWallet(r)
+	if walletID == "" || s.db == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	isAdmin, err := s.db.IsAdmin(ctx, walletID)
+	return err == nil && isAdmin
+}
+
+// adminKeyMatches reports whether the request carries ADMIN_API_KEY
+func (s *Server) adminKeyMatches(r *http.Request) bool {
+	key := s.adminKey
+	return key != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Key")), []byte(key)) == 1
+}
+
+// callerWallet returns the wallet named in X-Wallet-ID when the request's
+// bearer session belongs to that wallet's email, "" otherwise. Wallet IDs are
+// public, so the header alone proves nothing.
+func (s *Server) callerWallet(r *http.Request) string {
+	walletID := r.Header.Get("X-Wallet-ID")
+	if walletID == "" {
+		return ""
+	}
+	wlt, ok := s.ws.Get(walletID)
+	if !ok || wlt.Email == "" {
+		return ""
+	}
+	sess, ok := s.sessions.Validate(bearerToken(r))
+	if !ok || !strings.EqualFold(sess.Email, wlt.Email) {
+		return ""
+	}
+	return walletID
+}
+
+// requireWalletOwner answers the request and returns false unless its bearer
+// session belongs to walletID's email, as wallet topics on the websocket
+// require
+func (s *Server) requireWalletOwner(w http.ResponseWriter, r *http.Request, walletID string) bool {
+	wlt, ok := s.ws.Get(walletID)
+	if !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return false
+	}
+	token := bearerToken(r)
+	if token == "" {
+		Error(w, r, CodeUnauthorized, "Send the wallet owner's login session as Authorization: Bearer")
+		return false
+	}
+	if err := s.authorizeWalletSession(wlt, token); err != nil {
+		writeOpError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// authorizeWalletSession checks that a login session belongs to the wallet's
+// email
+func (s *Server) authorizeWalletSession(wlt wallet.Wallet, sessionToken string) error {
+	sess, ok := s.sessions.Validate(sessionToken)
+	if !ok {
+		return fail(CodeUnauthorized, "Invalid or expired session token")
+	}
+	if wlt.Email == "" || !strings.EqualFold(wlt.Email, sess.Email) {
+		return fail(CodeUnauthorized, "The session's email does not own this wallet")
+	}
+	return nil
+}
+
+// SetAdminKey sets the key operators send as X-Admin-Key (ADMIN_API_KEY);
+// empty leaves admin access to wallets flagged as admin
+func (s *Server) SetAdminKey(key string) {
+	s.adminKey = key
+}
+
+// SetAdminEmail sets the email of the operator behind X-Admin-Key
+// (ADMIN_EMAIL), who receives the one-time codes of OTP-gated admin actions
+func (s *Server) SetAdminEmail(email string) {
+	s.adminEmail = email
+}
+
+// adminOTPEmail is the email an admin proves control of for OTP-gated admin
+// actions: the admin wallet's email for wallet callers, otherwise ADMIN_EMAIL
+func (s *Server) adminOTPEmail(r *http.Request) string {
+	if walletID := s.callerWallet(r); walletID != "" {
+		if wlt, ok := s.ws.Get(walletID); ok && wlt.Email != "" {
+			return wlt.Email
+		}
+	}
+	return s.adminEmail
+}
+
+// adminActor identifies the admin performing a request, for audit logs: the
+// authenticated admin wallet, or "admin-key"
+func (s *Server) adminActor(r *http.Request) string {
+	if walletID := s.callerWallet(r); walletID != "" {
+		return walletID
+	}
+	return "admin-key"
+}
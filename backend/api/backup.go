@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/crypto"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// Backup file format
+const (
+	backupFormat        = "wallet-backup"
+	backupVersion       = 1
+	minPassphraseLength = 8
+)
+
+// backupPayload is the plaintext sealed inside a backup file
+type backupPayload struct {
+	Wallet struct {
+		WalletID   string `json:"wallet_id"`
+		PublicKey  string `json:"public_key"`
+		PrivateKey string `json:"private_key"` // raw hex
+		FullName   string `json:"full_name"`
+		Email      string `json:"email"`
+		CNIC       string `json:"cnic"`
+		Type       string `json:"type"`
+	} `json:"wallet"`
+	Beneficiaries []backupBeneficiary `json:"beneficiaries"`
+}
+
+type backupBeneficiary struct {
+	WalletID     string `json:"wallet_id"`
+	Name         string `json:"name"`
+	Relationship string `json:"relationship,omitempty"`
+	Alias        string `json:"alias,omitempty"`
+}
+
+// handleExportWallet returns a passphrase-encrypted backup of the wallet's
+// keys, profile and beneficiaries as a downloadable file
+func (s *Server) handleExportWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req ExportWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if len(req.Passphrase) < minPassphraseLength {
+		Error(w, r, CodeValidationFailed, fmt.Sprintf("Passphrase must be at least %d characters", minPassphraseLength))
+		return
+	}
+
+	wlt, err := s.verifyWalletKey(r.Context(), walletID, req.PrivateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "wallet_export_failed", walletID, r.RemoteAddr, err.Error())
+		writeOpError(w, r, err)
+		return
+	}
+	privHex, _ := resolvePrivateKey(req.PrivateKey)
+
+	var payload backupPayload
+	payload.Wallet.WalletID = wlt.WalletID
+	payload.Wallet.PublicKey = wlt.PublicKey
+	payload.Wallet.PrivateKey = privHex
+	payload.Wallet.FullName = wlt.FullName
+	payload.Wallet.Email = wlt.Email
+	payload.Wallet.CNIC = wlt.CNIC
+	payload.Wallet.Type = wlt.TypeOrDefault()
+	payload.Beneficiaries = []backupBeneficiary{}
+
+	if s.db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if userID, err := s.db.GetUserIDByWalletID(ctx, wlt.WalletID); err == nil {
+			rows, err := s.db.GetBeneficiaries(ctx, userID)
+			if err != nil {
+				Error(w, r, CodeInternal, "Failed to load beneficiaries")
+				return
+			}
+			for _, row := range rows {
+				b := backupBeneficiary{}
+				b.WalletID, _ = row["wallet_id"].(string)
+				b.Name, _ = row["name"].(string)
+				b.Relationship, _ = row["relationship"].(string)
+				b.Alias, _ = row["alias"].(string)
+				payload.Beneficiaries = append(payload.Beneficiaries, b)
+			}
+		}
+	}
+
+	plaintext, _ := json.Marshal(payload)
+	box, err := crypto.SealWithPassphrase(plaintext, req.Passphrase)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to encrypt backup")
+		return
+	}
+
+	s.logSensitive(r.Context(), "wallet_exported", wlt.WalletID, wlt.Email, r.RemoteAddr, fmt.Sprintf("Backup exported with %d beneficiaries", len(payload.Beneficiaries)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="wallet-%s.backup.json"`, wlt.WalletID[:8]))
+	json.NewEncoder(w).Encode(WalletBackup{
+		Format:     backupFormat,
+		Version:    backupVersion,
+		WalletID:   wlt.WalletID,
+		CreatedAt:  time.Now().UTC(),
+		Encryption: box,
+	})
+}
+
+// handleImportWallet restores a wallet from a backup file, e.g. one exported
+// by another deployment. Non-personal wallet types are filed for admin
+// approval again; balances come from this server's chain, not the backup.
+func (s *Server) handleImportWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ImportWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if req.Backup.Format != backupFormat || req.Backup.Version != backupVersion {
+		Error(w, r, CodeValidationFailed, fmt.Sprintf("Unsupported backup format %q version %d", req.Backup.Format, req.Backup.Version))
+		return
+	}
+
+	plaintext, err := crypto.OpenWithPassphrase(req.Backup.Encryption, req.Passphrase)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "wallet_import_failed", req.Backup.WalletID, r.RemoteAddr, err.Error())
+		if errors.Is(err, crypto.ErrWrongPassphrase) {
+			Error(w, r, CodeInvalidPassphrase, "Wrong passphrase or corrupted backup")
+		} else {
+			Error(w, r, CodeValidationFailed, "Invalid backup: "+err.Error())
+		}
+		return
+	}
+
+	var payload backupPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid backup contents")
+		return
+	}
+	bw := payload.Wallet
+
+	// The backup must describe one consistent keypair
+	walletID, err := wallet.WalletIDFromPub(bw.PublicKey)
+	if err != nil || walletID != bw.WalletID || walletID != req.Backup.WalletID {
+		Error(w, r, CodeValidationFailed, "Backup wallet ID does not match its public key")
+		return
+	}
+	if !keyMatchesPub(bw.PrivateKey, bw.PublicKey) {
+		Error(w, r, CodeValidationFailed, "Backup private key does not match its public key")
+		return
+	}
+	if _, exists := s.ws.Get(walletID); exists {
+		Error(w, r, CodeWalletExists, "Wallet already exists on this server")
+		return
+	}
+
+	if s.db != nil && bw.Email != "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		taken, err := s.db.CheckEmailExists(ctx, bw.Email)
+		cancel()
+		if err != nil {
+			Error(w, r, CodeInternal, "Failed to verify email")
+			return
+		}
+		if taken {
+			Error(w, r, CodeEmailTaken, "Email already registered on this server")
+			return
+		}
+	}
+
+	wlt, err := s.ws.CreateFromPub(bw.PublicKey, bw.PrivateKey, bw.FullName, bw.Email, bw.CNIC)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, err.Error())
+		return
+	}
+	auditWallet(r, wlt.WalletID)
+	if orgID := services.OrgFrom(r.Context()); orgID != "" {
+		if err := s.orgs.Assign(wlt.WalletID, orgID); err != nil {
+			writeOpError(w, r, orgError(err))
+			return
+		}
+		wlt, _ = s.ws.Get(wlt.WalletID)
+	}
+
+	resp := ImportWalletResponse{Status: "success", WalletID: wlt.WalletID, SkippedBeneficiaries: []string{}}
+	if bw.Type != "" && bw.Type != wallet.TypePersonal {
+		if change, err := s.walletTypes.RequestChange(wlt.WalletID, bw.Type, "restored from backup", wlt.WalletID, false); err == nil {
+			resp.TypeChangeRequest = change
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 10*time.Second)
+	defer cancel()
+	if s.store != nil {
+		if err := s.store.SaveWallet(ctx, wlt.WalletID, wlt.PublicKey, wlt.PrivateKey, wlt.FullName, wlt.Email, wlt.CNIC, wlt.Type); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "wallet_db_save_failed", wlt.WalletID, r.RemoteAddr, err.Error())
+		}
+		if wlt.OrgID != "" {
+			if err := s.store.UpdateWalletOrg(ctx, wlt.WalletID, wlt.OrgID); err != nil {
+				s.logSvc.LogSystemCtx(r.Context(), "wallet_db_save_failed", wlt.WalletID, r.RemoteAddr, err.Error())
+			}
+		}
+	}
+	if s.db != nil {
+		if userID, err := s.db.GetUserIDByWalletID(ctx, wlt.WalletID); err == nil {
+			for _, b := range payload.Beneficiaries {
+				if exists, _ := s.db.BeneficiaryExists(ctx, userID, b.WalletID); exists {
+					resp.SkippedBeneficiaries = append(resp.SkippedBeneficiaries, b.WalletID)
+					continue
+				}
+				alias := b.Alias
+				if alias != "" {
+					if taken, _ := s.db.BeneficiaryAliasExists(ctx, userID, alias, 0); taken {
+						alias = ""
+					}
+				}
+				if err := s.db.AddBeneficiary(ctx, userID, b.WalletID, b.Name, b.Relationship, alias, 0); err != nil {
+					resp.SkippedBeneficiaries = append(resp.SkippedBeneficiaries, b.WalletID)
+					continue
+				}
+				resp.RestoredBeneficiaries++
+			}
+		} else {
+			for _, b := range payload.Beneficiaries {
+				resp.SkippedBeneficiaries = append(resp.SkippedBeneficiaries, b.WalletID)
+			}
+		}
+	} else {
+		// Beneficiaries need the database
+		for _, b := range payload.Beneficiaries {
+			resp.SkippedBeneficiaries = append(resp.SkippedBeneficiaries, b.WalletID)
+		}
+	}
+
+	resp.Message = fmt.Sprintf("Wallet restored with %d beneficiaries", resp.RestoredBeneficiaries)
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_imported", wlt.WalletID, r.RemoteAddr, resp.Message)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
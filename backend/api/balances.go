@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"blockchain-backend/services"
+)
+
+// BalanceStatsResponse reports the balance recompute counters and the last
+// repair run
+type BalanceStatsResponse struct {
+	Stats      services.BalanceStats      `json:"stats"`
+	LastRepair *services.BalanceRepairRun `json:"last_repair"`
+}
+
+// handleBalanceStats reports how often stored balances were recomputed and
+// how many lock conflicts and mismatches were detected
+func (s *Server) handleBalanceStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	stats, last := s.balances.Stats()
+	json.NewEncoder(w).Encode(BalanceStatsResponse{Stats: stats, LastRepair: last})
+}
+
+// handleRepairBalances runs the balance repair job now
+func (s *Server) handleRepairBalances(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	run, err := s.balances.Repair(r.Context())
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to repair balances")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "balances_repaired", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%d drifted, %d repaired, %d failed", run.Drifted, run.Repaired, run.Failed))
+	json.NewEncoder(w).Encode(run)
+}
+
+// handleReconcile recomputes every wallet balance from the UTXO set and
+// reports where the database disagrees with it
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rec, err := s.balances.Reconcile(r.Context())
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to reconcile balances")
+		return
+	}
+	json.NewEncoder(w).Encode(rec)
+}
+
+// handleRepairReconcile writes the ledger back to the database for every
+// wallet that disagrees with it
+func (s *Server) handleRepairReconcile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rec, err := s.balances.RepairLedger(r.Context())
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to repair balances")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "ledger_reconciled", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%d discrepancies, %d repaired, %d failed, %d unbalanced transactions", len(rec.Discrepancies), rec.Repaired, rec.Failed, len(rec.Unbalanced)))
+	json.NewEncoder(w).Encode(rec)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"blockchain-backend/blockchain"
+)
+
+// A batch send pays every recipient in one transaction, so it is accepted or
+// refused as a whole and pays one transfer fee; see
+// services.CreateBatchTransaction. Limits and second factors apply to its
+// total.
+
+// handleSendBatch queues one transaction paying each recipient of the batch
+func (s *Server) handleSendBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req BatchSendRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	var total uint64
+	for _, p := range req.Payments {
+		total += p.Amount
+	}
+	create := func(ctx context.Context, senderID, _ string, _ uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+		return s.txSvc.CreateBatchTransaction(ctx, senderID, req.Payments, note, pubKey, privKey)
+	}
+	tx, err := s.sendWith(r.Context(), sendInput{
+		SenderID:     req.SenderID,
+		ReceiverID:   blockchain.BatchReceiver,
+		Amount:       total,
+		Note:         req.Note,
+		SigningToken: req.SigningToken,
+		PrivateKey:   req.PrivateKey,
+		TOTPCode:     req.TOTPCode,
+		LimitOTP:     req.LimitOTP,
+		Payments:     req.Payments,
+	}, create, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	resp := BatchSendResponse{Status: "pending", TxID: tx.ID, Total: tx.Amount, Fee: tx.Fee}
+	for i, out := range tx.Outputs[:len(req.Payments)] {
+		resp.Payments = append(resp.Payments, BatchPaymentResult{ReceiverID: out.Owner, Amount: out.Amount, UTXOID: blockchain.UTXOKey(tx.ID, i)})
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "batch_sent", req.SenderID, r.RemoteAddr,
+		fmt.Sprintf("%s to %d wallets in %s", blockchain.FormatAmount(tx.Amount), len(req.Payments), tx.ID))
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/events"
+	"blockchain-backend/services"
+)
+
+// A split bill asks each participant for its share with a payment request;
+// see services.BillService. Participants pay through the payment request
+// endpoints, and the creator is told once every share is paid.
+
+// handleCreateBill splits a bill and issues its payment requests
+func (s *Server) handleCreateBill(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateBillRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.CreatorID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	participants := make([]services.BillParticipant, len(req.Participants))
+	for i, p := range req.Participants {
+		walletID := p.WalletID
+		if p.Alias != "" {
+			if s.db == nil {
+				Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+				return
+			}
+			dbCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			id, err := s.resolveBeneficiaryAlias(dbCtx, req.CreatorID, p.Alias)
+			cancel()
+			if err != nil {
+				Error(w, r, CodeAliasNotFound, err.Error())
+				return
+			}
+			walletID = id
+		}
+		if !s.inOrg(r.Context(), walletID) {
+			Error(w, r, CodeWalletNotFound, "Participant wallet not found")
+			return
+		}
+		participants[i] = services.BillParticipant{WalletID: walletID, Amount: p.Amount}
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	bill, err := s.bills.Create(req.CreatorID, req.Total, req.Note, participants, req.IncludeCreator, expiresAt)
+	if err != nil {
+		s.writeBillError(w, r, err)
+		return
+	}
+	for _, sh := range bill.Shares {
+		if pr, ok := s.payRequests.Get(sh.RequestID); ok {
+			s.feed.Publish(events.PaymentRequested, pr.Payer, paymentRequestEvent(pr))
+		}
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "bill_created", bill.Creator, r.RemoteAddr,
+		fmt.Sprintf("%s: %s split among %d wallets", bill, blockchain.FormatAmount(bill.Total), len(bill.Shares)))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(bill)
+}
+
+// handleGetBill returns a bill with who has paid their share
+func (s *Server) handleGetBill(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid bill ID")
+		return
+	}
+	bill, ok := s.bills.Get(id)
+	if !ok || !s.inOrg(r.Context(), bill.Creator) {
+		Error(w, r, CodeNotFound, services.ErrBillNotFound.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(bill)
+}
+
+// handleListBills lists the bills a wallet created or has a share in,
+// newest first
+func (s *Server) handleListBills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	list := s.bills.ForWallet(walletID)
+	if list == nil {
+		list = []services.Bill{}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// billShareAccepted tells the creator of a bill when the share just paid
+// was the last one
+func (s *Server) billShareAccepted(ctx context.Context, billID int64, remoteAddr string) {
+	bill, settled := s.bills.ShareAccepted(billID)
+	if !settled {
+		return
+	}
+	s.feed.Publish(events.BillSettled, bill.Creator, map[string]interface{}{
+		"bill_id": bill.ID,
+		"total":   bill.Total,
+		"paid":    bill.Paid,
+	})
+	s.notifications.BillSettled(bill.Creator, bill)
+	s.logSvc.LogSystemCtx(ctx, "bill_settled", bill.Creator, remoteAddr, fmt.Sprintf("%s: %s paid", bill, blockchain.FormatAmount(bill.Paid)))
+}
+
+func (s *Server) writeBillError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidBill):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		s.writePaymentRequestError(w, r, err)
+	}
+}
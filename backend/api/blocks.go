@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// coinbaseSender is the sender of the mining reward transaction
+const coinbaseSender = "COINBASE"
+
+// maxHeaders is how many headers one GET /api/headers returns at most
+const maxHeaders = 2000
+
+// handleGetBlock returns a block by height or by hash, with its
+// confirmations, totals, miner and links to its neighbours
+func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ref := mux.Vars(r)["index"]
+
+	// One copy of the chain keeps the block, its neighbours and its
+	// confirmations consistent with each other
+	blocks := s.bc.GetChain()
+	index, ok := findBlock(blocks, ref)
+	if !ok {
+		Error(w, r, CodeNotFound, "Block not found")
+		return
+	}
+	json.NewEncoder(w).Encode(s.blockDetail(r, blocks, index))
+}
+
+// handleGetRawBlock returns a block in the canonical binary encoding, for
+// peers and clients that verify or relay blocks. Organizations see only their
+// own transactions, which no longer hash to the block, so multi-tenant
+// deployments do not serve it.
+func (s *Server) handleGetRawBlock(w http.ResponseWriter, r *http.Request) {
+	if s.orgs.Enabled() {
+		Error(w, r, CodeNotConfigured, "Raw blocks are not served in multi-tenant mode")
+		return
+	}
+	blocks := s.bc.GetChain()
+	index, ok := findBlock(blocks, mux.Vars(r)["index"])
+	if !ok {
+		Error(w, r, CodeNotFound, "Block not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(blockchain.EncodeBlock(blocks[index]))
+}
+
+// findBlock resolves a block height or hash to a height
+func findBlock(blocks []blockchain.Block, ref string) (int64, bool) {
+	if index, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return index, index >= 0 && index < int64(len(blocks))
+	}
+	hash := strings.ToLower(ref)
+	for _, b := range blocks {
+		if b.Hash == hash {
+			return b.Index, true
+		}
+	}
+	return 0, false
+}
+
+// blockDetail computes the detail of the block at index of blocks
+func (s *Server) blockDetail(r *http.Request, blocks []blockchain.Block, index int64) BlockDetail {
+	full := blocks[index]
+	d := BlockDetail{
+		Block:         s.scopeBlock(r.Context(), full),
+		Confirmations: int64(len(blocks)) - index,
+	}
+	if raw, err := json.Marshal(full); err == nil {
+		d.Size = len(raw)
+	}
+	for _, tx := range d.Transactions {
+		if tx.SenderID == coinbaseSender {
+			d.MinerWallet = tx.ReceiverID
+			continue
+		}
+		if tx.AssetID == "" {
+			d.TotalTransferred += tx.Amount
+		}
+		d.TotalFees += tx.Fee
+	}
+	if index > 0 {
+		d.Previous = blockLink(blocks[index-1])
+	}
+	if index+1 < int64(len(blocks)) {
+		d.Next = blockLink(blocks[index+1])
+	}
+	return d
+}
+
+// handleGetTransaction returns one transaction from the chain or the pending
+// pool, found through the chain's transaction index
+func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loc, ok := s.bc.GetTransactionByID(mux.Vars(r)["txid"])
+	if !ok || !s.txVisible(r, loc.Transaction) {
+		Error(w, r, CodeNotFound, "Transaction not found")
+		return
+	}
+
+	resp := TransactionResponse{Transaction: loc.Transaction, Status: "pending", Decimals: blockchain.Decimals}
+	resp.AmountFiat = s.rates.ConvertAt(loc.Transaction.Amount, time.Unix(loc.Transaction.Timestamp, 0))
+	if !loc.Pending {
+		index, position := loc.Block.Index, loc.Position
+		resp.Status = "confirmed"
+		resp.BlockIndex = &index
+		resp.BlockHash = loc.Block.Hash
+		resp.BlockTimestamp = loc.Block.Timestamp
+		resp.Position = &position
+		resp.Confirmations = s.bc.GetConfirmations(index)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleTransactionProof returns the merkle path and block header that prove
+// a mined transaction is in its block
+func (s *Server) handleTransactionProof(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	txid := mux.Vars(r)["txid"]
+	if loc, ok := s.bc.GetTransactionByID(txid); !ok || !s.txVisible(r, loc.Transaction) {
+		Error(w, r, CodeNotFound, "Transaction not found")
+		return
+	}
+	proof, err := s.bc.GetMerkleProof(txid)
+	switch {
+	case errors.Is(err, blockchain.ErrTxNotMined):
+		Error(w, r, CodeTxNotMined, err.Error())
+		return
+	case err != nil:
+		Error(w, r, CodeNotFound, "Transaction not found")
+		return
+	}
+	json.NewEncoder(w).Encode(TransactionProofResponse{MerkleProof: proof, Confirmations: s.bc.GetConfirmations(proof.Header.Index)})
+}
+
+// handleHeaders lists block headers from..to for light clients, at most
+// maxHeaders per request
+func (s *Server) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, ok := s.blockRange(w, r)
+	if !ok {
+		return
+	}
+	if to-from >= maxHeaders {
+		to = from + maxHeaders - 1
+	}
+	json.NewEncoder(w).Encode(s.bc.GetHeaders(from, to))
+}
+
+// blockRange reads the from and to block indexes of a listing, defaulting to
+// the whole chain, and writes the error response if either is invalid
+func (s *Server) blockRange(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	from, to := int64(0), s.bc.Height()
+	var errs validation.Errors
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			errs.Add("from", "must be a non-negative integer")
+		}
+		from = n
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			errs.Add("to", "must be a non-negative integer")
+		}
+		to = n
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+func blockLink(b blockchain.Block) *BlockLink {
+	return &BlockLink{Index: b.Index, Hash: b.Hash, Href: fmt.Sprintf("/api/block/%d", b.Index)}
+}
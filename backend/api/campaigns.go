@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// Admins open fundraising campaigns for a destination wallet, such as the
+// zakat committee's. Donations are signed transfers of type donation whose
+// note names the campaign, so the chain itself records each campaign's
+// progress.
+
+// campaign looks up the campaign in the path, answering NOT_FOUND for one
+// outside the caller's organization
+func (s *Server) campaign(w http.ResponseWriter, r *http.Request) (services.Campaign, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid campaign ID")
+		return services.Campaign{}, false
+	}
+	c, ok := s.campaigns.Get(id)
+	if !ok || !s.inOrg(r.Context(), c.DestinationWallet) {
+		Error(w, r, CodeNotFound, services.ErrCampaignNotFound.Error())
+		return services.Campaign{}, false
+	}
+	return c, true
+}
+
+// orgCampaigns returns the campaigns raising for wallets in the caller's
+// organization
+func (s *Server) orgCampaigns(ctx context.Context) []services.Campaign {
+	var visible []services.Campaign
+	for _, c := range s.campaigns.List() {
+		if s.inOrg(ctx, c.DestinationWallet) {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// handleListCampaigns lists campaigns with their progress, newest first.
+// ?status=open keeps those taking donations, ?status=closed the rest.
+func (s *Server) handleListCampaigns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "open" && status != "closed" && status != "all" {
+		Error(w, r, CodeValidationFailed, "status must be open, closed or all")
+		return
+	}
+
+	progress := s.campaigns.ProgressAll(s.orgCampaigns(r.Context()))
+	list := make([]services.CampaignProgress, 0, len(progress))
+	for _, p := range progress {
+		if (status == "open" && !p.Open) || (status == "closed" && p.Open) {
+			continue
+		}
+		list = append(list, p)
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleGetCampaign returns a campaign's progress and donations
+func (s *Server) handleGetCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	c, ok := s.campaign(w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(CampaignResponse{
+		CampaignProgress: s.campaigns.Progress(c),
+		DonationList:     s.campaigns.Donations(c),
+	})
+}
+
+// handleDonate sends a donation to an open campaign's destination wallet
+func (s *Server) handleDonate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req DonateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	c, ok := s.campaign(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.campaigns.CheckDonation(c.ID); err != nil {
+		s.writeCampaignError(w, r, err)
+		return
+	}
+
+	tx, err := s.sendTransactionOfType(r.Context(), sendInput{
+		SenderID:     req.SenderID,
+		ReceiverID:   c.DestinationWallet,
+		Amount:       req.Amount,
+		Note:         services.DonationNote(c.ID, req.Message),
+		SigningToken: req.SigningToken,
+		PrivateKey:   req.PrivateKey,
+		TOTPCode:     req.TOTPCode,
+		LimitOTP:     req.LimitOTP,
+	}, "donation", r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "campaign_donation", req.SenderID, r.RemoteAddr, fmt.Sprintf("%d to %s in %s", req.Amount, c, tx.ID))
+	json.NewEncoder(w).Encode(SendResponse{
+		Status:  "success",
+		TxID:    tx.ID,
+		Message: "Donation added to pending pool",
+	})
+}
+
+// handleCreateCampaign opens a campaign for an active wallet
+func (s *Server) handleCreateCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateCampaignRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if !s.inOrg(r.Context(), req.DestinationWallet) {
+		Error(w, r, CodeWalletNotFound, "Destination wallet not found")
+		return
+	}
+
+	c, err := s.campaigns.Create(req.Name, req.Description, req.TargetAmount, req.Deadline, req.DestinationWallet, s.adminActor(r))
+	if err != nil {
+		s.writeCampaignError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "campaign_created", c.DestinationWallet, r.RemoteAddr, fmt.Sprintf("%s raising %d by %s, by %s", c, c.TargetAmount, c.Deadline.Format("2006-01-02"), c.CreatedBy))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.campaigns.Progress(*c))
+}
+
+// handleUpdateCampaign changes an active campaign's details
+func (s *Server) handleUpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req UpdateCampaignRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	c, ok := s.campaign(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.campaigns.Update(c.ID, services.CampaignUpdate{
+		Name:         req.Name,
+		Description:  req.Description,
+		TargetAmount: req.TargetAmount,
+		Deadline:     req.Deadline,
+	})
+	if err != nil {
+		s.writeCampaignError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "campaign_updated", updated.DestinationWallet, r.RemoteAddr, fmt.Sprintf("%s by %s", updated, s.adminActor(r)))
+	json.NewEncoder(w).Encode(s.campaigns.Progress(*updated))
+}
+
+// handleCloseCampaign stops a campaign taking donations before its deadline
+func (s *Server) handleCloseCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	c, ok := s.campaign(w, r)
+	if !ok {
+		return
+	}
+	closed, err := s.campaigns.Close(c.ID)
+	if err != nil {
+		s.writeCampaignError(w, r, err)
+		return
+	}
+
+	p := s.campaigns.Progress(*closed)
+	s.logSvc.LogSystemCtx(r.Context(), "campaign_closed", closed.DestinationWallet, r.RemoteAddr, fmt.Sprintf("%s raised %d of %d, closed by %s", closed, p.Raised, closed.TargetAmount, s.adminActor(r)))
+	json.NewEncoder(w).Encode(p)
+}
+
+// handleDeleteCampaign removes a campaign created by mistake, before anyone
+// donates to it
+func (s *Server) handleDeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	c, ok := s.campaign(w, r)
+	if !ok {
+		return
+	}
+	if err := s.campaigns.Delete(c.ID); err != nil {
+		s.writeCampaignError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "campaign_deleted", c.DestinationWallet, r.RemoteAddr, fmt.Sprintf("%s by %s", c, s.adminActor(r)))
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Campaign deleted"})
+}
+
+func (s *Server) writeCampaignError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrCampaignNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrCampaignClosed):
+		Error(w, r, CodeCampaignClosed, err.Error())
+	case errors.Is(err, services.ErrCampaignHasDonations):
+		Error(w, r, CodeHasDonations, err.Error())
+	case errors.Is(err, services.ErrInvalidCampaignWallet):
+		Error(w, r, CodeWalletNotFound, err.Error())
+	case errors.Is(err, services.ErrDeadlinePassed):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
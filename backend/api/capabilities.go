@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+// clientConfig is the part of a tenant configuration clients need; email
+// templates stay on the server
+func clientConfig(cfg services.TenantConfig) ClientConfig {
+	return ClientConfig{
+		FaucetAmount:    cfg.FaucetAmount,
+		Zakat:           cfg.Zakat,
+		Fees:            cfg.Fees,
+		DisplayCurrency: cfg.DisplayCurrency,
+		Decimals:        blockchain.Decimals,
+		Branding:        cfg.Branding,
+	}
+}
+
+// handleCapabilities lists the features this deployment offers and the
+// configuration in effect for the organization named by X-Org-ID, if any
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, ok := s.optionalOrg(r)
+	if !ok {
+		Error(w, r, CodeOrgNotFound, "Organization not found")
+		return
+	}
+
+	tenancy := services.TenancySingle
+	if s.orgs.Enabled() {
+		tenancy = services.TenancyMulti
+	}
+	json.NewEncoder(w).Encode(CapabilitiesResponse{
+		Tenancy: tenancy,
+		OrgID:   orgID,
+		Sandbox: s.sandbox,
+		Features: map[string]bool{
+			"database":        s.db != nil,
+			"email":           s.deliveries.HasSender(services.ChannelEmail),
+			"google_login":    s.google != nil,
+			"two_factor":      true,
+			"offline_signing": true,
+			"graphql":         true,
+			"grpc":            true,
+			"faucet_claim":    s.faucet.Policy().Mode == services.FaucetModeClaim,
+			"faucet_signup":   s.faucet.GrantsOnSignup(),
+			"chain_reset":     s.chainReset,
+		},
+		Config: clientConfig(s.config.ForOrg(orgID)),
+	})
+}
+
+// handleGetOrgConfig shows the organization's overrides and the resulting
+// configuration, email templates included
+func (s *Server) handleGetOrgConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := services.OrgFrom(r.Context())
+
+	json.NewEncoder(w).Encode(OrgConfigResponse{
+		OrgID:     orgID,
+		Overrides: s.config.Overrides(orgID),
+		Effective: s.config.ForOrg(orgID),
+	})
+}
+
+// handleUpdateOrgConfig merges a JSON merge patch into the organization's
+// overrides; null resets a field to the deployment default
+func (s *Server) handleUpdateOrgConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := services.OrgFrom(r.Context())
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+
+	cfg, err := s.config.Update(orgID, patch, s.adminActor(r))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidConfig) {
+			Error(w, r, CodeValidationFailed, err.Error())
+		} else {
+			Error(w, r, CodeInternal, err.Error())
+		}
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "org_config_updated", "", r.RemoteAddr, "Configuration of "+orgID+" changed by "+s.adminActor(r))
+	json.NewEncoder(w).Encode(OrgConfigResponse{
+		OrgID:     orgID,
+		Overrides: s.config.Overrides(orgID),
+		Effective: cfg,
+	})
+}
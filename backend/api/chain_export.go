@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/blockchain/chainfile"
+	"blockchain-backend/database"
+	"blockchain-backend/services"
+)
+
+// A chain export holds every block and the snapshot of the tip, in the
+// versioned format of package chainfile. Exports back a node up, move its
+// chain to another storage backend and bootstrap new nodes, which import
+// them before their first block. Wallets are not part of them; they move with
+// wallet backups or with the database.
+
+const (
+	// MaxChainImportBytes caps the exports POST /api/admin/chain/import reads
+	MaxChainImportBytes = 1 << 30
+
+	// chainExportWriteTimeout is how long the client has to take each part
+	// of an export; the deadline moves forward as the export is written
+	chainExportWriteTimeout = 30 * time.Second
+
+	// chainImportTimeout bounds reading, checking and storing an export
+	chainImportTimeout = 10 * time.Minute
+)
+
+// deadlineWriter moves the write deadline forward before every write, so a
+// long export may run past the server's WriteTimeout while a stalled client
+// is still cut off
+type deadlineWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (d deadlineWriter) Write(p []byte) (int, error) {
+	if err := d.rc.SetWriteDeadline(time.Now().Add(chainExportWriteTimeout)); err != nil {
+		return 0, err
+	}
+	return d.w.Write(p)
+}
+
+// exportEncoding reads the format query parameter, falling back to the
+// request's Content-Type and then to JSON
+func exportEncoding(r *http.Request) (string, bool) {
+	switch f := r.URL.Query().Get("format"); f {
+	case chainfile.EncodingJSON, chainfile.EncodingProtobuf:
+		return f, true
+	case "":
+		if strings.HasPrefix(r.Header.Get("Content-Type"), chainfile.ContentType(chainfile.EncodingProtobuf)) {
+			return chainfile.EncodingProtobuf, true
+		}
+		return chainfile.EncodingJSON, true
+	}
+	return "", false
+}
+
+// handleChainExport streams every block and the snapshot of the tip as a
+// chain export, in JSON or protobuf
+func (s *Server) handleChainExport(w http.ResponseWriter, r *http.Request) {
+	encoding, ok := exportEncoding(r)
+	if !ok {
+		Error(w, r, CodeValidationFailed, "format must be json or protobuf")
+		return
+	}
+	// Organizations only see their own transactions, and an export cannot
+	// leave the others out without breaking every merkle root
+	if s.orgs.Enabled() && !s.isAdminRequest(r) {
+		Error(w, r, CodeAdminRequired, "Exporting the whole chain needs admin credentials in multi-tenant mode")
+		return
+	}
+
+	blocks, snap := s.bc.SnapshotWithBlocks()
+	tip := blocks[len(blocks)-1]
+	ext := "ndjson"
+	if encoding == chainfile.EncodingProtobuf {
+		ext = "pb"
+	}
+	w.Header().Set("Content-Type", chainfile.ContentType(encoding))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chain-%d.%s"`, tip.Index, ext))
+
+	out := deadlineWriter{w: w, rc: http.NewResponseController(w)}
+	if err := chainfile.Write(out, encoding, chainfile.NewHeader(tip, s.bc.ActiveConsensus()), blocks, snap); err != nil {
+		// The response has started, so the client sees a stream without its snapshot
+		s.logSvc.LogSystemCtx(r.Context(), "chain_export_failed", "", r.RemoteAddr, err.Error())
+		return
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "chain_exported", "", r.RemoteAddr,
+		fmt.Sprintf("%d blocks and %d UTXOs as %s", len(blocks), len(snap.UTXOs), encoding))
+}
+
+// handleChainImport loads a chain export into a node whose chain is still
+// empty, and stores it. The export is checked whole before anything changes.
+func (s *Server) handleChainImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	encoding, ok := exportEncoding(r)
+	if !ok {
+		Error(w, r, CodeValidationFailed, "format must be json or protobuf")
+		return
+	}
+	// Large exports take longer to upload than the server's ReadTimeout
+	http.NewResponseController(w).SetReadDeadline(time.Now().Add(chainImportTimeout))
+	exp, err := chainfile.Read(http.MaxBytesReader(w, r.Body, MaxChainImportBytes), encoding)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			Error(w, r, CodeInvalidExport, fmt.Sprintf("The export is larger than %d bytes", int64(MaxChainImportBytes)))
+			return
+		}
+		Error(w, r, CodeInvalidExport, err.Error())
+		return
+	}
+	if mode := s.bc.ActiveConsensus().Mode(); exp.Header.Consensus != mode {
+		Error(w, r, CodeInvalidExport, fmt.Sprintf("The chain was produced under %s and this node runs %s", exp.Header.Consensus, mode))
+		return
+	}
+	// The state comes from replaying the blocks, not from the export's snapshot
+	state, err := s.bc.CheckImport(&exp.Snapshot, exp.Blocks)
+	if err != nil {
+		writeOpError(w, r, importError(err))
+		return
+	}
+
+	// The store goes first, so a failure leaves the node as it was
+	stored := s.store != nil
+	if stored {
+		ctx, cancel := context.WithTimeout(r.Context(), chainImportTimeout)
+		defer cancel()
+		if err := s.store.Atomic(ctx, func(tx database.Store) error { return saveImport(ctx, tx, exp.Blocks, state.UTXOs) }); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "chain_import_failed", s.adminActor(r), r.RemoteAddr, err.Error())
+			Error(w, r, CodeInternal, "Failed to store the imported chain")
+			return
+		}
+	}
+	if err := s.bc.Import(&exp.Snapshot, exp.Blocks); err != nil {
+		// Only a block mined since the check gets here
+		s.logSvc.LogSystemCtx(r.Context(), "chain_import_failed", s.adminActor(r), r.RemoteAddr, err.Error())
+		writeOpError(w, r, importError(err))
+		return
+	}
+	s.reloadChainServices(r.Context(), state.UTXOs)
+
+	tip := exp.Blocks[len(exp.Blocks)-1]
+	s.logSvc.LogSystemCtx(r.Context(), "chain_imported", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%d blocks to #%d and %d UTXOs from a %s export of %s (stored: %t)", len(exp.Blocks), tip.Index, len(state.UTXOs), encoding, exp.Header.CreatedAt.Format(time.RFC3339), stored))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ChainImportResponse{
+		Height:    tip.Index,
+		TipHash:   tip.Hash,
+		Blocks:    len(exp.Blocks),
+		UTXOs:     len(state.UTXOs),
+		Consensus: exp.Header.Consensus,
+		Stored:    stored,
+	})
+}
+
+// importError maps the reasons the chain refuses an import to API errors
+func importError(err error) error {
+	if errors.Is(err, blockchain.ErrChainNotEmpty) {
+		return fail(CodeChainNotEmpty, "The chain already has blocks, pending transactions or outputs; import into a new node, or reset the chain first")
+	}
+	return fail(CodeInvalidExport, err.Error())
+}
+
+// saveImport replaces the stored chain with imported blocks, their
+// transactions and the UTXOs replaying them left
+func saveImport(ctx context.Context, tx database.Store, blocks []blockchain.Block, utxos []blockchain.UTXO) error {
+	if err := tx.ResetChain(ctx); err != nil {
+		return fmt.Errorf("reset chain: %w", err)
+	}
+	for _, blk := range blocks {
+		body, err := json.Marshal(blk)
+		if err != nil {
+			return err
+		}
+		if err := tx.SaveBlock(ctx, blk.Version, blk.Index, blk.Timestamp, blk.PreviousHash, blk.Hash, blk.Nonce, blk.MerkleRoot, body); err != nil {
+			return fmt.Errorf("save block %d: %w", blk.Index, err)
+		}
+		for _, t := range blk.Transactions {
+			blockIdx := blk.Index
+			if err := tx.SaveTransaction(ctx, t.ID, t.SenderID, t.ReceiverID, t.Amount, t.Fee, t.Nonce, t.Note, t.Timestamp, t.PubKey, t.Signature, t.Type, &blockIdx, "confirmed"); err != nil {
+				return fmt.Errorf("save transaction %s: %w", t.ID, err)
+			}
+		}
+	}
+	for _, u := range utxos {
+		if err := tx.SaveUTXO(ctx, u.ID, u.Owner, u.AssetID, u.Amount, u.OriginTx, u.Index, u.Spent); err != nil {
+			return fmt.Errorf("save utxo %s: %w", u.ID, err)
+		}
+	}
+	return nil
+}
+
+// reloadChainServices brings what is derived from the chain in step with an
+// imported one: the stored balances of the wallets holding its outputs, the
+// issuance history, the miners and a snapshot to restart from
+func (s *Server) reloadChainServices(ctx context.Context, utxos []blockchain.UTXO) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), chainImportTimeout)
+	defer cancel()
+
+	owners := make(map[string]bool)
+	for _, u := range utxos {
+		owners[u.Owner] = true
+	}
+	for walletID := range owners {
+		if err := s.balances.Sync(ctx, walletID); err != nil {
+			s.logSvc.LogSystemCtx(ctx, "balance_update_failed", walletID, "", err.Error())
+		}
+	}
+
+	s.supply.Reset()
+	s.miners.Reset()
+	if s.db != nil {
+		// The tables were emptied with the chain, so they are refilled from it
+		s.supply.SetDatabase(s.db)
+		s.miners.SetDatabase(s.db)
+	} else {
+		s.miners.Rebuild()
+	}
+	if _, err := s.snapshots.Create(ctx); err != nil && !errors.Is(err, services.ErrNoDatabase) {
+		s.logSvc.LogSystemCtx(ctx, "chain_snapshot_failed", "", "", err.Error())
+	}
+}
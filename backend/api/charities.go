@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// Admins register charity wallets with weights. After each zakat run the
+// collected zakat is split between them by weight and sent on in follow-up
+// transactions; the registry and the distributions are public.
+
+// handleListCharities lists the charities the zakat pool is distributed to
+func (s *Server) handleListCharities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.charities.List())
+}
+
+// handleZakatDistributions lists zakat distributions, newest first
+func (s *Server) handleZakatDistributions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	list := s.charities.Distributions()
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			Error(w, r, CodeValidationFailed, "limit must be a positive integer")
+			return
+		}
+		if limit < len(list) {
+			list = list[:limit]
+		}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleSetCharity registers a charity or changes its name and weight
+func (s *Server) handleSetCharity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CharityRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	walletID := mux.Vars(r)["wallet"]
+	if !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	charity, err := s.charities.Set(walletID, req.Name, req.Weight, s.adminActor(r))
+	if err != nil {
+		s.writeCharityError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "charity_set", walletID, r.RemoteAddr, fmt.Sprintf("%s with weight %d by %s", charity.Name, charity.Weight, s.adminActor(r)))
+	json.NewEncoder(w).Encode(charity)
+}
+
+// handleRemoveCharity takes a charity out of future distributions
+func (s *Server) handleRemoveCharity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if err := s.charities.Remove(walletID); err != nil {
+		s.writeCharityError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "charity_removed", walletID, r.RemoteAddr, "by "+s.adminActor(r))
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Charity removed"})
+}
+
+func (s *Server) writeCharityError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrCharityNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidCharityWallet):
+		Error(w, r, CodeWalletNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidWeight):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleConsolidate queues a signed self-transfer that combines the wallet's
+// smallest outputs into one, so later sends need fewer inputs
+func (s *Server) handleConsolidate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ConsolidateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	walletID := mux.Vars(r)["wallet"]
+	wlt, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	privateKey, session, err := s.resolveSigner(r.Context(), wlt, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	tx, err := s.txSvc.CreateConsolidation(walletID, req.MaxInputs, req.Below, wlt.PublicKey, privateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "consolidation_failed", walletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+	if err := s.txSvc.ValidateTransaction(r.Context(), tx); err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "transaction_validation_failed", walletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+		return
+	}
+
+	// Only the fee leaves the wallet, so only it counts against the session
+	if session != nil {
+		if _, err := s.signing.Reserve(req.SigningToken, walletID, tx.Fee); err != nil {
+			writeOpError(w, r, signingError(err))
+			return
+		}
+	}
+
+	if err := s.queueTransaction(r.Context(), tx, r.RemoteAddr); err != nil {
+		if session != nil {
+			s.signing.Release(req.SigningToken, tx.Fee)
+		}
+		writeOpError(w, r, err)
+		return
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_consolidated", walletID, r.RemoteAddr, fmt.Sprintf("%d outputs combined into %d", len(tx.Inputs), tx.Amount))
+
+	json.NewEncoder(w).Encode(ConsolidateResponse{TxID: tx.ID, Inputs: len(tx.Inputs), Amount: tx.Amount, Fee: tx.Fee})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"blockchain-backend/services"
+)
+
+// handleListDeliveries lists webhook and email deliveries, failed ones by default
+func (s *Server) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	status := q.Get("status")
+	if status == "" {
+		status = services.DeliveryFailed
+	} else if status == "all" {
+		status = ""
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	deliveries := s.deliveries.List(status, q.Get("channel"), limit)
+	if deliveries == nil {
+		deliveries = []services.Delivery{}
+	}
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// handleRedeliver re-sends the given failed deliveries, or every failed one when all_failed is set
+func (s *Server) handleRedeliver(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RedeliverRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+
+	ids := req.IDs
+	if req.AllFailed {
+		ids = append(ids, s.deliveries.FailedIDs()...)
+	}
+	if len(ids) == 0 {
+		Error(w, r, CodeValidationFailed, "No deliveries selected")
+		return
+	}
+
+	results := s.deliveries.Redeliver(ids)
+	queued := 0
+	for _, res := range results {
+		if res.Status == "queued" {
+			queued++
+		}
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "deliveries_redelivered", s.adminActor(r), r.RemoteAddr, fmt.Sprintf("Requeued %d of %d deliveries", queued, len(results)))
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queued":  queued,
+		"results": results,
+	})
+}
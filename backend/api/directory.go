@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// The email directory lets senders pay an email address instead of a wallet
+// ID, for wallets whose owners opted in; see services.DirectoryService.
+
+// handleGetDirectorySetting reports whether a wallet can be found by email
+func (s *Server) handleGetDirectorySetting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := r.URL.Query().Get("wallet_id")
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", walletID)
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	json.NewEncoder(w).Encode(DirectoryStatusResponse{WalletID: walletID, Discoverable: s.directory.Discoverable(walletID)})
+}
+
+// handleSetDirectorySetting lets the owner list the wallet under their email
+// or take it out of the directory
+func (s *Server) handleSetDirectorySetting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req DirectorySettingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	if err := s.directory.SetDiscoverable(req.WalletID, req.Discoverable); err != nil {
+		if errors.Is(err, services.ErrNoEmail) {
+			Error(w, r, CodeValidationFailed, "The wallet has no email to be found by")
+			return
+		}
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	event := "directory_opt_out"
+	if req.Discoverable {
+		event = "directory_opt_in"
+	}
+	s.logSvc.LogSystemCtx(r.Context(), event, req.WalletID, r.RemoteAddr, "Email directory listing changed")
+	json.NewEncoder(w).Encode(DirectoryStatusResponse{WalletID: req.WalletID, Discoverable: req.Discoverable})
+}
+
+// handleResolveEmail returns the wallet listed under an email. Unknown
+// emails and wallets that did not opt in answer alike, and each client IP
+// address gets a limited number of lookups.
+func (s *Server) handleResolveEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ResolveEmailRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	walletID, err := s.directory.Resolve(req.Email, remoteIP(r.RemoteAddr))
+	var limit *services.ResolveLimitError
+	switch {
+	case errors.As(err, &limit):
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(time.Until(limit.Until)/time.Second)+1, 10))
+		s.logSvc.LogSystemCtx(r.Context(), "email_resolve_limited", "", r.RemoteAddr, err.Error())
+		Error(w, r, CodeResolveLimited, "Too many email lookups; try again later")
+		return
+	case err != nil, !s.inOrg(r.Context(), walletID):
+		Error(w, r, CodeNotDiscoverable, services.ErrNotDiscoverable.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(ResolveEmailResponse{WalletID: walletID})
+}
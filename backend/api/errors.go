@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// ErrorCode is a stable, machine-readable identifier for an API error.
+// Clients should branch on the code, never on the message text.
+type ErrorCode string
+
+// Error codes. The HTTP status for each code is fixed by errorCatalog.
+const (
+	// Request shape
+	CodeInvalidRequest   ErrorCode = "INVALID_REQUEST"   // body is not valid JSON for the endpoint
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED" // a field is missing or malformed
+	CodeInvalidKey       ErrorCode = "INVALID_PRIVATE_KEY"
+	CodeRawKeyRejected   ErrorCode = "PRIVATE_KEY_REJECTED" // REJECT_PRIVATE_KEYS is on
+
+	// Wallets and transactions
+	CodeWalletNotFound      ErrorCode = "WALLET_NOT_FOUND"
+	CodeWalletExists        ErrorCode = "WALLET_ALREADY_EXISTS"
+	CodeInvalidPassphrase   ErrorCode = "INVALID_PASSPHRASE"
+	CodeInsufficientBalance ErrorCode = "INSUFFICIENT_BALANCE"
+	CodeTransactionRejected ErrorCode = "TRANSACTION_REJECTED" // failed signature or UTXO validation
+	CodeDuplicateTx         ErrorCode = "DUPLICATE_TRANSACTION"
+	CodeInputConflict       ErrorCode = "INPUT_CONFLICT" // an input is spent by another pending transaction
+	CodeWalletFrozen        ErrorCode = "WALLET_FROZEN"
+	CodeSpendingLimit       ErrorCode = "SPENDING_LIMIT_EXCEEDED" // over the wallet's own limits without limit_otp
+
+	CodeTypeChangePending ErrorCode = "TYPE_CHANGE_PENDING"
+
+	CodeWalletInactive ErrorCode = "WALLET_INACTIVE"    // the wallet's key was rotated or it was deactivated
+	CodeWalletBusy     ErrorCode = "WALLET_HAS_PENDING" // pending transactions or unconfirmed outputs block the change
+	CodeWalletNotEmpty ErrorCode = "WALLET_NOT_EMPTY"
+
+	// Webhooks
+	CodeWebhookLimit ErrorCode = "WEBHOOK_LIMIT_REACHED"
+
+	// Faucet
+	CodeFaucetCooldown   ErrorCode = "FAUCET_COOLDOWN" // the email or IP address claimed recently; see Retry-After
+	CodeFaucetDisabled   ErrorCode = "FAUCET_DISABLED"
+	CodeFaucetIneligible ErrorCode = "FAUCET_INELIGIBLE"
+
+	// KYC
+	CodeKYCPending       ErrorCode = "KYC_PENDING"
+	CodeKYCVerified      ErrorCode = "KYC_ALREADY_VERIFIED"
+	CodeKYCLimitExceeded ErrorCode = "KYC_LIMIT_EXCEEDED" // unverified wallet's daily send cap
+
+	// Accounts
+	CodeEmailTaken    ErrorCode = "EMAIL_ALREADY_REGISTERED"
+	CodeInvalidOTP    ErrorCode = "INVALID_OTP"
+	CodeOTPLocked     ErrorCode = "OTP_LOCKED"      // too many wrong codes for the email; see Retry-After
+	CodeOTPCooldown   ErrorCode = "OTP_COOLDOWN"    // a code was sent to the email moments ago; see Retry-After
+	CodeOTPDailyLimit ErrorCode = "OTP_DAILY_LIMIT" // the email was sent too many codes today; see Retry-After
+	CodeInvalidTOTP   ErrorCode = "INVALID_TOTP"
+	CodeTOTPRequired  ErrorCode = "TOTP_REQUIRED" // send above the 2FA threshold without a code
+	CodeUserNotFound  ErrorCode = "USER_NOT_FOUND"
+
+	CodeEmailNotVerified ErrorCode = "EMAIL_NOT_VERIFIED" // wallet creation or login without a verified code or session for the email
+
+	CodeNotDiscoverable ErrorCode = "NOT_DISCOVERABLE" // no wallet opted in to the email directory under the email
+	CodeResolveLimited  ErrorCode = "RESOLVE_LIMITED"  // too many email lookups from the client; see Retry-After
+
+	CodeTwoFactorEnabled    ErrorCode = "TWO_FACTOR_ALREADY_ENABLED"
+	CodeTwoFactorNotEnabled ErrorCode = "TWO_FACTOR_NOT_ENABLED"
+
+	CodeSigningTokenInvalid ErrorCode = "SIGNING_TOKEN_INVALID"
+	CodeSigningLimit        ErrorCode = "SIGNING_LIMIT_EXCEEDED"
+
+	// Beneficiaries
+	CodeBeneficiaryNotFound ErrorCode = "BENEFICIARY_NOT_FOUND"
+	CodeBeneficiaryExists   ErrorCode = "BENEFICIARY_EXISTS"
+	CodeAliasTaken          ErrorCode = "ALIAS_TAKEN"
+	CodeAliasNotFound       ErrorCode = "ALIAS_NOT_FOUND"
+
+	CodeHandleTaken    ErrorCode = "HANDLE_TAKEN" // held by another wallet, or released by one recently
+	CodeHandleReserved ErrorCode = "HANDLE_RESERVED"
+	CodeHandleNotFound ErrorCode = "HANDLE_NOT_FOUND"
+
+	// Other resources
+	CodeNotFound        ErrorCode = "NOT_FOUND" // block, schema, anchor, ...
+	CodeAlreadyAnchored ErrorCode = "ALREADY_ANCHORED"
+	CodeTxNotMined      ErrorCode = "TRANSACTION_NOT_MINED" // no merkle proof while pending
+
+	CodeAlreadyDecided  ErrorCode = "REQUEST_ALREADY_DECIDED" // approval request was already approved or rejected
+	CodePayoutCancelled ErrorCode = "PAYOUT_CANCELLED"        // inheritance payout overtaken by the owner or an empty wallet
+
+	CodeCampaignClosed ErrorCode = "CAMPAIGN_CLOSED"        // campaign closed or past its deadline
+	CodeHasDonations   ErrorCode = "CAMPAIGN_HAS_DONATIONS" // only campaigns without donations can be deleted
+
+	CodeAssetNotFound ErrorCode = "ASSET_NOT_FOUND"
+	CodeAssetExists   ErrorCode = "ASSET_ALREADY_EXISTS"
+	CodeAssetSupply   ErrorCode = "ASSET_SUPPLY_EXCEEDED" // the asset is not mintable or would pass its max supply
+
+	CodeOutputLocked ErrorCode = "OUTPUT_LOCKED" // before an output's lock time, or without the preimage of its hash lock
+
+	CodePayRequestClosed ErrorCode = "PAYMENT_REQUEST_CLOSED" // accepted, declined, expired or being paid
+
+	CodeQueryTooComplex ErrorCode = "QUERY_TOO_COMPLEX" // GraphQL depth or complexity limit
+
+	CodeResetDisabled ErrorCode = "CHAIN_RESET_DISABLED" // neither ALLOW_CHAIN_RESET nor a SANDBOX
+	CodeChainNotEmpty ErrorCode = "CHAIN_NOT_EMPTY"      // chain imports need a chain with only its genesis block
+	CodeInvalidExport ErrorCode = "INVALID_CHAIN_EXPORT" // unreadable, cut short, or blocks this node would not accept
+
+	CodeMiningQueueFull ErrorCode = "MINING_QUEUE_FULL"  // too many mining jobs waiting for the worker
+	CodeNotProducer     ErrorCode = "NOT_VALIDATOR_TURN" // proof of authority gives the next block to another validator
+
+	// Organizations (multi-tenant mode)
+	CodeOrgRequired      ErrorCode = "ORG_REQUIRED" // X-Org-ID header missing and not inferred
+	CodeOrgNotFound      ErrorCode = "ORG_NOT_FOUND"
+	CodeOrgExists        ErrorCode = "ORG_ALREADY_EXISTS"
+	CodeOrgAdminRequired ErrorCode = "ORG_ADMIN_REQUIRED"
+
+	// Access and infrastructure
+	CodeUnauthorized        ErrorCode = "UNAUTHORIZED"
+	CodeInvalidToken        ErrorCode = "INVALID_ID_TOKEN"
+	CodeAccountConflict     ErrorCode = "ACCOUNT_LINK_CONFLICT"
+	CodeNotConfigured       ErrorCode = "FEATURE_NOT_CONFIGURED"
+	CodeAdminRequired       ErrorCode = "ADMIN_REQUIRED"
+	CodeDatabaseUnavailable ErrorCode = "DATABASE_UNAVAILABLE"
+	CodeRequestCancelled    ErrorCode = "REQUEST_CANCELLED" // the client went away or the deadline passed
+	CodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// errorCatalog maps every code to its HTTP status and a description; it is
+// served at GET /api/errors so clients can discover the full set
+var errorCatalog = map[ErrorCode]struct {
+	Status      int
+	Description string
+}{
+	CodeInvalidRequest:      {http.StatusBadRequest, "The request body could not be parsed"},
+	CodeValidationFailed:    {http.StatusBadRequest, "A required field is missing or has an invalid value"},
+	CodeInvalidKey:          {http.StatusBadRequest, "The private key is malformed or does not match the wallet"},
+	CodeRawKeyRejected:      {http.StatusBadRequest, "The server no longer accepts private keys in requests; use a signing session"},
+	CodeWalletNotFound:      {http.StatusNotFound, "The wallet does not exist"},
+	CodeWalletExists:        {http.StatusConflict, "The wallet already exists on this server"},
+	CodeInvalidPassphrase:   {http.StatusBadRequest, "The backup passphrase is wrong or the file is corrupted"},
+	CodeInsufficientBalance: {http.StatusBadRequest, "The wallet does not hold enough unspent outputs"},
+	CodeTransactionRejected: {http.StatusBadRequest, "The transaction failed signature or UTXO validation"},
+	CodeDuplicateTx:         {http.StatusConflict, "A transaction with this signature or nonce was already submitted"},
+	CodeInputConflict:       {http.StatusConflict, "An input is already spent by another pending transaction"},
+	CodeWalletFrozen:        {http.StatusForbidden, "An administrator froze the wallet; it can receive but not send"},
+	CodeSpendingLimit:       {http.StatusForbidden, "The send exceeds the wallet's spending limits; confirm it with limit_otp"},
+	CodeTypeChangePending:   {http.StatusConflict, "The wallet already has a pending type change request"},
+	CodeWalletInactive:      {http.StatusForbidden, "The wallet was retired by a key rotation or deactivated; it can neither send nor receive"},
+	CodeWalletBusy:          {http.StatusConflict, "The wallet has pending transactions or outputs still waiting for confirmations"},
+	CodeWalletNotEmpty:      {http.StatusConflict, "Only a wallet without a balance can be deactivated; send or rotate its coins first"},
+	CodeWebhookLimit:        {http.StatusConflict, "The wallet already has the maximum number of webhooks"},
+	CodeFaucetCooldown:      {http.StatusTooManyRequests, "The wallet's email or the client's IP address claimed the faucet recently"},
+	CodeFaucetDisabled:      {http.StatusForbidden, "The faucet does not accept claims on this server"},
+	CodeFaucetIneligible:    {http.StatusForbidden, "Only active personal wallets with an email may claim faucet coins"},
+	CodeKYCPending:          {http.StatusConflict, "The wallet already has a KYC submission under review"},
+	CodeKYCVerified:         {http.StatusConflict, "The wallet is already KYC-verified"},
+	CodeKYCLimitExceeded:    {http.StatusForbidden, "The wallet is not KYC-verified and the send would exceed its daily limit"},
+	CodeEmailTaken:          {http.StatusConflict, "A wallet is already registered with this email"},
+	CodeEmailNotVerified:    {http.StatusForbidden, "Verify the email with a one-time code or a login session first"},
+	CodeNotDiscoverable:     {http.StatusNotFound, "No wallet can be found by the email; its owner may not have opted in"},
+	CodeResolveLimited:      {http.StatusTooManyRequests, "The client looked up too many emails recently"},
+	CodeInvalidOTP:          {http.StatusBadRequest, "The one-time code is wrong or has expired"},
+	CodeOTPLocked:           {http.StatusTooManyRequests, "Too many wrong one-time codes were entered for the email; request a new code later"},
+	CodeOTPCooldown:         {http.StatusTooManyRequests, "A one-time code was sent to the email moments ago"},
+	CodeOTPDailyLimit:       {http.StatusTooManyRequests, "The email was sent too many one-time codes in the last 24 hours"},
+	CodeInvalidTOTP:         {http.StatusBadRequest, "The authenticator code is wrong, expired or already used"},
+	CodeTOTPRequired:        {http.StatusForbidden, "The amount is above the wallet's 2FA threshold; send totp_code"},
+	CodeTwoFactorEnabled:    {http.StatusConflict, "Two-factor authentication is already enabled for the wallet"},
+	CodeTwoFactorNotEnabled: {http.StatusConflict, "Two-factor authentication is not enabled or enrollment was not started"},
+	CodeSigningTokenInvalid: {http.StatusUnauthorized, "The signing token is unknown, expired or belongs to another wallet"},
+	CodeSigningLimit:        {http.StatusForbidden, "The amount exceeds what the signing session may still spend"},
+	CodeUserNotFound:        {http.StatusNotFound, "No user record exists for the wallet"},
+	CodeBeneficiaryNotFound: {http.StatusNotFound, "The beneficiary does not exist"},
+	CodeBeneficiaryExists:   {http.StatusConflict, "The wallet is already a beneficiary"},
+	CodeAliasTaken:          {http.StatusConflict, "The alias is already used by another beneficiary"},
+	CodeAliasNotFound:       {http.StatusNotFound, "No beneficiary has this alias"},
+	CodeHandleTaken:         {http.StatusConflict, "Another wallet holds the handle, or gave it up recently and may still reclaim it"},
+	CodeHandleReserved:      {http.StatusConflict, "The handle is reserved for the operator"},
+	CodeHandleNotFound:      {http.StatusNotFound, "No wallet holds the handle"},
+	CodeNotFound:            {http.StatusNotFound, "The requested resource does not exist"},
+	CodeAlreadyAnchored:     {http.StatusConflict, "The document hash is already anchored"},
+	CodeTxNotMined:          {http.StatusConflict, "The transaction is still pending, so it has no merkle proof yet"},
+	CodeAlreadyDecided:      {http.StatusConflict, "The request was already approved or rejected"},
+	CodePayoutCancelled:     {http.StatusConflict, "The wallet sent coins since the inheritance payout was filed, or holds nothing to pay; the payout was cancelled"},
+	CodeCampaignClosed:      {http.StatusConflict, "The campaign is closed or past its deadline"},
+	CodeHasDonations:        {http.StatusConflict, "The campaign has donations; close it instead of deleting it"},
+	CodeAssetNotFound:       {http.StatusNotFound, "No asset has this symbol"},
+	CodeAssetExists:         {http.StatusConflict, "An asset with this symbol already exists"},
+	CodeAssetSupply:         {http.StatusConflict, "The asset has a fixed supply, or issuing this much would pass its max supply"},
+	CodeOutputLocked:        {http.StatusConflict, "The output is time-locked, or the preimage does not open its hash lock"},
+	CodePayRequestClosed:    {http.StatusConflict, "The payment request was already accepted, declined or expired, or is being paid"},
+	CodeQueryTooComplex:     {http.StatusBadRequest, "The GraphQL query exceeds the depth or complexity limit"},
+	CodeResetDisabled:       {http.StatusForbidden, "Chain resets are turned off on this server"},
+	CodeChainNotEmpty:       {http.StatusConflict, "The chain already has blocks, pending transactions or outputs; import into a new node or reset the chain first"},
+	CodeInvalidExport:       {http.StatusBadRequest, "The chain export is unreadable, cut short, or holds blocks this node's consensus does not accept"},
+	CodeMiningQueueFull:     {http.StatusTooManyRequests, "Too many mining jobs are waiting; try again once some finish"},
+	CodeNotProducer:         {http.StatusConflict, "Under proof of authority the next block is another validator's to produce"},
+	CodeOrgRequired:         {http.StatusBadRequest, "Multi-tenant mode is on and the X-Org-ID header is missing where the session does not settle the organization"},
+	CodeOrgNotFound:         {http.StatusNotFound, "The organization does not exist"},
+	CodeOrgExists:           {http.StatusConflict, "An organization with this ID already exists"},
+	CodeOrgAdminRequired:    {http.StatusForbidden, "The endpoint requires an admin of the organization"},
+	CodeUnauthorized:        {http.StatusUnauthorized, "A valid session token is required"},
+	CodeInvalidToken:        {http.StatusUnauthorized, "The identity provider token failed verification"},
+	CodeAccountConflict:     {http.StatusConflict, "The email is already linked to a different external account"},
+	CodeNotConfigured:       {http.StatusServiceUnavailable, "The feature is not configured on this server"},
+	CodeAdminRequired:       {http.StatusForbidden, "The endpoint requires admin credentials"},
+	CodeDatabaseUnavailable: {http.StatusServiceUnavailable, "The feature requires the database, which is not connected"},
+	CodeRequestCancelled:    {http.StatusRequestTimeout, "The request was cancelled or ran out of time before it changed anything"},
+	CodeInternal:            {http.StatusInternalServerError, "Unexpected server error; quote the request_id to support"},
+}
+
+// ErrorBody is the "error" object of every error response
+type ErrorBody struct {
+	Code      ErrorCode               `json:"code"`
+	Message   string                  `json:"message"`
+	Fields    []validation.FieldError `json:"fields,omitempty"` // per-field problems, for VALIDATION_FAILED
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// Error writes the standard error envelope {"error":{"code","message","request_id"}}
+// with the HTTP status registered for the code. The request ID lets users quote
+// the failure to support and match it against the logs.
+func Error(w http.ResponseWriter, r *http.Request, code ErrorCode, message string) {
+	writeError(w, r, code, message, nil)
+}
+
+// ValidationError writes VALIDATION_FAILED listing every invalid field
+func ValidationError(w http.ResponseWriter, r *http.Request, errs validation.Errors) {
+	writeError(w, r, CodeValidationFailed, errs.Error(), errs)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, code ErrorCode, message string, fields validation.Errors) {
+	status := http.StatusInternalServerError
+	if entry, ok := errorCatalog[code]; ok {
+		status = entry.Status
+	}
+	if note := auditNoteFrom(r); note != nil {
+		note.errorCode = code
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]ErrorBody{
+		"error": {Code: code, Message: message, Fields: fields, RequestID: services.RequestIDFrom(r.Context())},
+	})
+}
+
+// transactionErrorCode classifies errors from transaction construction
+func transactionErrorCode(err error) ErrorCode {
+	switch {
+	case errors.Is(err, services.ErrInsufficientBalance):
+		return CodeInsufficientBalance
+	case errors.Is(err, services.ErrSenderNotFound), errors.Is(err, services.ErrReceiverNotFound):
+		return CodeWalletNotFound
+	case errors.Is(err, services.ErrMalformedTransaction), errors.Is(err, services.ErrStaleTransaction),
+		errors.Is(err, services.ErrSelfTransfer), errors.Is(err, services.ErrTooManyInputs),
+		errors.Is(err, services.ErrNothingToConsolidate), errors.Is(err, services.ErrNothingToSweep), errors.Is(err, services.ErrNotRetired), errors.Is(err, services.ErrLockInPast), errors.Is(err, services.ErrInvalidVesting),
+		errors.Is(err, services.ErrNotHashLocked), errors.Is(err, blockchain.ErrMalformedLock), errors.Is(err, blockchain.ErrInvalidOutput):
+		return CodeValidationFailed
+	case errors.Is(err, blockchain.ErrInputLocked), errors.Is(err, blockchain.ErrBadPreimage):
+		return CodeOutputLocked
+	case errors.Is(err, services.ErrDuplicateTransaction), errors.Is(err, services.ErrNonceUsed),
+		errors.Is(err, blockchain.ErrDuplicateTx):
+		return CodeDuplicateTx
+	case errors.Is(err, blockchain.ErrInputConflict):
+		return CodeInputConflict
+	case errors.Is(err, services.ErrWalletFrozen):
+		return CodeWalletFrozen
+	case errors.Is(err, services.ErrWalletInactive):
+		return CodeWalletInactive
+	case errors.Is(err, services.ErrWalletBusy):
+		return CodeWalletBusy
+	case errors.Is(err, services.ErrKYCLimitExceeded):
+		return CodeKYCLimitExceeded
+	case errors.Is(err, services.ErrSpendingLimitExceeded):
+		return CodeSpendingLimit
+	case errors.Is(err, services.ErrUnknownAsset):
+		return CodeAssetNotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return CodeRequestCancelled
+	}
+	return CodeTransactionRejected
+}
+
+// handleErrorCatalog lists every error code with its HTTP status
+func (s *Server) handleErrorCatalog(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		Code        ErrorCode `json:"code"`
+		Status      int       `json:"status"`
+		Description string    `json:"description"`
+	}
+	list := make([]entry, 0, len(errorCatalog))
+	for code, e := range errorCatalog {
+		list = append(list, entry{Code: code, Status: e.Status, Description: e.Description})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": list})
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/events"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// faucetLedgerLimit is how many grants the admin ledger lists by default
+const faucetLedgerLimit = 100
+
+// handleFaucetStatus describes the faucet and, for a wallet_id, whether the
+// wallet can claim now
+func (s *Server) handleFaucetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	policy := s.faucet.Policy()
+	resp := FaucetStatusResponse{
+		Mode:                 policy.Mode,
+		Amount:               policy.Amount,
+		EmailCooldownSeconds: int64(policy.EmailCooldown / time.Second),
+		IPCooldownSeconds:    int64(policy.IPCooldown / time.Second),
+	}
+
+	if walletID := r.URL.Query().Get("wallet_id"); walletID != "" {
+		var errs validation.Errors
+		checkWalletID(&errs, "wallet_id", walletID)
+		if len(errs) > 0 {
+			ValidationError(w, r, errs)
+			return
+		}
+		wlt, ok := s.ws.Get(walletID)
+		if !ok || !s.inOrg(r.Context(), walletID) {
+			Error(w, r, CodeWalletNotFound, "Wallet not found")
+			return
+		}
+		resp.WalletID = walletID
+		resp.Amount = s.config.ForWallet(walletID).FaucetAmount
+		eligible := policy.Mode == services.FaucetModeClaim && resp.Amount > 0 && wlt.FaucetEligible() && wlt.Email != ""
+		if next := s.faucet.NextClaim(wlt.Email, remoteIP(r.RemoteAddr)); !next.IsZero() {
+			eligible = false
+			resp.NextClaimAt = &next
+		}
+		resp.Eligible = &eligible
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleFaucetClaim grants faucet coins to a wallet once its owner proves the
+// wallet's email, with an emailed one-time code or a login session for it.
+// Each email and each client IP address may claim once per cooldown.
+func (s *Server) handleFaucetClaim(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ip := remoteIP(r.RemoteAddr)
+
+	var req FaucetClaimRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	wlt, ok := s.ws.Get(req.WalletID)
+	if !ok || !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	amount := s.config.ForWallet(wlt.WalletID).FaucetAmount
+	if s.faucet.Policy().Mode != services.FaucetModeClaim || amount == 0 {
+		Error(w, r, CodeFaucetDisabled, "The faucet does not accept claims on this server")
+		return
+	}
+	if !wlt.FaucetEligible() {
+		Error(w, r, CodeFaucetIneligible, services.ErrFaucetIneligible.Error())
+		return
+	}
+	if wlt.Email == "" {
+		Error(w, r, CodeFaucetIneligible, "The wallet has no email to verify")
+		return
+	}
+
+	if err := s.verifyFaucetEmail(r, wlt, req.OTPCode); err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "faucet_claim_denied", wlt.WalletID, r.RemoteAddr, err.Error())
+		writeOpError(w, r, err)
+		return
+	}
+
+	grant, utxo, err := s.faucet.Claim(wlt.WalletID, wlt.Email, ip, amount)
+	if err != nil {
+		var cooldown *services.FaucetCooldownError
+		switch {
+		case errors.As(err, &cooldown):
+			wait := int64(time.Until(cooldown.Until)/time.Second) + 1
+			w.Header().Set("Retry-After", strconv.FormatInt(wait, 10))
+			s.logSvc.LogSystemCtx(r.Context(), "faucet_claim_denied", wlt.WalletID, r.RemoteAddr, err.Error())
+			Error(w, r, CodeFaucetCooldown, err.Error())
+		case errors.Is(err, services.ErrFaucetDisabled):
+			Error(w, r, CodeFaucetDisabled, "The faucet does not accept claims on this server")
+		default:
+			Error(w, r, CodeInternal, err.Error())
+		}
+		return
+	}
+	if req.OTPCode != "" {
+		otp.ClearOTP(wlt.Email)
+	}
+
+	s.recordFaucetGrant(r.Context(), grant, utxo, r.RemoteAddr)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(FaucetClaimResponse{FaucetGrant: grant, Balance: s.bc.GetBalance(wlt.WalletID)})
+}
+
+// handleFaucetLedger lists recent faucet grants, claims and signup grants alike
+func (s *Server) handleFaucetLedger(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := faucetLedgerLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	grants, err := s.faucet.Ledger(r.Context(), limit)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to load the faucet ledger")
+		return
+	}
+	json.NewEncoder(w).Encode(grants)
+}
+
+// verifyFaucetEmail checks that the caller controls the wallet's email, by a
+// one-time code sent to it or a login session for it
+func (s *Server) verifyFaucetEmail(r *http.Request, wlt wallet.Wallet, code string) error {
+	if code != "" {
+		if !otp.VerifyOTP(wlt.Email, code) {
+			return fail(CodeInvalidOTP, "Invalid or expired otp_code")
+		}
+		return nil
+	}
+	if token := bearerToken(r); token != "" {
+		sess, ok := s.sessions.Validate(token)
+		if !ok {
+			return fail(CodeUnauthorized, "Invalid or expired session token")
+		}
+		if !strings.EqualFold(wlt.Email, sess.Email) {
+			return fail(CodeUnauthorized, "The session's email does not own this wallet")
+		}
+		return nil
+	}
+	return fail(CodeUnauthorized, "Claiming needs otp_code from POST /api/otp/send or a login session for the wallet's email")
+}
+
+// recordFaucetGrant persists a granted UTXO and announces it on the wallet's
+// event feed
+func (s *Server) recordFaucetGrant(ctx context.Context, grant services.FaucetGrant, utxo blockchain.UTXO, remoteAddr string) {
+	s.logSvc.LogSystemCtx(ctx, "faucet_granted", grant.WalletID, remoteAddr, fmt.Sprintf("%s faucet coins granted (%s)", blockchain.FormatAmount(grant.Amount), grant.Source))
+	s.feed.Publish(events.FaucetGranted, grant.WalletID, map[string]interface{}{
+		"utxo_id": utxo.ID,
+		"amount":  utxo.Amount,
+	})
+
+	if s.store == nil {
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := s.store.SaveUTXO(dbCtx, utxo.ID, utxo.Owner, utxo.AssetID, utxo.Amount, utxo.OriginTx, utxo.Index, utxo.Spent); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "faucet_utxo_db_save_failed", grant.WalletID, remoteAddr, err.Error())
+	}
+	if err := s.balances.Sync(dbCtx, grant.WalletID); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "balance_update_failed", grant.WalletID, remoteAddr, err.Error())
+	}
+}
+
+// remoteIP strips the port from a request's remote address. Forwarding
+// headers are ignored because clients can set them freely; behind a proxy
+// every client shares the proxy's cooldown.
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// Fixtures fill the node with made-up data, so pagination, balances and
+// persistence can be measured at realistic volumes. They are allowed where
+// chain resets are, which clear them again; the wallets are kept.
+
+const (
+	maxFixtureWallets      = 1000
+	maxFixtureTransactions = 20000
+	maxFixtureBlocks       = 200
+
+	// fixtureFunding is minted to every fixture wallet in the first block
+	fixtureFunding = 1000 * blockchain.UnitsPerCoin
+
+	// fixturesTimeout replaces the server's WriteTimeout, which a large run
+	// takes far longer than; see withTimeout
+	fixturesTimeout = 10 * time.Minute
+)
+
+// handleGenerateFixtures creates wallets, funds them with a minted block and
+// mines the remaining blocks, each with random transfers between the wallets
+func (s *Server) handleGenerateFixtures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !s.chainReset {
+		Error(w, r, CodeResetDisabled, "Fixtures are turned off with chain resets; set ALLOW_CHAIN_RESET=true or run in a SANDBOX")
+		return
+	}
+	var req FixturesRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	started := time.Now()
+	resp, err := s.generateFixtures(r.Context(), req, r.RemoteAddr)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "fixtures_failed", s.adminActor(r), r.RemoteAddr, err.Error())
+		writeOpError(w, r, err)
+		return
+	}
+	resp.DurationMs = time.Since(started).Milliseconds()
+
+	s.logSvc.LogSystemCtx(r.Context(), "fixtures_generated", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%d wallets, %d transactions (%d skipped) and %d blocks in %dms", len(resp.WalletIDs), resp.Transactions, resp.Skipped, resp.Blocks, resp.DurationMs))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// fixtureWallet is a generated wallet and the key that signs its transfers
+type fixtureWallet struct {
+	id, pub, priv string
+}
+
+func (s *Server) generateFixtures(ctx context.Context, req FixturesRequest, remoteAddr string) (*FixturesResponse, error) {
+	resp := &FixturesResponse{WalletIDs: make([]string, 0, req.Wallets)}
+	wallets := make([]fixtureWallet, 0, req.Wallets)
+	for i := 0; i < req.Wallets; i++ {
+		pub, priv := wallet.GenerateKeypair()
+		wobj, err := s.ws.CreateFromPub(pub, priv, fmt.Sprintf("Fixture %d", i+1), "", "")
+		if err != nil {
+			return nil, fail(CodeInternal, "Failed to create a fixture wallet: "+err.Error())
+		}
+		if s.store != nil {
+			dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			err := s.store.SaveWallet(dbCtx, wobj.WalletID, wobj.PublicKey, wobj.PrivateKey, wobj.FullName, wobj.Email, wobj.CNIC, wobj.Type)
+			cancel()
+			if err != nil {
+				return nil, fail(CodeInternal, "Failed to save a fixture wallet: "+err.Error())
+			}
+		}
+		wallets = append(wallets, fixtureWallet{id: wobj.WalletID, pub: pub, priv: priv})
+		resp.WalletIDs = append(resp.WalletIDs, wobj.WalletID)
+	}
+
+	// The first block mints every wallet its funding
+	for _, fw := range wallets {
+		tx, err := s.txSvc.CreateMintTransaction(fw.id, fixtureFunding, "Load-test fixture funding")
+		if err != nil {
+			return nil, fail(transactionErrorCode(err), err.Error())
+		}
+		if err := s.queueTransaction(ctx, tx, remoteAddr); err != nil {
+			return nil, err
+		}
+		s.bc.AddIssued(fixtureFunding)
+		s.supply.Record(services.IssuedByMint, fixtureFunding, tx.ID)
+	}
+
+	remaining := req.Transactions
+	for b := 0; b < req.Blocks; b++ {
+		if b > 0 && remaining > 0 {
+			// Spread what is left evenly over the blocks still to mine
+			target := (remaining + req.Blocks - b - 1) / (req.Blocks - b)
+			queued, skipped := s.queueFixtureTransfers(ctx, wallets, target, remoteAddr)
+			resp.Transactions += queued
+			resp.Skipped += skipped
+			remaining -= queued + skipped
+		}
+		miner := wallets[rand.IntN(len(wallets))]
+		blk, err := s.mineBlock(ctx, miner.id, 0, remoteAddr, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Blocks++
+		resp.Height = blk.Index
+	}
+	return resp, nil
+}
+
+// queueFixtureTransfers queues up to n random transfers, each from a
+// different wallet, since a wallet's change cannot be spent until it is
+// mined. It returns how many were queued and how many could not be made.
+func (s *Server) queueFixtureTransfers(ctx context.Context, wallets []fixtureWallet, n int, remoteAddr string) (queued, skipped int) {
+	for _, i := range rand.Perm(len(wallets))[:min(n, len(wallets))] {
+		sender := wallets[i]
+		receiver := wallets[rand.IntN(len(wallets)-1)]
+		if receiver.id == sender.id {
+			receiver = wallets[len(wallets)-1]
+		}
+
+		fee := s.txSvc.Fees(sender.id).Transfer
+		balance := s.bc.GetBalance(sender.id)
+		if balance <= fee+1 {
+			skipped++
+			continue
+		}
+		// Up to a tenth of the balance, so wallets can keep sending
+		amount := 1 + rand.Uint64N(max((balance-fee)/10, 1))
+
+		tx, err := s.txSvc.CreateTransaction(ctx, sender.id, receiver.id, amount, "Load-test fixture", sender.pub, sender.priv)
+		if err == nil {
+			err = s.txSvc.ValidateTransaction(ctx, tx)
+		}
+		if err == nil {
+			err = s.queueTransaction(ctx, tx, remoteAddr)
+		}
+		if err != nil {
+			skipped++
+			continue
+		}
+		queued++
+	}
+	return queued, skipped
+}
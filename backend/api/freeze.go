@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleFreezeWallet stops a wallet from sending. It keeps receiving, and
+// transactions it already queued are still mined.
+func (s *Server) handleFreezeWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req FreezeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	s.setFrozen(w, r, mux.Vars(r)["wallet"], true, req.Reason)
+}
+
+// handleUnfreezeWallet lets a frozen wallet send again
+func (s *Server) handleUnfreezeWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.setFrozen(w, r, mux.Vars(r)["wallet"], false, "")
+}
+
+func (s *Server) setFrozen(w http.ResponseWriter, r *http.Request, walletID string, frozen bool, reason string) {
+	if _, ok := s.ws.Get(walletID); !ok {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	// Persist first so a restart cannot silently lift a freeze
+	if s.store != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		if err := s.store.UpdateWalletFrozen(ctx, walletID, frozen, reason); err != nil {
+			Error(w, r, CodeInternal, "Failed to save freeze state")
+			return
+		}
+	}
+	if err := s.ws.SetFrozen(walletID, frozen, reason); err != nil {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	actor := s.adminActor(r)
+	if frozen {
+		s.logSvc.LogSystemCtx(r.Context(), "wallet_frozen", walletID, r.RemoteAddr, reason+" (by "+actor+")")
+	} else {
+		s.logSvc.LogSystemCtx(r.Context(), "wallet_unfrozen", walletID, r.RemoteAddr, "by "+actor)
+	}
+	json.NewEncoder(w).Encode(FreezeResponse{WalletID: walletID, Frozen: frozen, Reason: reason, By: actor})
+}
@@ -0,0 +1,779 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// GraphQL limits. Every field costs 1 and the subtree of a paginated field is
+// multiplied by its page size, so nested lists cannot fan out unbounded.
+const (
+	graphqlDefaultPage    = 20
+	graphqlMaxPage        = 100
+	graphqlMaxDepth       = 10
+	graphqlMaxComplexity  = 5000
+	graphqlMaxQueryLength = 10000
+)
+
+// graphqlConnections lists the paginated fields; their page size (first)
+// multiplies the complexity of everything selected below them
+var graphqlConnections = map[string]bool{
+	"blocks":              true,
+	"transactions":        true,
+	"pendingTransactions": true,
+	"wallets":             true,
+	"utxos":               true,
+	"systemLogs":          true,
+	"transactionLogs":     true,
+}
+
+// handleGraphQL executes a read-only GraphQL query over blocks, transactions,
+// wallets (public fields only), UTXOs and logs
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req GraphQLRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				Error(w, r, CodeInvalidRequest, "Invalid variables")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+
+	if req.Query == "" {
+		Error(w, r, CodeValidationFailed, "Query is required")
+		return
+	}
+	if len(req.Query) > graphqlMaxQueryLength {
+		Error(w, r, CodeQueryTooComplex, fmt.Sprintf("Query exceeds %d characters", graphqlMaxQueryLength))
+		return
+	}
+	if err := checkQueryComplexity(req.Query, req.Variables); err != nil {
+		Error(w, r, CodeQueryTooComplex, err.Error())
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), chainViewKey{}, s.newChainView(r.Context()))
+	result := graphql.Do(graphql.Params{
+		Schema:         s.graphqlSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	json.NewEncoder(w).Encode(result)
+}
+
+// checkQueryComplexity rejects queries that are too deep or too expensive
+// before any resolver runs
+func checkQueryComplexity(query string, variables map[string]interface{}) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil // syntax errors are reported by graphql.Do
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+
+	c := complexityWalker{fragments: fragments, variables: variables}
+	total := 0
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			cost, err := c.selectionSet(op.SelectionSet, 1, map[string]bool{})
+			if err != nil {
+				return err
+			}
+			total += cost
+		}
+	}
+	if total > graphqlMaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d; request smaller pages or fewer nested fields", total, graphqlMaxComplexity)
+	}
+	return nil
+}
+
+type complexityWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func (c complexityWalker) selectionSet(set *ast.SelectionSet, depth int, visiting map[string]bool) (int, error) {
+	if set == nil {
+		return 0, nil
+	}
+	if depth > graphqlMaxDepth {
+		return 0, fmt.Errorf("query depth exceeds the limit of %d", graphqlMaxDepth)
+	}
+
+	total := 0
+	for _, sel := range set.Selections {
+		switch node := sel.(type) {
+		case *ast.Field:
+			children, err := c.selectionSet(node.SelectionSet, depth+1, visiting)
+			if err != nil {
+				return 0, err
+			}
+			if graphqlConnections[node.Name.Value] {
+				children *= c.pageSize(node)
+			}
+			total += 1 + children
+		case *ast.InlineFragment:
+			cost, err := c.selectionSet(node.SelectionSet, depth, visiting)
+			if err != nil {
+				return 0, err
+			}
+			total += cost
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			frag, ok := c.fragments[name]
+			if !ok || visiting[name] {
+				continue // unknown or cyclic fragments fail validation later
+			}
+			visiting[name] = true
+			cost, err := c.selectionSet(frag.SelectionSet, depth, visiting)
+			delete(visiting, name)
+			if err != nil {
+				return 0, err
+			}
+			total += cost
+		}
+	}
+	return total, nil
+}
+
+// pageSize returns the effective "first" argument of a paginated field
+func (c complexityWalker) pageSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		var n int
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			if f, ok := c.variables[v.Name.Value].(float64); ok {
+				n = int(f)
+			}
+		}
+		return clampPage(n)
+	}
+	return graphqlDefaultPage
+}
+
+func clampPage(n int) int {
+	if n <= 0 {
+		return graphqlDefaultPage
+	}
+	if n > graphqlMaxPage {
+		return graphqlMaxPage
+	}
+	return n
+}
+
+// chainView is a consistent snapshot of the chain taken once per query, so
+// nested fields resolve against the same state
+type chainView struct {
+	blocks  []blockchain.Block
+	pending []blockchain.Transaction
+	txBlock map[string]int64
+	utxos   map[string]blockchain.UTXO
+	sees    func(walletID string) bool // nil unless scoped to an organization
+}
+
+type chainViewKey struct{}
+
+func (s *Server) newChainView(ctx context.Context) *chainView {
+	utxos := s.bc.GetUTXOs()
+	v := &chainView{
+		blocks:  s.bc.GetChain(),
+		pending: s.bc.GetPending(),
+		txBlock: make(map[string]int64),
+		utxos:   make(map[string]blockchain.UTXO, len(utxos)),
+	}
+	if s.orgs.Enabled() {
+		v.sees = func(walletID string) bool { return s.inOrg(ctx, walletID) }
+		for i, b := range v.blocks {
+			v.blocks[i] = s.scopeBlock(ctx, b)
+		}
+		v.pending = s.scopeTxs(ctx, v.pending)
+	}
+	for _, b := range v.blocks {
+		for _, tx := range b.Transactions {
+			v.txBlock[tx.ID] = b.Index
+		}
+	}
+	for _, u := range utxos {
+		if v.visible(u.Owner) {
+			v.utxos[u.ID] = u
+		}
+	}
+	return v
+}
+
+// visible reports whether the query may see a wallet and its logs
+func (v *chainView) visible(walletID string) bool {
+	return v.sees == nil || v.sees(walletID)
+}
+
+func viewFrom(p graphql.ResolveParams) *chainView {
+	return p.Context.Value(chainViewKey{}).(*chainView)
+}
+
+func (v *chainView) confirmations(height int64) int64 {
+	tip := int64(len(v.blocks) - 1)
+	if height < 0 || height > tip {
+		return 0
+	}
+	return tip - height + 1
+}
+
+// gqlTx pairs a transaction with the block containing it (nil while pending)
+type gqlTx struct {
+	tx    blockchain.Transaction
+	block *blockchain.Block
+}
+
+func (v *chainView) findTx(id string) (gqlTx, bool) {
+	if idx, ok := v.txBlock[id]; ok {
+		b := &v.blocks[idx]
+		for _, tx := range b.Transactions {
+			if tx.ID == id {
+				return gqlTx{tx: tx, block: b}, true
+			}
+		}
+	}
+	for _, tx := range v.pending {
+		if tx.ID == id {
+			return gqlTx{tx: tx}, true
+		}
+	}
+	return gqlTx{}, false
+}
+
+// confirmedTxs returns confirmed transactions newest first, optionally
+// restricted to those sent or received by walletID
+func (v *chainView) confirmedTxs(walletID string) []gqlTx {
+	var list []gqlTx
+	for i := len(v.blocks) - 1; i >= 0; i-- {
+		b := &v.blocks[i]
+		for j := len(b.Transactions) - 1; j >= 0; j-- {
+			tx := b.Transactions[j]
+			if walletID == "" || tx.SenderID == walletID || tx.Pays(walletID) {
+				list = append(list, gqlTx{tx: tx, block: b})
+			}
+		}
+	}
+	return list
+}
+
+func (v *chainView) walletUTXOs(owner string, includeSpent bool) []blockchain.UTXO {
+	var list []blockchain.UTXO
+	for _, u := range v.utxos {
+		if u.Owner == owner && (includeSpent || !u.Spent) {
+			list = append(list, u)
+		}
+	}
+	// Newest first, then by ID for a stable cursor order
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Height != list[j].Height {
+			return list[i].Height > list[j].Height
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// connection is the page returned by every paginated field
+type connection struct {
+	Nodes      interface{}
+	TotalCount int
+	EndCursor  string
+	HasNext    bool
+}
+
+// paginate slices items using an opaque offset cursor
+func paginate[T any](items []T, p graphql.ResolveParams) (connection, error) {
+	first, _ := p.Args["first"].(int)
+	first = clampPage(first)
+
+	start := 0
+	if after, ok := p.Args["after"].(string); ok && after != "" {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return connection{}, err
+		}
+		start = offset + 1
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + first
+	if end > len(items) {
+		end = len(items)
+	}
+
+	conn := connection{Nodes: items[start:end], TotalCount: len(items), HasNext: end < len(items)}
+	if end > start {
+		conn.EndCursor = encodeCursor(end - 1)
+	}
+	return conn, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(raw), "cursor:") {
+		if n, err := strconv.Atoi(strings.TrimPrefix(string(raw), "cursor:")); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, errors.New("invalid cursor")
+}
+
+var pageArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{Type: graphql.Int, Description: fmt.Sprintf("Page size (default %d, max %d)", graphqlDefaultPage, graphqlMaxPage)},
+	"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "endCursor of the previous page"},
+}
+
+func withPageArgs(extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{}
+	for k, v := range pageArgs {
+		args[k] = v
+	}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+// bigIntScalar carries uint64 amounts and unix timestamps, which overflow the
+// 32-bit GraphQL Int
+var bigIntScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "BigInt",
+	Description: "64-bit integer (amounts, nonces, unix timestamps)",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if valueAST.GetKind() == kinds.IntValue {
+			n, err := strconv.ParseInt(valueAST.GetValue().(string), 10, 64)
+			if err == nil {
+				return n
+			}
+		}
+		return nil
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"endCursor": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if c := p.Source.(connection).EndCursor; c != "" {
+				return c, nil
+			}
+			return nil, nil
+		}},
+		"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(connection).HasNext, nil
+		}},
+	},
+})
+
+func connectionType(name string, node graphql.Type) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Connection",
+		Fields: graphql.Fields{
+			"nodes": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(node))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(connection).Nodes, nil
+			}},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(connection).TotalCount, nil
+			}},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source, nil
+			}},
+		},
+	})
+}
+
+// field builds a non-null field whose value is read from the typed source
+func field[T any](t graphql.Output, get func(T) interface{}) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(t), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(T)), nil
+	}}
+}
+
+// buildGraphQLSchema defines the explorer schema over the server's state
+func (s *Server) buildGraphQLSchema() (graphql.Schema, error) {
+	var blockType, txType, walletType, utxoType *graphql.Object
+	var blockConn, txConn, walletConn, utxoConn *graphql.Object
+
+	walletByID := func(p graphql.ResolveParams, id string) interface{} {
+		if w, ok := s.ws.Get(id); ok && viewFrom(p).visible(id) {
+			return w
+		}
+		return nil
+	}
+
+	utxoType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "UTXO",
+		Description: "Unspent (or spent) transaction output",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":      field(graphql.String, func(u blockchain.UTXO) interface{} { return u.ID }),
+				"ownerId": field(graphql.String, func(u blockchain.UTXO) interface{} { return u.Owner }),
+				"amount":  field(bigIntScalar, func(u blockchain.UTXO) interface{} { return u.Amount }),
+				"index":   field(graphql.Int, func(u blockchain.UTXO) interface{} { return u.Index }),
+				"spent":   field(graphql.Boolean, func(u blockchain.UTXO) interface{} { return u.Spent }),
+				"height":  field(bigIntScalar, func(u blockchain.UTXO) interface{} { return u.Height }),
+				"owner": &graphql.Field{Type: walletType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return walletByID(p, p.Source.(blockchain.UTXO).Owner), nil
+				}},
+				"originTx": &graphql.Field{Type: txType, Description: "Null for faucet grants", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if tx, ok := viewFrom(p).findTx(p.Source.(blockchain.UTXO).OriginTx); ok {
+						return tx, nil
+					}
+					return nil, nil
+				}},
+				"confirmations": &graphql.Field{Type: bigIntScalar, Description: "Null for faucet grants, which are always spendable", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					u := p.Source.(blockchain.UTXO)
+					if u.Height == 0 {
+						return nil, nil
+					}
+					return viewFrom(p).confirmations(u.Height), nil
+				}},
+			}
+		}),
+	})
+
+	txType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":         field(graphql.String, func(t gqlTx) interface{} { return t.tx.ID }),
+				"type":       field(graphql.String, func(t gqlTx) interface{} { return t.tx.Type }),
+				"senderId":   field(graphql.String, func(t gqlTx) interface{} { return t.tx.SenderID }),
+				"receiverId": field(graphql.String, func(t gqlTx) interface{} { return t.tx.ReceiverID }),
+				"amount":     field(bigIntScalar, func(t gqlTx) interface{} { return t.tx.Amount }),
+				"fee":        field(bigIntScalar, func(t gqlTx) interface{} { return t.tx.Fee }),
+				"note":       field(graphql.String, func(t gqlTx) interface{} { return t.tx.Note }),
+				"timestamp":  field(bigIntScalar, func(t gqlTx) interface{} { return t.tx.Timestamp }),
+				"pubKey":     field(graphql.String, func(t gqlTx) interface{} { return t.tx.PubKey }),
+				"signature":  field(graphql.String, func(t gqlTx) interface{} { return t.tx.Signature }),
+				"status": field(graphql.String, func(t gqlTx) interface{} {
+					if t.block == nil {
+						return "pending"
+					}
+					return "confirmed"
+				}),
+				"sender": &graphql.Field{Type: walletType, Description: "Null for system senders such as COINBASE", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return walletByID(p, p.Source.(gqlTx).tx.SenderID), nil
+				}},
+				"receiver": &graphql.Field{Type: walletType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return walletByID(p, p.Source.(gqlTx).tx.ReceiverID), nil
+				}},
+				"block": &graphql.Field{Type: blockType, Description: "Null while pending", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if b := p.Source.(gqlTx).block; b != nil {
+						return *b, nil
+					}
+					return nil, nil
+				}},
+				"confirmations": &graphql.Field{Type: graphql.NewNonNull(bigIntScalar), Description: "0 while pending", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					t := p.Source.(gqlTx)
+					if t.block == nil {
+						return int64(0), nil
+					}
+					return viewFrom(p).confirmations(t.block.Index), nil
+				}},
+				"inputs": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(utxoType))), Description: "Outputs spent by this transaction", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := viewFrom(p)
+					var inputs []blockchain.UTXO
+					for _, in := range p.Source.(gqlTx).tx.Inputs {
+						if u, ok := v.utxos[blockchain.UTXOKey(in.TxID, in.Index)]; ok {
+							inputs = append(inputs, u)
+						}
+					}
+					return inputs, nil
+				}},
+				"outputs": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(utxoType))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					// Outputs stored in blocks carry no ID or spent flag; prefer the UTXO set entry
+					v := viewFrom(p)
+					t := p.Source.(gqlTx)
+					outputs := make([]blockchain.UTXO, len(t.tx.Outputs))
+					for i, o := range t.tx.Outputs {
+						id := blockchain.UTXOKey(t.tx.ID, i)
+						if u, ok := v.utxos[id]; ok {
+							outputs[i] = u
+							continue
+						}
+						o.ID = id
+						outputs[i] = o
+					}
+					return outputs, nil
+				}},
+			}
+		}),
+	})
+
+	blockType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Block",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"index":            field(bigIntScalar, func(b blockchain.Block) interface{} { return b.Index }),
+				"timestamp":        field(bigIntScalar, func(b blockchain.Block) interface{} { return b.Timestamp }),
+				"hash":             field(graphql.String, func(b blockchain.Block) interface{} { return b.Hash }),
+				"previousHash":     field(graphql.String, func(b blockchain.Block) interface{} { return b.PreviousHash }),
+				"merkleRoot":       field(graphql.String, func(b blockchain.Block) interface{} { return b.MerkleRoot }),
+				"nonce":            field(bigIntScalar, func(b blockchain.Block) interface{} { return b.Nonce }),
+				"transactionCount": field(graphql.Int, func(b blockchain.Block) interface{} { return len(b.Transactions) }),
+				"confirmations": &graphql.Field{Type: graphql.NewNonNull(bigIntScalar), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return viewFrom(p).confirmations(p.Source.(blockchain.Block).Index), nil
+				}},
+				"transactions": &graphql.Field{Type: graphql.NewNonNull(txConn), Args: pageArgs, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					b := p.Source.(blockchain.Block)
+					txs := make([]gqlTx, len(b.Transactions))
+					for i, tx := range b.Transactions {
+						txs[i] = gqlTx{tx: tx, block: &b}
+					}
+					return paginate(txs, p)
+				}},
+			}
+		}),
+	})
+
+	walletType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Wallet",
+		Description: "Public wallet information; names, emails and keys are never exposed",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        field(graphql.String, func(w wallet.Wallet) interface{} { return w.WalletID }),
+				"publicKey": field(graphql.String, func(w wallet.Wallet) interface{} { return w.PublicKey }),
+				"type":      field(graphql.String, func(w wallet.Wallet) interface{} { return w.TypeOrDefault() }),
+				"balance": field(bigIntScalar, func(w wallet.Wallet) interface{} {
+					return s.bc.GetBalance(w.WalletID)
+				}),
+				"pendingBalance": field(bigIntScalar, func(w wallet.Wallet) interface{} {
+					return s.bc.GetPendingBalance(w.WalletID)
+				}),
+				"utxos": &graphql.Field{
+					Type: graphql.NewNonNull(utxoConn),
+					Args: withPageArgs(graphql.FieldConfigArgument{
+						"includeSpent": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+					}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						includeSpent, _ := p.Args["includeSpent"].(bool)
+						return paginate(viewFrom(p).walletUTXOs(p.Source.(wallet.Wallet).WalletID, includeSpent), p)
+					},
+				},
+				"transactions": &graphql.Field{Type: graphql.NewNonNull(txConn), Args: pageArgs, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return paginate(viewFrom(p).confirmedTxs(p.Source.(wallet.Wallet).WalletID), p)
+				}},
+			}
+		}),
+	})
+
+	blockConn = connectionType("Block", blockType)
+	txConn = connectionType("Transaction", txType)
+	walletConn = connectionType("Wallet", walletType)
+	utxoConn = connectionType("UTXO", utxoType)
+
+	systemLogType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SystemLog",
+		Fields: graphql.Fields{
+			"id":        field(graphql.Int, func(l services.LogEntry) interface{} { return l.ID }),
+			"eventType": field(graphql.String, func(l services.LogEntry) interface{} { return l.EventType }),
+			"walletId":  field(graphql.String, func(l services.LogEntry) interface{} { return l.WalletID }),
+			"details":   field(graphql.String, func(l services.LogEntry) interface{} { return l.Details }),
+			"requestId": field(graphql.String, func(l services.LogEntry) interface{} { return l.RequestID }),
+			"createdAt": field(graphql.DateTime, func(l services.LogEntry) interface{} { return l.CreatedAt }),
+		},
+	})
+	txLogType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TransactionLog",
+		Fields: graphql.Fields{
+			"id":            field(graphql.Int, func(l services.TransactionLog) interface{} { return l.ID }),
+			"transactionId": field(graphql.String, func(l services.TransactionLog) interface{} { return l.TransactionID }),
+			"action":        field(graphql.String, func(l services.TransactionLog) interface{} { return l.Action }),
+			"walletId":      field(graphql.String, func(l services.TransactionLog) interface{} { return l.WalletID }),
+			"blockHash":     field(graphql.String, func(l services.TransactionLog) interface{} { return l.BlockHash }),
+			"status":        field(graphql.String, func(l services.TransactionLog) interface{} { return l.Status }),
+			"requestId":     field(graphql.String, func(l services.TransactionLog) interface{} { return l.RequestID }),
+			"createdAt":     field(graphql.DateTime, func(l services.TransactionLog) interface{} { return l.CreatedAt }),
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"block": &graphql.Field{
+				Type: blockType,
+				Args: graphql.FieldConfigArgument{"index": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := viewFrom(p)
+					idx := p.Args["index"].(int)
+					if idx < 0 || idx >= len(v.blocks) {
+						return nil, nil
+					}
+					return v.blocks[idx], nil
+				},
+			},
+			"blocks": &graphql.Field{
+				Type:        graphql.NewNonNull(blockConn),
+				Description: "Blocks, newest first",
+				Args:        pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := viewFrom(p)
+					blocks := make([]blockchain.Block, len(v.blocks))
+					for i, b := range v.blocks {
+						blocks[len(v.blocks)-1-i] = b
+					}
+					return paginate(blocks, p)
+				},
+			},
+			"transaction": &graphql.Field{
+				Type: txType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if tx, ok := viewFrom(p).findTx(p.Args["id"].(string)); ok {
+						return tx, nil
+					}
+					return nil, nil
+				},
+			},
+			"transactions": &graphql.Field{
+				Type:        graphql.NewNonNull(txConn),
+				Description: "Confirmed transactions, newest first",
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"wallet": &graphql.ArgumentConfig{Type: graphql.String, Description: "Only transactions sent or received by this wallet"},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					walletID, _ := p.Args["wallet"].(string)
+					return paginate(viewFrom(p).confirmedTxs(walletID), p)
+				},
+			},
+			"pendingTransactions": &graphql.Field{
+				Type: graphql.NewNonNull(txConn),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := viewFrom(p)
+					txs := make([]gqlTx, len(v.pending))
+					for i, tx := range v.pending {
+						txs[i] = gqlTx{tx: tx}
+					}
+					return paginate(txs, p)
+				},
+			},
+			"wallet": &graphql.Field{
+				Type: walletType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return walletByID(p, p.Args["id"].(string)), nil
+				},
+			},
+			"wallets": &graphql.Field{
+				Type: graphql.NewNonNull(walletConn),
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"type": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					walletType, _ := p.Args["type"].(string)
+					var list []wallet.Wallet
+					for _, w := range s.ws.GetAll() {
+						if (walletType == "" || w.TypeOrDefault() == walletType) && viewFrom(p).visible(w.WalletID) {
+							list = append(list, w)
+						}
+					}
+					sort.Slice(list, func(i, j int) bool { return list[i].WalletID < list[j].WalletID })
+					return paginate(list, p)
+				},
+			},
+			"utxos": &graphql.Field{
+				Type: graphql.NewNonNull(utxoConn),
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"owner":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"includeSpent": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					includeSpent, _ := p.Args["includeSpent"].(bool)
+					return paginate(viewFrom(p).walletUTXOs(p.Args["owner"].(string), includeSpent), p)
+				},
+			},
+			"systemLogs": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType("SystemLog", systemLogType)),
+				Description: "System logs, newest first",
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"wallet":    &graphql.ArgumentConfig{Type: graphql.String},
+					"eventType": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					walletID, _ := p.Args["wallet"].(string)
+					eventType, _ := p.Args["eventType"].(string)
+					logs := s.logSvc.GetSystemLogs(0)
+					var list []services.LogEntry
+					for i := len(logs) - 1; i >= 0; i-- {
+						l := logs[i]
+						if (walletID == "" || l.WalletID == walletID) && (eventType == "" || l.EventType == eventType) && viewFrom(p).visible(l.WalletID) {
+							list = append(list, l)
+						}
+					}
+					return paginate(list, p)
+				},
+			},
+			"transactionLogs": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType("TransactionLog", txLogType)),
+				Description: "Transaction logs, newest first",
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"wallet": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					walletID, _ := p.Args["wallet"].(string)
+					logs := s.logSvc.GetTransactionLogs(walletID, 0)
+					list := make([]services.TransactionLog, 0, len(logs))
+					for i := len(logs) - 1; i >= 0; i-- {
+						if viewFrom(p).visible(logs[i].WalletID) {
+							list = append(list, logs[i])
+						}
+					}
+					return paginate(list, p)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
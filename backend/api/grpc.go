@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/proto/walletpb"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// GRPCServer returns a gRPC server exposing the wallet, transaction, mining and
+// block services over the same chain, wallet store and services as the REST
+// API. The standard health service and server reflection are registered too.
+func (s *Server) GRPCServer() *grpc.Server {
+	gs := grpc.NewServer(grpc.UnaryInterceptor(s.grpcRequestID))
+	walletpb.RegisterWalletServiceServer(gs, &grpcWallets{s: s})
+	walletpb.RegisterTransactionServiceServer(gs, &grpcTransactions{s: s})
+	walletpb.RegisterMiningServiceServer(gs, &grpcMining{s: s})
+	walletpb.RegisterBlockServiceServer(gs, &grpcBlocks{s: s})
+
+	hs := health.NewServer()
+	for name := range gs.GetServiceInfo() {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(gs, hs)
+	reflection.Register(gs)
+	return gs
+}
+
+// grpcRequestID is the gRPC counterpart of the requestID middleware: it honours
+// an incoming x-request-id, returns it as response metadata and logs the call
+func (s *Server) grpcRequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var id, device, userAgent string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			id = ids[0]
+		}
+		if v := md.Get("x-device-fingerprint"); len(v) > 0 {
+			device = v[0]
+		}
+		if v := md.Get("user-agent"); len(v) > 0 {
+			userAgent = v[0]
+		}
+	}
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+
+	start := time.Now()
+	var resp interface{}
+	ctx = services.WithDevice(services.WithRequestID(ctx, id), deviceFingerprint(device, userAgent))
+	ctx, err := s.grpcTenant(ctx, info.FullMethod)
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+
+	slog.Info("grpc request",
+		"method", info.FullMethod,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"remote_addr", remoteAddr(ctx),
+		"request_id", id,
+	)
+	return resp, err
+}
+
+// grpcTenant is the gRPC counterpart of tenantScope: in multi-tenant mode the
+// wallet services are scoped to the organization of the login session in
+// authorization metadata, picked by x-org-id when it has several
+func (s *Server) grpcTenant(ctx context.Context, method string) (context.Context, error) {
+	if !s.orgs.Enabled() || !strings.HasPrefix(method, "/wallet.v1.") {
+		return ctx, nil
+	}
+	var orgID, token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-org-id"); len(v) > 0 {
+			orgID = v[0]
+		}
+		if v := md.Get("authorization"); len(v) > 0 {
+			token, _ = strings.CutPrefix(v[0], "Bearer ")
+		}
+	}
+	orgID, err := s.requestOrg(false, strings.TrimSpace(token), orgID, method == "/wallet.v1.WalletService/CreateWallet")
+	if err != nil {
+		return ctx, grpcError(err)
+	}
+	return services.WithOrg(ctx, orgID), nil
+}
+
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// grpcError converts an operation error to a gRPC status, mapping the API
+// error code's HTTP status to the closest gRPC code
+func grpcError(err error) error {
+	code := errorCode(err)
+	grpcCode := codes.Internal
+	switch errorCatalog[code].Status {
+	case http.StatusBadRequest:
+		grpcCode = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		grpcCode = codes.Unauthenticated
+	case http.StatusForbidden:
+		grpcCode = codes.PermissionDenied
+	case http.StatusNotFound:
+		grpcCode = codes.NotFound
+	case http.StatusConflict:
+		grpcCode = codes.AlreadyExists
+	case http.StatusServiceUnavailable:
+		grpcCode = codes.Unavailable
+	case http.StatusRequestTimeout:
+		grpcCode = codes.Canceled
+	}
+	st := status.New(grpcCode, string(code)+": "+err.Error())
+
+	// Invalid fields travel as a google.rpc.BadRequest detail
+	var oe *opError
+	if errors.As(err, &oe) && len(oe.fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, fe := range oe.fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+		}
+		if detailed, derr := st.WithDetails(br); derr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+type grpcWallets struct {
+	walletpb.UnimplementedWalletServiceServer
+	s *Server
+}
+
+func (g *grpcWallets) GenerateKeypair(ctx context.Context, _ *walletpb.GenerateKeypairRequest) (*walletpb.GenerateKeypairResponse, error) {
+	pub, priv := wallet.GenerateKeypair()
+	g.s.logSvc.LogSystemCtx(ctx, "keypair_generated", "", remoteAddr(ctx), "New keypair generated")
+	return &walletpb.GenerateKeypairResponse{Public: pub, Private: priv}, nil
+}
+
+func (g *grpcWallets) CreateWallet(ctx context.Context, req *walletpb.CreateWalletRequest) (*walletpb.Wallet, error) {
+	// A login session proving the email travels as x-session-token metadata
+	var sessionToken string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-session-token"); len(v) > 0 {
+			sessionToken = v[0]
+		}
+	}
+
+	wobj, err := g.s.createWallet(ctx, createWalletInput{
+		Public:       req.GetPublic(),
+		Private:      req.GetPrivate(),
+		Name:         req.GetName(),
+		Email:        req.GetEmail(),
+		CNIC:         req.GetCnic(),
+		Type:         req.GetType(),
+		SessionToken: sessionToken,
+	}, remoteAddr(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbWallet(wobj), nil
+}
+
+func (g *grpcWallets) GetWallet(ctx context.Context, req *walletpb.GetWalletRequest) (*walletpb.Wallet, error) {
+	wobj, ok := g.s.ws.Get(req.GetWalletId())
+	if !ok || !g.s.inOrg(ctx, req.GetWalletId()) {
+		return nil, grpcError(fail(CodeWalletNotFound, "Wallet not found"))
+	}
+	return pbWallet(wobj), nil
+}
+
+func (g *grpcWallets) GetBalance(ctx context.Context, req *walletpb.GetBalanceRequest) (*walletpb.GetBalanceResponse, error) {
+	if !g.s.inOrg(ctx, req.GetWalletId()) {
+		return nil, grpcError(fail(CodeWalletNotFound, "Wallet not found"))
+	}
+	return &walletpb.GetBalanceResponse{
+		WalletId: req.GetWalletId(),
+		Balance:  g.s.bc.GetBalance(req.GetWalletId()),
+	}, nil
+}
+
+func (g *grpcWallets) ListUTXOs(ctx context.Context, req *walletpb.ListUTXOsRequest) (*walletpb.ListUTXOsResponse, error) {
+	if !g.s.inOrg(ctx, req.GetWalletId()) {
+		return nil, grpcError(fail(CodeWalletNotFound, "Wallet not found"))
+	}
+	resp := &walletpb.ListUTXOsResponse{}
+	for _, utxo := range g.s.bc.GetWalletUTXOs(req.GetWalletId()) {
+		if !utxo.Spent {
+			resp.Utxos = append(resp.Utxos, pbUTXO(utxo))
+		}
+	}
+	return resp, nil
+}
+
+type grpcTransactions struct {
+	walletpb.UnimplementedTransactionServiceServer
+	s *Server
+}
+
+func (g *grpcTransactions) Send(ctx context.Context, req *walletpb.SendRequest) (*walletpb.SendResponse, error) {
+	// The authenticator code for 2FA-protected sends travels as x-totp-code
+	// metadata, a signing session token as x-signing-token and the emailed
+	// code confirming a send over the spending limits as x-limit-otp
+	var totpCode, signingToken, limitOTP string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-totp-code"); len(v) > 0 {
+			totpCode = v[0]
+		}
+		if v := md.Get("x-signing-token"); len(v) > 0 {
+			signingToken = v[0]
+		}
+		if v := md.Get("x-limit-otp"); len(v) > 0 {
+			limitOTP = v[0]
+		}
+	}
+
+	tx, err := g.s.sendTransaction(ctx, sendInput{
+		SenderID:      req.GetSenderId(),
+		ReceiverID:    req.GetReceiverId(),
+		ReceiverAlias: req.GetReceiverAlias(),
+		Amount:        req.GetAmount(),
+		Note:          req.GetNote(),
+		SigningToken:  signingToken,
+		PrivateKey:    req.GetPrivateKey(),
+		TOTPCode:      totpCode,
+		LimitOTP:      limitOTP,
+	}, remoteAddr(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &walletpb.SendResponse{Txid: tx.ID, Status: "pending"}, nil
+}
+
+func (g *grpcTransactions) ListTransactions(ctx context.Context, _ *walletpb.ListTransactionsRequest) (*walletpb.ListTransactionsResponse, error) {
+	resp := &walletpb.ListTransactionsResponse{}
+	for _, block := range g.s.bc.GetChain() {
+		for _, tx := range g.s.scopeTxs(ctx, block.Transactions) {
+			resp.Transactions = append(resp.Transactions, pbTransaction(tx))
+		}
+	}
+	return resp, nil
+}
+
+func (g *grpcTransactions) ListPending(ctx context.Context, _ *walletpb.ListPendingRequest) (*walletpb.ListTransactionsResponse, error) {
+	resp := &walletpb.ListTransactionsResponse{}
+	for _, tx := range g.s.scopeTxs(ctx, g.s.bc.GetPending()) {
+		resp.Transactions = append(resp.Transactions, pbTransaction(tx))
+	}
+	return resp, nil
+}
+
+type grpcMining struct {
+	walletpb.UnimplementedMiningServiceServer
+	s *Server
+}
+
+// Mine carries no signing token, so under proof of authority, where blocks
+// must be signed by their validator, it is refused; POST /api/mine takes one
+func (g *grpcMining) Mine(ctx context.Context, req *walletpb.MineRequest) (*walletpb.Block, error) {
+	blk, err := g.s.mineBlock(ctx, req.GetMinerWalletId(), req.GetStart(), remoteAddr(ctx), nil)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbBlock(blk), nil
+}
+
+type grpcBlocks struct {
+	walletpb.UnimplementedBlockServiceServer
+	s *Server
+}
+
+func (g *grpcBlocks) ListBlocks(ctx context.Context, _ *walletpb.ListBlocksRequest) (*walletpb.ListBlocksResponse, error) {
+	resp := &walletpb.ListBlocksResponse{}
+	for _, b := range g.s.bc.GetChain() {
+		resp.Blocks = append(resp.Blocks, pbBlock(g.s.scopeBlock(ctx, b)))
+	}
+	return resp, nil
+}
+
+func (g *grpcBlocks) GetBlock(ctx context.Context, req *walletpb.GetBlockRequest) (*walletpb.Block, error) {
+	b, ok := g.s.bc.GetBlockByIndex(req.GetIndex())
+	if !ok {
+		return nil, grpcError(fail(CodeNotFound, "Block not found"))
+	}
+	return pbBlock(g.s.scopeBlock(ctx, b)), nil
+}
+
+func pbWallet(w wallet.Wallet) *walletpb.Wallet {
+	return &walletpb.Wallet{
+		WalletId:  w.WalletID,
+		PublicKey: w.PublicKey,
+		FullName:  w.FullName,
+		Email:     w.Email,
+		Type:      w.TypeOrDefault(),
+	}
+}
+
+func pbUTXO(u blockchain.UTXO) *walletpb.UTXO {
+	return &walletpb.UTXO{
+		Id:       u.ID,
+		Owner:    u.Owner,
+		Amount:   u.Amount,
+		OriginTx: u.OriginTx,
+		Index:    int32(u.Index),
+		Spent:    u.Spent,
+	}
+}
+
+func pbTransaction(tx blockchain.Transaction) *walletpb.Transaction {
+	out := &walletpb.Transaction{
+		Id:         tx.ID,
+		SenderId:   tx.SenderID,
+		ReceiverId: tx.ReceiverID,
+		Amount:     tx.Amount,
+		Fee:        tx.Fee,
+		Note:       tx.Note,
+		Timestamp:  tx.Timestamp,
+		Pubkey:     tx.PubKey,
+		Signature:  tx.Signature,
+		Type:       tx.Type,
+	}
+	for _, in := range tx.Inputs {
+		out.Inputs = append(out.Inputs, &walletpb.UTXORef{Txid: in.TxID, Index: int32(in.Index)})
+	}
+	for _, o := range tx.Outputs {
+		out.Outputs = append(out.Outputs, pbUTXO(o))
+	}
+	return out
+}
+
+func pbBlock(b blockchain.Block) *walletpb.Block {
+	out := &walletpb.Block{
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		PreviousHash: b.PreviousHash,
+		Nonce:        b.Nonce,
+		Hash:         b.Hash,
+		MerkleRoot:   b.MerkleRoot,
+	}
+	for _, tx := range b.Transactions {
+		out.Transactions = append(out.Transactions, pbTransaction(tx))
+	}
+	return out
+}
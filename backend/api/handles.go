@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// Handles are global @names for wallets; see services.HandleService. Sends
+// take one as receiver_id, written with its "@".
+
+// handleClaimHandle gives the wallet a handle, replacing its current one
+func (s *Server) handleClaimHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ClaimHandleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	wlt, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	if !wlt.Active() {
+		Error(w, r, CodeWalletInactive, "Wallet is "+wlt.Status)
+		return
+	}
+
+	previous := s.handles.Handle(req.WalletID)
+	claim, err := s.handles.Claim(req.WalletID, req.Handle)
+	if err != nil {
+		writeHandleError(w, r, err)
+		return
+	}
+	if claim.Handle != previous {
+		detail := "Claimed @" + claim.Handle
+		if previous != "" {
+			detail = fmt.Sprintf("Changed @%s to @%s", previous, claim.Handle)
+		}
+		s.logSvc.LogSystemCtx(r.Context(), "handle_claimed", req.WalletID, r.RemoteAddr, detail)
+	}
+	json.NewEncoder(w).Encode(claim)
+}
+
+// handleReleaseHandle gives up the wallet's handle
+func (s *Server) handleReleaseHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ReleaseHandleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	claim, err := s.handles.Release(req.WalletID)
+	if err != nil {
+		writeHandleError(w, r, err)
+		return
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "handle_released", req.WalletID, r.RemoteAddr, "Released @"+claim.Handle)
+	json.NewEncoder(w).Encode(claim)
+}
+
+// handleResolveHandle returns the wallet holding a handle
+func (s *Server) handleResolveHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID, ok := s.handles.Resolve(mux.Vars(r)["handle"])
+	if !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeHandleNotFound, "No wallet has that handle")
+		return
+	}
+	json.NewEncoder(w).Encode(HandleResponse{Handle: s.handles.Handle(walletID), WalletID: walletID})
+}
+
+// handleWalletHandle returns a wallet's handle and every handle it held
+func (s *Server) handleWalletHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	resp := WalletHandleResponse{WalletID: walletID, Handle: s.handles.Handle(walletID), History: s.handles.History(walletID)}
+	if resp.History == nil {
+		resp.History = []services.HandleClaim{}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeHandleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidHandle):
+		Error(w, r, CodeValidationFailed, err.Error())
+	case errors.Is(err, services.ErrHandleReserved):
+		Error(w, r, CodeHandleReserved, err.Error())
+	case errors.Is(err, services.ErrHandleTaken):
+		Error(w, r, CodeHandleTaken, err.Error())
+	case errors.Is(err, services.ErrHandleNotFound):
+		Error(w, r, CodeHandleNotFound, "The wallet has no handle")
+	default:
+		Error(w, r, CodeInternal, "Failed to save the handle")
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Readiness check outcomes. A down check makes the node unready; a degraded
+// one is reported but the node keeps serving.
+const (
+	CheckOK       = "ok"
+	CheckDegraded = "degraded"
+	CheckDown     = "down"
+	CheckSkipped  = "skipped"
+)
+
+// readinessPingTimeout bounds the database ping of a readiness check
+const readinessPingTimeout = 2 * time.Second
+
+// zakatTickGrace is how long past its tick the zakat scheduler may be before
+// it counts as stalled
+const zakatTickGrace = time.Minute
+
+// ReadinessLimits are the chain thresholds past which readiness reports the
+// chain degraded; zero disables a threshold
+type ReadinessLimits struct {
+	MaxBlockAge      time.Duration // since the last block was mined
+	MempoolThreshold int           // pending transactions
+}
+
+// SetReadinessLimits sets the chain thresholds of GET /readyz
+func (s *Server) SetReadinessLimits(l ReadinessLimits) {
+	s.readyLimits = l
+}
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// readiness checks each dependency the node needs to serve requests
+func (s *Server) readiness(ctx context.Context) ReadinessResponse {
+	checks := []HealthCheck{
+		s.checkDatabase(ctx),
+		s.checkChain(),
+		s.checkZakatScheduler(),
+		// There is no P2P networking yet; the check is listed so clients
+		// need no change once peers are added
+		{Name: "peers", Status: CheckSkipped, Detail: "P2P networking is not enabled"},
+	}
+
+	resp := ReadinessResponse{Status: CheckOK, CheckedAt: time.Now().UTC(), Checks: checks}
+	for _, c := range checks {
+		switch {
+		case c.Status == CheckDown:
+			resp.Status = CheckDown
+		case c.Status == CheckDegraded && resp.Status == CheckOK:
+			resp.Status = CheckDegraded
+		}
+	}
+	return resp
+}
+
+func (s *Server) checkDatabase(ctx context.Context) HealthCheck {
+	c := HealthCheck{Name: "database", Status: CheckOK}
+	p, ok := s.store.(pinger)
+	if !ok {
+		c.Detail = "in-memory mode"
+		return c
+	}
+	ctx, cancel := context.WithTimeout(ctx, readinessPingTimeout)
+	defer cancel()
+	if err := p.Ping(ctx); err != nil {
+		c.Status, c.Detail = CheckDown, fmt.Sprintf("ping failed: %v", err)
+		return c
+	}
+	c.Detail = "ping ok"
+	return c
+}
+
+// checkChain reports the mempool depth and how long ago a block was mined.
+// Blocks are mined on request, so neither makes the node unready.
+func (s *Server) checkChain() HealthCheck {
+	c := HealthCheck{Name: "chain", Status: CheckOK}
+	last := s.bc.LastBlock()
+	age := time.Since(time.Unix(last.Timestamp, 0)).Truncate(time.Second)
+	pending := s.bc.MempoolStats().Pending
+	c.Detail = fmt.Sprintf("height %d, last block mined %s ago, %d pending transactions", last.Index, age, pending)
+
+	if limit := s.readyLimits.MempoolThreshold; limit > 0 && pending > limit {
+		c.Status = CheckDegraded
+		c.Detail += fmt.Sprintf(" (more than %d)", limit)
+	}
+	if limit := s.readyLimits.MaxBlockAge; limit > 0 && age > limit {
+		c.Status = CheckDegraded
+		c.Detail += fmt.Sprintf("; no block for more than %s", limit)
+	}
+	return c
+}
+
+func (s *Server) checkZakatScheduler() HealthCheck {
+	c := HealthCheck{Name: "zakat_scheduler", Status: CheckOK}
+	next, running := s.zakat.NextRun()
+	switch {
+	case !running:
+		c.Status, c.Detail = CheckDown, "scheduler is not running"
+	case time.Now().After(next.Add(zakatTickGrace)):
+		c.Status, c.Detail = CheckDown, fmt.Sprintf("scheduler stalled: run was due at %s", next.UTC().Format(time.RFC3339))
+	default:
+		c.Detail = fmt.Sprintf("next run at %s", next.UTC().Format(time.RFC3339))
+	}
+	if run, ok := s.zakat.LastRun(); ok && run.Failed > 0 && c.Status == CheckOK {
+		c.Status = CheckDegraded
+		c.Detail += fmt.Sprintf("; last run had %d failures", run.Failed)
+	}
+	return c
+}
+
+// handleLivez reports that the process is up and serving requests; it
+// checks no dependency, so a restart is only warranted when it fails
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+}
+
+// handleReadyz reports whether the node can serve requests, with the outcome
+// of each dependency check, and 503 when any check is down
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	resp := s.readiness(r.Context())
+	if resp.Status == CheckDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
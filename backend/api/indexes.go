@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// handleIndexReport returns the index advisor report: required composite
+// indexes, tables dominated by sequential scans and slow statements
+func (s *Server) handleIndexReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.db == nil {
+		Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := s.db.IndexReport(ctx)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to build index report: "+err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// ensureIndexesTimeout bounds handleEnsureIndexes in place of the server's
+// WriteTimeout, as index builds can take a while on large tables
+const ensureIndexesTimeout = time.Minute
+
+// handleEnsureIndexes creates any missing required index on demand
+func (s *Server) handleEnsureIndexes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.db == nil {
+		Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+		return
+	}
+
+	created, err := s.db.EnsureIndexes(r.Context())
+	if err != nil {
+		Error(w, r, CodeInternal, err.Error())
+		return
+	}
+	if created == nil {
+		created = []string{}
+	}
+
+	if len(created) > 0 {
+		s.logSvc.LogSystemCtx(r.Context(), "indexes_created", "", r.RemoteAddr, strings.Join(created, ", "))
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created":  created,
+		"required": len(database.RequiredIndexes),
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// A beneficiary with a share is one of its user's nominees. A wallet with an
+// inactivity rule that sends nothing for that many months gets a payout filed
+// for admin review; approving it splits the balance between the nominees.
+
+// shareFits checks that giving a beneficiary the share keeps the user's
+// shares within 100%, answering VALIDATION_FAILED when it does not
+func (s *Server) shareFits(ctx context.Context, w http.ResponseWriter, r *http.Request, userID, beneficiaryID int64, share int) bool {
+	others, err := s.db.BeneficiaryShareTotal(ctx, userID, beneficiaryID)
+	if err != nil {
+		Error(w, r, CodeInternal, err.Error())
+		return false
+	}
+	if others+share > 100 {
+		Error(w, r, CodeValidationFailed, fmt.Sprintf("Shares would total %d%%; other beneficiaries already hold %d%%", others+share, others))
+		return false
+	}
+	return true
+}
+
+// handleGetInheritance returns the wallet's inactivity rule, nominees and
+// payouts
+func (s *Server) handleGetInheritance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if _, exists := s.ws.Get(walletID); !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	nominees, err := s.inheritance.Nominees(r.Context(), walletID)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to load nominees")
+		return
+	}
+	json.NewEncoder(w).Encode(s.inheritanceResponse(walletID, nominees))
+}
+
+// handleSetInheritance sets or removes the wallet's inactivity rule at its
+// owner's request
+func (s *Server) handleSetInheritance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req InheritanceRuleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	walletID := mux.Vars(r)["wallet"]
+	wlt, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	if !wlt.Active() {
+		Error(w, r, CodeWalletInactive, "Wallet is "+wlt.Status)
+		return
+	}
+	if _, _, err := s.resolveSigner(r.Context(), wlt, req.SigningToken, req.PrivateKey, r.RemoteAddr); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	if s.db == nil {
+		Error(w, r, CodeDatabaseUnavailable, "Nominees need the database")
+		return
+	}
+
+	nominees, err := s.inheritance.Nominees(r.Context(), walletID)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to load nominees")
+		return
+	}
+	if req.InactivityMonths > 0 && len(nominees) == 0 {
+		Error(w, r, CodeValidationFailed, "Give at least one beneficiary a share_percent before setting a rule")
+		return
+	}
+	if _, err := s.inheritance.SetRule(walletID, req.InactivityMonths); err != nil {
+		s.writeInheritanceError(w, r, err)
+		return
+	}
+
+	detail := "Inheritance rule removed"
+	if req.InactivityMonths > 0 {
+		detail = fmt.Sprintf("Nominees paid after %d months without activity", req.InactivityMonths)
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "inheritance_rule_set", walletID, r.RemoteAddr, detail)
+	json.NewEncoder(w).Encode(s.inheritanceResponse(walletID, nominees))
+}
+
+func (s *Server) inheritanceResponse(walletID string, nominees []services.Nominee) InheritanceResponse {
+	resp := InheritanceResponse{
+		WalletID: walletID,
+		Nominees: nominees,
+		Payouts:  s.inheritance.List("", walletID),
+	}
+	if rule, ok := s.inheritance.Rule(walletID); ok {
+		last := s.inheritance.LastActivity(walletID)
+		eligible := last.AddDate(0, rule.InactivityMonths, 0)
+		resp.Rule, resp.LastActivity, resp.EligibleAt = &rule, &last, &eligible
+	}
+	return resp
+}
+
+// handleListInheritancePayouts lists inheritance payouts, pending ones by
+// default
+func (s *Server) handleListInheritancePayouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = services.PayoutPending
+	} else if status == "all" {
+		status = ""
+	}
+
+	json.NewEncoder(w).Encode(s.inheritance.List(status, r.URL.Query().Get("wallet_id")))
+}
+
+// handleDecideInheritancePayout approves or rejects a pending payout
+func (s *Server) handleDecideInheritancePayout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid payout ID")
+		return
+	}
+	var req InheritanceDecisionRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var payout *services.InheritancePayout
+	if vars["decision"] == "approve" {
+		payout, err = s.inheritance.Approve(id, s.adminActor(r))
+	} else {
+		payout, err = s.inheritance.Reject(id, s.adminActor(r), req.Reason)
+	}
+	if err != nil {
+		s.writeInheritanceError(w, r, err)
+		return
+	}
+
+	detail := "by " + payout.DecidedBy
+	if payout.SplitTxID != "" {
+		detail += fmt.Sprintf("; %d split in %s", payout.Balance, payout.SplitTxID)
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "inheritance_payout_"+payout.Status, payout.WalletID, r.RemoteAddr, detail)
+	json.NewEncoder(w).Encode(payout)
+}
+
+// handleRunInheritanceCheck files and pays out payouts now instead of
+// waiting for the checker's next run
+func (s *Server) handleRunInheritanceCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	run := s.inheritance.Check()
+	s.logSvc.LogSystemCtx(r.Context(), "inheritance_check", "", r.RemoteAddr, fmt.Sprintf("%d filed, %d paid by %s", run.Filed, run.Paid, s.adminActor(r)))
+	json.NewEncoder(w).Encode(run)
+}
+
+func (s *Server) writeInheritanceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+	case errors.Is(err, services.ErrPayoutNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrPayoutDecided):
+		Error(w, r, CodeAlreadyDecided, err.Error())
+	case errors.Is(err, services.ErrOwnerActive), errors.Is(err, services.ErrNothingToPay):
+		Error(w, r, CodePayoutCancelled, err.Error())
+	case errors.Is(err, services.ErrNoNominees), errors.Is(err, services.ErrInvalidMonths):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
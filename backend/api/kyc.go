@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// handleGetKYC reports a wallet's KYC status, its submissions and what it may
+// still send today
+func (s *Server) handleGetKYC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	resp := KYCStatusResponse{
+		WalletID:    walletID,
+		Verified:    s.kyc.Verified(walletID),
+		SentToday:   s.txSvc.SentToday(walletID),
+		Submissions: s.kyc.History(walletID),
+	}
+	if limit := s.kyc.DailyLimit(); !resp.Verified && limit > 0 {
+		remaining := uint64(0)
+		if resp.SentToday < limit {
+			remaining = limit - resp.SentToday
+		}
+		resp.DailyLimit, resp.Remaining = limit, &remaining
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSubmitKYC files a CNIC and a document reference for admin review
+func (s *Server) handleSubmitKYC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req KYCSubmitRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	sub, err := s.kyc.Submit(walletID, req.CNIC, req.DocumentType, req.DocumentRef)
+	if err != nil {
+		s.writeKYCError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "kyc_submitted", walletID, r.RemoteAddr, req.DocumentType)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// handleListPendingKYC lists submissions awaiting review, newest first
+func (s *Server) handleListPendingKYC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.kyc.List(services.KYCPending))
+}
+
+// handleReviewKYC approves or rejects a pending submission
+func (s *Server) handleReviewKYC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid submission ID")
+		return
+	}
+	var req KYCReviewRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var sub *services.KYCSubmission
+	if vars["decision"] == "approve" {
+		sub, err = s.kyc.Approve(id, s.adminActor(r), req.Note)
+	} else {
+		sub, err = s.kyc.Reject(id, s.adminActor(r), req.Note)
+	}
+	if err != nil {
+		s.writeKYCError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "kyc_"+sub.Status, sub.WalletID, r.RemoteAddr, "by "+sub.ReviewedBy)
+	json.NewEncoder(w).Encode(sub)
+}
+
+func (s *Server) writeKYCError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+	case errors.Is(err, services.ErrKYCNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrKYCPending):
+		Error(w, r, CodeKYCPending, err.Error())
+	case errors.Is(err, services.ErrKYCAlreadyVerified):
+		Error(w, r, CodeKYCVerified, err.Error())
+	case errors.Is(err, services.ErrKYCDecided):
+		Error(w, r, CodeAlreadyDecided, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
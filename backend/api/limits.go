@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/otp"
+)
+
+// handleGetLimits reports a wallet's spending limits and what it sent in the
+// last 24 hours
+func (s *Server) handleGetLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	wlt, ok := s.ws.Get(walletID)
+	if !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	json.NewEncoder(w).Encode(s.limitsResponse(wlt.WalletID, SpendingLimits{MaxTxAmount: wlt.MaxTxAmount, MaxDailyAmount: wlt.MaxDailyAmount}))
+}
+
+// handleSetLimits lets the owner change the wallet's spending limits.
+// Tightening a limit applies at once; raising or removing one needs an
+// emailed one-time code, so a leaked key alone cannot lift the limits.
+func (s *Server) handleSetLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req SetLimitsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	wlt, err := s.verifyWalletKey(r.Context(), walletID, req.PrivateKey)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	if loosens(wlt.MaxTxAmount, req.MaxTxAmount) || loosens(wlt.MaxDailyAmount, req.MaxDailyAmount) {
+		if wlt.Email == "" {
+			Error(w, r, CodeValidationFailed, "The wallet has no email for a one-time code; ask an administrator to change the limits")
+			return
+		}
+		if req.OTPCode == "" || !otp.VerifyOTP(wlt.Email, req.OTPCode) {
+			s.logSvc.LogSystemCtx(r.Context(), "spending_limits_denied", walletID, r.RemoteAddr, "Invalid or missing OTP")
+			Error(w, r, CodeInvalidOTP, "Raising or removing a limit needs a valid otp_code; request one with POST /api/otp/send")
+			return
+		}
+		otp.ClearOTP(wlt.Email)
+	}
+	s.setLimits(w, r, walletID, req.SpendingLimits, "owner")
+}
+
+// handleAdminSetLimits sets a wallet's spending limits without owner proof
+func (s *Server) handleAdminSetLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req SpendingLimits
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, ok := s.ws.Get(walletID); !ok {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	s.setLimits(w, r, walletID, req, s.adminActor(r))
+}
+
+// loosens reports whether changing a limit from old to new lets more through
+func loosens(old, new uint64) bool {
+	return old > 0 && (new == 0 || new > old)
+}
+
+func (s *Server) setLimits(w http.ResponseWriter, r *http.Request, walletID string, limits SpendingLimits, actor string) {
+	// Persist first so a restart cannot silently drop a limit
+	if s.store != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		if err := s.store.UpdateWalletLimits(ctx, walletID, limits.MaxTxAmount, limits.MaxDailyAmount); err != nil {
+			Error(w, r, CodeInternal, "Failed to save spending limits")
+			return
+		}
+	}
+	if err := s.ws.SetLimits(walletID, limits.MaxTxAmount, limits.MaxDailyAmount); err != nil {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "spending_limits_changed", walletID, r.RemoteAddr,
+		fmt.Sprintf("max_tx_amount=%d max_daily_amount=%d (by %s)", limits.MaxTxAmount, limits.MaxDailyAmount, actor))
+	json.NewEncoder(w).Encode(s.limitsResponse(walletID, limits))
+}
+
+func (s *Server) limitsResponse(walletID string, limits SpendingLimits) SpendingLimitsResponse {
+	resp := SpendingLimitsResponse{
+		WalletID:       walletID,
+		SpendingLimits: limits,
+		Sent24h:        s.txSvc.SentSince(walletID, time.Now().Add(-24*time.Hour)),
+	}
+	if limits.MaxDailyAmount > 0 {
+		remaining := uint64(0)
+		if resp.Sent24h < limits.MaxDailyAmount {
+			remaining = limits.MaxDailyAmount - resp.Sent24h
+		}
+		resp.Remaining = &remaining
+	}
+	return resp
+}
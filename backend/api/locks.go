@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+)
+
+// Sends may lock the receiver's output with lock_time, hash_lock or both; see
+// blockchain.Lock. Time-locked outputs become ordinary coins once their time
+// passes. Hash-locked ones stay locked until their owner claims them here with
+// the preimage, or their sender refunds them after the lock time.
+
+// handleUnlock queues a claim or refund of a hash-locked output, paying it to
+// the calling wallet less the transfer fee
+func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req UnlockRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	vars := mux.Vars(r)
+	wlt, exists := s.ws.Get(req.WalletID)
+	if !exists || !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	privateKey, session, err := s.resolveSigner(r.Context(), wlt, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	var tx *blockchain.Transaction
+	if vars["action"] == "claim" {
+		tx, err = s.txSvc.CreateClaimTransaction(req.WalletID, vars["id"], req.Preimage, wlt.PublicKey, privateKey)
+	} else {
+		tx, err = s.txSvc.CreateRefundTransaction(req.WalletID, vars["id"], wlt.PublicKey, privateKey)
+	}
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "unlock_failed", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+	if err := s.txSvc.ValidateTransaction(r.Context(), tx); err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "transaction_validation_failed", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+		return
+	}
+
+	// Only the fee leaves the wallet, so only it counts against the session
+	if session != nil {
+		if _, err := s.signing.Reserve(req.SigningToken, req.WalletID, tx.Fee); err != nil {
+			writeOpError(w, r, signingError(err))
+			return
+		}
+	}
+
+	if err := s.queueTransaction(r.Context(), tx, r.RemoteAddr); err != nil {
+		if session != nil {
+			s.signing.Release(req.SigningToken, tx.Fee)
+		}
+		writeOpError(w, r, err)
+		return
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "output_unlocked", req.WalletID, r.RemoteAddr, fmt.Sprintf("%s of %s", tx.Type, vars["id"]))
+
+	json.NewEncoder(w).Encode(UnlockResponse{TxID: tx.ID, Amount: tx.Amount, Fee: tx.Fee})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blockchain-backend/database"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// logExportPageTimeout is how long the client has to take each page of an
+// export. The write deadline moves forward with every page, so an export may
+// run far past the server's WriteTimeout while a stalled client is still cut off.
+const logExportPageTimeout = 30 * time.Second
+
+var (
+	systemLogColumns      = []string{"id", "created_at", "event_type", "wallet_id", "ip_address", "details", "request_id", "country", "city", "device"}
+	transactionLogColumns = []string{"id", "created_at", "transaction_id", "action", "wallet_id", "block_hash", "status", "ip_address", "request_id"}
+)
+
+// parseExportTime accepts RFC 3339 timestamps or plain dates (midnight UTC)
+func parseExportTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// logExportWriter writes CSV records or NDJSON lines. Nothing reaches the
+// client until the first flush, so a failure on the first page can still be
+// answered with a JSON error.
+type logExportWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	buf     *bufio.Writer
+	csv     *csv.Writer // nil for NDJSON
+	enc     *json.Encoder
+	rows    int
+	flushed bool
+}
+
+func (e *logExportWriter) write(record []string, v interface{}) error {
+	e.rows++
+	if e.csv != nil {
+		return e.csv.Write(record)
+	}
+	return e.enc.Encode(v)
+}
+
+// flush sends a page and gives the client another logExportPageTimeout
+func (e *logExportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := e.buf.Flush(); err != nil {
+		return err
+	}
+	e.flushed = true
+	if err := e.rc.Flush(); err != nil {
+		return err
+	}
+	return e.rc.SetWriteDeadline(time.Now().Add(logExportPageTimeout))
+}
+
+// handleExportLogs streams the full system or transaction log as CSV or
+// NDJSON for audits, page by page, instead of the newest 100 entries
+func (s *Server) handleExportLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	logType := q.Get("type")
+	if errs.Required("type", logType) && logType != "system" && logType != "tx" {
+		errs.Add("type", "must be system or tx")
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "csv" && format != "ndjson" {
+		errs.Add("format", "must be csv or ndjson")
+	}
+	var filter database.LogFilter
+	if v := q.Get("from"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			errs.Add("from", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.From = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			errs.Add("to", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.To = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		errs.Add("to", "must be after from")
+	}
+	if filter.WalletID = q.Get("wallet"); filter.WalletID != "" {
+		checkWalletID(&errs, "wallet", filter.WalletID)
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	e := &logExportWriter{w: w, rc: rc, buf: bufio.NewWriterSize(w, 64<<10)}
+	filename := fmt.Sprintf("%s-logs-%s.%s", logType, time.Now().UTC().Format("20060102T150405Z"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.csv = csv.NewWriter(e.buf)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		e.enc = json.NewEncoder(e.buf)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	rc.SetWriteDeadline(time.Now().Add(logExportPageTimeout))
+
+	var err error
+	if logType == "system" {
+		if e.csv != nil {
+			e.csv.Write(systemLogColumns)
+		}
+		err = s.logSvc.ExportSystemLogs(r.Context(), filter, func(page []services.LogEntry) error {
+			for _, l := range page {
+				record := []string{strconv.FormatInt(l.ID, 10), l.CreatedAt.UTC().Format(time.RFC3339Nano), l.EventType, l.WalletID, l.IPAddress, l.Details, l.RequestID, l.Country, l.City, l.Device}
+				if err := e.write(record, l); err != nil {
+					return err
+				}
+			}
+			return e.flush()
+		})
+	} else {
+		if e.csv != nil {
+			e.csv.Write(transactionLogColumns)
+		}
+		err = s.logSvc.ExportTransactionLogs(r.Context(), filter, func(page []services.TransactionLog) error {
+			for _, l := range page {
+				record := []string{strconv.FormatInt(l.ID, 10), l.CreatedAt.UTC().Format(time.RFC3339Nano), l.TransactionID, l.Action, l.WalletID, l.BlockHash, l.Status, l.IPAddress, l.RequestID}
+				if err := e.write(record, l); err != nil {
+					return err
+				}
+			}
+			return e.flush()
+		})
+	}
+	if err == nil {
+		err = e.flush()
+	}
+
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "log_export_failed", s.adminActor(r), r.RemoteAddr, fmt.Sprintf("%s logs as %s after %d rows: %v", logType, format, e.rows, err))
+		if !e.flushed {
+			w.Header().Del("Content-Disposition")
+			Error(w, r, CodeInternal, "Failed to export logs")
+			return
+		}
+		// Rows have been sent, so the status is fixed. Abort the response so
+		// the client sees a broken stream rather than a complete-looking export.
+		panic(http.ErrAbortHandler)
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "logs_exported", s.adminActor(r), r.RemoteAddr, fmt.Sprintf("%d %s log rows as %s (from %q, to %q, wallet %q)", e.rows, logType, format, q.Get("from"), q.Get("to"), filter.WalletID))
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"blockchain-backend/googleauth"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// bearerToken returns the session token from "Authorization: Bearer <token>"
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// issueSession starts a session for a verified email and lists the wallets
+// registered with it
+func (s *Server) issueSession(email string, userID int64, method string) (LoginResponse, error) {
+	token, sess, err := s.sessions.Issue(email, userID, method)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	wallets := []string{}
+	for _, wlt := range s.ws.GetAll() {
+		if wlt.Email != "" && strings.EqualFold(wlt.Email, email) {
+			wallets = append(wallets, wlt.WalletID)
+		}
+	}
+
+	return LoginResponse{
+		SessionToken: token,
+		ExpiresAt:    sess.ExpiresAt,
+		Email:        sess.Email,
+		UserID:       sess.UserID,
+		Method:       sess.Method,
+		Wallets:      wallets,
+	}, nil
+}
+
+// handleGoogleConfig tells the frontend whether to show the Google button
+// and which OAuth client ID to initialise Sign-In with
+func (s *Server) handleGoogleConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp := map[string]interface{}{"enabled": s.google != nil}
+	if s.google != nil {
+		resp["client_id"] = s.google.ClientID
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGoogleLogin exchanges a Google ID token for a session. The Google
+// account is linked to the user with the same email, or a new verified user
+// is created when the database is connected.
+func (s *Server) handleGoogleLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.google == nil {
+		Error(w, r, CodeNotConfigured, "Google login is not configured (set GOOGLE_CLIENT_ID)")
+		return
+	}
+
+	var req GoogleLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if req.IDToken == "" {
+		Error(w, r, CodeValidationFailed, "id_token is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	claims, err := s.google.Verify(ctx, req.IDToken)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "google_login_failed", "", r.RemoteAddr, err.Error())
+		if errors.Is(err, googleauth.ErrInvalidToken) {
+			Error(w, r, CodeInvalidToken, "Invalid Google ID token")
+		} else {
+			Error(w, r, CodeInternal, "Failed to verify Google ID token")
+		}
+		return
+	}
+	if !claims.EmailVerified {
+		Error(w, r, CodeInvalidToken, "Google account email is not verified")
+		return
+	}
+
+	var userID int64
+	var created bool
+	email := claims.Email
+	if s.db != nil {
+		if user, err := s.db.GetUserByGoogleID(ctx, claims.Subject); err == nil {
+			userID, _ = user["id"].(int64)
+			email, _ = user["email"].(string)
+		} else {
+			name := claims.Name
+			if name == "" {
+				name = claims.Email
+			}
+			userID, created, err = s.db.LinkGoogleUser(ctx, claims.Email, name, claims.Subject)
+			if errors.Is(err, pgx.ErrNoRows) {
+				Error(w, r, CodeAccountConflict, "This email is already linked to another Google account")
+				return
+			}
+			if err != nil {
+				Error(w, r, CodeInternal, "Failed to link Google account")
+				return
+			}
+		}
+	}
+
+	login, err := s.issueSession(email, userID, services.LoginGoogle)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to create session")
+		return
+	}
+	login.Created = created
+
+	s.logSensitive(r.Context(), "google_login", "", email, r.RemoteAddr, fmt.Sprintf("Google login for %s", email))
+	json.NewEncoder(w).Encode(login)
+}
+
+// handleLogin logs in an email proven by a verified code or a login session
+// and returns the email's wallets, so their owner can find them without
+// knowing the wallet IDs
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req LoginRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	method, err := s.verifyEmailOwner(req.Email, bearerToken(r))
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "login_failed", "", r.RemoteAddr, fmt.Sprintf("Login refused for %s: %v", req.Email, err))
+		writeOpError(w, r, err)
+		return
+	}
+
+	wallets, err := s.walletsByEmail(r.Context(), req.Email)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to load wallets")
+		return
+	}
+
+	var userID int64
+	if s.db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		if user, err := s.db.GetUserByEmail(ctx, req.Email); err == nil {
+			userID, _ = user["id"].(int64)
+		}
+		cancel()
+	}
+	login, err := s.issueSession(req.Email, userID, method)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to create session")
+		return
+	}
+	login.Wallets = make([]string, len(wallets))
+	for i, wlt := range wallets {
+		login.Wallets[i] = wlt.WalletID
+	}
+
+	// The verified code is spent on this login
+	otp.ClearOTP(req.Email)
+
+	s.logSensitive(r.Context(), "login", "", req.Email, r.RemoteAddr, fmt.Sprintf("Login for %s with %d wallets", req.Email, len(wallets)))
+	json.NewEncoder(w).Encode(EmailLoginResponse{LoginResponse: login, WalletDetails: wallets})
+}
+
+// walletsByEmail lists the wallets registered with an email, from the
+// database when it is connected so wallets created by other instances are
+// found too
+func (s *Server) walletsByEmail(ctx context.Context, email string) ([]LoginWallet, error) {
+	wallets := []LoginWallet{}
+	if s.db != nil {
+		dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		rows, err := s.db.GetWalletsByEmail(dbCtx, email)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			wlt := LoginWallet{}
+			wlt.WalletID, _ = row["wallet_id"].(string)
+			wlt.PublicKey, _ = row["public_key"].(string)
+			wlt.FullName, _ = row["full_name"].(string)
+			wlt.Type, _ = row["wallet_type"].(string)
+			wlt.OrgID, _ = row["org_id"].(string)
+			wlt.Label, _ = row["label"].(string)
+			wlt.Status, _ = row["status"].(string)
+			if createdAt, ok := row["created_at"].(time.Time); ok {
+				wlt.CreatedAt = &createdAt
+			}
+			wlt.Balance = s.bc.GetBalance(wlt.WalletID)
+			wallets = append(wallets, wlt)
+		}
+		return wallets, nil
+	}
+
+	for _, wlt := range s.ws.GetAll() {
+		if wlt.Email == "" || !strings.EqualFold(wlt.Email, email) {
+			continue
+		}
+		wallets = append(wallets, s.loginWallet(wlt))
+	}
+	sort.Slice(wallets, func(i, j int) bool { return wallets[i].WalletID < wallets[j].WalletID })
+	return wallets, nil
+}
+
+// loginWallet is the public side of a stored wallet
+func (s *Server) loginWallet(wlt wallet.Wallet) LoginWallet {
+	return LoginWallet{
+		WalletID:  wlt.WalletID,
+		PublicKey: wlt.PublicKey,
+		FullName:  wlt.FullName,
+		Type:      wlt.TypeOrDefault(),
+		OrgID:     wlt.OrgID,
+		Label:     wlt.Label,
+		Status:    wlt.StatusOrDefault(),
+		Balance:   s.bc.GetBalance(wlt.WalletID),
+	}
+}
+
+// handleGetSession returns the session behind the bearer token
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sess, ok := s.sessions.Validate(bearerToken(r))
+	if !ok {
+		Error(w, r, CodeUnauthorized, "Missing, invalid or expired session token")
+		return
+	}
+	json.NewEncoder(w).Encode(sess)
+}
+
+// handleLogout revokes the bearer token
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token := bearerToken(r)
+	sess, ok := s.sessions.Validate(token)
+	if !ok {
+		Error(w, r, CodeUnauthorized, "Missing, invalid or expired session token")
+		return
+	}
+	s.sessions.Revoke(token)
+
+	s.logSvc.LogSystemCtx(r.Context(), "logout", "", r.RemoteAddr, fmt.Sprintf("Session ended for %s", sess.Email))
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Logged out"})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"blockchain-backend/blockchain"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+)
+
+// blockIntervalWindow is how many recent blocks the mining cadence is
+// averaged over
+const blockIntervalWindow = 10
+
+// handleGetMempool lists the pending pool with each transaction's lane, age,
+// reserved inputs and estimated confirmation time
+func (s *Server) handleGetMempool(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	interval := s.bc.BlockInterval(blockIntervalWindow)
+	now := time.Now()
+	resp := MempoolResponse{BlockIntervalSeconds: interval.Seconds(), Transactions: []MempoolTransaction{}}
+	for _, e := range s.bc.MempoolEntries() {
+		if !s.txVisible(r, e.Transaction) {
+			continue
+		}
+		resp.Transactions = append(resp.Transactions, MempoolTransaction{
+			MempoolEntry:          e,
+			EstimatedConfirmation: estimateConfirmation(now, e.BlocksAway, interval),
+		})
+	}
+	resp.Pending = len(resp.Transactions)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// estimateConfirmation assumes blocks keep coming at the recent cadence; it
+// is nil while the cadence is unknown
+func estimateConfirmation(now time.Time, blocksAway int, interval time.Duration) *time.Time {
+	if interval <= 0 || blocksAway <= 0 {
+		return nil
+	}
+	t := now.Add(time.Duration(blocksAway) * interval).UTC().Truncate(time.Second)
+	return &t
+}
+
+// handleTransactionStatus reports whether a transaction is pending,
+// confirmed or cancelled. The chain and pool are checked first, then the
+// persisted transactions and the transaction log, which outlive a restart.
+func (s *Server) handleTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	txid := mux.Vars(r)["txid"]
+	resp := TransactionStatusResponse{TxID: txid}
+
+	if loc, ok := s.bc.GetTransactionByID(txid); ok && s.txVisible(r, loc.Transaction) {
+		if loc.Pending {
+			resp.Status = "pending"
+			resp.Lane = blockchain.LaneOf(loc.Transaction)
+			for _, e := range s.bc.MempoolEntries() {
+				if e.Transaction.ID == txid {
+					resp.BlocksAway = e.BlocksAway
+				}
+			}
+			resp.EstimatedConfirmation = estimateConfirmation(time.Now(), resp.BlocksAway, s.bc.BlockInterval(blockIntervalWindow))
+		} else {
+			index := loc.Block.Index
+			resp.Status = "confirmed"
+			resp.BlockIndex = &index
+			resp.BlockHash = loc.Block.Hash
+			resp.Confirmations = s.bc.GetConfirmations(index)
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	if s.db != nil {
+		row, err := s.db.GetTransactionStatus(r.Context(), txid)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			Error(w, r, CodeInternal, "Failed to look up transaction")
+			return
+		}
+		if err == nil {
+			sender, _ := row["sender_id"].(string)
+			receiver, _ := row["receiver_id"].(string)
+			if s.inOrg(r.Context(), sender) || s.inOrg(r.Context(), receiver) {
+				resp.Status = "cancelled"
+				if status, _ := row["status"].(string); status == "confirmed" {
+					resp.Status = "confirmed"
+					resp.BlockIndex, _ = row["block_index"].(*int64)
+				}
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+		}
+	}
+
+	// Accepted in this process but neither mined nor waiting any more
+	for _, l := range s.logSvc.GetAllTransactionLogs() {
+		if l.TransactionID == txid && s.inOrg(r.Context(), l.WalletID) {
+			resp.Status = "cancelled"
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+	}
+	Error(w, r, CodeNotFound, "Transaction not found")
+}
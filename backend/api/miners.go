@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+const (
+	// leaderboardSize is how many miners GET /api/miners/leaderboard ranks by
+	// default, and maxLeaderboardSize the most it ranks
+	leaderboardSize    = 10
+	maxLeaderboardSize = 100
+)
+
+// handleListMiners lists the wallets that have mined blocks, most blocks
+// first. Under proof of authority the validators yet to produce one follow.
+func (s *Server) handleListMiners(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	validators := map[string]bool{}
+	if a, ok := s.bc.ActiveConsensus().(blockchain.Authority); ok {
+		for _, id := range a.Validators {
+			validators[id] = true
+		}
+	}
+
+	list := []MinerResponse{}
+	seen := map[string]bool{}
+	for _, m := range s.miners.List() {
+		seen[m.WalletID] = true
+		if !s.inOrg(r.Context(), m.WalletID) {
+			continue
+		}
+		list = append(list, MinerResponse{MinerStats: m, Handle: s.handles.Handle(m.WalletID), Validator: validators[m.WalletID]})
+	}
+	for id := range validators {
+		if seen[id] || !s.inOrg(r.Context(), id) {
+			continue
+		}
+		if _, ok := s.ws.Get(id); ok {
+			list = append(list, MinerResponse{MinerStats: services.MinerStats{WalletID: id}, Handle: s.handles.Handle(id), Validator: true})
+		}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleMinerLeaderboard ranks the miners by blocks mined or by rewards
+func (s *Server) handleMinerLeaderboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	by := services.RankByBlocks
+	if v := q.Get("by"); v != "" {
+		if v != services.RankByBlocks && v != services.RankByRewards {
+			Error(w, r, CodeValidationFailed, "by must be blocks or rewards")
+			return
+		}
+		by = v
+	}
+	limit := leaderboardSize
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > maxLeaderboardSize {
+			Error(w, r, CodeValidationFailed, "limit must be between 1 and "+strconv.Itoa(maxLeaderboardSize))
+			return
+		}
+		limit = l
+	}
+
+	// Wallets deleted since they mined are left off, as are other organizations'
+	var ranked []services.MinerStats
+	resp := LeaderboardResponse{By: by, Entries: []LeaderboardEntry{}}
+	for _, m := range s.miners.Ranked(by) {
+		if _, ok := s.ws.Get(m.WalletID); !ok || !s.inOrg(r.Context(), m.WalletID) {
+			continue
+		}
+		ranked = append(ranked, m)
+		resp.TotalBlocks += m.BlocksMined
+	}
+	for i, m := range ranked {
+		if i == limit {
+			break
+		}
+		resp.Entries = append(resp.Entries, LeaderboardEntry{
+			Rank:         i + 1,
+			WalletID:     m.WalletID,
+			Handle:       s.handles.Handle(m.WalletID),
+			BlocksMined:  m.BlocksMined,
+			TotalRewards: m.TotalRewards,
+			Share:        float64(m.BlocksMined) / float64(resp.TotalBlocks),
+			LastMinedAt:  m.LastMinedAt,
+		})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,392 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// Mining can take far longer than the server's WriteTimeout, so POST
+// /api/mine only queues a job and answers 202 Accepted; one worker mines the
+// jobs in turn, off the request path, and GET /api/mine/jobs/{id} reports how
+// each is going. Jobs live in memory and are lost on restart, like the
+// pending pool they mine. Under proof of authority the worker also produces
+// the validators' blocks on a timer; see ProduceBlocks.
+
+// Mining job states
+const (
+	MineJobQueued  = "queued"
+	MineJobRunning = "running"
+	MineJobDone    = "done"
+	MineJobFailed  = "failed"
+)
+
+const (
+	// maxQueuedMineJobs bounds the jobs waiting for the worker
+	maxQueuedMineJobs = 100
+
+	// Finished jobs are kept for mineJobRetention, and at most
+	// maxFinishedMineJobs of them, so clients can collect their results
+	mineJobRetention    = time.Hour
+	maxFinishedMineJobs = 1000
+)
+
+// MineJob is a block being mined in the background
+type MineJob struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"` // queued, running, done or failed
+	MinerWalletID string            `json:"miner_wallet_id"`
+	Start         int64             `json:"start,omitempty"`
+	Position      int               `json:"position,omitempty"` // jobs ahead of it, while queued
+	Attempts      int64             `json:"attempts"`           // hashes tried so far
+	QueuedAt      time.Time         `json:"queued_at"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	Block         *blockchain.Block `json:"block,omitempty"` // once done
+	Error         *ErrorBody        `json:"error,omitempty"` // once failed
+}
+
+// mineJob is a queued job and the request it came from
+type mineJob struct {
+	MineJob
+	seq        uint64
+	ctx        context.Context        // the request's values, without its cancellation
+	sign       blockchain.BlockSigner // under proof of authority; see MineSigned
+	remoteAddr string
+	attempts   atomic.Int64
+}
+
+// mineQueue holds the mining jobs and runs them one at a time
+type mineQueue struct {
+	mu       sync.Mutex
+	jobs     map[string]*mineJob
+	finished []*mineJob // oldest first, for pruning
+	seq      uint64
+	running  uint64 // seq of the running job, 0 when idle
+	pending  chan *mineJob
+
+	interval time.Duration // see ProduceBlocks; 0 when blocks are only mined on request
+
+	start  sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newMineQueue() *mineQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &mineQueue{
+		jobs:    make(map[string]*mineJob),
+		pending: make(chan *mineJob, maxQueuedMineJobs),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// handleMine queues a block to be mined and returns its job
+func (s *Server) handleMine(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req MineRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.checkMiner(r.Context(), req.MinerWalletID); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	// Naming a validator is not enough to produce its block; its key must
+	// sign it
+	var sign blockchain.BlockSigner
+	if s.bc.NextProducer() != "" {
+		miner, _ := s.ws.Get(req.MinerWalletID)
+		privateKey, _, err := s.resolveSigner(r.Context(), miner, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+		if err != nil {
+			writeOpError(w, r, err)
+			return
+		}
+		markRawKeyDeprecated(w, req.PrivateKey)
+		sign = blockSigner(privateKey)
+	}
+
+	job, err := s.enqueueMining(r.Context(), req, sign, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/mine/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleConsensus reports how blocks are produced and, under proof of
+// authority, whose turn the next block is
+func (s *Server) handleConsensus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	c := s.bc.ActiveConsensus()
+	resp := ConsensusResponse{Mode: c.Mode(), Difficulty: c.Difficulty(), NextProducer: s.bc.NextProducer()}
+	if a, ok := c.(blockchain.Authority); ok {
+		resp.Validators = a.Validators
+	}
+	if resp.NextProducer != "" {
+		resp.BlockIntervalSeconds = int(s.mining.interval / time.Second)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGetMineJob reports a mining job's progress, and its block or error
+// once it finishes
+func (s *Server) handleGetMineJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	job, ok := s.mining.get(mux.Vars(r)["id"])
+	if !ok || !s.inOrg(r.Context(), job.MinerWalletID) {
+		Error(w, r, CodeNotFound, "Mining job not found")
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+// enqueueMining queues a mining job, starting the worker with the first one
+func (s *Server) enqueueMining(ctx context.Context, req MineRequest, sign blockchain.BlockSigner, remoteAddr string) (MineJob, error) {
+	q := s.mining
+	q.start.Do(func() { go q.run(s) })
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ctx.Err() != nil {
+		return MineJob{}, fail(CodeNotConfigured, "Mining has stopped; the server is shutting down")
+	}
+	job := q.newJob(ctx, req, sign, remoteAddr)
+	select {
+	case q.pending <- job:
+	default:
+		return MineJob{}, fail(CodeMiningQueueFull, "Too many blocks are waiting to be mined; try again once some finish")
+	}
+	q.jobs[job.ID] = job
+	s.logSvc.LogSystemCtx(ctx, "mining_queued", req.MinerWalletID, remoteAddr, "Job "+job.ID)
+	return q.snapshot(job), nil
+}
+
+// newJob creates a queued job; q.mu must be held
+func (q *mineQueue) newJob(ctx context.Context, req MineRequest, sign blockchain.BlockSigner, remoteAddr string) *mineJob {
+	q.prune(time.Now())
+	q.seq++
+	return &mineJob{
+		MineJob: MineJob{
+			ID:            newRequestID(),
+			Status:        MineJobQueued,
+			MinerWalletID: req.MinerWalletID,
+			Start:         req.Start,
+			QueuedAt:      time.Now().UTC(),
+		},
+		seq:        q.seq,
+		ctx:        context.WithoutCancel(ctx),
+		sign:       sign,
+		remoteAddr: remoteAddr,
+	}
+}
+
+// run mines the queued jobs in turn until StopMining, and produces the
+// scheduled blocks between them
+func (q *mineQueue) run(s *Server) {
+	defer close(q.done)
+	var tick <-chan time.Time
+	if q.interval > 0 {
+		ticker := time.NewTicker(q.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-q.ctx.Done():
+			q.abandon()
+			return
+		case job := <-q.pending:
+			s.runMineJob(job)
+		case <-tick:
+			s.produceBlock()
+		}
+	}
+}
+
+// ProduceBlocks has the mining worker produce a block every interval while
+// transactions are pending, paying the validator whose turn it is. It is for
+// a consensus that names each block's producer, and must be called before
+// the server takes requests.
+func (s *Server) ProduceBlocks(interval time.Duration) {
+	q := s.mining
+	q.interval = interval
+	q.start.Do(func() { go q.run(s) })
+}
+
+// produceBlock runs a job for the next validator's block, signed with its
+// stored key, unless nothing is pending
+func (s *Server) produceBlock() {
+	producer := s.bc.NextProducer()
+	if producer == "" || len(s.bc.GetPending()) == 0 {
+		return
+	}
+	validator, ok := s.ws.Get(producer)
+	if !ok {
+		s.logSvc.LogSystemCtx(s.mining.ctx, "block_production_failed", producer, "", "Validator wallet not found")
+		return
+	}
+	privateKey, err := wallet.DecryptPrivateKey(validator.PrivateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(s.mining.ctx, "block_production_failed", producer, "", "The validator's stored key could not be decrypted: "+err.Error())
+		return
+	}
+
+	q := s.mining
+	q.mu.Lock()
+	// The validator's organization lets the job see its wallet
+	job := q.newJob(services.WithOrg(context.Background(), validator.OrgID), MineRequest{MinerWalletID: producer}, blockSigner(privateKey), "")
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+	s.runMineJob(job)
+
+	// The turn passes to the next validator once this one times out, so say
+	// why it could not produce
+	if snap, _ := q.get(job.ID); snap.Error != nil {
+		s.logSvc.LogSystemCtx(job.ctx, "block_production_failed", producer, "", string(snap.Error.Code)+": "+snap.Error.Message)
+	}
+}
+
+func (s *Server) runMineJob(job *mineJob) {
+	q := s.mining
+	q.mu.Lock()
+	started := time.Now().UTC()
+	job.Status, job.StartedAt = MineJobRunning, &started
+	q.running = job.seq
+	q.mu.Unlock()
+
+	// The job outlives its request, but not the server
+	ctx, cancel := context.WithCancel(blockchain.WithAttemptCounter(job.ctx, &job.attempts))
+	stop := context.AfterFunc(q.ctx, cancel)
+	blk, err := s.mineBlock(ctx, job.MinerWalletID, job.Start, job.remoteAddr, job.sign)
+	stop()
+	cancel()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err != nil {
+		q.finish(job, MineJobFailed)
+		job.Error = mineJobError(job.ctx, err)
+		return
+	}
+	q.finish(job, MineJobDone)
+	job.Block = &blk
+}
+
+// blockSigner signs blocks with the hex private key privHex
+func blockSigner(privHex string) blockchain.BlockSigner {
+	return func(hash string) (string, string, error) {
+		priv, err := hex.DecodeString(privHex)
+		if err != nil || len(priv) != ed25519.PrivateKeySize {
+			return "", "", errors.New("invalid validator private key")
+		}
+		key := ed25519.PrivateKey(priv)
+		pub := key.Public().(ed25519.PublicKey)
+		return hex.EncodeToString(pub), hex.EncodeToString(ed25519.Sign(key, []byte(hash))), nil
+	}
+}
+
+// finish records that job ended; q.mu must be held
+func (q *mineQueue) finish(job *mineJob, status string) {
+	finished := time.Now().UTC()
+	job.Status, job.FinishedAt = status, &finished
+	if q.running == job.seq {
+		q.running = 0
+	}
+	q.finished = append(q.finished, job)
+}
+
+// abandon fails the jobs still queued when mining stops
+func (q *mineQueue) abandon() {
+	for {
+		select {
+		case job := <-q.pending:
+			q.mu.Lock()
+			q.finish(job, MineJobFailed)
+			job.Error = mineJobError(job.ctx, fail(CodeRequestCancelled, "Mining stopped before the job ran; the server is shutting down"))
+			q.mu.Unlock()
+		default:
+			return
+		}
+	}
+}
+
+// mineJobError is the error body a failed job reports
+func mineJobError(ctx context.Context, err error) *ErrorBody {
+	body := &ErrorBody{Code: errorCode(err), Message: err.Error(), RequestID: services.RequestIDFrom(ctx)}
+	var oe *opError
+	if errors.As(err, &oe) {
+		body.Fields = oe.fields
+	}
+	return body
+}
+
+// prune drops finished jobs past mineJobRetention or beyond
+// maxFinishedMineJobs; q.mu must be held
+func (q *mineQueue) prune(now time.Time) {
+	n := 0
+	for n < len(q.finished) && (len(q.finished)-n > maxFinishedMineJobs || now.Sub(*q.finished[n].FinishedAt) > mineJobRetention) {
+		delete(q.jobs, q.finished[n].ID)
+		n++
+	}
+	q.finished = q.finished[n:]
+}
+
+func (q *mineQueue) get(id string) (MineJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return MineJob{}, false
+	}
+	return q.snapshot(job), true
+}
+
+// snapshot copies a job for a response; q.mu must be held
+func (q *mineQueue) snapshot(job *mineJob) MineJob {
+	out := job.MineJob
+	out.Attempts = job.attempts.Load()
+	if job.Status == MineJobQueued {
+		for _, other := range q.jobs {
+			if other.Status == MineJobQueued && other.seq < job.seq {
+				out.Position++
+			}
+		}
+		if q.running != 0 {
+			out.Position++
+		}
+	}
+	return out
+}
+
+// StopMining cancels the running mining job, fails the queued ones and waits
+// for the worker to exit. Jobs cannot be queued afterwards.
+func (s *Server) StopMining() {
+	q := s.mining
+	q.mu.Lock()
+	q.cancel()
+	q.mu.Unlock()
+	// Without a worker there is nothing to wait for
+	q.start.Do(func() { close(q.done) })
+	<-q.done
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+)
+
+// Admins mint coins to a wallet or burn them from it to correct balances or
+// seed test environments. Each adjustment needs a one-time code sent to the
+// acting admin's email (see adminOTPEmail) and is queued as a system
+// transaction whose note names the admin and the reason. The system log,
+// the audit log and the supply report record it too.
+
+// maxAdjustmentReason keeps the reason, the admin and the kind within a
+// transaction note
+const maxAdjustmentReason = 160
+
+// handleAdminMint queues new coins for a wallet
+func (s *Server) handleAdminMint(w http.ResponseWriter, r *http.Request) {
+	s.adjustSupply(w, r, services.AdjustmentMint)
+}
+
+// handleAdminBurn queues the destruction of a wallet's coins
+func (s *Server) handleAdminBurn(w http.ResponseWriter, r *http.Request) {
+	s.adjustSupply(w, r, services.AdjustmentBurn)
+}
+
+func (s *Server) adjustSupply(w http.ResponseWriter, r *http.Request, kind string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SupplyAdjustmentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, exists := s.ws.Get(req.WalletID); !exists || !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	actor := s.adminActor(r)
+	email := s.adminOTPEmail(r)
+	if email == "" || !otp.VerifyOTP(email, req.OTPCode) {
+		s.logSvc.LogSystemCtx(r.Context(), "admin_"+kind+"_denied", req.WalletID, r.RemoteAddr, "Invalid or missing OTP from "+actor)
+		Error(w, r, CodeInvalidOTP, "Minting and burning need a valid otp_code sent to the admin email; request one with POST /api/otp/send")
+		return
+	}
+	otp.ClearOTP(email)
+
+	note := fmt.Sprintf("Admin %s by %s: %s", kind, actor, req.Reason)
+	var tx *blockchain.Transaction
+	var err error
+	if kind == services.AdjustmentMint {
+		tx, err = s.txSvc.CreateMintTransaction(req.WalletID, req.Amount, note)
+	} else {
+		tx, err = s.txSvc.CreateBurnTransaction(req.WalletID, req.Amount, note)
+	}
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "admin_"+kind+"_failed", req.WalletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+	if err := s.queueTransaction(r.Context(), tx, r.RemoteAddr); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	// Mints count as issued once queued, as faucet grants do when granted
+	if kind == services.AdjustmentMint {
+		s.bc.AddIssued(req.Amount)
+		s.supply.Record(services.IssuedByMint, req.Amount, tx.ID)
+	} else {
+		s.supply.Record(services.SupplyBurn, req.Amount, tx.ID)
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "admin_"+kind, req.WalletID, r.RemoteAddr,
+		fmt.Sprintf("%s %s by %s in %s: %s", kind, blockchain.FormatAmount(req.Amount), actor, tx.ID, req.Reason))
+	w.WriteHeader(http.StatusCreated)
+	adjustment, _ := services.SupplyAdjustmentOf(*tx, true)
+	json.NewEncoder(w).Encode(SupplyAdjustmentResponse{Adjustment: adjustment, Transaction: tx})
+}
+
+// handleListSupplyAdjustments lists every admin mint and burn, newest first
+func (s *Server) handleListSupplyAdjustments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	adjustments := s.txSvc.SupplyAdjustments()
+	if adjustments == nil {
+		adjustments = []services.SupplyAdjustment{}
+	}
+	json.NewEncoder(w).Encode(adjustments)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/validation"
+)
+
+// Inbox page sizes
+const (
+	defaultInboxLimit = 50
+	maxInboxLimit     = 200
+)
+
+// handleGetNotificationPreferences reports which events a wallet's owner is
+// emailed about
+func (s *Server) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := r.URL.Query().Get("wallet_id")
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", walletID)
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	json.NewEncoder(w).Encode(s.notifications.Preferences(walletID))
+}
+
+// handleSetNotificationPreferences lets the owner choose which events they
+// are emailed about
+func (s *Server) handleSetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req NotificationPreferencesRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	errs.Required("private_key", req.PrivateKey)
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	prefs := s.notifications.Preferences(req.WalletID)
+	if req.IncomingPayments != nil {
+		prefs.IncomingPayments = *req.IncomingPayments
+	}
+	if req.MinIncomingAmount != nil {
+		prefs.MinIncomingAmount = *req.MinIncomingAmount
+	}
+	if req.ZakatDeductions != nil {
+		prefs.ZakatDeductions = *req.ZakatDeductions
+	}
+	if req.ProfileChanges != nil {
+		prefs.ProfileChanges = *req.ProfileChanges
+	}
+	if req.BeneficiaryChanges != nil {
+		prefs.BeneficiaryChanges = *req.BeneficiaryChanges
+	}
+	prefs, err := s.notifications.SetPreferences(prefs)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to save notification preferences")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "notification_preferences_changed", req.WalletID, r.RemoteAddr,
+		fmt.Sprintf("incoming_payments=%t min_incoming_amount=%d zakat_deductions=%t profile_changes=%t beneficiary_changes=%t",
+			prefs.IncomingPayments, prefs.MinIncomingAmount, prefs.ZakatDeductions, prefs.ProfileChanges, prefs.BeneficiaryChanges))
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// handleGetNotifications lists a wallet's in-app notifications, newest
+// first, with its unread count for a bell icon. Only the owner's login
+// session may read them.
+func (s *Server) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	unreadOnly := false
+	if v := q.Get("unread"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs.Add("unread", "must be true or false")
+		}
+		unreadOnly = b
+	}
+	var before int64
+	if v := q.Get("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			errs.Add("before", "must be a positive integer")
+		}
+		before = id
+	}
+	limit := defaultInboxLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxInboxLimit {
+			errs.Add("limit", "must be an integer from 1 to "+strconv.Itoa(maxInboxLimit))
+		}
+		limit = n
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if !s.requireWalletOwner(w, r, walletID) {
+		return
+	}
+
+	list, unread := s.notifications.Inbox(walletID, unreadOnly, before, limit)
+	resp := NotificationInboxResponse{WalletID: walletID, Unread: unread, Notifications: list}
+	if len(list) == limit {
+		resp.NextBefore = list[len(list)-1].ID
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleMarkNotificationsRead marks some or all of a wallet's notifications
+// read, for the owner's login session
+func (s *Server) handleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req MarkNotificationsReadRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if !s.requireWalletOwner(w, r, walletID) {
+		return
+	}
+
+	marked, err := s.notifications.MarkRead(walletID, req.IDs)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to mark notifications read")
+		return
+	}
+	_, unread := s.notifications.Inbox(walletID, true, 0, 0)
+	json.NewEncoder(w).Encode(MarkNotificationsReadResponse{Marked: marked, Unread: unread})
+}
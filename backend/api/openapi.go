@@ -0,0 +1,598 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+//go:embed swagger.html
+var swaggerPage []byte
+
+// routeDoc describes one route for the OpenAPI document. Paths and methods
+// come from the router itself; only what the router cannot know lives here.
+type routeDoc struct {
+	Summary  string
+	Tag      string
+	Request  interface{} // request body type, nil when there is none
+	Response interface{} // success body type, nil for a free-form object
+	Status   int         // success status, 200 when zero
+	Query    []queryParam
+	Admin    bool
+	OrgAdmin bool // needs an admin of the X-Org-ID organization
+	Owner    bool // needs a login session for the {wallet} owner's email
+	HTML     bool
+	Binary   bool // application/octet-stream body
+	Image    bool // image/png, or image/svg+xml with format=svg
+	Upload   bool // takes an application/octet-stream request body
+
+	// Deprecated marks a superseded route: it is flagged in the document,
+	// answered with Deprecation/Sunset headers and reported by /api/admin/usage
+	Deprecated *deprecation
+}
+
+type queryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// routeDocs is keyed by "METHOD /path/template" as registered in routes()
+var routeDocs = map[string]routeDoc{
+	"POST /api/generate-keypair":              {Summary: "Generate an Ed25519 keypair", Tag: "Wallets", Response: KeypairResponse{}},
+	"POST /api/create-wallet":                 {Summary: "Create a wallet from a keypair for an email verified by OTP or a login session", Tag: "Wallets", Request: CreateWalletRequest{}, Response: wallet.Wallet{}},
+	"GET /api/wallet/{wallet}":                {Summary: "Get a wallet (private key masked) and its last nonce", Tag: "Wallets", Response: WalletResponse{}},
+	"GET /api/kyc/{wallet}":                   {Summary: "KYC status, submissions and what an unverified wallet may still send today", Tag: "Wallets", Response: KYCStatusResponse{}},
+	"POST /api/kyc/{wallet}":                  {Summary: "Submit a CNIC and document reference for KYC review", Tag: "Wallets", Request: KYCSubmitRequest{}, Response: services.KYCSubmission{}, Status: http.StatusAccepted},
+	"GET /api/limits/{wallet}":                {Summary: "Spending limits and what the wallet sent in the last 24 hours", Tag: "Wallets", Response: SpendingLimitsResponse{}},
+	"PUT /api/limits/{wallet}":                {Summary: "Change the wallet's spending limits (raising or removing one needs otp_code)", Tag: "Wallets", Request: SetLimitsRequest{}, Response: SpendingLimitsResponse{}},
+	"GET /api/notifications/preferences":      {Summary: "Which wallet events the owner is emailed about", Tag: "Wallets", Response: services.NotificationPreferences{}, Query: []queryParam{{"wallet_id", "string", "Wallet whose preferences to read"}}},
+	"PUT /api/notifications/preferences":      {Summary: "Change which wallet events the owner is emailed about; omitted fields keep their value", Tag: "Wallets", Request: NotificationPreferencesRequest{}, Response: services.NotificationPreferences{}},
+	"POST /api/alias":                         {Summary: "Claim a @handle for the wallet, replacing its current one", Tag: "Wallets", Request: ClaimHandleRequest{}, Response: services.HandleClaim{}},
+	"DELETE /api/alias":                       {Summary: "Give up the wallet's handle", Tag: "Wallets", Request: ReleaseHandleRequest{}, Response: services.HandleClaim{}},
+	"GET /api/alias/{handle}":                 {Summary: "The wallet holding a handle", Tag: "Wallets", Response: HandleResponse{}},
+	"GET /api/wallet/{wallet}/alias":          {Summary: "The wallet's handle and every handle it held, newest first", Tag: "Wallets", Response: WalletHandleResponse{}},
+	"GET /api/directory":                      {Summary: "Whether others can find the wallet by its owner's email", Tag: "Wallets", Response: DirectoryStatusResponse{}, Query: []queryParam{{"wallet_id", "string", "Wallet whose setting to read"}}},
+	"PUT /api/directory":                      {Summary: "Opt the wallet in to or out of the email directory", Tag: "Wallets", Request: DirectorySettingRequest{}, Response: DirectoryStatusResponse{}},
+	"POST /api/resolve":                       {Summary: "The wallet listed under an email, if its owner opted in; rate limited per client", Tag: "Wallets", Request: ResolveEmailRequest{}, Response: ResolveEmailResponse{}},
+	"GET /api/notifications/{wallet}":         {Summary: "The wallet's in-app notifications, newest first, with its unread count", Tag: "Wallets", Response: NotificationInboxResponse{}, Owner: true, Query: []queryParam{{"unread", "boolean", "Only unread notifications"}, {"before", "integer", "Only notifications with a lower ID, for paging back"}, {"limit", "integer", "Maximum number of notifications (default 50, max 200)"}}},
+	"POST /api/notifications/{wallet}/read":   {Summary: "Mark notifications read; all of them when ids is empty", Tag: "Wallets", Request: MarkNotificationsReadRequest{}, Response: MarkNotificationsReadResponse{}, Owner: true},
+	"POST /api/webhooks":                      {Summary: "Register a URL for a wallet's events; returns the signing secret once", Tag: "Webhooks", Request: WebhookCreateRequest{}, Response: WebhookCreatedResponse{}, Status: http.StatusCreated},
+	"GET /api/webhooks":                       {Summary: "A wallet's webhooks", Tag: "Webhooks", Response: []services.Webhook{}, Owner: true, Query: []queryParam{{"wallet_id", "string", "Wallet whose webhooks to list"}}},
+	"GET /api/webhooks/{id}":                  {Summary: "One webhook", Tag: "Webhooks", Response: services.Webhook{}, Owner: true},
+	"PUT /api/webhooks/{id}":                  {Summary: "Change a webhook's URL, events or active flag", Tag: "Webhooks", Request: WebhookUpdateRequest{}, Response: services.Webhook{}},
+	"DELETE /api/webhooks/{id}":               {Summary: "Delete a webhook and drop its queued retries", Tag: "Webhooks", Request: WebhookDeleteRequest{}, Response: StatusResponse{}},
+	"GET /api/faucet":                         {Summary: "Faucet mode, amount and cooldowns; with wallet_id, whether the wallet can claim now", Tag: "Faucet", Response: FaucetStatusResponse{}, Query: []queryParam{{"wallet_id", "string", "Wallet to check"}}},
+	"POST /api/faucet/claim":                  {Summary: "Claim faucet coins with otp_code or a login session for the wallet's email", Tag: "Faucet", Request: FaucetClaimRequest{}, Response: FaucetClaimResponse{}, Status: http.StatusCreated},
+	"GET /api/webhooks/{id}/deliveries":       {Summary: "A webhook's recent deliveries with each attempt", Tag: "Webhooks", Response: []services.Delivery{}, Owner: true, Query: []queryParam{{"limit", "integer", "Maximum number of deliveries (default 50)"}}},
+	"GET /api/admin/kyc/pending":              {Summary: "KYC submissions awaiting review", Tag: "Admin", Admin: true, Response: []services.KYCSubmission{}},
+	"POST /api/admin/kyc/{id}/{decision}":     {Summary: "Approve or reject a KYC submission", Tag: "Admin", Admin: true, Request: KYCReviewRequest{}, Response: services.KYCSubmission{}},
+	"POST /api/wallet/{wallet}/type-change":   {Summary: "Request a wallet type change for admin approval", Tag: "Wallets", Request: TypeChangeBody{}, Response: services.TypeChangeRequest{}, Status: http.StatusAccepted},
+	"POST /api/wallet/{wallet}/consolidate":   {Summary: "Combine the wallet's smallest outputs into one with a signed self-transfer", Tag: "Transactions", Request: ConsolidateRequest{}, Response: ConsolidateResponse{}},
+	"POST /api/wallet/{wallet}/rotate-key":    {Summary: "Move the wallet to a new keypair, sweeping its outputs there and retiring the old wallet", Tag: "Wallets", Request: RotateKeyRequest{}, Response: RotateKeyResponse{}},
+	"POST /api/wallet/{wallet}/deactivate":    {Summary: "Close an empty wallet; it can no longer send or receive", Tag: "Wallets", Request: DeactivateRequest{}, Response: WalletResponse{}},
+	"POST /api/wallet/{wallet}/sweep-retired": {Summary: "Carry a retired wallet's outputs that have since unlocked to its newest key", Tag: "Wallets", Request: SweepRetiredRequest{}, Response: SweepRetiredResponse{}},
+	"GET /api/wallet/{wallet}/rotations":      {Summary: "The wallet's key lineage, oldest first", Tag: "Wallets", Response: WalletRotationsResponse{}},
+	"GET /api/wallet/{wallet}/inheritance":    {Summary: "The wallet's inactivity rule, nominees and inheritance payouts", Tag: "Beneficiaries", Response: InheritanceResponse{}},
+	"PUT /api/wallet/{wallet}/inheritance":    {Summary: "Pay the balance to nominees after months without outgoing activity; 0 removes the rule", Tag: "Beneficiaries", Request: InheritanceRuleRequest{}, Response: InheritanceResponse{}},
+	"GET /api/balance/{wallet}":               {Summary: "Spendable and pending balance", Tag: "Wallets", Response: BalanceResponse{}},
+	"GET /api/wallet/{wallet}/updates": {Summary: "Long-poll wallet events after since_seq", Tag: "Wallets", Owner: true, Query: []queryParam{
+		{"since_seq", "integer", "Return events with a greater sequence number"},
+		{"limit", "integer", "Maximum events (default 100, max 500)"},
+		{"wait", "integer", "Seconds to hold the request open when nothing is available (max 55)"},
+	}},
+	"GET /api/ws": {Summary: "Websocket for blocks, announcements and, with proof of ownership, tx:<wallet> and balance:<wallet>", Tag: "Wallets", Status: http.StatusSwitchingProtocols, Query: []queryParam{
+		{"session_token", "string", "Login session used for wallet topics (or send Authorization: Bearer)"},
+	}},
+	"POST /api/send":                       {Summary: "Send coins to a wallet or beneficiary alias", Tag: "Transactions", Request: SendRequest{}, Response: SendResponse{}},
+	"POST /api/send/simulate":              {Summary: "Preview a send: the inputs it would spend, its change, fee and resulting balance, without signing or queueing it", Tag: "Transactions", Request: SendRequest{}, Response: SimulateSendResponse{}},
+	"POST /api/send/batch":                 {Summary: "Pay many wallets from one sender in a single transaction", Tag: "Transactions", Request: BatchSendRequest{}, Response: BatchSendResponse{}},
+	"GET /api/transactions/prepare":        {Summary: "Unsigned transfer and signing payload for an offline wallet", Tag: "Transactions", Response: PrepareTransactionResponse{}, Query: []queryParam{{"sender_id", "string", "Sending wallet"}, {"receiver_id", "string", "Receiving wallet"}, {"amount", "integer", "Units to send (10^8 per coin)"}, {"note", "string", ""}, {"asset_id", "string", "Asset to send; omit for coins"}}},
+	"POST /api/transactions/submit-signed": {Summary: "Queue a transaction signed offline", Tag: "Transactions", Request: SubmitSignedRequest{}, Response: SendResponse{}},
+	"POST /api/signing-sessions":           {Summary: "Authorize server-side signing with an OTP or authenticator code", Tag: "Transactions", Request: SigningSessionRequest{}, Response: SigningSessionResponse{}, Status: http.StatusCreated},
+	"GET /api/signing-sessions/current":    {Summary: "Show the signing session named by X-Signing-Token", Tag: "Transactions", Response: services.SigningSession{}},
+	"DELETE /api/signing-sessions/current": {Summary: "Revoke the signing session named by X-Signing-Token", Tag: "Transactions", Response: StatusResponse{}},
+	"GET /api/transactions":                {Summary: "All confirmed transactions", Tag: "Transactions", Response: []blockchain.Transaction{}},
+	"GET /api/pending":                     {Summary: "Pending transactions", Tag: "Transactions", Response: []blockchain.Transaction{}},
+	"GET /api/mempool":                     {Summary: "Pending transactions with lane, age, reserved inputs and estimated confirmation", Tag: "Transactions", Response: MempoolResponse{}},
+	"GET /api/transaction/{txid}":          {Summary: "One transaction, with its block, position and confirmations once mined", Tag: "Transactions", Response: TransactionResponse{}},
+	"GET /api/headers": {Summary: "Block headers from..to for light clients, at most 2000 per request", Tag: "Blockchain", Response: []blockchain.BlockHeader{}, Query: []queryParam{
+		{"from", "integer", "First block index (default 0)"},
+		{"to", "integer", "Last block index, included (default the tip)"},
+	}},
+	"GET /api/transaction/{txid}/proof":         {Summary: "Merkle inclusion proof and block header of a mined transaction", Tag: "Transactions", Response: TransactionProofResponse{}},
+	"GET /api/transaction/{txid}/status":        {Summary: "Whether a transaction is pending, confirmed or cancelled, and its block", Tag: "Transactions", Response: TransactionStatusResponse{}},
+	"GET /api/mempool/stats":                    {Summary: "Pending transactions per priority lane and how many the next block takes", Tag: "Transactions", Response: blockchain.MempoolStats{}},
+	"POST /api/utxos/{id}/{action}":             {Summary: "Claim a hash-locked output with its preimage, or refund one whose lock time has passed", Tag: "Transactions", Request: UnlockRequest{}, Response: UnlockResponse{}},
+	"POST /api/vesting":                         {Summary: "Grant coins to a wallet in monthly time-locked tranches", Tag: "Transactions", Request: VestingRequest{}, Response: services.VestingGrant{}, Status: http.StatusCreated},
+	"GET /api/vesting/{wallet}":                 {Summary: "Vesting grants a wallet gave or received, with each tranche's unlock time and state", Tag: "Transactions", Response: VestingResponse{}},
+	"POST /api/payment-requests":                {Summary: "Ask another wallet to pay an amount", Tag: "Transactions", Request: CreatePaymentRequestRequest{}, Response: services.PaymentRequest{}, Status: http.StatusCreated},
+	"GET /api/payment-requests/{id}":            {Summary: "A payment request and its status", Tag: "Transactions", Response: services.PaymentRequest{}},
+	"POST /api/payment-requests/{id}/accept":    {Summary: "Pay a pending request with a signed transfer whose note names it", Tag: "Transactions", Request: AcceptPaymentRequestRequest{}, Response: services.PaymentRequest{}},
+	"POST /api/payment-requests/{id}/decline":   {Summary: "Refuse a pending payment request", Tag: "Transactions", Request: DeclinePaymentRequestRequest{}, Response: services.PaymentRequest{}},
+	"GET /api/wallet/{wallet}/payment-requests": {Summary: "Payment requests a wallet made or was asked to pay, newest first", Tag: "Transactions", Response: []services.PaymentRequest{}, Query: []queryParam{{"direction", "string", "incoming (to pay) or outgoing (made); both when omitted"}, {"status", "string", "pending, accepted, declined or expired"}}},
+	"GET /api/wallet/{wallet}/qr":               {Summary: "QR code of a wallet's payment URI, optionally asking for an amount", Tag: "Transactions", Image: true, Query: []queryParam{{"amount", "integer", "amount to ask for, in units"}, {"note", "string", "note for the payer"}, {"format", "string", "png (default) or svg"}, {"size", "integer", "width in pixels, 64 to 1024 (default 256)"}}},
+	"GET /api/invoices/{id}/qr":                 {Summary: "QR code paying a pending payment request", Tag: "Transactions", Image: true, Query: []queryParam{{"format", "string", "png (default) or svg"}, {"size", "integer", "width in pixels, 64 to 1024 (default 256)"}}},
+	"POST /api/bills":                           {Summary: "Split a bill among wallets, asking each for its share with a payment request", Tag: "Transactions", Request: CreateBillRequest{}, Response: services.Bill{}, Status: http.StatusCreated},
+	"GET /api/bills/{id}":                       {Summary: "A split bill and which shares are paid", Tag: "Transactions", Response: services.Bill{}},
+	"GET /api/wallet/{wallet}/bills":            {Summary: "Bills a wallet created or has a share in, newest first", Tag: "Transactions", Response: []services.Bill{}},
+	"GET /api/utxos/{wallet}":                   {Summary: "Unspent outputs of a wallet", Tag: "Transactions", Response: []blockchain.UTXO{}},
+	"POST /api/mine":                            {Summary: "Queue a job mining the pending pool into a block", Tag: "Blockchain", Request: MineRequest{}, Response: MineJob{}},
+	"GET /api/consensus":                        {Summary: "How blocks are produced: proof of work or the validators' turns", Tag: "Blockchain", Response: ConsensusResponse{}},
+	"GET /api/miners":                           {Summary: "Blocks mined, rewards and last activity of each miner; under proof of authority every validator", Tag: "Blockchain", Response: []MinerResponse{}},
+	"GET /api/miners/leaderboard":               {Summary: "Rank the miners by blocks mined or rewards", Tag: "Blockchain", Response: LeaderboardResponse{}, Query: []queryParam{{"by", "string", "blocks (default) or rewards"}, {"limit", "integer", "Miners ranked (default 10, at most 100)"}}},
+	"GET /api/mine/jobs/{id}":                   {Summary: "Mining job progress, and its block once done", Tag: "Blockchain", Response: MineJob{}},
+	"GET /api/blocks": {Summary: "All blocks, or the range from..to", Tag: "Blockchain", Response: []blockchain.Block{}, Query: []queryParam{
+		{"from", "integer", "First block index (default 0)"},
+		{"to", "integer", "Last block index, included (default the tip)"},
+	}},
+	"GET /api/block/{index}":                               {Summary: "Block by height or hash, with confirmations, totals, miner, size and neighbour links", Tag: "Blockchain", Response: BlockDetail{}},
+	"GET /api/block/{index}/raw":                           {Summary: "Block by height or hash in the canonical binary encoding (spec/README.md); not served in multi-tenant mode", Tag: "Blockchain", Binary: true},
+	"GET /api/chain/export":                                {Summary: "Every block and the UTXO snapshot of the tip as a versioned chain export (NDJSON or length-delimited protobuf, see proto/chain.proto); admin only in multi-tenant mode", Tag: "Blockchain", Binary: true, Query: []queryParam{{"format", "string", "json (default) or protobuf"}}},
+	"GET /api/search":                                      {Summary: "Resolve a query to blocks, transactions and wallets", Tag: "Blockchain", Response: SearchResponse{}, Query: []queryParam{{"q", "string", "Block index or hash, transaction ID, wallet ID, or (admins only) an email address"}}},
+	"GET /api/graphql":                                     {Summary: "GraphQL explorer query (query string)", Tag: "Blockchain", Query: []queryParam{{"query", "string", "GraphQL query"}, {"variables", "string", "JSON-encoded variables"}, {"operationName", "string", ""}}},
+	"POST /api/graphql":                                    {Summary: "GraphQL explorer query", Tag: "Blockchain", Request: GraphQLRequest{}},
+	"POST /api/anchor":                                     {Summary: "Anchor a SHA-256 document hash on-chain", Tag: "Anchoring", Request: AnchorRequest{}},
+	"POST /api/anchor/verify":                              {Summary: "Hash the document in the body and check whether it is anchored", Tag: "Anchoring", Upload: true},
+	"GET /api/anchor/{hash}":                               {Summary: "Proof that a document hash was anchored", Tag: "Anchoring"},
+	"GET /api/logs/system":                                 {Summary: "System logs", Tag: "Analytics", Response: []services.LogEntry{}, Query: []queryParam{{"limit", "integer", "Maximum entries (default 100)"}}},
+	"GET /api/logs/transactions":                           {Summary: "Transaction logs", Tag: "Analytics", Response: []services.TransactionLog{}, Query: []queryParam{{"limit", "integer", "Maximum entries (default 100)"}}},
+	"GET /api/logs/transactions/{wallet}":                  {Summary: "Transaction logs of a wallet", Tag: "Analytics", Response: []services.TransactionLog{}, Query: []queryParam{{"limit", "integer", "Maximum entries (default 100)"}}},
+	"GET /api/reports/wallet/{wallet}":                     {Summary: "Wallet activity report", Tag: "Analytics"},
+	"GET /api/supply":                                      {Summary: "Issued and circulating supply, the supply cap and issuance per day", Tag: "Blockchain", Response: services.SupplyReport{}, Query: []queryParam{{"days", "integer", "Days of history, newest last (default 30, 0 for all)"}}},
+	"GET /api/rates":                                       {Summary: "Current price of one coin in each fiat currency", Tag: "Blockchain", Response: RatesResponse{}},
+	"GET /api/assets":                                      {Summary: "List the assets the chain carries besides the coin", Tag: "Blockchain", Response: []services.Asset{}},
+	"GET /api/assets/{symbol}":                             {Summary: "Get an asset and how much of it has been issued", Tag: "Blockchain", Response: services.Asset{}},
+	"GET /api/rates/history":                               {Summary: "Rates of a currency over time, for pricing past transactions", Tag: "Blockchain", Response: RateHistoryResponse{}, Query: []queryParam{{"currency", "string", "PKR or USD"}, {"from", "string", "RFC 3339 timestamp or YYYY-MM-DD"}, {"to", "string", "RFC 3339 timestamp or YYYY-MM-DD"}}},
+	"GET /api/reports/system":                              {Summary: "System statistics", Tag: "Analytics"},
+	"GET /api/statements/{wallet}":                         {Summary: "Monthly statements emailed to a wallet, newest first", Tag: "Analytics", Owner: true},
+	"GET /api/statements/{wallet}/{period}":                {Summary: "Generate a wallet statement for a month (YYYY-MM) with its transactions", Tag: "Analytics", Response: services.Statement{}, Owner: true},
+	"GET /api/beneficiaries/{user_id}":                     {Summary: "List beneficiaries", Tag: "Beneficiaries"},
+	"POST /api/beneficiaries":                              {Summary: "Add a beneficiary", Tag: "Beneficiaries", Request: AddBeneficiaryRequest{}, Response: StatusResponse{}},
+	"PUT /api/beneficiaries/{user_id}/{beneficiary_id}":    {Summary: "Update a beneficiary", Tag: "Beneficiaries", Request: UpdateBeneficiaryRequest{}, Response: StatusResponse{}},
+	"DELETE /api/beneficiaries/{user_id}/{beneficiary_id}": {Summary: "Remove a beneficiary", Tag: "Beneficiaries", Response: StatusResponse{}},
+	"GET /api/zakat/{wallet}":                              {Summary: "Zakat deductions of a wallet", Tag: "Zakat"},
+	"GET /api/zakat/{wallet}/preview":                      {Summary: "What the next zakat run would deduct from a wallet, or why nothing", Tag: "Zakat", Response: services.ZakatAssessment{}},
+	"GET /api/zakat/preview":                               {Summary: "What the next zakat run would deduct from each wallet and in total, without creating transactions", Tag: "Zakat", Admin: true, Response: services.ZakatPreview{}, Query: []queryParam{{"due", "boolean", "Only the wallets that would pay"}}},
+	"GET /api/zakat/charities":                             {Summary: "Charities the zakat pool is distributed to, with their weights", Tag: "Zakat", Response: []services.Charity{}},
+	"GET /api/zakat/distributions":                         {Summary: "Splits of the collected zakat between charities, newest first", Tag: "Zakat", Response: []services.ZakatDistribution{}, Query: []queryParam{{"limit", "integer", "Maximum number of distributions"}}},
+	"GET /api/campaigns":                                   {Summary: "Fundraising campaigns with their progress, newest first", Tag: "Zakat", Response: []services.CampaignProgress{}, Query: []queryParam{{"status", "string", "open, closed or all (default)"}}},
+	"GET /api/campaigns/{id}":                              {Summary: "A campaign's progress and donations", Tag: "Zakat", Response: CampaignResponse{}},
+	"POST /api/campaigns/{id}/donate":                      {Summary: "Donate to an open campaign with a signed transfer to its wallet", Tag: "Zakat", Request: DonateRequest{}, Response: SendResponse{}},
+	"PUT /api/profile/{wallet}":                            {Summary: "Update a wallet profile", Tag: "Wallets", Request: UpdateProfileRequest{}},
+	"POST /api/otp/send":                                   {Summary: "Send a one-time code by email", Tag: "OTP", Request: SendOTPRequest{}, Response: StatusResponse{}},
+	"POST /api/otp/verify":                                 {Summary: "Verify a one-time code", Tag: "OTP", Request: VerifyOTPRequest{}, Response: VerifyOTPResponse{}},
+	"POST /api/wallet/{wallet}/export":                     {Summary: "Download a passphrase-encrypted wallet backup", Tag: "Wallets", Request: ExportWalletRequest{}, Response: WalletBackup{}},
+	"POST /api/wallet/import":                              {Summary: "Restore a wallet from a backup file", Tag: "Wallets", Request: ImportWalletRequest{}, Response: ImportWalletResponse{}, Status: http.StatusCreated},
+	"POST /api/2fa/enroll":                                 {Summary: "Start authenticator app enrollment", Tag: "2FA", Request: TwoFactorEnrollRequest{}, Response: TwoFactorEnrollResponse{}},
+	"POST /api/2fa/verify":                                 {Summary: "Confirm enrollment with the first code", Tag: "2FA", Request: TwoFactorVerifyRequest{}, Response: services.TwoFactor{}},
+	"POST /api/2fa/disable":                                {Summary: "Turn authenticator 2FA off", Tag: "2FA", Request: TwoFactorDisableRequest{}, Response: StatusResponse{}},
+	"GET /api/2fa/{wallet}":                                {Summary: "Wallet 2FA status and send threshold", Tag: "2FA", Response: services.TwoFactor{}},
+	"PUT /api/2fa/{wallet}/threshold":                      {Summary: "Set the amount above which sends need a code", Tag: "2FA", Request: TwoFactorThresholdRequest{}, Response: services.TwoFactor{}},
+	"GET /api/auth/google/config":                          {Summary: "Whether Google login is enabled and its client ID", Tag: "Auth"},
+	"POST /api/auth/google":                                {Summary: "Log in with a Google ID token", Tag: "Auth", Request: GoogleLoginRequest{}, Response: LoginResponse{}},
+	"GET /api/auth/session":                                {Summary: "The session behind the bearer token", Tag: "Auth", Response: services.Session{}},
+	"POST /api/login":                                      {Summary: "Log in an email verified by OTP or a login session; returns its wallets and a new session", Tag: "Auth", Request: LoginRequest{}, Response: EmailLoginResponse{}},
+	"POST /api/auth/logout":                                {Summary: "Revoke the bearer token", Tag: "Auth", Response: StatusResponse{}},
+	"GET /api/account/wallets":                             {Summary: "The session email's wallets with labels and balances, and their total", Tag: "Accounts", Response: AccountWalletsResponse{}},
+	"POST /api/account/wallets":                            {Summary: "Add a wallet to the session's email, optionally labeled", Tag: "Accounts", Request: AccountWalletRequest{}, Response: wallet.Wallet{}, Status: http.StatusCreated},
+	"PUT /api/account/wallets/{wallet}":                    {Summary: "Label one of the session email's wallets (empty removes the label)", Tag: "Accounts", Request: WalletLabelRequest{}, Response: LoginWallet{}},
+	"GET /api/admin/check/{wallet}":                        {Summary: "Whether a wallet is an admin", Tag: "Admin"},
+	"GET /api/admin/deliveries": {Summary: "Outbound delivery records", Tag: "Admin", Admin: true, Response: []services.Delivery{}, Query: []queryParam{
+		{"status", "string", "failed (default), delivered or all"},
+		{"channel", "string", "webhook or email"},
+		{"limit", "integer", "Maximum records (default 100)"},
+	}},
+	"POST /api/admin/deliveries/redeliver": {Summary: "Re-send failed deliveries", Tag: "Admin", Admin: true, Request: RedeliverRequest{}},
+	"GET /api/admin/logs/export": {Summary: "Stream the whole system or transaction log as CSV or NDJSON", Tag: "Admin", Admin: true, Query: []queryParam{
+		{"type", "string", "system or tx"},
+		{"format", "string", "ndjson (default) or csv"},
+		{"from", "string", "Earliest entry, RFC 3339 or YYYY-MM-DD"},
+		{"to", "string", "Entries before this time, RFC 3339 or YYYY-MM-DD"},
+		{"wallet", "string", "Only entries about this wallet"},
+	}},
+	"GET /api/admin/audit": {Summary: "Audited state-changing API calls, newest first", Tag: "Admin", Admin: true, Response: AuditLogResponse{}, Query: []queryParam{
+		{"wallet", "string", "Only calls on this wallet"},
+		{"route", "string", "Only calls to this route template, e.g. /api/send"},
+		{"method", "string", "POST, PUT, PATCH or DELETE"},
+		{"from", "string", "Earliest call, RFC 3339 or YYYY-MM-DD"},
+		{"to", "string", "Calls before this time, RFC 3339 or YYYY-MM-DD"},
+		{"before", "integer", "Calls with a lower ID: next_before of the previous page"},
+		{"limit", "integer", "Maximum calls (default 100, at most 1000)"},
+	}},
+	"POST /api/admin/wallets/{wallet}/freeze":   {Summary: "Freeze a wallet: it can receive but not send", Tag: "Admin", Admin: true, Request: FreezeRequest{}, Response: FreezeResponse{}},
+	"GET /api/admin/faucet/ledger":              {Summary: "Recent faucet grants, claims and signup grants", Tag: "Admin", Admin: true, Response: []services.FaucetGrant{}, Query: []queryParam{{"limit", "integer", "Maximum number of grants (default 100)"}}},
+	"PUT /api/admin/limits/{wallet}":            {Summary: "Set a wallet's spending limits without owner proof", Tag: "Admin", Admin: true, Request: SpendingLimits{}, Response: SpendingLimitsResponse{}},
+	"POST /api/admin/wallets/{wallet}/unfreeze": {Summary: "Let a frozen wallet send again", Tag: "Admin", Admin: true, Response: FreezeResponse{}},
+	"GET /api/admin/balances":                   {Summary: "Balance recompute counters, lock conflicts and the last repair run", Tag: "Admin", Admin: true, Response: BalanceStatsResponse{}},
+	"POST /api/admin/balances/repair":           {Summary: "Recompute every stored balance that drifted from the persisted UTXOs", Tag: "Admin", Admin: true, Response: services.BalanceRepairRun{}},
+	"GET /api/admin/reconcile":                  {Summary: "Ledger recomputed from the UTXO set, with wallets whose stored balance or persisted UTXOs disagree", Tag: "Admin", Admin: true, Response: services.Reconciliation{}},
+	"POST /api/admin/reconcile/repair":          {Summary: "Write the ledger back to the database for every wallet that disagrees with it", Tag: "Admin", Admin: true, Response: services.Reconciliation{}},
+	"GET /api/admin/utxos/prune":                {Summary: "UTXO pruning policy and the last pass", Tag: "Admin", Admin: true, Response: PrunePolicyResponse{}},
+	"POST /api/admin/utxos/prune":               {Summary: "Archive and drop UTXOs spent more than keep_blocks blocks ago", Tag: "Admin", Admin: true, Request: PruneRequest{}, Response: services.PruneRun{}},
+	"PUT /api/admin/utxos/prune/policy":         {Summary: "Change how many blocks keep their spent UTXOs and how often pruning runs", Tag: "Admin", Admin: true, Request: PrunePolicyRequest{}, Response: PrunePolicyResponse{}},
+	"GET /api/admin/snapshots":                  {Summary: "Stored chain snapshots, newest first", Tag: "Admin", Admin: true, Response: []services.SnapshotInfo{}},
+	"POST /api/admin/snapshots":                 {Summary: "Snapshot the chain state at the current tip", Tag: "Admin", Admin: true, Response: services.SnapshotInfo{}, Status: http.StatusCreated},
+	"GET /api/admin/vesting":                    {Summary: "Every vesting grant on the chain, newest first", Tag: "Admin", Admin: true, Response: []services.VestingGrant{}},
+	"POST /api/admin/assets":                    {Summary: "Define an asset and issue its initial supply to the issuer wallet", Tag: "Admin", Admin: true, Request: DefineAssetRequest{}, Response: AssetIssueResponse{}},
+	"POST /api/admin/assets/{symbol}/mint":      {Summary: "Issue more units of a mintable asset, up to its max supply", Tag: "Admin", Admin: true, Request: MintAssetRequest{}, Response: AssetIssueResponse{}},
+	"POST /api/admin/fixtures":                  {Summary: "Generate load-test wallets, random transfers and blocks; needs ALLOW_CHAIN_RESET or a SANDBOX", Tag: "Admin", Admin: true, Request: FixturesRequest{}, Response: FixturesResponse{}},
+	"POST /api/admin/reset":                     {Summary: "Wipe the chain, mempool and UTXOs and start from a new genesis block; needs ALLOW_CHAIN_RESET or a SANDBOX", Tag: "Admin", Admin: true, Response: ResetChainResponse{}},
+	"POST /api/admin/chain/import":              {Summary: "Check a chain export in the body and load it into a node whose chain has only its genesis block", Tag: "Admin", Admin: true, Upload: true, Status: http.StatusCreated, Response: ChainImportResponse{}, Query: []queryParam{{"format", "string", "json or protobuf; by default from the Content-Type, else json"}}},
+	"POST /api/admin/mint":                      {Summary: "Mint new coins to a wallet; needs otp_code sent to the admin email", Tag: "Admin", Admin: true, Request: SupplyAdjustmentRequest{}, Response: SupplyAdjustmentResponse{}},
+	"POST /api/admin/burn":                      {Summary: "Burn coins from a wallet, returning its change; needs otp_code sent to the admin email", Tag: "Admin", Admin: true, Request: SupplyAdjustmentRequest{}, Response: SupplyAdjustmentResponse{}},
+	"GET /api/admin/supply/adjustments":         {Summary: "Every admin mint and burn, newest first", Tag: "Admin", Admin: true, Response: []services.SupplyAdjustment{}},
+	"PUT /api/admin/rates/{currency}":           {Summary: "Set the price of one coin in PKR or USD, effective now", Tag: "Admin", Admin: true, Request: SetRateRequest{}, Response: services.Rate{}},
+	"POST /api/admin/statements/run":            {Summary: "Email a finished month's statements to opted-in wallets not yet sent one", Tag: "Admin", Admin: true, Response: services.StatementRun{}, Query: []queryParam{{"period", "string", "Month as YYYY-MM (default: the previous month)"}}},
+	"POST /api/admin/announcements":             {Summary: "Broadcast a message on the websocket announcements topic", Tag: "Admin", Admin: true, Request: AnnouncementRequest{}, Response: StatusResponse{}},
+	"GET /api/admin/wallet-type-requests": {Summary: "Wallet type change requests", Tag: "Admin", Admin: true, Response: []services.TypeChangeRequest{}, Query: []queryParam{
+		{"status", "string", "pending (default), approved, rejected or all"},
+	}},
+	"POST /api/admin/wallet-type-requests/{id}/{decision}": {Summary: "Approve or reject a type change", Tag: "Admin", Admin: true, Response: services.TypeChangeRequest{}},
+	"GET /api/admin/inheritance/payouts": {Summary: "Inheritance payouts filed for inactive wallets", Tag: "Admin", Admin: true, Response: []services.InheritancePayout{}, Query: []queryParam{
+		{"status", "string", "pending (default), approved, paid, rejected, cancelled or all"},
+		{"wallet_id", "string", "Only payouts of this wallet"},
+	}},
+	"POST /api/admin/inheritance/payouts/{id}/{decision}": {Summary: "Approve a payout, splitting the wallet's balance between its nominees, or reject it", Tag: "Admin", Admin: true, Request: InheritanceDecisionRequest{}, Response: services.InheritancePayout{}},
+	"POST /api/admin/inheritance/check":                   {Summary: "File payouts for inactive wallets and pay approved ones now", Tag: "Admin", Admin: true, Response: services.InheritanceRun{}},
+	"POST /api/admin/campaigns":                           {Summary: "Open a fundraising campaign for an active wallet", Tag: "Admin", Admin: true, Request: CreateCampaignRequest{}, Response: services.CampaignProgress{}, Status: http.StatusCreated},
+	"PUT /api/admin/campaigns/{id}":                       {Summary: "Change an active campaign's name, description, target or deadline", Tag: "Admin", Admin: true, Request: UpdateCampaignRequest{}, Response: services.CampaignProgress{}},
+	"DELETE /api/admin/campaigns/{id}":                    {Summary: "Delete a campaign nobody has donated to", Tag: "Admin", Admin: true, Response: StatusResponse{}},
+	"POST /api/admin/campaigns/{id}/close":                {Summary: "Stop a campaign taking donations", Tag: "Admin", Admin: true, Response: services.CampaignProgress{}},
+	"PUT /api/admin/charities/{wallet}":                   {Summary: "Register a charity for the zakat split or change its name and weight", Tag: "Admin", Admin: true, Request: CharityRequest{}, Response: services.Charity{}},
+	"DELETE /api/admin/charities/{wallet}":                {Summary: "Take a charity out of future zakat distributions", Tag: "Admin", Admin: true, Response: StatusResponse{}},
+	"GET /api/admin/indexes":                              {Summary: "Database index advisor report", Tag: "Admin", Admin: true},
+	"POST /api/admin/indexes/ensure":                      {Summary: "Create missing required indexes", Tag: "Admin", Admin: true},
+	"GET /api/admin/alerts/operational": {Summary: "Firing operational alerts and rule definitions", Tag: "Admin", Admin: true, Query: []queryParam{
+		{"format", "string", "prometheus for the text exposition format"},
+	}},
+	"GET /api/admin/usage": {Summary: "API usage per endpoint and client", Tag: "Admin", Admin: true, Response: UsageReport{}, Query: []queryParam{
+		{"deprecated", "boolean", "Only deprecated routes"},
+		{"route", "string", "Route template, e.g. /api/wallet/{wallet}"},
+		{"client", "string", "Substring of the client (api_key:<fingerprint> or ua:<user agent>)"},
+	}},
+	"GET /api/admin/orgs":                {Summary: "Organizations (multi-tenant mode)", Tag: "Organizations", Admin: true, Response: []services.Organization{}},
+	"POST /api/admin/orgs":               {Summary: "Create an organization", Tag: "Organizations", Admin: true, Request: CreateOrgRequest{}, Response: services.Organization{}, Status: http.StatusCreated},
+	"GET /api/admin/orgs/{org}":          {Summary: "Organization details", Tag: "Organizations", Admin: true, Response: services.Organization{}},
+	"POST /api/admin/orgs/{org}/wallets": {Summary: "Move a wallet into an organization", Tag: "Organizations", Admin: true, Request: OrgWalletRequest{}, Response: services.Organization{}},
+	"GET /api/org":                       {Summary: "The organization the request is scoped to", Tag: "Organizations", Response: services.Organization{}},
+	"GET /api/org/wallets":               {Summary: "Member wallets with balances", Tag: "Organizations", OrgAdmin: true, Response: []OrgWallet{}},
+	"POST /api/org/admins":               {Summary: "Make a member wallet an organization admin", Tag: "Organizations", OrgAdmin: true, Request: OrgWalletRequest{}, Response: services.Organization{}},
+	"DELETE /api/org/admins/{wallet}":    {Summary: "Revoke organization admin rights", Tag: "Organizations", OrgAdmin: true, Response: services.Organization{}},
+	"GET /api/org/config":                {Summary: "Configuration overrides and the effective configuration", Tag: "Organizations", OrgAdmin: true, Response: OrgConfigResponse{}},
+	"PATCH /api/org/config":              {Summary: "Override configuration (JSON merge patch; null restores the default)", Tag: "Organizations", OrgAdmin: true, Request: services.TenantConfig{}, Response: OrgConfigResponse{}},
+
+	"GET /api/errors":          {Summary: "Error code catalog", Tag: "Meta"},
+	"GET /api/schemas":         {Summary: "Event types and schema versions", Tag: "Meta"},
+	"GET /api/schemas/{event}": {Summary: "JSON Schema of an event type", Tag: "Meta", Query: []queryParam{{"version", "integer", "Schema version (latest by default)"}}},
+	"GET /api/health":          {Summary: "Liveness check (same as /livez)", Tag: "Meta"},
+	"GET /livez":               {Summary: "Liveness check: the process is serving requests", Tag: "Meta"},
+	"GET /readyz":              {Summary: "Readiness check of the database, chain, zakat scheduler and peers (503 when any is down)", Tag: "Meta", Response: ReadinessResponse{}},
+	"GET /api/capabilities":    {Summary: "Enabled features and the organization's faucet, zakat, fee, currency and branding settings", Tag: "Meta", Response: CapabilitiesResponse{}},
+	"GET /api/openapi.json":    {Summary: "This OpenAPI document", Tag: "Meta"},
+	"GET /api/docs":            {Summary: "Swagger UI", Tag: "Meta", HTML: true},
+}
+
+// pathParamTypes lists the path variables that are not plain strings
+var pathParamTypes = map[string]string{
+	"index":          "integer",
+	"id":             "integer",
+	"beneficiary_id": "integer",
+}
+
+var pathVarPattern = regexp.MustCompile(`\{([^}:]+)(?::([^}]+))?\}`)
+
+// handleOpenAPI serves the OpenAPI 3 document generated from the router
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	spec, err := s.openAPISpec()
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to generate OpenAPI document: "+err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(spec)
+}
+
+// handleSwaggerUI serves the Swagger UI page pointed at /api/openapi.json
+func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(swaggerPage)
+}
+
+// openAPISpec walks the registered routes and builds the OpenAPI document,
+// describing request and response bodies by reflecting over their types
+func (s *Server) openAPISpec() (map[string]interface{}, error) {
+	gen := &schemaGen{components: map[string]interface{}{}, names: map[reflect.Type]string{}}
+	paths := map[string]map[string]interface{}{}
+
+	err := s.r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // subrouters have no methods
+		}
+
+		path := pathVarPattern.ReplaceAllString(tpl, "{$1}")
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+			if paths[path] == nil {
+				paths[path] = map[string]interface{}{}
+			}
+			paths[path][strings.ToLower(method)] = gen.operation(method, path, tpl, routeDocs[method+" "+path])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gen.components["ErrorBody"] = gen.errorBodySchema()
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Blockchain Wallet API",
+			"version":     "1.0.0",
+			"description": "UTXO wallet, transaction, mining and explorer API. Errors use the envelope {\"error\":{\"code\",\"message\",\"request_id\"}}; see GET /api/errors. In multi-tenant mode most routes also need an X-Org-ID header.",
+		},
+		"servers": []map[string]string{{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": gen.components,
+			"securitySchemes": map[string]interface{}{
+				"AdminKey":    map[string]string{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+				"AdminWallet": map[string]string{"type": "apiKey", "in": "header", "name": "X-Wallet-ID"},
+				"OrgID":       map[string]string{"type": "apiKey", "in": "header", "name": orgIDHeader},
+				"Session":     map[string]string{"type": "http", "scheme": "bearer", "description": "Login session token from OTP or Google login"},
+			},
+		},
+	}, nil
+}
+
+func (g *schemaGen) operation(method, path, tpl string, doc routeDoc) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": operationID(method, path),
+	}
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+	if doc.Tag != "" {
+		op["tags"] = []string{doc.Tag}
+	}
+
+	var params []map[string]interface{}
+	for _, m := range pathVarPattern.FindAllStringSubmatch(tpl, -1) {
+		schema := map[string]interface{}{"type": "string"}
+		if t, ok := pathParamTypes[m[1]]; ok {
+			schema["type"] = t
+		}
+		if m[2] != "" {
+			schema["enum"] = strings.Split(m[2], "|")
+		}
+		params = append(params, map[string]interface{}{"name": m[1], "in": "path", "required": true, "schema": schema})
+	}
+	for _, q := range doc.Query {
+		param := map[string]interface{}{"name": q.Name, "in": "query", "schema": map[string]string{"type": q.Type}}
+		if q.Description != "" {
+			param["description"] = q.Description
+		}
+		params = append(params, param)
+	}
+	if params != nil {
+		op["parameters"] = params
+	}
+
+	switch {
+	case doc.Upload:
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/octet-stream": map[string]interface{}{"schema": map[string]string{"type": "string", "format": "binary"}}},
+		}
+	case doc.Request != nil:
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(doc.Request))}},
+		}
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+	switch {
+	case doc.HTML:
+		success["content"] = map[string]interface{}{"text/html": map[string]interface{}{"schema": map[string]string{"type": "string"}}}
+	case doc.Binary:
+		success["content"] = map[string]interface{}{"application/octet-stream": map[string]interface{}{"schema": map[string]string{"type": "string", "format": "binary"}}}
+	case doc.Image:
+		success["content"] = map[string]interface{}{
+			"image/png":     map[string]interface{}{"schema": map[string]string{"type": "string", "format": "binary"}},
+			"image/svg+xml": map[string]interface{}{"schema": map[string]string{"type": "string"}},
+		}
+	case doc.Response != nil:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(doc.Response))}}
+	default:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]string{"type": "object"}}}
+	}
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op["responses"] = map[string]interface{}{
+		fmt.Sprint(status): success,
+		"default": map[string]interface{}{
+			"description": "Error envelope",
+			"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"error": map[string]string{"$ref": "#/components/schemas/ErrorBody"}},
+			}}},
+		},
+	}
+
+	if doc.Deprecated != nil {
+		op["deprecated"] = true
+		note := "Deprecated; removed on " + doc.Deprecated.Sunset + "."
+		if doc.Deprecated.Successor != "" {
+			note += " Use " + doc.Deprecated.Successor + " instead."
+		}
+		op["description"] = note
+	}
+
+	if doc.Admin {
+		op["security"] = []map[string][]string{{"AdminKey": {}}, {"AdminWallet": {}, "Session": {}}}
+	}
+	if doc.Owner {
+		op["security"] = []map[string][]string{{"Session": {}}}
+	}
+	if doc.OrgAdmin {
+		op["security"] = []map[string][]string{{"OrgID": {}, "AdminWallet": {}, "Session": {}}, {"OrgID": {}, "AdminKey": {}}}
+	}
+	return op
+}
+
+// operationID derives a stable ID such as getWalletByWalletUpdates
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(strings.TrimPrefix(path, "/api"), "/") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, "{") {
+			b.WriteString("By")
+			seg = strings.Trim(seg, "{}")
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// schemaGen converts Go types to OpenAPI schemas, registering named structs
+// as reusable components
+type schemaGen struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	errorCodeType = reflect.TypeOf(ErrorCode(""))
+)
+
+func (g *schemaGen) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case errorCodeType:
+		return g.errorCodeSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + g.component(t)}
+	}
+	return map[string]interface{}{}
+}
+
+// component registers a named struct once and returns its component name,
+// qualifying it with the package when two packages use the same type name
+func (g *schemaGen) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	g.components[name] = map[string]interface{}{} // placeholder for recursive types
+	g.components[name] = g.structSchema(t)
+	return name
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	// VisibleFields promotes the fields of embedded structs, as encoding/json does
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || (f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGen) errorCodeSchema() map[string]interface{} {
+	codes := make([]string, 0, len(errorCatalog))
+	for code := range errorCatalog {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	return map[string]interface{}{"type": "string", "enum": codes}
+}
+
+func (g *schemaGen) errorBodySchema() map[string]interface{} {
+	return g.structSchema(reflect.TypeOf(ErrorBody{}))
+}
@@ -0,0 +1,619 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// The operations below are shared by the REST handlers and the gRPC server so
+// both transports apply the same validation, logging, events and persistence.
+// ctx carries the request ID used in logs.
+
+// opError is an operation failure tagged with the API error code it maps to
+type opError struct {
+	code   ErrorCode
+	msg    string
+	fields validation.Errors
+}
+
+func (e *opError) Error() string { return e.msg }
+
+func fail(code ErrorCode, msg string) error {
+	return &opError{code: code, msg: msg}
+}
+
+// invalid fails an operation with field-level validation errors
+func invalid(errs validation.Errors) error {
+	return &opError{code: CodeValidationFailed, msg: errs.Error(), fields: errs}
+}
+
+// errorCode returns the API error code for an operation error
+func errorCode(err error) ErrorCode {
+	var oe *opError
+	if errors.As(err, &oe) {
+		return oe.code
+	}
+	return CodeInternal
+}
+
+// writeOpError writes an operation error as the standard error envelope
+func writeOpError(w http.ResponseWriter, r *http.Request, err error) {
+	var oe *opError
+	if errors.As(err, &oe) {
+		writeError(w, r, oe.code, oe.msg, oe.fields)
+		return
+	}
+	Error(w, r, errorCode(err), err.Error())
+}
+
+type createWalletInput struct {
+	Public  string
+	Private string
+	Name    string
+	Email   string
+	CNIC    string
+	Type    string
+	// SessionToken is a login session for Email, proving it when no code
+	// was verified for it
+	SessionToken string
+	// Additional adds a wallet for an email that may already have some
+	Additional bool
+}
+
+// createWallet registers a wallet, files a type change for any requested type
+// other than personal and, when the faucet runs in signup mode, grants faucet
+// coins to personal wallets
+func (s *Server) createWallet(ctx context.Context, in createWalletInput, remoteAddr string) (wallet.Wallet, error) {
+	errs := in.validate()
+	if in.Type != "" && !wallet.ValidType(in.Type) {
+		errs.Add("type", "is not a wallet type")
+	}
+	if len(errs) > 0 {
+		s.logSvc.LogSystemCtx(ctx, "wallet_creation_failed", "", remoteAddr, errs.Error())
+		return wallet.Wallet{}, invalid(errs)
+	}
+
+	// The email must be proven before it is registered
+	if _, err := s.verifyEmailOwner(in.Email, in.SessionToken); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "wallet_creation_failed", "", remoteAddr, "Email not verified: "+in.Email)
+		return wallet.Wallet{}, err
+	}
+
+	// Registering a retired or deactivated wallet again must not revive it
+	if wid, err := wallet.WalletIDFromPub(in.Public); err == nil {
+		if existing, ok := s.ws.Get(wid); ok && !existing.Active() {
+			s.logSvc.LogSystemCtx(ctx, "wallet_creation_failed", wid, remoteAddr, "Wallet is "+existing.Status)
+			return wallet.Wallet{}, fail(CodeWalletInactive, "The wallet was "+existing.Status+" and cannot be registered again")
+		}
+	}
+
+	// Check if email already exists in database
+	if s.db != nil && !in.Additional {
+		dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		emailExists, err := s.db.CheckEmailExists(dbCtx, in.Email)
+		cancel()
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "email_check_failed", "", remoteAddr, err.Error())
+			return wallet.Wallet{}, fail(CodeInternal, "Failed to verify email")
+		}
+		if emailExists {
+			s.logSvc.LogSystemCtx(ctx, "wallet_creation_failed", "", remoteAddr, "Email already registered: "+in.Email)
+			return wallet.Wallet{}, fail(CodeEmailTaken, "Email already registered. Please use a different email or login with existing wallet.")
+		}
+	}
+
+	wobj, err := s.ws.CreateFromPub(in.Public, in.Private, in.Name, in.Email, in.CNIC)
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "wallet_creation_failed", "", remoteAddr, err.Error())
+		return wallet.Wallet{}, fail(CodeValidationFailed, err.Error())
+	}
+
+	// In multi-tenant mode the wallet joins the organization it was created in
+	if orgID := services.OrgFrom(ctx); orgID != "" {
+		if err := s.orgs.Assign(wobj.WalletID, orgID); err != nil {
+			return wallet.Wallet{}, orgError(err)
+		}
+		wobj, _ = s.ws.Get(wobj.WalletID)
+	}
+
+	// Every wallet starts as personal; other types need admin approval, and
+	// wallets asking for one do not get faucet coins
+	var faucetUTXO *blockchain.UTXO
+	if in.Type != "" && in.Type != wallet.TypePersonal {
+		change, err := s.walletTypes.RequestChange(wobj.WalletID, in.Type, "requested at wallet creation", wobj.WalletID, false)
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "wallet_type_change_failed", wobj.WalletID, remoteAddr, err.Error())
+		} else {
+			s.logSvc.LogSystemCtx(ctx, "wallet_type_change_requested", wobj.WalletID, remoteAddr, change.FromType+" -> "+change.ToType)
+		}
+	} else if amount := s.config.ForWallet(wobj.WalletID).FaucetAmount; amount > 0 && s.faucet.GrantsOnSignup() {
+		// Legacy faucet: the new wallet starts with a balance; otherwise the
+		// owner claims coins with POST /api/faucet/claim
+		grant, utxo := s.faucet.GrantOnSignup(wobj.WalletID, wobj.Email, remoteIP(remoteAddr), amount)
+		faucetUTXO = &utxo
+		s.logSvc.LogSystemCtx(ctx, "faucet_granted", wobj.WalletID, remoteAddr, fmt.Sprintf("Initial balance of %s coins granted", blockchain.FormatAmount(grant.Amount)))
+		s.feed.Publish(events.FaucetGranted, wobj.WalletID, map[string]interface{}{
+			"utxo_id": faucetUTXO.ID,
+			"amount":  faucetUTXO.Amount,
+		})
+	}
+
+	// Persist to database if available
+	if s.store != nil {
+		dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+
+		// The wallet row, its faucet UTXO and its balance commit together
+		err := s.store.Atomic(dbCtx, func(tx database.Store) error {
+			if err := tx.SaveWallet(dbCtx, wobj.WalletID, wobj.PublicKey, wobj.PrivateKey, wobj.FullName, wobj.Email, wobj.CNIC, wobj.Type); err != nil {
+				return err
+			}
+			// The organization was assigned before the row existed
+			if wobj.OrgID != "" {
+				if err := tx.UpdateWalletOrg(dbCtx, wobj.WalletID, wobj.OrgID); err != nil {
+					return err
+				}
+			}
+			if faucetUTXO != nil {
+				if err := tx.SaveUTXO(dbCtx, faucetUTXO.ID, faucetUTXO.Owner, faucetUTXO.AssetID, faucetUTXO.Amount, faucetUTXO.OriginTx, faucetUTXO.Index, faucetUTXO.Spent); err != nil {
+					return err
+				}
+			}
+			// Recompute the stored balance from the persisted UTXOs
+			return s.balances.SyncIn(dbCtx, tx, wobj.WalletID)
+		})
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "wallet_db_save_failed", wobj.WalletID, remoteAddr, err.Error())
+			// Continue anyway - wallet is in memory
+		} else {
+			s.logSvc.LogSystemCtx(ctx, "wallet_persisted", wobj.WalletID, remoteAddr, "Wallet saved to database")
+		}
+	}
+
+	// The verified code is spent on this wallet
+	otp.ClearOTP(in.Email)
+
+	s.logSvc.LogSystemCtx(ctx, "wallet_created", wobj.WalletID, remoteAddr, fmt.Sprintf("Wallet created for %s", in.Name))
+	return wobj, nil
+}
+
+// verifyEmailOwner checks that the caller controls an email, by a code
+// verified with POST /api/otp/verify or a login session for it, and returns
+// the login method that proved it
+func (s *Server) verifyEmailOwner(email, sessionToken string) (string, error) {
+	if otp.IsVerified(email) {
+		return services.LoginOTP, nil
+	}
+	if sessionToken != "" {
+		sess, ok := s.sessions.Validate(sessionToken)
+		if !ok {
+			return "", fail(CodeUnauthorized, "Invalid or expired session token")
+		}
+		if !strings.EqualFold(email, sess.Email) {
+			return "", fail(CodeEmailNotVerified, "The session is for a different email")
+		}
+		return sess.Method, nil
+	}
+	return "", fail(CodeEmailNotVerified, "Verify the email with POST /api/otp/send and /api/otp/verify, or send its login session as Authorization: Bearer")
+}
+
+type sendInput struct {
+	SenderID      string
+	ReceiverID    string
+	ReceiverAlias string
+	Amount        uint64
+	AssetID       string
+	LockTime      int64
+	HashLock      string
+	Note          string
+	SigningToken  string
+	PrivateKey    string // deprecated in favour of SigningToken
+	TOTPCode      string
+	LimitOTP      string
+	Payments      []services.Payment // a batch; ReceiverID is then blockchain.BatchReceiver
+}
+
+// input is the send a SendRequest asks for
+func (req SendRequest) input() sendInput {
+	return sendInput{
+		SenderID:      req.SenderID,
+		ReceiverID:    req.ReceiverID,
+		ReceiverAlias: req.ReceiverAlias,
+		Amount:        req.Amount,
+		AssetID:       req.AssetID,
+		LockTime:      req.LockTime,
+		HashLock:      req.HashLock,
+		Note:          req.Note,
+		SigningToken:  req.SigningToken,
+		PrivateKey:    req.PrivateKey,
+		TOTPCode:      req.TOTPCode,
+		LimitOTP:      req.LimitOTP,
+	}
+}
+
+// receivers are the wallets a send pays
+func (in sendInput) receivers() []string {
+	if len(in.Payments) == 0 {
+		return []string{in.ReceiverID}
+	}
+	ids := make([]string, len(in.Payments))
+	for i, p := range in.Payments {
+		ids[i] = p.ReceiverID
+	}
+	return ids
+}
+
+// lock is the condition the receiver's output is paid under. A hash lock with
+// a lock time can be refunded to the sender once the time has passed.
+func (in sendInput) lock() blockchain.Lock {
+	lock := blockchain.Lock{LockTime: in.LockTime, HashLock: in.HashLock}
+	if in.HashLock != "" && in.LockTime != 0 {
+		lock.RefundTo = in.SenderID
+	}
+	return lock
+}
+
+// sendTransaction builds, validates and queues a transfer
+func (s *Server) sendTransaction(ctx context.Context, in sendInput, remoteAddr string) (*blockchain.Transaction, error) {
+	return s.sendTransactionOfType(ctx, in, "transfer", remoteAddr)
+}
+
+// createFunc builds and signs the transaction of a send
+type createFunc func(ctx context.Context, senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error)
+
+// sendTransactionOfType builds, validates and queues a transfer or a
+// donation; both go through the same limits and second factors
+func (s *Server) sendTransactionOfType(ctx context.Context, in sendInput, txType, remoteAddr string) (*blockchain.Transaction, error) {
+	create := func(ctx context.Context, senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+		if in.LockTime != 0 || in.HashLock != "" {
+			return s.txSvc.CreateLockedTransaction(ctx, senderID, receiverID, in.AssetID, amount, in.lock(), note, pubKey, privKey)
+		}
+		return s.txSvc.CreateAssetTransaction(ctx, senderID, receiverID, in.AssetID, amount, note, pubKey, privKey)
+	}
+	if txType == "donation" {
+		create = s.txSvc.CreateDonation
+	}
+	return s.sendWith(ctx, in, create, remoteAddr)
+}
+
+// sendWith builds a send with create and validates and queues it, after the
+// checks every send goes through: the sender's key, the receiver's
+// organization, spending limits, 2FA and the signing session's limit
+func (s *Server) sendWith(ctx context.Context, in sendInput, create createFunc, remoteAddr string) (*blockchain.Transaction, error) {
+	if errs := in.validate(); len(errs) > 0 {
+		s.logSvc.LogSystemCtx(ctx, "send_failed", in.SenderID, remoteAddr, errs.Error())
+		return nil, invalid(errs)
+	}
+
+	sender, err := s.resolveParties(ctx, &in)
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "send_failed", in.SenderID, remoteAddr, err.Error())
+		return nil, err
+	}
+
+	privateKey, session, err := s.resolveSigner(ctx, sender, in.SigningToken, in.PrivateKey, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create transaction with full UTXO logic
+	tx, err := create(ctx, in.SenderID, in.ReceiverID, in.Amount, in.Note, sender.PublicKey, privateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "send_failed", in.SenderID, remoteAddr, err.Error())
+		return nil, fail(transactionErrorCode(err), err.Error())
+	}
+
+	// Validate transaction. A send over the wallet's own spending limits goes
+	// through only with a one-time code emailed to the owner.
+	err = s.txSvc.ValidateTransaction(ctx, tx)
+	if errors.Is(err, services.ErrSpendingLimitExceeded) && in.LimitOTP != "" {
+		if sender.Email == "" || !otp.VerifyOTP(sender.Email, in.LimitOTP) {
+			s.logSvc.LogSystemCtx(ctx, "spending_limit_override_failed", in.SenderID, remoteAddr, "Invalid or expired OTP")
+			return nil, fail(CodeInvalidOTP, "Invalid or expired OTP")
+		}
+		otp.ClearOTP(sender.Email)
+		if err = s.txSvc.ValidateTransactionOverridingLimits(ctx, tx); err == nil {
+			s.logSvc.LogSystemCtx(ctx, "spending_limit_override", in.SenderID, remoteAddr, fmt.Sprintf("Send of %d confirmed by OTP", tx.Amount+tx.Fee))
+		}
+	}
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "transaction_validation_failed", in.SenderID, remoteAddr, err.Error())
+		return nil, fail(transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+	}
+
+	// High-value sends from wallets with 2FA need an authenticator code,
+	// unless one was already checked when the signing session was created
+	if session == nil || session.AuthMethod != services.SigningAuthTOTP {
+		if err := s.twoFactor.RequireForSend(in.SenderID, coinAmount(tx), in.TOTPCode); err != nil {
+			s.logSvc.LogSystemCtx(ctx, "send_2fa_failed", in.SenderID, remoteAddr, err.Error())
+			return nil, twoFactorError(err)
+		}
+	}
+
+	// The amount and fee count against the session's spend limit
+	if session != nil {
+		if _, err := s.signing.Reserve(in.SigningToken, in.SenderID, coinSpend(tx)); err != nil {
+			s.logSvc.LogSystemCtx(ctx, "send_failed", in.SenderID, remoteAddr, err.Error())
+			return nil, signingError(err)
+		}
+	}
+
+	if err := s.queueTransaction(ctx, tx, remoteAddr); err != nil {
+		if session != nil {
+			s.signing.Release(in.SigningToken, coinSpend(tx))
+		}
+		return nil, err
+	}
+	s.logSensitive(ctx, "transaction_sent", tx.SenderID, sender.Email, remoteAddr, fmt.Sprintf("Sent %d to %s in %s", tx.Amount, tx.ReceiverID, tx.ID))
+	return tx, nil
+}
+
+// resolveParties returns the sender of a send and resolves its receiver
+// alias or handle to a wallet ID, checking that every party is in the
+// caller's organization
+func (s *Server) resolveParties(ctx context.Context, in *sendInput) (wallet.Wallet, error) {
+	sender, exists := s.ws.Get(in.SenderID)
+	if !exists || !s.inOrg(ctx, in.SenderID) {
+		return wallet.Wallet{}, fail(CodeWalletNotFound, "Sender wallet not found")
+	}
+
+	// Resolve receiver alias through the sender's beneficiary list
+	if in.ReceiverAlias != "" {
+		if s.db == nil {
+			return wallet.Wallet{}, fail(CodeDatabaseUnavailable, "Database not connected")
+		}
+
+		dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receiverID, err := s.resolveBeneficiaryAlias(dbCtx, in.SenderID, in.ReceiverAlias)
+		cancel()
+		if err != nil {
+			return wallet.Wallet{}, fail(CodeAliasNotFound, err.Error())
+		}
+		in.ReceiverID = receiverID
+	}
+
+	// Resolve a receiver handle to the wallet holding it
+	if strings.HasPrefix(in.ReceiverID, "@") {
+		receiverID, ok := s.handles.Resolve(in.ReceiverID)
+		if !ok {
+			return wallet.Wallet{}, fail(CodeHandleNotFound, "No wallet has the handle "+in.ReceiverID)
+		}
+		if receiverID == in.SenderID {
+			return wallet.Wallet{}, fail(CodeValidationFailed, "receiver_id: must differ from sender_id")
+		}
+		in.ReceiverID = receiverID
+	}
+
+	// Organizations are separate economies: transfers stay inside one
+	for _, id := range in.receivers() {
+		if !s.inOrg(ctx, id) {
+			return wallet.Wallet{}, fail(CodeWalletNotFound, "Receiver wallet not found")
+		}
+	}
+	return sender, nil
+}
+
+// submitSignedTransaction validates a transfer signed offline and queues it,
+// so cold wallets never send their private key to the server
+func (s *Server) submitSignedTransaction(ctx context.Context, tx blockchain.Transaction, totpCode, remoteAddr string) (*blockchain.Transaction, error) {
+	if !s.inOrg(ctx, tx.SenderID) {
+		s.logSvc.LogSystemCtx(ctx, "send_failed", tx.SenderID, remoteAddr, "Sender wallet not found")
+		return nil, fail(CodeWalletNotFound, "Sender wallet not found")
+	}
+	if !s.inOrg(ctx, tx.ReceiverID) {
+		s.logSvc.LogSystemCtx(ctx, "send_failed", tx.SenderID, remoteAddr, "Receiver outside the organization")
+		return nil, fail(CodeWalletNotFound, "Receiver wallet not found")
+	}
+
+	accepted, err := s.txSvc.AcceptSignedTransaction(ctx, &tx, time.Now())
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "transaction_validation_failed", tx.SenderID, remoteAddr, "Signed transaction rejected: "+err.Error())
+		return nil, fail(transactionErrorCode(err), err.Error())
+	}
+
+	// High-value sends from wallets with 2FA need an authenticator code
+	if err := s.twoFactor.RequireForSend(accepted.SenderID, coinAmount(accepted), totpCode); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "send_2fa_failed", accepted.SenderID, remoteAddr, err.Error())
+		return nil, twoFactorError(err)
+	}
+
+	if err := s.queueTransaction(ctx, accepted, remoteAddr); err != nil {
+		return nil, err
+	}
+	sender, _ := s.ws.Get(accepted.SenderID)
+	s.logSensitive(ctx, "transaction_sent", accepted.SenderID, sender.Email, remoteAddr, fmt.Sprintf("Sent %d to %s in %s", accepted.Amount, accepted.ReceiverID, accepted.ID))
+	return accepted, nil
+}
+
+// coinAmount is the coins a transaction sends. Asset transfers send none, so
+// thresholds and limits set in coins pass them.
+func coinAmount(tx *blockchain.Transaction) uint64 {
+	if tx.AssetID != "" {
+		return 0
+	}
+	return tx.Amount
+}
+
+// coinSpend is what a transaction takes from the sender's coins, amount and
+// fee
+func coinSpend(tx *blockchain.Transaction) uint64 {
+	return coinAmount(tx) + tx.Fee
+}
+
+// queueTransaction adds a validated transaction to the pending pool, announces
+// it and persists it. The pool has the last word: it refuses duplicates and
+// inputs another pending transaction already spends.
+func (s *Server) queueTransaction(ctx context.Context, tx *blockchain.Transaction, remoteAddr string) error {
+	if err := s.bc.AddPending(*tx); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "transaction_rejected_by_mempool", tx.SenderID, remoteAddr, err.Error())
+		return fail(transactionErrorCode(err), "Transaction rejected: "+err.Error())
+	}
+	s.recordPending(ctx, tx, remoteAddr)
+	return nil
+}
+
+// queueTransactions is queueTransaction for transactions that must enter the
+// pool together: when the pool refuses one, none of them is queued
+func (s *Server) queueTransactions(ctx context.Context, txs []*blockchain.Transaction, remoteAddr string) error {
+	pending := make([]blockchain.Transaction, len(txs))
+	for i, tx := range txs {
+		pending[i] = *tx
+	}
+	if err := s.bc.AddPendingAll(pending); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "transaction_rejected_by_mempool", txs[0].SenderID, remoteAddr, err.Error())
+		return fail(transactionErrorCode(err), "Transaction rejected: "+err.Error())
+	}
+	for _, tx := range txs {
+		s.recordPending(ctx, tx, remoteAddr)
+	}
+	return nil
+}
+
+// recordPending announces and persists a transaction the pool admitted
+func (s *Server) recordPending(ctx context.Context, tx *blockchain.Transaction, remoteAddr string) {
+	s.logSvc.LogTransactionCtx(ctx, tx.ID, "created", tx.SenderID, "", "pending", remoteAddr)
+	s.feed.PublishTransaction(events.TxPending, *tx, nil)
+
+	// Persist pending transaction to database; it is in the pool already, so
+	// it is saved even if the client goes away
+	if s.store != nil {
+		dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := s.store.SaveTransaction(dbCtx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+			s.logSvc.LogSystemCtx(ctx, "transaction_db_save_failed", tx.SenderID, remoteAddr, err.Error())
+		}
+	}
+}
+
+// checkMiner fails unless minerID names an active wallet the caller may see,
+// and one the consensus lets produce the next block
+func (s *Server) checkMiner(ctx context.Context, minerID string) error {
+	if minerID == "" {
+		return fail(CodeValidationFailed, "Miner wallet ID is required")
+	}
+	miner, exists := s.ws.Get(minerID)
+	if !exists || !s.inOrg(ctx, minerID) {
+		return fail(CodeWalletNotFound, "Miner wallet not found")
+	}
+	if !miner.Active() {
+		return fail(CodeWalletInactive, "Miner wallet is "+miner.Status)
+	}
+	if p := s.bc.NextProducer(); p != "" && p != minerID {
+		return fail(CodeNotProducer, "The next block is validator "+p+"'s to produce")
+	}
+	return nil
+}
+
+// mineBlock mines the pending pool, rewarding minerID, and persists the
+// result. Under proof of authority sign signs the block with minerID's key;
+// without it the block is refused.
+func (s *Server) mineBlock(ctx context.Context, minerID string, start int64, remoteAddr string, sign blockchain.BlockSigner) (blockchain.Block, error) {
+	if err := s.checkMiner(ctx, minerID); err != nil {
+		return blockchain.Block{}, err
+	}
+
+	// Mining stops when ctx is done; nothing is committed then
+	blk, dropped, err := s.bc.MineSigned(ctx, start, minerID, sign)
+	if errors.Is(err, blockchain.ErrNotProducer) {
+		return blockchain.Block{}, fail(CodeNotProducer, err.Error())
+	}
+	if errors.Is(err, blockchain.ErrBlockSignature) {
+		s.logSvc.LogSystemCtx(ctx, "block_signature_failed", minerID, remoteAddr, err.Error())
+		return blockchain.Block{}, fail(CodeValidationFailed, "Under proof of authority the validator's signing_token must sign the block: "+err.Error())
+	}
+	if err != nil {
+		s.logSvc.LogSystemCtx(ctx, "mining_cancelled", minerID, remoteAddr, err.Error())
+		return blockchain.Block{}, fail(CodeRequestCancelled, "Mining stopped before a block was found: "+err.Error())
+	}
+	s.feed.PublishBlock(s.bc, blk)
+
+	// Collect all wallet IDs that need balance updates
+	affectedWallets := make(map[string]bool)
+	for _, tx := range blk.Transactions {
+		if tx.SenderID != "COINBASE" && tx.SenderID != "" {
+			affectedWallets[tx.SenderID] = true
+		}
+		for _, payee := range tx.Payees() {
+			affectedWallets[payee] = true
+		}
+	}
+
+	// Persist block to database. The block is on the chain now, so it is
+	// saved even if the client goes away.
+	if s.store != nil {
+		dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		// The block, its transactions, the UTXO set and the balances it changed
+		// commit together, so a crash cannot leave half a block persisted
+		err := s.store.Atomic(dbCtx, func(tx database.Store) error {
+			body, err := json.Marshal(blk)
+			if err != nil {
+				return err
+			}
+			if err := tx.SaveBlock(dbCtx, blk.Version, blk.Index, blk.Timestamp, blk.PreviousHash, blk.Hash, blk.Nonce, blk.MerkleRoot, body); err != nil {
+				return fmt.Errorf("save block: %w", err)
+			}
+
+			// Persist all transactions in the block
+			for _, t := range blk.Transactions {
+				blockIdx := blk.Index
+				if err := tx.SaveTransaction(dbCtx, t.ID, t.SenderID, t.ReceiverID, t.Amount, t.Fee, t.Nonce, t.Note, t.Timestamp, t.PubKey, t.Signature, t.Type, &blockIdx, "confirmed"); err != nil {
+					return fmt.Errorf("save transaction %s: %w", t.ID, err)
+				}
+			}
+
+			// Transactions that went invalid while pending will never be mined
+			for _, d := range dropped {
+				t := d.Transaction
+				if err := tx.SaveTransaction(dbCtx, t.ID, t.SenderID, t.ReceiverID, t.Amount, t.Fee, t.Nonce, t.Note, t.Timestamp, t.PubKey, t.Signature, t.Type, nil, "dropped"); err != nil {
+					return fmt.Errorf("save transaction %s: %w", t.ID, err)
+				}
+			}
+
+			// Persist UTXOs
+			for _, utxo := range s.bc.GetUTXOs() {
+				if err := tx.SaveUTXO(dbCtx, utxo.ID, utxo.Owner, utxo.AssetID, utxo.Amount, utxo.OriginTx, utxo.Index, utxo.Spent); err != nil {
+					return fmt.Errorf("save utxo %s: %w", utxo.ID, err)
+				}
+			}
+
+			// Recompute the stored balances of all affected wallets
+			for walletID := range affectedWallets {
+				if err := s.balances.SyncIn(dbCtx, tx, walletID); err != nil {
+					return fmt.Errorf("balance of %s: %w", walletID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "block_db_save_failed", "", remoteAddr, err.Error())
+		}
+	}
+
+	// Log all transactions in the mined block
+	for _, tx := range blk.Transactions {
+		s.logSvc.LogTransactionCtx(ctx, tx.ID, "mined", tx.SenderID, blk.Hash, "confirmed", remoteAddr)
+	}
+	for _, d := range dropped {
+		s.logSvc.LogTransactionCtx(ctx, d.Transaction.ID, "dropped", d.Transaction.SenderID, "", "dropped", remoteAddr)
+		s.logSvc.LogSystemCtx(ctx, "transaction_dropped", d.Transaction.SenderID, remoteAddr, d.Reason)
+	}
+
+	s.logSvc.LogSystemCtx(ctx, "block_mined", "", remoteAddr, fmt.Sprintf("Block #%d mined with %d transactions", blk.Index, len(blk.Transactions)))
+	return blk, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// deviceHeader carries a fingerprint of the client device, computed by the
+// client. Without it the User-Agent stands in.
+const deviceHeader = "X-Device-Fingerprint"
+
+// deviceFingerprint hashes what identifies the caller's device, so neither
+// raw header is stored
+func deviceFingerprint(header, userAgent string) string {
+	var src string
+	switch {
+	case strings.TrimSpace(header) != "":
+		src = "fp:" + strings.TrimSpace(header)
+	case strings.TrimSpace(userAgent) != "":
+		src = "ua:" + strings.TrimSpace(userAgent)
+	default:
+		return ""
+	}
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:8])
+}
+
+// logSensitive records a login, send or key export with the caller's
+// location and device, and alerts email and its inboxes if either is new
+func (s *Server) logSensitive(ctx context.Context, eventType, walletID, email, remoteAddr, details string) {
+	origin := s.logSvc.LogSensitiveCtx(ctx, eventType, walletID, remoteAddr, details)
+	if s.devices != nil && s.devices.Observe(email, eventType, remoteAddr, origin) {
+		s.logSvc.LogSystemCtx(ctx, "new_origin_alert", walletID, remoteAddr, "New device or country alert emailed for "+eventType)
+		s.notifications.NewOrigin(walletID, email, eventType, origin)
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/events"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// A wallet asks another to pay it an amount. The payer sees the request in
+// its incoming list and either declines it or accepts it, which sends an
+// ordinary signed transfer whose note names the request.
+
+// paymentRequest looks up the request in the path, answering NOT_FOUND for
+// one outside the caller's organization
+func (s *Server) paymentRequest(w http.ResponseWriter, r *http.Request) (services.PaymentRequest, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid payment request ID")
+		return services.PaymentRequest{}, false
+	}
+	pr, ok := s.payRequests.Get(id)
+	if !ok || !s.inOrg(r.Context(), pr.Payer) {
+		Error(w, r, CodeNotFound, services.ErrPaymentRequestNotFound.Error())
+		return services.PaymentRequest{}, false
+	}
+	return pr, true
+}
+
+// handleCreatePaymentRequest records a request and tells the payer about it
+func (s *Server) handleCreatePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreatePaymentRequestRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.RequesterID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+	if !s.inOrg(r.Context(), req.PayerID) {
+		Error(w, r, CodeWalletNotFound, "Payer wallet not found")
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	pr, err := s.payRequests.Create(req.RequesterID, req.PayerID, req.Amount, req.Note, expiresAt)
+	if err != nil {
+		s.writePaymentRequestError(w, r, err)
+		return
+	}
+
+	s.feed.Publish(events.PaymentRequested, pr.Payer, paymentRequestEvent(*pr))
+	s.logSvc.LogSystemCtx(r.Context(), "payment_requested", pr.Requester, r.RemoteAddr, fmt.Sprintf("%s: %d from %s", pr, pr.Amount, pr.Payer))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(pr)
+}
+
+// handleGetPaymentRequest returns one request
+func (s *Server) handleGetPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pr, ok := s.paymentRequest(w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(pr)
+}
+
+// handleListPaymentRequests lists a wallet's requests, newest first.
+// ?direction=incoming keeps those it was asked to pay, outgoing those it
+// made; ?status keeps one status.
+func (s *Server) handleListPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	direction := q.Get("direction")
+	if direction != "" && direction != "incoming" && direction != "outgoing" {
+		errs.Add("direction", "must be incoming or outgoing")
+	}
+	status := q.Get("status")
+	if status != "" && !services.ValidPaymentRequestStatus(status) {
+		errs.Add("status", "must be pending, accepted, declined or expired")
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	list := s.payRequests.ForWallet(walletID, direction, status)
+	if list == nil {
+		list = []services.PaymentRequest{}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleAcceptPaymentRequest pays a pending request from the payer wallet.
+// The request stays pending if the send fails.
+func (s *Server) handleAcceptPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req AcceptPaymentRequestRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	pr, ok := s.paymentRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.payRequests.BeginAccept(pr.ID); err != nil {
+		s.writePaymentRequestError(w, r, err)
+		return
+	}
+
+	tx, err := s.sendTransaction(r.Context(), sendInput{
+		SenderID:     pr.Payer,
+		ReceiverID:   pr.Requester,
+		Amount:       pr.Amount,
+		Note:         services.PaymentRequestNote(pr.ID, pr.Note),
+		SigningToken: req.SigningToken,
+		PrivateKey:   req.PrivateKey,
+		TOTPCode:     req.TOTPCode,
+		LimitOTP:     req.LimitOTP,
+	}, r.RemoteAddr)
+	if err != nil {
+		s.payRequests.CancelAccept(pr.ID)
+		writeOpError(w, r, err)
+		return
+	}
+	pr = s.payRequests.CompleteAccept(pr.ID, tx.ID)
+
+	s.feed.Publish(events.PaymentRequestDecided, pr.Requester, paymentRequestEvent(pr))
+	s.logSvc.LogSystemCtx(r.Context(), "payment_request_accepted", pr.Payer, r.RemoteAddr, fmt.Sprintf("%s paid in %s", pr, tx.ID))
+	if pr.BillID != 0 {
+		s.billShareAccepted(r.Context(), pr.BillID, r.RemoteAddr)
+	}
+	json.NewEncoder(w).Encode(pr)
+}
+
+// handleDeclinePaymentRequest lets the payer refuse a pending request
+func (s *Server) handleDeclinePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req DeclinePaymentRequestRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	pr, ok := s.paymentRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), pr.Payer, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	pr, err := s.payRequests.Decline(pr.ID)
+	if err != nil {
+		s.writePaymentRequestError(w, r, err)
+		return
+	}
+
+	s.feed.Publish(events.PaymentRequestDecided, pr.Requester, paymentRequestEvent(pr))
+	s.logSvc.LogSystemCtx(r.Context(), "payment_request_declined", pr.Payer, r.RemoteAddr, pr.String())
+	json.NewEncoder(w).Encode(pr)
+}
+
+// paymentRequestEvent is the feed event data of a request
+func paymentRequestEvent(pr services.PaymentRequest) map[string]interface{} {
+	data := map[string]interface{}{
+		"request_id": pr.ID,
+		"requester":  pr.Requester,
+		"payer":      pr.Payer,
+		"amount":     pr.Amount,
+		"status":     pr.Status,
+		"expires_at": pr.ExpiresAt,
+	}
+	if pr.TxID != "" {
+		data["txid"] = pr.TxID
+	}
+	return data
+}
+
+func (s *Server) writePaymentRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentRequestNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrPaymentRequestClosed), errors.Is(err, services.ErrPaymentRequestBusy):
+		Error(w, r, CodePayRequestClosed, err.Error())
+	case errors.Is(err, services.ErrPaymentRequestWallet):
+		Error(w, r, CodeWalletNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidPaymentRequest):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+// PrunePolicyResponse reports the UTXO pruning policy and the last pass
+type PrunePolicyResponse struct {
+	KeepBlocks      int64              `json:"keep_blocks"`
+	IntervalMinutes int64              `json:"interval_minutes"` // 0 when automatic pruning is off
+	PrunedHeight    int64              `json:"pruned_height"`    // highest block whose spent UTXOs may be archived
+	LastRun         *services.PruneRun `json:"last_run"`
+}
+
+// PruneRequest runs a pruning pass now; KeepBlocks defaults to the policy's
+type PruneRequest struct {
+	KeepBlocks *int64 `json:"keep_blocks,omitempty"`
+}
+
+// PrunePolicyRequest replaces the pruning policy
+type PrunePolicyRequest struct {
+	KeepBlocks      int64 `json:"keep_blocks"`
+	IntervalMinutes int64 `json:"interval_minutes"` // 0 turns automatic pruning off
+}
+
+func (s *Server) prunePolicyResponse() PrunePolicyResponse {
+	policy := s.pruner.Policy()
+	var height int64
+	s.bc.View(func(v blockchain.View) { height = v.PrunedHeight() })
+	return PrunePolicyResponse{
+		KeepBlocks:      policy.KeepBlocks,
+		IntervalMinutes: int64(policy.Interval / time.Minute),
+		PrunedHeight:    height,
+		LastRun:         s.pruner.LastRun(),
+	}
+}
+
+// handlePruneStatus reports the pruning policy and the last pass
+func (s *Server) handlePruneStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.prunePolicyResponse())
+}
+
+// handlePrune archives and drops spent UTXOs now
+func (s *Server) handlePrune(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req PruneRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+	keep := s.pruner.Policy().KeepBlocks
+	if req.KeepBlocks != nil {
+		keep = *req.KeepBlocks
+	}
+
+	run, err := s.pruner.Prune(r.Context(), keep)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to archive spent UTXOs")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "utxos_pruned", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%d pruned, %d archived, up to block %d", run.Pruned, run.Archived, run.Horizon))
+	json.NewEncoder(w).Encode(run)
+}
+
+// handleSetPrunePolicy changes how many blocks keep their spent UTXOs and how
+// often pruning runs
+func (s *Server) handleSetPrunePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req PrunePolicyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.pruner.SetPolicy(services.PrunePolicy{
+		KeepBlocks: req.KeepBlocks,
+		Interval:   time.Duration(req.IntervalMinutes) * time.Minute,
+	})
+	s.logSvc.LogSystemCtx(r.Context(), "prune_policy_changed", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("keep_blocks=%d interval_minutes=%d", req.KeepBlocks, req.IntervalMinutes))
+	json.NewEncoder(w).Encode(s.prunePolicyResponse())
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/skip2/go-qrcode"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// QR codes are rendered here so every client encodes the same payment URI;
+// see blockchain.PaymentURI.
+
+const (
+	paymentURIHeader = "X-Payment-URI"
+
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// qrOptions reads the format and size query parameters shared by the QR routes
+func qrOptions(errs *validation.Errors, q url.Values) (format string, size int) {
+	format, size = "png", defaultQRSize
+	if v := q.Get("format"); v != "" {
+		format = v
+	}
+	if format != "png" && format != "svg" {
+		errs.Add("format", "must be png or svg")
+	}
+	if v := q.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minQRSize || n > maxQRSize {
+			errs.Add("size", fmt.Sprintf("must be an integer from %d to %d", minQRSize, maxQRSize))
+		}
+		size = n
+	}
+	return format, size
+}
+
+// handleWalletQR renders a QR code of a wallet's payment URI, asking for
+// an optional amount and note
+func (s *Server) handleWalletQR(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["wallet"]
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	format, size := qrOptions(&errs, q)
+	var amount uint64
+	if v := q.Get("amount"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			errs.Add("amount", "must be a positive integer")
+		}
+		amount = n
+	}
+	note := q.Get("note")
+	if len(note) > services.MaxPaymentRequestNoteLength {
+		errs.Add("note", fmt.Sprintf("must be at most %d bytes", services.MaxPaymentRequestNoteLength))
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, ok := s.ws.Get(walletID); !ok || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	writeQR(w, r, blockchain.PaymentURI(walletID, amount, note, 0), format, size)
+}
+
+// handleInvoiceQR renders a QR code that pays a pending payment request
+func (s *Server) handleInvoiceQR(w http.ResponseWriter, r *http.Request) {
+	var errs validation.Errors
+	format, size := qrOptions(&errs, r.URL.Query())
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	pr, ok := s.paymentRequest(w, r)
+	if !ok {
+		return
+	}
+	if pr.Status != services.PaymentRequestPending {
+		Error(w, r, CodePayRequestClosed, fmt.Sprintf("%s: it is %s", services.ErrPaymentRequestClosed, pr.Status))
+		return
+	}
+
+	writeQR(w, r, blockchain.PaymentURI(pr.Requester, pr.Amount, pr.Note, pr.ID), format, size)
+}
+
+// writeQR renders uri as a PNG or SVG QR code size pixels square. The URI
+// also goes out in the X-Payment-URI header for clients that want the link.
+func writeQR(w http.ResponseWriter, r *http.Request, uri, format string, size int) {
+	code, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to render QR code")
+		return
+	}
+
+	var body []byte
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		body = qrSVG(code.Bitmap(), size)
+	} else {
+		if body, err = code.PNG(size); err != nil {
+			Error(w, r, CodeInternal, "Failed to render QR code")
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+	}
+	w.Header().Set(paymentURIHeader, uri)
+	w.Write(body)
+}
+
+// qrSVG draws a QR bitmap, quiet zone included, as one path of unit squares
+// scaled to size pixels
+func qrSVG(bitmap [][]bool, size int) []byte {
+	n := len(bitmap)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// RatesResponse lists the current price of one coin in each currency with a
+// rate, and the state of the rate feed when one is configured
+type RatesResponse struct {
+	Rates     []services.Rate `json:"rates"`
+	FetchedAt *time.Time      `json:"fetched_at,omitempty"` // last feed poll
+	FeedError string          `json:"feed_error,omitempty"` // why the last poll failed
+}
+
+// RateHistoryResponse lists the rates of one currency, oldest first
+type RateHistoryResponse struct {
+	Currency string          `json:"currency"`
+	Rates    []services.Rate `json:"rates"`
+}
+
+// SetRateRequest sets the price of one coin in a currency, effective now
+type SetRateRequest struct {
+	Rate float64 `json:"rate"`
+}
+
+// handleGetRates returns the current exchange rates
+func (s *Server) handleGetRates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp := RatesResponse{Rates: s.rates.Rates()}
+	if resp.Rates == nil {
+		resp.Rates = []services.Rate{}
+	}
+	if fetched, feedErr := s.rates.FeedStatus(); !fetched.IsZero() {
+		resp.FetchedAt, resp.FeedError = &fetched, feedErr
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleRateHistory returns the rates of a currency that took effect between
+// from and to, for pricing past transactions
+func (s *Server) handleRateHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	var errs validation.Errors
+	currency, err := services.NormalizeCurrency(q.Get("currency"))
+	if errs.Required("currency", q.Get("currency")) && err != nil {
+		errs.Add("currency", "must be one of "+strings.Join(services.FiatCurrencies, ", "))
+	}
+	var from, to time.Time
+	if v := q.Get("from"); v != "" {
+		if from, err = parseExportTime(v); err != nil {
+			errs.Add("from", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = parseExportTime(v); err != nil {
+			errs.Add("to", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		errs.Add("to", "must be after from")
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+
+	json.NewEncoder(w).Encode(RateHistoryResponse{Currency: currency, Rates: s.rates.History(currency, from, to)})
+}
+
+// handleSetRate lets an admin set the rate of a currency by hand
+func (s *Server) handleSetRate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SetRateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	rate, err := s.rates.Set(mux.Vars(r)["currency"], req.Rate, services.RateSourceAdmin, s.adminActor(r))
+	switch {
+	case errors.Is(err, services.ErrUnknownCurrency):
+		ValidationError(w, r, validation.Errors{{Field: "currency", Message: "must be one of " + strings.Join(services.FiatCurrencies, ", ")}})
+		return
+	case errors.Is(err, services.ErrInvalidRate):
+		ValidationError(w, r, validation.Errors{{Field: "rate", Message: "must be greater than zero"}})
+		return
+	case err != nil:
+		Error(w, r, CodeInternal, "Failed to save the rate")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "exchange_rate_set", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("1 coin = %g %s", rate.Rate, rate.Currency))
+	json.NewEncoder(w).Encode(rate)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Developers running demos reset the chain instead of restarting against an
+// empty database. Resets are turned off unless ALLOW_CHAIN_RESET is set or the
+// node runs in a SANDBOX, and production only allows them in a sandbox.
+
+// SetSandbox tells the server the sandbox it runs in, empty for none, and
+// whether POST /api/admin/reset is allowed
+func (s *Server) SetSandbox(name string, chainReset bool) {
+	s.sandbox, s.chainReset = name, chainReset
+}
+
+// handleResetChain wipes the chain, the pending pool and every UTXO, with
+// everything recorded about them, and starts over from a new genesis block.
+// Wallets are kept, with no balance. A configured store is always reset
+// too, or the old chain would come back at the next start.
+func (s *Server) handleResetChain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !s.chainReset {
+		Error(w, r, CodeResetDisabled, "Chain resets are turned off; set ALLOW_CHAIN_RESET=true or run in a SANDBOX")
+		return
+	}
+
+	// The store goes first, so a failure leaves the chain as it was
+	if s.store != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		if err := s.store.ResetChain(ctx); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "chain_reset_failed", s.adminActor(r), r.RemoteAddr, err.Error())
+			Error(w, r, CodeInternal, "Failed to reset the database")
+			return
+		}
+	}
+	dropped := len(s.bc.GetPending())
+	height := s.bc.Height()
+	genesis := s.bc.Reset()
+	s.supply.Reset()
+	s.miners.Reset()
+	s.faucet.Reset()
+	s.zakat.Reset()
+	s.assets.Reset()
+	s.notifications.Reset()
+	s.bills.Reset()
+	s.payRequests.Reset()
+	s.inheritance.Reset()
+	s.charities.Reset()
+	s.statements.Reset()
+	s.auditLog.Reset()
+	s.logSvc.ResetTransactionLogs()
+
+	s.logSvc.LogSystemCtx(r.Context(), "chain_reset", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("chain of %d blocks and %d pending transactions reset (database: %t, sandbox: %q)", height+1, dropped, s.store != nil, s.sandbox))
+	json.NewEncoder(w).Encode(ResetChainResponse{Genesis: genesis, Database: s.store != nil, Sandbox: s.sandbox})
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// handleRotateKey replaces a wallet's keypair: a new wallet takes over its
+// details, signed transactions sweep every output to it, and the old wallet
+// is retired with a link to its successor so its history stays queryable
+func (s *Server) handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RotateKeyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	walletID := mux.Vars(r)["wallet"]
+	old, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	if !old.Active() {
+		Error(w, r, CodeWalletInactive, "Wallet is already "+old.Status)
+		return
+	}
+
+	privateKey, session, err := s.resolveSigner(r.Context(), old, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	newPub, newPriv := wallet.GenerateKeypair()
+	newWalletID, err := wallet.WalletIDFromPub(newPub)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to generate a keypair")
+		return
+	}
+
+	// The sweeps are checked before anything changes, while the wallet is
+	// active
+	sweeps, err := s.txSvc.CreateKeyRotation(walletID, newWalletID, old.PublicKey, privateKey)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "key_rotation_failed", walletID, r.RemoteAddr, err.Error())
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+	var fee uint64
+	for _, sweep := range sweeps {
+		if err := s.txSvc.ValidateTransaction(r.Context(), sweep); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "transaction_validation_failed", walletID, r.RemoteAddr, err.Error())
+			Error(w, r, transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+			return
+		}
+		fee += sweep.Fee
+	}
+
+	// Only the fee leaves the owner's wallets, so only it counts against the
+	// session. It is reserved before the successor exists, and every failure
+	// from here on gives it back and removes the successor again.
+	reserved := len(sweeps) > 0 && session != nil
+	if reserved {
+		if _, err := s.signing.Reserve(req.SigningToken, walletID, fee); err != nil {
+			writeOpError(w, r, signingError(err))
+			return
+		}
+	}
+
+	successor, err := s.createSuccessor(r.Context(), old, newPub, newPriv)
+	if err != nil {
+		if reserved {
+			s.signing.Release(req.SigningToken, fee)
+		}
+		s.logSvc.LogSystemCtx(r.Context(), "key_rotation_failed", walletID, r.RemoteAddr, err.Error())
+		Error(w, r, CodeInternal, "Failed to save the new wallet")
+		return
+	}
+
+	resp := RotateKeyResponse{
+		OldWalletID: walletID,
+		WalletID:    successor.WalletID,
+		PublicKey:   successor.PublicKey,
+		PrivateKey:  newPriv,
+		Warning:     "Store the new private key securely; it is not shown again. The old key no longer controls any funds.",
+	}
+	// The sweeps enter the pool together, so no asset is left behind with
+	// the retired key
+	if len(sweeps) > 0 {
+		if err := s.queueTransactions(r.Context(), sweeps, r.RemoteAddr); err != nil {
+			if reserved {
+				s.signing.Release(req.SigningToken, fee)
+			}
+			s.discardSuccessor(r.Context(), successor.WalletID)
+			writeOpError(w, r, err)
+			return
+		}
+	}
+	resp.SweepTxID, resp.Swept, resp.Fee, resp.AssetSweeps = summarizeSweeps(sweeps)
+	if locked := s.bc.GetLockedBalance(walletID); locked > 0 {
+		resp.Warning = fmt.Sprintf("Store the new private key securely; it is not shown again. The old key keeps %s time-locked coins until they unlock; move them with POST /api/wallet/%s/sweep-retired then.", blockchain.FormatAmount(locked), walletID)
+	}
+
+	if err := s.setWalletStatus(r.Context(), walletID, wallet.StatusRetired, old.RotatedFrom, successor.WalletID); err != nil {
+		// The coins are already on their way, so the old key is retired here
+		// even if the database missed it
+		s.ws.SetStatus(walletID, wallet.StatusRetired, old.RotatedFrom, successor.WalletID)
+		s.logSvc.LogSystemCtx(r.Context(), "wallet_db_save_failed", walletID, r.RemoteAddr, err.Error())
+	}
+	if err := s.handles.Move(walletID, successor.WalletID); err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "handle_move_failed", walletID, r.RemoteAddr, err.Error())
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_key_rotated", walletID, r.RemoteAddr, fmt.Sprintf("Retired in favour of %s; %d swept", successor.WalletID, resp.Swept))
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSweepRetired carries the outputs a retired wallet still holds, those
+// that were time-locked when its key was rotated, to its newest key once
+// they unlock. The old key signs, as it did the rotation.
+func (s *Server) handleSweepRetired(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SweepRetiredRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	walletID := mux.Vars(r)["wallet"]
+	old, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	privateKey, session, err := s.resolveSigner(r.Context(), old, req.SigningToken, req.PrivateKey, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	sweeps, err := s.txSvc.CreateRetiredSweep(walletID, old.PublicKey, privateKey)
+	if err != nil {
+		Error(w, r, transactionErrorCode(err), err.Error())
+		return
+	}
+	var fee uint64
+	for _, sweep := range sweeps {
+		if err := s.txSvc.ValidateTransaction(r.Context(), sweep); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "transaction_validation_failed", walletID, r.RemoteAddr, err.Error())
+			Error(w, r, transactionErrorCode(err), "Transaction validation failed: "+err.Error())
+			return
+		}
+		fee += sweep.Fee
+	}
+	if session != nil {
+		if _, err := s.signing.Reserve(req.SigningToken, walletID, fee); err != nil {
+			writeOpError(w, r, signingError(err))
+			return
+		}
+	}
+	if err := s.queueTransactions(r.Context(), sweeps, r.RemoteAddr); err != nil {
+		if session != nil {
+			s.signing.Release(req.SigningToken, fee)
+		}
+		writeOpError(w, r, err)
+		return
+	}
+
+	resp := SweepRetiredResponse{WalletID: sweeps[0].ReceiverID}
+	resp.SweepTxID, resp.Swept, resp.Fee, resp.AssetSweeps = summarizeSweeps(sweeps)
+	s.logSvc.LogSystemCtx(r.Context(), "retired_wallet_swept", walletID, r.RemoteAddr, fmt.Sprintf("%d swept to %s", resp.Swept, resp.WalletID))
+	json.NewEncoder(w).Encode(resp)
+}
+
+// summarizeSweeps splits the sweeps of a rotation into the coin sweep's ID,
+// amount and fee and one entry per asset
+func summarizeSweeps(sweeps []*blockchain.Transaction) (txID string, swept, fee uint64, assets []AssetSweep) {
+	for _, sweep := range sweeps {
+		if sweep.AssetID != "" {
+			assets = append(assets, AssetSweep{TxID: sweep.ID, AssetID: sweep.AssetID, Amount: sweep.Amount})
+			continue
+		}
+		txID, swept, fee = sweep.ID, sweep.Amount, sweep.Fee
+	}
+	return txID, swept, fee, assets
+}
+
+// createSuccessor saves the wallet replacing old under a new keypair, with
+// old's owner, type, organization, label, limits and statement settings. When
+// the store refuses it, it is not kept in memory either.
+func (s *Server) createSuccessor(ctx context.Context, old wallet.Wallet, pubHex, privHex string) (wallet.Wallet, error) {
+	created, err := s.ws.CreateFromPub(pubHex, privHex, old.FullName, old.Email, old.CNIC)
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	next := old
+	next.WalletID, next.PublicKey, next.PrivateKey = created.WalletID, created.PublicKey, created.PrivateKey
+	next.Status, next.RotatedFrom, next.RotatedTo = wallet.StatusActive, old.WalletID, ""
+	next.Type = old.TypeOrDefault()
+	s.ws.Save(next)
+
+	if s.store == nil {
+		return next, nil
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	err = s.store.Atomic(dbCtx, func(tx database.Store) error {
+		if err := tx.SaveWallet(dbCtx, next.WalletID, next.PublicKey, next.PrivateKey, next.FullName, next.Email, next.CNIC, next.Type); err != nil {
+			return err
+		}
+		if err := tx.UpdateWalletOrg(dbCtx, next.WalletID, next.OrgID); err != nil {
+			return err
+		}
+		if err := tx.UpdateWalletStatements(dbCtx, next.WalletID, next.MonthlyStatements); err != nil {
+			return err
+		}
+		if err := tx.UpdateWalletLimits(dbCtx, next.WalletID, next.MaxTxAmount, next.MaxDailyAmount); err != nil {
+			return err
+		}
+		if err := tx.UpdateWalletLabel(dbCtx, next.WalletID, next.Label); err != nil {
+			return err
+		}
+		return tx.UpdateWalletStatus(dbCtx, next.WalletID, next.Status, next.RotatedFrom, next.RotatedTo)
+	})
+	if err != nil {
+		s.ws.Delete(next.WalletID)
+		return wallet.Wallet{}, err
+	}
+	return next, nil
+}
+
+// discardSuccessor removes a successor whose sweep never made it into the
+// pool, so a failed rotation leaves no wallet behind
+func (s *Server) discardSuccessor(ctx context.Context, walletID string) {
+	s.ws.Delete(walletID)
+	if s.store == nil {
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.store.DeleteWallet(dbCtx, walletID); err != nil {
+		s.logSvc.LogSystemCtx(ctx, "wallet_db_delete_failed", walletID, "", err.Error())
+	}
+}
+
+// handleDeactivateWallet closes an empty wallet at its owner's request
+func (s *Server) handleDeactivateWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req DeactivateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	walletID := mux.Vars(r)["wallet"]
+	wlt, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	if !wlt.Active() {
+		Error(w, r, CodeWalletInactive, "Wallet is already "+wlt.Status)
+		return
+	}
+	if _, _, err := s.resolveSigner(r.Context(), wlt, req.SigningToken, req.PrivateKey, r.RemoteAddr); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	if !s.txSvc.Settled(walletID) {
+		Error(w, r, CodeWalletBusy, "Wait for the wallet's pending transactions and outputs to confirm")
+		return
+	}
+	// Settled lets time-locked outputs wait, but a closed wallet would never
+	// get them back
+	if balance := s.bc.GetBalance(walletID) + s.bc.GetPendingBalance(walletID) + s.bc.GetLockedBalance(walletID); balance > 0 {
+		Error(w, r, CodeWalletNotEmpty, fmt.Sprintf("Wallet still holds %s coins", blockchain.FormatAmount(balance)))
+		return
+	}
+	var assets []string
+	s.bc.View(func(v blockchain.View) {
+		assets = v.HeldAssets(walletID)
+	})
+	if len(assets) > 0 {
+		Error(w, r, CodeWalletNotEmpty, "Wallet still holds "+strings.Join(assets, ", "))
+		return
+	}
+
+	if err := s.setWalletStatus(r.Context(), walletID, wallet.StatusDeactivated, wlt.RotatedFrom, ""); err != nil {
+		Error(w, r, CodeInternal, "Failed to deactivate the wallet")
+		return
+	}
+	detail := "Deactivated by its owner"
+	if req.Reason != "" {
+		detail += ": " + req.Reason
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_deactivated", walletID, r.RemoteAddr, detail)
+	if claim, err := s.handles.Release(walletID); err == nil {
+		s.logSvc.LogSystemCtx(r.Context(), "handle_released", walletID, r.RemoteAddr, "Released @"+claim.Handle)
+	}
+
+	wlt, _ = s.ws.Get(walletID)
+	wlt.PrivateKey = "***ENCRYPTED***"
+	json.NewEncoder(w).Encode(WalletResponse{Wallet: wlt, Nonce: s.bc.GetNonce(walletID)})
+}
+
+// handleWalletRotations follows a wallet's key rotations both ways, so
+// clients can find the history of every key that held its funds
+func (s *Server) handleWalletRotations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	wlt, exists := s.ws.Get(walletID)
+	if !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	// Walk back to the first key, then forward to the latest; seen guards
+	// against a corrupted cycle
+	seen := map[string]bool{wlt.WalletID: true}
+	first := wlt
+	for first.RotatedFrom != "" {
+		prev, ok := s.ws.Get(first.RotatedFrom)
+		if !ok || seen[prev.WalletID] {
+			break
+		}
+		seen[prev.WalletID] = true
+		first = prev
+	}
+
+	resp := WalletRotationsResponse{WalletID: walletID}
+	visited := map[string]bool{}
+	for cur, ok := first, true; ok && !visited[cur.WalletID]; cur, ok = s.ws.Get(cur.RotatedTo) {
+		visited[cur.WalletID] = true
+		resp.Lineage = append(resp.Lineage, WalletRotation{
+			WalletID:  cur.WalletID,
+			PublicKey: cur.PublicKey,
+			Status:    cur.StatusOrDefault(),
+		})
+		resp.Current = cur.WalletID
+		if cur.RotatedTo == "" {
+			break
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// setWalletStatus persists a wallet's status and rotation links, then
+// applies them in memory
+func (s *Server) setWalletStatus(ctx context.Context, walletID, status, rotatedFrom, rotatedTo string) error {
+	if s.store != nil {
+		dbCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		if err := s.store.UpdateWalletStatus(dbCtx, walletID, status, rotatedFrom, rotatedTo); err != nil {
+			return err
+		}
+	}
+	return s.ws.SetStatus(walletID, status, rotatedFrom, rotatedTo)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/events"
+)
+
+// handleListSchemas lists every published event schema and its versions
+func (s *Server) handleListSchemas(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"current_version": events.SchemaVersion,
+		"schemas":         events.Schemas(),
+	})
+}
+
+// handleGetSchema returns the JSON Schema for one event type (latest version unless ?version= is given)
+func (s *Server) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	eventType := mux.Vars(r)["event"]
+
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			Error(w, r, CodeValidationFailed, "Invalid version")
+			return
+		}
+		version = parsed
+	}
+
+	schema, ok := events.Schema(eventType, version)
+	if !ok {
+		Error(w, r, CodeNotFound, "Schema not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(schema)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
+)
+
+// searchMaxQuery is the longest query that can match anything: an email
+const searchMaxQuery = validation.MaxEmailLength
+
+// handleSearch resolves a search bar query to blocks (by index or hash),
+// transactions (by ID) and wallets (by ID, or by email for admins). A query
+// that matches nothing returns no results rather than an error.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	var errs validation.Errors
+	if errs.Required("q", q) && len(q) > searchMaxQuery {
+		errs.Add("q", fmt.Sprintf("must be at most %d characters", searchMaxQuery))
+	}
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+
+	resp := SearchResponse{Query: q, Results: []SearchResult{}}
+	resp.Results = append(resp.Results, s.searchChain(r, q)...)
+	resp.Results = append(resp.Results, s.searchWallets(r, q)...)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// searchChain matches blocks by index or hash and transactions by ID
+func (s *Server) searchChain(r *http.Request, q string) []SearchResult {
+	var results []SearchResult
+	if index, err := strconv.ParseInt(strings.TrimPrefix(q, "#"), 10, 64); err == nil {
+		if b, ok := s.bc.GetBlockByIndex(index); ok {
+			results = append(results, s.blockResult(r, b))
+		}
+	}
+	if b, ok := s.bc.GetBlockByHash(strings.ToLower(q)); ok {
+		results = append(results, s.blockResult(r, b))
+	}
+	if loc, ok := s.bc.GetTransactionByID(q); ok && s.txVisible(r, loc.Transaction) {
+		if loc.Pending {
+			results = append(results, txResult(loc.Transaction, nil, 0))
+		} else {
+			index := loc.Block.Index
+			results = append(results, txResult(loc.Transaction, &index, s.bc.GetConfirmations(index)))
+		}
+	}
+	return results
+}
+
+// blockResult summarises a block
+func (s *Server) blockResult(r *http.Request, b blockchain.Block) SearchResult {
+	txs := len(s.scopeTxs(r.Context(), b.Transactions))
+	return SearchResult{
+		Type:    "block",
+		ID:      strconv.FormatInt(b.Index, 10),
+		Summary: fmt.Sprintf("Block #%d with %d transactions", b.Index, txs),
+		Block: &SearchBlock{
+			Index:         b.Index,
+			Hash:          b.Hash,
+			PreviousHash:  b.PreviousHash,
+			Timestamp:     b.Timestamp,
+			Transactions:  txs,
+			Confirmations: s.bc.GetConfirmations(b.Index),
+		},
+	}
+}
+
+func (s *Server) txVisible(r *http.Request, tx blockchain.Transaction) bool {
+	return s.inOrg(r.Context(), tx.SenderID) || s.inOrg(r.Context(), tx.ReceiverID)
+}
+
+func txResult(tx blockchain.Transaction, blockIndex *int64, confirmations int64) SearchResult {
+	status := "confirmed"
+	if blockIndex == nil {
+		status = "pending"
+	}
+	return SearchResult{
+		Type:    "transaction",
+		ID:      tx.ID,
+		Summary: fmt.Sprintf("%s of %s from %s to %s (%s)", tx.Type, blockchain.FormatAmount(tx.Amount), tx.SenderID, tx.ReceiverID, status),
+		Transaction: &SearchTransaction{
+			Type:          tx.Type,
+			SenderID:      tx.SenderID,
+			ReceiverID:    tx.ReceiverID,
+			Amount:        tx.Amount,
+			Fee:           tx.Fee,
+			Timestamp:     tx.Timestamp,
+			Status:        status,
+			BlockIndex:    blockIndex,
+			Confirmations: confirmations,
+		},
+	}
+}
+
+// searchWallets matches a wallet ID or handle, or for admins an email
+// address. Other callers never learn whether an email is registered.
+func (s *Server) searchWallets(r *http.Request, q string) []SearchResult {
+	admin := s.isAdminRequest(r)
+	byEmail := admin && strings.Contains(q, "@") && !strings.HasPrefix(q, "@")
+	handleHolder, _ := s.handles.Resolve(q)
+
+	var results []SearchResult
+	for _, wlt := range s.ws.GetAll() {
+		if !s.inOrg(r.Context(), wlt.WalletID) {
+			continue
+		}
+		if !strings.EqualFold(wlt.WalletID, q) && wlt.WalletID != handleHolder && !(byEmail && wlt.Email != "" && strings.EqualFold(wlt.Email, q)) {
+			continue
+		}
+		sw := &SearchWallet{
+			WalletID: wlt.WalletID,
+			Handle:   s.handles.Handle(wlt.WalletID),
+			Type:     wlt.TypeOrDefault(),
+			FullName: wlt.FullName,
+			Balance:  s.bc.GetBalance(wlt.WalletID),
+		}
+		summary := fmt.Sprintf("%s wallet", sw.Type)
+		if sw.FullName != "" {
+			summary += " of " + sw.FullName
+		}
+		if sw.Handle != "" {
+			summary += " (@" + sw.Handle + ")"
+		}
+		if admin {
+			sw.Email = wlt.Email
+		}
+		results = append(results, SearchResult{Type: "wallet", ID: wlt.WalletID, Summary: summary, Wallet: sw})
+	}
+	return results
+}
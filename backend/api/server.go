@@ -4,37 +4,128 @@ import (
     "context"
     "encoding/json"
     "fmt"
+    "errors"
     "net/http"
+    "regexp"
     "strconv"
+    "strings"
     "time"
 
     "github.com/gorilla/mux"
+    "github.com/graphql-go/graphql"
+    "github.com/jackc/pgx/v5"
     "github.com/rs/cors"
 
+    "blockchain-backend/alerts"
     "blockchain-backend/blockchain"
     "blockchain-backend/database"
+    "blockchain-backend/events"
+    "blockchain-backend/googleauth"
     "blockchain-backend/otp"
     "blockchain-backend/services"
     "blockchain-backend/wallet"
 )
 
 type Server struct {
-    bc      *blockchain.Blockchain
-    ws      *wallet.Store
-    txSvc   *services.TransactionService
-    logSvc  *services.LoggingService
-    db      *database.DB
-    r       *mux.Router
+    bc         *blockchain.Blockchain
+    ws         *wallet.Store
+    txSvc      *services.TransactionService
+    logSvc     *services.LoggingService
+    db         *database.DB
+    store      database.Store // wallets, chain and logs: db, SQLite, or nil in memory
+    feed       *events.Feed
+    deliveries *services.DeliveryService
+    walletTypes *services.WalletTypeService
+    alerts     *alerts.Manager
+    usage      *services.UsageService
+    twoFactor  *services.TwoFactorService
+    sessions   *services.SessionService
+    google     *googleauth.Verifier // nil when Google login is disabled
+    orgs       *services.OrgService
+    config     *services.ConfigCascade
+    signing    *services.SigningService
+    hub        *events.Hub
+    statements *services.StatementService
+    balances   *services.BalanceService
+    kyc        *services.KYCService
+    webhooks   *services.WebhookService
+    faucet     *services.FaucetService
+    supply     *services.SupplyService
+    pruner     *services.PruneService
+    snapshots  *services.SnapshotService
+    inheritance *services.InheritanceService
+    campaigns   *services.CampaignService
+    charities   *services.CharityService
+    zakat       *services.ZakatService
+    auditLog    *services.AuditService
+    devices     *services.DeviceService
+    notifications *services.NotificationService
+    rates       *services.RateService
+    assets      *services.AssetService
+    payRequests *services.PaymentRequestService
+    bills       *services.BillService
+    directory   *services.DirectoryService
+    handles     *services.HandleService
+    miners      *services.MinerService
+    adminKey    string // ADMIN_API_KEY; see requireAdmin
+    adminEmail  string // ADMIN_EMAIL; see adminOTPEmail
+    sandbox     string // SANDBOX_NAME, empty outside a sandbox
+    chainReset  bool   // ALLOW_CHAIN_RESET; see handleResetChain
+    readyLimits ReadinessLimits
+    mining      *mineQueue // see handleMine
+    graphqlSchema graphql.Schema
+    r          *mux.Router
 }
 
-func NewServer(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *services.TransactionService, logSvc *services.LoggingService, db *database.DB) *Server {
+func NewServer(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *services.TransactionService, logSvc *services.LoggingService, db *database.DB, store database.Store, feed *events.Feed, deliveries *services.DeliveryService, walletTypes *services.WalletTypeService, alertMgr *alerts.Manager, usage *services.UsageService, twoFactor *services.TwoFactorService, sessions *services.SessionService, google *googleauth.Verifier, orgs *services.OrgService, config *services.ConfigCascade, signing *services.SigningService, hub *events.Hub, statements *services.StatementService, balances *services.BalanceService, kyc *services.KYCService, webhooks *services.WebhookService, faucet *services.FaucetService, supply *services.SupplyService, pruner *services.PruneService, snapshots *services.SnapshotService, inheritance *services.InheritanceService, campaigns *services.CampaignService, charities *services.CharityService, zakat *services.ZakatService, auditLog *services.AuditService, devices *services.DeviceService, notifications *services.NotificationService, rates *services.RateService, assets *services.AssetService, payRequests *services.PaymentRequestService, bills *services.BillService, directory *services.DirectoryService, handles *services.HandleService, miners *services.MinerService) *Server {
     s := &Server{
-        bc:     bc,
-        ws:     ws,
-        txSvc:  txSvc,
-        logSvc: logSvc,
-        db:     db,
+        bc:         bc,
+        ws:         ws,
+        txSvc:      txSvc,
+        logSvc:     logSvc,
+        db:         db,
+        store:      store,
+        feed:       feed,
+        deliveries: deliveries,
+        walletTypes: walletTypes,
+        alerts:     alertMgr,
+        usage:      usage,
+        twoFactor:  twoFactor,
+        sessions:   sessions,
+        google:     google,
+        orgs:       orgs,
+        config:     config,
+        signing:    signing,
+        hub:        hub,
+        statements: statements,
+        balances:   balances,
+        kyc:        kyc,
+        webhooks:   webhooks,
+        faucet:     faucet,
+        supply:     supply,
+        pruner:     pruner,
+        snapshots:  snapshots,
+        inheritance: inheritance,
+        campaigns:   campaigns,
+        charities:   charities,
+        zakat:       zakat,
+        auditLog:    auditLog,
+        devices:     devices,
+        notifications: notifications,
+        rates:       rates,
+        assets:      assets,
+        payRequests: payRequests,
+        bills:       bills,
+        directory:   directory,
+        handles:     handles,
+        miners:      miners,
+        mining:      newMineQueue(),
+    }
+    schema, err := s.buildGraphQLSchema()
+    if err != nil {
+        panic("graphql schema: " + err.Error())
     }
+    s.graphqlSchema = schema
     s.r = mux.NewRouter()
     s.routes()
     return s
@@ -44,33 +135,125 @@ func (s *Server) Router() http.Handler {
     // Add CORS middleware
     c := cors.New(cors.Options{
         AllowedOrigins: []string{"*"},
-        AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+        AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
         AllowedHeaders: []string{"*"},
+        ExposedHeaders: []string{requestIDHeader, paymentURIHeader, "Deprecation", "Warning"},
     })
-    return c.Handler(s.r)
+    return c.Handler(s.requestID(s.r))
 }
 
 func (s *Server) routes() {
     a := s.r.PathPrefix("/api").Subrouter()
+    a.Use(s.trackUsage)
+    a.Use(s.auditCalls)
+    a.Use(s.tenantScope)
     
     // Wallet operations
     a.HandleFunc("/generate-keypair", s.handleGenerateKeypair).Methods("POST", "OPTIONS")
     a.HandleFunc("/create-wallet", s.handleCreateWallet).Methods("POST", "OPTIONS")
     a.HandleFunc("/wallet/{wallet}", s.handleGetWallet).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/updates", s.handleWalletUpdates).Methods("GET", "OPTIONS")
+    a.HandleFunc("/ws", s.handleWebSocket).Methods("GET")
+    a.HandleFunc("/wallet/import", s.handleImportWallet).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/export", s.handleExportWallet).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/type-change", s.handleRequestTypeChange).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/consolidate", s.handleConsolidate).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/rotate-key", s.handleRotateKey).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/sweep-retired", s.handleSweepRetired).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/deactivate", s.handleDeactivateWallet).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/rotations", s.handleWalletRotations).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/inheritance", s.handleGetInheritance).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/inheritance", s.handleSetInheritance).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/payment-requests", s.handleCreatePaymentRequest).Methods("POST", "OPTIONS")
+    a.HandleFunc("/payment-requests/{id}", s.handleGetPaymentRequest).Methods("GET", "OPTIONS")
+    a.HandleFunc("/payment-requests/{id}/accept", s.handleAcceptPaymentRequest).Methods("POST", "OPTIONS")
+    a.HandleFunc("/payment-requests/{id}/decline", s.handleDeclinePaymentRequest).Methods("POST", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/payment-requests", s.handleListPaymentRequests).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/qr", s.handleWalletQR).Methods("GET", "OPTIONS")
+    a.HandleFunc("/invoices/{id}/qr", s.handleInvoiceQR).Methods("GET", "OPTIONS")
+    a.HandleFunc("/bills", s.handleCreateBill).Methods("POST", "OPTIONS")
+    a.HandleFunc("/bills/{id}", s.handleGetBill).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/bills", s.handleListBills).Methods("GET", "OPTIONS")
+    a.HandleFunc("/campaigns", s.handleListCampaigns).Methods("GET", "OPTIONS")
+    a.HandleFunc("/campaigns/{id}", s.handleGetCampaign).Methods("GET", "OPTIONS")
+    a.HandleFunc("/campaigns/{id}/donate", s.handleDonate).Methods("POST", "OPTIONS")
+    a.HandleFunc("/kyc/{wallet}", s.handleGetKYC).Methods("GET", "OPTIONS")
+    a.HandleFunc("/kyc/{wallet}", s.handleSubmitKYC).Methods("POST", "OPTIONS")
+    a.HandleFunc("/limits/{wallet}", s.handleGetLimits).Methods("GET", "OPTIONS")
+    a.HandleFunc("/limits/{wallet}", s.handleSetLimits).Methods("PUT", "OPTIONS")
+    
+    // Webhooks
+    a.HandleFunc("/notifications/preferences", s.handleGetNotificationPreferences).Methods("GET", "OPTIONS")
+    a.HandleFunc("/notifications/preferences", s.handleSetNotificationPreferences).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/directory", s.handleGetDirectorySetting).Methods("GET", "OPTIONS")
+    a.HandleFunc("/directory", s.handleSetDirectorySetting).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/resolve", s.handleResolveEmail).Methods("POST", "OPTIONS")
+    a.HandleFunc("/alias", s.handleClaimHandle).Methods("POST", "OPTIONS")
+    a.HandleFunc("/alias", s.handleReleaseHandle).Methods("DELETE", "OPTIONS")
+    a.HandleFunc("/alias/{handle}", s.handleResolveHandle).Methods("GET", "OPTIONS")
+    a.HandleFunc("/wallet/{wallet}/alias", s.handleWalletHandle).Methods("GET", "OPTIONS")
+    a.HandleFunc("/notifications/{wallet}", s.handleGetNotifications).Methods("GET", "OPTIONS")
+    a.HandleFunc("/notifications/{wallet}/read", s.handleMarkNotificationsRead).Methods("POST", "OPTIONS")
+    a.HandleFunc("/webhooks", s.handleCreateWebhook).Methods("POST", "OPTIONS")
+    a.HandleFunc("/webhooks", s.handleListWebhooks).Methods("GET", "OPTIONS")
+    a.HandleFunc("/webhooks/{id}", s.handleGetWebhook).Methods("GET", "OPTIONS")
+    a.HandleFunc("/webhooks/{id}", s.handleUpdateWebhook).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/webhooks/{id}", s.handleDeleteWebhook).Methods("DELETE", "OPTIONS")
+    a.HandleFunc("/webhooks/{id}/deliveries", s.handleWebhookDeliveries).Methods("GET", "OPTIONS")
+    
+    // Faucet
+    a.HandleFunc("/faucet", s.handleFaucetStatus).Methods("GET", "OPTIONS")
+    a.HandleFunc("/faucet/claim", s.handleFaucetClaim).Methods("POST", "OPTIONS")
     a.HandleFunc("/balance/{wallet}", s.handleGetBalance).Methods("GET", "OPTIONS")
     
     // Transaction operations
     a.HandleFunc("/send", s.handleSend).Methods("POST", "OPTIONS")
+    a.HandleFunc("/send/batch", s.handleSendBatch).Methods("POST", "OPTIONS")
+    a.HandleFunc("/send/simulate", s.handleSimulateSend).Methods("POST", "OPTIONS")
+    a.HandleFunc("/transactions/prepare", s.handlePrepareTransaction).Methods("GET", "OPTIONS")
+    a.HandleFunc("/transactions/submit-signed", s.handleSubmitSigned).Methods("POST", "OPTIONS")
+    a.HandleFunc("/signing-sessions", s.handleCreateSigningSession).Methods("POST", "OPTIONS")
+    a.HandleFunc("/signing-sessions/current", s.handleGetSigningSession).Methods("GET", "OPTIONS")
+    a.HandleFunc("/signing-sessions/current", s.handleRevokeSigningSession).Methods("DELETE", "OPTIONS")
     a.HandleFunc("/transactions", s.handleGetTransactions).Methods("GET", "OPTIONS")
     a.HandleFunc("/pending", s.handleGetPending).Methods("GET", "OPTIONS")
+    a.HandleFunc("/mempool", s.handleGetMempool).Methods("GET", "OPTIONS")
+    a.HandleFunc("/mempool/stats", s.handleMempoolStats).Methods("GET", "OPTIONS")
+    a.HandleFunc("/headers", s.handleHeaders).Methods("GET", "OPTIONS")
+    a.HandleFunc("/transaction/{txid}", s.handleGetTransaction).Methods("GET", "OPTIONS")
+    a.HandleFunc("/transaction/{txid}/status", s.handleTransactionStatus).Methods("GET", "OPTIONS")
+    a.HandleFunc("/transaction/{txid}/proof", s.handleTransactionProof).Methods("GET", "OPTIONS")
     
     // Blockchain operations
     a.HandleFunc("/mine", s.handleMine).Methods("POST", "OPTIONS")
+    a.HandleFunc("/mine/jobs/{id}", s.handleGetMineJob).Methods("GET", "OPTIONS")
+    a.HandleFunc("/consensus", s.handleConsensus).Methods("GET", "OPTIONS")
+    a.HandleFunc("/miners", s.handleListMiners).Methods("GET", "OPTIONS")
+    a.HandleFunc("/miners/leaderboard", s.handleMinerLeaderboard).Methods("GET", "OPTIONS")
     a.HandleFunc("/blocks", s.handleBlocks).Methods("GET", "OPTIONS")
     a.HandleFunc("/block/{index}", s.handleGetBlock).Methods("GET", "OPTIONS")
+    a.HandleFunc("/block/{index}/raw", s.handleGetRawBlock).Methods("GET", "OPTIONS")
+    a.HandleFunc("/chain/export", s.handleChainExport).Methods("GET", "OPTIONS")
+    a.HandleFunc("/supply", s.handleSupply).Methods("GET", "OPTIONS")
+    a.HandleFunc("/rates", s.handleGetRates).Methods("GET", "OPTIONS")
+    a.HandleFunc("/rates/history", s.handleRateHistory).Methods("GET", "OPTIONS")
+    a.HandleFunc("/assets", s.handleListAssets).Methods("GET", "OPTIONS")
+    a.HandleFunc("/assets/{symbol}", s.handleGetAsset).Methods("GET", "OPTIONS")
+    a.HandleFunc("/search", s.handleSearch).Methods("GET", "OPTIONS")
+    
+    // GraphQL explorer queries (read-only)
+    a.HandleFunc("/graphql", s.handleGraphQL).Methods("GET", "POST", "OPTIONS")
+    
+    // Document anchoring
+    a.HandleFunc("/anchor", s.handleCreateAnchor).Methods("POST", "OPTIONS")
+    a.HandleFunc("/anchor/verify", s.handleVerifyDocument).Methods("POST", "OPTIONS")
+    a.HandleFunc("/anchor/{hash}", s.handleGetAnchor).Methods("GET", "OPTIONS")
     
     // UTXO operations
     a.HandleFunc("/utxos/{wallet}", s.handleGetUTXOs).Methods("GET", "OPTIONS")
+    a.HandleFunc("/utxos/{id}/{action:claim|refund}", s.handleUnlock).Methods("POST", "OPTIONS")
+    a.HandleFunc("/vesting", s.handleCreateVesting).Methods("POST", "OPTIONS")
+    a.HandleFunc("/vesting/{wallet}", s.handleGetVesting).Methods("GET", "OPTIONS")
     
     // Logging and analytics
     a.HandleFunc("/logs/system", s.handleGetSystemLogs).Methods("GET", "OPTIONS")
@@ -81,12 +264,21 @@ func (s *Server) routes() {
     a.HandleFunc("/reports/wallet/{wallet}", s.handleWalletReport).Methods("GET", "OPTIONS")
     a.HandleFunc("/reports/system", s.handleSystemReport).Methods("GET", "OPTIONS")
     
+    // Monthly statements
+    a.HandleFunc("/statements/{wallet}", s.handleListStatements).Methods("GET", "OPTIONS")
+    a.HandleFunc("/statements/{wallet}/{period}", s.handleGetStatement).Methods("GET", "OPTIONS")
+    
     // Beneficiaries
     a.HandleFunc("/beneficiaries/{user_id}", s.handleGetBeneficiaries).Methods("GET", "OPTIONS")
     a.HandleFunc("/beneficiaries", s.handleAddBeneficiary).Methods("POST", "OPTIONS")
+    a.HandleFunc("/beneficiaries/{user_id}/{beneficiary_id}", s.handleUpdateBeneficiary).Methods("PUT", "OPTIONS")
     a.HandleFunc("/beneficiaries/{user_id}/{beneficiary_id}", s.handleRemoveBeneficiary).Methods("DELETE", "OPTIONS")
     
     // Zakat
+    a.HandleFunc("/zakat/charities", s.handleListCharities).Methods("GET", "OPTIONS")
+    a.HandleFunc("/zakat/distributions", s.handleZakatDistributions).Methods("GET", "OPTIONS")
+    a.HandleFunc("/zakat/preview", s.requireAdmin(s.handleZakatPreview)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/zakat/{wallet}/preview", s.handleWalletZakatPreview).Methods("GET", "OPTIONS")
     a.HandleFunc("/zakat/{wallet}", s.handleGetZakatDeductions).Methods("GET", "OPTIONS")
     
     // Profile management
@@ -96,23 +288,114 @@ func (s *Server) routes() {
     a.HandleFunc("/otp/send", s.handleSendOTP).Methods("POST", "OPTIONS")
     a.HandleFunc("/otp/verify", s.handleVerifyOTP).Methods("POST", "OPTIONS")
     
+    // Authenticator app 2FA
+    a.HandleFunc("/2fa/enroll", s.handleTwoFactorEnroll).Methods("POST", "OPTIONS")
+    a.HandleFunc("/2fa/verify", s.handleTwoFactorVerify).Methods("POST", "OPTIONS")
+    a.HandleFunc("/2fa/disable", s.handleTwoFactorDisable).Methods("POST", "OPTIONS")
+    a.HandleFunc("/2fa/{wallet}", s.handleTwoFactorStatus).Methods("GET", "OPTIONS")
+    a.HandleFunc("/2fa/{wallet}/threshold", s.handleTwoFactorThreshold).Methods("PUT", "OPTIONS")
+    
+    // Login sessions
+    a.HandleFunc("/auth/google/config", s.handleGoogleConfig).Methods("GET", "OPTIONS")
+    a.HandleFunc("/auth/google", s.handleGoogleLogin).Methods("POST", "OPTIONS")
+    a.HandleFunc("/auth/session", s.handleGetSession).Methods("GET", "OPTIONS")
+    a.HandleFunc("/login", s.handleLogin).Methods("POST", "OPTIONS")
+    
+    // Accounts: the wallets of the session's email
+    a.HandleFunc("/account/wallets", s.handleAccountWallets).Methods("GET", "OPTIONS")
+    a.HandleFunc("/account/wallets", s.handleCreateAccountWallet).Methods("POST", "OPTIONS")
+    a.HandleFunc("/account/wallets/{wallet}", s.handleSetWalletLabel).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/auth/logout", s.handleLogout).Methods("POST", "OPTIONS")
+    
     // Admin operations
     a.HandleFunc("/admin/check/{wallet}", s.handleCheckAdmin).Methods("GET", "OPTIONS")
-    
-    // Health check
-    a.HandleFunc("/health", s.handleHealth).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/orgs", s.requireAdmin(s.handleListOrgs)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/orgs", s.requireAdmin(s.handleCreateOrg)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/orgs/{org}", s.requireAdmin(s.handleGetOrgByID)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/orgs/{org}/wallets", s.requireAdmin(s.handleAssignOrgWallet)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/deliveries", s.requireAdmin(s.handleListDeliveries)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/deliveries/redeliver", s.requireAdmin(s.handleRedeliver)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/wallet-type-requests", s.requireAdmin(s.handleListTypeChanges)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/wallet-type-requests/{id}/{decision:approve|reject}", s.requireAdmin(s.handleDecideTypeChange)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/usage", s.requireAdmin(s.handleUsage)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/indexes", s.requireAdmin(s.handleIndexReport)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/indexes/ensure", s.requireAdmin(withTimeout(ensureIndexesTimeout, s.handleEnsureIndexes))).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/alerts/operational", s.requireAdmin(s.handleOperationalAlerts)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/announcements", s.requireAdmin(s.handleAnnouncement)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/logs/export", s.requireAdmin(s.handleExportLogs)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/audit", s.requireAdmin(s.handleAuditLog)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/kyc/pending", s.requireAdmin(s.handleListPendingKYC)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/kyc/{id}/{decision:approve|reject}", s.requireAdmin(s.handleReviewKYC)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/wallets/{wallet}/freeze", s.requireAdmin(s.handleFreezeWallet)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/wallets/{wallet}/unfreeze", s.requireAdmin(s.handleUnfreezeWallet)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/limits/{wallet}", s.requireAdmin(s.handleAdminSetLimits)).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/admin/faucet/ledger", s.requireAdmin(s.handleFaucetLedger)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/balances", s.requireAdmin(s.handleBalanceStats)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/balances/repair", s.requireAdmin(s.handleRepairBalances)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/reconcile", s.requireAdmin(s.handleReconcile)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/reconcile/repair", s.requireAdmin(s.handleRepairReconcile)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/utxos/prune", s.requireAdmin(s.handlePruneStatus)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/utxos/prune", s.requireAdmin(s.handlePrune)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/utxos/prune/policy", s.requireAdmin(s.handleSetPrunePolicy)).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/admin/snapshots", s.requireAdmin(s.handleListSnapshots)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/snapshots", s.requireAdmin(s.handleCreateSnapshot)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/reset", s.requireAdmin(s.handleResetChain)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/chain/import", s.requireAdmin(withTimeout(chainImportTimeout, s.handleChainImport))).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/fixtures", s.requireAdmin(withTimeout(fixturesTimeout, s.handleGenerateFixtures))).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/rates/{currency}", s.requireAdmin(s.handleSetRate)).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/admin/assets", s.requireAdmin(s.handleDefineAsset)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/vesting", s.requireAdmin(s.handleListVesting)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/assets/{symbol}/mint", s.requireAdmin(s.handleMintAsset)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/mint", s.requireAdmin(s.handleAdminMint)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/burn", s.requireAdmin(s.handleAdminBurn)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/supply/adjustments", s.requireAdmin(s.handleListSupplyAdjustments)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/statements/run", s.requireAdmin(s.handleRunStatements)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/inheritance/payouts", s.requireAdmin(s.handleListInheritancePayouts)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/admin/inheritance/payouts/{id}/{decision:approve|reject}", s.requireAdmin(s.handleDecideInheritancePayout)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/inheritance/check", s.requireAdmin(s.handleRunInheritanceCheck)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/campaigns", s.requireAdmin(s.handleCreateCampaign)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/campaigns/{id}", s.requireAdmin(s.handleUpdateCampaign)).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/admin/campaigns/{id}", s.requireAdmin(s.handleDeleteCampaign)).Methods("DELETE", "OPTIONS")
+    a.HandleFunc("/admin/campaigns/{id}/close", s.requireAdmin(s.handleCloseCampaign)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/admin/charities/{wallet}", s.requireAdmin(s.handleSetCharity)).Methods("PUT", "OPTIONS")
+    a.HandleFunc("/admin/charities/{wallet}", s.requireAdmin(s.handleRemoveCharity)).Methods("DELETE", "OPTIONS")
+    
+    // Organization self-management (multi-tenant mode)
+    a.HandleFunc("/org", s.handleGetOrg).Methods("GET", "OPTIONS")
+    a.HandleFunc("/org/wallets", s.requireOrgAdmin(s.handleListOrgWallets)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/org/admins", s.requireOrgAdmin(s.handleAddOrgAdmin)).Methods("POST", "OPTIONS")
+    a.HandleFunc("/org/admins/{wallet}", s.requireOrgAdmin(s.handleRemoveOrgAdmin)).Methods("DELETE", "OPTIONS")
+    a.HandleFunc("/org/config", s.requireOrgAdmin(s.handleGetOrgConfig)).Methods("GET", "OPTIONS")
+    a.HandleFunc("/org/config", s.requireOrgAdmin(s.handleUpdateOrgConfig)).Methods("PATCH", "OPTIONS")
+    
+    // API description
+    a.HandleFunc("/openapi.json", s.handleOpenAPI).Methods("GET", "OPTIONS")
+    a.HandleFunc("/docs", s.handleSwaggerUI).Methods("GET", "OPTIONS")
+    
+    // Error code catalog
+    a.HandleFunc("/errors", s.handleErrorCatalog).Methods("GET", "OPTIONS")
+    
+    // Event schemas
+    a.HandleFunc("/schemas", s.handleListSchemas).Methods("GET", "OPTIONS")
+    a.HandleFunc("/schemas/{event}", s.handleGetSchema).Methods("GET", "OPTIONS")
+    
+    // Health checks; /api/health is the liveness check under its old path
+    s.r.HandleFunc("/livez", s.handleLivez).Methods("GET", "OPTIONS")
+    s.r.HandleFunc("/readyz", s.handleReadyz).Methods("GET", "OPTIONS")
+    a.HandleFunc("/health", s.handleLivez).Methods("GET", "OPTIONS")
+    a.HandleFunc("/capabilities", s.handleCapabilities).Methods("GET", "OPTIONS")
 }
 
 func (s *Server) handleGenerateKeypair(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     pub, priv := wallet.GenerateKeypair()
     
-    s.logSvc.LogSystem("keypair_generated", "", r.RemoteAddr, "New keypair generated")
+    s.logSvc.LogSystemCtx(r.Context(), "keypair_generated", "", r.RemoteAddr, "New keypair generated")
     
-    resp := map[string]string{
-        "public": pub,
-        "private": priv,
-        "warning": "Store private key securely. Never share it.",
+    resp := KeypairResponse{
+        Public:  pub,
+        Private: priv,
+        Warning: "Store private key securely. Never share it.",
     }
     json.NewEncoder(w).Encode(resp)
 }
@@ -120,81 +403,27 @@ func (s *Server) handleGenerateKeypair(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleCreateWallet(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    var req struct {
-        Public  string `json:"public"`
-        Private string `json:"private"`
-        Name    string `json:"name"`
-        Email   string `json:"email"`
-        CNIC    string `json:"cnic"`
-    }
+    var req CreateWalletRequest
     
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
+        Error(w, r, CodeInvalidRequest, "Invalid request")
         return
     }
     
-    // Validate email is provided
-    if req.Email == "" {
-        s.logSvc.LogSystem("wallet_creation_failed", "", r.RemoteAddr, "Email is required")
-        http.Error(w, "Email is required", 400)
-        return
-    }
-    
-    // Check if email already exists in database
-    if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        defer cancel()
-        
-        emailExists, err := s.db.CheckEmailExists(ctx, req.Email)
-        if err != nil {
-            s.logSvc.LogSystem("email_check_failed", "", r.RemoteAddr, err.Error())
-            http.Error(w, "Failed to verify email", 500)
-            return
-        }
-        
-        if emailExists {
-            s.logSvc.LogSystem("wallet_creation_failed", "", r.RemoteAddr, "Email already registered: "+req.Email)
-            http.Error(w, "Email already registered. Please use a different email or login with existing wallet.", 409)
-            return
-        }
-    }
-    
-    wobj, err := s.ws.CreateFromPub(req.Public, req.Private, req.Name, req.Email, req.CNIC)
+    wobj, err := s.createWallet(r.Context(), createWalletInput{
+        Public:       req.Public,
+        Private:      req.Private,
+        Name:         req.Name,
+        Email:        req.Email,
+        CNIC:         req.CNIC,
+        Type:         req.Type,
+        SessionToken: bearerToken(r),
+    }, r.RemoteAddr)
     if err != nil {
-        s.logSvc.LogSystem("wallet_creation_failed", "", r.RemoteAddr, err.Error())
-        http.Error(w, err.Error(), 400)
+        writeOpError(w, r, err)
         return
     }
-    
-    // Give new wallet initial faucet balance
-    faucetUTXO := s.bc.CreateFaucetUTXO(wobj.WalletID)
-    s.logSvc.LogSystem("faucet_granted", wobj.WalletID, r.RemoteAddr, fmt.Sprintf("Initial balance of %d coins granted", faucetUTXO.Amount))
-    
-    // Persist to database if available
-    if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-        defer cancel()
-        
-        if err := s.db.SaveWallet(ctx, wobj.WalletID, wobj.PublicKey, wobj.PrivateKey, wobj.FullName, wobj.Email, wobj.CNIC); err != nil {
-            s.logSvc.LogSystem("wallet_db_save_failed", wobj.WalletID, r.RemoteAddr, err.Error())
-            // Continue anyway - wallet is in memory
-        } else {
-            s.logSvc.LogSystem("wallet_persisted", wobj.WalletID, r.RemoteAddr, "Wallet saved to database")
-        }
-        
-        // Save faucet UTXO to database
-        if err := s.db.SaveUTXO(ctx, faucetUTXO.ID, faucetUTXO.Owner, faucetUTXO.Amount, faucetUTXO.OriginTx, faucetUTXO.Index, faucetUTXO.Spent); err != nil {
-            s.logSvc.LogSystem("faucet_utxo_db_save_failed", wobj.WalletID, r.RemoteAddr, err.Error())
-        }
-        
-        // Update wallet balance in database
-        balance := s.bc.GetBalance(wobj.WalletID)
-        if err := s.db.UpdateWalletBalance(ctx, wobj.WalletID, balance); err != nil {
-            s.logSvc.LogSystem("balance_update_failed", wobj.WalletID, r.RemoteAddr, err.Error())
-        }
-    }
-    
-    s.logSvc.LogSystem("wallet_created", wobj.WalletID, r.RemoteAddr, fmt.Sprintf("Wallet created for %s", req.Name))
+    auditWallet(r, wobj.WalletID)
     
     json.NewEncoder(w).Encode(wobj)
 }
@@ -206,13 +435,13 @@ func (s *Server) handleGetWallet(w http.ResponseWriter, r *http.Request) {
     
     wobj, exists := s.ws.Get(wid)
     if !exists {
-        http.Error(w, "Wallet not found", 404)
+        Error(w, r, CodeWalletNotFound, "Wallet not found")
         return
     }
     
     // Don't expose private key in response
     wobj.PrivateKey = "***ENCRYPTED***"
-    json.NewEncoder(w).Encode(wobj)
+    json.NewEncoder(w).Encode(WalletResponse{Wallet: wobj, Nonce: s.bc.GetNonce(wid)})
 }
 
 func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
@@ -221,83 +450,43 @@ func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
     wid := vars["wallet"]
     
     bal := s.bc.GetBalance(wid)
-    json.NewEncoder(w).Encode(map[string]interface{}{"balance": bal, "wallet_id": wid})
+    pending := s.bc.GetPendingBalance(wid)
+    resp := BalanceResponse{
+        WalletID:           wid,
+        Balance:            bal,
+        PendingBalance:     pending,
+        LockedBalance:      s.bc.GetLockedBalance(wid),
+        Decimals:           blockchain.Decimals,
+        BalanceFiat:        s.rates.Convert(bal),
+        PendingBalanceFiat: s.rates.Convert(pending),
+    }
+    if assets := s.bc.GetAssetBalances(wid); len(assets) > 0 {
+        resp.Assets = assets
+    }
+    json.NewEncoder(w).Encode(resp)
 }
 
 func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    var req struct {
-        SenderID   string `json:"sender_id"`
-        ReceiverID string `json:"receiver_id"`
-        Amount     uint64 `json:"amount"`
-        Note       string `json:"note"`
-        PrivateKey string `json:"private_key"`
-    }
+    var req SendRequest
     
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
-        return
-    }
-    
-    // Get sender wallet to get public key
-    sender, exists := s.ws.Get(req.SenderID)
-    if !exists {
-        s.logSvc.LogSystem("send_failed", req.SenderID, r.RemoteAddr, "Sender wallet not found")
-        http.Error(w, "Sender wallet not found", 404)
+        Error(w, r, CodeInvalidRequest, "Invalid request")
         return
     }
+    markRawKeyDeprecated(w, req.PrivateKey)
     
-    // Decrypt private key if it's encrypted
-    privateKey := req.PrivateKey
-    // Check if private key is encrypted (contains non-hex characters or is too long)
-    if len(privateKey) > 128 || !isHexString(privateKey) {
-        decryptedKey, err := wallet.DecryptPrivateKey(privateKey)
-        if err != nil {
-            s.logSvc.LogSystem("send_failed", req.SenderID, r.RemoteAddr, "Failed to decrypt private key: "+err.Error())
-            http.Error(w, "Invalid private key", 400)
-            return
-        }
-        privateKey = decryptedKey
-    }
-    
-    // Create transaction with full UTXO logic
-    tx, err := s.txSvc.CreateTransaction(req.SenderID, req.ReceiverID, req.Amount, req.Note, sender.PublicKey, privateKey)
+    tx, err := s.sendTransaction(r.Context(), req.input(), r.RemoteAddr)
     if err != nil {
-        s.logSvc.LogSystem("send_failed", req.SenderID, r.RemoteAddr, err.Error())
-        http.Error(w, err.Error(), 400)
-        return
-    }
-    
-    // Validate transaction
-    if err := s.txSvc.ValidateTransaction(tx); err != nil {
-        s.logSvc.LogSystem("transaction_validation_failed", req.SenderID, r.RemoteAddr, err.Error())
-        http.Error(w, "Transaction validation failed: "+err.Error(), 400)
+        writeOpError(w, r, err)
         return
     }
     
-    // Add to pending
-    s.bc.AddPending(*tx)
-    s.logSvc.LogTransaction(tx.ID, "created", req.SenderID, "", "pending", r.RemoteAddr)
-    
-    // Persist pending transaction to database
-    if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        defer cancel()
-        
-        if err := s.db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
-            s.logSvc.LogSystem("transaction_db_save_failed", req.SenderID, r.RemoteAddr, err.Error())
-        }
-        
-        if err := s.db.SaveTransactionLog(ctx, tx.ID, "created", req.SenderID, "", "pending", r.RemoteAddr); err != nil {
-            s.logSvc.LogSystem("txlog_db_save_failed", req.SenderID, r.RemoteAddr, err.Error())
-        }
-    }
-    
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "status": "success",
-        "txid": tx.ID,
-        "message": "Transaction added to pending pool",
+    json.NewEncoder(w).Encode(SendResponse{
+        Status:  "success",
+        TxID:    tx.ID,
+        Message: "Transaction added to pending pool",
     })
 }
 
@@ -305,141 +494,45 @@ func (s *Server) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
     var allTxs []blockchain.Transaction
-    for _, block := range s.bc.Chain {
+    for _, block := range s.bc.GetChain() {
         allTxs = append(allTxs, block.Transactions...)
     }
+    if s.orgs.Enabled() {
+        allTxs = s.scopeTxs(r.Context(), allTxs)
+    }
     
     json.NewEncoder(w).Encode(allTxs)
 }
 
 func (s *Server) handleGetPending(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(s.bc.GetPending())
+    json.NewEncoder(w).Encode(s.scopeTxs(r.Context(), s.bc.GetPending()))
 }
 
-func (s *Server) handleMine(w http.ResponseWriter, r *http.Request) {
+// handleMempoolStats reports the depth of each mempool lane. Block space is
+// shared, so the counts cover every organization.
+func (s *Server) handleMempoolStats(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
-    
-    var req struct {
-        MinerWalletID string `json:"miner_wallet_id"`
-        Start         int64  `json:"start,omitempty"`
-    }
-    
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
-        return
-    }
-    
-    if req.MinerWalletID == "" {
-        http.Error(w, "Miner wallet ID is required", 400)
-        return
-    }
-    
-    // Verify miner wallet exists
-    if _, exists := s.ws.Get(req.MinerWalletID); !exists {
-        http.Error(w, "Miner wallet not found", 404)
-        return
-    }
-    
-    ns := req.Start
-    if ns == 0 {
-        ns = 0 // Default nonce start
-    }
-    
-    blk := s.bc.Mine(ns, req.MinerWalletID)
-    
-    // Collect all wallet IDs that need balance updates
-    affectedWallets := make(map[string]bool)
-    for _, tx := range blk.Transactions {
-        if tx.SenderID != "COINBASE" && tx.SenderID != "" {
-            affectedWallets[tx.SenderID] = true
-        }
-        if tx.ReceiverID != "" {
-            affectedWallets[tx.ReceiverID] = true
-        }
-    }
-    
-    // Persist block to database
-    if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-        defer cancel()
-        
-        if err := s.db.SaveBlock(ctx, blk.Index, blk.Timestamp, blk.PreviousHash, blk.Hash, blk.Nonce, blk.MerkleRoot); err != nil {
-            s.logSvc.LogSystem("block_db_save_failed", "", r.RemoteAddr, err.Error())
-        }
-        
-        // Persist all transactions in the block
-        for _, tx := range blk.Transactions {
-            blockIdx := blk.Index
-            if err := s.db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, &blockIdx, "confirmed"); err != nil {
-                s.logSvc.LogSystem("transaction_db_save_failed", tx.SenderID, r.RemoteAddr, err.Error())
-            }
-        }
-        
-        // Persist UTXOs
-        s.bc.RLock()
-        for _, utxo := range s.bc.UTXOs {
-            if err := s.db.SaveUTXO(ctx, utxo.ID, utxo.Owner, utxo.Amount, utxo.OriginTx, utxo.Index, utxo.Spent); err != nil {
-                s.logSvc.LogSystem("utxo_db_save_failed", "", r.RemoteAddr, err.Error())
-            }
-        }
-        s.bc.RUnlock()
-        
-        // Update wallet balances in database for all affected wallets
-        for walletID := range affectedWallets {
-            balance := s.bc.GetBalance(walletID)
-            if err := s.db.UpdateWalletBalance(ctx, walletID, balance); err != nil {
-                s.logSvc.LogSystem("balance_update_failed", walletID, r.RemoteAddr, err.Error())
-            }
-        }
-    }
-    
-    // Log all transactions in the mined block
-    for _, tx := range blk.Transactions {
-        s.logSvc.LogTransaction(tx.ID, "mined", tx.SenderID, blk.Hash, "confirmed", r.RemoteAddr)
-        
-        // Persist transaction log to database
-        if s.db != nil {
-            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-            s.db.SaveTransactionLog(ctx, tx.ID, "mined", tx.SenderID, blk.Hash, "confirmed", r.RemoteAddr)
-            cancel()
-        }
-    }
-    
-    s.logSvc.LogSystem("block_mined", "", r.RemoteAddr, fmt.Sprintf("Block #%d mined with %d transactions", blk.Index, len(blk.Transactions)))
-    
-    // Persist system log to database
-    if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        s.db.SaveSystemLog(ctx, "block_mined", "", r.RemoteAddr, fmt.Sprintf("Block #%d mined with %d transactions", blk.Index, len(blk.Transactions)))
-        cancel()
-    }
-    
-    json.NewEncoder(w).Encode(blk)
+    json.NewEncoder(w).Encode(s.bc.MempoolStats())
 }
 
+// handleBlocks lists the chain, or the blocks from index from to index to
+// when either is given
 func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(s.bc.Chain)
-}
-
-func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
-    w.Header().Set("Content-Type", "application/json")
-    vars := mux.Vars(r)
-    indexStr := vars["index"]
     
-    index, err := strconv.ParseInt(indexStr, 10, 64)
-    if err != nil {
-        http.Error(w, "Invalid block index", 400)
+    from, to, ok := s.blockRange(w, r)
+    if !ok {
         return
     }
     
-    if index < 0 || int(index) >= len(s.bc.Chain) {
-        http.Error(w, "Block not found", 404)
-        return
+    blocks := s.bc.GetBlocks(from, to)
+    if s.orgs.Enabled() {
+        for i, b := range blocks {
+            blocks[i] = s.scopeBlock(r.Context(), b)
+        }
     }
-    
-    json.NewEncoder(w).Encode(s.bc.Chain[index])
+    json.NewEncoder(w).Encode(blocks)
 }
 
 func (s *Server) handleGetUTXOs(w http.ResponseWriter, r *http.Request) {
@@ -448,8 +541,8 @@ func (s *Server) handleGetUTXOs(w http.ResponseWriter, r *http.Request) {
     wid := vars["wallet"]
     
     var utxos []blockchain.UTXO
-    for _, utxo := range s.bc.UTXOs {
-        if utxo.Owner == wid && !utxo.Spent {
+    for _, utxo := range s.bc.GetWalletUTXOs(wid) {
+        if !utxo.Spent {
             utxos = append(utxos, utxo)
         }
     }
@@ -468,6 +561,11 @@ func (s *Server) handleGetSystemLogs(w http.ResponseWriter, r *http.Request) {
         }
     }
     
+    if s.orgs.Enabled() {
+        json.NewEncoder(w).Encode(s.scopeSystemLogs(r.Context(), limit))
+        return
+    }
+    
     logs := s.logSvc.GetSystemLogs(limit)
     json.NewEncoder(w).Encode(logs)
 }
@@ -483,6 +581,11 @@ func (s *Server) handleGetTransactionLogs(w http.ResponseWriter, r *http.Request
         }
     }
     
+    if s.orgs.Enabled() {
+        json.NewEncoder(w).Encode(s.scopeTransactionLogs(r.Context(), limit))
+        return
+    }
+    
     logs := s.logSvc.GetTransactionLogs("", limit)
     json.NewEncoder(w).Encode(logs)
 }
@@ -514,14 +617,17 @@ func (s *Server) handleWalletReport(w http.ResponseWriter, r *http.Request) {
     var sent, received uint64 = 0, 0
     var sentCount, receivedCount int = 0, 0
     
-    for _, block := range s.bc.Chain {
+    for _, block := range s.bc.GetChain() {
         for _, tx := range block.Transactions {
+            if tx.AssetID != "" {
+                continue // totals are in coins
+            }
             if tx.SenderID == wid {
                 sent += tx.Amount
                 sentCount++
             }
-            if tx.ReceiverID == wid {
-                received += tx.Amount
+            if tx.Pays(wid) {
+                received += tx.PaidTo(wid)
                 receivedCount++
             }
         }
@@ -529,11 +635,19 @@ func (s *Server) handleWalletReport(w http.ResponseWriter, r *http.Request) {
     
     report := map[string]interface{}{
         "wallet_id":       wid,
+        "type":            s.walletType(wid),
         "balance":         balance,
         "total_sent":      sent,
         "total_received":  received,
         "sent_count":      sentCount,
         "received_count":  receivedCount,
+        "decimals":        blockchain.Decimals,
+    }
+    // Totals are priced at the current rates; statements price each transaction at its own
+    if fiat := s.rates.Convert(balance); fiat != nil {
+        report["balance_fiat"] = fiat
+        report["total_sent_fiat"] = s.rates.Convert(sent)
+        report["total_received_fiat"] = s.rates.Convert(received)
     }
     
     json.NewEncoder(w).Encode(report)
@@ -542,80 +656,181 @@ func (s *Server) handleWalletReport(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleSystemReport(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    totalBlocks := len(s.bc.Chain)
+    blocks := s.bc.GetChain()
+    totalBlocks := len(blocks)
     var totalTxs int
-    for _, block := range s.bc.Chain {
-        totalTxs += len(block.Transactions)
+    volume := make(map[string]*typeVolume)
+    for _, t := range wallet.Types {
+        volume[t] = &typeVolume{}
+    }
+    for _, block := range blocks {
+        txs := s.scopeTxs(r.Context(), block.Transactions)
+        totalTxs += len(txs)
+        for _, tx := range txs {
+            if tx.AssetID != "" {
+                continue // volumes are in coins
+            }
+            sent := volume[s.walletType(tx.SenderID)]
+            sent.SentVolume += tx.Amount
+            sent.SentCount++
+            payees := []string{tx.ReceiverID}
+            if tx.ReceiverID == blockchain.BatchReceiver {
+                payees = tx.Payees()
+            }
+            for _, payee := range payees {
+                received := volume[s.walletType(payee)]
+                received.ReceivedVolume += tx.PaidTo(payee)
+                received.ReceivedCount++
+            }
+        }
+    }
+    for _, wlt := range s.ws.GetAll() {
+        if s.inOrg(r.Context(), wlt.WalletID) {
+            volume[wlt.TypeOrDefault()].Wallets++
+        }
+    }
+    totalUTXOs := s.bc.UTXOCount()
+    if s.orgs.Enabled() {
+        totalUTXOs = 0
+        for _, u := range s.bc.GetUTXOs() {
+            if s.inOrg(r.Context(), u.Owner) {
+                totalUTXOs++
+            }
+        }
     }
     
     report := map[string]interface{}{
         "total_blocks":       totalBlocks,
         "total_transactions": totalTxs,
-        "pending_transactions": len(s.bc.GetPending()),
-        "total_utxos":        len(s.bc.UTXOs),
-        "difficulty":         s.bc.DifficultyPref,
+        "volume_by_type":     volume,
+        "pending_transactions": len(s.scopeTxs(r.Context(), s.bc.GetPending())),
+        "total_utxos":        totalUTXOs,
+        "difficulty":         s.bc.ActiveConsensus().Difficulty(),
+        "consensus":          s.bc.ActiveConsensus().Mode(),
+        "min_confirmations":  s.bc.MinConfirmations,
+        "campaigns":          s.campaigns.Totals(s.orgCampaigns(r.Context())),
     }
     
     json.NewEncoder(w).Encode(report)
 }
 
+// typeVolume aggregates on-chain activity for one wallet type
+type typeVolume struct {
+    Wallets        int    `json:"wallets"`
+    SentVolume     uint64 `json:"sent_volume"`
+    SentCount      int    `json:"sent_count"`
+    ReceivedVolume uint64 `json:"received_volume"`
+    ReceivedCount  int    `json:"received_count"`
+}
+
+// walletType returns the type of a wallet; pseudo-wallets such as COINBASE,
+// ZAKAT_POOL and ANCHOR are not in the store and count as system
+func (s *Server) walletType(walletID string) string {
+    if wlt, ok := s.ws.Get(walletID); ok {
+        return wlt.TypeOrDefault()
+    }
+    return wallet.TypeSystem
+}
+
 func (s *Server) handleSendOTP(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    var req struct {
-        Email string `json:"email"`
-    }
+    var req SendOTPRequest
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
+    if !decodeRequest(w, r, &req) {
         return
     }
     
-    if req.Email == "" {
-        http.Error(w, "Email is required", 400)
+    code, err := otp.StoreOTP(req.Email)
+    var limit *otp.LimitError
+    if errors.As(err, &limit) {
+        s.otpLimited(w, r, req.Email, limit)
+        return
+    }
+    if err != nil {
+        Error(w, r, CodeInternal, "Failed to create OTP")
         return
     }
     
-    code := otp.StoreOTP(req.Email)
-    s.logSvc.LogSystem("otp_sent", "", r.RemoteAddr, fmt.Sprintf("OTP sent to %s", req.Email))
+    // Email the code when SMTP is configured; otherwise return it in the response (DEMO ONLY)
+    if s.deliveries.HasSender(services.ChannelEmail) {
+        // Organizations may brand the email; X-Org-ID is optional here
+        orgID, _ := s.optionalOrg(r)
+        subject, body, err := s.config.ForOrg(orgID).RenderEmail(services.EmailOTP, services.EmailData{Code: code, Minutes: int(otp.CurrentPolicy().CodeTTL / time.Minute)})
+        if err != nil {
+            Error(w, r, CodeInternal, "Failed to render email")
+            return
+        }
+        s.deliveries.EnqueueEmail(req.Email, subject, body, "otp.sent", "")
+        s.logSvc.LogSystemCtx(r.Context(), "otp_sent", "", r.RemoteAddr, fmt.Sprintf("OTP emailed to %s", req.Email))
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "status":  "success",
+            "message": "OTP sent to email",
+        })
+        return
+    }
     
-    // In production, send email here using SendGrid, AWS SES, etc.
-    // For now, we'll just return the code in the response (DEMO ONLY)
+    s.logSvc.LogSystemCtx(r.Context(), "otp_sent", "", r.RemoteAddr, fmt.Sprintf("OTP sent to %s", req.Email))
     json.NewEncoder(w).Encode(map[string]interface{}{
         "status":  "success",
         "message": "OTP sent to email",
-        "code":    code, // Remove this in production!
+        "code":    code, // Only returned when SMTP is not configured
     })
 }
 
 func (s *Server) handleVerifyOTP(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    var req struct {
-        Email string `json:"email"`
-        Code  string `json:"code"`
-    }
-    
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
-        return
-    }
+    var req VerifyOTPRequest
     
-    if req.Email == "" || req.Code == "" {
-        http.Error(w, "Email and code are required", 400)
+    if !decodeRequest(w, r, &req) {
         return
     }
     
     if otp.VerifyOTP(req.Email, req.Code) {
-        s.logSvc.LogSystem("otp_verified", "", r.RemoteAddr, fmt.Sprintf("OTP verified for %s", req.Email))
-        json.NewEncoder(w).Encode(map[string]interface{}{
-            "status":   "success",
-            "verified": true,
-            "message":  "OTP verified successfully",
+        s.logSvc.LogSystemCtx(r.Context(), "otp_verified", "", r.RemoteAddr, fmt.Sprintf("OTP verified for %s", req.Email))
+        
+        // A verified code logs the email in, the same as Google login
+        var userID int64
+        if s.db != nil {
+            ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+            defer cancel()
+            userID, _ = s.db.MarkUserVerified(ctx, req.Email) // no user row yet is fine
+        }
+        login, err := s.issueSession(req.Email, userID, services.LoginOTP)
+        if err != nil {
+            Error(w, r, CodeInternal, "Failed to create session")
+            return
+        }
+        json.NewEncoder(w).Encode(VerifyOTPResponse{
+            Status:        "success",
+            Verified:      true,
+            Message:       "OTP verified successfully",
+            LoginResponse: login,
         })
     } else {
-        s.logSvc.LogSystem("otp_verification_failed", "", r.RemoteAddr, fmt.Sprintf("OTP verification failed for %s", req.Email))
-        http.Error(w, "Invalid or expired OTP", 400)
+        s.logSvc.LogSystemCtx(r.Context(), "otp_verification_failed", "", r.RemoteAddr, fmt.Sprintf("OTP verification failed for %s", req.Email))
+        if until, locked := otp.LockedUntil(req.Email); locked {
+            s.otpLimited(w, r, req.Email, &otp.LimitError{Err: otp.ErrLocked, Until: until})
+            return
+        }
+        Error(w, r, CodeInvalidOTP, "Invalid or expired OTP")
+    }
+}
+
+// otpLimited reports a code refused by a lockout, the resend cooldown or the
+// daily limit, with the seconds until it ends in Retry-After
+func (s *Server) otpLimited(w http.ResponseWriter, r *http.Request, email string, limit *otp.LimitError) {
+    w.Header().Set("Retry-After", strconv.FormatInt(int64(time.Until(limit.Until).Seconds())+1, 10))
+    switch {
+    case errors.Is(limit, otp.ErrCooldown):
+        Error(w, r, CodeOTPCooldown, "A code was sent moments ago; try again later")
+    case errors.Is(limit, otp.ErrDailyLimit):
+        s.logSvc.LogSystemCtx(r.Context(), "otp_daily_limit", "", r.RemoteAddr, fmt.Sprintf("OTP daily limit reached for %s", email))
+        Error(w, r, CodeOTPDailyLimit, "Too many codes sent today; try again later")
+    default:
+        s.logSvc.LogSystemCtx(r.Context(), "otp_locked", "", r.RemoteAddr, fmt.Sprintf("OTP locked for %s", email))
+        Error(w, r, CodeOTPLocked, "Too many failed verifications; try again later")
     }
 }
 
@@ -629,7 +844,7 @@ func (s *Server) handleCheckAdmin(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
     defer cancel()
     
     isAdmin, err := s.db.IsAdmin(ctx, walletID)
@@ -641,53 +856,55 @@ func (s *Server) handleCheckAdmin(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(map[string]interface{}{"is_admin": isAdmin})
 }
 
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-}
-
 func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     vars := mux.Vars(r)
     walletID := vars["wallet"]
     
-    var req struct {
-        FullName string `json:"full_name"`
-        Email    string `json:"email"`
-        CNIC     string `json:"cnic"`
-    }
+    var req UpdateProfileRequest
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
+    if !decodeRequest(w, r, &req) {
         return
     }
     
     // Verify wallet exists
     wobj, exists := s.ws.Get(walletID)
     if !exists {
-        http.Error(w, "Wallet not found", 404)
+        Error(w, r, CodeWalletNotFound, "Wallet not found")
         return
     }
     
     // Update wallet in memory
+    before := wobj
     wobj.FullName = req.FullName
     wobj.Email = req.Email
     wobj.CNIC = req.CNIC
+    if req.MonthlyStatements != nil {
+        wobj.MonthlyStatements = *req.MonthlyStatements
+    }
     s.ws.Save(wobj)
     
     // Update in database
     if s.db != nil {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
         defer cancel()
         
         if err := s.db.UpdateUserProfile(ctx, walletID, req.FullName, req.Email, req.CNIC); err != nil {
-            s.logSvc.LogSystem("profile_update_failed", walletID, r.RemoteAddr, err.Error())
-            http.Error(w, "Failed to update profile", 500)
+            s.logSvc.LogSystemCtx(r.Context(), "profile_update_failed", walletID, r.RemoteAddr, err.Error())
+            Error(w, r, CodeInternal, "Failed to update profile")
             return
         }
+        if req.MonthlyStatements != nil {
+            if err := s.db.UpdateWalletStatements(ctx, walletID, *req.MonthlyStatements); err != nil {
+                s.logSvc.LogSystemCtx(r.Context(), "profile_update_failed", walletID, r.RemoteAddr, err.Error())
+                Error(w, r, CodeInternal, "Failed to update profile")
+                return
+            }
+        }
     }
     
-    s.logSvc.LogSystem("profile_updated", walletID, r.RemoteAddr, "Profile updated successfully")
+    s.logSvc.LogSystemCtx(r.Context(), "profile_updated", walletID, r.RemoteAddr, "Profile updated successfully")
+    s.notifications.ProfileChanged(before, wobj)
     
     json.NewEncoder(w).Encode(map[string]interface{}{
         "status": "success",
@@ -706,7 +923,7 @@ func (s *Server) handleGetBeneficiaries(w http.ResponseWriter, r *http.Request)
         return
     }
     
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
     defer cancel()
     
     // Get user_id from wallet_id
@@ -719,7 +936,7 @@ func (s *Server) handleGetBeneficiaries(w http.ResponseWriter, r *http.Request)
     
     beneficiaries, err := s.db.GetBeneficiaries(ctx, userID)
     if err != nil {
-        http.Error(w, err.Error(), 500)
+        Error(w, r, CodeInternal, err.Error())
         return
     }
     
@@ -729,30 +946,68 @@ func (s *Server) handleGetBeneficiaries(w http.ResponseWriter, r *http.Request)
 func (s *Server) handleAddBeneficiary(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     
-    var req struct {
-        UserID              string `json:"user_id"`                // wallet_id from frontend
-        BeneficiaryName     string `json:"beneficiary_name"`
-        BeneficiaryWalletID string `json:"beneficiary_wallet_id"`
-        Relationship        string `json:"relationship"`
-    }
+    var req AddBeneficiaryRequest
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request", 400)
+    if !decodeRequest(w, r, &req) {
         return
     }
     
     if s.db == nil {
-        http.Error(w, "Database not connected", 503)
+        Error(w, r, CodeDatabaseUnavailable, "Database not connected")
         return
     }
     
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    // Beneficiary must point at a real wallet
+    if !s.inOrg(r.Context(), req.UserID) {
+        Error(w, r, CodeWalletNotFound, "Wallet not found")
+        return
+    }
+    
+    if _, exists := s.ws.Get(req.BeneficiaryWalletID); !exists || !s.inOrg(r.Context(), req.BeneficiaryWalletID) {
+        Error(w, r, CodeWalletNotFound, "Beneficiary wallet not found")
+        return
+    }
+    
+    alias, err := normalizeAlias(req.Alias)
+    if err != nil {
+        Error(w, r, CodeValidationFailed, err.Error())
+        return
+    }
+    
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
     defer cancel()
     
     // Get numeric user_id from wallet_id
     userID, err := s.db.GetUserIDByWalletID(ctx, req.UserID)
     if err != nil {
-        http.Error(w, "User not found: "+err.Error(), 404)
+        Error(w, r, CodeUserNotFound, "User not found: "+err.Error())
+        return
+    }
+    
+    // Duplicate detection: one entry per wallet, aliases unique per address book
+    exists, err := s.db.BeneficiaryExists(ctx, userID, req.BeneficiaryWalletID)
+    if err != nil {
+        Error(w, r, CodeInternal, err.Error())
+        return
+    }
+    if exists {
+        Error(w, r, CodeBeneficiaryExists, "Beneficiary already exists")
+        return
+    }
+    
+    if alias != "" {
+        taken, err := s.db.BeneficiaryAliasExists(ctx, userID, alias, 0)
+        if err != nil {
+            Error(w, r, CodeInternal, err.Error())
+            return
+        }
+        if taken {
+            Error(w, r, CodeAliasTaken, "Alias already in use: "+alias)
+            return
+        }
+    }
+    
+    if req.SharePercent > 0 && !s.shareFits(ctx, w, r, userID, 0, req.SharePercent) {
         return
     }
     
@@ -762,14 +1017,87 @@ func (s *Server) handleAddBeneficiary(w http.ResponseWriter, r *http.Request) {
         relationship = "Other"
     }
     
-    if err := s.db.AddBeneficiary(ctx, userID, req.BeneficiaryWalletID, req.BeneficiaryName, relationship); err != nil {
-        http.Error(w, err.Error(), 500)
+    if err := s.db.AddBeneficiary(ctx, userID, req.BeneficiaryWalletID, req.BeneficiaryName, relationship, alias, req.SharePercent); err != nil {
+        Error(w, r, CodeInternal, err.Error())
         return
     }
     
-    s.logSvc.LogSystem("beneficiary_added", req.BeneficiaryWalletID, r.RemoteAddr, fmt.Sprintf("User %s added beneficiary %s", req.UserID, req.BeneficiaryWalletID))
+    s.logSvc.LogSystemCtx(r.Context(), "beneficiary_added", req.BeneficiaryWalletID, r.RemoteAddr, fmt.Sprintf("User %s added beneficiary %s", req.UserID, req.BeneficiaryWalletID))
+    s.notifications.BeneficiaryAdded(req.UserID, req.BeneficiaryWalletID, req.BeneficiaryName)
     
-    json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Beneficiary added"})
+    json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Beneficiary added", "alias": alias})
+}
+
+func (s *Server) handleUpdateBeneficiary(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    vars := mux.Vars(r)
+    walletID := vars["user_id"] // Actually wallet_id from frontend
+    
+    beneficiaryID, err := strconv.ParseInt(vars["beneficiary_id"], 10, 64)
+    if err != nil {
+        Error(w, r, CodeValidationFailed, "Invalid beneficiary ID")
+        return
+    }
+    
+    var req UpdateBeneficiaryRequest
+    
+    if !decodeRequest(w, r, &req) {
+        return
+    }
+    
+    if s.db == nil {
+        Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+        return
+    }
+    
+    alias, err := normalizeAlias(req.Alias)
+    if err != nil {
+        Error(w, r, CodeValidationFailed, err.Error())
+        return
+    }
+    
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+    defer cancel()
+    
+    userID, err := s.db.GetUserIDByWalletID(ctx, walletID)
+    if err != nil {
+        Error(w, r, CodeUserNotFound, "User not found: "+err.Error())
+        return
+    }
+    
+    if alias != "" {
+        taken, err := s.db.BeneficiaryAliasExists(ctx, userID, alias, beneficiaryID)
+        if err != nil {
+            Error(w, r, CodeInternal, err.Error())
+            return
+        }
+        if taken {
+            Error(w, r, CodeAliasTaken, "Alias already in use: "+alias)
+            return
+        }
+    }
+    
+    if req.SharePercent != nil && *req.SharePercent > 0 && !s.shareFits(ctx, w, r, userID, beneficiaryID, *req.SharePercent) {
+        return
+    }
+    
+    relationship := req.Relationship
+    if relationship == "" {
+        relationship = "Other"
+    }
+    
+    if err := s.db.UpdateBeneficiary(ctx, userID, beneficiaryID, req.BeneficiaryName, relationship, alias, req.SharePercent); err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            Error(w, r, CodeBeneficiaryNotFound, "Beneficiary not found")
+            return
+        }
+        Error(w, r, CodeInternal, err.Error())
+        return
+    }
+    
+    s.logSvc.LogSystemCtx(r.Context(), "beneficiary_updated", walletID, r.RemoteAddr, fmt.Sprintf("User %s updated beneficiary %d", walletID, beneficiaryID))
+    
+    json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Beneficiary updated", "alias": alias})
 }
 
 func (s *Server) handleRemoveBeneficiary(w http.ResponseWriter, r *http.Request) {
@@ -780,31 +1108,31 @@ func (s *Server) handleRemoveBeneficiary(w http.ResponseWriter, r *http.Request)
     
     beneficiaryID, err := strconv.ParseInt(beneficiaryIDStr, 10, 64)
     if err != nil {
-        http.Error(w, "Invalid beneficiary ID", 400)
+        Error(w, r, CodeValidationFailed, "Invalid beneficiary ID")
         return
     }
     
     if s.db == nil {
-        http.Error(w, "Database not connected", 503)
+        Error(w, r, CodeDatabaseUnavailable, "Database not connected")
         return
     }
     
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
     defer cancel()
     
     // Get numeric user_id from wallet_id
     userID, err := s.db.GetUserIDByWalletID(ctx, walletID)
     if err != nil {
-        http.Error(w, "User not found: "+err.Error(), 404)
+        Error(w, r, CodeUserNotFound, "User not found: "+err.Error())
         return
     }
     
     if err := s.db.RemoveBeneficiary(ctx, userID, beneficiaryID); err != nil {
-        http.Error(w, err.Error(), 500)
+        Error(w, r, CodeInternal, err.Error())
         return
     }
     
-    s.logSvc.LogSystem("beneficiary_removed", "", r.RemoteAddr, fmt.Sprintf("User %s removed beneficiary %d", walletID, beneficiaryID))
+    s.logSvc.LogSystemCtx(r.Context(), "beneficiary_removed", "", r.RemoteAddr, fmt.Sprintf("User %s removed beneficiary %d", walletID, beneficiaryID))
     
     json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Beneficiary removed"})
 }
@@ -819,18 +1147,61 @@ func (s *Server) handleGetZakatDeductions(w http.ResponseWriter, r *http.Request
         return
     }
     
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
     defer cancel()
     
     deductions, err := s.db.GetZakatDeductions(ctx, wid)
     if err != nil {
-        http.Error(w, err.Error(), 500)
+        Error(w, r, CodeInternal, err.Error())
         return
     }
     
     json.NewEncoder(w).Encode(deductions)
 }
 
+// resolveBeneficiaryAlias looks up an alias in the sender's beneficiary list
+func (s *Server) resolveBeneficiaryAlias(ctx context.Context, senderID, alias string) (string, error) {
+    normalized, err := normalizeAlias(alias)
+    if err != nil {
+        return "", err
+    }
+    
+    userID, err := s.db.GetUserIDByWalletID(ctx, senderID)
+    if err != nil {
+        return "", fmt.Errorf("sender has no address book")
+    }
+    
+    walletID, err := s.db.GetBeneficiaryWalletByAlias(ctx, userID, normalized)
+    if err != nil {
+        return "", fmt.Errorf("no beneficiary with alias %q", normalized)
+    }
+    return walletID, nil
+}
+
+var aliasPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// normalizeAlias lowercases an alias and strips a leading "@"; empty aliases are allowed
+func normalizeAlias(alias string) (string, error) {
+    alias = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(alias), "@"))
+    if alias == "" {
+        return "", nil
+    }
+    if !aliasPattern.MatchString(alias) {
+        return "", fmt.Errorf("invalid alias: use 1-32 letters, digits, '.', '_' or '-'")
+    }
+    return alias, nil
+}
+
+// resolvePrivateKey accepts either a raw hex private key or the encrypted form
+// stored with the wallet, and returns the raw hex key
+func resolvePrivateKey(privateKey string) (string, error) {
+    // Check if private key is encrypted (contains non-hex characters or is too long)
+    if len(privateKey) > 128 || !isHexString(privateKey) {
+        return wallet.DecryptPrivateKey(privateKey)
+    }
+    return privateKey, nil
+}
+
 // Helper function to check if a string is valid hexadecimal
 func isHexString(s string) bool {
     for _, c := range s {
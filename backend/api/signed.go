@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blockchain-backend/services"
+)
+
+// handlePrepareTransaction selects UTXOs for a transfer and returns it
+// unsigned, with the exact payload an offline (cold) wallet has to sign
+func (s *Server) handlePrepareTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	senderID, receiverID := q.Get("sender_id"), q.Get("receiver_id")
+	if senderID == "" || receiverID == "" {
+		Error(w, r, CodeValidationFailed, "sender_id and receiver_id are required")
+		return
+	}
+	amount, err := strconv.ParseUint(q.Get("amount"), 10, 64)
+	if err != nil || amount == 0 {
+		Error(w, r, CodeValidationFailed, "amount must be a positive integer")
+		return
+	}
+	if !s.inOrg(r.Context(), senderID) {
+		Error(w, r, CodeWalletNotFound, "Sender wallet not found")
+		return
+	}
+	if !s.inOrg(r.Context(), receiverID) {
+		Error(w, r, CodeWalletNotFound, "Receiver wallet not found")
+		return
+	}
+
+	tx, selected, err := s.txSvc.PrepareAssetTransaction(r.Context(), senderID, receiverID, q.Get("asset_id"), amount, q.Get("note"))
+	if err != nil {
+		writeOpError(w, r, fail(transactionErrorCode(err), err.Error()))
+		return
+	}
+
+	tf, _ := s.twoFactor.Get(senderID)
+	json.NewEncoder(w).Encode(PrepareTransactionResponse{
+		Transaction:       *tx,
+		SelectedUTXOs:     selected,
+		SigningPayloadHex: hex.EncodeToString(services.SigningPayload(tx)),
+		TOTPRequired:      tf.Enabled && coinAmount(tx) > tf.Threshold,
+		ExpiresAt:         time.Unix(tx.Timestamp, 0).Add(services.SignedTxMaxAge).UTC(),
+	})
+}
+
+// handleSubmitSigned queues a transaction signed outside the server
+func (s *Server) handleSubmitSigned(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SubmitSignedRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tx, err := s.submitSignedTransaction(r.Context(), req.Transaction, req.TOTPCode, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(SendResponse{
+		Status:  "success",
+		TxID:    tx.ID,
+		Message: "Signed transaction added to pending pool",
+	})
+}
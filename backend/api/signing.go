@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// signingTokenHeader carries the token to revoke, and for gRPC sends the
+// token itself as x-signing-token metadata
+const signingTokenHeader = "X-Signing-Token"
+
+// signingError maps signing service errors to API error codes
+func signingError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrSigningLimitExceeded):
+		return fail(CodeSigningLimit, err.Error())
+	case errors.Is(err, services.ErrSigningTokenInvalid), errors.Is(err, services.ErrSigningTokenWallet):
+		return fail(CodeSigningTokenInvalid, err.Error())
+	case errors.Is(err, services.ErrRawKeyRejected):
+		return fail(CodeRawKeyRejected, err.Error())
+	}
+	return fail(CodeInternal, err.Error())
+}
+
+// resolveSigner returns the hex private key that signs for wlt. A signing
+// token unlocks the wallet's stored key; a raw private_key is deprecated and
+// refused outright when REJECT_PRIVATE_KEYS is on. sess is nil for raw keys.
+func (s *Server) resolveSigner(ctx context.Context, wlt wallet.Wallet, token, privateKey, remoteAddr string) (string, *services.SigningSession, error) {
+	switch {
+	case token != "" && privateKey != "":
+		return "", nil, fail(CodeValidationFailed, "Provide either signing_token or private_key, not both")
+
+	case token != "":
+		sess, ok := s.signing.Get(token)
+		if !ok {
+			return "", nil, signingError(services.ErrSigningTokenInvalid)
+		}
+		if sess.WalletID != wlt.WalletID {
+			return "", nil, signingError(services.ErrSigningTokenWallet)
+		}
+		privHex, err := wallet.DecryptPrivateKey(wlt.PrivateKey)
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "signing_key_unavailable", wlt.WalletID, remoteAddr, err.Error())
+			return "", nil, fail(CodeInternal, "The wallet's stored key could not be decrypted")
+		}
+		return privHex, &sess, nil
+
+	case privateKey != "":
+		if !s.signing.RawKeysAllowed() {
+			s.logSvc.LogSystemCtx(ctx, "private_key_rejected", wlt.WalletID, remoteAddr, "Request carried a raw private key")
+			return "", nil, signingError(services.ErrRawKeyRejected)
+		}
+		privHex, err := resolvePrivateKey(privateKey)
+		if err != nil {
+			s.logSvc.LogSystemCtx(ctx, "send_failed", wlt.WalletID, remoteAddr, "Failed to decrypt private key: "+err.Error())
+			return "", nil, fail(CodeInvalidKey, "Invalid private key")
+		}
+		s.logSvc.LogSystemCtx(ctx, "private_key_deprecated", wlt.WalletID, remoteAddr, "Request carried a raw private key")
+		return privHex, nil, nil
+	}
+
+	if s.signing.RawKeysAllowed() {
+		return "", nil, fail(CodeValidationFailed, "signing_token (or the deprecated private_key) is required")
+	}
+	return "", nil, fail(CodeValidationFailed, "signing_token is required")
+}
+
+// markRawKeyDeprecated tells clients that sent private_key to move to signing
+// sessions (RFC 8594 style Deprecation header)
+func markRawKeyDeprecated(w http.ResponseWriter, privateKey string) {
+	if privateKey == "" {
+		return
+	}
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Warning", `299 - "private_key is deprecated; create a signing session and send signing_token"`)
+}
+
+// handleCreateSigningSession issues a short-lived token that lets the server
+// sign for the wallet with its stored key. Wallets with 2FA prove control with
+// an authenticator code; others with a code emailed by POST /api/otp/send.
+func (s *Server) handleCreateSigningSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SigningSessionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	wlt, ok := s.ws.Get(req.WalletID)
+	if !ok || !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	var method string
+	if tf, _ := s.twoFactor.Get(wlt.WalletID); tf.Enabled {
+		if req.TOTPCode == "" {
+			Error(w, r, CodeTOTPRequired, "The wallet has 2FA enabled; totp_code is required")
+			return
+		}
+		if err := s.twoFactor.Verify(wlt.WalletID, req.TOTPCode); err != nil {
+			s.logSvc.LogSystemCtx(r.Context(), "signing_session_denied", wlt.WalletID, r.RemoteAddr, err.Error())
+			writeOpError(w, r, twoFactorError(err))
+			return
+		}
+		method = services.SigningAuthTOTP
+	} else {
+		if wlt.Email == "" {
+			Error(w, r, CodeValidationFailed, "The wallet has no email for a one-time code; enable 2FA instead")
+			return
+		}
+		if req.OTPCode == "" || !otp.VerifyOTP(wlt.Email, req.OTPCode) {
+			s.logSvc.LogSystemCtx(r.Context(), "signing_session_denied", wlt.WalletID, r.RemoteAddr, "Invalid or missing OTP")
+			Error(w, r, CodeInvalidOTP, "Invalid or expired otp_code; request one with POST /api/otp/send")
+			return
+		}
+		otp.ClearOTP(wlt.Email)
+		method = services.SigningAuthEmailOTP
+	}
+
+	token, sess, err := s.signing.Issue(wlt.WalletID, method, req.SpendLimit, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to create signing session")
+		return
+	}
+
+	limit := "no spend limit"
+	if sess.SpendLimit > 0 {
+		limit = fmt.Sprintf("spend limit %d", sess.SpendLimit)
+	}
+	s.logSvc.LogSystemCtx(r.Context(), "signing_session_created", wlt.WalletID, r.RemoteAddr, fmt.Sprintf("Authorized by %s, %s, expires %s", method, limit, sess.ExpiresAt.Format(time.RFC3339)))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(SigningSessionResponse{SigningToken: token, SigningSession: sess})
+}
+
+// handleGetSigningSession shows the session behind the X-Signing-Token header
+func (s *Server) handleGetSigningSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sess, ok := s.signing.Get(r.Header.Get(signingTokenHeader))
+	if !ok || !s.inOrg(r.Context(), sess.WalletID) {
+		Error(w, r, CodeSigningTokenInvalid, "Missing, invalid or expired signing token")
+		return
+	}
+	json.NewEncoder(w).Encode(sess)
+}
+
+// handleRevokeSigningSession ends the session behind the X-Signing-Token header
+func (s *Server) handleRevokeSigningSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token := r.Header.Get(signingTokenHeader)
+	sess, ok := s.signing.Get(token)
+	if !ok || !s.inOrg(r.Context(), sess.WalletID) {
+		Error(w, r, CodeSigningTokenInvalid, "Missing, invalid or expired signing token")
+		return
+	}
+	s.signing.Revoke(token)
+
+	s.logSvc.LogSystemCtx(r.Context(), "signing_session_revoked", sess.WalletID, r.RemoteAddr, "Signing session ended by the client")
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Signing session revoked"})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleSimulateSend runs a send's receiver resolution, coin selection, fee
+// and validation without signing it or changing any state. It takes the body
+// of POST /api/send; the signing and second-factor fields are ignored, and a
+// send that would fail answers with the error the send would.
+func (s *Server) handleSimulateSend(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SendRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	in := sendInput{
+		SenderID:      req.SenderID,
+		ReceiverID:    req.ReceiverID,
+		ReceiverAlias: req.ReceiverAlias,
+		Amount:        req.Amount,
+		AssetID:       req.AssetID,
+		LockTime:      req.LockTime,
+		HashLock:      req.HashLock,
+		Note:          req.Note,
+	}
+	if errs := in.validate(); len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if _, err := s.resolveParties(r.Context(), &in); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	sim, err := s.txSvc.SimulateTransfer(r.Context(), in.SenderID, in.ReceiverID, in.AssetID, in.Amount, in.lock(), in.Note)
+	if err != nil {
+		writeOpError(w, r, fail(transactionErrorCode(err), err.Error()))
+		return
+	}
+
+	resp := SimulateSendResponse{
+		Transaction:      *sim.Transaction,
+		SelectedUTXOs:    sim.SelectedUTXOs,
+		Change:           sim.Change,
+		Fee:              sim.Transaction.Fee,
+		Balance:          sim.Balance,
+		BalanceAfter:     sim.BalanceAfter,
+		LimitOTPRequired: sim.LimitExceeded,
+	}
+	tf, _ := s.twoFactor.Get(in.SenderID)
+	resp.TOTPRequired = tf.Enabled && coinAmount(sim.Transaction) > tf.Threshold
+	json.NewEncoder(w).Encode(resp)
+}
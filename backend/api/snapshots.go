@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"blockchain-backend/services"
+)
+
+// handleListSnapshots lists the stored chain snapshots, newest first
+func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	snapshots, err := s.snapshots.List(r.Context())
+	if errors.Is(err, services.ErrNoDatabase) {
+		Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+		return
+	}
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to list snapshots")
+		return
+	}
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// handleCreateSnapshot snapshots the chain state at the current tip now
+func (s *Server) handleCreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	info, err := s.snapshots.Create(r.Context())
+	if errors.Is(err, services.ErrNoDatabase) {
+		Error(w, r, CodeDatabaseUnavailable, "Database not connected")
+		return
+	}
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to create snapshot")
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "chain_snapshot_created", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("block %d, %d UTXOs, %d bytes", info.Height, info.UTXOs, info.Size))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
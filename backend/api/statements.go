@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// handleListStatements lists the monthly statements emailed to a wallet,
+// newest first, for the owner's login session
+func (s *Server) handleListStatements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	if !s.requireWalletOwner(w, r, walletID) {
+		return
+	}
+	wobj, _ := s.ws.Get(walletID)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"wallet_id":          walletID,
+		"monthly_statements": wobj.MonthlyStatements,
+		"statements":         s.statements.History(walletID),
+	})
+}
+
+// handleGetStatement generates a wallet's statement for a month, with every
+// transaction in it, for the owner's login session. The current month runs
+// up to now.
+func (s *Server) handleGetStatement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	if !s.requireWalletOwner(w, r, vars["wallet"]) {
+		return
+	}
+
+	st, err := s.statements.Generate(vars["wallet"], vars["period"])
+	if err != nil {
+		s.writeStatementError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(st)
+}
+
+// handleRunStatements emails the statements for a finished month, the
+// previous one by default, to every opted-in wallet not yet sent one
+func (s *Server) handleRunStatements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = services.PreviousPeriod(time.Now())
+	}
+	run, err := s.statements.RunMonthly(period)
+	if err != nil {
+		s.writeStatementError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "statements_run", s.adminActor(r), r.RemoteAddr,
+		fmt.Sprintf("%s: %d sent, %d already sent, %d without email", run.Period, run.Sent, run.Skipped, run.NoEmail))
+	json.NewEncoder(w).Encode(run)
+}
+
+func (s *Server) writeStatementError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+	case errors.Is(err, services.ErrInvalidPeriod), errors.Is(err, services.ErrFuturePeriod), errors.Is(err, services.ErrPeriodNotEnded):
+		var errs validation.Errors
+		errs.Add("period", err.Error())
+		ValidationError(w, r, errs)
+	default:
+		Error(w, r, CodeInternal, "Failed to generate statement")
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// supplyHistoryDays is how many days of issuance GET /api/supply lists by default
+const supplyHistoryDays = 30
+
+// handleSupply reports issued and circulating supply, the cap and the daily
+// issuance history
+func (s *Server) handleSupply(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days := supplyHistoryDays
+	if d, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && d >= 0 {
+		days = d
+	}
+	json.NewEncoder(w).Encode(s.supply.Report(days))
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+// orgIDHeader selects the organization a request is scoped to in multi-tenant mode
+const orgIDHeader = "X-Org-ID"
+
+// tenantFreePrefixes are served without an organization: the platform admin
+// surface, docs, health, logins, public anchor lookups and the websocket hub,
+// whose private topics need proof of wallet ownership instead
+var tenantFreePrefixes = []string{
+	"/api/admin/",
+	"/api/health",
+	"/api/capabilities",
+	"/api/openapi.json",
+	"/api/docs",
+	"/api/errors",
+	"/api/schemas",
+	"/api/generate-keypair",
+	"/api/otp/",
+	"/api/auth/",
+	"/api/login",
+	"/api/anchor/",
+	"/api/ws",
+}
+
+func tenantFree(path string) bool {
+	for _, prefix := range tenantFreePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantJoinRoutes create a wallet, so they let a login session into an
+// organization where its email has no wallet yet
+var tenantJoinRoutes = map[string]bool{
+	"POST /api/create-wallet":   true,
+	"POST /api/wallet/import":   true,
+	"POST /api/account/wallets": true,
+}
+
+// tenantScope scopes each request to the caller's organization when
+// multi-tenant mode is on; see requestOrg. Wallets of other organizations
+// named in the path are reported as not found, so tenants cannot probe each
+// other.
+func (s *Server) tenantScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.orgs.Enabled() || r.Method == http.MethodOptions || tenantFree(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		orgID, err := s.requestOrg(s.isAdminRequest(r), bearerToken(r), r.Header.Get(orgIDHeader), tenantJoinRoutes[r.Method+" "+r.URL.Path])
+		if err != nil {
+			writeOpError(w, r, err)
+			return
+		}
+		vars := mux.Vars(r)
+		for _, name := range []string{"wallet", "user_id"} {
+			if id, ok := vars[name]; ok && !s.orgs.InOrg(id, orgID) {
+				Error(w, r, CodeWalletNotFound, "Wallet not found")
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(services.WithOrg(r.Context(), orgID)))
+	})
+}
+
+// requestOrg resolves the organization a request acts in. Platform admins
+// name any organization in X-Org-ID. Everyone else acts as the login session
+// behind the bearer token, in an organization where its email owns a wallet:
+// the only one, or the one X-Org-ID picks. join lets the session into any
+// organization, to create its first wallet there. Organizations the session
+// may not act in are reported as not found.
+func (s *Server) requestOrg(admin bool, sessionToken, orgID string, join bool) (string, error) {
+	if admin {
+		if orgID == "" {
+			return "", fail(CodeOrgRequired, "X-Org-ID header is required in multi-tenant mode")
+		}
+		if !s.orgs.Exists(orgID) {
+			return "", fail(CodeOrgNotFound, "Organization not found")
+		}
+		return orgID, nil
+	}
+
+	sess, ok := s.sessions.Validate(sessionToken)
+	if !ok {
+		return "", fail(CodeUnauthorized, "Multi-tenant mode needs a login session, sent as a bearer token")
+	}
+	member := s.orgs.OrgsOf(sess.Email)
+	switch {
+	case orgID == "" && len(member) == 1:
+		return member[0], nil
+	case orgID == "":
+		return "", fail(CodeOrgRequired, "X-Org-ID must name the organization to act in")
+	case !s.orgs.Exists(orgID):
+		return "", fail(CodeOrgNotFound, "Organization not found")
+	case join || slices.Contains(member, orgID):
+		return orgID, nil
+	}
+	return "", fail(CodeOrgNotFound, "Organization not found")
+}
+
+// optionalOrg returns the organization a tenant-free request names in
+// X-Org-ID, so it can be branded. ok is false when the header names an
+// unknown organization.
+func (s *Server) optionalOrg(r *http.Request) (orgID string, ok bool) {
+	orgID = r.Header.Get(orgIDHeader)
+	if !s.orgs.Enabled() || orgID == "" {
+		return "", true
+	}
+	if !s.orgs.Exists(orgID) {
+		return "", false
+	}
+	return orgID, true
+}
+
+// inOrg reports whether a wallet is visible to the organization ctx is scoped
+// to; outside multi-tenant mode every wallet is
+func (s *Server) inOrg(ctx context.Context, walletID string) bool {
+	if !s.orgs.Enabled() {
+		return true
+	}
+	return s.orgs.InOrg(walletID, services.OrgFrom(ctx))
+}
+
+// scopeTxs keeps the transactions sent or received by the organization's wallets
+func (s *Server) scopeTxs(ctx context.Context, txs []blockchain.Transaction) []blockchain.Transaction {
+	if !s.orgs.Enabled() {
+		return txs
+	}
+	scoped := []blockchain.Transaction{}
+	for _, tx := range txs {
+		if s.inOrg(ctx, tx.SenderID) || s.inOrg(ctx, tx.ReceiverID) {
+			scoped = append(scoped, tx)
+		}
+	}
+	return scoped
+}
+
+// scopeBlock returns the block with only the organization's transactions. The
+// header is unchanged, so the merkle root covers transactions not shown.
+func (s *Server) scopeBlock(ctx context.Context, b blockchain.Block) blockchain.Block {
+	b.Transactions = s.scopeTxs(ctx, b.Transactions)
+	return b
+}
+
+// scopeSystemLogs returns the newest limit system log entries about the
+// organization's wallets
+func (s *Server) scopeSystemLogs(ctx context.Context, limit int) []services.LogEntry {
+	scoped := []services.LogEntry{}
+	for _, l := range s.logSvc.GetSystemLogs(0) {
+		if s.inOrg(ctx, l.WalletID) {
+			scoped = append(scoped, l)
+		}
+	}
+	if len(scoped) > limit {
+		scoped = scoped[len(scoped)-limit:]
+	}
+	return scoped
+}
+
+// scopeTransactionLogs returns the newest limit transaction log entries about
+// the organization's wallets
+func (s *Server) scopeTransactionLogs(ctx context.Context, limit int) []services.TransactionLog {
+	scoped := []services.TransactionLog{}
+	for _, l := range s.logSvc.GetTransactionLogs("", 0) {
+		if s.inOrg(ctx, l.WalletID) {
+			scoped = append(scoped, l)
+		}
+	}
+	if len(scoped) > limit {
+		scoped = scoped[len(scoped)-limit:]
+	}
+	return scoped
+}
+
+// orgError maps organization service errors to API error codes
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTenancyDisabled):
+		return fail(CodeNotConfigured, err.Error())
+	case errors.Is(err, services.ErrInvalidOrgID), errors.Is(err, services.ErrNotOrgMember):
+		return fail(CodeValidationFailed, err.Error())
+	case errors.Is(err, services.ErrOrgExists):
+		return fail(CodeOrgExists, err.Error())
+	case errors.Is(err, services.ErrOrgNotFound):
+		return fail(CodeOrgNotFound, err.Error())
+	case errors.Is(err, services.ErrWalletNotFound):
+		return fail(CodeWalletNotFound, "Wallet not found")
+	}
+	return fail(CodeInternal, err.Error())
+}
+
+// requireOrgAdmin wraps handlers that manage the request's organization. The
+// caller must be one of its admin wallets, proven as for admins (see
+// callerWallet); platform admins pass too.
+func (s *Server) requireOrgAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if !s.orgs.Enabled() {
+			writeOpError(w, r, orgError(services.ErrTenancyDisabled))
+			return
+		}
+		walletID := s.callerWallet(r)
+		if !s.orgs.IsAdmin(services.OrgFrom(r.Context()), walletID) && !s.isAdminRequest(r) {
+			s.logSvc.LogSystemCtx(r.Context(), "org_admin_access_denied", walletID, r.RemoteAddr, r.Method+" "+r.URL.Path)
+			Error(w, r, CodeOrgAdminRequired, "Organization admin access required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleGetOrg describes the organization the request is scoped to
+func (s *Server) handleGetOrg(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !s.orgs.Enabled() {
+		writeOpError(w, r, orgError(services.ErrTenancyDisabled))
+		return
+	}
+	org, _ := s.orgs.Get(services.OrgFrom(r.Context()))
+	json.NewEncoder(w).Encode(org)
+}
+
+// handleListOrgWallets lists the organization's wallets with balances
+func (s *Server) handleListOrgWallets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := services.OrgFrom(r.Context())
+
+	list := []OrgWallet{}
+	for _, wlt := range s.orgs.Wallets(orgID) {
+		list = append(list, OrgWallet{
+			WalletID: wlt.WalletID,
+			FullName: wlt.FullName,
+			Email:    wlt.Email,
+			Type:     wlt.TypeOrDefault(),
+			Balance:  s.bc.GetBalance(wlt.WalletID),
+			Admin:    s.orgs.IsAdmin(orgID, wlt.WalletID),
+		})
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleAddOrgAdmin promotes a member wallet to organization admin
+func (s *Server) handleAddOrgAdmin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := services.OrgFrom(r.Context())
+
+	var req OrgWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if err := s.orgs.AddAdmin(orgID, req.WalletID); err != nil {
+		writeOpError(w, r, orgError(err))
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "org_admin_added", req.WalletID, r.RemoteAddr, "Admin of "+orgID+", by "+s.adminActor(r))
+	org, _ := s.orgs.Get(orgID)
+	json.NewEncoder(w).Encode(org)
+}
+
+// handleRemoveOrgAdmin revokes a wallet's organization admin rights
+func (s *Server) handleRemoveOrgAdmin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := services.OrgFrom(r.Context())
+	walletID := mux.Vars(r)["wallet"]
+
+	if !s.orgs.IsAdmin(orgID, walletID) {
+		Error(w, r, CodeNotFound, "Wallet is not an admin of this organization")
+		return
+	}
+	s.orgs.RemoveAdmin(orgID, walletID)
+
+	s.logSvc.LogSystemCtx(r.Context(), "org_admin_removed", walletID, r.RemoteAddr, "Admin of "+orgID+", by "+s.adminActor(r))
+	org, _ := s.orgs.Get(orgID)
+	json.NewEncoder(w).Encode(org)
+}
+
+// handleListOrgs lists every organization (platform admins only)
+func (s *Server) handleListOrgs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.orgs.List())
+}
+
+// handleCreateOrg registers an organization, optionally with its first admin
+func (s *Server) handleCreateOrg(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateOrgRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if req.AdminWalletID != "" {
+		if _, ok := s.ws.Get(req.AdminWalletID); !ok {
+			Error(w, r, CodeWalletNotFound, "Admin wallet not found")
+			return
+		}
+	}
+
+	org, err := s.orgs.Create(req.ID, req.Name, s.adminActor(r))
+	if err != nil {
+		writeOpError(w, r, orgError(err))
+		return
+	}
+	if req.AdminWalletID != "" {
+		if err := s.orgs.Assign(req.AdminWalletID, org.ID); err != nil {
+			writeOpError(w, r, orgError(err))
+			return
+		}
+		if err := s.orgs.AddAdmin(org.ID, req.AdminWalletID); err != nil {
+			writeOpError(w, r, orgError(err))
+			return
+		}
+		org, _ = s.orgs.Get(org.ID)
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "org_created", req.AdminWalletID, r.RemoteAddr, "Organization "+org.ID+" created by "+s.adminActor(r))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(org)
+}
+
+// handleGetOrgByID describes any organization (platform admins only)
+func (s *Server) handleGetOrgByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	org, ok := s.orgs.Get(mux.Vars(r)["org"])
+	if !ok {
+		Error(w, r, CodeOrgNotFound, "Organization not found")
+		return
+	}
+	json.NewEncoder(w).Encode(org)
+}
+
+// handleAssignOrgWallet moves a wallet into an organization, e.g. wallets
+// created before multi-tenant mode was turned on
+func (s *Server) handleAssignOrgWallet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orgID := mux.Vars(r)["org"]
+
+	var req OrgWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return
+	}
+	if err := s.orgs.Assign(req.WalletID, orgID); err != nil {
+		writeOpError(w, r, orgError(err))
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "org_wallet_assigned", req.WalletID, r.RemoteAddr, "Moved to "+orgID+" by "+s.adminActor(r))
+	org, _ := s.orgs.Get(orgID)
+	json.NewEncoder(w).Encode(org)
+}
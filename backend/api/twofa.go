@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/skip2/go-qrcode"
+
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+const totpIssuer = "Blockchain Wallet"
+
+// twoFactorError maps two-factor service errors to API error codes
+func twoFactorError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorEnabled):
+		return fail(CodeTwoFactorEnabled, err.Error())
+	case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrNoEnrollment):
+		return fail(CodeTwoFactorNotEnabled, err.Error())
+	case errors.Is(err, services.ErrInvalidTOTP):
+		return fail(CodeInvalidTOTP, err.Error())
+	case errors.Is(err, services.ErrTOTPRequired):
+		return fail(CodeTOTPRequired, err.Error())
+	}
+	return fail(CodeInternal, err.Error())
+}
+
+// verifyWalletKey checks that privateKey (raw hex or encrypted) belongs to
+// the wallet, proving the caller owns it
+func (s *Server) verifyWalletKey(ctx context.Context, walletID, privateKey string) (wallet.Wallet, error) {
+	wlt, ok := s.ws.Get(walletID)
+	if !ok || !s.inOrg(ctx, walletID) {
+		return wallet.Wallet{}, fail(CodeWalletNotFound, "Wallet not found")
+	}
+	privHex, err := resolvePrivateKey(privateKey)
+	if err != nil {
+		return wallet.Wallet{}, fail(CodeInvalidKey, "Invalid private key")
+	}
+	if !keyMatchesPub(privHex, wlt.PublicKey) {
+		return wallet.Wallet{}, fail(CodeInvalidKey, "Private key does not belong to this wallet")
+	}
+	return wlt, nil
+}
+
+// keyMatchesPub reports whether the hex ed25519 private key has the given public key
+func keyMatchesPub(privHex, pubHex string) bool {
+	priv, err := hex.DecodeString(privHex)
+	if err != nil || len(priv) != ed25519.PrivateKeySize {
+		return false
+	}
+	pub := ed25519.PrivateKey(priv).Public().(ed25519.PublicKey)
+	return hex.EncodeToString(pub) == pubHex
+}
+
+// handleTwoFactorEnroll creates a TOTP secret for the wallet and returns it
+// with an otpauth:// URI and a QR code for authenticator apps
+func (s *Server) handleTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req TwoFactorEnrollRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	wlt, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	secret, err := s.twoFactor.Enroll(wlt.WalletID)
+	if err != nil {
+		writeOpError(w, r, twoFactorError(err))
+		return
+	}
+
+	account := wlt.Email
+	if account == "" {
+		account = wlt.WalletID
+	}
+	uri := otp.TOTPURI(totpIssuer, account, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		Error(w, r, CodeInternal, "Failed to render QR code")
+		return
+	}
+
+	tf, _ := s.twoFactor.Get(wlt.WalletID)
+	s.logSvc.LogSystemCtx(r.Context(), "2fa_enroll_started", wlt.WalletID, r.RemoteAddr, "Authenticator enrollment started")
+	json.NewEncoder(w).Encode(TwoFactorEnrollResponse{
+		WalletID:   wlt.WalletID,
+		Secret:     secret,
+		OTPAuthURL: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		Threshold:  tf.Threshold,
+		Message:    "Scan the QR code, then confirm with POST /api/2fa/verify",
+	})
+}
+
+// handleTwoFactorVerify confirms enrollment with the first authenticator code
+func (s *Server) handleTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req TwoFactorVerifyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if !s.inOrg(r.Context(), req.WalletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	tf, err := s.twoFactor.Confirm(req.WalletID, req.Code)
+	if err != nil {
+		s.logSvc.LogSystemCtx(r.Context(), "2fa_verify_failed", req.WalletID, r.RemoteAddr, err.Error())
+		writeOpError(w, r, twoFactorError(err))
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "2fa_enabled", req.WalletID, r.RemoteAddr, "Authenticator 2FA enabled")
+	json.NewEncoder(w).Encode(tf)
+}
+
+// handleTwoFactorStatus reports whether 2FA is on and the send threshold
+func (s *Server) handleTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	if _, ok := s.ws.Get(walletID); !ok {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	tf, ok := s.twoFactor.Get(walletID)
+	if !ok {
+		tf = services.TwoFactor{WalletID: walletID}
+	}
+	json.NewEncoder(w).Encode(tf)
+}
+
+// handleTwoFactorThreshold changes the amount above which sends need a code
+func (s *Server) handleTwoFactorThreshold(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req TwoFactorThresholdRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), walletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	tf, err := s.twoFactor.SetThreshold(walletID, req.Threshold, req.Code)
+	if err != nil {
+		writeOpError(w, r, twoFactorError(err))
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "2fa_threshold_changed", walletID, r.RemoteAddr, "2FA threshold set")
+	json.NewEncoder(w).Encode(tf)
+}
+
+// handleTwoFactorDisable turns 2FA off after checking the key and a code
+func (s *Server) handleTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req TwoFactorDisableRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	if err := s.twoFactor.Disable(req.WalletID, req.Code); err != nil {
+		writeOpError(w, r, twoFactorError(err))
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "2fa_disabled", req.WalletID, r.RemoteAddr, "Authenticator 2FA disabled")
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Two-factor authentication disabled"})
+}
@@ -0,0 +1,1158 @@
+package api
+
+import (
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/crypto"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// Request and response bodies of the REST API. They are named so the
+// OpenAPI document served at /api/openapi.json can describe them.
+
+// CreateWalletRequest registers a wallet for a generated keypair
+type CreateWalletRequest struct {
+	Public  string `json:"public"`
+	Private string `json:"private"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	CNIC    string `json:"cnic"`
+	Type    string `json:"type"`
+}
+
+// SendRequest transfers coins to a wallet ID, a @handle or a beneficiary
+// alias
+type SendRequest struct {
+	SenderID      string `json:"sender_id"`
+	ReceiverID    string `json:"receiver_id"` // wallet ID, or a handle with its "@"
+	ReceiverAlias string `json:"receiver_alias"`
+	Amount        uint64 `json:"amount"`
+	AssetID       string `json:"asset_id,omitempty"`  // symbol of the asset to send; the coin when empty
+	LockTime      int64  `json:"lock_time,omitempty"` // Unix time before which the receiver cannot spend it, or with hash_lock, from which the sender can take it back
+	HashLock      string `json:"hash_lock,omitempty"` // SHA-256 of a secret the receiver must reveal to spend it
+	Note          string `json:"note"`
+	SigningToken  string `json:"signing_token,omitempty"` // from POST /api/signing-sessions
+	PrivateKey    string `json:"private_key,omitempty"`   // deprecated: use signing_token
+	TOTPCode      string `json:"totp_code,omitempty"`     // required above the wallet's 2FA threshold
+	LimitOTP      string `json:"limit_otp,omitempty"`     // emailed code that lets this send exceed the wallet's spending limits
+}
+
+// PrepareTransactionResponse is an unsigned transfer for an offline signer.
+// Sign the bytes of SigningPayloadHex with the sender's ed25519 key, put the
+// hex signature in the transaction and post it to
+// /api/transactions/submit-signed.
+type PrepareTransactionResponse struct {
+	Transaction       blockchain.Transaction `json:"transaction"`
+	SelectedUTXOs     []blockchain.UTXO      `json:"selected_utxos"`
+	SigningPayloadHex string                 `json:"signing_payload_hex"`
+	TOTPRequired      bool                   `json:"totp_required"` // the amount is above the sender's 2FA threshold
+	ExpiresAt         time.Time              `json:"expires_at"`    // submit before the timestamp is too old
+}
+
+// SubmitSignedRequest queues a transaction built and signed outside the server
+type SubmitSignedRequest struct {
+	Transaction blockchain.Transaction `json:"transaction"`
+	TOTPCode    string                 `json:"totp_code,omitempty"`
+}
+
+// SigningSessionRequest proves control of a wallet with an authenticator code
+// (wallets with 2FA) or an emailed code from POST /api/otp/send (all others)
+type SigningSessionRequest struct {
+	WalletID   string `json:"wallet_id"`
+	TOTPCode   string `json:"totp_code,omitempty"`
+	OTPCode    string `json:"otp_code,omitempty"`
+	SpendLimit uint64 `json:"spend_limit,omitempty"` // total the session may send; 0 for no cap
+	TTLSeconds int    `json:"ttl_seconds,omitempty"` // shorter than the server's lifetime; 0 for the default
+}
+
+// SigningSessionResponse carries the signing token, which is shown once
+type SigningSessionResponse struct {
+	SigningToken string `json:"signing_token"`
+	services.SigningSession
+}
+
+// MineRequest mines the pending pool; the reward goes to MinerWalletID.
+// Under proof of authority the validator's signing token (or the deprecated
+// private key) signs the block.
+type MineRequest struct {
+	MinerWalletID string `json:"miner_wallet_id"`
+	Start         int64  `json:"start,omitempty"`
+	SigningToken  string `json:"signing_token,omitempty"`
+	PrivateKey    string `json:"private_key,omitempty"`
+}
+
+// ConsensusResponse describes how the node produces blocks
+type ConsensusResponse struct {
+	Mode                 string   `json:"mode"`                 // pow or poa
+	Difficulty           string   `json:"difficulty,omitempty"` // hash prefix under proof of work
+	Validators           []string `json:"validators,omitempty"` // in turn order, under proof of authority
+	NextProducer         string   `json:"next_producer,omitempty"`
+	BlockIntervalSeconds int      `json:"block_interval_seconds,omitempty"` // how often validators produce blocks
+}
+
+// MinerResponse is what a wallet has mined; under proof of authority every
+// validator is listed, mined or not
+type MinerResponse struct {
+	services.MinerStats
+	Handle    string `json:"handle,omitempty"`
+	Validator bool   `json:"validator,omitempty"`
+}
+
+// LeaderboardEntry is a miner's place on the mining leaderboard
+type LeaderboardEntry struct {
+	Rank         int       `json:"rank"`
+	WalletID     string    `json:"wallet_id"`
+	Handle       string    `json:"handle,omitempty"`
+	BlocksMined  int64     `json:"blocks_mined"`
+	TotalRewards uint64    `json:"total_rewards"`
+	Share        float64   `json:"share"` // of the blocks mined, 0 to 1
+	LastMinedAt  time.Time `json:"last_mined_at"`
+}
+
+// LeaderboardResponse ranks the miners by blocks or rewards
+type LeaderboardResponse struct {
+	By          string             `json:"by"`
+	TotalBlocks int64              `json:"total_blocks"` // mined by the miners ranked, the genesis block aside
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// ChainImportResponse describes the chain a node imported
+type ChainImportResponse struct {
+	Height    int64  `json:"height"`
+	TipHash   string `json:"tip_hash"`
+	Blocks    int    `json:"blocks"` // genesis included
+	UTXOs     int    `json:"utxos"`
+	Consensus string `json:"consensus"`
+	Stored    bool   `json:"stored"` // false without storage, when the chain lives in memory only
+}
+
+// AnchorRequest records a SHA-256 document hash on-chain
+type AnchorRequest struct {
+	WalletID     string `json:"wallet_id"`
+	Hash         string `json:"hash"`
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+}
+
+// ConsolidateRequest combines a wallet's smallest outputs into one. MaxInputs
+// defaults to the most a transaction may spend; Below, when set, limits the
+// sweep to outputs worth less than it.
+type ConsolidateRequest struct {
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+	MaxInputs    int    `json:"max_inputs,omitempty"`
+	Below        uint64 `json:"below,omitempty"`
+}
+
+// ConsolidateResponse is the queued consolidation
+type ConsolidateResponse struct {
+	TxID   string `json:"txid"`
+	Inputs int    `json:"inputs"`
+	Amount uint64 `json:"amount"` // the single output, after the fee
+	Fee    uint64 `json:"fee"`
+}
+
+// VestingRequest grants total to the beneficiary in monthly time-locked
+// tranches, the first unlocking one month after start
+type VestingRequest struct {
+	SenderID      string     `json:"sender_id"`
+	BeneficiaryID string     `json:"beneficiary_id"`
+	Total         uint64     `json:"total"`
+	Tranches      int        `json:"tranches"`
+	Start         *time.Time `json:"start,omitempty"` // RFC 3339; now when omitted
+	Note          string     `json:"note"`
+	SigningToken  string     `json:"signing_token,omitempty"`
+	PrivateKey    string     `json:"private_key,omitempty"` // deprecated: use signing_token
+	TOTPCode      string     `json:"totp_code,omitempty"`
+	LimitOTP      string     `json:"limit_otp,omitempty"`
+}
+
+// BatchSendRequest pays many wallets from one sender in a single transaction
+type BatchSendRequest struct {
+	SenderID     string             `json:"sender_id"`
+	Payments     []services.Payment `json:"payments"`
+	Note         string             `json:"note"`
+	SigningToken string             `json:"signing_token,omitempty"`
+	PrivateKey   string             `json:"private_key,omitempty"` // deprecated: use signing_token
+	TOTPCode     string             `json:"totp_code,omitempty"`
+	LimitOTP     string             `json:"limit_otp,omitempty"`
+}
+
+// BatchSendResponse is a queued batch and what it pays each recipient
+type BatchSendResponse struct {
+	Status   string               `json:"status"`
+	TxID     string               `json:"txid"`
+	Total    uint64               `json:"total"` // sum of the payments, fee excluded
+	Fee      uint64               `json:"fee"`
+	Payments []BatchPaymentResult `json:"payments"`
+}
+
+// BatchPaymentResult is one recipient's output of a batch
+type BatchPaymentResult struct {
+	ReceiverID string `json:"receiver_id"`
+	Amount     uint64 `json:"amount"`
+	UTXOID     string `json:"utxo_id"`
+}
+
+// VestingResponse is a wallet's vesting grants, given and received, newest
+// first. Locked and Released total the grants it received.
+type VestingResponse struct {
+	WalletID string                  `json:"wallet_id"`
+	Locked   uint64                  `json:"locked"`
+	Released uint64                  `json:"released"`
+	Grants   []services.VestingGrant `json:"grants"`
+}
+
+// UnlockRequest spends a hash-locked output to the wallet: its owner's with
+// the preimage, or its refund wallet's once the lock time has passed
+type UnlockRequest struct {
+	WalletID     string `json:"wallet_id"`
+	Preimage     string `json:"preimage,omitempty"` // hex secret; claims only
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+}
+
+// UnlockResponse is the queued claim or refund
+type UnlockResponse struct {
+	TxID   string `json:"txid"`
+	Amount uint64 `json:"amount"` // paid to the wallet, after the fee
+	Fee    uint64 `json:"fee"`
+}
+
+// RotateKeyRequest proves ownership of the wallet whose key is rotated
+type RotateKeyRequest struct {
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+}
+
+// RotateKeyResponse is the wallet replacing the retired one. The new private
+// key is shown only here.
+type RotateKeyResponse struct {
+	OldWalletID string       `json:"old_wallet_id"`
+	WalletID    string       `json:"wallet_id"`
+	PublicKey   string       `json:"public_key"`
+	PrivateKey  string       `json:"private_key"`
+	SweepTxID   string       `json:"sweep_txid,omitempty"` // empty when the wallet held no coins
+	Swept       uint64       `json:"swept"`                // arrives in the new wallet once mined, after the fee
+	Fee         uint64       `json:"fee"`
+	AssetSweeps []AssetSweep `json:"asset_sweeps,omitempty"` // one per asset the wallet held
+	Warning     string       `json:"warning"`
+}
+
+// AssetSweep is the fee-free transfer moving one asset to the new wallet
+type AssetSweep struct {
+	TxID    string `json:"txid"`
+	AssetID string `json:"asset_id"`
+	Amount  uint64 `json:"amount"`
+}
+
+// SweepRetiredRequest proves ownership of the retired wallet being swept
+type SweepRetiredRequest struct {
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+}
+
+// SweepRetiredResponse lists the transactions carrying a retired wallet's
+// unlocked outputs to its successor
+type SweepRetiredResponse struct {
+	WalletID    string       `json:"wallet_id"` // the newest key of the lineage, which receives them
+	SweepTxID   string       `json:"sweep_txid,omitempty"`
+	Swept       uint64       `json:"swept"`
+	Fee         uint64       `json:"fee"`
+	AssetSweeps []AssetSweep `json:"asset_sweeps,omitempty"`
+}
+
+// DeactivateRequest proves ownership of the wallet being closed
+type DeactivateRequest struct {
+	SigningToken string `json:"signing_token,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"` // deprecated: use signing_token
+	Reason       string `json:"reason,omitempty"`
+}
+
+// WalletRotationsResponse is a wallet's key lineage, oldest first; history
+// of the earlier wallets stays under their own IDs
+type WalletRotationsResponse struct {
+	WalletID string           `json:"wallet_id"`
+	Current  string           `json:"current"` // the active end of the lineage
+	Lineage  []WalletRotation `json:"lineage"`
+}
+
+// WalletRotation is one wallet in a key lineage
+type WalletRotation struct {
+	WalletID  string `json:"wallet_id"`
+	PublicKey string `json:"public_key"`
+	Status    string `json:"status"`
+}
+
+// InheritanceRuleRequest sets or removes the wallet's inactivity rule
+type InheritanceRuleRequest struct {
+	SigningToken     string `json:"signing_token,omitempty"`
+	PrivateKey       string `json:"private_key,omitempty"` // deprecated: use signing_token
+	InactivityMonths int    `json:"inactivity_months"`     // 0 removes the rule
+}
+
+// InheritanceResponse is a wallet's inactivity rule, its owner's nominees
+// and the payouts filed for it
+type InheritanceResponse struct {
+	WalletID     string                       `json:"wallet_id"`
+	Rule         *services.InheritanceRule    `json:"rule,omitempty"`
+	LastActivity *time.Time                   `json:"last_activity,omitempty"`
+	EligibleAt   *time.Time                   `json:"eligible_at,omitempty"` // when a payout is filed if the wallet stays inactive
+	Nominees     []services.Nominee           `json:"nominees"`
+	Payouts      []services.InheritancePayout `json:"payouts"`
+}
+
+// InheritanceDecisionRequest carries an optional reason for a decision
+type InheritanceDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateCampaignRequest starts a fundraising campaign for a wallet
+type CreateCampaignRequest struct {
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	TargetAmount      uint64    `json:"target_amount"`
+	Deadline          time.Time `json:"deadline"` // RFC 3339, in the future
+	DestinationWallet string    `json:"destination_wallet"`
+}
+
+// UpdateCampaignRequest changes an active campaign; omitted fields are kept
+// and the destination wallet cannot change
+type UpdateCampaignRequest struct {
+	Name         *string    `json:"name,omitempty"`
+	Description  *string    `json:"description,omitempty"`
+	TargetAmount *uint64    `json:"target_amount,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// DonateRequest donates coins to an open campaign
+type DonateRequest struct {
+	SenderID     string `json:"sender_id"`
+	Amount       uint64 `json:"amount"`
+	Message      string `json:"message"`                 // shown with the donation
+	SigningToken string `json:"signing_token,omitempty"` // from POST /api/signing-sessions
+	PrivateKey   string `json:"private_key,omitempty"`   // deprecated: use signing_token
+	TOTPCode     string `json:"totp_code,omitempty"`     // required above the wallet's 2FA threshold
+	LimitOTP     string `json:"limit_otp,omitempty"`     // emailed code that lets this donation exceed the wallet's spending limits
+}
+
+// CreatePaymentRequestRequest asks the payer wallet for an amount. The
+// private key proves the requester owns its wallet.
+type CreatePaymentRequestRequest struct {
+	RequesterID string     `json:"requester_id"`
+	PayerID     string     `json:"payer_id"`
+	Amount      uint64     `json:"amount"`
+	Note        string     `json:"note"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // RFC 3339; a week from now when omitted
+	PrivateKey  string     `json:"private_key"`
+}
+
+// AcceptPaymentRequestRequest pays a request from the payer wallet, with the
+// same credentials as any send
+type AcceptPaymentRequestRequest struct {
+	SigningToken string `json:"signing_token,omitempty"` // from POST /api/signing-sessions
+	PrivateKey   string `json:"private_key,omitempty"`   // deprecated: use signing_token
+	TOTPCode     string `json:"totp_code,omitempty"`     // required above the wallet's 2FA threshold
+	LimitOTP     string `json:"limit_otp,omitempty"`     // emailed code that lets this payment exceed the wallet's spending limits
+}
+
+// DeclinePaymentRequestRequest proves the payer owns its wallet
+type DeclinePaymentRequestRequest struct {
+	PrivateKey string `json:"private_key"`
+}
+
+// CreateBillRequest splits a bill among wallets and requests each share.
+// Leave every amount out for an equal split, or give them all.
+type CreateBillRequest struct {
+	CreatorID      string            `json:"creator_id"`
+	Total          uint64            `json:"total"`
+	Note           string            `json:"note"`
+	Participants   []BillParticipant `json:"participants"`
+	IncludeCreator bool              `json:"include_creator,omitempty"` // the creator takes a share too, which is not requested
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`      // of the payment requests; a week from now when omitted
+	PrivateKey     string            `json:"private_key"`
+}
+
+// BillParticipant is a wallet, by ID or by the creator's beneficiary alias,
+// and for a custom split its share
+type BillParticipant struct {
+	WalletID string `json:"wallet_id,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	Amount   uint64 `json:"amount,omitempty"`
+}
+
+// DefineAssetRequest defines an asset and issues its initial supply to the
+// issuer wallet
+type DefineAssetRequest struct {
+	Symbol        string `json:"symbol"` // 2 to 10 letters and digits
+	Name          string `json:"name"`
+	Issuer        string `json:"issuer"`
+	InitialSupply uint64 `json:"initial_supply"`
+	MaxSupply     uint64 `json:"max_supply,omitempty"` // 0 means no cap
+	Mintable      bool   `json:"mintable"`
+}
+
+// MintAssetRequest issues more units of a mintable asset to its issuer
+type MintAssetRequest struct {
+	Amount uint64 `json:"amount"`
+}
+
+// SupplyAdjustmentRequest mints coins to a wallet or burns them from it.
+// otp_code proves the acting admin controls their email; request it with
+// POST /api/otp/send.
+type SupplyAdjustmentRequest struct {
+	WalletID string `json:"wallet_id"`
+	Amount   uint64 `json:"amount"`
+	Reason   string `json:"reason"` // recorded on-chain in the transaction note
+	OTPCode  string `json:"otp_code"`
+}
+
+// SupplyAdjustmentResponse is a queued mint or burn and the transaction that
+// carries it
+type SupplyAdjustmentResponse struct {
+	Adjustment  services.SupplyAdjustment `json:"adjustment"`
+	Transaction *blockchain.Transaction   `json:"transaction"`
+}
+
+// ResetChainResponse is the new genesis block after a reset
+type ResetChainResponse struct {
+	Genesis  blockchain.Block `json:"genesis"`
+	Database bool             `json:"database"` // the node has a store, whose chain tables were emptied too
+	Sandbox  string           `json:"sandbox,omitempty"`
+}
+
+// FixturesRequest asks for made-up load-test data: wallets, then random
+// transfers between them spread over blocks. The first block funds the
+// wallets, so transactions need at least two blocks.
+type FixturesRequest struct {
+	Wallets      int `json:"wallets"`
+	Transactions int `json:"transactions"`
+	Blocks       int `json:"blocks"`
+}
+
+// FixturesResponse reports the data a fixtures run generated
+type FixturesResponse struct {
+	WalletIDs    []string `json:"wallet_ids"`
+	Transactions int      `json:"transactions"` // transfers queued
+	Skipped      int      `json:"skipped"`      // transfers that could not be made, e.g. for want of confirmed coins
+	Blocks       int      `json:"blocks"`
+	Height       int64    `json:"height"`
+	DurationMs   int64    `json:"duration_ms"`
+}
+
+// AssetIssueResponse is an asset after an issuance and the transaction that
+// carries it, if any
+type AssetIssueResponse struct {
+	Asset       services.Asset          `json:"asset"`
+	Transaction *blockchain.Transaction `json:"transaction,omitempty"`
+}
+
+// CampaignResponse is a campaign's progress and its donations, newest first
+type CampaignResponse struct {
+	services.CampaignProgress
+	DonationList []services.Donation `json:"donation_list"`
+}
+
+// CharityRequest registers a charity for the zakat split or changes it
+type CharityRequest struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"` // relative share of each distribution
+}
+
+// RedeliverRequest selects failed deliveries to send again
+type RedeliverRequest struct {
+	IDs       []int64 `json:"ids"`
+	AllFailed bool    `json:"all_failed"`
+}
+
+// TypeChangeBody asks for a wallet type change
+type TypeChangeBody struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// KYCSubmitRequest files identity evidence for review. DocumentRef points
+// at the uploaded document, e.g. a storage key, URL or content hash.
+type KYCSubmitRequest struct {
+	CNIC         string `json:"cnic"`
+	DocumentType string `json:"document_type"`
+	DocumentRef  string `json:"document_ref"`
+}
+
+// KYCReviewRequest carries an optional reviewer's note
+type KYCReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// KYCStatusResponse reports a wallet's verification and what it may still
+// send today
+type KYCStatusResponse struct {
+	WalletID    string                   `json:"wallet_id"`
+	Verified    bool                     `json:"verified"`
+	DailyLimit  uint64                   `json:"daily_limit"` // 0 when verified or unlimited
+	SentToday   uint64                   `json:"sent_today"`
+	Remaining   *uint64                  `json:"remaining,omitempty"`
+	Submissions []services.KYCSubmission `json:"submissions"`
+}
+
+// FreezeRequest freezes a wallet for a compliance reason
+type FreezeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// FreezeResponse reports a wallet's freeze state after an admin action
+type FreezeResponse struct {
+	WalletID string `json:"wallet_id"`
+	Frozen   bool   `json:"frozen"`
+	Reason   string `json:"reason,omitempty"`
+	By       string `json:"by"`
+}
+
+// SpendingLimits caps what a wallet sends, fees included; 0 means no limit
+type SpendingLimits struct {
+	MaxTxAmount    uint64 `json:"max_tx_amount"`    // per transaction
+	MaxDailyAmount uint64 `json:"max_daily_amount"` // per rolling 24 hours
+}
+
+// SetLimitsRequest changes a wallet's own spending limits. Raising or removing
+// a limit also needs otp_code, emailed by POST /api/otp/send.
+type SetLimitsRequest struct {
+	SpendingLimits
+	PrivateKey string `json:"private_key"`
+	OTPCode    string `json:"otp_code,omitempty"`
+}
+
+// NotificationPreferencesRequest changes which events a wallet's owner is
+// emailed about. Omitted fields keep their current value.
+type NotificationPreferencesRequest struct {
+	WalletID           string  `json:"wallet_id"`
+	PrivateKey         string  `json:"private_key"`
+	IncomingPayments   *bool   `json:"incoming_payments,omitempty"`
+	MinIncomingAmount  *uint64 `json:"min_incoming_amount,omitempty"`
+	ZakatDeductions    *bool   `json:"zakat_deductions,omitempty"`
+	ProfileChanges     *bool   `json:"profile_changes,omitempty"`
+	BeneficiaryChanges *bool   `json:"beneficiary_changes,omitempty"`
+}
+
+// ClaimHandleRequest gives a wallet a handle, replacing its current one
+type ClaimHandleRequest struct {
+	WalletID   string `json:"wallet_id"`
+	Handle     string `json:"handle"` // with or without the leading "@"
+	PrivateKey string `json:"private_key"`
+}
+
+// ReleaseHandleRequest gives up a wallet's handle
+type ReleaseHandleRequest struct {
+	WalletID   string `json:"wallet_id"`
+	PrivateKey string `json:"private_key"`
+}
+
+// HandleResponse is the wallet a handle points to
+type HandleResponse struct {
+	Handle   string `json:"handle"`
+	WalletID string `json:"wallet_id"`
+}
+
+// WalletHandleResponse is a wallet's handle, empty when it has none, and
+// every handle it held, newest first
+type WalletHandleResponse struct {
+	WalletID string                 `json:"wallet_id"`
+	Handle   string                 `json:"handle"`
+	History  []services.HandleClaim `json:"history"`
+}
+
+// DirectorySettingRequest lists a wallet in the email directory, or takes
+// it out
+type DirectorySettingRequest struct {
+	WalletID     string `json:"wallet_id"`
+	PrivateKey   string `json:"private_key"`
+	Discoverable bool   `json:"discoverable"`
+}
+
+// DirectoryStatusResponse says whether others can find a wallet by its
+// owner's email
+type DirectoryStatusResponse struct {
+	WalletID     string `json:"wallet_id"`
+	Discoverable bool   `json:"discoverable"`
+}
+
+// ResolveEmailRequest looks up the wallet listed under an email
+type ResolveEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// ResolveEmailResponse is the wallet to send to for the email
+type ResolveEmailResponse struct {
+	WalletID string `json:"wallet_id"`
+}
+
+// NotificationInboxResponse is a page of a wallet's in-app notifications.
+// Pass next_before as ?before= for the next page.
+type NotificationInboxResponse struct {
+	WalletID      string                  `json:"wallet_id"`
+	Unread        int                     `json:"unread"`
+	Notifications []services.Notification `json:"notifications"`
+	NextBefore    int64                   `json:"next_before,omitempty"`
+}
+
+// MarkNotificationsReadRequest names the notifications to mark read; none
+// marks them all
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids,omitempty"`
+}
+
+// MarkNotificationsReadResponse reports how many notifications were marked
+// read and how many are left unread
+type MarkNotificationsReadResponse struct {
+	Marked int `json:"marked"`
+	Unread int `json:"unread"`
+}
+
+// SpendingLimitsResponse reports a wallet's limits and its recent sends
+type SpendingLimitsResponse struct {
+	WalletID string `json:"wallet_id"`
+	SpendingLimits
+	Sent24h   uint64  `json:"sent_24h"`
+	Remaining *uint64 `json:"remaining_24h,omitempty"` // only with a daily limit
+}
+
+// WebhookCreateRequest registers a URL for a wallet's events
+type WebhookCreateRequest struct {
+	WalletID   string   `json:"wallet_id"`
+	PrivateKey string   `json:"private_key"`
+	URL        string   `json:"url"`
+	Events     []string `json:"events"` // transaction.confirmed, zakat.deducted, block.mined
+}
+
+// WebhookCreatedResponse is a new webhook with its signing secret, which is
+// never shown again
+type WebhookCreatedResponse struct {
+	services.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookUpdateRequest changes a webhook; omitted fields keep their value
+type WebhookUpdateRequest struct {
+	PrivateKey string   `json:"private_key"`
+	URL        string   `json:"url,omitempty"`
+	Events     []string `json:"events,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// WebhookDeleteRequest proves ownership of the webhook's wallet
+type WebhookDeleteRequest struct {
+	PrivateKey string `json:"private_key"`
+}
+
+// FaucetClaimRequest claims faucet coins for a wallet. Without otp_code the
+// request needs a login session for the wallet's email.
+type FaucetClaimRequest struct {
+	WalletID string `json:"wallet_id"`
+	OTPCode  string `json:"otp_code,omitempty"` // emailed by POST /api/otp/send
+}
+
+// FaucetClaimResponse is the recorded grant and the wallet's new balance
+type FaucetClaimResponse struct {
+	services.FaucetGrant
+	Balance uint64 `json:"balance"`
+}
+
+// FaucetStatusResponse describes the faucet; the wallet fields are set when
+// wallet_id is given
+type FaucetStatusResponse struct {
+	Mode                 string     `json:"mode"` // claim or signup
+	Amount               uint64     `json:"amount"`
+	EmailCooldownSeconds int64      `json:"email_cooldown_seconds"`
+	IPCooldownSeconds    int64      `json:"ip_cooldown_seconds"`
+	WalletID             string     `json:"wallet_id,omitempty"`
+	Eligible             *bool      `json:"eligible,omitempty"`
+	NextClaimAt          *time.Time `json:"next_claim_at,omitempty"`
+}
+
+// SendOTPRequest asks for a one-time code by email
+type SendOTPRequest struct {
+	Email string `json:"email"`
+}
+
+// VerifyOTPRequest checks a one-time code
+type VerifyOTPRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// LoginRequest logs in an email proven by a verified code or a login session
+type LoginRequest struct {
+	Email string `json:"email"`
+}
+
+// UpdateProfileRequest replaces a wallet's profile fields. MonthlyStatements
+// is left unchanged when omitted.
+type UpdateProfileRequest struct {
+	FullName          string `json:"full_name"`
+	Email             string `json:"email"`
+	CNIC              string `json:"cnic"`
+	MonthlyStatements *bool  `json:"monthly_statements,omitempty"`
+}
+
+// AddBeneficiaryRequest adds a wallet to a user's address book
+type AddBeneficiaryRequest struct {
+	UserID              string `json:"user_id"` // wallet_id from frontend
+	BeneficiaryName     string `json:"beneficiary_name"`
+	BeneficiaryWalletID string `json:"beneficiary_wallet_id"`
+	Relationship        string `json:"relationship"`
+	Alias               string `json:"alias"`
+	SharePercent        int    `json:"share_percent,omitempty"` // above 0 makes the beneficiary a nominee
+}
+
+// UpdateBeneficiaryRequest edits an address book entry
+type UpdateBeneficiaryRequest struct {
+	BeneficiaryName string `json:"beneficiary_name"`
+	Relationship    string `json:"relationship"`
+	Alias           string `json:"alias"`
+	SharePercent    *int   `json:"share_percent,omitempty"` // omitted keeps the current share
+}
+
+// GraphQLRequest is a GraphQL query with optional variables
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GoogleLoginRequest carries the ID token from Google Sign-In
+type GoogleLoginRequest struct {
+	IDToken string `json:"id_token"`
+}
+
+// LoginResponse carries a new session token; send it as
+// "Authorization: Bearer <token>"
+type LoginResponse struct {
+	SessionToken string    `json:"session_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Email        string    `json:"email"`
+	UserID       int64     `json:"user_id,omitempty"`
+	Method       string    `json:"method"`
+	Created      bool      `json:"created,omitempty"` // a new user was registered
+	Wallets      []string  `json:"wallets"`           // wallets registered with the email
+}
+
+// LoginWallet is the public side of a wallet registered with the email
+type LoginWallet struct {
+	WalletID  string     `json:"wallet_id"`
+	PublicKey string     `json:"public_key"`
+	FullName  string     `json:"full_name,omitempty"`
+	Type      string     `json:"type"`
+	OrgID     string     `json:"org_id,omitempty"`
+	Label     string     `json:"label,omitempty"`
+	Status    string     `json:"status"` // active, retired or deactivated
+	Balance   uint64     `json:"balance"`
+	CreatedAt *time.Time `json:"created_at,omitempty"` // only when the database is connected
+}
+
+// EmailLoginResponse is the new session and the email's wallets
+type EmailLoginResponse struct {
+	LoginResponse
+	WalletDetails []LoginWallet `json:"wallet_details"`
+}
+
+// AccountWalletRequest adds a wallet to the session's email. The fields are
+// those of CreateWalletRequest, with the email taken from the session.
+type AccountWalletRequest struct {
+	Public  string `json:"public"`
+	Private string `json:"private"`
+	Name    string `json:"name"`
+	CNIC    string `json:"cnic"`
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+}
+
+// WalletLabelRequest renames a wallet; an empty label removes it
+type WalletLabelRequest struct {
+	Label string `json:"label"`
+}
+
+// AccountWalletsResponse lists the session email's wallets and their total
+type AccountWalletsResponse struct {
+	Email          string        `json:"email"`
+	Wallets        []LoginWallet `json:"wallets"`
+	TotalBalance   uint64        `json:"total_balance"`
+	PendingBalance uint64        `json:"pending_balance"` // received in blocks not yet confirmed
+}
+
+// VerifyOTPResponse confirms the code and logs the email in
+type VerifyOTPResponse struct {
+	Status   string `json:"status"`
+	Verified bool   `json:"verified"`
+	Message  string `json:"message"`
+	LoginResponse
+}
+
+// UsageEntry is one endpoint/client call counter
+type UsageEntry struct {
+	services.Usage
+	Deprecated bool   `json:"deprecated"`
+	Sunset     string `json:"sunset,omitempty"`
+	Successor  string `json:"successor,omitempty"`
+}
+
+// DeprecatedRouteUsage summarises who still calls a deprecated route
+type DeprecatedRouteUsage struct {
+	Method    string     `json:"method"`
+	Route     string     `json:"route"`
+	Sunset    string     `json:"sunset"`
+	Successor string     `json:"successor,omitempty"`
+	Calls     int64      `json:"calls"`
+	Clients   []string   `json:"clients"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"` // nil when never called
+}
+
+// UsageReport is the API usage telemetry shown to admins
+type UsageReport struct {
+	Usage            []UsageEntry           `json:"usage"`
+	DeprecatedRoutes []DeprecatedRouteUsage `json:"deprecated_routes"`
+}
+
+// TwoFactorEnrollRequest starts authenticator enrollment; the private key
+// proves wallet ownership
+type TwoFactorEnrollRequest struct {
+	WalletID   string `json:"wallet_id"`
+	PrivateKey string `json:"private_key"`
+}
+
+// TwoFactorEnrollResponse carries the TOTP secret, shown only once
+type TwoFactorEnrollResponse struct {
+	WalletID   string `json:"wallet_id"`
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"` // PNG data URI of otpauth_url
+	Threshold  uint64 `json:"threshold"`
+	Message    string `json:"message"`
+}
+
+// TwoFactorVerifyRequest confirms enrollment with the first code
+type TwoFactorVerifyRequest struct {
+	WalletID string `json:"wallet_id"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorThresholdRequest sets the amount above which sends need a code
+type TwoFactorThresholdRequest struct {
+	PrivateKey string `json:"private_key"`
+	Code       string `json:"code"`
+	Threshold  uint64 `json:"threshold"`
+}
+
+// TwoFactorDisableRequest turns 2FA off
+type TwoFactorDisableRequest struct {
+	WalletID   string `json:"wallet_id"`
+	PrivateKey string `json:"private_key"`
+	Code       string `json:"code"`
+}
+
+// ExportWalletRequest asks for a backup; the private key proves ownership
+// and the passphrase encrypts the file
+type ExportWalletRequest struct {
+	PrivateKey string `json:"private_key"`
+	Passphrase string `json:"passphrase"`
+}
+
+// WalletBackup is an exported backup file. Everything but the wallet ID is
+// inside the passphrase-encrypted box.
+type WalletBackup struct {
+	Format     string           `json:"format"` // wallet-backup
+	Version    int              `json:"version"`
+	WalletID   string           `json:"wallet_id"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Encryption crypto.SealedBox `json:"encryption"`
+}
+
+// ImportWalletRequest restores a backup file
+type ImportWalletRequest struct {
+	Backup     WalletBackup `json:"backup"`
+	Passphrase string       `json:"passphrase"`
+}
+
+// ImportWalletResponse reports what was restored
+type ImportWalletResponse struct {
+	Status                string                      `json:"status"`
+	WalletID              string                      `json:"wallet_id"`
+	RestoredBeneficiaries int                         `json:"restored_beneficiaries"`
+	SkippedBeneficiaries  []string                    `json:"skipped_beneficiaries"` // already present, or no database
+	TypeChangeRequest     *services.TypeChangeRequest `json:"type_change_request,omitempty"`
+	Message               string                      `json:"message"`
+}
+
+// CreateOrgRequest registers an organization. AdminWalletID optionally moves
+// an existing wallet into it as its first admin.
+type CreateOrgRequest struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	AdminWalletID string `json:"admin_wallet_id,omitempty"`
+}
+
+// OrgWalletRequest names a wallet to move into an organization or promote
+type OrgWalletRequest struct {
+	WalletID string `json:"wallet_id"`
+}
+
+// OrgWallet is a member wallet as listed to organization admins
+type OrgWallet struct {
+	WalletID string `json:"wallet_id"`
+	FullName string `json:"full_name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Type     string `json:"type"`
+	Balance  uint64 `json:"balance"`
+	Admin    bool   `json:"admin"`
+}
+
+// OrgConfigResponse shows an organization's configuration overrides and the
+// configuration they resolve to
+type OrgConfigResponse struct {
+	OrgID     string                 `json:"org_id"`
+	Overrides map[string]interface{} `json:"overrides"`
+	Effective services.TenantConfig  `json:"effective"`
+}
+
+// ClientConfig is the configuration clients use to present amounts, fees
+// and branding
+type ClientConfig struct {
+	FaucetAmount    uint64               `json:"faucet_amount"`
+	Zakat           services.ZakatParams `json:"zakat"`
+	Fees            services.FeeSchedule `json:"fees"`
+	DisplayCurrency string               `json:"display_currency"`
+	Decimals        int                  `json:"decimals"` // amounts are in units of 10^-decimals coin
+	Branding        services.Branding    `json:"branding"`
+}
+
+// CapabilitiesResponse lists the features of this deployment and the
+// configuration in effect for the caller's organization
+type CapabilitiesResponse struct {
+	Tenancy  string          `json:"tenancy"` // single or multi
+	OrgID    string          `json:"org_id,omitempty"`
+	Sandbox  string          `json:"sandbox,omitempty"` // the sandbox the node runs in, if any
+	Features map[string]bool `json:"features"`
+	Config   ClientConfig    `json:"config"`
+}
+
+// AuditLogResponse is a page of audited calls, newest first. NextBefore is
+// passed as ?before= for the next page; it is omitted on the last page.
+type AuditLogResponse struct {
+	Entries    []services.AuditEntry `json:"entries"`
+	NextBefore int64                 `json:"next_before,omitempty"`
+}
+
+// HealthCheck is the outcome of one readiness check
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, degraded, down or skipped
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReadinessResponse reports whether the node can serve requests: down when
+// any check is down, degraded when any is degraded, and ok otherwise
+type ReadinessResponse struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []HealthCheck `json:"checks"`
+}
+
+// KeypairResponse carries a freshly generated keypair
+type KeypairResponse struct {
+	Public  string `json:"public"`
+	Private string `json:"private"`
+	Warning string `json:"warning"`
+}
+
+// WalletResponse is a wallet with its private key masked and its nonce: the
+// highest nonce the wallet has used. Its next transaction must sign a higher
+// one, usually nonce+1.
+type WalletResponse struct {
+	wallet.Wallet
+	Nonce uint64 `json:"nonce"`
+}
+
+// BalanceResponse reports spendable and not-yet-spendable funds
+type BalanceResponse struct {
+	WalletID       string `json:"wallet_id"`
+	Balance        uint64 `json:"balance"`
+	PendingBalance uint64 `json:"pending_balance"`          // received but not yet spendable
+	LockedBalance  uint64 `json:"locked_balance,omitempty"` // owned behind a time or hash lock
+	Decimals       int    `json:"decimals"`                 // amounts are in units of 10^-decimals coin
+	// At the current rates; absent until a rate is set
+	BalanceFiat        services.FiatAmounts `json:"balance_fiat,omitempty"`
+	PendingBalanceFiat services.FiatAmounts `json:"pending_balance_fiat,omitempty"`
+	// Spendable units of every other asset the wallet holds or held, by symbol
+	Assets map[string]uint64 `json:"assets,omitempty"`
+}
+
+// SendResponse acknowledges a transaction added to the pending pool
+type SendResponse struct {
+	Status  string `json:"status"`
+	TxID    string `json:"txid"`
+	Message string `json:"message"`
+}
+
+// SimulateSendResponse is what a send would do if it were made now. The
+// transaction is unsigned and nothing was reserved, so the send can still
+// fail if the wallet changes before it is made.
+type SimulateSendResponse struct {
+	Transaction      blockchain.Transaction `json:"transaction"`
+	SelectedUTXOs    []blockchain.UTXO      `json:"selected_utxos"` // the inputs it would spend
+	Change           *blockchain.UTXO       `json:"change"`         // null when the inputs match the amount and fee
+	Fee              uint64                 `json:"fee"`
+	Balance          uint64                 `json:"balance"`            // sender's spendable balance of the sent asset
+	BalanceAfter     uint64                 `json:"balance_after"`      // that balance once the send is made
+	TOTPRequired     bool                   `json:"totp_required"`      // the send needs totp_code
+	LimitOTPRequired bool                   `json:"limit_otp_required"` // over the wallet's spending limits, so it needs limit_otp
+}
+
+// MempoolResponse lists the pending pool with estimated confirmation times
+type MempoolResponse struct {
+	Pending              int                  `json:"pending"`
+	BlockIntervalSeconds float64              `json:"block_interval_seconds"` // 0 until enough blocks are mined to tell
+	Transactions         []MempoolTransaction `json:"transactions"`
+}
+
+// MempoolTransaction is a pending transaction with its queue position
+type MempoolTransaction struct {
+	blockchain.MempoolEntry
+	EstimatedConfirmation *time.Time `json:"estimated_confirmation,omitempty"`
+}
+
+// TransactionStatusResponse reports where a transaction is. Cancelled
+// transactions were accepted but left the pending pool without being mined,
+// such as when the node restarted.
+type TransactionStatusResponse struct {
+	TxID                  string     `json:"txid"`
+	Status                string     `json:"status"` // pending, confirmed or cancelled
+	BlockIndex            *int64     `json:"block_index,omitempty"`
+	BlockHash             string     `json:"block_hash,omitempty"`
+	Confirmations         int64      `json:"confirmations"`
+	Lane                  string     `json:"lane,omitempty"`
+	BlocksAway            int        `json:"blocks_away,omitempty"`
+	EstimatedConfirmation *time.Time `json:"estimated_confirmation,omitempty"`
+}
+
+// TransactionResponse is one transaction with where it stands in the chain
+type TransactionResponse struct {
+	Transaction    blockchain.Transaction `json:"transaction"`
+	Status         string                 `json:"status"` // pending or confirmed
+	BlockIndex     *int64                 `json:"block_index,omitempty"`
+	BlockHash      string                 `json:"block_hash,omitempty"`
+	BlockTimestamp int64                  `json:"block_timestamp,omitempty"`
+	Position       *int                   `json:"position,omitempty"` // index within the block; 0 is the coinbase
+	Confirmations  int64                  `json:"confirmations"`
+	AmountFiat     services.FiatAmounts   `json:"amount_fiat,omitempty"` // at the rates when it was sent
+	Decimals       int                    `json:"decimals"`              // amounts are in units of 10^-decimals coin
+}
+
+// TransactionProofResponse is a merkle proof of inclusion with the block's
+// current confirmations
+type TransactionProofResponse struct {
+	blockchain.MerkleProof
+	Confirmations int64 `json:"confirmations"`
+}
+
+// SearchResponse lists what a search query resolved to
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// BlockDetail is a block with figures computed from its transactions. For an
+// organization-scoped caller the totals cover only the transactions it sees.
+type BlockDetail struct {
+	blockchain.Block
+	Confirmations    int64      `json:"confirmations"`
+	TotalTransferred uint64     `json:"total_transferred"` // excluding the coinbase reward
+	TotalFees        uint64     `json:"total_fees"`
+	MinerWallet      string     `json:"miner_wallet,omitempty"`
+	Size             int        `json:"size"` // bytes of the block's JSON encoding
+	Previous         *BlockLink `json:"previous,omitempty"`
+	Next             *BlockLink `json:"next,omitempty"`
+}
+
+// BlockLink points at a neighbouring block
+type BlockLink struct {
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+	Href  string `json:"href"`
+}
+
+// SearchResult is one block, transaction or wallet a query matched. Summary
+// is a one-line description for a search bar; the field named by Type holds
+// the details.
+type SearchResult struct {
+	Type        string             `json:"type"` // block, transaction or wallet
+	ID          string             `json:"id"`
+	Summary     string             `json:"summary"`
+	Block       *SearchBlock       `json:"block,omitempty"`
+	Transaction *SearchTransaction `json:"transaction,omitempty"`
+	Wallet      *SearchWallet      `json:"wallet,omitempty"`
+}
+
+// SearchBlock summarises a block
+type SearchBlock struct {
+	Index         int64  `json:"index"`
+	Hash          string `json:"hash"`
+	PreviousHash  string `json:"previous_hash"`
+	Timestamp     int64  `json:"timestamp"`
+	Transactions  int    `json:"transactions"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// SearchTransaction summarises a mined or pending transaction
+type SearchTransaction struct {
+	Type          string `json:"type"`
+	SenderID      string `json:"sender_id"`
+	ReceiverID    string `json:"receiver_id"`
+	Amount        uint64 `json:"amount"`
+	Fee           uint64 `json:"fee,omitempty"`
+	Timestamp     int64  `json:"timestamp"`
+	Status        string `json:"status"`                // confirmed or pending
+	BlockIndex    *int64 `json:"block_index,omitempty"` // nil while pending
+	Confirmations int64  `json:"confirmations"`
+}
+
+// SearchWallet summarises a wallet. Email is only shown to admins.
+type SearchWallet struct {
+	WalletID string `json:"wallet_id"`
+	Handle   string `json:"handle,omitempty"`
+	Type     string `json:"type"`
+	FullName string `json:"full_name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Balance  uint64 `json:"balance"`
+}
+
+// AnnouncementRequest is broadcast to every subscriber of the announcements topic
+type AnnouncementRequest struct {
+	Message string `json:"message"`
+}
+
+// WSClientMessage is sent by websocket clients to /api/ws. Wallet topics need
+// session_token (a login session for the wallet's email) or signature (the
+// wallet key's hex signature of "subscribe:<topic>:<challenge>").
+type WSClientMessage struct {
+	Action       string `json:"action"` // subscribe or unsubscribe
+	Topic        string `json:"topic"`
+	SessionToken string `json:"session_token,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+}
+
+// WSControlMessage answers a client message; topic data arrives as
+// events.Message
+type WSControlMessage struct {
+	Type      string    `json:"type"` // welcome, subscribed, unsubscribed or error
+	Topic     string    `json:"topic,omitempty"`
+	Challenge string    `json:"challenge,omitempty"` // welcome only
+	Code      ErrorCode `json:"code,omitempty"`      // error only
+	Message   string    `json:"message,omitempty"`
+}
+
+// StatusResponse is the generic success acknowledgement
+type StatusResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
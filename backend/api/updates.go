@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/events"
+)
+
+const (
+	defaultUpdatesWait  = 25 * time.Second
+	maxUpdatesWait      = 55 * time.Second
+	defaultUpdatesLimit = 100
+	maxUpdatesLimit     = 500
+)
+
+// handleWalletUpdates returns all events affecting a wallet after since_seq.
+// When nothing is available yet the request is held open (long poll) until an
+// event arrives or the wait period elapses. The events are the ones private
+// websocket topics carry, so only the owner's login session may read them.
+func (s *Server) handleWalletUpdates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	wid := mux.Vars(r)["wallet"]
+
+	if !s.requireWalletOwner(w, r, wid) {
+		return
+	}
+
+	q := r.URL.Query()
+	var since uint64
+	if v := q.Get("since_seq"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			Error(w, r, CodeValidationFailed, "Invalid since_seq")
+			return
+		}
+		since = parsed
+	}
+
+	limit := defaultUpdatesLimit
+	if v := q.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > maxUpdatesLimit {
+		limit = maxUpdatesLimit
+	}
+
+	wait := defaultUpdatesWait
+	if v := q.Get("wait"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil || secs < 0 {
+			Error(w, r, CodeValidationFailed, "Invalid wait")
+			return
+		}
+		wait = time.Duration(secs) * time.Second
+	}
+	if wait > maxUpdatesWait {
+		wait = maxUpdatesWait
+	}
+
+	// The server-wide WriteTimeout is shorter than a long poll
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 5*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	cursor := since
+	for {
+		evs, next, truncated := s.feed.Since(wid, cursor, limit)
+		if len(evs) > 0 || truncated || ctx.Err() != nil {
+			if evs == nil {
+				evs = []events.Event{}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"wallet_id":  wid,
+				"since_seq":  since,
+				"events":     evs,
+				"next_seq":   next,
+				"latest_seq": s.feed.LastSeq(),
+				"truncated":  truncated,
+			})
+			return
+		}
+		// Nothing for this wallet up to next; skip ahead and wait for more
+		cursor = next
+		s.feed.Wait(ctx, cursor)
+	}
+}
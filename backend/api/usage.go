@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// deprecation marks a route that is superseded and will be removed
+type deprecation struct {
+	Sunset    string // removal date, YYYY-MM-DD
+	Successor string // route to migrate to, e.g. "GET /api/v2/wallet/{wallet}"
+}
+
+// trackUsage counts every matched API call per route template and client,
+// and announces deprecated routes with Deprecation, Sunset and Link headers
+func (s *Server) trackUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if r.Method == http.MethodOptions || route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path := pathVarPattern.ReplaceAllString(tpl, "{$1}")
+		s.usage.Record(r.Method, path, usageClient(r))
+
+		if dep := routeDocs[r.Method+" "+path].Deprecated; dep != nil {
+			w.Header().Set("Deprecation", "true")
+			if sunset, err := time.Parse("2006-01-02", dep.Sunset); err == nil {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if _, successor, ok := strings.Cut(dep.Successor, " "); ok {
+				w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// usageClient identifies the caller: a fingerprint of the API key when one is
+// sent (never the key itself), otherwise the user agent
+func usageClient(r *http.Request) string {
+	if key := r.Header.Get("X-Admin-Key"); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "api_key:" + hex.EncodeToString(sum[:4])
+	}
+	ua := r.UserAgent()
+	if ua == "" {
+		return "ua:unknown"
+	}
+	if len(ua) > 200 {
+		ua = ua[:200]
+	}
+	return "ua:" + ua
+}
+
+// handleUsage reports API usage per endpoint and client. ?deprecated=true
+// limits it to deprecated routes, the ones to check before removing them.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	onlyDeprecated := q.Get("deprecated") == "true"
+	route, client := q.Get("route"), q.Get("client")
+
+	counters := s.usage.List(func(u services.Usage) bool {
+		if route != "" && u.Route != route {
+			return false
+		}
+		if client != "" && !strings.Contains(u.Client, client) {
+			return false
+		}
+		return !onlyDeprecated || routeDocs[u.Method+" "+u.Route].Deprecated != nil
+	})
+
+	report := UsageReport{Usage: make([]UsageEntry, 0, len(counters)), DeprecatedRoutes: []DeprecatedRouteUsage{}}
+	byRoute := map[string]*DeprecatedRouteUsage{}
+	for key, doc := range routeDocs {
+		if doc.Deprecated == nil {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		byRoute[key] = &DeprecatedRouteUsage{Method: method, Route: path, Sunset: doc.Deprecated.Sunset, Successor: doc.Deprecated.Successor}
+	}
+
+	for _, u := range counters {
+		entry := UsageEntry{Usage: u}
+		if dr, ok := byRoute[u.Method+" "+u.Route]; ok {
+			entry.Deprecated = true
+			entry.Sunset = dr.Sunset
+			entry.Successor = dr.Successor
+			dr.Calls += u.Count
+			dr.Clients = append(dr.Clients, u.Client)
+			if dr.LastSeen == nil || u.LastSeen.After(*dr.LastSeen) {
+				lastSeen := u.LastSeen
+				dr.LastSeen = &lastSeen
+			}
+		}
+		report.Usage = append(report.Usage, entry)
+	}
+
+	for _, dr := range byRoute {
+		if dr.Clients == nil {
+			dr.Clients = []string{}
+		}
+		report.DeprecatedRoutes = append(report.DeprecatedRoutes, *dr)
+	}
+	sort.Slice(report.DeprecatedRoutes, func(i, j int) bool {
+		return report.DeprecatedRoutes[i].Sunset < report.DeprecatedRoutes[j].Sunset
+	})
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,762 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// validatable is implemented by request bodies that check their fields. Validate
+// may normalize fields in place (trimming, canonical formats) before checking.
+type validatable interface {
+	Validate() validation.Errors
+}
+
+// decodeRequest decodes the JSON body into req and, when req is validatable,
+// validates it. On failure the error response is written and false returned.
+func decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		Error(w, r, CodeInvalidRequest, "Invalid request")
+		return false
+	}
+	if v, ok := req.(validatable); ok {
+		if errs := v.Validate(); len(errs) > 0 {
+			ValidationError(w, r, errs)
+			return false
+		}
+	}
+	return true
+}
+
+// checkWalletID records a missing or malformed wallet ID
+func checkWalletID(errs *validation.Errors, field, id string) {
+	if errs.Required(field, id) {
+		errs.Check(field, validation.WalletID(id))
+	}
+}
+
+// checkEmail trims and checks a required email address
+func checkEmail(errs *validation.Errors, field string, email *string) {
+	*email = validation.Clean(*email)
+	if errs.Required(field, *email) {
+		errs.Check(field, validation.Email(*email))
+	}
+}
+
+// checkCNIC checks an optional CNIC and rewrites it in the dashed form
+func checkCNIC(errs *validation.Errors, field string, cnic *string) {
+	*cnic = validation.Clean(*cnic)
+	if *cnic == "" {
+		return
+	}
+	normalized, err := validation.CNIC(*cnic)
+	if err != nil {
+		errs.Check(field, err)
+		return
+	}
+	*cnic = normalized
+}
+
+// checkName trims and checks an optional display name
+func checkName(errs *validation.Errors, field string, name *string) {
+	*name = validation.Clean(*name)
+	errs.Check(field, validation.Name(*name))
+}
+
+// checkLabel trims and checks an optional wallet label
+func checkLabel(errs *validation.Errors, field string, label *string) {
+	*label = validation.Clean(*label)
+	errs.Check(field, validation.Label(*label))
+}
+
+// checkText trims and checks optional free text
+func checkText(errs *validation.Errors, field string, text *string) {
+	*text = validation.Clean(*text)
+	if len(*text) > validation.MaxTextLength {
+		errs.Add(field, "is too long")
+		return
+	}
+	errs.Check(field, validation.Text(*text))
+}
+
+// checkShare checks a beneficiary's share of an inheritance payout
+func checkShare(errs *validation.Errors, field string, percent int) {
+	if percent < 0 || percent > 100 {
+		errs.Add(field, "must be from 0 to 100")
+	}
+}
+
+// checkPrivateKey checks a private key given as proof of ownership
+func checkPrivateKey(errs *validation.Errors, field, key string) {
+	if errs.Required(field, key) {
+		errs.Check(field, validation.PrivateKey(key))
+	}
+}
+
+// checkCode checks a required 6-digit code
+func checkCode(errs *validation.Errors, field string, code *string) {
+	*code = validation.Clean(*code)
+	if errs.Required(field, *code) {
+		errs.Check(field, validation.Code(*code))
+	}
+}
+
+func (in *createWalletInput) validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("public", in.Public) {
+		errs.Check("public", validation.PublicKey(in.Public))
+	}
+	if errs.Required("private", in.Private) {
+		if err := validation.PrivateKey(in.Private); err != nil {
+			errs.Check("private", err)
+		} else if privHex, err := resolvePrivateKey(in.Private); err != nil || !keyMatchesPub(privHex, in.Public) {
+			errs.Add("private", "does not belong to the public key")
+		}
+	}
+	checkName(&errs, "name", &in.Name)
+	checkEmail(&errs, "email", &in.Email)
+	checkCNIC(&errs, "cnic", &in.CNIC)
+	return errs
+}
+
+func (in *sendInput) validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "sender_id", in.SenderID)
+	switch {
+	case len(in.Payments) > 0:
+		checkPayments(&errs, in.SenderID, in.Payments)
+	case in.ReceiverID != "" && in.ReceiverAlias != "":
+		errs.Add("receiver_alias", "provide either receiver_id or receiver_alias, not both")
+	case in.ReceiverAlias != "":
+		in.ReceiverAlias = validation.Clean(in.ReceiverAlias)
+	case strings.HasPrefix(in.ReceiverID, "@"):
+		// A handle, resolved when the send is made
+		if _, err := services.NormalizeHandle(in.ReceiverID); errors.Is(err, services.ErrInvalidHandle) {
+			errs.Check("receiver_id", err)
+		}
+	default:
+		checkWalletID(&errs, "receiver_id", in.ReceiverID)
+		if in.ReceiverID != "" && in.ReceiverID == in.SenderID {
+			errs.Add("receiver_id", "must differ from sender_id")
+		}
+	}
+	errs.Check("amount", validation.Amount(in.Amount))
+	if in.AssetID != "" {
+		symbol, err := services.NormalizeAssetSymbol(in.AssetID)
+		errs.Check("asset_id", err)
+		in.AssetID = symbol
+	}
+	if in.LockTime < 0 {
+		errs.Add("lock_time", "must be a Unix time")
+	}
+	if in.HashLock != "" {
+		in.HashLock = strings.ToLower(strings.TrimSpace(in.HashLock))
+		errs.Check("hash_lock", in.lock().Check())
+	}
+	errs.Check("note", validation.Note(in.Note))
+	if in.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(in.PrivateKey))
+	}
+	if in.LimitOTP != "" {
+		checkCode(&errs, "limit_otp", &in.LimitOTP)
+	}
+	return errs
+}
+
+// checkPayments checks the recipients of a batch: known wallet IDs other than
+// the sender, each paid once
+func checkPayments(errs *validation.Errors, senderID string, payments []services.Payment) {
+	if len(payments) > services.MaxBatchPayments {
+		errs.Add("payments", fmt.Sprintf("must have at most %d entries", services.MaxBatchPayments))
+		return
+	}
+	seen := make(map[string]bool)
+	for i, p := range payments {
+		field := fmt.Sprintf("payments[%d]", i)
+		checkWalletID(errs, field+".receiver_id", p.ReceiverID)
+		switch {
+		case p.ReceiverID != "" && p.ReceiverID == senderID:
+			errs.Add(field+".receiver_id", "must differ from sender_id")
+		case seen[p.ReceiverID]:
+			errs.Add(field+".receiver_id", "appears more than once")
+		}
+		seen[p.ReceiverID] = true
+		errs.Check(field+".amount", validation.Amount(p.Amount))
+	}
+}
+
+// Validate checks the fields of a signed transaction that can be judged
+// without the chain; the signature covers them, so nothing is rewritten
+func (req *SubmitSignedRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	tx := req.Transaction
+	checkWalletID(&errs, "transaction.sender_id", tx.SenderID)
+	checkWalletID(&errs, "transaction.receiver_id", tx.ReceiverID)
+	if tx.SenderID != "" && tx.SenderID == tx.ReceiverID {
+		errs.Add("transaction.receiver_id", "must differ from sender_id")
+	}
+	errs.Check("transaction.amount", validation.Amount(tx.Amount))
+	errs.Check("transaction.note", validation.Note(tx.Note))
+	if errs.Required("transaction.pubkey", tx.PubKey) {
+		errs.Check("transaction.pubkey", validation.PublicKey(tx.PubKey))
+	}
+	errs.Required("transaction.signature", tx.Signature)
+	if len(tx.Inputs) > validation.MaxTxInputs {
+		errs.Add("transaction.inputs", "too many inputs")
+	}
+	return errs
+}
+
+func (req *SigningSessionRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	if req.SpendLimit > validation.MaxAmount {
+		errs.Add("spend_limit", "is too large")
+	}
+	if req.TTLSeconds < 0 {
+		errs.Add("ttl_seconds", "must not be negative")
+	}
+	return errs
+}
+
+func (req *MineRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "miner_wallet_id", req.MinerWalletID)
+	return errs
+}
+
+func (req *AnchorRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	errs.Required("hash", req.Hash)
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	return errs
+}
+
+func (req *ConsolidateRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	if req.MaxInputs < 0 || req.MaxInputs == 1 || req.MaxInputs > validation.MaxTxInputs {
+		errs.Add("max_inputs", fmt.Sprintf("must be from 2 to %d", validation.MaxTxInputs))
+	}
+	return errs
+}
+
+func (req *VestingRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "sender_id", req.SenderID)
+	checkWalletID(&errs, "beneficiary_id", req.BeneficiaryID)
+	if req.BeneficiaryID != "" && req.BeneficiaryID == req.SenderID {
+		errs.Add("beneficiary_id", "must differ from sender_id")
+	}
+	errs.Check("total", validation.Amount(req.Total))
+	if req.Tranches < 1 || req.Tranches > services.MaxVestingTranches {
+		errs.Add("tranches", fmt.Sprintf("must be from 1 to %d", services.MaxVestingTranches))
+	} else if req.Total > 0 && req.Total < uint64(req.Tranches) {
+		errs.Add("total", "must be at least one unit per tranche")
+	}
+	errs.Check("note", validation.Note(req.Note))
+	return errs
+}
+
+func (req *FixturesRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.Wallets < 2 || req.Wallets > maxFixtureWallets {
+		errs.Add("wallets", fmt.Sprintf("must be from 2 to %d", maxFixtureWallets))
+	}
+	if req.Blocks < 1 || req.Blocks > maxFixtureBlocks {
+		errs.Add("blocks", fmt.Sprintf("must be from 1 to %d", maxFixtureBlocks))
+	}
+	switch {
+	case req.Transactions < 0 || req.Transactions > maxFixtureTransactions:
+		errs.Add("transactions", fmt.Sprintf("must be from 0 to %d", maxFixtureTransactions))
+	case req.Transactions > req.Wallets*(req.Blocks-1) && len(errs) == 0:
+		// Each wallet sends at most once a block, after the funding block
+		errs.Add("transactions", fmt.Sprintf("at most %d for %d wallets over %d blocks", req.Wallets*(req.Blocks-1), req.Wallets, req.Blocks))
+	}
+	return errs
+}
+
+func (req *BatchSendRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "sender_id", req.SenderID)
+	if len(req.Payments) == 0 {
+		errs.Add("payments", "is required")
+	} else {
+		checkPayments(&errs, req.SenderID, req.Payments)
+	}
+	errs.Check("note", validation.Note(req.Note))
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	return errs
+}
+
+func (req *CreatePaymentRequestRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "requester_id", req.RequesterID)
+	checkWalletID(&errs, "payer_id", req.PayerID)
+	if req.PayerID != "" && req.PayerID == req.RequesterID {
+		errs.Add("payer_id", "must differ from requester_id")
+	}
+	errs.Check("amount", validation.Amount(req.Amount))
+	if len(req.Note) > services.MaxPaymentRequestNoteLength {
+		errs.Add("note", fmt.Sprintf("must be at most %d bytes", services.MaxPaymentRequestNoteLength))
+	} else {
+		errs.Check("note", validation.Note(req.Note))
+	}
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *CreateBillRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "creator_id", req.CreatorID)
+	errs.Check("total", validation.Amount(req.Total))
+	if len(req.Note) > services.MaxBillNoteLength {
+		errs.Add("note", fmt.Sprintf("must be at most %d bytes", services.MaxBillNoteLength))
+	} else {
+		errs.Check("note", validation.Note(req.Note))
+	}
+	if len(req.Participants) == 0 || len(req.Participants) > services.MaxBillParticipants {
+		errs.Add("participants", fmt.Sprintf("must have from 1 to %d entries", services.MaxBillParticipants))
+	}
+	for i := range req.Participants {
+		p := &req.Participants[i]
+		field := fmt.Sprintf("participants[%d]", i)
+		switch {
+		case p.WalletID != "" && p.Alias != "":
+			errs.Add(field+".alias", "provide either wallet_id or alias, not both")
+		case p.Alias != "":
+			p.Alias = validation.Clean(p.Alias)
+		default:
+			checkWalletID(&errs, field+".wallet_id", p.WalletID)
+		}
+	}
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *DeclinePaymentRequestRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *UnlockRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	req.Preimage = strings.ToLower(strings.TrimSpace(req.Preimage))
+	if _, err := hex.DecodeString(req.Preimage); err != nil {
+		errs.Add("preimage", "must be hex")
+	}
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	return errs
+}
+
+func (req *RotateKeyRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	return errs
+}
+
+func (req *DeactivateRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	checkText(&errs, "reason", &req.Reason)
+	return errs
+}
+
+func (req *AnnouncementRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	req.Message = validation.Clean(req.Message)
+	if errs.Required("message", req.Message) {
+		checkText(&errs, "message", &req.Message)
+	}
+	return errs
+}
+
+func (req *SendOTPRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkEmail(&errs, "email", &req.Email)
+	return errs
+}
+
+func (req *VerifyOTPRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkEmail(&errs, "email", &req.Email)
+	checkCode(&errs, "code", &req.Code)
+	return errs
+}
+
+func (req *ClaimHandleRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	if errs.Required("handle", req.Handle) {
+		handle, err := services.NormalizeHandle(req.Handle)
+		if errors.Is(err, services.ErrInvalidHandle) {
+			errs.Check("handle", err)
+		}
+		if err == nil {
+			req.Handle = handle
+		}
+	}
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *ReleaseHandleRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *DirectorySettingRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *ResolveEmailRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkEmail(&errs, "email", &req.Email)
+	return errs
+}
+
+func (req *LoginRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkEmail(&errs, "email", &req.Email)
+	return errs
+}
+
+func (req *AccountWalletRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkLabel(&errs, "label", &req.Label)
+	return errs
+}
+
+func (req *WalletLabelRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkLabel(&errs, "label", &req.Label)
+	return errs
+}
+
+func (req *UpdateProfileRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkName(&errs, "full_name", &req.FullName)
+	checkEmail(&errs, "email", &req.Email)
+	checkCNIC(&errs, "cnic", &req.CNIC)
+	if req.MonthlyStatements != nil && *req.MonthlyStatements && req.Email == "" {
+		errs.Add("monthly_statements", "requires an email address")
+	}
+	return errs
+}
+
+func (req *AddBeneficiaryRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "user_id", req.UserID)
+	checkWalletID(&errs, "beneficiary_wallet_id", req.BeneficiaryWalletID)
+	if req.UserID != "" && req.UserID == req.BeneficiaryWalletID {
+		errs.Add("beneficiary_wallet_id", "cannot be your own wallet")
+	}
+	checkName(&errs, "beneficiary_name", &req.BeneficiaryName)
+	checkText(&errs, "relationship", &req.Relationship)
+	checkShare(&errs, "share_percent", req.SharePercent)
+	return errs
+}
+
+func (req *UpdateBeneficiaryRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkName(&errs, "beneficiary_name", &req.BeneficiaryName)
+	checkText(&errs, "relationship", &req.Relationship)
+	if req.SharePercent != nil {
+		checkShare(&errs, "share_percent", *req.SharePercent)
+	}
+	return errs
+}
+
+func (req *TypeChangeBody) Validate() validation.Errors {
+	var errs validation.Errors
+	errs.Required("type", req.Type)
+	checkText(&errs, "reason", &req.Reason)
+	return errs
+}
+
+func (req *KYCSubmitRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("cnic", req.CNIC) {
+		checkCNIC(&errs, "cnic", &req.CNIC)
+	}
+	req.DocumentType = validation.Clean(req.DocumentType)
+	if errs.Required("document_type", req.DocumentType) && !slices.Contains(services.KYCDocumentTypes, req.DocumentType) {
+		errs.Add("document_type", "must be one of "+strings.Join(services.KYCDocumentTypes, ", "))
+	}
+	if errs.Required("document_ref", req.DocumentRef) {
+		checkText(&errs, "document_ref", &req.DocumentRef)
+	}
+	return errs
+}
+
+func (req *InheritanceRuleRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	if m := req.InactivityMonths; m != 0 && (m < services.MinInactivityMonths || m > services.MaxInactivityMonths) {
+		errs.Add("inactivity_months", fmt.Sprintf("must be 0 to remove the rule, or from %d to %d", services.MinInactivityMonths, services.MaxInactivityMonths))
+	}
+	return errs
+}
+
+func (req *InheritanceDecisionRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkText(&errs, "reason", &req.Reason)
+	return errs
+}
+
+func (req *CreateCampaignRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("name", req.Name) {
+		checkName(&errs, "name", &req.Name)
+	}
+	checkText(&errs, "description", &req.Description)
+	errs.Check("target_amount", validation.Amount(req.TargetAmount))
+	if req.Deadline.IsZero() {
+		errs.Add("deadline", "is required")
+	}
+	checkWalletID(&errs, "destination_wallet", req.DestinationWallet)
+	return errs
+}
+
+func (req *UpdateCampaignRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.Name != nil && errs.Required("name", *req.Name) {
+		checkName(&errs, "name", req.Name)
+	}
+	if req.Description != nil {
+		checkText(&errs, "description", req.Description)
+	}
+	if req.TargetAmount != nil {
+		errs.Check("target_amount", validation.Amount(*req.TargetAmount))
+	}
+	return errs
+}
+
+func (req *DonateRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "sender_id", req.SenderID)
+	errs.Check("amount", validation.Amount(req.Amount))
+	req.Message = validation.Clean(req.Message)
+	if len(req.Message) > services.MaxDonationMessageLength {
+		errs.Add("message", fmt.Sprintf("must be at most %d bytes", services.MaxDonationMessageLength))
+	} else {
+		errs.Check("message", validation.Text(req.Message))
+	}
+	if req.PrivateKey != "" {
+		errs.Check("private_key", validation.PrivateKey(req.PrivateKey))
+	}
+	return errs
+}
+
+func (req *CharityRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("name", req.Name) {
+		checkName(&errs, "name", &req.Name)
+	}
+	if req.Weight < services.MinCharityWeight || req.Weight > services.MaxCharityWeight {
+		errs.Add("weight", fmt.Sprintf("must be from %d to %d", services.MinCharityWeight, services.MaxCharityWeight))
+	}
+	return errs
+}
+
+func (req *DefineAssetRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("symbol", req.Symbol) {
+		symbol, err := services.NormalizeAssetSymbol(req.Symbol)
+		errs.Check("symbol", err)
+		req.Symbol = symbol
+	}
+	if errs.Required("name", req.Name) {
+		checkName(&errs, "name", &req.Name)
+	}
+	checkWalletID(&errs, "issuer", req.Issuer)
+	if req.InitialSupply == 0 && !req.Mintable {
+		errs.Add("initial_supply", "is required for an asset that is not mintable")
+	}
+	if req.MaxSupply > 0 && req.InitialSupply > req.MaxSupply {
+		errs.Add("initial_supply", "must not exceed max_supply")
+	}
+	return errs
+}
+
+func (req *MintAssetRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	errs.Check("amount", validation.Amount(req.Amount))
+	return errs
+}
+
+func (req *SupplyAdjustmentRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	errs.Check("amount", validation.Amount(req.Amount))
+	if errs.Required("reason", req.Reason) {
+		checkText(&errs, "reason", &req.Reason)
+		if len(req.Reason) > maxAdjustmentReason {
+			errs.Add("reason", fmt.Sprintf("must be at most %d bytes", maxAdjustmentReason))
+		}
+	}
+	if errs.Required("otp_code", req.OTPCode) {
+		checkCode(&errs, "otp_code", &req.OTPCode)
+	}
+	return errs
+}
+
+func (req *KYCReviewRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkText(&errs, "note", &req.Note)
+	return errs
+}
+
+func (req *FreezeRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if errs.Required("reason", req.Reason) {
+		checkText(&errs, "reason", &req.Reason)
+	}
+	return errs
+}
+
+func (req *SetLimitsRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	if req.OTPCode != "" {
+		checkCode(&errs, "otp_code", &req.OTPCode)
+	}
+	return errs
+}
+
+func (req *FaucetClaimRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	if req.OTPCode != "" {
+		checkCode(&errs, "otp_code", &req.OTPCode)
+	}
+	return errs
+}
+
+// checkWebhookEvents checks a list of webhook event types and drops repeats
+func checkWebhookEvents(errs *validation.Errors, field string, list *[]string) {
+	seen := make([]string, 0, len(*list))
+	for _, e := range *list {
+		if !slices.Contains(services.WebhookEvents, e) {
+			errs.Add(field, "must be among "+strings.Join(services.WebhookEvents, ", "))
+			return
+		}
+		if !slices.Contains(seen, e) {
+			seen = append(seen, e)
+		}
+	}
+	*list = seen
+}
+
+func (req *WebhookCreateRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	req.URL = validation.Clean(req.URL)
+	if errs.Required("url", req.URL) {
+		errs.Check("url", validation.URL(req.URL))
+	}
+	if len(req.Events) == 0 {
+		errs.Add("events", "is required")
+	} else {
+		checkWebhookEvents(&errs, "events", &req.Events)
+	}
+	return errs
+}
+
+func (req *WebhookUpdateRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	req.URL = validation.Clean(req.URL)
+	if req.URL != "" {
+		errs.Check("url", validation.URL(req.URL))
+	}
+	checkWebhookEvents(&errs, "events", &req.Events)
+	return errs
+}
+
+func (req *WebhookDeleteRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *TwoFactorEnrollRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	return errs
+}
+
+func (req *TwoFactorVerifyRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkCode(&errs, "code", &req.Code)
+	return errs
+}
+
+func (req *TwoFactorThresholdRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	checkCode(&errs, "code", &req.Code)
+	if req.Threshold > validation.MaxAmount {
+		errs.Add("threshold", "is too large")
+	}
+	return errs
+}
+
+func (req *TwoFactorDisableRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", req.WalletID)
+	checkPrivateKey(&errs, "private_key", req.PrivateKey)
+	checkCode(&errs, "code", &req.Code)
+	return errs
+}
+
+func (req *PruneRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.KeepBlocks != nil && *req.KeepBlocks < 0 {
+		errs.Add("keep_blocks", "must not be negative")
+	}
+	return errs
+}
+
+func (req *PrunePolicyRequest) Validate() validation.Errors {
+	var errs validation.Errors
+	if req.KeepBlocks < 0 {
+		errs.Add("keep_blocks", "must not be negative")
+	}
+	if req.IntervalMinutes < 0 {
+		errs.Add("interval_minutes", "must not be negative")
+	}
+	return errs
+}
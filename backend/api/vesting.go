@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/services"
+)
+
+// A vesting grant is one signed send whose receiver outputs unlock a month
+// apart; see services.CreateVestingGrant. It goes through the same limits and
+// second factors as any send of its total.
+
+// handleCreateVesting queues a vesting grant from the sender to the
+// beneficiary
+func (s *Server) handleCreateVesting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req VestingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	markRawKeyDeprecated(w, req.PrivateKey)
+
+	start := time.Now()
+	if req.Start != nil {
+		start = *req.Start
+	}
+	create := func(ctx context.Context, senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+		return s.txSvc.CreateVestingGrant(ctx, senderID, receiverID, amount, req.Tranches, start, note, pubKey, privKey)
+	}
+	tx, err := s.sendWith(r.Context(), sendInput{
+		SenderID:     req.SenderID,
+		ReceiverID:   req.BeneficiaryID,
+		Amount:       req.Total,
+		Note:         req.Note,
+		SigningToken: req.SigningToken,
+		PrivateKey:   req.PrivateKey,
+		TOTPCode:     req.TOTPCode,
+		LimitOTP:     req.LimitOTP,
+	}, create, r.RemoteAddr)
+	if err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "vesting_granted", req.SenderID, r.RemoteAddr,
+		fmt.Sprintf("%s to %s in %d monthly tranches in %s", blockchain.FormatAmount(req.Total), req.BeneficiaryID, req.Tranches, tx.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.vestingGrant(tx.ID))
+}
+
+// handleGetVesting returns a wallet's vesting grants and how much of what it
+// was granted is still locked
+func (s *Server) handleGetVesting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if _, exists := s.ws.Get(walletID); !exists || !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	resp := VestingResponse{WalletID: walletID, Grants: s.txSvc.VestingGrants(walletID)}
+	for _, g := range resp.Grants {
+		if g.Beneficiary == walletID {
+			resp.Locked += g.Locked
+			resp.Released += g.Released
+		}
+	}
+	if resp.Grants == nil {
+		resp.Grants = []services.VestingGrant{}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleListVesting lists every vesting grant on the chain, newest first
+func (s *Server) handleListVesting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	grants := s.txSvc.VestingGrants("")
+	if grants == nil {
+		grants = []services.VestingGrant{}
+	}
+	json.NewEncoder(w).Encode(grants)
+}
+
+// vestingGrant returns the schedule of the grant created by transaction txID
+func (s *Server) vestingGrant(txID string) services.VestingGrant {
+	tx, _ := s.bc.GetTransactionByID(txID)
+	for _, g := range s.txSvc.VestingGrants(tx.Transaction.SenderID) {
+		if g.TxID == txID {
+			return g
+		}
+	}
+	return services.VestingGrant{TxID: txID}
+}
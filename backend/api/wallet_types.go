@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// handleRequestTypeChange files a wallet type change for admin approval
+func (s *Server) handleRequestTypeChange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	walletID := mux.Vars(r)["wallet"]
+
+	var req TypeChangeBody
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	requestedBy := walletID
+	byAdmin := s.isAdminRequest(r)
+	if byAdmin {
+		requestedBy = s.adminActor(r)
+	}
+
+	change, err := s.walletTypes.RequestChange(walletID, req.Type, req.Reason, requestedBy, byAdmin)
+	if err != nil {
+		s.writeTypeChangeError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_type_change_requested", walletID, r.RemoteAddr, change.FromType+" -> "+change.ToType)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(change)
+}
+
+// handleListTypeChanges lists type change requests, pending ones by default
+func (s *Server) handleListTypeChanges(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = services.TypeChangePending
+	} else if status == "all" {
+		status = ""
+	}
+
+	json.NewEncoder(w).Encode(s.walletTypes.List(status))
+}
+
+// handleDecideTypeChange approves or rejects a pending type change
+func (s *Server) handleDecideTypeChange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid request ID")
+		return
+	}
+
+	var change *services.TypeChangeRequest
+	if vars["decision"] == "approve" {
+		change, err = s.walletTypes.Approve(id, s.adminActor(r))
+	} else {
+		change, err = s.walletTypes.Reject(id, s.adminActor(r))
+	}
+	if err != nil {
+		s.writeTypeChangeError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "wallet_type_change_"+change.Status, change.WalletID, r.RemoteAddr,
+		change.FromType+" -> "+change.ToType+" by "+change.DecidedBy)
+	json.NewEncoder(w).Encode(change)
+}
+
+func (s *Server) writeTypeChangeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+	case errors.Is(err, services.ErrTypeChangeNotFound):
+		Error(w, r, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrTypeChangePending):
+		Error(w, r, CodeTypeChangePending, err.Error())
+	case errors.Is(err, services.ErrTypeChangeDecided):
+		Error(w, r, CodeAlreadyDecided, err.Error())
+	case errors.Is(err, services.ErrSystemTypeRestricted):
+		Error(w, r, CodeAdminRequired, err.Error())
+	case errors.Is(err, services.ErrInvalidWalletType), errors.Is(err, services.ErrTypeUnchanged):
+		Error(w, r, CodeValidationFailed, err.Error())
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
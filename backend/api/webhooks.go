@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// webhookDeliveriesLimit is how many recent deliveries a webhook lists by default
+const webhookDeliveriesLimit = 50
+
+// handleCreateWebhook registers a URL for a wallet's events. The response
+// carries the signing secret, which is not shown again.
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req WebhookCreateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), req.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	wh, secret, err := s.webhooks.Create(req.WalletID, req.URL, req.Events)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "webhook_created", req.WalletID, r.RemoteAddr, fmt.Sprintf("Webhook %d for %s", wh.ID, strings.Join(wh.Events, ", ")))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(WebhookCreatedResponse{Webhook: *wh, Secret: secret})
+}
+
+// handleListWebhooks lists a wallet's webhooks, for the owner's login session
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := r.URL.Query().Get("wallet_id")
+	var errs validation.Errors
+	checkWalletID(&errs, "wallet_id", walletID)
+	if len(errs) > 0 {
+		ValidationError(w, r, errs)
+		return
+	}
+	if !s.requireWalletOwner(w, r, walletID) {
+		return
+	}
+	json.NewEncoder(w).Encode(s.webhooks.List(walletID))
+}
+
+// handleGetWebhook returns one webhook, for the owner's login session
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	wh, ok := s.findWebhook(w, r)
+	if !ok || !s.requireWalletOwner(w, r, wh.WalletID) {
+		return
+	}
+	json.NewEncoder(w).Encode(wh)
+}
+
+// handleUpdateWebhook changes a webhook's URL, events or active flag
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req WebhookUpdateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	wh, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), wh.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	updated, err := s.webhooks.Update(wh.ID, req.URL, req.Events, req.Active)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "webhook_updated", wh.WalletID, r.RemoteAddr, fmt.Sprintf("Webhook %d", wh.ID))
+	json.NewEncoder(w).Encode(updated)
+}
+
+// handleDeleteWebhook removes a webhook; retries still queued for it are dropped
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req WebhookDeleteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	wh, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.verifyWalletKey(r.Context(), wh.WalletID, req.PrivateKey); err != nil {
+		writeOpError(w, r, err)
+		return
+	}
+
+	if err := s.webhooks.Delete(wh.ID); err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	s.logSvc.LogSystemCtx(r.Context(), "webhook_deleted", wh.WalletID, r.RemoteAddr, fmt.Sprintf("Webhook %d", wh.ID))
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Webhook deleted"})
+}
+
+// handleWebhookDeliveries lists a webhook's recent deliveries with their
+// attempts, for the owner's login session; the payloads show the wallet's
+// activity
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	wh, ok := s.findWebhook(w, r)
+	if !ok || !s.requireWalletOwner(w, r, wh.WalletID) {
+		return
+	}
+	limit := webhookDeliveriesLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	json.NewEncoder(w).Encode(s.deliveries.ForWebhook(wh.ID, limit))
+}
+
+// findWebhook resolves the {id} route variable to a webhook in the caller's
+// organization, writing the error response if there is none
+func (s *Server) findWebhook(w http.ResponseWriter, r *http.Request) (services.Webhook, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		Error(w, r, CodeValidationFailed, "Invalid webhook ID")
+		return services.Webhook{}, false
+	}
+	wh, err := s.webhooks.Get(id)
+	if err != nil || !s.inOrg(r.Context(), wh.WalletID) {
+		Error(w, r, CodeNotFound, "Webhook not found")
+		return services.Webhook{}, false
+	}
+	return wh, true
+}
+
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		Error(w, r, CodeNotFound, "Webhook not found")
+	case errors.Is(err, services.ErrWebhookLimit):
+		Error(w, r, CodeWebhookLimit, err.Error())
+	case errors.Is(err, services.ErrWebhookURLNotAllowed):
+		ValidationError(w, r, validation.Errors{{Field: "url", Message: "must not point at a private or loopback address"}})
+	default:
+		Error(w, r, CodeInternal, err.Error())
+	}
+}
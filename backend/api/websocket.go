@@ -0,0 +1,177 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"blockchain-backend/events"
+	"blockchain-backend/wallet"
+)
+
+// wsMaxMessageBytes bounds a single client message
+const wsMaxMessageBytes = 4096
+
+// wsSubscribePayload is what a wallet key signs to subscribe to one of the
+// wallet's topics on a connection
+func wsSubscribePayload(topic, challenge string) []byte {
+	return []byte("subscribe:" + topic + ":" + challenge)
+}
+
+// handleWebSocket upgrades to a websocket on the event hub. Public topics
+// (blocks, announcements) are open; tx:<wallet> and balance:<wallet> need
+// proof of ownership per subscription. Browsers cannot set headers on the
+// upgrade, so a login session token may also come as ?session_token=.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sessionToken := bearerToken(r)
+	if sessionToken == "" {
+		sessionToken = r.URL.Query().Get("session_token")
+	}
+
+	// Any origin may connect, as with CORS on the REST API; private topics are
+	// guarded by the ownership proof, not by the origin
+	srv := websocket.Server{Handler: func(conn *websocket.Conn) {
+		s.serveWebSocket(conn, sessionToken, r.RemoteAddr)
+	}}
+	srv.ServeHTTP(w, r)
+}
+
+func (s *Server) serveWebSocket(conn *websocket.Conn, sessionToken, remoteAddr string) {
+	defer conn.Close()
+	// The HTTP server's read and write timeouts would otherwise end the
+	// connection after a few seconds
+	conn.SetDeadline(time.Time{})
+	conn.MaxPayloadBytes = wsMaxMessageBytes
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return
+	}
+	challenge := hex.EncodeToString(buf)
+
+	var writeMu sync.Mutex
+	send := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return websocket.JSON.Send(conn, v)
+	}
+
+	sub := s.hub.Register()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.C {
+			if send(msg) != nil {
+				break
+			}
+		}
+		// The hub closes the channel when the client falls too far behind;
+		// closing the connection ends the read loop below
+		conn.Close()
+	}()
+
+	if send(WSControlMessage{Type: "welcome", Challenge: challenge}) == nil {
+		for {
+			var in WSClientMessage
+			if err := websocket.JSON.Receive(conn, &in); err != nil {
+				if isJSONError(err) {
+					if send(WSControlMessage{Type: "error", Code: CodeInvalidRequest, Message: "Invalid message"}) == nil {
+						continue
+					}
+				}
+				break
+			}
+			if send(s.handleWSMessage(sub, in, challenge, sessionToken, remoteAddr)) != nil {
+				break
+			}
+		}
+	}
+
+	s.hub.Unregister(sub)
+	<-done
+}
+
+// isJSONError reports a malformed message, after which the connection is
+// still usable
+func isJSONError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// handleWSMessage applies one subscribe or unsubscribe and returns the reply
+func (s *Server) handleWSMessage(sub *events.Subscriber, in WSClientMessage, challenge, connToken, remoteAddr string) WSControlMessage {
+	walletID, ok := events.TopicWallet(in.Topic)
+	if !ok {
+		return WSControlMessage{Type: "error", Topic: in.Topic, Code: CodeValidationFailed, Message: "Unknown topic; use blocks, announcements, tx:<wallet_id> or balance:<wallet_id>"}
+	}
+
+	switch in.Action {
+	case "unsubscribe":
+		s.hub.Unsubscribe(sub, in.Topic)
+		return WSControlMessage{Type: "unsubscribed", Topic: in.Topic}
+	case "subscribe":
+	default:
+		return WSControlMessage{Type: "error", Topic: in.Topic, Code: CodeInvalidRequest, Message: "action must be subscribe or unsubscribe"}
+	}
+
+	if walletID != "" {
+		token := in.SessionToken
+		if token == "" {
+			token = connToken
+		}
+		if err := s.authorizeWalletTopic(walletID, in.Topic, token, in.Signature, challenge); err != nil {
+			s.logSvc.LogSystem("ws_subscribe_denied", walletID, remoteAddr, fmt.Sprintf("%s: %s", in.Topic, err.Error()))
+			return WSControlMessage{Type: "error", Topic: in.Topic, Code: errorCode(err), Message: err.Error()}
+		}
+	}
+
+	s.hub.Subscribe(sub, in.Topic)
+	return WSControlMessage{Type: "subscribed", Topic: in.Topic}
+}
+
+// authorizeWalletTopic checks that the subscriber owns walletID, either with a
+// login session for the wallet's email or a signature of the challenge by the
+// wallet's key
+func (s *Server) authorizeWalletTopic(walletID, topic, sessionToken, signature, challenge string) error {
+	wlt, ok := s.ws.Get(walletID)
+	if !ok {
+		return fail(CodeWalletNotFound, "Wallet not found")
+	}
+
+	if signature != "" {
+		valid, err := wallet.VerifySignature(wlt.PublicKey, wsSubscribePayload(topic, challenge), signature)
+		if err != nil || !valid {
+			return fail(CodeUnauthorized, "Signature does not match the wallet's key")
+		}
+		return nil
+	}
+
+	if sessionToken != "" {
+		return s.authorizeWalletSession(wlt, sessionToken)
+	}
+
+	return fail(CodeUnauthorized, "Wallet topics need session_token or a signature of the challenge")
+}
+
+// handleAnnouncement broadcasts an admin message on the announcements topic
+func (s *Server) handleAnnouncement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req AnnouncementRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.hub.Broadcast(events.TopicAnnouncements, events.Announcement, map[string]string{"message": req.Message})
+
+	s.logSvc.LogSystemCtx(r.Context(), "announcement_sent", s.adminActor(r), r.RemoteAddr, req.Message)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "success", Message: "Announcement sent"})
+}
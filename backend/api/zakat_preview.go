@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"blockchain-backend/services"
+)
+
+// The zakat previews compute what the next scheduled run would deduct with
+// the same rules as the run itself, without creating any transaction, so
+// the figures can be checked before the scheduler fires.
+
+// handleZakatPreview returns the next run's deductions for every wallet in
+// the caller's organization and their total. ?due=true keeps only the
+// wallets that would pay.
+func (s *Server) handleZakatPreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	due := r.URL.Query().Get("due")
+	if due != "" && due != "true" && due != "false" {
+		Error(w, r, CodeValidationFailed, "due must be true or false")
+		return
+	}
+
+	preview := s.zakat.Preview(func(walletID string) bool {
+		return s.inOrg(r.Context(), walletID)
+	})
+	if due == "true" {
+		list := make([]services.ZakatAssessment, 0, preview.Due)
+		for _, a := range preview.Assessments {
+			if a.Skipped == "" && a.Amount > 0 {
+				list = append(list, a)
+			}
+		}
+		preview.Assessments = list
+	}
+	json.NewEncoder(w).Encode(preview)
+}
+
+// handleWalletZakatPreview returns what the next run would deduct from a
+// wallet, or why it would deduct nothing
+func (s *Server) handleWalletZakatPreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	walletID := mux.Vars(r)["wallet"]
+	if !s.inOrg(r.Context(), walletID) {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	a, ok := s.zakat.Assess(walletID)
+	if !ok {
+		Error(w, r, CodeWalletNotFound, "Wallet not found")
+		return
+	}
+	json.NewEncoder(w).Encode(a)
+}
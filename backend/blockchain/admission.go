@@ -0,0 +1,238 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// Reasons AddPending refuses a transaction
+var (
+	ErrDuplicateTx      = errors.New("transaction is already pending or mined")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrNonceUsed        = errors.New("nonce already used")
+	ErrUnknownInput     = errors.New("input does not exist")
+	ErrInputSpent       = errors.New("input is already spent")
+	ErrInputNotOwned    = errors.New("input does not belong to the sender")
+	ErrInputConflict    = errors.New("input is spent by another pending transaction")
+	ErrUnbalanced       = errors.New("inputs do not equal outputs plus fee")
+	ErrInvalidOutput    = errors.New("output amount must be positive and at most the maximum amount")
+	ErrAssetMismatch    = errors.New("input is not of the transaction's asset")
+	ErrAssetFee         = errors.New("asset transfers pay no fee")
+)
+
+// AddPending admits a transaction to the pending pool. Everything in the pool
+// may end up in a block, so the pool refuses what a block must not hold: a
+// transaction already pending or mined, a bad signature or replayed nonce, and
+// inputs that are missing, spent, someone else's or already spent by another
+// pending transaction. Wallet policy (frozen wallets, spending limits,
+// confirmations) is the transaction service's business.
+func (bc *Blockchain) AddPending(tx Transaction) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if err := bc.admit(tx); err != nil {
+		return err
+	}
+	bc.pending = append(bc.pending, tx)
+	bc.noteNonce(tx)
+	return nil
+}
+
+// AddPendingAll admits txs in order, each checked against the pool including
+// the ones before it, or none of them when one is refused
+func (bc *Blockchain) AddPendingAll(txs []Transaction) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	n := len(bc.pending)
+	nonces := make(map[string]uint64, len(txs))
+	for _, tx := range txs {
+		nonces[tx.SenderID] = bc.nonces[tx.SenderID]
+	}
+	for _, tx := range txs {
+		if err := bc.admit(tx); err != nil {
+			bc.pending = bc.pending[:n]
+			for id, nonce := range nonces {
+				bc.nonces[id] = nonce
+			}
+			return err
+		}
+		bc.pending = append(bc.pending, tx)
+		bc.noteNonce(tx)
+	}
+	return nil
+}
+
+// admit checks a transaction against the chain and the pending pool. The
+// caller must hold the lock.
+func (bc *Blockchain) admit(tx Transaction) error {
+	if _, mined := bc.txIndex[tx.ID]; mined {
+		return fmt.Errorf("%w: %s is in the chain", ErrDuplicateTx, tx.ID)
+	}
+	system := strings.EqualFold(tx.PubKey, systemPubKey)
+
+	reserved := make(map[string]string)
+	for _, p := range bc.pending {
+		if p.ID == tx.ID || (!system && p.Signature == tx.Signature) {
+			return fmt.Errorf("%w: %s is pending", ErrDuplicateTx, p.ID)
+		}
+		for _, in := range p.Inputs {
+			reserved[UTXOKey(in.TxID, in.Index)] = p.ID
+		}
+	}
+
+	if err := bc.verify(tx, reserved); err != nil {
+		return err
+	}
+	if !system && tx.Version >= TxVersion && tx.Nonce <= bc.nonces[tx.SenderID] {
+		return fmt.Errorf("%w: nonce %d, the wallet's last nonce is %d", ErrNonceUsed, tx.Nonce, bc.nonces[tx.SenderID])
+	}
+	return nil
+}
+
+// verify checks a transaction against the chain: its signature and sender,
+// inputs that exist, are unspent, the sender may unlock now, are of the
+// transaction's asset and are not claimed by another transaction, well-formed
+// locks and amounts on its outputs, and amounts that balance without
+// overflowing. claimed maps inputs already spoken for to the transaction that
+// took them. The caller must hold the lock.
+func (bc *Blockchain) verify(tx Transaction, claimed map[string]string) error {
+	return bc.verifyAt(tx, claimed, time.Now().Unix())
+}
+
+// verifyAt is verify with time locks judged at Unix time at, such as the
+// timestamp of the block a replayed transaction was mined in
+func (bc *Blockchain) verifyAt(tx Transaction, claimed map[string]string, at int64) error {
+	system := strings.EqualFold(tx.PubKey, systemPubKey)
+	if tx.AssetID != "" && tx.Version < TxVersionAsset {
+		return fmt.Errorf("%w: version %d transactions cannot move assets", ErrAssetMismatch, tx.Version)
+	}
+	if tx.AssetID != "" && tx.Fee > 0 {
+		return ErrAssetFee
+	}
+	if tx.Version < TxVersionLock && hasLocks(tx) {
+		return fmt.Errorf("%w: version %d transactions cannot carry locks or preimages", ErrMalformedLock, tx.Version)
+	}
+	for i, o := range tx.Outputs {
+		if err := o.Lock.Check(); err != nil {
+			return err
+		}
+		if err := validation.Amount(o.Amount); err != nil {
+			return fmt.Errorf("%w: output %d %v", ErrInvalidOutput, i, err)
+		}
+	}
+	// Issuance creates asset units from nothing, as the coinbase does coins;
+	// the asset registry holds it to the asset's supply rules
+	if system && tx.Type == "asset_issue" {
+		if tx.AssetID == "" || len(tx.Inputs) > 0 {
+			return fmt.Errorf("%w: issuance must name an asset and spend nothing", ErrUnbalanced)
+		}
+		return nil
+	}
+	// An admin mint creates coins for one wallet the same way
+	if system && tx.Type == "admin_mint" {
+		if tx.AssetID != "" || len(tx.Inputs) > 0 || tx.SenderID != MintIssuer {
+			return fmt.Errorf("%w: a mint must create coins from %s and spend nothing", ErrUnbalanced, MintIssuer)
+		}
+		return nil
+	}
+
+	// Transactions the backend issues itself carry no wallet signature
+	if !system {
+		valid, err := wallet.VerifySignature(tx.PubKey, SigningPayload(tx), tx.Signature)
+		if err != nil || !valid {
+			return ErrInvalidSignature
+		}
+		if id, err := wallet.WalletIDFromPub(tx.PubKey); err != nil || id != tx.SenderID {
+			return fmt.Errorf("%w: public key does not belong to %s", ErrInvalidSignature, tx.SenderID)
+		}
+	}
+
+	var in, out uint64
+	seen := make(map[string]bool)
+	for _, ref := range tx.Inputs {
+		key := UTXOKey(ref.TxID, ref.Index)
+		if seen[key] {
+			return fmt.Errorf("%w: %s is listed twice", ErrInputConflict, key)
+		}
+		seen[key] = true
+
+		u, ok := bc.utxos[key]
+		switch {
+		case !ok:
+			return fmt.Errorf("%w: %s", ErrUnknownInput, key)
+		case u.Spent:
+			return fmt.Errorf("%w: %s", ErrInputSpent, key)
+		case u.AssetID != tx.AssetID:
+			return fmt.Errorf("%w: %s", ErrAssetMismatch, key)
+		}
+		if err := u.Unlock(tx.SenderID, ref.Preimage, at); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if other, ok := claimed[key]; ok {
+			return fmt.Errorf("%w: %s is spent by %s", ErrInputConflict, key, other)
+		}
+		if in, ok = AddAmounts(in, u.Amount); !ok {
+			return fmt.Errorf("%w: inputs overflow", ErrUnbalanced)
+		}
+	}
+	for _, o := range tx.Outputs {
+		var ok bool
+		if out, ok = AddAmounts(out, o.Amount); !ok {
+			return fmt.Errorf("%w: outputs overflow", ErrUnbalanced)
+		}
+	}
+	// An admin burn destroys its amount and may only return change
+	if system && tx.Type == "admin_burn" {
+		for _, o := range tx.Outputs {
+			if o.Owner != tx.SenderID {
+				return fmt.Errorf("%w: a burn may only pay change to %s", ErrUnbalanced, tx.SenderID)
+			}
+		}
+		var ok bool
+		if out, ok = AddAmounts(out, tx.Amount); !ok {
+			return fmt.Errorf("%w: outputs overflow", ErrUnbalanced)
+		}
+	}
+	if spent, ok := AddAmounts(out, tx.Fee); !ok || in != spent {
+		return fmt.Errorf("%w: inputs %d, outputs %d, fee %d", ErrUnbalanced, in, out, tx.Fee)
+	}
+	return nil
+}
+
+// DroppedTx is a pending transaction that block assembly found invalid and
+// removed from the pool
+type DroppedTx struct {
+	Transaction Transaction `json:"transaction"`
+	Reason      string      `json:"reason"`
+}
+
+// assemble checks the transactions a block is about to take, in block order,
+// and splits off those no longer valid: inputs spent or gone since they were
+// admitted, or claimed by an earlier transaction of the same block. The
+// caller must hold the lock.
+func (bc *Blockchain) assemble(included []Transaction) (valid []Transaction, dropped []DroppedTx) {
+	claimed := make(map[string]string)
+	ids := make(map[string]bool)
+	for _, tx := range included {
+		err := bc.verify(tx, claimed)
+		if _, mined := bc.txIndex[tx.ID]; err == nil && (mined || ids[tx.ID]) {
+			err = fmt.Errorf("%w: %s", ErrDuplicateTx, tx.ID)
+		}
+		if err != nil {
+			log.Printf("🗑️  Dropping pending transaction %s from block assembly: %v", tx.ID, err)
+			dropped = append(dropped, DroppedTx{Transaction: tx, Reason: err.Error()})
+			continue
+		}
+		for _, in := range tx.Inputs {
+			claimed[UTXOKey(in.TxID, in.Index)] = tx.ID
+		}
+		ids[tx.ID] = true
+		valid = append(valid, tx)
+	}
+	return valid, dropped
+}
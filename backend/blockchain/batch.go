@@ -0,0 +1,52 @@
+package blockchain
+
+// A batch transaction pays many wallets at once: one output per payee, then
+// the sender's change. Its receiver is BatchReceiver and its amount the sum
+// of the payments.
+
+// Payees returns the wallets a transaction pays other than its sender: each
+// output owner of a batch, otherwise the receiver
+func (tx Transaction) Payees() []string {
+	if tx.ReceiverID != BatchReceiver {
+		if tx.ReceiverID == "" || tx.ReceiverID == tx.SenderID {
+			return nil
+		}
+		return []string{tx.ReceiverID}
+	}
+	var payees []string
+	seen := make(map[string]bool)
+	for _, out := range tx.Outputs {
+		if out.Owner != tx.SenderID && !seen[out.Owner] {
+			seen[out.Owner] = true
+			payees = append(payees, out.Owner)
+		}
+	}
+	return payees
+}
+
+// Pays reports whether a transaction pays walletID, as its receiver or as a
+// payee of a batch
+func (tx Transaction) Pays(walletID string) bool {
+	if tx.ReceiverID != BatchReceiver {
+		return tx.ReceiverID == walletID
+	}
+	return walletID != tx.SenderID && tx.PaidTo(walletID) > 0
+}
+
+// PaidTo returns what a transaction pays walletID: the amount for its
+// receiver, or the wallet's outputs of a batch
+func (tx Transaction) PaidTo(walletID string) uint64 {
+	if tx.ReceiverID != BatchReceiver {
+		if tx.ReceiverID == walletID {
+			return tx.Amount
+		}
+		return 0
+	}
+	var paid uint64
+	for _, out := range tx.Outputs {
+		if out.Owner == walletID && walletID != tx.SenderID {
+			paid += out.Amount
+		}
+	}
+	return paid
+}
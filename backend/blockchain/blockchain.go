@@ -1,28 +1,41 @@
 package blockchain
 
 import (
+    "context"
     "crypto/sha256"
     "encoding/hex"
     "fmt"
+    "log"
     "sort"
+    "strconv"
     "strings"
     "sync"
     "time"
 )
 
 const (
-    MiningReward     = 50   // Coins rewarded for mining a block
-    FaucetAmount     = 1000 // Initial coins for new wallets
-    ZakatNisab       = 500  // Minimum balance required for zakat eligibility
+    MiningReward     = 50 * UnitsPerCoin   // Rewarded for mining a block
+    FaucetAmount     = 1000 * UnitsPerCoin // Initial coins for new wallets
+    ZakatNisab       = 500 * UnitsPerCoin  // Minimum balance required for zakat eligibility
     ZakatRate        = 0.025 // 2.5% zakat rate
     ZakatIntervalDays = 30   // Zakat applied every 30 days
+    AnchorFee        = UnitsPerCoin // Fee charged for recording a document hash on-chain
+    AnchorReceiver   = "ANCHOR" // Receiver ID used by anchor transactions
+    BatchReceiver    = "BATCH" // Receiver ID of batch transactions, which pay each output's owner
+    AssetIssuer      = "ASSET_ISSUER" // Sender ID of asset_issue transactions, which create asset units
+    MintIssuer       = "MINT" // Sender ID of admin_mint transactions, which create coins
+    BurnReceiver     = "BURN" // Receiver ID of admin_burn transactions, which destroy their amount
 )
 
 type Transaction struct {
+    Version     int               `json:"version,omitempty"` // TxVersion; absent on transactions signed before versioning
     ID          string            `json:"id"`
     SenderID    string            `json:"sender_id"`
     ReceiverID  string            `json:"receiver_id"`
+    AssetID     string            `json:"asset_id,omitempty"` // asset moved; empty for the coin, see TxVersionAsset
     Amount      uint64            `json:"amount"`
+    Fee         uint64            `json:"fee,omitempty"`
+    Nonce       uint64            `json:"nonce,omitempty"` // above every earlier nonce of the sender; see View.Nonce
     Note        string            `json:"note,omitempty"`
     Timestamp   int64             `json:"timestamp"`
     PubKey      string            `json:"pubkey"`
@@ -33,20 +46,32 @@ type Transaction struct {
 }
 
 type UTXORef struct {
-    TxID  string `json:"txid"`
-    Index int    `json:"index"`
+    TxID     string `json:"txid"`
+    Index    int    `json:"index"`
+    Preimage string `json:"preimage,omitempty"` // hex secret opening the output's hash lock
+}
+
+// UTXOKey is the ID of output index of transaction txID, the key UTXOs are
+// stored and spent under
+func UTXOKey(txID string, index int) string {
+    return fmt.Sprintf("%s:%d", txID, index)
 }
 
 type UTXO struct {
     ID        string `json:"id"`
     Owner     string `json:"owner"`
+    AssetID   string `json:"asset_id,omitempty"` // empty for the coin
     Amount    uint64 `json:"amount"`
     OriginTx  string `json:"origin_tx"`
     Index     int    `json:"index"`
     Spent     bool   `json:"spent"`
+    Height    int64  `json:"height,omitempty"` // block that created it; 0 for off-chain faucet UTXOs
+    SpentHeight int64 `json:"spent_height,omitempty"` // block that spent it; 0 while unspent or when not known
+    Lock                // conditions beyond the owner's signature; zero for most outputs
 }
 
 type Block struct {
+    Version     int           `json:"version"` // BlockVersion; 0 in dumps from before versioning means BlockVersionLegacy
     Index       int64         `json:"index"`
     Timestamp   int64         `json:"timestamp"`
     Transactions []Transaction `json:"transactions"`
@@ -54,60 +79,52 @@ type Block struct {
     Nonce        int64        `json:"nonce"`
     Hash         string       `json:"hash"`
     MerkleRoot   string       `json:"merkle_root"`
+    Signer       string       `json:"signer,omitempty"`    // producer's public key, under a consensus that names one
+    Signature    string       `json:"signature,omitempty"` // Signer's signature of Hash
 }
 
 type Blockchain struct {
 	mu             sync.RWMutex
-	Chain          []Block
-	Pending        []Transaction
-	UTXOs          map[string]UTXO
+	chain          []Block
+	pending        []Transaction
+	utxos          map[string]UTXO
+	txIndex        map[string]txPosition // where every mined transaction is; see indexBlock
+	byOwner        map[string][]string // UTXO IDs per owner, spent ones included until pruned; see putUTXO
+	nonces         map[string]uint64   // highest nonce per sender; see nonce
 	DifficultyPref string
-}
-
-func (bc *Blockchain) RLock() {
-	bc.mu.RLock()
-}
-
-func (bc *Blockchain) RUnlock() {
-	bc.mu.RUnlock()
-}
-
-func (bc *Blockchain) Lock() {
-	bc.mu.Lock()
-}
-
-func (bc *Blockchain) Unlock() {
-	bc.mu.Unlock()
-}
-
-func (bc *Blockchain) GetPending() []Transaction {
-	bc.mu.RLock()
-	defer bc.mu.RUnlock()
-	return bc.Pending
+	Miner          Miner // proof-of-work search; nil uses CPUMiner
+	Consensus      Consensus // who produces blocks and how; nil is proof of work
+	MinConfirmations ConfirmationPolicy
+	Mempool        MempoolPolicy
+	Supply         SupplyPolicy
+	issued         uint64 // coins created so far; see Issued
+	prunedHeight   int64  // highest block whose spent UTXOs may have been pruned; see Prune
 }
 
 func NewBlockchain() *Blockchain {
     bc := &Blockchain{
-        Chain: make([]Block, 0),
-        Pending: make([]Transaction, 0),
-        UTXOs: make(map[string]UTXO),
+        chain: make([]Block, 0),
+        pending: make([]Transaction, 0),
+        utxos: make(map[string]UTXO),
+        txIndex: make(map[string]txPosition),
+        byOwner: make(map[string][]string),
+        nonces: make(map[string]uint64),
         DifficultyPref: "00000",
+        MinConfirmations: DefaultConfirmationPolicy(),
+        Mempool: DefaultMempoolPolicy(),
+        Supply: DefaultSupplyPolicy(),
     }
-    // create genesis
-    genesis := Block{
-        Index: 0,
-        Timestamp: time.Now().Unix(),
-        Transactions: []Transaction{},
-        PreviousHash: "0",
-        Nonce: 0,
-    }
-    genesis.MerkleRoot = bc.computeMerkle(genesis.Transactions)
-    genesis.Hash = bc.hashBlock(genesis)
-    bc.Chain = append(bc.Chain, genesis)
+    bc.chain = append(bc.chain, bc.newGenesis())
     return bc
 }
 
 func (bc *Blockchain) computeMerkle(txs []Transaction) string {
+    return MerkleRoot(txs)
+}
+
+// MerkleRoot computes the merkle root over the transaction IDs; exported so
+// offline tools can recompute it from a chain dump
+func MerkleRoot(txs []Transaction) string {
     if len(txs) == 0 {
         return ""
     }
@@ -134,10 +151,17 @@ func (bc *Blockchain) computeMerkle(txs []Transaction) string {
 }
 
 func (bc *Blockchain) hashBlock(b Block) string {
+    return HashBlock(b)
+}
+
+// HashBlockLegacy is the hash of BlockVersionLegacy blocks: index, timestamp,
+// sorted transaction IDs, previous hash and nonce joined with "|". The stored
+// Hash and MerkleRoot are not part of the input.
+func HashBlockLegacy(b Block) string {
     // deterministic hash of block
     var parts []string
-    parts = append(parts, string(b.Index))
-    parts = append(parts, string(b.Timestamp))
+    parts = append(parts, strconv.FormatInt(b.Index, 10))
+    parts = append(parts, strconv.FormatInt(b.Timestamp, 10))
     // collect tx ids
     var txs []string
     for _, t := range b.Transactions {
@@ -146,31 +170,77 @@ func (bc *Blockchain) hashBlock(b Block) string {
     sort.Strings(txs)
     parts = append(parts, strings.Join(txs, ","))
     parts = append(parts, b.PreviousHash)
-    parts = append(parts, string(b.Nonce))
+    parts = append(parts, strconv.FormatInt(b.Nonce, 10))
     joined := strings.Join(parts, "|")
     h := sha256.Sum256([]byte(joined))
     return hex.EncodeToString(h[:])
 }
 
-func (bc *Blockchain) AddPending(tx Transaction) {
-    bc.mu.Lock()
-    defer bc.mu.Unlock()
-    bc.Pending = append(bc.Pending, tx)
+// Mine assembles and mines a block from the pending pool, rewarding
+// minerWalletID. Pending transactions that are no longer valid are dropped.
+func (bc *Blockchain) Mine(nonceStart int64, minerWalletID string) Block {
+    b, _ := bc.MineReport(nonceStart, minerWalletID)
+    return b
 }
 
-func (bc *Blockchain) Mine(nonceStart int64, minerWalletID string) Block {
+// MineReport is Mine that also returns the pending transactions block
+// assembly dropped, and why
+func (bc *Blockchain) MineReport(nonceStart int64, minerWalletID string) (Block, []DroppedTx) {
+    b, dropped, _ := bc.MineContext(context.Background(), nonceStart, minerWalletID)
+    return b, dropped
+}
+
+// MineContext is MineReport that gives up when ctx is done, while waiting
+// for the chain or searching for the proof of work. The chain and the pending
+// pool are left as they were; a mined block is committed regardless. Under a
+// consensus that gives the block to another wallet it fails with
+// ErrNotProducer, and under one that names a producer at all, with
+// ErrBlockSignature, as those blocks need MineSigned.
+func (bc *Blockchain) MineContext(ctx context.Context, nonceStart int64, minerWalletID string) (Block, []DroppedTx, error) {
+    return bc.MineSigned(ctx, nonceStart, minerWalletID, nil)
+}
+
+// MineSigned is MineContext that signs the block with sign once it is
+// sealed, as a consensus that names the producer requires
+func (bc *Blockchain) MineSigned(ctx context.Context, nonceStart int64, minerWalletID string, sign BlockSigner) (Block, []DroppedTx, error) {
     bc.mu.Lock()
     defer bc.mu.Unlock()
-    b := Block{}
-    b.Index = int64(len(bc.Chain))
+    if err := ctx.Err(); err != nil {
+        return Block{}, nil, err
+    }
+    cons := bc.consensus()
+    b := Block{Version: BlockVersion}
+    b.Index = int64(len(bc.chain))
     b.Timestamp = time.Now().Unix()
+    producer := cons.Producer(b.Index, BlockInterval(bc.chain[len(bc.chain)-1].Timestamp, b.Timestamp))
+    if producer != "" && producer != minerWalletID {
+        return Block{}, nil, fmt.Errorf("%w: block #%d is %s's", ErrNotProducer, b.Index, producer)
+    }
+    if producer != "" && sign == nil {
+        return Block{}, nil, fmt.Errorf("%w: block #%d needs %s's signature", ErrBlockSignature, b.Index, producer)
+    }
+    
+    // Take pending transactions lane by lane up to the block size limit; the
+    // rest wait for a later block. Those that went invalid while they waited
+    // leave the pool.
+    included, deferred := bc.selectPending()
+    included, dropped := bc.assemble(included)
+    
+    // Miner collects the block subsidy plus all fees paid by included
+    // transactions; the subsidy shrinks as issuance nears the supply cap
+    var fees uint64
+    for _, tx := range included {
+        fees += tx.Fee
+    }
+    subsidy := bc.Supply.Subsidy(bc.issued)
+    reward := subsidy + fees
     
     // Create coinbase transaction (mining reward)
     coinbaseTx := Transaction{
-        ID:         fmt.Sprintf("coinbase-%d-%d", b.Index, b.Timestamp),
+        Version:    TxVersion,
         SenderID:   "COINBASE",
         ReceiverID: minerWalletID,
-        Amount:     MiningReward,
+        Amount:     reward,
         Note:       fmt.Sprintf("Mining reward for block #%d", b.Index),
         Timestamp:  b.Timestamp,
         PubKey:     "SYSTEM",
@@ -179,94 +249,207 @@ func (bc *Blockchain) Mine(nonceStart int64, minerWalletID string) Block {
         Outputs: []UTXO{
             {
                 Owner:    minerWalletID,
-                Amount:   MiningReward,
-                OriginTx: fmt.Sprintf("coinbase-%d-%d", b.Index, b.Timestamp),
+                Amount:   reward,
                 Index:    0,
                 Spent:    false,
             },
         },
         Type: "mining_reward",
     }
+    AssignID(&coinbaseTx) // the note names the block, so every coinbase ID differs
     
     // Add coinbase transaction first, then pending transactions
-    b.Transactions = append([]Transaction{coinbaseTx}, bc.Pending...)
-    b.PreviousHash = bc.Chain[len(bc.Chain)-1].Hash
+    b.Transactions = append([]Transaction{coinbaseTx}, included...)
+    b.PreviousHash = bc.chain[len(bc.chain)-1].Hash
     b.MerkleRoot = bc.computeMerkle(b.Transactions)
 
-    nonce := nonceStart
-    maxIterations := int64(10000000) // Prevent infinite loop - 10 million attempts
-    hashAttempts := int64(0)
-    
-    for i := int64(0); i < maxIterations; i++ {
-        b.Nonce = nonce
-        h := bc.hashBlock(b)
-        hashAttempts++
-        
-        if strings.HasPrefix(h, bc.DifficultyPref) {
-            b.Hash = h
-            fmt.Printf("⛏️  Block mined! Found valid hash after %d attempts (nonce: %d)\n", hashAttempts, nonce)
-            break
-        }
-        nonce++
+    hashAttempts, err := cons.Seal(ctx, &b, nonceStart)
+    if err != nil {
+        return Block{}, nil, err
+    }
+    if b.Hash != "" {
+        log.Printf("⛏️  Block mined! Found valid hash after %d attempts (nonce: %d)", hashAttempts, b.Nonce)
     }
     
     // If we didn't find a valid hash, use what we have (shouldn't happen with 00000 difficulty)
     if b.Hash == "" {
-        fmt.Printf("⚠️  Warning: Mining reached max iterations (%d), using current hash\n", maxIterations)
+        log.Printf("⚠️  Warning: Mining stopped after %d attempts, using current hash", hashAttempts)
         b.Hash = bc.hashBlock(b)
     }
+    if producer != "" {
+        b.Signer, b.Signature, err = sign(b.Hash)
+        if err == nil {
+            err = VerifyBlockSignature(b, producer)
+        }
+        if err != nil {
+            return Block{}, nil, err
+        }
+    }
 
     // commit
-    bc.Chain = append(bc.Chain, b)
+    bc.chain = append(bc.chain, b)
+    bc.issued += subsidy
     // mark UTXOs with correct key format
+    bc.indexBlock(b)
+    bc.applyUTXOs(b)
+    // keep what did not fit
+    bc.pending = deferred
+    return b, dropped, nil
+}
+
+// applyUTXOs marks the UTXOs a block's transactions spend and adds the ones
+// they create. The caller must hold the write lock.
+func (bc *Blockchain) applyUTXOs(b Block) {
     for _, tx := range b.Transactions {
         for _, in := range tx.Inputs {
-            key := fmt.Sprintf("%s:%d", in.TxID, in.Index)
-            if ut, ok := bc.UTXOs[key]; ok {
+            if ut, ok := bc.utxos[UTXOKey(in.TxID, in.Index)]; ok {
                 ut.Spent = true
-                bc.UTXOs[key] = ut
+                ut.SpentHeight = b.Index
+                bc.putUTXO(ut)
             }
         }
         for idx, out := range tx.Outputs {
-            key := fmt.Sprintf("%s:%d", tx.ID, idx)
-            out.ID = key
-            bc.UTXOs[key] = out
+            out.ID = UTXOKey(tx.ID, idx)
+            out.AssetID = tx.AssetID
+            out.Height = b.Index
+            bc.putUTXO(out)
         }
     }
-    // clear pending
-    bc.Pending = []Transaction{}
-    return b
 }
 
+// AnchorRecord locates an anchor transaction for a document hash
+type AnchorRecord struct {
+    Transaction Transaction
+    Confirmed   bool
+    Block       Block // zero value while pending
+}
+
+// FindAnchor looks up the anchor transaction recording docHash, first in the
+// chain and then in the pending pool
+func (bc *Blockchain) FindAnchor(docHash string) (AnchorRecord, bool) {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    for _, b := range bc.chain {
+        for _, tx := range b.Transactions {
+            if tx.Type == "anchor" && tx.Note == docHash {
+                return AnchorRecord{Transaction: tx, Confirmed: true, Block: b}, true
+            }
+        }
+    }
+    for _, tx := range bc.pending {
+        if tx.Type == "anchor" && tx.Note == docHash {
+            return AnchorRecord{Transaction: tx}, true
+        }
+    }
+    return AnchorRecord{}, false
+}
+
+// TxLocation locates a transaction in the chain or the pending pool
+type TxLocation struct {
+    Transaction Transaction
+    Pending     bool
+    Block       Block // zero value while pending
+    Position    int   // index within Block.Transactions; 0 is the coinbase
+}
+
+// Height returns the index of the latest block
+func (bc *Blockchain) Height() int64 {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    return int64(len(bc.chain) - 1)
+}
+
+// GetBalance returns the spendable coin balance: unspent coin UTXOs with
+// enough confirmations
 func (bc *Blockchain) GetBalance(walletID string) uint64 {
+    return bc.GetAssetBalance(walletID, "")
+}
+
+// GetAssetBalance returns the spendable amount of an asset a wallet holds;
+// the empty asset ID is the coin
+func (bc *Blockchain) GetAssetBalance(walletID, assetID string) uint64 {
     bc.mu.RLock()
     defer bc.mu.RUnlock()
     var sum uint64 = 0
-    for _, ut := range bc.UTXOs {
-        if ut.Owner == walletID && !ut.Spent {
+    for _, ut := range bc.ownedAssetUTXOs(walletID, assetID) {
+        if bc.spendable(ut) {
             sum += ut.Amount
         }
     }
     return sum
 }
 
-// CreateFaucetUTXO gives new wallets initial balance
-func (bc *Blockchain) CreateFaucetUTXO(walletID string) UTXO {
+// GetAssetBalances returns the spendable amount of every asset other than the
+// coin that a wallet holds or once held
+func (bc *Blockchain) GetAssetBalances(walletID string) map[string]uint64 {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    balances := make(map[string]uint64)
+    for _, id := range bc.byOwner[walletID] {
+        ut := bc.utxos[id]
+        if ut.AssetID == "" {
+            continue
+        }
+        if bc.spendable(ut) {
+            balances[ut.AssetID] += ut.Amount
+        } else if _, ok := balances[ut.AssetID]; !ok {
+            balances[ut.AssetID] = 0
+        }
+    }
+    return balances
+}
+
+// putUTXO stores a UTXO under its ID and indexes it by owner. Spending marks
+// the stored copy rather than deleting it, so an ID stays with one owner.
+// The caller must hold the write lock.
+func (bc *Blockchain) putUTXO(u UTXO) {
+    if _, exists := bc.utxos[u.ID]; !exists {
+        bc.byOwner[u.Owner] = append(bc.byOwner[u.Owner], u.ID)
+    }
+    bc.utxos[u.ID] = u
+}
+
+// ownedUTXOs returns a wallet's coin UTXOs, spent ones included, in the order
+// they were created or loaded. It reads the owner index, so the cost grows
+// with the wallet's outputs rather than with the whole set. The caller must
+// hold the read lock.
+func (bc *Blockchain) ownedUTXOs(walletID string) []UTXO {
+    return bc.ownedAssetUTXOs(walletID, "")
+}
+
+// ownedAssetUTXOs is ownedUTXOs for one asset; the empty asset ID is the
+// coin. The caller must hold the read lock.
+func (bc *Blockchain) ownedAssetUTXOs(walletID, assetID string) []UTXO {
+    ids := bc.byOwner[walletID]
+    owned := make([]UTXO, 0, len(ids))
+    for _, id := range ids {
+        if u := bc.utxos[id]; u.AssetID == assetID {
+            owned = append(owned, u)
+        }
+    }
+    return owned
+}
+
+// CreateFaucetUTXO gives new wallets initial balance (FaucetAmount unless
+// their organization grants a different amount)
+func (bc *Blockchain) CreateFaucetUTXO(walletID string, amount uint64) UTXO {
     bc.mu.Lock()
     defer bc.mu.Unlock()
     
     timestamp := time.Now().Unix()
-    utxoID := fmt.Sprintf("faucet-%s-%d:0", walletID, timestamp)
+    originTx := fmt.Sprintf("faucet-%s-%d", walletID, timestamp)
     
     faucetUTXO := UTXO{
-        ID:       utxoID,
+        ID:       UTXOKey(originTx, 0),
         Owner:    walletID,
-        Amount:   FaucetAmount,
-        OriginTx: fmt.Sprintf("faucet-%s-%d", walletID, timestamp),
+        Amount:   amount,
+        OriginTx: originTx,
         Index:    0,
         Spent:    false,
     }
     
-    bc.UTXOs[utxoID] = faucetUTXO
+    bc.putUTXO(faucetUTXO)
+    bc.issued += amount
     return faucetUTXO
 }
@@ -0,0 +1,293 @@
+package blockchain
+
+import (
+	"iter"
+	"slices"
+)
+
+// Read accessors for the chain, the pending pool and the UTXO set. The Get*
+// methods take the read lock themselves and return copies, so callers never
+// hold references into state Mine is changing. Mined blocks are immutable,
+// so a copied Block shares its Transactions with the chain.
+//
+// Services that combine several reads into one consistent view do them
+// inside View, which holds the read lock for the duration.
+
+// GetChain returns every block from genesis to the tip
+func (bc *Blockchain) GetChain() []Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]Block(nil), bc.chain...)
+}
+
+// GetBlocks returns the blocks with index from to index to, both included,
+// clamped to the chain
+func (bc *Blockchain) GetBlocks(from, to int64) []Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if from < 0 {
+		from = 0
+	}
+	if tip := int64(len(bc.chain) - 1); to > tip {
+		to = tip
+	}
+	if from > to {
+		return []Block{}
+	}
+	return append([]Block(nil), bc.chain[from:to+1]...)
+}
+
+// GetBlockByIndex returns the block at height
+func (bc *Blockchain) GetBlockByIndex(height int64) (Block, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if height < 0 || height >= int64(len(bc.chain)) {
+		return Block{}, false
+	}
+	return bc.chain[height], true
+}
+
+// GetBlockByHash returns the block with the given hash
+func (bc *Blockchain) GetBlockByHash(hash string) (Block, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	for _, b := range bc.chain {
+		if b.Hash == hash {
+			return b, true
+		}
+	}
+	return Block{}, false
+}
+
+// GetHeaders returns the headers of the blocks with index from to index to,
+// both included, clamped to the chain
+func (bc *Blockchain) GetHeaders(from, to int64) []BlockHeader {
+	blocks := bc.GetBlocks(from, to)
+	headers := make([]BlockHeader, len(blocks))
+	for i, b := range blocks {
+		headers[i] = bc.header(b)
+	}
+	return headers
+}
+
+// header is the block's header with the chain's difficulty
+func (bc *Blockchain) header(b Block) BlockHeader {
+	h := b.Header()
+	h.Difficulty = bc.consensus().Difficulty()
+	return h
+}
+
+// LastBlock returns the tip of the chain
+func (bc *Blockchain) LastBlock() Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.chain[len(bc.chain)-1]
+}
+
+// txPosition locates a mined transaction
+type txPosition struct {
+	block int64
+	pos   int
+}
+
+// indexBlock adds a block's transactions to the transaction index and the
+// wallet nonces. Every block appended to the chain must go through here. The
+// caller must hold the write lock.
+func (bc *Blockchain) indexBlock(b Block) {
+	for i, tx := range b.Transactions {
+		bc.txIndex[tx.ID] = txPosition{block: b.Index, pos: i}
+		bc.noteNonce(tx)
+	}
+}
+
+// GetTransactionByID looks up a transaction by ID, first in the chain through
+// the transaction index and then in the pending pool
+func (bc *Blockchain) GetTransactionByID(id string) (TxLocation, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if p, ok := bc.txIndex[id]; ok {
+		b := bc.chain[p.block]
+		return TxLocation{Transaction: b.Transactions[p.pos], Block: b, Position: p.pos}, true
+	}
+	for _, tx := range bc.pending {
+		if tx.ID == id {
+			return TxLocation{Transaction: tx, Pending: true}, true
+		}
+	}
+	return TxLocation{}, false
+}
+
+// GetPending returns the pending pool in arrival order
+func (bc *Blockchain) GetPending() []Transaction {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]Transaction(nil), bc.pending...)
+}
+
+// GetConfirmations is View.Confirmations for callers outside a View
+func (bc *Blockchain) GetConfirmations(height int64) int64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.confirmations(height)
+}
+
+// GetUTXO returns the UTXO with the given ID ("<txid>:<index>"), spent or not
+func (bc *Blockchain) GetUTXO(id string) (UTXO, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	u, ok := bc.utxos[id]
+	return u, ok
+}
+
+// GetUTXOs returns every UTXO, spent ones included
+func (bc *Blockchain) GetUTXOs() []UTXO {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	all := make([]UTXO, 0, len(bc.utxos))
+	for _, u := range bc.utxos {
+		all = append(all, u)
+	}
+	return all
+}
+
+// GetWalletUTXOs is View.OwnedUTXOs for callers outside a View
+func (bc *Blockchain) GetWalletUTXOs(walletID string) []UTXO {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.ownedUTXOs(walletID)
+}
+
+// UTXOCount is the number of UTXOs, spent ones included
+func (bc *Blockchain) UTXOCount() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return len(bc.utxos)
+}
+
+// View is a consistent read of the chain, the pending pool and the UTXO set.
+// It is only valid inside the function passed to Blockchain.View, and hands
+// out values rather than the chain's own slices and maps.
+type View struct {
+	bc *Blockchain
+}
+
+// View calls fn with the read lock held. fn must not call the Blockchain's
+// own methods, which take the lock again.
+func (bc *Blockchain) View(fn func(v View)) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	fn(View{bc: bc})
+}
+
+// Height is the index of the tip
+func (v View) Height() int64 {
+	return int64(len(v.bc.chain) - 1)
+}
+
+// Block returns the block at height, which must be on the chain
+func (v View) Block(height int64) Block {
+	return v.bc.chain[height]
+}
+
+// Blocks yields the blocks from genesis to the tip
+func (v View) Blocks() iter.Seq[Block] {
+	return func(yield func(Block) bool) {
+		for _, b := range v.bc.chain {
+			if !yield(b) {
+				return
+			}
+		}
+	}
+}
+
+// Pending yields the pending pool in arrival order
+func (v View) Pending() iter.Seq[Transaction] {
+	return func(yield func(Transaction) bool) {
+		for _, tx := range v.bc.pending {
+			if !yield(tx) {
+				return
+			}
+		}
+	}
+}
+
+// UTXO returns the UTXO with the given ID, spent or not
+func (v View) UTXO(id string) (UTXO, bool) {
+	u, ok := v.bc.utxos[id]
+	return u, ok
+}
+
+// UTXOs yields every UTXO, spent ones included, in no particular order
+func (v View) UTXOs() iter.Seq[UTXO] {
+	return func(yield func(UTXO) bool) {
+		for _, u := range v.bc.utxos {
+			if !yield(u) {
+				return
+			}
+		}
+	}
+}
+
+// OwnedUTXOs returns a wallet's coin UTXOs, spent ones included, in the order
+// they were created or loaded
+func (v View) OwnedUTXOs(walletID string) []UTXO {
+	return v.bc.ownedUTXOs(walletID)
+}
+
+// OwnedAssetUTXOs is OwnedUTXOs for one asset; the empty asset ID is the coin
+func (v View) OwnedAssetUTXOs(walletID, assetID string) []UTXO {
+	return v.bc.ownedAssetUTXOs(walletID, assetID)
+}
+
+// HeldAssets returns the assets other than the coin among a wallet's unspent
+// UTXOs, sorted
+func (v View) HeldAssets(walletID string) []string {
+	var assets []string
+	for _, id := range v.bc.byOwner[walletID] {
+		if u := v.bc.utxos[id]; u.AssetID != "" && !u.Spent && !slices.Contains(assets, u.AssetID) {
+			assets = append(assets, u.AssetID)
+		}
+	}
+	slices.Sort(assets)
+	return assets
+}
+
+// Output returns output index of transaction txID, from the UTXO set or, once
+// pruned, from the mined transaction
+func (v View) Output(txID string, index int) (UTXO, bool) {
+	return v.bc.output(txID, index)
+}
+
+// Confirmations returns the number of confirmations of the block at height
+func (v View) Confirmations(height int64) int64 {
+	return v.bc.confirmations(height)
+}
+
+// UTXOConfirmations returns the confirmations of the block that created u, or
+// -1 for faucet UTXOs
+func (v View) UTXOConfirmations(u UTXO) int64 {
+	return v.bc.utxoConfirmations(u)
+}
+
+// Confirmed reports whether u has reached the spend confirmation threshold
+func (v View) Confirmed(u UTXO) bool {
+	return v.bc.confirmed(u)
+}
+
+// Spendable reports whether u is unspent, confirmed and free of locks
+func (v View) Spendable(u UTXO) bool {
+	return v.bc.spendable(u)
+}
+
+// Nonce returns the highest nonce the wallet used in a mined or pending
+// transaction
+func (v View) Nonce(walletID string) uint64 {
+	return v.bc.nonce(walletID)
+}
+
+// PrunedHeight is the highest block whose spent outputs may have been pruned;
+// 0 when nothing was
+func (v View) PrunedHeight() int64 {
+	return v.bc.prunedHeight
+}
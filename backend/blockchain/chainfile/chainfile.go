@@ -0,0 +1,208 @@
+// Package chainfile reads and writes chain exports: every block of a chain
+// and the snapshot of its tip, in a versioned file that backs a node up,
+// moves its chain to another storage backend or bootstraps a new node.
+//
+// An export is a stream of records: one Header, every block from genesis up,
+// then the Snapshot of the tip. A stream that ends before the snapshot was
+// cut short and is refused. Records are written one at a time, so an export
+// can be streamed as it is produced.
+//
+// Two encodings carry the same records. EncodingJSON is NDJSON, one object per
+// line holding one of "header", "block" or "snapshot", with blocks and
+// snapshots in the JSON of the REST API. EncodingProtobuf is a stream of
+// chain.v1.Record messages (proto/chain.proto), each preceded by its size as
+// a varint.
+package chainfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/proto/chainpb"
+)
+
+// Format and Version identify the export layout in every Header
+const (
+	Format  = "chain-export"
+	Version = 1
+)
+
+// Encodings
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// ContentType returns the media type an encoding is served as
+func ContentType(encoding string) string {
+	if encoding == EncodingProtobuf {
+		return "application/x-protobuf"
+	}
+	return "application/x-ndjson"
+}
+
+// maxRecordBytes bounds one protobuf record, a block or the snapshot
+const maxRecordBytes = 256 << 20
+
+// ErrInvalid is wrapped by every error about the contents of an export, as
+// opposed to errors reading it
+var ErrInvalid = errors.New("invalid chain export")
+
+// Header describes the chain an export holds and how its blocks were
+// produced
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	Height     int64     `json:"height"`
+	TipHash    string    `json:"tip_hash"`
+	Consensus  string    `json:"consensus"`            // pow or poa
+	Difficulty string    `json:"difficulty,omitempty"` // under proof of work
+	Validators []string  `json:"validators,omitempty"` // under proof of authority, in turn order
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Export is a chain read from an export
+type Export struct {
+	Header   Header
+	Blocks   []blockchain.Block
+	Snapshot blockchain.Snapshot
+}
+
+// NewHeader describes the chain from genesis to tip, produced under c
+func NewHeader(tip blockchain.Block, c blockchain.Consensus) Header {
+	h := Header{
+		Format:     Format,
+		Version:    Version,
+		Height:     tip.Index,
+		TipHash:    tip.Hash,
+		Consensus:  c.Mode(),
+		Difficulty: c.Difficulty(),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if a, ok := c.(blockchain.Authority); ok {
+		h.Validators = a.Validators
+	}
+	return h
+}
+
+// jsonRecord is one line of EncodingJSON; exactly one field is set
+type jsonRecord struct {
+	Header   *Header              `json:"header,omitempty"`
+	Block    *blockchain.Block    `json:"block,omitempty"`
+	Snapshot *blockchain.Snapshot `json:"snapshot,omitempty"`
+}
+
+// Write writes an export of blocks, genesis first, and the snapshot of the
+// last of them to w in encoding
+func Write(w io.Writer, encoding string, h Header, blocks []blockchain.Block, snap blockchain.Snapshot) error {
+	bw := bufio.NewWriter(w)
+	var write func(jsonRecord) error
+	switch encoding {
+	case EncodingJSON:
+		enc := json.NewEncoder(bw)
+		write = func(rec jsonRecord) error { return enc.Encode(rec) }
+	case EncodingProtobuf:
+		write = func(rec jsonRecord) error {
+			_, err := protodelim.MarshalTo(bw, toProto(rec))
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown chain export encoding %q", encoding)
+	}
+
+	if err := write(jsonRecord{Header: &h}); err != nil {
+		return err
+	}
+	for i := range blocks {
+		if err := write(jsonRecord{Block: &blocks[i]}); err != nil {
+			return err
+		}
+	}
+	if err := write(jsonRecord{Snapshot: &snap}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Read reads an export in encoding and checks that it is whole: a header of
+// this Format and Version, blocks numbered from genesis up to the header's
+// tip, and the snapshot of that tip. The blocks themselves are checked when
+// they are imported; see Blockchain.Import.
+func Read(r io.Reader, encoding string) (*Export, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	var next func() (jsonRecord, error)
+	switch encoding {
+	case EncodingJSON:
+		dec := json.NewDecoder(br)
+		dec.DisallowUnknownFields()
+		next = func() (rec jsonRecord, err error) {
+			err = dec.Decode(&rec)
+			return rec, err
+		}
+	case EncodingProtobuf:
+		opts := protodelim.UnmarshalOptions{MaxSize: maxRecordBytes}
+		next = func() (jsonRecord, error) {
+			var rec chainpb.Record
+			if err := opts.UnmarshalFrom(br, &rec); err != nil {
+				return jsonRecord{}, err
+			}
+			return fromProto(&rec), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown chain export encoding %q", encoding)
+	}
+
+	exp := &Export{}
+	var haveHeader, haveSnapshot bool
+	for n := 1; ; n++ {
+		rec, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalid, n, err)
+		}
+		switch {
+		case haveSnapshot:
+			return nil, fmt.Errorf("%w: record %d follows the snapshot", ErrInvalid, n)
+		case rec.Header != nil:
+			if haveHeader {
+				return nil, fmt.Errorf("%w: record %d is a second header", ErrInvalid, n)
+			}
+			if rec.Header.Format != Format || rec.Header.Version != Version {
+				return nil, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalid, rec.Header.Format, rec.Header.Version)
+			}
+			exp.Header, haveHeader = *rec.Header, true
+		case !haveHeader:
+			return nil, fmt.Errorf("%w: record %d comes before the header", ErrInvalid, n)
+		case rec.Block != nil:
+			if want := int64(len(exp.Blocks)); rec.Block.Index != want {
+				return nil, fmt.Errorf("%w: record %d is block %d, expected block %d", ErrInvalid, n, rec.Block.Index, want)
+			}
+			exp.Blocks = append(exp.Blocks, *rec.Block)
+		case rec.Snapshot != nil:
+			exp.Snapshot, haveSnapshot = *rec.Snapshot, true
+		default:
+			return nil, fmt.Errorf("%w: record %d is empty", ErrInvalid, n)
+		}
+	}
+
+	switch {
+	case !haveHeader:
+		return nil, fmt.Errorf("%w: the export is empty", ErrInvalid)
+	case !haveSnapshot:
+		return nil, fmt.Errorf("%w: the export ends after %d blocks, before its snapshot; it was cut short", ErrInvalid, len(exp.Blocks))
+	case int64(len(exp.Blocks)) != exp.Header.Height+1:
+		return nil, fmt.Errorf("%w: the header promises %d blocks, the export holds %d", ErrInvalid, exp.Header.Height+1, len(exp.Blocks))
+	case exp.Blocks[len(exp.Blocks)-1].Hash != exp.Header.TipHash:
+		return nil, fmt.Errorf("%w: the last block does not have the header's tip hash", ErrInvalid)
+	}
+	return exp, nil
+}
@@ -0,0 +1,204 @@
+package chainfile
+
+import (
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/proto/chainpb"
+)
+
+// Conversions between the records and their chain.v1 messages. Every field
+// is carried over, so blocks still hash to their stored hash after a round
+// trip.
+
+func toProto(rec jsonRecord) *chainpb.Record {
+	switch {
+	case rec.Header != nil:
+		h := rec.Header
+		return &chainpb.Record{Record: &chainpb.Record_Header{Header: &chainpb.Header{
+			Format:     h.Format,
+			Version:    int32(h.Version),
+			Height:     h.Height,
+			TipHash:    h.TipHash,
+			Consensus:  h.Consensus,
+			Difficulty: h.Difficulty,
+			Validators: h.Validators,
+			CreatedAt:  h.CreatedAt.UnixNano(),
+		}}}
+	case rec.Block != nil:
+		return &chainpb.Record{Record: &chainpb.Record_Block{Block: blockToProto(*rec.Block)}}
+	case rec.Snapshot != nil:
+		s := rec.Snapshot
+		ps := &chainpb.Snapshot{
+			Version:      int32(s.Version),
+			Height:       s.Height,
+			Hash:         s.Hash,
+			Issued:       s.Issued,
+			PrunedHeight: s.PrunedHeight,
+			Nonces:       s.Nonces,
+			Utxos:        make([]*chainpb.UTXO, len(s.UTXOs)),
+			CreatedAt:    s.CreatedAt.UnixNano(),
+		}
+		for i, u := range s.UTXOs {
+			ps.Utxos[i] = utxoToProto(u)
+		}
+		return &chainpb.Record{Record: &chainpb.Record_Snapshot{Snapshot: ps}}
+	}
+	return &chainpb.Record{}
+}
+
+func fromProto(rec *chainpb.Record) jsonRecord {
+	switch r := rec.Record.(type) {
+	case *chainpb.Record_Header:
+		h := r.Header
+		return jsonRecord{Header: &Header{
+			Format:     h.Format,
+			Version:    int(h.Version),
+			Height:     h.Height,
+			TipHash:    h.TipHash,
+			Consensus:  h.Consensus,
+			Difficulty: h.Difficulty,
+			Validators: h.Validators,
+			CreatedAt:  time.Unix(0, h.CreatedAt).UTC(),
+		}}
+	case *chainpb.Record_Block:
+		b := blockFromProto(r.Block)
+		return jsonRecord{Block: &b}
+	case *chainpb.Record_Snapshot:
+		s := r.Snapshot
+		snap := &blockchain.Snapshot{
+			Version:      int(s.Version),
+			Height:       s.Height,
+			Hash:         s.Hash,
+			Issued:       s.Issued,
+			PrunedHeight: s.PrunedHeight,
+			Nonces:       s.Nonces,
+			UTXOs:        make([]blockchain.UTXO, len(s.Utxos)),
+			CreatedAt:    time.Unix(0, s.CreatedAt).UTC(),
+		}
+		if snap.Nonces == nil {
+			snap.Nonces = map[string]uint64{}
+		}
+		for i, u := range s.Utxos {
+			snap.UTXOs[i] = utxoFromProto(u)
+		}
+		return jsonRecord{Snapshot: snap}
+	}
+	return jsonRecord{}
+}
+
+func blockToProto(b blockchain.Block) *chainpb.Block {
+	pb := &chainpb.Block{
+		Version:      int32(b.Version),
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		Transactions: make([]*chainpb.Transaction, len(b.Transactions)),
+		PreviousHash: b.PreviousHash,
+		Nonce:        b.Nonce,
+		Hash:         b.Hash,
+		MerkleRoot:   b.MerkleRoot,
+		Signer:       b.Signer,
+		Signature:    b.Signature,
+	}
+	for i, tx := range b.Transactions {
+		pt := &chainpb.Transaction{
+			Version:    int32(tx.Version),
+			Id:         tx.ID,
+			SenderId:   tx.SenderID,
+			ReceiverId: tx.ReceiverID,
+			AssetId:    tx.AssetID,
+			Amount:     tx.Amount,
+			Fee:        tx.Fee,
+			Nonce:      tx.Nonce,
+			Note:       tx.Note,
+			Timestamp:  tx.Timestamp,
+			Pubkey:     tx.PubKey,
+			Signature:  tx.Signature,
+			Inputs:     make([]*chainpb.UTXORef, len(tx.Inputs)),
+			Outputs:    make([]*chainpb.UTXO, len(tx.Outputs)),
+			Type:       tx.Type,
+		}
+		for j, in := range tx.Inputs {
+			pt.Inputs[j] = &chainpb.UTXORef{Txid: in.TxID, Index: int64(in.Index), Preimage: in.Preimage}
+		}
+		for j, out := range tx.Outputs {
+			pt.Outputs[j] = utxoToProto(out)
+		}
+		pb.Transactions[i] = pt
+	}
+	return pb
+}
+
+func blockFromProto(pb *chainpb.Block) blockchain.Block {
+	b := blockchain.Block{
+		Version:      int(pb.Version),
+		Index:        pb.Index,
+		Timestamp:    pb.Timestamp,
+		Transactions: make([]blockchain.Transaction, len(pb.Transactions)),
+		PreviousHash: pb.PreviousHash,
+		Nonce:        pb.Nonce,
+		Hash:         pb.Hash,
+		MerkleRoot:   pb.MerkleRoot,
+		Signer:       pb.Signer,
+		Signature:    pb.Signature,
+	}
+	for i, pt := range pb.Transactions {
+		tx := blockchain.Transaction{
+			Version:    int(pt.Version),
+			ID:         pt.Id,
+			SenderID:   pt.SenderId,
+			ReceiverID: pt.ReceiverId,
+			AssetID:    pt.AssetId,
+			Amount:     pt.Amount,
+			Fee:        pt.Fee,
+			Nonce:      pt.Nonce,
+			Note:       pt.Note,
+			Timestamp:  pt.Timestamp,
+			PubKey:     pt.Pubkey,
+			Signature:  pt.Signature,
+			Type:       pt.Type,
+			Inputs:     make([]blockchain.UTXORef, len(pt.Inputs)),
+			Outputs:    make([]blockchain.UTXO, len(pt.Outputs)),
+		}
+		for j, in := range pt.Inputs {
+			tx.Inputs[j] = blockchain.UTXORef{TxID: in.Txid, Index: int(in.Index), Preimage: in.Preimage}
+		}
+		for j, out := range pt.Outputs {
+			tx.Outputs[j] = utxoFromProto(out)
+		}
+		b.Transactions[i] = tx
+	}
+	return b
+}
+
+func utxoToProto(u blockchain.UTXO) *chainpb.UTXO {
+	return &chainpb.UTXO{
+		Id:          u.ID,
+		Owner:       u.Owner,
+		AssetId:     u.AssetID,
+		Amount:      u.Amount,
+		OriginTx:    u.OriginTx,
+		Index:       int64(u.Index),
+		Spent:       u.Spent,
+		Height:      u.Height,
+		SpentHeight: u.SpentHeight,
+		LockTime:    u.LockTime,
+		HashLock:    u.HashLock,
+		RefundTo:    u.RefundTo,
+	}
+}
+
+func utxoFromProto(pu *chainpb.UTXO) blockchain.UTXO {
+	return blockchain.UTXO{
+		ID:          pu.Id,
+		Owner:       pu.Owner,
+		AssetID:     pu.AssetId,
+		Amount:      pu.Amount,
+		OriginTx:    pu.OriginTx,
+		Index:       int(pu.Index),
+		Spent:       pu.Spent,
+		Height:      pu.Height,
+		SpentHeight: pu.SpentHeight,
+		Lock:        blockchain.Lock{LockTime: pu.LockTime, HashLock: pu.HashLock, RefundTo: pu.RefundTo},
+	}
+}
@@ -0,0 +1,82 @@
+package blockchain
+
+import "time"
+
+// ConfirmationPolicy sets how many confirmations (the containing block plus
+// every block mined on top of it) an operation waits for. A value of 1 means
+// the transaction only needs to be mined.
+type ConfirmationPolicy struct {
+	Spend   int `json:"spend"`   // before received funds can be spent or count towards the balance
+	Webhook int `json:"webhook"` // before tx.confirmed is published
+	Invoice int `json:"invoice"` // before a payment request is marked paid
+}
+
+// DefaultConfirmationPolicy requires a single confirmation for everything
+func DefaultConfirmationPolicy() ConfirmationPolicy {
+	return ConfirmationPolicy{Spend: 1, Webhook: 1, Invoice: 1}
+}
+
+// confirmations returns the number of confirmations of the block at height.
+// The caller must hold the read lock.
+func (bc *Blockchain) confirmations(height int64) int64 {
+	tip := int64(len(bc.chain) - 1)
+	if height < 0 || height > tip {
+		return 0
+	}
+	return tip - height + 1
+}
+
+// utxoConfirmations returns the confirmations of the block that created u, or
+// -1 for faucet UTXOs, which are created off-chain (height 0) and are always
+// spendable. The caller must hold the read lock.
+func (bc *Blockchain) utxoConfirmations(u UTXO) int64 {
+	if u.Height == 0 {
+		return -1
+	}
+	return bc.confirmations(u.Height)
+}
+
+// confirmed reports whether u has reached the spend confirmation threshold.
+// The caller must hold the read lock.
+func (bc *Blockchain) confirmed(u UTXO) bool {
+	return u.Height == 0 || bc.confirmations(u.Height) >= int64(bc.MinConfirmations.Spend)
+}
+
+// spendable reports whether u is unspent, confirmed and free of locks, so its
+// owner can spend it with a signature alone. The caller must hold the read
+// lock.
+func (bc *Blockchain) spendable(u UTXO) bool {
+	return !u.Spent && !u.LockedAt(time.Now().Unix()) && bc.confirmed(u)
+}
+
+// GetPendingBalance returns the unspent amount that has not yet reached the
+// spend confirmation threshold. Locked outputs count towards the locked
+// balance instead.
+func (bc *Blockchain) GetPendingBalance(walletID string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	now := time.Now().Unix()
+	var sum uint64
+	for _, ut := range bc.ownedUTXOs(walletID) {
+		if !ut.Spent && !ut.LockedAt(now) && !bc.confirmed(ut) {
+			sum += ut.Amount
+		}
+	}
+	return sum
+}
+
+// GetLockedBalance returns the unspent amount the wallet owns under a lock it
+// cannot yet open with a signature alone: before its lock time, or behind a
+// hash lock
+func (bc *Blockchain) GetLockedBalance(walletID string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	now := time.Now().Unix()
+	var sum uint64
+	for _, ut := range bc.ownedUTXOs(walletID) {
+		if !ut.Spent && ut.LockedAt(now) {
+			sum += ut.Amount
+		}
+	}
+	return sum
+}
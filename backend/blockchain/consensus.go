@@ -0,0 +1,150 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blockchain-backend/wallet"
+)
+
+// Consensus modes selectable with CONSENSUS
+const (
+	ConsensusPoW = "pow" // proof of work: any wallet mines, the default
+	ConsensusPoA = "poa" // proof of authority: validators take turns
+)
+
+// ErrNotProducer is returned by MineContext when the consensus gives the
+// next block to another wallet than the miner
+var ErrNotProducer = errors.New("the next block is another validator's to produce")
+
+// ErrBlockSignature is returned for a block the consensus names a producer
+// for that is not signed by that producer's key
+var ErrBlockSignature = errors.New("block is not signed by its producer")
+
+// BlockSigner signs a block hash with the producing wallet's key, returning
+// the hex public key and signature
+type BlockSigner func(hash string) (pubKey, signature string, err error)
+
+// Consensus decides which wallet produces each block and seals it. A nil
+// Blockchain.Consensus is ProofOfWork with DifficultyPref and Miner.
+type Consensus interface {
+	// Mode is ConsensusPoW or ConsensusPoA
+	Mode() string
+	// Producer returns the wallet whose turn the block at height is, elapsed
+	// after the block before it, or "" when any wallet may produce it. A named
+	// producer signs its blocks.
+	Producer(height int64, elapsed time.Duration) string
+	// Difficulty is the prefix block hashes must start with, "" for none
+	Difficulty() string
+	// Seal sets b.Nonce and b.Hash as Miner.Seal does
+	Seal(ctx context.Context, b *Block, start int64) (attempts int64, err error)
+}
+
+// ProofOfWork lets any wallet produce a block, once Miner finds a nonce
+// whose block hash starts with Prefix
+type ProofOfWork struct {
+	Prefix string
+	Miner  Miner
+}
+
+// Mode implements Consensus
+func (ProofOfWork) Mode() string { return ConsensusPoW }
+
+// Producer implements Consensus: any wallet may mine
+func (ProofOfWork) Producer(int64, time.Duration) string { return "" }
+
+// Difficulty implements Consensus
+func (p ProofOfWork) Difficulty() string { return p.Prefix }
+
+// Seal implements Consensus
+func (p ProofOfWork) Seal(ctx context.Context, b *Block, start int64) (int64, error) {
+	return p.Miner.Seal(ctx, b, p.Prefix, start)
+}
+
+// DefaultTurnTimeout is how long a validator has to produce its block before
+// the turn passes to the next one
+const DefaultTurnTimeout = 30 * time.Second
+
+// Authority is proof of authority: the approved Validators take turns in
+// order, the block at height h falling to Validators[h % len(Validators)].
+// Their turn is their authority, so blocks are sealed without proof of work
+// but signed by the validator's key. A validator that lets TurnTimeout pass
+// after the previous block loses the turn to the next one, and so on, so an
+// offline validator slows the chain down rather than halting it.
+type Authority struct {
+	Validators  []string
+	TurnTimeout time.Duration // 0 waits for each validator forever
+}
+
+// Mode implements Consensus
+func (Authority) Mode() string { return ConsensusPoA }
+
+// Producer implements Consensus
+func (a Authority) Producer(height int64, elapsed time.Duration) string {
+	if len(a.Validators) == 0 {
+		return ""
+	}
+	turn := height
+	if a.TurnTimeout > 0 && elapsed > 0 {
+		turn += int64(elapsed / a.TurnTimeout)
+	}
+	return a.Validators[turn%int64(len(a.Validators))]
+}
+
+// Difficulty implements Consensus: there is none
+func (Authority) Difficulty() string { return "" }
+
+// Seal implements Consensus, hashing the block once at nonce start
+func (Authority) Seal(ctx context.Context, b *Block, start int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.Nonce = start
+	b.Hash = HashBlock(*b)
+	return 1, nil
+}
+
+// consensus returns the Consensus blocks are produced under
+func (bc *Blockchain) consensus() Consensus {
+	if bc.Consensus != nil {
+		return bc.Consensus
+	}
+	return ProofOfWork{Prefix: bc.DifficultyPref, Miner: bc.miner()}
+}
+
+// ActiveConsensus returns the Consensus blocks are produced under
+func (bc *Blockchain) ActiveConsensus() Consensus {
+	return bc.consensus()
+}
+
+// NextProducer returns the wallet whose turn the next block is, were it
+// produced now, or "" when any wallet may produce it
+func (bc *Blockchain) NextProducer() string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	tip := bc.chain[len(bc.chain)-1]
+	return bc.consensus().Producer(tip.Index+1, BlockInterval(tip.Timestamp, time.Now().Unix()))
+}
+
+// BlockInterval is the time between a block made at prev and one made at at,
+// both Unix seconds, as Consensus.Producer takes it
+func BlockInterval(prev, at int64) time.Duration {
+	return time.Duration(at-prev) * time.Second
+}
+
+// VerifyBlockSignature checks that b is signed, over its hash, by the key of
+// producer
+func VerifyBlockSignature(b Block, producer string) error {
+	if b.Signer == "" || b.Signature == "" {
+		return fmt.Errorf("%w: block %d carries no signature from %s", ErrBlockSignature, b.Index, producer)
+	}
+	if id, err := wallet.WalletIDFromPub(b.Signer); err != nil || id != producer {
+		return fmt.Errorf("%w: block %d is signed by another key than %s's", ErrBlockSignature, b.Index, producer)
+	}
+	if ok, err := wallet.VerifySignature(b.Signer, []byte(b.Hash), b.Signature); err != nil || !ok {
+		return fmt.Errorf("%w: block %d has an invalid signature", ErrBlockSignature, b.Index)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Canonical binary encoding of blocks and transactions, specified in
+// spec/README.md. Integers are fixed width and big-endian, strings are a
+// uint32 byte length followed by the UTF-8 bytes, and lists are a uint32
+// count followed by their items. Every encoding starts with a version.
+
+// Block versions
+const (
+	BlockVersionLegacy = 1 // hashed over the "|"-joined text of HashBlockLegacy
+	BlockVersionCoins  = 2 // hashed over EncodeHeader, with amounts in whole coins
+	BlockVersion       = 3 // hashed as BlockVersionCoins, with amounts in units; what Mine produces
+)
+
+// Transaction versions, which lead every encoded transaction
+const (
+	TxVersionLegacy = 1 // signs the JSON summary of sender, receiver, amount, timestamp and note
+	TxVersion       = 2 // signs SigningPayload, the whole transaction but its ID and signature
+	TxVersionAsset  = 3 // as TxVersion with the asset ID after the type; moves an asset other than the coin
+	TxVersionLock   = 4 // as TxVersionAsset with a preimage per input and a Lock per output
+)
+
+// ErrMalformedEncoding is returned when bytes are not a canonical encoding
+var ErrMalformedEncoding = errors.New("malformed encoding")
+
+// HashBlock computes the block hash that mining searches a nonce for: the
+// SHA-256 of the encoded header, or the legacy hash for blocks mined before
+// versioning. The stored Hash is not part of the input.
+func HashBlock(b Block) string {
+	if b.Version <= BlockVersionLegacy {
+		return HashBlockLegacy(b)
+	}
+	h := sha256.Sum256(EncodeHeader(b.Header()))
+	return hex.EncodeToString(h[:])
+}
+
+// EncodeHeader encodes a block header: version, index, timestamp, previous
+// hash, merkle root and nonce. Difficulty and transaction count are not part
+// of it; the merkle root commits to the transactions.
+func EncodeHeader(h BlockHeader) []byte {
+	var e encoder
+	e.uint32(uint32(h.Version))
+	e.int64(h.Index)
+	e.int64(h.Timestamp)
+	e.string(h.PreviousHash)
+	e.string(h.MerkleRoot)
+	e.int64(h.Nonce)
+	return e.buf
+}
+
+// SigningPayload returns the bytes a transaction's signature covers. For
+// TxVersion and later that is the encoding less the ID and the signature, so
+// inputs, outputs, locks, fee, nonce and asset cannot be changed after
+// signing. Legacy transactions
+// sign only a JSON summary; they still verify, but their inputs, outputs and
+// nonce are not covered.
+func SigningPayload(tx Transaction) []byte {
+	if tx.Version <= TxVersionLegacy {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"sender": tx.SenderID, "receiver": tx.ReceiverID, "amount": tx.Amount, "timestamp": tx.Timestamp, "note": tx.Note,
+		})
+		return payload
+	}
+	var e encoder
+	e.uint32(uint32(tx.Version))
+	e.string(tx.Type)
+	e.asset(tx)
+	e.string(tx.SenderID)
+	e.string(tx.ReceiverID)
+	e.uint64(tx.Amount)
+	e.uint64(tx.Fee)
+	e.uint64(tx.Nonce)
+	e.int64(tx.Timestamp)
+	e.string(tx.Note)
+	e.string(tx.PubKey)
+	e.inputsOutputs(tx)
+	return e.buf
+}
+
+// TxID derives a transaction's ID from its content: the lowercase hex SHA-256
+// of SigningPayload. Anyone holding the transaction can recompute it, and two
+// transactions only share an ID when they are the same transaction. It holds
+// for TxVersion and later; legacy transactions keep the IDs the server gave
+// them.
+func TxID(tx Transaction) string {
+	h := sha256.Sum256(SigningPayload(tx))
+	return hex.EncodeToString(h[:])
+}
+
+// AssignID sets a transaction's ID to TxID and labels its outputs with it.
+// Call it once the transaction is complete, before it is signed or queued.
+func AssignID(tx *Transaction) {
+	tx.ID = TxID(*tx)
+	for i := range tx.Outputs {
+		tx.Outputs[i].OriginTx = tx.ID
+		tx.Outputs[i].Index = i
+	}
+}
+
+// EncodeTransaction encodes a transaction. Outputs are encoded as owner and
+// amount, and from TxVersionLock on their lock; their index is their position
+// and their origin the transaction.
+func EncodeTransaction(tx Transaction) []byte {
+	var e encoder
+	e.transaction(tx)
+	return e.buf
+}
+
+// DecodeTransaction is the inverse of EncodeTransaction
+func DecodeTransaction(data []byte) (Transaction, error) {
+	d := decoder{buf: data}
+	tx := d.transaction()
+	if err := d.finish(); err != nil {
+		return Transaction{}, err
+	}
+	return tx, nil
+}
+
+// EncodeBlock encodes a block for transfer: its header, its hash, its
+// producer's signature and its transactions
+func EncodeBlock(b Block) []byte {
+	e := encoder{buf: EncodeHeader(b.Header())}
+	e.string(b.Hash)
+	e.string(b.Signer)
+	e.string(b.Signature)
+	e.uint32(uint32(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		e.transaction(tx)
+	}
+	return e.buf
+}
+
+// DecodeBlock is the inverse of EncodeBlock. It does not check the hash or
+// merkle root; chainverify does.
+func DecodeBlock(data []byte) (Block, error) {
+	d := decoder{buf: data}
+	b := Block{
+		Version:      int(d.uint32()),
+		Index:        d.int64(),
+		Timestamp:    d.int64(),
+		PreviousHash: d.string(),
+		MerkleRoot:   d.string(),
+		Nonce:        d.int64(),
+		Hash:         d.string(),
+		Signer:       d.string(),
+		Signature:    d.string(),
+	}
+	b.Transactions = make([]Transaction, d.count(txMinSize))
+	for i := range b.Transactions {
+		b.Transactions[i] = d.transaction()
+	}
+	if err := d.finish(); err != nil {
+		return Block{}, err
+	}
+	return b, nil
+}
+
+// Smallest encodings, to reject counts the remaining bytes cannot hold
+const (
+	txMinSize     = 4 + 7*4 + 4*8 + 2*4
+	inputMinSize  = 4 + 4
+	outputMinSize = 4 + 8
+)
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) int64(v int64) {
+	e.uint64(uint64(v))
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) transaction(tx Transaction) {
+	e.uint32(uint32(txVersion(tx)))
+	e.string(tx.ID)
+	e.string(tx.Type)
+	e.asset(tx)
+	e.string(tx.SenderID)
+	e.string(tx.ReceiverID)
+	e.uint64(tx.Amount)
+	e.uint64(tx.Fee)
+	e.uint64(tx.Nonce)
+	e.int64(tx.Timestamp)
+	e.string(tx.Note)
+	e.string(tx.PubKey)
+	e.string(tx.Signature)
+	e.inputsOutputs(tx)
+}
+
+// asset writes the asset ID, which only TxVersionAsset transactions carry
+func (e *encoder) asset(tx Transaction) {
+	if tx.Version >= TxVersionAsset {
+		e.string(tx.AssetID)
+	}
+}
+
+func (e *encoder) inputsOutputs(tx Transaction) {
+	locks := tx.Version >= TxVersionLock
+	e.uint32(uint32(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		e.string(in.TxID)
+		e.uint32(uint32(in.Index))
+		if locks {
+			e.string(in.Preimage)
+		}
+	}
+	e.uint32(uint32(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		e.string(out.Owner)
+		e.uint64(out.Amount)
+		if locks {
+			e.int64(out.LockTime)
+			e.string(out.HashLock)
+			e.string(out.RefundTo)
+		}
+	}
+}
+
+// decoder reads an encoding; the first error sticks and later reads return
+// zero values
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf) {
+		d.err = fmt.Errorf("%w: unexpected end of data", ErrMalformedEncoding)
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	return int64(d.uint64())
+}
+
+func (d *decoder) string() string {
+	n := d.uint32()
+	if n > math.MaxInt32 {
+		d.fail("string length %d", n)
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// count reads a list length, failing if the rest of the data cannot hold
+// that many items of at least minSize bytes
+func (d *decoder) count(minSize int) int {
+	n := d.uint32()
+	if d.err == nil && uint64(n)*uint64(minSize) > uint64(len(d.buf)) {
+		d.fail("list of %d items", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) transaction() Transaction {
+	v := d.uint32()
+	if d.err == nil && (v < TxVersionLegacy || v > TxVersionLock) {
+		d.fail("transaction version %d", v)
+		return Transaction{}
+	}
+	tx := Transaction{
+		Version: int(v),
+		ID:      d.string(),
+		Type:    d.string(),
+	}
+	if v >= TxVersionAsset {
+		tx.AssetID = d.string()
+	}
+	tx.SenderID = d.string()
+	tx.ReceiverID = d.string()
+	tx.Amount = d.uint64()
+	tx.Fee = d.uint64()
+	tx.Nonce = d.uint64()
+	tx.Timestamp = d.int64()
+	tx.Note = d.string()
+	tx.PubKey = d.string()
+	tx.Signature = d.string()
+	locks := v >= TxVersionLock
+	tx.Inputs = make([]UTXORef, d.count(inputMinSize))
+	for i := range tx.Inputs {
+		tx.Inputs[i] = UTXORef{TxID: d.string(), Index: int(d.uint32())}
+		if locks {
+			tx.Inputs[i].Preimage = d.string()
+		}
+	}
+	tx.Outputs = make([]UTXO, d.count(outputMinSize))
+	for i := range tx.Outputs {
+		tx.Outputs[i] = UTXO{Owner: d.string(), AssetID: tx.AssetID, Amount: d.uint64(), OriginTx: tx.ID, Index: i}
+		if locks {
+			tx.Outputs[i].Lock = Lock{LockTime: d.int64(), HashLock: d.string(), RefundTo: d.string()}
+		}
+	}
+	return tx
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrMalformedEncoding, fmt.Sprintf(format, args...))
+	}
+}
+
+// txVersion is the transaction's version; transactions from before
+// versioning have none and are TxVersionLegacy
+func txVersion(tx Transaction) int {
+	if tx.Version == 0 {
+		return TxVersionLegacy
+	}
+	return tx.Version
+}
+
+// finish returns the first error, or an error if bytes are left over
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.buf) > 0 {
+		d.fail("%d trailing bytes", len(d.buf))
+	}
+	return d.err
+}
@@ -0,0 +1,247 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrChainNotEmpty is returned by Import on a chain past its genesis block,
+// or holding pending transactions or UTXOs
+var ErrChainNotEmpty = errors.New("chain is not empty")
+
+// ErrInvalidImport wraps the reasons CheckImport refuses blocks
+var ErrInvalidImport = errors.New("blocks cannot be imported")
+
+// CheckImport reports whether Import would take blocks, genesis first, and
+// snap, the snapshot of the last of them, and returns the state of the tip
+// that Import loads. The chain must still be empty. The blocks must link up
+// from a genesis block, hash to their stored hash and merkle root and, past
+// genesis, satisfy the consensus: the difficulty prefix, or a coinbase paying
+// the validator whose turn the block was, signed with its key. Their
+// transactions are then replayed, each checked as block assembly checks it,
+// and the UTXO set, nonces and issued count are rebuilt from them; see
+// replayImport.
+func (bc *Blockchain) CheckImport(snap *Snapshot, blocks []Block) (Snapshot, error) {
+	if err := bc.checkImportBlocks(snap, blocks); err != nil {
+		return Snapshot{}, err
+	}
+	return bc.replayImport(snap, blocks)
+}
+
+// checkImportBlocks is the part of CheckImport that looks at the blocks alone
+func (bc *Blockchain) checkImportBlocks(snap *Snapshot, blocks []Block) error {
+	bc.mu.RLock()
+	empty := len(bc.chain) == 1 && len(bc.pending) == 0 && len(bc.utxos) == 0
+	cons := bc.consensus()
+	bc.mu.RUnlock()
+	if !empty {
+		return ErrChainNotEmpty
+	}
+
+	if len(blocks) == 0 {
+		return fmt.Errorf("%w: no blocks", ErrInvalidImport)
+	}
+	tip := blocks[len(blocks)-1]
+	switch {
+	case snap == nil:
+		return fmt.Errorf("%w: no snapshot", ErrInvalidImport)
+	case snap.Version != SnapshotVersion:
+		return fmt.Errorf("%w: unsupported snapshot version %d", ErrInvalidImport, snap.Version)
+	case snap.Height != tip.Index || snap.Hash != tip.Hash:
+		return fmt.Errorf("%w: the snapshot is of block %d, not the tip", ErrInvalidImport, snap.Height)
+	}
+
+	difficulty := cons.Difficulty()
+	for i, b := range blocks {
+		switch {
+		case b.Index != int64(i):
+			return fmt.Errorf("%w: block at position %d has index %d", ErrInvalidImport, i, b.Index)
+		case i == 0 && b.PreviousHash != "0":
+			return fmt.Errorf("%w: first block is not a genesis block", ErrInvalidImport)
+		case i == 0 && b.Version < BlockVersion:
+			return ErrWholeCoinChain
+		case i > 0 && b.PreviousHash != blocks[i-1].Hash:
+			return fmt.Errorf("%w: block %d does not link to block %d", ErrInvalidImport, i, i-1)
+		case bc.hashBlock(b) != b.Hash:
+			return fmt.Errorf("%w: block %d does not hash to its stored hash", ErrInvalidImport, i)
+		case bc.computeMerkle(b.Transactions) != b.MerkleRoot:
+			return fmt.Errorf("%w: block %d does not match its merkle root", ErrInvalidImport, i)
+		}
+		if i == 0 {
+			continue
+		}
+		if !strings.HasPrefix(b.Hash, difficulty) {
+			return fmt.Errorf("%w: block %d does not meet the difficulty %q", ErrInvalidImport, i, difficulty)
+		}
+		if producer := cons.Producer(b.Index, BlockInterval(blocks[i-1].Timestamp, b.Timestamp)); producer != "" {
+			if miner := coinbaseReceiver(b); miner != producer {
+				return fmt.Errorf("%w: block %d pays %q, but was validator %s's to produce", ErrInvalidImport, i, miner, producer)
+			}
+			if err := VerifyBlockSignature(b, producer); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Import replaces an empty chain with blocks and the state CheckImport
+// rebuilds from them. Pruned outputs come back, as on a node that never
+// pruned.
+func (bc *Blockchain) Import(snap *Snapshot, blocks []Block) error {
+	state, err := bc.CheckImport(snap, blocks)
+	if err != nil {
+		return err
+	}
+	_, err = bc.Restore(&state, blocks)
+	return err
+}
+
+// replayImport replays blocks on a scratch chain and returns the snapshot of
+// its tip. Every transaction must pass verify, with time locks judged at its
+// block's timestamp, and carry a fresh ID, which for current versions is its
+// content hash; every coinbase must come first and pay its miner at most the
+// subsidy and the block's fees. Faucet grants are made off the chain, so they
+// are the one part of snap taken as given, and only unspent: the blocks
+// decide which were spent. Any other output of snap must come from one of
+// the blocks; the blocks' own outputs are what the state holds.
+func (bc *Blockchain) replayImport(snap *Snapshot, blocks []Block) (Snapshot, error) {
+	bc.mu.RLock()
+	supply := bc.Supply
+	bc.mu.RUnlock()
+	r := &Blockchain{
+		utxos:   make(map[string]UTXO),
+		txIndex: make(map[string]txPosition),
+		byOwner: make(map[string][]string),
+		nonces:  make(map[string]uint64),
+		Supply:  supply,
+	}
+
+	// Grants and admin mints raise the issued count as well, but when they
+	// did so is not known; without them the subsidy is its highest possible
+	var granted, mined, minted uint64
+	for _, u := range snap.UTXOs {
+		if !isFaucetGrant(u) {
+			continue
+		}
+		if _, dup := r.utxos[u.ID]; dup {
+			return Snapshot{}, fmt.Errorf("%w: faucet grant %s is listed twice", ErrInvalidImport, u.ID)
+		}
+		u.Spent, u.SpentHeight, u.Height = false, 0, 0
+		r.putUTXO(u)
+		var ok bool
+		if granted, ok = AddAmounts(granted, u.Amount); !ok {
+			return Snapshot{}, fmt.Errorf("%w: faucet grants overflow", ErrInvalidImport)
+		}
+	}
+
+	for _, b := range blocks {
+		subsidy, mint, err := r.replayBlock(b, mined)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("%w: block %d: %v", ErrInvalidImport, b.Index, err)
+		}
+		mined += subsidy
+		minted += mint
+		r.chain = append(r.chain, b)
+		r.indexBlock(b)
+		r.applyUTXOs(b)
+	}
+
+	for _, u := range snap.UTXOs {
+		if _, ok := r.txIndex[u.OriginTx]; !ok && !isFaucetGrant(u) {
+			return Snapshot{}, fmt.Errorf("%w: output %s comes from no imported block and is no faucet grant", ErrInvalidImport, u.ID)
+		}
+	}
+	issued, ok := AddAmounts(mined, granted, minted)
+	if !ok || (supply.Cap > 0 && issued > supply.Cap) {
+		return Snapshot{}, fmt.Errorf("%w: the chain issues more coins than the supply cap of %d", ErrInvalidImport, supply.Cap)
+	}
+	r.issued = issued
+	return r.snapshotLocked(), nil
+}
+
+// replayBlock checks block b against the scratch chain r, before it is
+// applied, given the coins mined before it. It returns the subsidy its
+// coinbase took and the coins its admin mints created.
+func (r *Blockchain) replayBlock(b Block, mined uint64) (subsidy, minted uint64, err error) {
+	if b.Index == 0 {
+		if len(b.Transactions) > 0 {
+			return 0, 0, errors.New("genesis block has transactions")
+		}
+		return 0, 0, nil
+	}
+	if len(b.Transactions) == 0 || b.Transactions[0].SenderID != "COINBASE" {
+		return 0, 0, errors.New("first transaction is not the coinbase")
+	}
+
+	claimed := make(map[string]string)
+	ids := make(map[string]bool)
+	var fees uint64
+	for i, tx := range b.Transactions {
+		if _, dup := r.txIndex[tx.ID]; dup || ids[tx.ID] {
+			return 0, 0, fmt.Errorf("%w: %s", ErrDuplicateTx, tx.ID)
+		}
+		ids[tx.ID] = true
+		if tx.Version >= TxVersion && tx.ID != TxID(tx) {
+			return 0, 0, fmt.Errorf("transaction %s is not its content hash", tx.ID)
+		}
+		if i == 0 {
+			continue
+		}
+		if tx.SenderID == "COINBASE" || tx.Type == "mining_reward" {
+			return 0, 0, fmt.Errorf("transaction %s is a second coinbase", tx.ID)
+		}
+		if err := r.verifyAt(tx, claimed, b.Timestamp); err != nil {
+			return 0, 0, fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		for _, in := range tx.Inputs {
+			claimed[UTXOKey(in.TxID, in.Index)] = tx.ID
+		}
+		var ok bool
+		if fees, ok = AddAmounts(fees, tx.Fee); !ok {
+			return 0, 0, fmt.Errorf("%w: fees overflow", ErrUnbalanced)
+		}
+		if strings.EqualFold(tx.PubKey, systemPubKey) && tx.Type == "admin_mint" {
+			for _, o := range tx.Outputs {
+				if minted, ok = AddAmounts(minted, o.Amount); !ok {
+					return 0, 0, fmt.Errorf("%w: mints overflow", ErrUnbalanced)
+				}
+			}
+		}
+	}
+
+	cb := b.Transactions[0]
+	switch {
+	case len(cb.Inputs) > 0:
+		return 0, 0, errors.New("coinbase has inputs")
+	case len(cb.Outputs) != 1 || cb.Outputs[0].Owner != cb.ReceiverID || cb.Outputs[0].Amount != cb.Amount || !cb.Outputs[0].Lock.IsZero():
+		return 0, 0, fmt.Errorf("coinbase must have one unlocked output paying %d to %s", cb.Amount, cb.ReceiverID)
+	case cb.AssetID != "":
+		return 0, 0, errors.New("coinbase pays an asset")
+	case cb.Amount < fees:
+		return 0, 0, fmt.Errorf("coinbase pays %d, less than the %d in fees", cb.Amount, fees)
+	}
+	subsidy = cb.Amount - fees
+	if most := r.Supply.Subsidy(mined); subsidy > most {
+		return 0, 0, fmt.Errorf("coinbase takes a subsidy of %d, more than %d", subsidy, most)
+	}
+	return subsidy, minted, nil
+}
+
+// isFaucetGrant reports whether u has the shape of a grant CreateFaucetUTXO
+// makes
+func isFaucetGrant(u UTXO) bool {
+	return strings.HasPrefix(u.OriginTx, "faucet-"+u.Owner+"-") && u.Index == 0 && u.ID == UTXOKey(u.OriginTx, 0) &&
+		u.AssetID == "" && u.Lock.IsZero() && u.Amount > 0
+}
+
+// coinbaseReceiver returns the wallet a block's coinbase pays, "" without one
+func coinbaseReceiver(b Block) string {
+	for _, tx := range b.Transactions {
+		if tx.Type == "mining_reward" {
+			return tx.ReceiverID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Reasons a locked output cannot be spent
+var (
+	ErrInputLocked   = errors.New("input is time-locked")
+	ErrBadPreimage   = errors.New("preimage does not match the input's hash lock")
+	ErrMalformedLock = errors.New("malformed lock")
+)
+
+// Lock is the conditions an output is spent under, carried by TxVersionLock
+// transactions. The zero Lock is no condition: the owner spends the output
+// once it is confirmed.
+//
+//   - LockTime alone: the owner spends it from LockTime on (vesting)
+//   - HashLock: the owner spends it by revealing the preimage whose SHA-256 is
+//     HashLock
+//   - HashLock, LockTime and RefundTo: as HashLock, and from LockTime on
+//     RefundTo may take it back without the preimage (a hash time-locked
+//     contract)
+type Lock struct {
+	LockTime int64  `json:"lock_time,omitempty"` // Unix seconds
+	HashLock string `json:"hash_lock,omitempty"` // lowercase hex SHA-256 of the secret
+	RefundTo string `json:"refund_to,omitempty"` // wallet that may reclaim an expired hash lock
+}
+
+// IsZero reports whether the lock sets no condition
+func (l Lock) IsZero() bool {
+	return l == Lock{}
+}
+
+// Check reports a lock that cannot be satisfied as written
+func (l Lock) Check() error {
+	if l.LockTime < 0 {
+		return fmt.Errorf("%w: lock_time must not be negative", ErrMalformedLock)
+	}
+	if l.HashLock != "" {
+		if b, err := hex.DecodeString(l.HashLock); err != nil || len(b) != sha256.Size || l.HashLock != hex.EncodeToString(b) {
+			return fmt.Errorf("%w: hash_lock must be a lowercase hex SHA-256 digest", ErrMalformedLock)
+		}
+	}
+	if l.RefundTo != "" && (l.HashLock == "" || l.LockTime == 0) {
+		return fmt.Errorf("%w: refund_to needs both a hash_lock and a lock_time", ErrMalformedLock)
+	}
+	return nil
+}
+
+// LockedAt reports whether the output's owner needs more than a signature to
+// spend it at time at: a preimage, or waiting for its lock time
+func (u UTXO) LockedAt(at int64) bool {
+	return u.HashLock != "" || u.LockTime > at
+}
+
+// Unlock checks that walletID may spend u at time at, revealing preimage, the
+// hex secret of a hash lock. It does not check that u is unspent or confirmed.
+func (u UTXO) Unlock(walletID, preimage string, at int64) error {
+	if u.HashLock == "" {
+		switch {
+		case walletID != u.Owner:
+			return ErrInputNotOwned
+		case preimage != "":
+			return fmt.Errorf("%w: the input has no hash lock", ErrBadPreimage)
+		case u.LockTime > at:
+			return fmt.Errorf("%w until %d", ErrInputLocked, u.LockTime)
+		}
+		return nil
+	}
+
+	// A hash-locked output is claimed by its owner with the preimage, or
+	// refunded once its lock time has passed
+	switch {
+	case walletID == u.Owner && preimage != "":
+		if HashPreimage(preimage) != u.HashLock {
+			return ErrBadPreimage
+		}
+		return nil
+	case walletID == u.Owner:
+		return fmt.Errorf("%w: a preimage is required", ErrBadPreimage)
+	case walletID == u.RefundTo && preimage == "":
+		if u.LockTime > at {
+			return fmt.Errorf("%w: refundable from %d", ErrInputLocked, u.LockTime)
+		}
+		return nil
+	}
+	return ErrInputNotOwned
+}
+
+// HashPreimage returns the hash lock a hex preimage opens, or "" when it is
+// not hex
+func HashPreimage(preimage string) string {
+	b, err := hex.DecodeString(preimage)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// hasLocks reports whether a transaction sets a lock on an output or reveals
+// a preimage, which only TxVersionLock encodes
+func hasLocks(tx Transaction) bool {
+	for _, in := range tx.Inputs {
+		if in.Preimage != "" {
+			return true
+		}
+	}
+	for _, out := range tx.Outputs {
+		if !out.Lock.IsZero() {
+			return true
+		}
+	}
+	return false
+}
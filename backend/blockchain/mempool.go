@@ -0,0 +1,222 @@
+package blockchain
+
+import (
+	"strings"
+	"time"
+)
+
+// Mempool lanes, highest priority first. Mining takes transactions lane by
+// lane, oldest first within a lane.
+const (
+	LaneSystem = "system"     // issued by the backend itself, such as zakat
+	LaneFee    = "fee_paying" // user transactions that pay a fee
+	LaneFaucet = "faucet"     // faucet grants and other zero-fee transactions
+)
+
+// Lanes lists the mempool lanes in priority order
+var Lanes = []string{LaneSystem, LaneFee, LaneFaucet}
+
+// systemPubKey marks transactions the backend creates and signs for itself
+const systemPubKey = "system"
+
+// LaneOf returns the mempool lane of a pending transaction
+func LaneOf(tx Transaction) string {
+	switch {
+	case strings.EqualFold(tx.PubKey, systemPubKey):
+		return LaneSystem
+	case tx.Type == "faucet" || tx.Fee == 0:
+		return LaneFaucet
+	default:
+		return LaneFee
+	}
+}
+
+// MempoolPolicy bounds how many pending transactions a block takes. Each
+// lane's quota is the number of slots it is guaranteed in every block; room
+// the quotas leave over goes to the lanes in priority order, so a busy lane
+// cannot starve a lower one of its quota.
+type MempoolPolicy struct {
+	MaxBlockTxs int            `json:"max_block_txs"` // excluding the coinbase; 0 means no limit
+	Quotas      map[string]int `json:"quotas"`
+}
+
+// DefaultMempoolPolicy puts every pending transaction in the next block
+func DefaultMempoolPolicy() MempoolPolicy {
+	return MempoolPolicy{Quotas: map[string]int{}}
+}
+
+// selectPending splits the pending pool into the transactions the next block
+// takes, in lane order, and those left waiting, in their original order. The
+// caller must hold the lock.
+func (bc *Blockchain) selectPending() (included, deferred []Transaction) {
+	return bc.Mempool.split(bc.pending)
+}
+
+// split divides pending into the transactions one block takes under the
+// policy and those left waiting
+func (p MempoolPolicy) split(pending []Transaction) (included, deferred []Transaction) {
+	byLane := map[string][]int{}
+	for i, tx := range pending {
+		lane := LaneOf(tx)
+		byLane[lane] = append(byLane[lane], i)
+	}
+
+	room := len(pending)
+	if p.MaxBlockTxs > 0 && p.MaxBlockTxs < room {
+		room = p.MaxBlockTxs
+	}
+	taken := map[string]int{}
+	take := func(lane string, n int) {
+		if avail := len(byLane[lane]) - taken[lane]; n > avail {
+			n = avail
+		}
+		if n > room {
+			n = room
+		}
+		taken[lane] += n
+		room -= n
+	}
+	// Quotas first, then whatever room is left by priority
+	for _, lane := range Lanes {
+		take(lane, p.Quotas[lane])
+	}
+	for _, lane := range Lanes {
+		take(lane, len(byLane[lane]))
+	}
+
+	in := make(map[int]bool)
+	for _, lane := range Lanes {
+		for _, i := range byLane[lane][:taken[lane]] {
+			included = append(included, pending[i])
+			in[i] = true
+		}
+	}
+	deferred = []Transaction{}
+	for i, tx := range pending {
+		if !in[i] {
+			deferred = append(deferred, tx)
+		}
+	}
+	return included, deferred
+}
+
+// LaneStats describes one mempool lane
+type LaneStats struct {
+	Lane      string `json:"lane"`
+	Depth     int    `json:"depth"`      // pending transactions in the lane
+	Quota     int    `json:"quota"`      // slots guaranteed per block
+	NextBlock int    `json:"next_block"` // how many the next block would take
+	Fees      uint64 `json:"fees"`
+	OldestAge int64  `json:"oldest_age_seconds"` // 0 when the lane is empty
+}
+
+// MempoolStats summarises the pending pool by lane
+type MempoolStats struct {
+	Pending     int         `json:"pending"`
+	MaxBlockTxs int         `json:"max_block_txs"` // 0 means no limit
+	Lanes       []LaneStats `json:"lanes"`
+}
+
+// MempoolStats reports the depth of each lane and how much of it the next
+// block would take under the current policy
+func (bc *Blockchain) MempoolStats() MempoolStats {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	included, _ := bc.selectPending()
+	next := map[string]int{}
+	for _, tx := range included {
+		next[LaneOf(tx)]++
+	}
+
+	now := time.Now().Unix()
+	stats := MempoolStats{Pending: len(bc.pending), MaxBlockTxs: bc.Mempool.MaxBlockTxs}
+	for _, lane := range Lanes {
+		ls := LaneStats{Lane: lane, Quota: bc.Mempool.Quotas[lane], NextBlock: next[lane]}
+		for _, tx := range bc.pending {
+			if LaneOf(tx) != lane {
+				continue
+			}
+			ls.Depth++
+			ls.Fees += tx.Fee
+			if age := now - tx.Timestamp; age > ls.OldestAge {
+				ls.OldestAge = age
+			}
+		}
+		stats.Lanes = append(stats.Lanes, ls)
+	}
+	return stats
+}
+
+// MempoolEntry is a pending transaction with its place in the queue
+type MempoolEntry struct {
+	Transaction Transaction `json:"transaction"`
+	Lane        string      `json:"lane"`
+	AgeSeconds  int64       `json:"age_seconds"`
+	// BlocksAway is how many blocks are mined before it is included, counting
+	// the one that includes it: 1 means the next block, if nothing is added
+	BlocksAway int `json:"blocks_away"`
+	// ReservedInputs are the UTXOs it spends, held until it is mined
+	ReservedInputs []UTXO `json:"reserved_inputs"`
+}
+
+// MempoolEntries lists the pending pool in arrival order, simulating the
+// policy block by block to place each transaction
+func (bc *Blockchain) MempoolEntries() []MempoolEntry {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	away := make(map[string]int, len(bc.pending))
+	rest := bc.pending
+	for n := 1; len(rest) > 0; n++ {
+		var included []Transaction
+		included, rest = bc.Mempool.split(rest)
+		if len(included) == 0 {
+			break
+		}
+		for _, tx := range included {
+			away[tx.ID] = n
+		}
+	}
+
+	now := time.Now().Unix()
+	entries := make([]MempoolEntry, 0, len(bc.pending))
+	for _, tx := range bc.pending {
+		e := MempoolEntry{
+			Transaction:    tx,
+			Lane:           LaneOf(tx),
+			AgeSeconds:     now - tx.Timestamp,
+			BlocksAway:     away[tx.ID],
+			ReservedInputs: []UTXO{},
+		}
+		for _, in := range tx.Inputs {
+			key := UTXOKey(in.TxID, in.Index)
+			u, ok := bc.utxos[key]
+			if !ok {
+				u = UTXO{ID: key, OriginTx: in.TxID, Index: in.Index}
+			}
+			e.ReservedInputs = append(e.ReservedInputs, u)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// BlockInterval is the average time between the last n mined blocks, or 0
+// until two blocks after genesis have been mined. Mining is on demand, so it
+// is the observed cadence rather than a target.
+func (bc *Blockchain) BlockInterval(n int) time.Duration {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	// The genesis timestamp is when the node started, not a mining time
+	mined := bc.chain[1:]
+	if len(mined) < 2 {
+		return 0
+	}
+	if n > 0 && len(mined) > n+1 {
+		mined = mined[len(mined)-n-1:]
+	}
+	span := mined[len(mined)-1].Timestamp - mined[0].Timestamp
+	return time.Duration(span) * time.Second / time.Duration(len(mined)-1)
+}
@@ -0,0 +1,137 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Errors returned by GetMerkleProof
+var (
+	ErrTxNotFound = errors.New("transaction not found")
+	ErrTxNotMined = errors.New("transaction is still pending; a proof exists once it is mined")
+)
+
+// Sides of a merkle proof step: where the sibling hash goes when hashing
+const (
+	MerkleLeft  = "left"
+	MerkleRight = "right"
+)
+
+// MerkleStep is one level of a merkle proof
+type MerkleStep struct {
+	Hash string `json:"hash"`
+	Side string `json:"side"` // left or right of the running hash
+}
+
+// BlockHeader is a block without its transactions: enough to check the proof
+// of work and the chain links, and to verify merkle proofs against
+type BlockHeader struct {
+	Version      int    `json:"version"`
+	Index        int64  `json:"index"`
+	Timestamp    int64  `json:"timestamp"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+	MerkleRoot   string `json:"merkle_root"`
+	Nonce        int64  `json:"nonce"`
+	Difficulty   string `json:"difficulty,omitempty"` // hash prefix the proof of work meets
+	TxCount      int    `json:"tx_count"`
+}
+
+// Header returns the block's header. Blocks do not record their difficulty,
+// so it is left empty; the chain's header accessors fill it in.
+func (b Block) Header() BlockHeader {
+	return BlockHeader{
+		Version:      b.Version,
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		PreviousHash: b.PreviousHash,
+		Hash:         b.Hash,
+		MerkleRoot:   b.MerkleRoot,
+		Nonce:        b.Nonce,
+		TxCount:      len(b.Transactions),
+	}
+}
+
+// MerkleProof shows that a transaction is in a block without the block's
+// other transactions. Hashing Leaf with each step of Path in turn gives the
+// header's merkle root.
+type MerkleProof struct {
+	TxID     string       `json:"txid"`
+	Leaf     string       `json:"leaf"` // SHA-256 of the transaction ID
+	Position int          `json:"position"`
+	Path     []MerkleStep `json:"path"` // from the leaf up to the root
+	Header   BlockHeader  `json:"header"`
+}
+
+// GetMerkleProof builds the inclusion proof of a mined transaction
+func (bc *Blockchain) GetMerkleProof(txID string) (MerkleProof, error) {
+	loc, ok := bc.GetTransactionByID(txID)
+	if !ok {
+		return MerkleProof{}, ErrTxNotFound
+	}
+	if loc.Pending {
+		return MerkleProof{}, ErrTxNotMined
+	}
+	return MerkleProof{
+		TxID:     txID,
+		Leaf:     sha256Hex(txID),
+		Position: loc.Position,
+		Path:     MerklePath(loc.Block.Transactions, loc.Position),
+		Header:   bc.header(loc.Block),
+	}, nil
+}
+
+// MerklePath returns the sibling hashes from the transaction at pos up to the
+// root, following MerkleRoot: a hash left unpaired at the end of a level is
+// carried up without a step
+func MerklePath(txs []Transaction, pos int) []MerkleStep {
+	level := make([]string, len(txs))
+	for i, t := range txs {
+		level[i] = sha256Hex(t.ID)
+	}
+
+	path := []MerkleStep{}
+	for len(level) > 1 {
+		switch {
+		case pos%2 == 1:
+			path = append(path, MerkleStep{Hash: level[pos-1], Side: MerkleLeft})
+		case pos+1 < len(level):
+			path = append(path, MerkleStep{Hash: level[pos+1], Side: MerkleRight})
+		}
+
+		var next []string
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, sha256Hex(level[i]+level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+		level = next
+		pos /= 2
+	}
+	return path
+}
+
+// VerifyMerkleProof reports whether a transaction ID and proof path hash up
+// to the merkle root
+func VerifyMerkleProof(txID string, path []MerkleStep, root string) bool {
+	h := sha256Hex(txID)
+	for _, step := range path {
+		switch step.Side {
+		case MerkleLeft:
+			h = sha256Hex(step.Hash + h)
+		case MerkleRight:
+			h = sha256Hex(h + step.Hash)
+		default:
+			return false
+		}
+	}
+	return h == root
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
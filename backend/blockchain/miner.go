@@ -0,0 +1,74 @@
+package blockchain
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// MaxMiningAttempts bounds the proof-of-work search of CPUMiner
+const MaxMiningAttempts = 10000000
+
+// Miner searches for a block's proof of work: a nonce, from start on, whose
+// block hash starts with difficulty. Seal sets b.Nonce and b.Hash and returns
+// how many hashes it tried; it leaves b.Hash empty when it gives up, and
+// returns ctx's error when ctx is done first. Mine calls it with the write
+// lock held, so it must not use the Blockchain.
+type Miner interface {
+	Seal(ctx context.Context, b *Block, difficulty string, start int64) (attempts int64, err error)
+}
+
+// CPUMiner is the default Miner: it tries nonces one after another on the
+// calling goroutine, up to MaxMiningAttempts
+type CPUMiner struct{}
+
+// sealCheckEvery is how many hashes CPUMiner tries between checks of its
+// context
+const sealCheckEvery = 1 << 12
+
+// Seal implements Miner. Under a context from WithAttemptCounter it adds
+// the hashes it tries to the counter as it goes.
+func (CPUMiner) Seal(ctx context.Context, b *Block, difficulty string, start int64) (int64, error) {
+	counter, _ := ctx.Value(attemptCounterKey{}).(*atomic.Int64)
+	nonce := start
+	for attempts := int64(1); attempts <= MaxMiningAttempts; attempts++ {
+		if attempts%sealCheckEvery == 0 {
+			if counter != nil {
+				counter.Add(sealCheckEvery)
+			}
+			if err := ctx.Err(); err != nil {
+				return attempts, err
+			}
+		}
+		b.Nonce = nonce
+		if h := HashBlock(*b); strings.HasPrefix(h, difficulty) {
+			b.Hash = h
+			if counter != nil {
+				counter.Add(attempts % sealCheckEvery)
+			}
+			return attempts, nil
+		}
+		nonce++
+	}
+	if counter != nil {
+		counter.Add(MaxMiningAttempts % sealCheckEvery)
+	}
+	return MaxMiningAttempts, nil
+}
+
+// miner returns the Miner blocks are sealed with
+func (bc *Blockchain) miner() Miner {
+	if bc.Miner != nil {
+		return bc.Miner
+	}
+	return CPUMiner{}
+}
+
+type attemptCounterKey struct{}
+
+// WithAttemptCounter returns a context under which CPUMiner reports its
+// progress to n, so the search for a block's proof of work can be followed
+// while it runs
+func WithAttemptCounter(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, attemptCounterKey{}, n)
+}
@@ -0,0 +1,37 @@
+package blockchain
+
+// Wallet nonces guard against replays: every transaction a wallet signs
+// carries a nonce higher than any it used before, so a resubmitted signature
+// is refused even while its inputs are still unspent. Gaps are allowed, so a
+// transaction that never makes it into a block does not hold up later ones.
+
+// nonce returns the highest nonce the wallet used in a mined or pending
+// transaction; 0 when it has used none. The caller must hold the read lock.
+func (bc *Blockchain) nonce(walletID string) uint64 {
+	return bc.nonces[walletID]
+}
+
+// GetNonce is View.Nonce for callers outside a View
+func (bc *Blockchain) GetNonce(walletID string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.nonces[walletID]
+}
+
+// RestoreNonce raises the wallet's nonce to at least n, when state is loaded
+// from the database
+func (bc *Blockchain) RestoreNonce(walletID string, n uint64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if n > bc.nonces[walletID] {
+		bc.nonces[walletID] = n
+	}
+}
+
+// noteNonce records the nonce of a mined or pending transaction. The caller
+// must hold the write lock.
+func (bc *Blockchain) noteNonce(tx Transaction) {
+	if tx.Nonce > bc.nonces[tx.SenderID] {
+		bc.nonces[tx.SenderID] = tx.Nonce
+	}
+}
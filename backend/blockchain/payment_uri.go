@@ -0,0 +1,33 @@
+package blockchain
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PaymentURIScheme is the URI scheme of payment links and QR codes
+const PaymentURIScheme = "dcw"
+
+// PaymentURI builds the canonical link asking for a payment to walletID, in
+// the style of BIP 21: dcw:<wallet>?amount=1.5&note=Rent%20May&request=7.
+// The amount is in coins. A zero amount, empty note or zero request ID is
+// left out, so a bare dcw:<wallet> is just an address.
+func PaymentURI(walletID string, amount uint64, note string, requestID int64) string {
+	var params []string
+	if amount > 0 {
+		params = append(params, "amount="+FormatAmount(amount))
+	}
+	if note != "" {
+		// Spaces as %20, not the + of form encoding, which wallets read literally
+		params = append(params, "note="+strings.ReplaceAll(url.QueryEscape(note), "+", "%20"))
+	}
+	if requestID > 0 {
+		params = append(params, "request="+strconv.FormatInt(requestID, 10))
+	}
+	uri := PaymentURIScheme + ":" + walletID
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
@@ -0,0 +1,81 @@
+package blockchain
+
+// Spent UTXOs are kept after spending so lookups, ledgers and the owner index
+// can still see them. Pruning drops the old ones in two steps so the caller
+// can archive them in between: PrunableUTXOs lists them and Prune forgets them.
+
+// PrunableUTXOs returns the UTXOs spent more than keepBlocks blocks below the
+// tip, and that horizon height. UTXOs loaded spent without a known spending
+// block count as prunable.
+func (bc *Blockchain) PrunableUTXOs(keepBlocks int64) (int64, []UTXO) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	horizon := int64(len(bc.chain)-1) - keepBlocks
+	var prunable []UTXO
+	for _, u := range bc.utxos {
+		if u.Spent && u.SpentHeight <= horizon {
+			prunable = append(prunable, u)
+		}
+	}
+	return horizon, prunable
+}
+
+// Prune removes spent UTXOs from memory and the owner index, and records that
+// spent outputs up to horizon may be gone. IDs that are unknown or unspent are
+// left alone. It returns how many were removed.
+func (bc *Blockchain) Prune(horizon int64, ids []string) int {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	gone := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if u, ok := bc.utxos[id]; ok && u.Spent {
+			gone[id] = true
+			delete(bc.utxos, id)
+		}
+	}
+	if horizon > bc.prunedHeight {
+		bc.prunedHeight = horizon
+	}
+	if len(gone) == 0 {
+		return 0
+	}
+
+	for owner, owned := range bc.byOwner {
+		kept := owned[:0]
+		for _, id := range owned {
+			if !gone[id] {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(bc.byOwner, owner)
+		} else {
+			bc.byOwner[owner] = kept
+		}
+	}
+	return len(gone)
+}
+
+// output returns output index of transaction txID: from the UTXO set, or for
+// a pruned UTXO from the mined transaction that created it. Pruned faucet
+// grants are not found. The caller must hold the read lock.
+func (bc *Blockchain) output(txID string, index int) (UTXO, bool) {
+	if u, ok := bc.utxos[UTXOKey(txID, index)]; ok {
+		return u, true
+	}
+	pos, ok := bc.txIndex[txID]
+	if !ok {
+		return UTXO{}, false
+	}
+	tx := bc.chain[pos.block].Transactions[pos.pos]
+	if index < 0 || index >= len(tx.Outputs) {
+		return UTXO{}, false
+	}
+	u := tx.Outputs[index]
+	u.ID = UTXOKey(txID, index)
+	u.Height = pos.block
+	u.Spent = true
+	return u, true
+}
@@ -0,0 +1,36 @@
+package blockchain
+
+import "time"
+
+// newGenesis builds a genesis block stamped now
+func (bc *Blockchain) newGenesis() Block {
+	genesis := Block{
+		Version:      BlockVersion,
+		Index:        0,
+		Timestamp:    time.Now().Unix(),
+		Transactions: []Transaction{},
+		PreviousHash: "0",
+		Nonce:        0,
+	}
+	genesis.MerkleRoot = bc.computeMerkle(genesis.Transactions)
+	genesis.Hash = bc.hashBlock(genesis)
+	return genesis
+}
+
+// Reset discards every block, pending transaction, UTXO and nonce and the
+// issued count, and starts the chain over from a new genesis block, which it
+// returns. The difficulty and the policies are kept.
+func (bc *Blockchain) Reset() Block {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	genesis := bc.newGenesis()
+	bc.chain = []Block{genesis}
+	bc.pending = make([]Transaction, 0)
+	bc.utxos = make(map[string]UTXO)
+	bc.txIndex = make(map[string]txPosition)
+	bc.byOwner = make(map[string][]string)
+	bc.nonces = make(map[string]uint64)
+	bc.issued, bc.prunedHeight = 0, 0
+	return genesis
+}
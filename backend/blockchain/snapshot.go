@@ -0,0 +1,190 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the layout of Snapshot; Restore refuses other versions
+const SnapshotVersion = 1
+
+// ErrSnapshotMismatch is returned when a snapshot does not fit the blocks it
+// is restored with
+var ErrSnapshotMismatch = errors.New("snapshot does not match the chain")
+
+// ErrWholeCoinChain is returned by Restore for chains whose genesis block
+// predates BlockVersion. Their amounts are whole coins, and scaling them to
+// units would break every hash and signature.
+var ErrWholeCoinChain = errors.New("chain was recorded in whole coins, before amounts were kept in units")
+
+// Snapshot is the chain state as of one block: the UTXO set and what else the
+// blocks up to it would otherwise have to be replayed to recover
+type Snapshot struct {
+	Version      int               `json:"version"`
+	Height       int64             `json:"height"`
+	Hash         string            `json:"hash"`
+	Issued       uint64            `json:"issued"`
+	PrunedHeight int64             `json:"pruned_height"`
+	Nonces       map[string]uint64 `json:"nonces"`
+	UTXOs        []UTXO            `json:"utxos"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// Snapshot captures the state at the current tip. Pending transactions are
+// not part of it.
+func (bc *Blockchain) Snapshot() Snapshot {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.snapshotLocked()
+}
+
+// SnapshotWithBlocks returns every block and the snapshot of the last of
+// them, taken together so no block is mined in between
+func (bc *Blockchain) SnapshotWithBlocks() ([]Block, Snapshot) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]Block(nil), bc.chain...), bc.snapshotLocked()
+}
+
+// snapshotLocked is Snapshot for a caller holding the lock
+func (bc *Blockchain) snapshotLocked() Snapshot {
+	tip := bc.chain[len(bc.chain)-1]
+	snap := Snapshot{
+		Version:      SnapshotVersion,
+		Height:       tip.Index,
+		Hash:         tip.Hash,
+		Issued:       bc.issued,
+		PrunedHeight: bc.prunedHeight,
+		Nonces:       make(map[string]uint64, len(bc.nonces)),
+		UTXOs:        make([]UTXO, 0, len(bc.utxos)),
+		CreatedAt:    time.Now(),
+	}
+	for id, n := range bc.nonces {
+		snap.Nonces[id] = n
+	}
+	// Owner by owner, so a restored wallet lists its UTXOs in the same order
+	for _, ids := range bc.byOwner {
+		for _, id := range ids {
+			snap.UTXOs = append(snap.UTXOs, bc.utxos[id])
+		}
+	}
+	return snap
+}
+
+// Restore replaces the chain with blocks, genesis first. With a snapshot, the
+// state as of snap.Height is taken from it and only later blocks are
+// replayed; without one every block is. Blocks must link up, and replayed
+// blocks must hash to their stored hash and merkle root. Chains recorded in
+// whole coins are refused with ErrWholeCoinChain. On error the chain
+// is left as it was. It returns how many blocks were replayed.
+func (bc *Blockchain) Restore(snap *Snapshot, blocks []Block) (int, error) {
+	if len(blocks) == 0 {
+		return 0, errors.New("no blocks to restore")
+	}
+	for i, b := range blocks {
+		switch {
+		case b.Index != int64(i):
+			return 0, fmt.Errorf("block at position %d has index %d", i, b.Index)
+		case i == 0 && b.PreviousHash != "0":
+			return 0, errors.New("first block is not a genesis block")
+		case i == 0 && b.Version < BlockVersion:
+			return 0, ErrWholeCoinChain
+		case i > 0 && b.PreviousHash != blocks[i-1].Hash:
+			return 0, fmt.Errorf("block %d does not link to block %d", i, i-1)
+		}
+	}
+
+	from := int64(0)
+	if snap != nil {
+		switch {
+		case snap.Version != SnapshotVersion:
+			return 0, fmt.Errorf("%w: unsupported snapshot version %d", ErrSnapshotMismatch, snap.Version)
+		case snap.Height < 0 || snap.Height >= int64(len(blocks)):
+			return 0, fmt.Errorf("%w: snapshot height %d is beyond the %d stored blocks", ErrSnapshotMismatch, snap.Height, len(blocks))
+		case blocks[snap.Height].Hash != snap.Hash:
+			return 0, fmt.Errorf("%w: block %d has hash %s, the snapshot %s", ErrSnapshotMismatch, snap.Height, blocks[snap.Height].Hash, snap.Hash)
+		}
+		from = snap.Height
+	}
+	for _, b := range blocks[from+1:] {
+		if bc.hashBlock(b) != b.Hash {
+			return 0, fmt.Errorf("block %d does not hash to its stored hash", b.Index)
+		}
+		if bc.computeMerkle(b.Transactions) != b.MerkleRoot {
+			return 0, fmt.Errorf("block %d does not match its merkle root", b.Index)
+		}
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.chain = append([]Block(nil), blocks...)
+	bc.txIndex = make(map[string]txPosition)
+	bc.utxos = make(map[string]UTXO)
+	bc.byOwner = make(map[string][]string)
+	bc.nonces = make(map[string]uint64)
+	bc.issued, bc.prunedHeight = 0, 0
+	if snap != nil {
+		for _, u := range snap.UTXOs {
+			bc.putUTXO(u)
+		}
+		for id, n := range snap.Nonces {
+			bc.nonces[id] = n
+		}
+		bc.issued, bc.prunedHeight = snap.Issued, snap.PrunedHeight
+	}
+	for _, b := range bc.chain {
+		bc.indexBlock(b)
+		if b.Index <= from {
+			continue
+		}
+		bc.applyUTXOs(b)
+		bc.issued += subsidyOf(b)
+	}
+	return len(blocks) - 1 - int(from), nil
+}
+
+// subsidyOf is the coins a block's coinbase created beyond the fees it
+// collected
+func subsidyOf(b Block) uint64 {
+	var minted, fees uint64
+	for _, tx := range b.Transactions {
+		if tx.Type == "mining_reward" {
+			for _, o := range tx.Outputs {
+				minted += o.Amount
+			}
+			continue
+		}
+		fees += tx.Fee
+	}
+	if minted < fees {
+		return 0
+	}
+	return minted - fees
+}
+
+// RestoreUTXOs adds UTXOs loaded from storage after Restore, as restoreUTXO
+// does for each
+func (bc *Blockchain) RestoreUTXOs(utxos []UTXO) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	for _, u := range utxos {
+		bc.restoreUTXO(u)
+	}
+}
+
+// restoreUTXO adds a UTXO loaded from storage after Restore: one the chain
+// does not know, such as a faucet grant, is added, and a known one is only
+// ever marked spent. The caller must hold the write lock.
+func (bc *Blockchain) restoreUTXO(u UTXO) {
+	known, ok := bc.utxos[u.ID]
+	if !ok {
+		bc.putUTXO(u)
+		return
+	}
+	if u.Spent && !known.Spent {
+		known.Spent = true
+		bc.putUTXO(known)
+	}
+}
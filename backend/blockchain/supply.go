@@ -0,0 +1,86 @@
+package blockchain
+
+// Supply cap modes: what happens to the block subsidy as issuance nears the cap
+const (
+	SupplyCapStop  = "stop"  // full subsidy until the cap is reached, none after
+	SupplyCapHalve = "halve" // subsidy halves each time issuance covers half of what was left below the cap
+)
+
+// SupplyPolicy bounds how many coins may ever be issued. Only the mining
+// subsidy is held to the cap; fees move existing coins and are always paid.
+// Faucet grants count towards the issued supply but are not refused.
+type SupplyPolicy struct {
+	Cap  uint64 `json:"cap"` // 0 means no cap
+	Mode string `json:"mode"`
+}
+
+// DefaultSupplyPolicy issues the full MiningReward forever
+func DefaultSupplyPolicy() SupplyPolicy {
+	return SupplyPolicy{Mode: SupplyCapStop}
+}
+
+// Subsidy is the mining subsidy of the next block once issued coins exist.
+// In halve mode the subsidy halves when issuance passes cap/2, again at
+// 3/4 of the cap, 7/8 and so on; in both modes it never crosses the cap.
+func (p SupplyPolicy) Subsidy(issued uint64) uint64 {
+	subsidy := uint64(MiningReward)
+	if p.Cap == 0 {
+		return subsidy
+	}
+	if issued >= p.Cap {
+		return 0
+	}
+	remaining := p.Cap - issued
+	if p.Mode == SupplyCapHalve {
+		for gap := p.Cap / 2; gap > 0 && remaining <= gap && subsidy > 0; gap /= 2 {
+			subsidy /= 2
+		}
+	}
+	if subsidy > remaining {
+		subsidy = remaining
+	}
+	return subsidy
+}
+
+// Issued is the number of coins created so far by mining subsidies, faucet
+// grants and mints
+func (bc *Blockchain) Issued() uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.issued
+}
+
+// SetIssued restores the issued count, e.g. from the database at startup
+func (bc *Blockchain) SetIssued(n uint64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.issued = n
+}
+
+// AddIssued counts coins created outside mining and the faucet, e.g. by an
+// admin mint
+func (bc *Blockchain) AddIssued(n uint64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.issued += n
+}
+
+// NextSubsidy is the subsidy the next mined block will carry
+func (bc *Blockchain) NextSubsidy() uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.Supply.Subsidy(bc.issued)
+}
+
+// CirculatingSupply sums every unspent coin output, confirmed or not
+func (bc *Blockchain) CirculatingSupply() uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	var sum uint64
+	for _, ut := range bc.utxos {
+		if !ut.Spent && ut.AssetID == "" {
+			sum += ut.Amount
+		}
+	}
+	return sum
+}
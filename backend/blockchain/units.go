@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Amounts are whole numbers of the smallest unit, of which one coin holds
+// UnitsPerCoin. Decimals is how many places a coin amount is shown with.
+const (
+	Decimals            = 8
+	UnitsPerCoin uint64 = 100_000_000
+)
+
+// ErrInvalidAmount is returned for coin amounts that do not parse, have more
+// than Decimals places or do not fit in a uint64 of units
+var ErrInvalidAmount = errors.New("invalid coin amount")
+
+// Coins converts whole coins to units
+func Coins(n uint64) uint64 {
+	return n * UnitsPerCoin
+}
+
+// AddAmounts sums amounts of units. ok is false when the sum does not fit in
+// a uint64, which would otherwise wrap around.
+func AddAmounts(amounts ...uint64) (sum uint64, ok bool) {
+	for _, a := range amounts {
+		var carry uint64
+		if sum, carry = bits.Add64(sum, a, 0); carry != 0 {
+			return 0, false
+		}
+	}
+	return sum, true
+}
+
+// FormatAmount writes units as a coin amount without trailing zeros, so
+// 150000000 is "1.5" and 100000000 is "1"
+func FormatAmount(units uint64) string {
+	whole := strconv.FormatUint(units/UnitsPerCoin, 10)
+	frac := units % UnitsPerCoin
+	if frac == 0 {
+		return whole
+	}
+	digits := strconv.FormatUint(frac, 10)
+	digits = strings.Repeat("0", Decimals-len(digits)) + digits
+	return whole + "." + strings.TrimRight(digits, "0")
+}
+
+// ParseAmount reads a coin amount such as "12" or "0.005" as units
+func ParseAmount(s string) (uint64, error) {
+	whole, frac, dotted := strings.Cut(strings.TrimSpace(s), ".")
+	if (whole == "" && frac == "") || len(frac) > Decimals || (dotted && frac == "") {
+		return 0, ErrInvalidAmount
+	}
+	var w, f uint64
+	var err error
+	if whole != "" {
+		if w, err = strconv.ParseUint(whole, 10, 64); err != nil {
+			return 0, ErrInvalidAmount
+		}
+	}
+	if frac != "" {
+		if f, err = strconv.ParseUint(frac+strings.Repeat("0", Decimals-len(frac)), 10, 64); err != nil {
+			return 0, ErrInvalidAmount
+		}
+	}
+	if w > (math.MaxUint64-f)/UnitsPerCoin {
+		return 0, ErrInvalidAmount
+	}
+	return w*UnitsPerCoin + f, nil
+}
+
+// MulDiv returns amount*num/den rounded down, without overflowing when the
+// product does not fit in a uint64
+func MulDiv(amount, num, den uint64) uint64 {
+	return amount/den*num + amount%den*num/den
+}
@@ -0,0 +1,128 @@
+// Command chainverify audits an exported chain without a running server. It
+// reads the blocks of GET /api/blocks, as a JSON array or as NDJSON with one
+// block per line, optionally gzip-compressed, and checks block hashes and
+// links, proof of work (or with -validators the validators' turns and block
+// signatures), merkle
+// roots, transaction IDs and signatures and that coins are neither created
+// nor destroyed outside mining rewards and faucet grants.
+//
+// Usage:
+//
+//	curl -s localhost:8080/api/blocks > chain.json
+//	go run ./cmd/chainverify chain.json
+//	gzip -dc chain.ndjson.gz | go run ./cmd/chainverify -json -
+//
+// The exit status is 0 when the chain verifies, 1 when problems were found and
+// 2 when the dump could not be read.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"blockchain-backend/blockchain"
+)
+
+func main() {
+	difficulty := flag.String("difficulty", "00000", "hash prefix every mined block must have (empty to skip)")
+	validators := flag.String("validators", "", "comma-separated wallet IDs taking turns under proof of authority, in order; skips -difficulty")
+	turnTimeout := flag.Duration("turn-timeout", blockchain.DefaultTurnTimeout, "with -validators, how long each validator has before the turn passes to the next (0 never passes it)")
+	reward := flag.Uint64("reward", blockchain.MiningReward, "units minted per block on top of the fees (read as whole coins in blocks before version 3)")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: chainverify [flags] <dump.json|dump.ndjson[.gz]|->\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	blocks, err := readDump(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chainverify: %v\n", err)
+		os.Exit(2)
+	}
+
+	opts := options{Difficulty: *difficulty, Reward: *reward}
+	if *validators != "" {
+		opts.Difficulty = ""
+		opts.Validators = strings.Split(*validators, ",")
+		opts.TurnTimeout = *turnTimeout
+	}
+	report := verify(blocks, opts)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.print(os.Stdout)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+// readDump loads blocks from a file, or stdin for "-"
+func readDump(path string) ([]blockchain.Block, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %v", err)
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("dump is empty")
+	}
+
+	// A JSON array as served by /api/blocks
+	if data[0] == '[' {
+		var blocks []blockchain.Block
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			return nil, fmt.Errorf("parse JSON array: %v", err)
+		}
+		return blocks, nil
+	}
+
+	// NDJSON: one block per line
+	var blocks []blockchain.Block
+	for n, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var b blockchain.Block
+		if err := json.Unmarshal(line, &b); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
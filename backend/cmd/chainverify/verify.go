@@ -0,0 +1,383 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/wallet"
+)
+
+type options struct {
+	Difficulty  string
+	Reward      uint64
+	Validators  []string      // proof of authority: each block pays the validator whose turn it is
+	TurnTimeout time.Duration // proof of authority: how long a validator has before the turn passes on
+}
+
+// Report is the outcome of verifying a dump
+type Report struct {
+	OK           bool      `json:"ok"`
+	Blocks       int       `json:"blocks"`
+	FirstIndex   int64     `json:"first_index"`
+	Transactions int       `json:"transactions"`
+	Signatures   int       `json:"signatures_verified"`
+	SystemTxs    int       `json:"system_transactions"` // zakat deductions carry no wallet signature
+	Supply       Supply    `json:"supply"`
+	Problems     []Finding `json:"problems"`
+	Warnings     []Finding `json:"warnings"`
+}
+
+// Supply accounts for every coin in the dump. Faucet grants are created off
+// chain, so their amounts are inferred from the transactions spending them.
+// Assets other than the coin are only tallied by issuance.
+type Supply struct {
+	Minted      uint64 `json:"minted"` // mining rewards, excluding the fees miners collect
+	Fees        uint64 `json:"fees"`
+	External    uint64 `json:"external_inputs"` // faucet grants, and outputs older than a partial dump
+	AdminMinted uint64 `json:"admin_minted"`    // created by admin_mint transactions
+	Burned      uint64 `json:"burned"`          // destroyed by admin_burn transactions
+	Unspent     uint64 `json:"unspent"`
+	Conserved   bool   `json:"conserved"` // unspent + burned == minted + external_inputs + admin_minted
+
+	AssetsIssued map[string]uint64 `json:"assets_issued,omitempty"` // asset ID -> units created by asset_issue transactions
+}
+
+// Finding is one problem or warning, located by block and transaction
+type Finding struct {
+	Block   int64  `json:"block"`
+	TxID    string `json:"txid,omitempty"`
+	Message string `json:"message"`
+}
+
+type output struct {
+	owner  string
+	asset  string
+	amount uint64
+	lock   blockchain.Lock
+	spent  bool
+}
+
+type verifier struct {
+	opts     options
+	report   *Report
+	partial  bool
+	outputs  map[string]*output // "txid:index" -> on-chain output
+	external map[string]bool    // off-chain inputs already spent
+	txIDs    map[string]int64   // transaction ID -> block
+}
+
+func (v *verifier) problem(block int64, txID, format string, args ...interface{}) {
+	v.report.Problems = append(v.report.Problems, Finding{Block: block, TxID: txID, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *verifier) warn(block int64, txID, format string, args ...interface{}) {
+	v.report.Warnings = append(v.report.Warnings, Finding{Block: block, TxID: txID, Message: fmt.Sprintf(format, args...)})
+}
+
+// verify checks the blocks in order, as if replaying the chain from scratch
+func verify(blocks []blockchain.Block, opts options) *Report {
+	report := &Report{Blocks: len(blocks), Problems: []Finding{}, Warnings: []Finding{}}
+	v := &verifier{
+		opts:     opts,
+		report:   report,
+		outputs:  make(map[string]*output),
+		external: make(map[string]bool),
+		txIDs:    make(map[string]int64),
+	}
+	if len(blocks) == 0 {
+		v.problem(0, "", "dump contains no blocks")
+		return report
+	}
+
+	report.FirstIndex = blocks[0].Index
+	if blocks[0].Index != 0 {
+		v.partial = true
+		v.warn(blocks[0].Index, "", "dump starts at block %d; outputs created before it are treated as external inputs", blocks[0].Index)
+	}
+
+	for i, b := range blocks {
+		var prev *blockchain.Block
+		if i > 0 {
+			prev = &blocks[i-1]
+		}
+		v.block(b, prev, blocks[0].Index+int64(i))
+	}
+
+	for _, out := range v.outputs {
+		if !out.spent && out.asset == "" {
+			report.Supply.Unspent += out.amount
+		}
+	}
+	sp := report.Supply
+	report.Supply.Conserved = sp.Unspent+sp.Burned == sp.Minted+sp.External+sp.AdminMinted
+	if !report.Supply.Conserved {
+		v.problem(blocks[len(blocks)-1].Index, "", "unspent outputs total %d and %d were burned, but minted %d plus external inputs %d plus admin mints %d is %d",
+			sp.Unspent, sp.Burned, sp.Minted, sp.External, sp.AdminMinted, sp.Minted+sp.External+sp.AdminMinted)
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report
+}
+
+func (v *verifier) block(b blockchain.Block, prev *blockchain.Block, wantIndex int64) {
+	if b.Index != wantIndex {
+		v.problem(b.Index, "", "block index %d, expected %d", b.Index, wantIndex)
+	}
+	switch {
+	case prev != nil && b.PreviousHash != prev.Hash:
+		v.problem(b.Index, "", "previous_hash %s does not match block %d hash %s", b.PreviousHash, prev.Index, prev.Hash)
+	case prev == nil && b.Index == 0 && b.PreviousHash != "0":
+		v.problem(b.Index, "", "genesis previous_hash is %q, expected \"0\"", b.PreviousHash)
+	}
+	if prev != nil && b.Timestamp < prev.Timestamp {
+		v.warn(b.Index, "", "timestamp %d is before the previous block's %d", b.Timestamp, prev.Timestamp)
+	}
+
+	// Legacy hashes do not cover the merkle root, so a chain may not go back
+	// to them once it has moved on
+	switch {
+	case b.Version > blockchain.BlockVersion:
+		v.problem(b.Index, "", "unknown block version %d", b.Version)
+	case prev != nil && b.Version < prev.Version:
+		v.problem(b.Index, "", "version %d follows a version %d block", b.Version, prev.Version)
+	}
+
+	if root := blockchain.MerkleRoot(b.Transactions); root != b.MerkleRoot {
+		v.problem(b.Index, "", "merkle_root %s, recomputed %s", b.MerkleRoot, root)
+	}
+	if hash := blockchain.HashBlock(b); hash != b.Hash {
+		v.problem(b.Index, "", "hash %s, recomputed %s", b.Hash, hash)
+	} else if b.Index > 0 && !strings.HasPrefix(b.Hash, v.opts.Difficulty) {
+		v.problem(b.Index, "", "hash %s does not meet difficulty %q", b.Hash, v.opts.Difficulty)
+	}
+
+	if b.Index == 0 {
+		if len(b.Transactions) > 0 {
+			v.problem(b.Index, "", "genesis block has %d transactions", len(b.Transactions))
+		}
+		return
+	}
+	if len(b.Transactions) == 0 || b.Transactions[0].SenderID != "COINBASE" {
+		v.problem(b.Index, "", "first transaction is not the coinbase")
+	}
+
+	var fees uint64
+	for i, tx := range b.Transactions {
+		v.report.Transactions++
+		if first, seen := v.txIDs[tx.ID]; seen {
+			v.problem(b.Index, tx.ID, "transaction ID already used in block %d", first)
+		}
+		v.txIDs[tx.ID] = b.Index
+		if tx.Version >= blockchain.TxVersion {
+			if id := blockchain.TxID(tx); id != tx.ID {
+				v.problem(b.Index, tx.ID, "ID is not the content hash %s", id)
+			}
+		}
+
+		if tx.SenderID == "COINBASE" {
+			if i != 0 {
+				v.problem(b.Index, tx.ID, "coinbase is not the first transaction")
+			}
+			continue
+		}
+		v.transaction(b.Index, b.Timestamp, tx)
+		fees += tx.Fee
+	}
+
+	// The coinbase pays the reward plus every fee in the block to the miner.
+	// Blocks from before units count the reward in whole coins.
+	if len(b.Transactions) > 0 && b.Transactions[0].SenderID == "COINBASE" {
+		cb := b.Transactions[0]
+		reward := v.opts.Reward
+		if b.Version < blockchain.BlockVersion {
+			reward /= blockchain.UnitsPerCoin
+		}
+		if cb.Amount != reward+fees {
+			v.problem(b.Index, cb.ID, "coinbase pays %d, expected reward %d plus fees %d", cb.Amount, reward, fees)
+		}
+		if len(cb.Inputs) > 0 {
+			v.problem(b.Index, cb.ID, "coinbase has inputs")
+		}
+		if len(cb.Outputs) != 1 || cb.Outputs[0].Owner != cb.ReceiverID || cb.Outputs[0].Amount != cb.Amount {
+			v.problem(b.Index, cb.ID, "coinbase must have one output paying %d to %s", cb.Amount, cb.ReceiverID)
+		}
+		if len(v.opts.Validators) > 0 {
+			// The first block of a partial dump has nothing to time its turn by
+			var elapsed time.Duration
+			if prev != nil {
+				elapsed = blockchain.BlockInterval(prev.Timestamp, b.Timestamp)
+			}
+			authority := blockchain.Authority{Validators: v.opts.Validators, TurnTimeout: v.opts.TurnTimeout}
+			if turn := authority.Producer(b.Index, elapsed); cb.ReceiverID != turn {
+				v.problem(b.Index, cb.ID, "block produced by %s, but it was validator %s's turn", cb.ReceiverID, turn)
+			} else if err := blockchain.VerifyBlockSignature(b, turn); err != nil {
+				v.problem(b.Index, "", "%v", err)
+			}
+		}
+		v.addOutputs(b.Index, cb)
+		v.report.Supply.Minted += cb.Amount - fees
+		v.report.Supply.Fees += fees
+	}
+}
+
+// transaction checks a transaction of the block at index block, mined at time
+// at, against the outputs before it
+func (v *verifier) transaction(block, at int64, tx blockchain.Transaction) {
+	// Zakat deductions are created by the server, not signed by a wallet
+	system := strings.EqualFold(tx.PubKey, "system")
+	if system {
+		v.report.SystemTxs++
+		switch tx.Type {
+		case "asset_issue":
+			v.issue(block, tx)
+			return
+		case "admin_mint":
+			v.mint(block, tx)
+			return
+		}
+	} else {
+		valid, err := wallet.VerifySignature(tx.PubKey, blockchain.SigningPayload(tx), tx.Signature)
+		switch {
+		case err != nil:
+			v.problem(block, tx.ID, "signature could not be checked: %v", err)
+		case !valid:
+			v.problem(block, tx.ID, "invalid signature")
+		default:
+			v.report.Signatures++
+		}
+		if walletID, err := wallet.WalletIDFromPub(tx.PubKey); err != nil || walletID != tx.SenderID {
+			v.problem(block, tx.ID, "public key does not belong to sender %s", tx.SenderID)
+		}
+	}
+
+	// Spend the inputs
+	var known uint64
+	var unknown int
+	for _, in := range tx.Inputs {
+		key := blockchain.UTXOKey(in.TxID, in.Index)
+		if out, ok := v.outputs[key]; ok {
+			if out.spent {
+				v.problem(block, tx.ID, "input %s is already spent", key)
+				continue
+			}
+			locked := blockchain.UTXO{Owner: out.owner, Lock: out.lock}
+			if err := locked.Unlock(tx.SenderID, in.Preimage, at); errors.Is(err, blockchain.ErrInputNotOwned) {
+				v.problem(block, tx.ID, "input %s belongs to %s, not the sender", key, out.owner)
+			} else if err != nil {
+				v.problem(block, tx.ID, "input %s cannot be unlocked: %v", key, err)
+			}
+			if out.asset != tx.AssetID {
+				v.problem(block, tx.ID, "input %s is of asset %q, not %q", key, out.asset, tx.AssetID)
+			}
+			out.spent = true
+			known += out.amount
+			continue
+		}
+		if !strings.HasPrefix(in.TxID, "faucet-") && !v.partial {
+			v.problem(block, tx.ID, "input %s does not exist", key)
+			continue
+		}
+		if v.external[key] {
+			v.problem(block, tx.ID, "input %s is already spent", key)
+			continue
+		}
+		v.external[key] = true
+		unknown++
+	}
+
+	var out uint64
+	for _, o := range tx.Outputs {
+		out += o.Amount
+	}
+	// An admin burn destroys its amount
+	if system && tx.Type == "admin_burn" {
+		out += tx.Amount
+		v.report.Supply.Burned += tx.Amount
+	}
+	switch {
+	case unknown == 0 && known != out+tx.Fee:
+		v.problem(block, tx.ID, "inputs total %d, but outputs %d plus fee %d is %d", known, out, tx.Fee, out+tx.Fee)
+	case unknown > 0 && known >= out+tx.Fee:
+		v.problem(block, tx.ID, "known inputs already cover outputs and fee, leaving nothing for %d external inputs", unknown)
+	case unknown > 0:
+		v.report.Supply.External += out + tx.Fee - known
+	}
+	v.addOutputs(block, tx)
+}
+
+// issue records an asset_issue transaction, which creates units of an asset
+// from nothing
+func (v *verifier) issue(block int64, tx blockchain.Transaction) {
+	if tx.AssetID == "" || len(tx.Inputs) > 0 {
+		v.problem(block, tx.ID, "asset issuance must name an asset and spend nothing")
+	}
+	if v.report.Supply.AssetsIssued == nil {
+		v.report.Supply.AssetsIssued = make(map[string]uint64)
+	}
+	for _, o := range tx.Outputs {
+		v.report.Supply.AssetsIssued[tx.AssetID] += o.Amount
+	}
+	v.addOutputs(block, tx)
+}
+
+// mint records an admin_mint transaction, which creates coins from nothing
+func (v *verifier) mint(block int64, tx blockchain.Transaction) {
+	if tx.AssetID != "" || len(tx.Inputs) > 0 {
+		v.problem(block, tx.ID, "admin mint must create coins and spend nothing")
+	}
+	for _, o := range tx.Outputs {
+		v.report.Supply.AdminMinted += o.Amount
+	}
+	v.addOutputs(block, tx)
+}
+
+// addOutputs records a transaction's outputs under the keys later inputs use
+func (v *verifier) addOutputs(block int64, tx blockchain.Transaction) {
+	for i, o := range tx.Outputs {
+		if o.OriginTx != tx.ID || o.Index != i {
+			v.problem(block, tx.ID, "output %d is labelled %s:%d", i, o.OriginTx, o.Index)
+		}
+		if err := o.Lock.Check(); err != nil {
+			v.problem(block, tx.ID, "output %d: %v", i, err)
+		}
+		v.outputs[blockchain.UTXOKey(tx.ID, i)] = &output{owner: o.Owner, asset: tx.AssetID, amount: o.Amount, lock: o.Lock}
+	}
+}
+
+func (r *Report) print(w io.Writer) {
+	status := "OK"
+	if !r.OK {
+		status = "FAILED"
+	}
+	fmt.Fprintf(w, "Chain verification: %s\n", status)
+	fmt.Fprintf(w, "  blocks:        %d (from #%d)\n", r.Blocks, r.FirstIndex)
+	fmt.Fprintf(w, "  transactions:  %d (%d signatures verified, %d system)\n", r.Transactions, r.Signatures, r.SystemTxs)
+	fmt.Fprintf(w, "  minted:        %d (+%d in fees)\n", r.Supply.Minted, r.Supply.Fees)
+	fmt.Fprintf(w, "  external:      %d (faucet grants)\n", r.Supply.External)
+	if r.Supply.AdminMinted > 0 || r.Supply.Burned > 0 {
+		fmt.Fprintf(w, "  admin:         %d minted, %d burned\n", r.Supply.AdminMinted, r.Supply.Burned)
+	}
+	fmt.Fprintf(w, "  unspent:       %d (conserved: %v)\n", r.Supply.Unspent, r.Supply.Conserved)
+	for _, asset := range slices.Sorted(maps.Keys(r.Supply.AssetsIssued)) {
+		fmt.Fprintf(w, "  issued %-7s %d\n", asset+":", r.Supply.AssetsIssued[asset])
+	}
+	for _, f := range r.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", f)
+	}
+	for _, f := range r.Problems {
+		fmt.Fprintf(w, "problem: %s\n", f)
+	}
+}
+
+func (f Finding) String() string {
+	if f.TxID != "" {
+		return fmt.Sprintf("block %d, tx %s: %s", f.Block, f.TxID, f.Message)
+	}
+	return fmt.Sprintf("block %d: %s", f.Block, f.Message)
+}
@@ -0,0 +1,107 @@
+// Command migrate applies and reverts the versioned schema migrations of the
+// database at SUPABASE_DB_URL, read from the environment or a .env file. The
+// server applies pending migrations itself at startup unless DB_AUTO_MIGRATE
+// is false; this command applies them explicitly, for example before a
+// deploy. With SANDBOX on it works on the sandbox's schema.
+//
+// Usage:
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down -steps 1
+//
+// The exit status is 0 on success, 1 when a migration failed and 2 on a usage
+// or connection error.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"blockchain-backend/config"
+	"blockchain-backend/database"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 5*time.Minute, "time limit for the whole run")
+	steps := flag.Int("steps", 1, "migrations to revert with down")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: migrate [flags] <status|up|down>\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+	if command != "status" && command != "up" && command != "down" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if command == "down" && *steps < 1 {
+		fmt.Fprintln(os.Stderr, "migrate: -steps must be at least 1")
+		os.Exit(2)
+	}
+
+	godotenv.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		os.Exit(2)
+	}
+	db, err := database.NewDBInSchema(cfg.Storage.DatabaseURL, cfg.Storage.Schema)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		os.Exit(2)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch command {
+	case "status":
+		status, err := db.MigrationStatus(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			os.Exit(1)
+		}
+		for _, m := range status {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = "applied " + m.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", m.Version, m.Name, applied)
+		}
+	case "up":
+		applied, err := db.Migrate(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			os.Exit(1)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := db.MigrateDown(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			os.Exit(1)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	}
+}
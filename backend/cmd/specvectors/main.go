@@ -0,0 +1,80 @@
+// Command specvectors is the conformance suite for the wire format described
+// in spec/README.md. By default it checks the published vectors against the
+// spec's reference functions and the backend's own code; given a file it
+// checks that file instead, so another client can fill in the outputs from
+// the inputs and have them verified here.
+//
+// Usage:
+//
+//	go run ./cmd/specvectors                    # check spec/vectors.json
+//	go run ./cmd/specvectors mine.json          # check a client's outputs
+//	go run ./cmd/specvectors -generate > spec/vectors.json
+//
+// The exit status is 0 when every vector matches, 1 on mismatches and 2 when
+// the vectors could not be read or generated.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"blockchain-backend/spec"
+)
+
+func main() {
+	generate := flag.Bool("generate", false, "print freshly generated vectors instead of checking")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: specvectors [-generate] [vectors.json]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *generate {
+		v, err := spec.Generate()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "specvectors: %v\n", err)
+			os.Exit(2)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+
+	var v spec.Vectors
+	var err error
+	switch flag.NArg() {
+	case 0:
+		v, err = spec.Load()
+	case 1:
+		var data []byte
+		if data, err = os.ReadFile(flag.Arg(0)); err == nil {
+			err = json.Unmarshal(data, &v)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "specvectors: %v\n", err)
+		os.Exit(2)
+	}
+	if v.Version != spec.VectorsVersion {
+		fmt.Fprintf(os.Stderr, "specvectors: vectors are version %d, this backend speaks version %d\n", v.Version, spec.VectorsVersion)
+		os.Exit(1)
+	}
+
+	mismatches := spec.Check(v)
+	for _, m := range mismatches {
+		fmt.Println("✗", m)
+	}
+	total := len(v.Keys) + len(v.Payloads) + len(v.Merkle) + len(v.Blocks) + len(v.Txs)
+	if len(mismatches) > 0 {
+		fmt.Printf("%d mismatches across %d vectors\n", len(mismatches), total)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %d vectors match (version %d)\n", total, v.Version)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+func adminCommand(c *client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Admin tasks; need --admin-key or an admin --wallet",
+	}
+
+	check := &cobra.Command{
+		Use:   "check <wallet>",
+		Short: "Report whether a wallet is an admin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("GET", "/admin/check/"+url.PathEscape(args[0]), nil)
+		},
+	}
+
+	orgAdmins := &cobra.Command{Use: "org-admins", Short: "Manage the admins of the --org organization"}
+	orgAdmins.AddCommand(
+		&cobra.Command{
+			Use:   "add <wallet>",
+			Short: "Make a member wallet an organization admin",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return c.print("POST", "/org/admins", map[string]string{"wallet_id": args[0]})
+			},
+		},
+		&cobra.Command{
+			Use:   "remove <wallet>",
+			Short: "Take a wallet's organization admin rights away",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				return c.print("DELETE", "/org/admins/"+url.PathEscape(args[0]), nil)
+			},
+		},
+	)
+
+	var reason string
+	freeze := &cobra.Command{
+		Use:   "freeze <wallet>",
+		Short: "Stop a wallet from spending",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("POST", "/admin/wallets/"+url.PathEscape(args[0])+"/freeze", map[string]string{"reason": reason})
+		},
+	}
+	freeze.Flags().StringVar(&reason, "reason", "", "why the wallet is frozen")
+	freeze.MarkFlagRequired("reason")
+	unfreeze := &cobra.Command{
+		Use:   "unfreeze <wallet>",
+		Short: "Let a frozen wallet spend again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("POST", "/admin/wallets/"+url.PathEscape(args[0])+"/unfreeze", map[string]string{})
+		},
+	}
+
+	var repair bool
+	reconcile := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Check every wallet's ledger against its stored balance",
+		RunE: func(*cobra.Command, []string) error {
+			if repair {
+				return c.print("POST", "/admin/reconcile/repair", map[string]string{})
+			}
+			return c.print("GET", "/admin/reconcile", nil)
+		},
+	}
+	reconcile.Flags().BoolVar(&repair, "repair", false, "also repair the wallets that disagree")
+
+	snapshot := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Snapshot the chain state at the current tip",
+		RunE: func(*cobra.Command, []string) error {
+			return c.print("POST", "/admin/snapshots", map[string]string{})
+		},
+	}
+
+	reset := &cobra.Command{
+		Use:   "reset",
+		Short: "Wipe the chain, stored or not, and start over from a new genesis block",
+		RunE: func(*cobra.Command, []string) error {
+			return c.print("POST", "/admin/reset", map[string]string{})
+		},
+	}
+
+	var wallets, transactions, blocks int
+	fixtures := &cobra.Command{
+		Use:   "fixtures",
+		Short: "Generate load-test wallets, transfers and blocks",
+		RunE: func(*cobra.Command, []string) error {
+			return c.print("POST", "/admin/fixtures", map[string]int{"wallets": wallets, "transactions": transactions, "blocks": blocks})
+		},
+	}
+	fixtures.Flags().IntVar(&wallets, "wallets", 10, "wallets to create")
+	fixtures.Flags().IntVar(&transactions, "transactions", 0, "random transfers between them")
+	fixtures.Flags().IntVar(&blocks, "blocks", 1, "blocks to mine")
+
+	cmd.AddCommand(check, orgAdmins, freeze, unfreeze, reconcile, snapshot, reset, fixtures, importChainCommand(c))
+	return cmd
+}
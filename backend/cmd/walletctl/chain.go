@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"blockchain-backend/blockchain"
+)
+
+func sendCommand(c *client) *cobra.Command {
+	var from, to, amount, note, assetID, key, keyFile, token, totp, limitOTP string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send coins to a wallet ID or @handle",
+		Long: "Send coins to a wallet ID or @handle, signed with --signing-token, --key-file or\n" +
+			"--private-key. With --dry-run the send is only simulated: nothing is signed or queued.",
+		RunE: func(*cobra.Command, []string) error {
+			units, err := blockchain.ParseAmount(amount)
+			if err != nil {
+				return fmt.Errorf("--amount %q: %w", amount, err)
+			}
+			req := map[string]interface{}{"sender_id": from, "receiver_id": to, "amount": units, "note": note}
+			if assetID != "" {
+				req["asset_id"] = assetID
+			}
+			if dryRun {
+				return c.print("POST", "/send/simulate", req)
+			}
+
+			priv, err := privateKey(key, keyFile)
+			if err != nil {
+				return err
+			}
+			for field, v := range map[string]string{"private_key": priv, "signing_token": token, "totp_code": totp, "limit_otp": limitOTP} {
+				if v != "" {
+					req[field] = v
+				}
+			}
+			return c.print("POST", "/send", req)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&from, "from", "", "sending wallet")
+	f.StringVar(&to, "to", "", "receiving wallet ID or @handle")
+	f.StringVar(&amount, "amount", "", "coins to send, e.g. 1.5")
+	f.StringVar(&note, "note", "", "note for the receiver")
+	f.StringVar(&assetID, "asset", "", "asset symbol to send instead of coins")
+	f.StringVar(&token, "signing-token", "", "token from a signing session")
+	f.StringVar(&key, "private-key", "", "sender's private key (deprecated by the API; prefer --signing-token)")
+	f.StringVar(&keyFile, "key-file", "", "file holding the sender's private key")
+	f.StringVar(&totp, "totp", "", "authenticator code, for sends above the wallet's 2FA threshold")
+	f.StringVar(&limitOTP, "limit-otp", "", "emailed code letting the send exceed the wallet's spending limits")
+	f.BoolVar(&dryRun, "dry-run", false, "only simulate the send")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+	return cmd
+}
+
+func mineCommand(c *client) *cobra.Command {
+	var miner, token string
+	var noWait bool
+	cmd := &cobra.Command{
+		Use:   "mine",
+		Short: "Mine the pending transactions into a block",
+		Long: "Queue a job mining the pending transactions into a block and wait for it, printing\n" +
+			"the block. With --no-wait the queued job is printed instead; follow it with mine-job.\n" +
+			"Under proof of authority the validator's --signing-token signs the block.",
+		RunE: func(*cobra.Command, []string) error {
+			body := map[string]string{"miner_wallet_id": miner}
+			if token != "" {
+				body["signing_token"] = token
+			}
+			out, err := c.do("POST", "/mine", body)
+			if err != nil {
+				return err
+			}
+			if noWait {
+				return printJSON(out)
+			}
+			var job mineJob
+			if err := json.Unmarshal(out, &job); err != nil {
+				return err
+			}
+			return c.waitMineJob(job.ID)
+		},
+	}
+	cmd.Flags().StringVar(&miner, "miner", "", "wallet paid the block reward")
+	cmd.Flags().StringVar(&token, "signing-token", "", "validator's signing session token, under proof of authority")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "print the queued job instead of waiting for the block")
+	cmd.MarkFlagRequired("miner")
+	return cmd
+}
+
+func mineJobCommand(c *client) *cobra.Command {
+	var wait bool
+	cmd := &cobra.Command{
+		Use:   "mine-job <job-id>",
+		Short: "Show a mining job, or with --wait the block once it is mined",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if wait {
+				return c.waitMineJob(args[0])
+			}
+			return c.print("GET", "/mine/jobs/"+url.PathEscape(args[0]), nil)
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the job and print its block")
+	return cmd
+}
+
+// mineJob is the part of a mining job walletctl follows
+type mineJob struct {
+	ID     string          `json:"id"`
+	Status string          `json:"status"`
+	Block  json.RawMessage `json:"block"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// mineJobPoll is how often waitMineJob checks on a job
+const mineJobPoll = 500 * time.Millisecond
+
+// waitMineJob polls a mining job until it finishes and prints its block
+func (c *client) waitMineJob(id string) error {
+	for {
+		out, err := c.do("GET", "/mine/jobs/"+url.PathEscape(id), nil)
+		if err != nil {
+			return err
+		}
+		var job mineJob
+		if err := json.Unmarshal(out, &job); err != nil {
+			return err
+		}
+		switch job.Status {
+		case "done":
+			return printJSON(job.Block)
+		case "failed":
+			if job.Error != nil {
+				return fmt.Errorf("%s: %s", job.Error.Code, job.Error.Message)
+			}
+			return fmt.Errorf("mining job %s failed", id)
+		}
+		time.Sleep(mineJobPoll)
+	}
+}
+
+func blocksCommand(c *client) *cobra.Command {
+	var from, to int64
+	cmd := &cobra.Command{
+		Use:   "blocks",
+		Short: "List blocks, all of them or those from --from to --to",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			q := url.Values{}
+			if cmd.Flags().Changed("from") {
+				q.Set("from", strconv.FormatInt(from, 10))
+			}
+			if cmd.Flags().Changed("to") {
+				q.Set("to", strconv.FormatInt(to, 10))
+			}
+			path := "/blocks"
+			if len(q) > 0 {
+				path += "?" + q.Encode()
+			}
+			return c.print("GET", path, nil)
+		},
+	}
+	cmd.Flags().Int64Var(&from, "from", 0, "first block index")
+	cmd.Flags().Int64Var(&to, "to", 0, "last block index")
+	return cmd
+}
+
+func blockCommand(c *client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "block <index>",
+		Short: "Show one block",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if _, err := strconv.ParseInt(args[0], 10, 64); err != nil {
+				return fmt.Errorf("block index %q is not a number", args[0])
+			}
+			return c.print("GET", "/block/"+args[0], nil)
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// client calls the node's REST API
+type client struct {
+	base     string
+	adminKey string
+	walletID string
+	session  string
+	orgID    string
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute} // fixtures and exports run long
+
+// do sends body, when not nil, as JSON and returns the response body. Error
+// envelopes come back as errors carrying their code and message.
+func (c *client) do(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader, contentType = bytes.NewReader(b), "application/json"
+	}
+	resp, err := c.stream(method, path, contentType, reader)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// stream sends body, when not nil, as contentType and returns the response
+// for the caller to read and close, so large files need not fit in memory.
+// Errors come back as from do.
+func (c *client) stream(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(c.base, "/")+"/api"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.adminKey != "" {
+		req.Header.Set("X-Admin-Key", c.adminKey)
+	}
+	if c.walletID != "" {
+		req.Header.Set("X-Wallet-ID", c.walletID)
+	}
+	if c.session != "" {
+		req.Header.Set("Authorization", "Bearer "+c.session)
+	}
+	if c.orgID != "" {
+		req.Header.Set("X-Org-ID", c.orgID)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		out, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		var envelope struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(out, &envelope) == nil && envelope.Error.Code != "" {
+			return nil, fmt.Errorf("%s: %s", envelope.Error.Code, envelope.Error.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return resp, nil
+}
+
+// print calls the API and prints the response
+func (c *client) print(method, path string, body interface{}) error {
+	out, err := c.do(method, path, body)
+	if err != nil {
+		return err
+	}
+	return printJSON(out)
+}
+
+func printJSON(raw []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(os.Stdout)
+	return err
+}
+
+// privateKey returns the key given directly or read from a file, trimmed
+func privateKey(key, file string) (string, error) {
+	if file == "" {
+		return key, nil
+	}
+	if key != "" {
+		return "", fmt.Errorf("give either --private-key or --key-file, not both")
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
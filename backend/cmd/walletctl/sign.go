@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/wallet/offline"
+)
+
+// Air-gapped sends take three steps: prepare on a networked machine, sign on
+// the offline one, submit from the networked one again.
+
+func prepareCommand(c *client) *cobra.Command {
+	var from, to, amount, note, assetID string
+	cmd := &cobra.Command{
+		Use:   "prepare",
+		Short: "Prepare an unsigned transfer for `walletctl sign`",
+		RunE: func(*cobra.Command, []string) error {
+			units, err := blockchain.ParseAmount(amount)
+			if err != nil {
+				return fmt.Errorf("--amount %q: %w", amount, err)
+			}
+			q := url.Values{"sender_id": {from}, "receiver_id": {to}, "amount": {strconv.FormatUint(units, 10)}, "note": {note}}
+			if assetID != "" {
+				q.Set("asset_id", assetID)
+			}
+			return c.print("GET", "/transactions/prepare?"+q.Encode(), nil)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&from, "from", "", "sending wallet")
+	f.StringVar(&to, "to", "", "receiving wallet")
+	f.StringVar(&amount, "amount", "", "coins to send, e.g. 1.5")
+	f.StringVar(&note, "note", "", "note for the receiver")
+	f.StringVar(&assetID, "asset", "", "asset symbol to send instead of coins")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+	return cmd
+}
+
+func signCommand() *cobra.Command {
+	var key, keyFile string
+	cmd := &cobra.Command{
+		Use:   "sign <unsigned.json|->",
+		Short: "Sign a prepared transaction locally, without contacting the node",
+		Long: "Sign the output of `walletctl prepare` (or a bare transaction) with the sender's key.\n" +
+			"Nothing is sent anywhere; the signed body for `walletctl submit` is printed.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			unsigned, err := readInput(args[0])
+			if err != nil {
+				return err
+			}
+			priv, err := privateKey(key, keyFile)
+			if err != nil {
+				return err
+			}
+			signed, err := offline.SignJSON(unsigned, priv)
+			if err != nil {
+				return err
+			}
+			return printJSON(signed)
+		},
+	}
+	cmd.Flags().StringVar(&key, "private-key", "", "sender's private key")
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "file holding the sender's private key")
+	return cmd
+}
+
+func submitCommand(c *client) *cobra.Command {
+	var totp string
+	cmd := &cobra.Command{
+		Use:   "submit <signed.json|->",
+		Short: "Queue a transaction signed by `walletctl sign`",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			b, err := readInput(args[0])
+			if err != nil {
+				return err
+			}
+			var req map[string]json.RawMessage
+			if err := json.Unmarshal(b, &req); err != nil || req["transaction"] == nil {
+				return fmt.Errorf("%s is not the output of walletctl sign", args[0])
+			}
+			if totp != "" {
+				req["totp_code"], _ = json.Marshal(totp)
+			}
+			return c.print("POST", "/transactions/submit-signed", req)
+		},
+	}
+	cmd.Flags().StringVar(&totp, "totp", "", "authenticator code, for sends above the wallet's 2FA threshold")
+	return cmd
+}
+
+// readInput reads a file, or stdin for "-"
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"blockchain-backend/wallet"
+)
+
+func otpCommand(c *client) *cobra.Command {
+	cmd := &cobra.Command{Use: "otp", Short: "Email one-time codes"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "send <email>",
+		Short: "Email a one-time code, e.g. to verify an address before wallet create",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("POST", "/otp/send", map[string]string{"email": args[0]})
+		},
+	})
+	return cmd
+}
+
+func walletCommand(c *client) *cobra.Command {
+	cmd := &cobra.Command{Use: "wallet", Short: "Create, inspect, back up and restore wallets"}
+
+	var name, email, cnic, walletType, code, keyOut string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Generate a key pair locally and register its wallet",
+		Long: "Generate a key pair locally and register its wallet. The email must be verified first:\n" +
+			"pass the code from `walletctl otp send` as --otp. The private key is written to --key-out,\n" +
+			"or printed when it is not given; it cannot be recovered if lost.",
+		RunE: func(*cobra.Command, []string) error {
+			if code != "" {
+				if _, err := c.do("POST", "/otp/verify", map[string]string{"email": email, "code": code}); err != nil {
+					return err
+				}
+			}
+			pub, priv := wallet.GenerateKeypair()
+			out, err := c.do("POST", "/create-wallet", map[string]string{
+				"public": pub, "private": priv, "name": name, "email": email, "cnic": cnic, "type": walletType,
+			})
+			if err != nil {
+				return err
+			}
+			if keyOut != "" {
+				if err := os.WriteFile(keyOut, []byte(priv+"\n"), 0o600); err != nil {
+					return fmt.Errorf("the wallet was created but its key could not be saved: %w; private key: %s", err, priv)
+				}
+			} else {
+				fmt.Fprintln(os.Stderr, "Private key (store it safely, it is not shown again):", priv)
+			}
+			return printJSON(out)
+		},
+	}
+	create.Flags().StringVar(&name, "name", "", "owner's full name")
+	create.Flags().StringVar(&email, "email", "", "owner's email, verified with --otp")
+	create.Flags().StringVar(&cnic, "cnic", "", "owner's CNIC")
+	create.Flags().StringVar(&walletType, "type", "", "wallet type to request (personal by default)")
+	create.Flags().StringVar(&code, "otp", "", "code emailed by `walletctl otp send`")
+	create.Flags().StringVar(&keyOut, "key-out", "", "file to write the private key to (mode 0600)")
+	create.MarkFlagRequired("name")
+	create.MarkFlagRequired("email")
+
+	show := &cobra.Command{
+		Use:   "show <wallet>",
+		Short: "Show a wallet, its private key masked",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("GET", "/wallet/"+url.PathEscape(args[0]), nil)
+		},
+	}
+	balance := &cobra.Command{
+		Use:   "balance <wallet>",
+		Short: "Show a wallet's spendable, pending and locked balance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.print("GET", "/balance/"+url.PathEscape(args[0]), nil)
+		},
+	}
+
+	var key, keyFile, passphrase, out string
+	export := &cobra.Command{
+		Use:   "export <wallet>",
+		Short: "Export an encrypted backup of a wallet",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			priv, err := privateKey(key, keyFile)
+			if err != nil {
+				return err
+			}
+			backup, err := c.do("POST", "/wallet/"+url.PathEscape(args[0])+"/export", map[string]string{"private_key": priv, "passphrase": passphrase})
+			if err != nil {
+				return err
+			}
+			if out == "" {
+				return printJSON(backup)
+			}
+			return os.WriteFile(out, backup, 0o600)
+		},
+	}
+	export.Flags().StringVar(&key, "private-key", "", "the wallet's private key")
+	export.Flags().StringVar(&keyFile, "key-file", "", "file holding the wallet's private key")
+	export.Flags().StringVar(&passphrase, "passphrase", "", "passphrase the backup is encrypted with")
+	export.Flags().StringVar(&out, "out", "", "file to write the backup to (default stdout)")
+	export.MarkFlagRequired("passphrase")
+
+	var in, importPassphrase string
+	restore := &cobra.Command{
+		Use:   "import",
+		Short: "Restore a wallet from an exported backup",
+		RunE: func(*cobra.Command, []string) error {
+			b, err := os.ReadFile(in)
+			if err != nil {
+				return err
+			}
+			var backup json.RawMessage
+			if err := json.Unmarshal(b, &backup); err != nil {
+				return fmt.Errorf("%s is not a backup: %w", in, err)
+			}
+			return c.print("POST", "/wallet/import", map[string]interface{}{"backup": backup, "passphrase": importPassphrase})
+		},
+	}
+	restore.Flags().StringVar(&in, "file", "", "backup file from `walletctl wallet export`")
+	restore.Flags().StringVar(&importPassphrase, "passphrase", "", "passphrase the backup was encrypted with")
+	restore.MarkFlagRequired("file")
+	restore.MarkFlagRequired("passphrase")
+
+	cmd.AddCommand(create, show, balance, export, restore)
+	return cmd
+}
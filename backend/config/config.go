@@ -0,0 +1,327 @@
+// Package config loads every setting the node reads from the environment.
+// Settings are read and validated once at startup and handed to the
+// packages that use them, so none of them reads the environment itself.
+package config
+
+import (
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"blockchain-backend/alerts"
+	"blockchain-backend/blockchain"
+	"blockchain-backend/crypto"
+	"blockchain-backend/database"
+	"blockchain-backend/mailer"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/validation"
+)
+
+// Deployment modes selectable with APP_ENV
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// DefaultSandboxName names the sandbox when SANDBOX_NAME is not set
+const DefaultSandboxName = "default"
+
+// sandboxName is what SANDBOX_NAME accepts; it ends up in a schema name
+var sandboxName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// MinSecretLength is the shortest ENCRYPTION_KEY and ADMIN_API_KEY accepted
+// in production
+const MinSecretLength = 16
+
+// Config is the node's configuration
+type Config struct {
+	Env         string // APP_ENV
+	Port        string
+	GRPCPort    string
+	AdminAPIKey string // empty leaves admin access to admin wallets
+
+	// Secrets; the development defaults are refused in production
+	EncryptionKey string
+	AdminEmail    string
+	OTPSecret     string // empty uses a random key per process
+
+	Storage        Storage
+	Sandbox        string // SANDBOX_NAME when SANDBOX is on: the data lives apart from the real tables
+	ChainReset     bool   // ALLOW_CHAIN_RESET: POST /api/admin/reset may wipe the chain
+	RedisURL       string
+	GoogleClientID string // empty disables Google login
+	SMTP           mailer.Config
+	Alerts         alerts.Config
+
+	Consensus     Consensus
+	Confirmations blockchain.ConfirmationPolicy
+	Mempool       blockchain.MempoolPolicy
+	Supply        blockchain.SupplyPolicy
+
+	CoinSelection         string
+	Consolidation         services.ConsolidationPolicy
+	InheritanceInterval   time.Duration
+	Prune                 services.PrunePolicy
+	Snapshots             services.SnapshotPolicy
+	BalanceRepairInterval time.Duration // 0 disables the repair job
+	SessionTTL            time.Duration
+	SigningSessionTTL     time.Duration
+	RejectRawKeys         bool
+	TwoFactorThreshold    uint64
+	UnverifiedDailyLimit  uint64 // 0 for no limit
+	Faucet                services.FaucetPolicy
+	WebhookRetry          services.RetryPolicy
+	WebhookAllowPrivate   bool
+	MultiTenant           bool
+	OTP                   otp.Policy
+	GeoIPPath             string // empty leaves sensitive logs without a location
+	NewOriginAlerts       bool   // email users signing in from a new device or country
+	NotifyIncomingAmount  uint64 // default smallest incoming payment emailed to its receiver
+	Rates                 services.RatePolicy
+	Directory             services.DirectoryPolicy
+}
+
+// Storage is where the node persists its state
+type Storage struct {
+	Backend     string // database.BackendPostgres, BackendSQLite or BackendMemory
+	DatabaseURL string // SUPABASE_DB_URL
+	SQLitePath  string
+	AutoMigrate bool
+	Schema      string // Postgres schema of the tables; empty for the default search path
+}
+
+// Consensus is how the node produces blocks
+type Consensus struct {
+	Mode          string        // blockchain.ConsensusPoW or ConsensusPoA
+	Validators    []string      // wallets taking turns under proof of authority, in order
+	BlockInterval time.Duration // how often a validator produces a block
+	TurnTimeout   time.Duration // how long a validator has before the turn passes on; 0 never passes it
+}
+
+// DefaultBlockInterval is how often validators produce blocks unless
+// BLOCK_INTERVAL_SECONDS says otherwise
+const DefaultBlockInterval = 10 * time.Second
+
+// Production reports whether the node runs in production mode
+func (c *Config) Production() bool {
+	return c.Env == Production
+}
+
+// Load reads the configuration from the environment. Every invalid value is
+// reported in the returned error; in production, so are the development
+// defaults of the secrets and the settings that are unsafe in production.
+func Load() (*Config, error) {
+	return load(os.Getenv)
+}
+
+func load(getenv func(string) string) (*Config, error) {
+	r := &reader{getenv: getenv}
+	c := &Config{
+		Env:            r.oneOf("APP_ENV", Development, Development, Production),
+		Port:           r.str("PORT", "8080"),
+		GRPCPort:       r.str("GRPC_PORT", "9090"),
+		AdminAPIKey:    r.str("ADMIN_API_KEY", ""),
+		EncryptionKey:  r.str("ENCRYPTION_KEY", crypto.FallbackKey),
+		AdminEmail:     r.str("ADMIN_EMAIL", database.DefaultAdminEmail),
+		OTPSecret:      r.str("OTP_SECRET", ""),
+		RedisURL:       r.str("REDIS_URL", ""),
+		GoogleClientID: r.str("GOOGLE_CLIENT_ID", ""),
+		SMTP: mailer.Config{
+			Host:     r.str("SMTP_HOST", ""),
+			Port:     r.str("SMTP_PORT", ""),
+			Username: r.str("SMTP_USERNAME", ""),
+			Password: getenv("SMTP_PASSWORD"),
+			From:     r.str("SMTP_FROM", ""),
+		},
+	}
+
+	c.Storage = Storage{
+		DatabaseURL: r.str("SUPABASE_DB_URL", ""),
+		SQLitePath:  r.str("SQLITE_PATH", database.DefaultSQLitePath),
+		AutoMigrate: r.boolean("DB_AUTO_MIGRATE", true),
+	}
+	// Unset, the backend is postgres when a database URL is given
+	defaultBackend := database.BackendMemory
+	if c.Storage.DatabaseURL != "" {
+		defaultBackend = database.BackendPostgres
+	}
+	c.Storage.Backend = r.oneOf("STORAGE_BACKEND", defaultBackend, database.BackendPostgres, database.BackendSQLite, database.BackendMemory)
+	if c.Storage.Backend == database.BackendPostgres && c.Storage.DatabaseURL == "" {
+		r.problems = append(r.problems, "STORAGE_BACKEND=postgres needs SUPABASE_DB_URL")
+	}
+
+	// A sandbox keeps its tables in a schema, or a SQLite file, of its own
+	if r.boolean("SANDBOX", false) {
+		c.Sandbox = r.str("SANDBOX_NAME", DefaultSandboxName)
+		if !sandboxName.MatchString(c.Sandbox) {
+			r.invalid("SANDBOX_NAME", c.Sandbox, "1-32 lowercase letters, digits or underscores, starting with a letter")
+		}
+		c.Storage.Schema = database.SandboxSchema(c.Sandbox)
+		c.Storage.SQLitePath = database.SandboxPath(c.Storage.SQLitePath, c.Sandbox)
+	}
+	c.ChainReset = r.boolean("ALLOW_CHAIN_RESET", c.Sandbox != "")
+
+	c.Alerts = alerts.DefaultConfig()
+	c.Alerts.Interval = r.duration("ALERT_EVAL_INTERVAL_SECONDS", c.Alerts.Interval, time.Second, 1)
+	c.Alerts.MaxBlockAge = r.duration("ALERT_NO_BLOCK_HOURS", c.Alerts.MaxBlockAge, time.Hour, 1)
+	c.Alerts.MempoolThreshold = r.integer("ALERT_MEMPOOL_THRESHOLD", c.Alerts.MempoolThreshold, 1, maxInt)
+	c.Alerts.DBFailures = r.integer("ALERT_DB_FAILURES", c.Alerts.DBFailures, 1, maxInt)
+	c.Alerts.WebhookURL = r.str("ALERT_WEBHOOK_URL", "")
+	c.Alerts.Emails = r.list("ALERT_EMAILS")
+
+	// Proof of work stays the default; proof of authority needs its validators
+	c.Consensus = Consensus{
+		Mode:          r.oneOf("CONSENSUS", blockchain.ConsensusPoW, blockchain.ConsensusPoW, blockchain.ConsensusPoA),
+		Validators:    r.list("VALIDATORS"),
+		BlockInterval: r.duration("BLOCK_INTERVAL_SECONDS", DefaultBlockInterval, time.Second, 1),
+		TurnTimeout:   r.duration("VALIDATOR_TURN_TIMEOUT_SECONDS", blockchain.DefaultTurnTimeout, time.Second, 0),
+	}
+	if c.Consensus.Mode == blockchain.ConsensusPoA && len(c.Consensus.Validators) == 0 {
+		r.problems = append(r.problems, "CONSENSUS=poa needs VALIDATORS, the wallet IDs taking turns")
+	}
+	if t := c.Consensus.TurnTimeout; t > 0 && t <= c.Consensus.BlockInterval {
+		r.problems = append(r.problems, "VALIDATOR_TURN_TIMEOUT_SECONDS must be longer than BLOCK_INTERVAL_SECONDS, or every turn would pass on")
+	}
+
+	c.Confirmations = blockchain.DefaultConfirmationPolicy()
+	c.Confirmations.Spend = r.integer("MIN_CONFIRMATIONS_SPEND", c.Confirmations.Spend, 1, maxInt)
+	c.Confirmations.Webhook = r.integer("MIN_CONFIRMATIONS_WEBHOOK", c.Confirmations.Webhook, 1, maxInt)
+	c.Confirmations.Invoice = r.integer("MIN_CONFIRMATIONS_INVOICE", c.Confirmations.Invoice, 1, maxInt)
+
+	c.Mempool = blockchain.DefaultMempoolPolicy()
+	c.Mempool.MaxBlockTxs = r.integer("MAX_BLOCK_TXS", 0, 0, maxInt)
+	c.Mempool.Quotas = r.laneQuotas("MEMPOOL_LANE_QUOTAS")
+	if total := quotaTotal(c.Mempool.Quotas); c.Mempool.MaxBlockTxs > 0 && total > c.Mempool.MaxBlockTxs {
+		log.Printf("⚠️  MEMPOOL_LANE_QUOTAS reserve %d slots but MAX_BLOCK_TXS is %d; higher lanes fill their quotas first", total, c.Mempool.MaxBlockTxs)
+	}
+
+	c.Supply = blockchain.DefaultSupplyPolicy()
+	c.Supply.Cap = r.amount("SUPPLY_CAP", 0, maxUint64)
+	c.Supply.Mode = r.oneOf("SUPPLY_CAP_MODE", c.Supply.Mode, blockchain.SupplyCapStop, blockchain.SupplyCapHalve)
+
+	c.CoinSelection = r.oneOf("COIN_SELECTION", services.CoinSelectAuto, services.CoinSelectionStrategies...)
+
+	c.Consolidation = services.DefaultConsolidationPolicy()
+	c.Consolidation.DustThreshold = r.amount("CONSOLIDATE_DUST_THRESHOLD", 0, maxUint64)
+	c.Consolidation.MinDust = r.integer("CONSOLIDATE_MIN_DUST", c.Consolidation.MinDust, 2, validation.MaxTxInputs)
+	c.Consolidation.Interval = r.duration("CONSOLIDATE_INTERVAL_MINUTES", c.Consolidation.Interval, time.Minute, 1)
+
+	c.InheritanceInterval = r.duration("INHERITANCE_CHECK_INTERVAL_MINUTES", services.DefaultInheritanceCheckInterval, time.Minute, 1)
+
+	c.Prune = services.DefaultPrunePolicy()
+	c.Prune.KeepBlocks = int64(r.integer("PRUNE_KEEP_BLOCKS", int(c.Prune.KeepBlocks), 0, maxInt))
+	c.Prune.Interval = r.duration("PRUNE_INTERVAL_MINUTES", 0, time.Minute, 0)
+
+	c.Snapshots = services.DefaultSnapshotPolicy()
+	c.Snapshots.Interval = r.duration("SNAPSHOT_INTERVAL_MINUTES", c.Snapshots.Interval, time.Minute, 0)
+	c.Snapshots.Keep = r.integer("SNAPSHOT_KEEP", c.Snapshots.Keep, 1, maxInt)
+
+	c.BalanceRepairInterval = r.duration("BALANCE_REPAIR_MINUTES", services.DefaultBalanceRepairInterval, time.Minute, 0)
+	c.SessionTTL = r.duration("SESSION_TTL_HOURS", services.DefaultSessionTTL, time.Hour, 1)
+	c.SigningSessionTTL = time.Duration(r.integer("SIGNING_SESSION_TTL_MINUTES", int(services.DefaultSigningSessionTTL/time.Minute), 1, int(services.MaxSigningSessionTTL/time.Minute))) * time.Minute
+	c.RejectRawKeys = r.boolean("REJECT_PRIVATE_KEYS", false)
+	c.TwoFactorThreshold = r.amount("TWOFA_DEFAULT_THRESHOLD", services.DefaultTwoFactorThreshold, maxUint64)
+	c.UnverifiedDailyLimit = r.amount("KYC_UNVERIFIED_DAILY_LIMIT", services.DefaultUnverifiedDailyLimit, maxUint64)
+
+	c.Faucet = services.DefaultFaucetPolicy()
+	c.Faucet.Mode = r.oneOf("FAUCET_MODE", c.Faucet.Mode, services.FaucetModeClaim, services.FaucetModeSignup)
+	c.Faucet.Amount = r.amount("FAUCET_AMOUNT", c.Faucet.Amount, services.MaxFaucetAmount)
+	c.Faucet.EmailCooldown = r.duration("FAUCET_EMAIL_COOLDOWN_HOURS", c.Faucet.EmailCooldown, time.Hour, 0)
+	c.Faucet.IPCooldown = r.duration("FAUCET_IP_COOLDOWN_MINUTES", c.Faucet.IPCooldown, time.Minute, 0)
+
+	c.WebhookRetry = services.DefaultWebhookRetryPolicy()
+	c.WebhookRetry.MaxAttempts = r.integer("WEBHOOK_MAX_ATTEMPTS", c.WebhookRetry.MaxAttempts, 1, maxInt)
+	c.WebhookRetry.BaseDelay = r.duration("WEBHOOK_RETRY_BASE_SECONDS", c.WebhookRetry.BaseDelay, time.Second, 1)
+	c.WebhookAllowPrivate = r.boolean("WEBHOOK_ALLOW_PRIVATE_URLS", false)
+
+	c.MultiTenant = r.oneOf("TENANCY_MODE", services.TenancySingle, services.TenancySingle, services.TenancyMulti) == services.TenancyMulti
+
+	c.OTP = otp.DefaultPolicy()
+	c.OTP.CodeTTL = r.duration("OTP_TTL_MINUTES", c.OTP.CodeTTL, time.Minute, 1)
+	c.OTP.MaxAttempts = r.integer("OTP_MAX_ATTEMPTS", c.OTP.MaxAttempts, 1, maxInt)
+	c.OTP.LockoutDuration = r.duration("OTP_LOCKOUT_MINUTES", c.OTP.LockoutDuration, time.Minute, 0)
+	c.OTP.ResendCooldown = r.duration("OTP_RESEND_COOLDOWN_SECONDS", c.OTP.ResendCooldown, time.Second, 0)
+	c.OTP.DailyLimit = r.integer("OTP_DAILY_LIMIT", c.OTP.DailyLimit, 0, maxInt)
+
+	c.GeoIPPath = r.str("GEOIP_DB_PATH", "")
+	c.NewOriginAlerts = r.boolean("NEW_DEVICE_ALERTS", true)
+	c.NotifyIncomingAmount = r.amount("NOTIFY_INCOMING_THRESHOLD", services.DefaultNotifyIncomingThreshold, maxUint64)
+
+	c.Rates = services.DefaultRatePolicy()
+	c.Rates.FeedURL = r.str("RATES_FEED_URL", "")
+	c.Rates.Refresh = r.duration("RATES_REFRESH_MINUTES", c.Rates.Refresh, time.Minute, 1)
+
+	c.Directory = services.DefaultDirectoryPolicy()
+	c.Directory.ResolveLimit = r.integer("DIRECTORY_RESOLVE_LIMIT", c.Directory.ResolveLimit, 0, maxInt)
+
+	if c.Production() {
+		r.problems = append(r.problems, c.insecure()...)
+	}
+	if len(r.problems) > 0 {
+		return nil, errors.New("invalid configuration: " + strings.Join(r.problems, "; "))
+	}
+	return c, nil
+}
+
+// insecure lists the settings that must not reach production: the
+// development secrets every checkout shares, and protections turned off
+func (c *Config) insecure() []string {
+	var problems []string
+	switch {
+	case c.EncryptionKey == crypto.FallbackKey:
+		problems = append(problems, "ENCRYPTION_KEY must be set in production (the development key is published)")
+	case len(c.EncryptionKey) < MinSecretLength:
+		problems = append(problems, "ENCRYPTION_KEY must be at least 16 characters in production")
+	}
+	if c.AdminEmail == database.DefaultAdminEmail {
+		problems = append(problems, "ADMIN_EMAIL must be set in production (anyone signing up with the default one becomes an admin)")
+	}
+	if c.AdminAPIKey != "" && len(c.AdminAPIKey) < MinSecretLength {
+		problems = append(problems, "ADMIN_API_KEY must be at least 16 characters in production")
+	}
+	if c.WebhookAllowPrivate {
+		problems = append(problems, "WEBHOOK_ALLOW_PRIVATE_URLS cannot be enabled in production")
+	}
+	if c.ChainReset && c.Sandbox == "" {
+		problems = append(problems, "ALLOW_CHAIN_RESET can only be enabled in production inside a SANDBOX")
+	}
+	return problems
+}
+
+// laneQuotas reads lane=slots pairs separated by commas, e.g.
+// system=10,fee_paying=70,faucet=20
+func (r *reader) laneQuotas(name string) map[string]int {
+	quotas := map[string]int{}
+	for _, pair := range r.list(name) {
+		lane, slots, ok := strings.Cut(pair, "=")
+		lane = strings.TrimSpace(lane)
+		n, err := parseSlots(slots)
+		if !ok || err != nil || !validLane(lane) {
+			r.invalid(name, pair, "<lane>=<slots> pairs with lane system, fee_paying or faucet")
+			continue
+		}
+		quotas[lane] = n
+	}
+	return quotas
+}
+
+func validLane(lane string) bool {
+	for _, l := range blockchain.Lanes {
+		if l == lane {
+			return true
+		}
+	}
+	return false
+}
+
+func quotaTotal(quotas map[string]int) int {
+	total := 0
+	for _, n := range quotas {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+)
+
+// reader reads environment variables, collecting a problem for every value
+// that does not parse or is out of range instead of stopping at the first
+type reader struct {
+	getenv   func(string) string
+	problems []string
+}
+
+func (r *reader) invalid(name, v, want string) {
+	r.problems = append(r.problems, fmt.Sprintf("%s=%q: must be %s", name, v, want))
+}
+
+func (r *reader) str(name, def string) string {
+	if v := strings.TrimSpace(r.getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// oneOf reads a lower-cased value that must be one of allowed
+func (r *reader) oneOf(name, def string, allowed ...string) string {
+	v := strings.ToLower(strings.TrimSpace(r.getenv(name)))
+	if v == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	r.invalid(name, v, "one of "+strings.Join(allowed, ", "))
+	return def
+}
+
+// integer reads an int from min to max
+func (r *reader) integer(name string, def, min, max int) int {
+	v := strings.TrimSpace(r.getenv(name))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		if max == maxInt {
+			r.invalid(name, v, fmt.Sprintf("an integer of at least %d", min))
+		} else {
+			r.invalid(name, v, fmt.Sprintf("an integer from %d to %d", min, max))
+		}
+		return def
+	}
+	return n
+}
+
+// amount reads a number of coins, with up to blockchain.Decimals places, as
+// units. max is in units too.
+func (r *reader) amount(name string, def, max uint64) uint64 {
+	v := strings.TrimSpace(r.getenv(name))
+	if v == "" {
+		return def
+	}
+	n, err := blockchain.ParseAmount(v)
+	if err != nil || n > max {
+		if max == maxUint64 {
+			r.invalid(name, v, fmt.Sprintf("a non-negative number of coins with at most %d decimal places", blockchain.Decimals))
+		} else {
+			r.invalid(name, v, fmt.Sprintf("a number of coins from 0 to %s", blockchain.FormatAmount(max)))
+		}
+		return def
+	}
+	return n
+}
+
+// duration reads a whole number of units, at least min of them
+func (r *reader) duration(name string, def time.Duration, unit time.Duration, min int) time.Duration {
+	n := r.integer(name, -1, min, maxInt)
+	if n < 0 {
+		return def
+	}
+	return time.Duration(n) * unit
+}
+
+func (r *reader) boolean(name string, def bool) bool {
+	switch v := strings.ToLower(strings.TrimSpace(r.getenv(name))); v {
+	case "":
+		return def
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	default:
+		r.invalid(name, v, "true or false")
+		return def
+	}
+}
+
+// list reads comma-separated values, dropping empty ones
+func (r *reader) list(name string) []string {
+	var values []string
+	for _, v := range strings.Split(r.getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+const (
+	maxInt    = int(^uint(0) >> 1)
+	maxUint64 = ^uint64(0)
+)
+
+func parseSlots(v string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err == nil && n < 0 {
+		err = fmt.Errorf("negative")
+	}
+	return n, err
+}
@@ -7,8 +7,43 @@ import (
 	"encoding/base64"
 	"errors"
 	"io"
+	"sync"
 )
 
+// FallbackKey is the encryption passphrase used when none is configured
+// (development only)
+const FallbackKey = "DefaultKey12345678901234567890"
+
+var (
+	keyMu         sync.RWMutex
+	encryptionKey = FallbackKey
+)
+
+// SetEncryptionKey sets the server-side encryption passphrase (ENCRYPTION_KEY)
+func SetEncryptionKey(key string) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	encryptionKey = key
+}
+
+// EncryptionKey returns the server-side encryption passphrase
+func EncryptionKey() string {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return encryptionKey
+}
+
+// EncryptSecret encrypts a server-held secret (such as a TOTP seed) with
+// EncryptionKey, the same way wallet private keys are stored
+func EncryptSecret(plaintext string) (string, error) {
+	return EncryptPrivateKey(plaintext, EncryptionKey())
+}
+
+// DecryptSecret reverses EncryptSecret
+func DecryptSecret(encrypted string) (string, error) {
+	return DecryptPrivateKey(encrypted, EncryptionKey())
+}
+
 // EncryptPrivateKey encrypts a private key using AES-256-GCM
 func EncryptPrivateKey(plaintext, passphrase string) (string, error) {
 	// Derive a 32-byte key from passphrase (in production, use PBKDF2 or scrypt)
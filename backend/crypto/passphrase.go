@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// PBKDF2Iterations is the work factor for passphrase-encrypted files
+// (OWASP 2023 recommendation for PBKDF2-HMAC-SHA256)
+const PBKDF2Iterations = 600000
+
+// ErrWrongPassphrase is returned when a sealed box cannot be opened, which is
+// almost always a wrong passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// SealedBox is data encrypted with a user passphrase. Unlike EncryptPrivateKey,
+// the key is stretched with PBKDF2 and a random salt, so the box is safe to
+// hand to the user and store outside the server.
+type SealedBox struct {
+	KDF        string `json:"kdf"`    // pbkdf2-sha256
+	Cipher     string `json:"cipher"` // aes-256-gcm
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`  // base64
+	Nonce      string `json:"nonce"` // base64
+	Ciphertext string `json:"ciphertext"`
+}
+
+// SealWithPassphrase encrypts plaintext under a key derived from passphrase
+func SealWithPassphrase(plaintext []byte, passphrase string) (SealedBox, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return SealedBox{}, err
+	}
+	gcm, err := passphraseGCM(passphrase, salt, PBKDF2Iterations)
+	if err != nil {
+		return SealedBox{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return SealedBox{}, err
+	}
+
+	return SealedBox{
+		KDF:        "pbkdf2-sha256",
+		Cipher:     "aes-256-gcm",
+		Iterations: PBKDF2Iterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil)),
+	}, nil
+}
+
+// OpenWithPassphrase decrypts a SealedBox
+func OpenWithPassphrase(box SealedBox, passphrase string) ([]byte, error) {
+	if box.KDF != "pbkdf2-sha256" || box.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported encryption %s/%s", box.KDF, box.Cipher)
+	}
+	if box.Iterations < 1 || box.Iterations > 10*PBKDF2Iterations {
+		return nil, errors.New("invalid iteration count")
+	}
+	salt, errSalt := base64.StdEncoding.DecodeString(box.Salt)
+	nonce, errNonce := base64.StdEncoding.DecodeString(box.Nonce)
+	ciphertext, errCT := base64.StdEncoding.DecodeString(box.Ciphertext)
+	if errSalt != nil || errNonce != nil || errCT != nil {
+		return nil, errors.New("malformed sealed box")
+	}
+
+	gcm, err := passphraseGCM(passphrase, salt, box.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("malformed sealed box")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func passphraseGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
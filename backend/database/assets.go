@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveAsset creates an asset or updates how much of it has been issued. The
+// symbol is the asset's ID.
+func (db *DB) SaveAsset(ctx context.Context, symbol, name, issuer string, maxSupply uint64, mintable bool, issued uint64, createdBy string, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO assets (id, name, issuer, max_supply, mintable, issued, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		ON CONFLICT (id) DO UPDATE
+		SET issued = EXCLUDED.issued
+	`
+	_, err := db.conn().Exec(ctx, query, symbol, name, issuer, int64(maxSupply), mintable, int64(issued), createdBy, createdAt)
+	return err
+}
+
+// GetAssets returns every defined asset, oldest first
+func (db *DB) GetAssets(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT id, name, issuer, max_supply, mintable, issued, COALESCE(created_by, ''), created_at FROM assets ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []map[string]interface{}
+	for rows.Next() {
+		var symbol, name, issuer, createdBy string
+		var maxSupply, issued int64
+		var mintable bool
+		var createdAt time.Time
+
+		if err := rows.Scan(&symbol, &name, &issuer, &maxSupply, &mintable, &issued, &createdBy, &createdAt); err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, map[string]interface{}{
+			"symbol":     symbol,
+			"name":       name,
+			"issuer":     issuer,
+			"max_supply": uint64(maxSupply),
+			"mintable":   mintable,
+			"issued":     uint64(issued),
+			"created_by": createdBy,
+			"created_at": createdAt,
+		})
+	}
+	return assets, rows.Err()
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuditRecord is one row of audit_logs
+type AuditRecord struct {
+	ID        int64
+	Method    string
+	Path      string
+	Route     string
+	WalletID  string
+	Actor     string
+	OrgID     string
+	IPAddress string
+	UserAgent string
+	Status    int
+	Outcome   string
+	ErrorCode string
+	LatencyMS int64
+	RequestID string
+	CreatedAt time.Time
+}
+
+// AuditFilter restricts an audit log query. Empty fields and zero times
+// match everything.
+type AuditFilter struct {
+	WalletID string
+	Route    string // route template, e.g. /api/wallet/{wallet}
+	Method   string
+	From     time.Time
+	To       time.Time // exclusive
+	Before   int64     // only rows with a lower ID, for paging back
+}
+
+// Matches applies the filter to a record held in memory
+func (f AuditFilter) Matches(r AuditRecord) bool {
+	switch {
+	case f.WalletID != "" && r.WalletID != f.WalletID,
+		f.Route != "" && r.Route != f.Route,
+		f.Method != "" && r.Method != f.Method,
+		!f.From.IsZero() && r.CreatedAt.Before(f.From),
+		!f.To.IsZero() && !r.CreatedAt.Before(f.To),
+		f.Before > 0 && r.ID >= f.Before:
+		return false
+	}
+	return true
+}
+
+// SaveAuditLogs appends records to the audit log in one transaction
+func (db *DB) SaveAuditLogs(ctx context.Context, records []AuditRecord) error {
+	if db == nil || db.Pool == nil || len(records) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO audit_logs (method, path, route, wallet_id, actor, org_id, ip_address, user_agent, status, outcome, error_code, latency_ms, request_id, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14)
+	`
+	return db.WithTx(ctx, func(tx *DB) error {
+		for _, r := range records {
+			if _, err := tx.conn().Exec(ctx, query, r.Method, r.Path, r.Route, r.WalletID, r.Actor, r.OrgID, r.IPAddress, r.UserAgent,
+				r.Status, r.Outcome, r.ErrorCode, r.LatencyMS, r.RequestID, r.CreatedAt.UTC()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AuditLogPage returns up to limit audit records matching the filter, newest first
+func (db *DB) AuditLogPage(ctx context.Context, f AuditFilter, limit int) ([]AuditRecord, error) {
+	if db == nil || db.Pool == nil {
+		return []AuditRecord{}, nil
+	}
+
+	cond := "TRUE"
+	var args []interface{}
+	add := func(clause string, v interface{}) {
+		args = append(args, v)
+		cond += fmt.Sprintf(" AND "+clause, len(args))
+	}
+	if f.WalletID != "" {
+		add("wallet_id = $%d", f.WalletID)
+	}
+	if f.Route != "" {
+		add("route = $%d", f.Route)
+	}
+	if f.Method != "" {
+		add("method = $%d", f.Method)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To.UTC())
+	}
+	if f.Before > 0 {
+		add("id < $%d", f.Before)
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`SELECT id, method, path, route, COALESCE(wallet_id, ''), COALESCE(actor, ''), COALESCE(org_id, ''), COALESCE(ip_address, ''),
+		COALESCE(user_agent, ''), status, outcome, COALESCE(error_code, ''), latency_ms, COALESCE(request_id, ''), created_at
+		FROM audit_logs WHERE %s ORDER BY id DESC LIMIT $%d`, cond, len(args))
+
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []AuditRecord{}
+	for rows.Next() {
+		var r AuditRecord
+		if err := rows.Scan(&r.ID, &r.Method, &r.Path, &r.Route, &r.WalletID, &r.Actor, &r.OrgID, &r.IPAddress,
+			&r.UserAgent, &r.Status, &r.Outcome, &r.ErrorCode, &r.LatencyMS, &r.RequestID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrBalanceLocked is returned by RecomputeWalletBalance when another writer
+// holds the wallet row and the caller asked not to wait for it
+var ErrBalanceLocked = errors.New("wallet balance row is locked by another writer")
+
+// lockNotAvailable is the SQLSTATE of a FOR UPDATE NOWAIT that found the row locked
+const lockNotAvailable = "55P03"
+
+// RecomputeWalletBalance sets a wallet's stored balance to the total of its
+// unspent persisted UTXOs, the balance's source of truth. The wallet row is
+// locked while the total is read and written, so concurrent recomputes for
+// the same wallet serialize and the last one to commit wrote the newest
+// total; a stale value can never overwrite a fresh one. With nowait, a row
+// locked by another writer fails with ErrBalanceLocked instead of waiting.
+// It returns the stored balance before and after; found is false when the
+// wallet has no row.
+func (db *DB) RecomputeWalletBalance(ctx context.Context, walletID string, nowait bool) (previous, current uint64, found bool, err error) {
+	if db == nil || db.Pool == nil {
+		return 0, 0, false, nil
+	}
+
+	tx, err := db.conn().Begin(ctx)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	lock := `SELECT COALESCE(balance, 0)::bigint FROM wallets WHERE wallet_id = $1 FOR UPDATE`
+	if nowait {
+		lock += ` NOWAIT`
+	}
+	var stored int64
+	if err := tx.QueryRow(ctx, lock, walletID).Scan(&stored); err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, 0, false, nil
+		case errors.As(err, &pgErr) && pgErr.Code == lockNotAvailable:
+			return 0, 0, true, ErrBalanceLocked
+		}
+		return 0, 0, false, err
+	}
+
+	var total int64
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0)::bigint FROM utxos WHERE owner = $1 AND spent = FALSE AND asset_id = ''`, walletID).Scan(&total); err != nil {
+		return 0, 0, true, err
+	}
+	if total != stored {
+		if _, err := tx.Exec(ctx, `UPDATE wallets SET balance = $1 WHERE wallet_id = $2`, total, walletID); err != nil {
+			return 0, 0, true, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, true, err
+	}
+	return uint64(stored), uint64(total), true, nil
+}
+
+// GetBalanceDrift returns every wallet whose stored balance differs from the
+// total of its unspent persisted UTXOs
+func (db *DB) GetBalanceDrift(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `
+		SELECT w.wallet_id, COALESCE(w.balance, 0)::bigint, COALESCE(u.total, 0)::bigint
+		FROM wallets w
+		LEFT JOIN (SELECT owner, SUM(amount) AS total FROM utxos WHERE spent = FALSE AND asset_id = '' GROUP BY owner) u ON u.owner = w.wallet_id
+		WHERE COALESCE(w.balance, 0) <> COALESCE(u.total, 0)
+	`
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drift []map[string]interface{}
+	for rows.Next() {
+		var walletID string
+		var stored, computed int64
+		if err := rows.Scan(&walletID, &stored, &computed); err != nil {
+			continue
+		}
+		drift = append(drift, map[string]interface{}{
+			"wallet_id": walletID,
+			"stored":    uint64(stored),
+			"computed":  uint64(computed),
+		})
+	}
+	return drift, rows.Err()
+}
+
+// GetLedgerBalances returns every wallet's stored balance with the total of
+// its unspent persisted UTXOs
+func (db *DB) GetLedgerBalances(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `
+		SELECT w.wallet_id, COALESCE(w.balance, 0)::bigint, COALESCE(u.total, 0)::bigint
+		FROM wallets w
+		LEFT JOIN (SELECT owner, SUM(amount) AS total FROM utxos WHERE spent = FALSE AND asset_id = '' GROUP BY owner) u ON u.owner = w.wallet_id
+	`
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []map[string]interface{}
+	for rows.Next() {
+		var walletID string
+		var stored, persisted int64
+		if err := rows.Scan(&walletID, &stored, &persisted); err != nil {
+			continue
+		}
+		balances = append(balances, map[string]interface{}{
+			"wallet_id": walletID,
+			"stored":    uint64(stored),
+			"persisted": uint64(persisted),
+		})
+	}
+	return balances, rows.Err()
+}
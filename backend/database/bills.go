@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveBill records a split bill or when it was settled
+func (db *DB) SaveBill(ctx context.Context, id int64, creator string, total, creatorShare uint64, note string, createdAt time.Time, settledAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO bills (id, creator, total, creator_share, note, created_at, settled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET settled_at = EXCLUDED.settled_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, creator, int64(total), int64(creatorShare), note, createdAt, settledAt)
+	return err
+}
+
+// GetBills returns every split bill in ID order
+func (db *DB) GetBills(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT id, creator, total, creator_share, COALESCE(note, ''), created_at, settled_at FROM bills ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bills []map[string]interface{}
+	for rows.Next() {
+		var id, total, creatorShare int64
+		var creator, note string
+		var createdAt time.Time
+		var settledAt *time.Time
+
+		if err := rows.Scan(&id, &creator, &total, &creatorShare, &note, &createdAt, &settledAt); err != nil {
+			return nil, err
+		}
+
+		bills = append(bills, map[string]interface{}{
+			"id":            id,
+			"creator":       creator,
+			"total":         uint64(total),
+			"creator_share": uint64(creatorShare),
+			"note":          note,
+			"created_at":    createdAt,
+			"settled_at":    settledAt,
+		})
+	}
+	return bills, rows.Err()
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveCampaign records a fundraising campaign or its changes
+func (db *DB) SaveCampaign(ctx context.Context, id int64, name, description string, targetAmount uint64, deadline time.Time, destinationWallet, status, createdBy string, createdAt time.Time, closedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO campaigns (id, name, description, target_amount, deadline, destination_wallet, status, created_by, created_at, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name,
+		    description = EXCLUDED.description,
+		    target_amount = EXCLUDED.target_amount,
+		    deadline = EXCLUDED.deadline,
+		    status = EXCLUDED.status,
+		    closed_at = EXCLUDED.closed_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, name, description, int64(targetAmount), deadline, destinationWallet, status, createdBy, createdAt, closedAt)
+	return err
+}
+
+// DeleteCampaign removes a campaign
+func (db *DB) DeleteCampaign(ctx context.Context, id int64) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `DELETE FROM campaigns WHERE id = $1`, id)
+	return err
+}
+
+// GetCampaigns returns every campaign in ID order
+func (db *DB) GetCampaigns(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT id, name, COALESCE(description, ''), target_amount, deadline, destination_wallet, status, COALESCE(created_by, ''), created_at, closed_at
+		FROM campaigns ORDER BY id ASC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []map[string]interface{}
+	for rows.Next() {
+		var id, target int64
+		var name, description, destination, status, createdBy string
+		var deadline, createdAt time.Time
+		var closedAt *time.Time
+
+		if err := rows.Scan(&id, &name, &description, &target, &deadline, &destination, &status, &createdBy, &createdAt, &closedAt); err != nil {
+			continue
+		}
+
+		campaigns = append(campaigns, map[string]interface{}{
+			"id":                 id,
+			"name":               name,
+			"description":        description,
+			"target_amount":      uint64(target),
+			"deadline":           deadline,
+			"destination_wallet": destination,
+			"status":             status,
+			"created_by":         createdBy,
+			"created_at":         createdAt,
+			"closed_at":          closedAt,
+		})
+	}
+
+	return campaigns, rows.Err()
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveCharity records a charity approved to receive zakat, or its new name
+// and weight
+func (db *DB) SaveCharity(ctx context.Context, walletID, name string, weight int, addedBy string, createdAt, updatedAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO charities (wallet_id, name, weight, added_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET name = EXCLUDED.name,
+		    weight = EXCLUDED.weight,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, name, weight, addedBy, createdAt, updatedAt)
+	return err
+}
+
+// DeleteCharity removes a charity from the registry
+func (db *DB) DeleteCharity(ctx context.Context, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `DELETE FROM charities WHERE wallet_id = $1`, walletID)
+	return err
+}
+
+// GetCharities returns every registered charity
+func (db *DB) GetCharities(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT wallet_id, name, weight, COALESCE(added_by, ''), created_at, updated_at FROM charities`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var charities []map[string]interface{}
+	for rows.Next() {
+		var walletID, name, addedBy string
+		var weight int
+		var createdAt, updatedAt time.Time
+
+		if err := rows.Scan(&walletID, &name, &weight, &addedBy, &createdAt, &updatedAt); err != nil {
+			continue
+		}
+
+		charities = append(charities, map[string]interface{}{
+			"wallet_id":  walletID,
+			"name":       name,
+			"weight":     weight,
+			"added_by":   addedBy,
+			"created_at": createdAt,
+			"updated_at": updatedAt,
+		})
+	}
+
+	return charities, rows.Err()
+}
+
+// SaveZakatDistribution records a split of the collected zakat or its
+// progress; shares is a JSON array
+func (db *DB) SaveZakatDistribution(ctx context.Context, id int64, status string, amount uint64, splitTxID, shares string, createdAt time.Time, distributedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO zakat_distributions (id, status, amount, split_txid, shares, created_at, distributed_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    shares = EXCLUDED.shares,
+		    distributed_at = EXCLUDED.distributed_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, status, int64(amount), splitTxID, shares, createdAt, distributedAt)
+	return err
+}
+
+// GetZakatDistributions returns every zakat distribution in ID order
+func (db *DB) GetZakatDistributions(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT id, status, amount, COALESCE(split_txid, ''), shares::text, created_at, distributed_at
+		FROM zakat_distributions ORDER BY id ASC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var distributions []map[string]interface{}
+	for rows.Next() {
+		var id, amount int64
+		var status, splitTxID, shares string
+		var createdAt time.Time
+		var distributedAt *time.Time
+
+		if err := rows.Scan(&id, &status, &amount, &splitTxID, &shares, &createdAt, &distributedAt); err != nil {
+			continue
+		}
+
+		distributions = append(distributions, map[string]interface{}{
+			"id":             id,
+			"status":         status,
+			"amount":         uint64(amount),
+			"split_txid":     splitTxID,
+			"shares":         shares,
+			"created_at":     createdAt,
+			"distributed_at": distributedAt,
+		})
+	}
+
+	return distributions, rows.Err()
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveDirectoryEntry lists a wallet in the email directory under the hash of
+// its email
+func (db *DB) SaveDirectoryEntry(ctx context.Context, walletID, emailHash string, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO wallet_directory (wallet_id, email_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET email_hash = EXCLUDED.email_hash, created_at = EXCLUDED.created_at
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, emailHash, createdAt)
+	return err
+}
+
+// DeleteDirectoryEntry takes a wallet out of the email directory
+func (db *DB) DeleteDirectoryEntry(ctx context.Context, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `DELETE FROM wallet_directory WHERE wallet_id = $1`, walletID)
+	return err
+}
+
+// GetDirectoryEntries returns every wallet in the email directory, oldest
+// listing first
+func (db *DB) GetDirectoryEntries(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT wallet_id, email_hash, created_at FROM wallet_directory ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []map[string]interface{}
+	for rows.Next() {
+		var walletID, emailHash string
+		var createdAt time.Time
+		if err := rows.Scan(&walletID, &emailHash, &createdAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, map[string]interface{}{
+			"wallet_id":  walletID,
+			"email_hash": emailHash,
+			"created_at": createdAt,
+		})
+	}
+	return entries, rows.Err()
+}
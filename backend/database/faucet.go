@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveFaucetGrant records a faucet grant in the ledger
+func (db *DB) SaveFaucetGrant(ctx context.Context, id int64, walletID, email, ip string, amount uint64, utxoID, source string, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO faucet_ledger (id, wallet_id, email, ip, amount, utxo_id, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, email, ip, int64(amount), utxoID, source, createdAt)
+	return err
+}
+
+// GetMaxFaucetGrantID returns the highest faucet ledger ID
+func (db *DB) GetMaxFaucetGrantID(ctx context.Context) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	var maxID int64
+	err := db.conn().QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM faucet_ledger`).Scan(&maxID)
+	return maxID, err
+}
+
+// GetFaucetGrantsSince returns the grants made since a time, oldest first
+func (db *DB) GetFaucetGrantsSince(ctx context.Context, since time.Time) ([]map[string]interface{}, error) {
+	return db.queryFaucetGrants(ctx, `WHERE created_at >= $1 ORDER BY id ASC`, since)
+}
+
+// GetFaucetLedger returns the most recent grants, newest first
+func (db *DB) GetFaucetLedger(ctx context.Context, limit int) ([]map[string]interface{}, error) {
+	if limit <= 0 {
+		return db.queryFaucetGrants(ctx, `ORDER BY id DESC`)
+	}
+	return db.queryFaucetGrants(ctx, `ORDER BY id DESC LIMIT $1`, limit)
+}
+
+func (db *DB) queryFaucetGrants(ctx context.Context, clause string, args ...interface{}) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT id, wallet_id, COALESCE(email, ''), COALESCE(ip, ''), amount, utxo_id, source, created_at FROM faucet_ledger `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []map[string]interface{}
+	for rows.Next() {
+		var id, amount int64
+		var walletID, email, ip, utxoID, source string
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &walletID, &email, &ip, &amount, &utxoID, &source, &createdAt); err != nil {
+			continue
+		}
+
+		grants = append(grants, map[string]interface{}{
+			"id":         id,
+			"wallet_id":  walletID,
+			"email":      email,
+			"ip":         ip,
+			"amount":     uint64(amount),
+			"utxo_id":    utxoID,
+			"source":     source,
+			"created_at": createdAt,
+		})
+	}
+	return grants, rows.Err()
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Each feature service persists through a repository of its own below, so
+// a backend can take over a feature by implementing its repository. *DB
+// implements all of them; SQLiteStore implements SessionRepo, TwoFactorRepo,
+// WebhookRepo and DeliveryRepo. Features whose repository the store lacks
+// keep their in-memory behaviour.
+
+// AssetRepo stores issued assets and their issue transactions
+type AssetRepo interface {
+	GetAssets(ctx context.Context) ([]map[string]interface{}, error)
+	SaveAsset(ctx context.Context, symbol, name, issuer string, maxSupply uint64, mintable bool, issued uint64, createdBy string, createdAt time.Time) error
+	SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error
+}
+
+// AuditRepo stores the admin audit trail
+type AuditRepo interface {
+	SaveAuditLogs(ctx context.Context, records []AuditRecord) error
+	AuditLogPage(ctx context.Context, f AuditFilter, limit int) ([]AuditRecord, error)
+}
+
+// BalanceRepo keeps the stored wallet balances in step with the UTXOs
+type BalanceRepo interface {
+	RecomputeWalletBalance(ctx context.Context, walletID string, nowait bool) (previous, current uint64, found bool, err error)
+	GetBalanceDrift(ctx context.Context) ([]map[string]interface{}, error)
+	GetLedgerBalances(ctx context.Context) ([]map[string]interface{}, error)
+	SaveUTXO(ctx context.Context, id, owner, assetID string, amount uint64, originTx string, idx int, spent bool) error
+}
+
+// BillRepo stores split bills
+type BillRepo interface {
+	SaveBill(ctx context.Context, id int64, creator string, total, creatorShare uint64, note string, createdAt time.Time, settledAt *time.Time) error
+	GetBills(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// CampaignRepo stores fundraising campaigns
+type CampaignRepo interface {
+	SaveCampaign(ctx context.Context, id int64, name, description string, targetAmount uint64, deadline time.Time, destinationWallet, status, createdBy string, createdAt time.Time, closedAt *time.Time) error
+	DeleteCampaign(ctx context.Context, id int64) error
+	GetCampaigns(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// CharityRepo stores zakat charities, their distributions and the split transactions
+type CharityRepo interface {
+	SaveCharity(ctx context.Context, walletID, name string, weight int, addedBy string, createdAt, updatedAt time.Time) error
+	DeleteCharity(ctx context.Context, walletID string) error
+	GetCharities(ctx context.Context) ([]map[string]interface{}, error)
+	SaveZakatDistribution(ctx context.Context, id int64, status string, amount uint64, splitTxID, shares string, createdAt time.Time, distributedAt *time.Time) error
+	GetZakatDistributions(ctx context.Context) ([]map[string]interface{}, error)
+	SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error
+}
+
+// OrgConfigRepo stores per-organization setting overrides
+type OrgConfigRepo interface {
+	SaveOrgConfig(ctx context.Context, orgID, overrides, updatedBy string) error
+	GetOrgConfigs(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// ConsolidationRepo stores the transactions that consolidate a wallet's UTXOs
+type ConsolidationRepo interface {
+	SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error
+}
+
+// DeliveryRepo stores outgoing deliveries and each attempt at them
+type DeliveryRepo interface {
+	SaveDelivery(ctx context.Context, id int64, channel, target string, webhookID int64, eventType, payload, dedupKey, status string, attempts int, lastError string, createdAt time.Time, deliveredAt *time.Time) error
+	SaveDeliveryAttempt(ctx context.Context, deliveryID int64, attempt int, at time.Time, durationMs int64, errMsg string) error
+	GetUndeliveredDeliveries(ctx context.Context) ([]map[string]interface{}, error)
+	GetMaxDeliveryID(ctx context.Context) (int64, error)
+}
+
+// OriginRepo stores the countries and devices each user has signed in from
+type OriginRepo interface {
+	SaveKnownOrigin(ctx context.Context, email, kind, value string, firstSeen, lastSeen time.Time) error
+	GetKnownOrigins(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// DirectoryRepo stores the opt-in wallet directory
+type DirectoryRepo interface {
+	SaveDirectoryEntry(ctx context.Context, walletID, emailHash string, createdAt time.Time) error
+	DeleteDirectoryEntry(ctx context.Context, walletID string) error
+	GetDirectoryEntries(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// FaucetRepo stores faucet grants
+type FaucetRepo interface {
+	SaveFaucetGrant(ctx context.Context, id int64, walletID, email, ip string, amount uint64, utxoID, source string, createdAt time.Time) error
+	GetFaucetGrantsSince(ctx context.Context, since time.Time) ([]map[string]interface{}, error)
+	GetFaucetLedger(ctx context.Context, limit int) ([]map[string]interface{}, error)
+	GetMaxFaucetGrantID(ctx context.Context) (int64, error)
+}
+
+// HandleRepo stores wallet handles
+type HandleRepo interface {
+	ClaimHandle(ctx context.Context, id int64, walletID, handle string, at time.Time) error
+	ReleaseHandle(ctx context.Context, walletID string, at time.Time) error
+	GetHandles(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// InheritanceRepo stores inheritance rules, payouts and the payout transactions
+type InheritanceRepo interface {
+	SaveInheritanceRule(ctx context.Context, walletID string, inactivityMonths int, createdAt, updatedAt time.Time) error
+	DeleteInheritanceRule(ctx context.Context, walletID string) error
+	GetInheritanceRules(ctx context.Context) ([]map[string]interface{}, error)
+	GetNominees(ctx context.Context, walletID string) ([]map[string]interface{}, error)
+	SaveInheritancePayout(ctx context.Context, id int64, walletID, status string, balance uint64, lastActivity time.Time, nominees, splitTxID, reason, decidedBy string, createdAt time.Time, decidedAt, paidAt *time.Time) error
+	GetInheritancePayouts(ctx context.Context) ([]map[string]interface{}, error)
+	SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error
+}
+
+// KYCRepo stores KYC submissions
+type KYCRepo interface {
+	SaveKYCSubmission(ctx context.Context, id int64, walletID, cnic, documentType, documentRef, status, note, reviewedBy string, createdAt time.Time, reviewedAt *time.Time) error
+	GetKYCSubmissions(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// MinerRepo stores mined blocks per miner
+type MinerRepo interface {
+	SaveMinedBlock(ctx context.Context, walletID string, reward, fees uint64, blockIndex int64, minedAt time.Time) error
+	GetMiners(ctx context.Context) ([]map[string]interface{}, error)
+	BackfillMiners(ctx context.Context) (int64, error)
+}
+
+// NotificationRepo stores the notification inbox and notification preferences
+type NotificationRepo interface {
+	SaveNotification(ctx context.Context, id int64, walletID, kind, category, title, body, txID string, createdAt time.Time) error
+	MarkNotificationsRead(ctx context.Context, walletID string, ids []int64, readAt time.Time) error
+	GetRecentNotifications(ctx context.Context, perWallet int) ([]map[string]interface{}, error)
+	MaxNotificationID(ctx context.Context) (int64, error)
+	SaveNotificationPreferences(ctx context.Context, walletID string, incomingPayments bool, minIncomingAmount uint64, zakatDeductions, profileChanges, beneficiaryChanges bool, updatedAt time.Time) error
+	GetNotificationPreferences(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// OrgRepo stores organizations and their admins
+type OrgRepo interface {
+	SaveOrganization(ctx context.Context, id, name, createdBy string, createdAt time.Time) error
+	GetOrganizations(ctx context.Context) ([]map[string]interface{}, error)
+	AddOrgAdmin(ctx context.Context, orgID, walletID string) error
+	RemoveOrgAdmin(ctx context.Context, orgID, walletID string) error
+	GetOrgAdmins(ctx context.Context) ([]map[string]interface{}, error)
+	UpdateWalletOrg(ctx context.Context, walletID, orgID string) error
+}
+
+// PaymentRequestRepo stores payment requests
+type PaymentRequestRepo interface {
+	SavePaymentRequest(ctx context.Context, id int64, requester, payer string, amount uint64, note, status, txID string, billID int64, createdAt, expiresAt time.Time, decidedAt *time.Time) error
+	GetPaymentRequests(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// RateRepo stores exchange rates over time
+type RateRepo interface {
+	SaveExchangeRate(ctx context.Context, currency string, rate float64, source, setBy string, effectiveAt time.Time) error
+	GetExchangeRates(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// SessionRepo stores login sessions by the hash of their token
+type SessionRepo interface {
+	SaveSession(ctx context.Context, tokenHash, email string, userID int64, method string, createdAt, expiresAt time.Time) error
+	GetSession(ctx context.Context, tokenHash string) (map[string]interface{}, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+}
+
+// StatementRepo stores the monthly statements sent to wallets
+type StatementRepo interface {
+	SaveStatement(ctx context.Context, walletID, period string, opening, closing, received, sent, fees uint64, txCount int, email string, deliveryID int64, createdAt time.Time) error
+	GetStatements(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// SupplyRepo stores the coin issuance history
+type SupplyRepo interface {
+	SaveSupplyIssuance(ctx context.Context, source string, amount uint64, ref string, createdAt time.Time) error
+	GetSupplyIssuanceByDay(ctx context.Context) ([]map[string]interface{}, error)
+	BackfillSupplyIssuance(ctx context.Context) (int64, error)
+}
+
+// TwoFactorRepo stores two-factor enrollments
+type TwoFactorRepo interface {
+	SaveTwoFactor(ctx context.Context, walletID, secretEncrypted string, enabled bool, threshold, lastStep uint64, createdAt time.Time, confirmedAt *time.Time) error
+	DeleteTwoFactor(ctx context.Context, walletID string) error
+	GetTwoFactorEnrollments(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// UsageRepo stores API usage counts per endpoint and client
+type UsageRepo interface {
+	AddEndpointUsage(ctx context.Context, method, route, client string, count int64, firstSeen, lastSeen time.Time) error
+	GetEndpointUsage(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+// WalletTypeRepo stores wallet type change requests
+type WalletTypeRepo interface {
+	SaveWalletTypeRequest(ctx context.Context, id int64, walletID, fromType, toType, reason, status, requestedBy, decidedBy string, createdAt time.Time, decidedAt *time.Time) error
+	GetWalletTypeRequests(ctx context.Context) ([]map[string]interface{}, error)
+	UpdateWalletType(ctx context.Context, walletID, walletType string) error
+}
+
+// WalletEventRepo stores the wallet event feed
+type WalletEventRepo interface {
+	SaveWalletEvent(ctx context.Context, seq uint64, walletID, eventType string, schemaVersion int, payload string, createdAt time.Time) error
+	GetWalletEventsSince(ctx context.Context, walletID string, since uint64, limit int) ([]map[string]interface{}, error)
+	GetRecentWalletEvents(ctx context.Context, limit int) ([]map[string]interface{}, error)
+}
+
+// WebhookRepo stores webhook registrations
+type WebhookRepo interface {
+	SaveWebhook(ctx context.Context, id int64, walletID, url string, events []string, secretEncrypted string, active bool, createdAt time.Time) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetWebhooks(ctx context.Context) ([]map[string]interface{}, error)
+	GetMaxWebhookID(ctx context.Context) (int64, error)
+}
+
+// ZakatRepo stores zakat deductions
+type ZakatRepo interface {
+	SaveZakatDeduction(ctx context.Context, walletID string, amount uint64, month, year int, transactionID string) error
+}
+
+var (
+	_ AssetRepo          = (*DB)(nil)
+	_ AuditRepo          = (*DB)(nil)
+	_ BalanceRepo        = (*DB)(nil)
+	_ BillRepo           = (*DB)(nil)
+	_ CampaignRepo       = (*DB)(nil)
+	_ CharityRepo        = (*DB)(nil)
+	_ OrgConfigRepo      = (*DB)(nil)
+	_ ConsolidationRepo  = (*DB)(nil)
+	_ DeliveryRepo       = (*DB)(nil)
+	_ OriginRepo         = (*DB)(nil)
+	_ DirectoryRepo      = (*DB)(nil)
+	_ FaucetRepo         = (*DB)(nil)
+	_ HandleRepo         = (*DB)(nil)
+	_ InheritanceRepo    = (*DB)(nil)
+	_ KYCRepo            = (*DB)(nil)
+	_ MinerRepo          = (*DB)(nil)
+	_ NotificationRepo   = (*DB)(nil)
+	_ OrgRepo            = (*DB)(nil)
+	_ PaymentRequestRepo = (*DB)(nil)
+	_ RateRepo           = (*DB)(nil)
+	_ SessionRepo        = (*DB)(nil)
+	_ StatementRepo      = (*DB)(nil)
+	_ SupplyRepo         = (*DB)(nil)
+	_ TwoFactorRepo      = (*DB)(nil)
+	_ UsageRepo          = (*DB)(nil)
+	_ WalletTypeRepo     = (*DB)(nil)
+	_ WalletEventRepo    = (*DB)(nil)
+	_ WebhookRepo        = (*DB)(nil)
+	_ ZakatRepo          = (*DB)(nil)
+	_ SessionRepo        = (*SQLiteStore)(nil)
+	_ TwoFactorRepo      = (*SQLiteStore)(nil)
+	_ WebhookRepo        = (*SQLiteStore)(nil)
+	_ DeliveryRepo       = (*SQLiteStore)(nil)
+)
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrHandleTaken is returned when another wallet already holds a handle
+var ErrHandleTaken = errors.New("handle is taken")
+
+// ClaimHandle records claim id of handle by walletID, releasing the wallet's
+// current handle in the same transaction. The unique indexes make a handle
+// claimed concurrently by another node fail with ErrHandleTaken.
+func (db *DB) ClaimHandle(ctx context.Context, id int64, walletID, handle string, at time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	return db.WithTx(ctx, func(tx *DB) error {
+		if err := tx.ReleaseHandle(ctx, walletID, at); err != nil {
+			return err
+		}
+		_, err := tx.conn().Exec(ctx, `INSERT INTO wallet_handles (id, wallet_id, handle, claimed_at) VALUES ($1, $2, $3, $4)`, id, walletID, handle, at)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrHandleTaken
+		}
+		return err
+	})
+}
+
+// ReleaseHandle ends the wallet's current handle claim, if it has one
+func (db *DB) ReleaseHandle(ctx context.Context, walletID string, at time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `UPDATE wallet_handles SET released_at = $2 WHERE wallet_id = $1 AND released_at IS NULL`, walletID, at)
+	return err
+}
+
+// GetHandles returns every handle claim in ID order
+func (db *DB) GetHandles(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT id, wallet_id, handle, claimed_at, released_at FROM wallet_handles ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, handle string
+		var claimedAt time.Time
+		var releasedAt *time.Time
+		if err := rows.Scan(&id, &walletID, &handle, &claimedAt, &releasedAt); err != nil {
+			return nil, err
+		}
+		claims = append(claims, map[string]interface{}{
+			"id":          id,
+			"wallet_id":   walletID,
+			"handle":      handle,
+			"claimed_at":  claimedAt,
+			"released_at": releasedAt,
+		})
+	}
+	return claims, rows.Err()
+}
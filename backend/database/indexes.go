@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RequiredIndex is a composite index that a heavy endpoint depends on
+type RequiredIndex struct {
+	Name       string `json:"name"`
+	Table      string `json:"table"`
+	Definition string `json:"definition"`
+	Reason     string `json:"reason"`
+}
+
+// RequiredIndexes are created at startup by EnsureIndexes. They back the
+// per-wallet history, log and UTXO lookups, which filter on one column and
+// order or filter on a second, and the time-ordered pages of log exports.
+var RequiredIndexes = []RequiredIndex{
+	{"idx_transactions_sender_ts", "transactions", "(sender_id, timestamp DESC)", "wallet history and reports by sender, newest first"},
+	{"idx_transactions_receiver_ts", "transactions", "(receiver_id, timestamp DESC)", "wallet history and reports by receiver, newest first"},
+	{"idx_system_logs_wallet_created", "system_logs", "(wallet_id, created_at DESC)", "system log pages filtered by wallet"},
+	{"idx_transaction_logs_wallet_created", "transaction_logs", "(wallet_id, created_at DESC)", "transaction log pages filtered by wallet"},
+	{"idx_system_logs_created_id", "system_logs", "(created_at, id)", "log export, paged in time order"},
+	{"idx_transaction_logs_created_id", "transaction_logs", "(created_at, id)", "log export, paged in time order"},
+	{"idx_utxos_owner_spent", "utxos", "(owner, spent)", "balance and coin selection over a wallet's unspent outputs"},
+}
+
+// Advisor thresholds: tables smaller than minAdvisorRows are cheap to scan
+// sequentially, and statements faster than slowStatementMs are not reported
+const (
+	minAdvisorRows  = 1000
+	slowStatementMs = 100.0
+)
+
+// EnsureIndexes creates any missing required index and returns the names of
+// the ones it created
+func (db *DB) EnsureIndexes(ctx context.Context) ([]string, error) {
+	if db == nil || db.Pool == nil {
+		return nil, nil
+	}
+
+	existing, err := db.indexNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, idx := range RequiredIndexes {
+		if existing[idx.Name] {
+			continue
+		}
+		stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s %s", idx.Name, idx.Table, idx.Definition)
+		if _, err := db.conn().Exec(ctx, stmt); err != nil {
+			return created, fmt.Errorf("failed to create index %s: %v", idx.Name, err)
+		}
+		created = append(created, idx.Name)
+	}
+	return created, nil
+}
+
+func (db *DB) indexNames(ctx context.Context) (map[string]bool, error) {
+	rows, err := db.conn().Query(ctx, `SELECT indexname FROM pg_indexes WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// IndexReport describes the state of the required indexes, tables that are
+// mostly read by sequential scans, and the slowest statements recorded by
+// pg_stat_statements, together with human-readable recommendations
+func (db *DB) IndexReport(ctx context.Context) (map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	existing, err := db.indexNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var recommendations []string
+	required := make([]map[string]interface{}, 0, len(RequiredIndexes))
+	for _, idx := range RequiredIndexes {
+		required = append(required, map[string]interface{}{
+			"name":       idx.Name,
+			"table":      idx.Table,
+			"definition": idx.Definition,
+			"reason":     idx.Reason,
+			"present":    existing[idx.Name],
+		})
+		if !existing[idx.Name] {
+			recommendations = append(recommendations, fmt.Sprintf("Create %s ON %s %s (%s)", idx.Name, idx.Table, idx.Definition, idx.Reason))
+		}
+	}
+
+	scans, scanRecs, err := db.sequentialScans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recommendations = append(recommendations, scanRecs...)
+
+	statements, stmtRecs, stmtErr := db.slowStatements(ctx)
+	recommendations = append(recommendations, stmtRecs...)
+	statementsReport := map[string]interface{}{
+		"available":  stmtErr == nil,
+		"statements": statements,
+	}
+	if stmtErr != nil {
+		statementsReport["reason"] = stmtErr.Error()
+	}
+
+	if recommendations == nil {
+		recommendations = []string{}
+	}
+	return map[string]interface{}{
+		"required_indexes":   required,
+		"sequential_scans":   scans,
+		"pg_stat_statements": statementsReport,
+		"recommendations":    recommendations,
+	}, nil
+}
+
+// sequentialScans lists per-table scan counters and flags tables with enough
+// rows that are read by sequential scans more often than through an index
+func (db *DB) sequentialScans(ctx context.Context) ([]map[string]interface{}, []string, error) {
+	query := `SELECT relname, seq_scan, seq_tup_read, COALESCE(idx_scan, 0), n_live_tup
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema()
+		ORDER BY seq_tup_read DESC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	tables := []map[string]interface{}{}
+	var recommendations []string
+	for rows.Next() {
+		var table string
+		var seqScan, seqTupRead, idxScan, liveRows int64
+		if err := rows.Scan(&table, &seqScan, &seqTupRead, &idxScan, &liveRows); err != nil {
+			return nil, nil, err
+		}
+
+		heavy := liveRows >= minAdvisorRows && seqScan > idxScan
+		tables = append(tables, map[string]interface{}{
+			"table":        table,
+			"seq_scan":     seqScan,
+			"seq_tup_read": seqTupRead,
+			"idx_scan":     idxScan,
+			"live_rows":    liveRows,
+			"flagged":      heavy,
+		})
+		if heavy {
+			recommendations = append(recommendations, fmt.Sprintf(
+				"Table %s (%d rows) had %d sequential scans reading %d rows against %d index scans; index the columns its queries filter on",
+				table, liveRows, seqScan, seqTupRead, idxScan))
+		}
+	}
+	return tables, recommendations, rows.Err()
+}
+
+// slowStatements reads the slowest statements from pg_stat_statements. It
+// returns an error when the extension is not installed or not readable.
+func (db *DB) slowStatements(ctx context.Context) ([]map[string]interface{}, []string, error) {
+	var installed bool
+	if err := db.conn().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements')`).Scan(&installed); err != nil {
+		return []map[string]interface{}{}, nil, err
+	}
+	if !installed {
+		return []map[string]interface{}{}, nil, fmt.Errorf("pg_stat_statements extension is not installed")
+	}
+
+	query := `SELECT query, calls, total_exec_time, mean_exec_time, rows
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY mean_exec_time DESC
+		LIMIT 10`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return []map[string]interface{}{}, nil, err
+	}
+	defer rows.Close()
+
+	statements := []map[string]interface{}{}
+	var recommendations []string
+	for rows.Next() {
+		var text string
+		var calls, rowCount int64
+		var totalMs, meanMs float64
+		if err := rows.Scan(&text, &calls, &totalMs, &meanMs, &rowCount); err != nil {
+			return statements, nil, err
+		}
+
+		text = strings.Join(strings.Fields(text), " ")
+		statements = append(statements, map[string]interface{}{
+			"query":    text,
+			"calls":    calls,
+			"total_ms": totalMs,
+			"mean_ms":  meanMs,
+			"rows":     rowCount,
+		})
+		if meanMs >= slowStatementMs {
+			if len(text) > 120 {
+				text = text[:120] + "..."
+			}
+			recommendations = append(recommendations, fmt.Sprintf("Statement averages %.0f ms over %d calls; check its plan with EXPLAIN: %s", meanMs, calls, text))
+		}
+	}
+	return statements, recommendations, rows.Err()
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BeneficiaryShareTotal sums the shares of the user's beneficiaries,
+// ignoring the beneficiary identified by excludeID (pass 0 to sum all)
+func (db *DB) BeneficiaryShareTotal(ctx context.Context, userID, excludeID int64) (int, error) {
+	if db == nil || db.Pool == nil {
+		return 0, fmt.Errorf("no database connection")
+	}
+
+	var total int
+	query := `SELECT COALESCE(SUM(share_percent), 0) FROM beneficiaries WHERE user_id = $1 AND id <> $2`
+	if err := db.conn().QueryRow(ctx, query, userID, excludeID).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetNominees returns the beneficiaries with a share in the address book of
+// the wallet's owner, oldest first
+func (db *DB) GetNominees(ctx context.Context, walletID string) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT b.wallet_id, COALESCE(b.name, ''), b.share_percent
+		FROM beneficiaries b JOIN wallets w ON w.user_id = b.user_id
+		WHERE w.wallet_id = $1 AND b.share_percent > 0
+		ORDER BY b.id ASC`
+
+	rows, err := db.conn().Query(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nominees []map[string]interface{}
+	for rows.Next() {
+		var nomineeWallet, name string
+		var share int
+
+		if err := rows.Scan(&nomineeWallet, &name, &share); err != nil {
+			continue
+		}
+
+		nominees = append(nominees, map[string]interface{}{
+			"wallet_id":     nomineeWallet,
+			"name":          name,
+			"share_percent": share,
+		})
+	}
+
+	return nominees, rows.Err()
+}
+
+// SaveInheritanceRule records how many months without outgoing activity
+// make a wallet's balance payable to its nominees
+func (db *DB) SaveInheritanceRule(ctx context.Context, walletID string, inactivityMonths int, createdAt, updatedAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO inheritance_rules (wallet_id, inactivity_months, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET inactivity_months = EXCLUDED.inactivity_months,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, inactivityMonths, createdAt, updatedAt)
+	return err
+}
+
+// DeleteInheritanceRule removes a wallet's inactivity rule
+func (db *DB) DeleteInheritanceRule(ctx context.Context, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `DELETE FROM inheritance_rules WHERE wallet_id = $1`, walletID)
+	return err
+}
+
+// GetInheritanceRules returns every inactivity rule
+func (db *DB) GetInheritanceRules(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT wallet_id, inactivity_months, created_at, updated_at FROM inheritance_rules`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []map[string]interface{}
+	for rows.Next() {
+		var walletID string
+		var months int
+		var createdAt, updatedAt time.Time
+
+		if err := rows.Scan(&walletID, &months, &createdAt, &updatedAt); err != nil {
+			continue
+		}
+
+		rules = append(rules, map[string]interface{}{
+			"wallet_id":         walletID,
+			"inactivity_months": months,
+			"created_at":        createdAt,
+			"updated_at":        updatedAt,
+		})
+	}
+
+	return rules, rows.Err()
+}
+
+// SaveInheritancePayout records an inheritance payout or its progress;
+// nominees is a JSON array
+func (db *DB) SaveInheritancePayout(ctx context.Context, id int64, walletID, status string, balance uint64, lastActivity time.Time, nominees, splitTxID, reason, decidedBy string, createdAt time.Time, decidedAt, paidAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO inheritance_payouts (id, wallet_id, status, balance, last_activity, nominees, split_txid, reason, decided_by, created_at, decided_at, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    balance = EXCLUDED.balance,
+		    nominees = EXCLUDED.nominees,
+		    split_txid = EXCLUDED.split_txid,
+		    reason = EXCLUDED.reason,
+		    decided_by = EXCLUDED.decided_by,
+		    decided_at = EXCLUDED.decided_at,
+		    paid_at = EXCLUDED.paid_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, status, int64(balance), lastActivity, nominees, splitTxID, reason, decidedBy, createdAt, decidedAt, paidAt)
+	return err
+}
+
+// GetInheritancePayouts returns every inheritance payout in ID order
+func (db *DB) GetInheritancePayouts(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT id, wallet_id, status, balance, last_activity, nominees::text, COALESCE(split_txid, ''), COALESCE(reason, ''), COALESCE(decided_by, ''), created_at, decided_at, paid_at
+		FROM inheritance_payouts ORDER BY id ASC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payouts []map[string]interface{}
+	for rows.Next() {
+		var id, balance int64
+		var walletID, status, nominees, splitTxID, reason, decidedBy string
+		var lastActivity, createdAt time.Time
+		var decidedAt, paidAt *time.Time
+
+		if err := rows.Scan(&id, &walletID, &status, &balance, &lastActivity, &nominees, &splitTxID, &reason, &decidedBy, &createdAt, &decidedAt, &paidAt); err != nil {
+			continue
+		}
+
+		payouts = append(payouts, map[string]interface{}{
+			"id":            id,
+			"wallet_id":     walletID,
+			"status":        status,
+			"balance":       uint64(balance),
+			"last_activity": lastActivity,
+			"nominees":      nominees,
+			"split_txid":    splitTxID,
+			"reason":        reason,
+			"decided_by":    decidedBy,
+			"created_at":    createdAt,
+			"decided_at":    decidedAt,
+			"paid_at":       paidAt,
+		})
+	}
+
+	return payouts, rows.Err()
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveKYCSubmission records a KYC submission or its review
+func (db *DB) SaveKYCSubmission(ctx context.Context, id int64, walletID, cnic, documentType, documentRef, status, note, reviewedBy string, createdAt time.Time, reviewedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO kyc_submissions (id, wallet_id, cnic, document_type, document_ref, status, note, reviewed_by, created_at, reviewed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    note = EXCLUDED.note,
+		    reviewed_by = EXCLUDED.reviewed_by,
+		    reviewed_at = EXCLUDED.reviewed_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, cnic, documentType, documentRef, status, note, reviewedBy, createdAt, reviewedAt)
+	return err
+}
+
+// GetKYCSubmissions returns every KYC submission in ID order
+func (db *DB) GetKYCSubmissions(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT id, wallet_id, cnic, document_type, document_ref, status, COALESCE(note, ''), COALESCE(reviewed_by, ''), created_at, reviewed_at
+		FROM kyc_submissions ORDER BY id ASC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, cnic, documentType, documentRef, status, note, reviewedBy string
+		var createdAt time.Time
+		var reviewedAt *time.Time
+
+		if err := rows.Scan(&id, &walletID, &cnic, &documentType, &documentRef, &status, &note, &reviewedBy, &createdAt, &reviewedAt); err != nil {
+			continue
+		}
+
+		submissions = append(submissions, map[string]interface{}{
+			"id":            id,
+			"wallet_id":     walletID,
+			"cnic":          cnic,
+			"document_type": documentType,
+			"document_ref":  documentRef,
+			"status":        status,
+			"note":          note,
+			"reviewed_by":   reviewedBy,
+			"created_at":    createdAt,
+			"reviewed_at":   reviewedAt,
+		})
+	}
+
+	return submissions, rows.Err()
+}
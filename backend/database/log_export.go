@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LogCursor is the keyset position of the last row read by a log export.
+// Pages continue after (CreatedAt, ID), so no cursor is held open on the
+// server between pages and a slow reader never pins a connection.
+type LogCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// LogFilter restricts a log export. Zero times leave that end open.
+type LogFilter struct {
+	From     time.Time
+	To       time.Time // exclusive
+	WalletID string
+}
+
+// where builds the filter and keyset conditions; args continue from $1
+func (f LogFilter) where(after LogCursor) (string, []interface{}) {
+	cond := "(created_at, id) > ($1, $2)"
+	args := []interface{}{after.CreatedAt.UTC(), after.ID}
+	if !f.From.IsZero() {
+		args = append(args, f.From.UTC())
+		cond += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To.UTC())
+		cond += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	if f.WalletID != "" {
+		args = append(args, f.WalletID)
+		cond += fmt.Sprintf(" AND wallet_id = $%d", len(args))
+	}
+	return cond, args
+}
+
+// SystemLogPage returns up to limit system log rows after the cursor, oldest first
+func (db *DB) SystemLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	cond, args := f.where(after)
+	args = append(args, limit)
+	query := fmt.Sprintf(`SELECT id, event_type, COALESCE(wallet_id, ''), COALESCE(ip_address, ''), COALESCE(details, ''), COALESCE(request_id, ''),
+		COALESCE(country, ''), COALESCE(city, ''), COALESCE(device, ''), created_at
+		FROM system_logs WHERE %s ORDER BY created_at, id LIMIT $%d`, cond, len(args))
+
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []map[string]interface{}{}
+	for rows.Next() {
+		var id int64
+		var eventType, walletID, ipAddress, details, requestID, country, city, device string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &eventType, &walletID, &ipAddress, &details, &requestID, &country, &city, &device, &createdAt); err != nil {
+			return nil, err
+		}
+		logs = append(logs, map[string]interface{}{
+			"id":         id,
+			"event_type": eventType,
+			"wallet_id":  walletID,
+			"ip_address": ipAddress,
+			"details":    details,
+			"request_id": requestID,
+			"country":    country,
+			"city":       city,
+			"device":     device,
+			"created_at": createdAt,
+		})
+	}
+	return logs, rows.Err()
+}
+
+// TransactionLogPage returns up to limit transaction log rows after the cursor, oldest first
+func (db *DB) TransactionLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	cond, args := f.where(after)
+	args = append(args, limit)
+	query := fmt.Sprintf(`SELECT id, transaction_id, action, wallet_id, COALESCE(block_hash, ''), COALESCE(status, ''), COALESCE(ip_address, ''), COALESCE(request_id, ''), created_at
+		FROM transaction_logs WHERE %s ORDER BY created_at, id LIMIT $%d`, cond, len(args))
+
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []map[string]interface{}{}
+	for rows.Next() {
+		var id int64
+		var transactionID, action, walletID, blockHash, status, ipAddress, requestID string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &transactionID, &action, &walletID, &blockHash, &status, &ipAddress, &requestID, &createdAt); err != nil {
+			return nil, err
+		}
+		logs = append(logs, map[string]interface{}{
+			"id":             id,
+			"transaction_id": transactionID,
+			"action":         action,
+			"wallet_id":      walletID,
+			"block_hash":     blockHash,
+			"status":         status,
+			"ip_address":     ipAddress,
+			"request_id":     requestID,
+			"created_at":     createdAt,
+		})
+	}
+	return logs, rows.Err()
+}
+
+// Matches applies the filter to a row held in memory
+func (f LogFilter) Matches(createdAt time.Time, walletID string) bool {
+	if !f.From.IsZero() && createdAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !createdAt.Before(f.To) {
+		return false
+	}
+	return f.WalletID == "" || walletID == f.WalletID
+}
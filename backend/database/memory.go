@@ -0,0 +1,526 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MemoryStore keeps wallets, the chain and the logs in memory. It follows the
+// Postgres tables closely enough for tests and no-database mode: updates to
+// missing rows do nothing and a missing transaction is pgx.ErrNoRows.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	wallets   map[string]memWallet
+	blocks    map[int64][]byte
+	txs       map[string]memTx
+	utxos     map[string]memUTXO
+	archived  map[string]memUTXO
+	snapshots map[int64]memSnapshot
+	sysLogs   []map[string]interface{}
+	txLogs    []map[string]interface{}
+}
+
+type memWallet struct {
+	id, publicKey, privateKey, fullName, email, cnic, walletType string
+	orgID, frozenReason, label                                   string
+	status, rotatedFrom, rotatedTo                               string
+	monthly, frozen                                              bool
+	maxTx, maxDaily                                              uint64
+	createdAt                                                    time.Time
+}
+
+type memTx struct {
+	row   map[string]interface{}
+	nonce uint64
+}
+
+type memUTXO struct {
+	id, owner, originTx string
+	assetID             string
+	amount              uint64
+	idx                 int
+	spent               bool
+	createdAt           time.Time
+}
+
+type memSnapshot struct {
+	hash           string
+	version, utxos int
+	state          []byte
+	createdAt      time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		wallets:   make(map[string]memWallet),
+		blocks:    make(map[int64][]byte),
+		txs:       make(map[string]memTx),
+		utxos:     make(map[string]memUTXO),
+		archived:  make(map[string]memUTXO),
+		snapshots: make(map[int64]memSnapshot),
+	}
+}
+
+// Atomic runs fn on m. Writes are not rolled back when fn fails.
+func (m *MemoryStore) Atomic(ctx context.Context, fn func(Store) error) error {
+	return fn(m)
+}
+
+// Wallets
+
+func (m *MemoryStore) SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic, walletType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.wallets[walletID]
+	if !ok {
+		w = memWallet{id: walletID, createdAt: time.Now()}
+	}
+	w.publicKey, w.privateKey, w.fullName, w.email, w.cnic, w.walletType = publicKey, privateKeyEncrypted, fullName, email, cnic, walletType
+	m.wallets[walletID] = w
+	return nil
+}
+
+func (m *MemoryStore) GetWallet(ctx context.Context, walletID string) (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.wallets[walletID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	row := w.row(m.balance(walletID))
+	for _, key := range []string{"org_id", "monthly_statements", "frozen", "frozen_reason", "max_tx_amount", "max_daily_amount", "label", "status", "rotated_from", "rotated_to"} {
+		delete(row, key)
+	}
+	return row, nil
+}
+
+func (m *MemoryStore) GetAllWallets(ctx context.Context) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	wallets := make([]memWallet, 0, len(m.wallets))
+	for _, w := range m.wallets {
+		wallets = append(wallets, w)
+	}
+	sort.Slice(wallets, func(i, j int) bool { return wallets[i].createdAt.After(wallets[j].createdAt) })
+	rows := make([]map[string]interface{}, len(wallets))
+	for i, w := range wallets {
+		rows[i] = w.row(m.balance(w.id))
+	}
+	return rows, nil
+}
+
+// balance is the total of a wallet's unspent UTXOs, the figure the stored
+// balance converges on in Postgres. The caller must hold the lock.
+func (m *MemoryStore) balance(walletID string) int64 {
+	var sum int64
+	for _, u := range m.utxos {
+		if u.owner == walletID && !u.spent && u.assetID == "" {
+			sum += int64(u.amount)
+		}
+	}
+	return sum
+}
+
+// row is the wallet as GetAllWallets returns it
+func (w memWallet) row(balance int64) map[string]interface{} {
+	return map[string]interface{}{
+		"wallet_id":             w.id,
+		"public_key":            w.publicKey,
+		"private_key_encrypted": w.privateKey,
+		"full_name":             w.fullName,
+		"email":                 w.email,
+		"is_admin":              false,
+		"balance":               balance,
+		"created_at":            w.createdAt,
+		"wallet_type":           w.walletType,
+		"org_id":                w.orgID,
+		"monthly_statements":    w.monthly,
+		"frozen":                w.frozen,
+		"frozen_reason":         w.frozenReason,
+		"max_tx_amount":         w.maxTx,
+		"max_daily_amount":      w.maxDaily,
+		"label":                 w.label,
+		"status":                w.status,
+		"rotated_from":          w.rotatedFrom,
+		"rotated_to":            w.rotatedTo,
+	}
+}
+
+// updateWallet applies fn to a stored wallet; missing wallets are ignored
+func (m *MemoryStore) updateWallet(walletID string, fn func(*memWallet)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.wallets[walletID]; ok {
+		fn(&w)
+		m.wallets[walletID] = w
+	}
+	return nil
+}
+
+func (m *MemoryStore) UpdateWalletType(ctx context.Context, walletID, walletType string) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.walletType = walletType })
+}
+
+func (m *MemoryStore) UpdateWalletOrg(ctx context.Context, walletID, orgID string) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.orgID = orgID })
+}
+
+func (m *MemoryStore) UpdateWalletStatements(ctx context.Context, walletID string, enabled bool) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.monthly = enabled })
+}
+
+func (m *MemoryStore) UpdateWalletFrozen(ctx context.Context, walletID string, frozen bool, reason string) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.frozen, w.frozenReason = frozen, reason })
+}
+
+func (m *MemoryStore) UpdateWalletLimits(ctx context.Context, walletID string, maxTx, maxDaily uint64) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.maxTx, w.maxDaily = maxTx, maxDaily })
+}
+
+func (m *MemoryStore) UpdateWalletLabel(ctx context.Context, walletID, label string) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.label = label })
+}
+
+func (m *MemoryStore) UpdateWalletStatus(ctx context.Context, walletID, status, rotatedFrom, rotatedTo string) error {
+	return m.updateWallet(walletID, func(w *memWallet) { w.status, w.rotatedFrom, w.rotatedTo = status, rotatedFrom, rotatedTo })
+}
+
+func (m *MemoryStore) DeleteWallet(ctx context.Context, walletID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.wallets, walletID)
+	return nil
+}
+
+// Chain
+
+func (m *MemoryStore) SaveBlock(ctx context.Context, version int, idx, timestamp int64, previousHash, hash string, nonce int64, merkleRoot string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blocks[idx]; !ok {
+		m.blocks[idx] = append([]byte(nil), body...)
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetBlockBodies(ctx context.Context) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	heights := make([]int64, 0, len(m.blocks))
+	for h := range m.blocks {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	bodies := make([][]byte, len(heights))
+	for i, h := range heights {
+		bodies[i] = m.blocks[h]
+	}
+	return bodies, nil
+}
+
+func (m *MemoryStore) SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if tx, ok := m.txs[id]; ok {
+		tx.row["block_index"] = blockIndex
+		tx.row["status"] = status
+		return nil
+	}
+	m.txs[id] = memTx{nonce: nonce, row: map[string]interface{}{
+		"id":          id,
+		"sender_id":   senderID,
+		"receiver_id": receiverID,
+		"amount":      amount,
+		"note":        note,
+		"timestamp":   timestamp,
+		"pubkey":      pubkey,
+		"signature":   signature,
+		"tx_type":     txType,
+		"block_index": blockIndex,
+		"status":      status,
+		"created_at":  time.Now(),
+	}}
+	return nil
+}
+
+func (m *MemoryStore) GetAllTransactions(ctx context.Context) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rows := make([]map[string]interface{}, 0, len(m.txs))
+	for _, tx := range m.txs {
+		rows = append(rows, copyRow(tx.row))
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i]["timestamp"].(int64) > rows[j]["timestamp"].(int64) })
+	return rows, nil
+}
+
+func (m *MemoryStore) GetTransactionStatus(ctx context.Context, id string) (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tx, ok := m.txs[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return map[string]interface{}{
+		"id":          id,
+		"sender_id":   tx.row["sender_id"],
+		"receiver_id": tx.row["receiver_id"],
+		"status":      tx.row["status"],
+		"block_index": tx.row["block_index"],
+	}, nil
+}
+
+func (m *MemoryStore) GetWalletNonces(ctx context.Context) (map[string]uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	nonces := map[string]uint64{}
+	for _, tx := range m.txs {
+		sender := tx.row["sender_id"].(string)
+		if tx.nonce > nonces[sender] {
+			nonces[sender] = tx.nonce
+		}
+	}
+	return nonces, nil
+}
+
+func (m *MemoryStore) SaveUTXO(ctx context.Context, id, owner, assetID string, amount uint64, originTx string, idx int, spent bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if u, ok := m.utxos[id]; ok {
+		u.spent = spent
+		m.utxos[id] = u
+		return nil
+	}
+	m.utxos[id] = memUTXO{id: id, owner: owner, assetID: assetID, amount: amount, originTx: originTx, idx: idx, spent: spent, createdAt: time.Now()}
+	return nil
+}
+
+func (m *MemoryStore) GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	utxos := make([]memUTXO, 0, len(m.utxos))
+	for _, u := range m.utxos {
+		utxos = append(utxos, u)
+	}
+	sort.Slice(utxos, func(i, j int) bool { return utxos[i].createdAt.After(utxos[j].createdAt) })
+	rows := make([]map[string]interface{}, len(utxos))
+	for i, u := range utxos {
+		rows[i] = map[string]interface{}{
+			"id":         u.id,
+			"owner":      u.owner,
+			"asset_id":   u.assetID,
+			"amount":     u.amount,
+			"origin_tx":  u.originTx,
+			"index":      u.idx,
+			"spent":      u.spent,
+			"created_at": u.createdAt,
+		}
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) ArchiveUTXOs(ctx context.Context, ids []string, spentHeights []int64) (int64, error) {
+	if len(ids) != len(spentHeights) {
+		return 0, fmt.Errorf("%d ids but %d spent heights", len(ids), len(spentHeights))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	for _, id := range ids {
+		u, ok := m.utxos[id]
+		if !ok || !u.spent {
+			continue
+		}
+		delete(m.utxos, id)
+		if _, dup := m.archived[id]; !dup {
+			m.archived[id] = u
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (m *MemoryStore) ResetChain(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks = make(map[int64][]byte)
+	m.txs = make(map[string]memTx)
+	m.utxos = make(map[string]memUTXO)
+	m.archived = make(map[string]memUTXO)
+	m.snapshots = make(map[int64]memSnapshot)
+	m.txLogs = nil
+	return nil
+}
+
+func (m *MemoryStore) SaveChainSnapshot(ctx context.Context, height int64, hash string, version, utxoCount int, state []byte, createdAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots[height] = memSnapshot{hash: hash, version: version, utxos: utxoCount, state: append([]byte(nil), state...), createdAt: createdAt}
+	return nil
+}
+
+func (m *MemoryStore) GetLatestChainSnapshot(ctx context.Context, maxHeight int64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	best := int64(-1)
+	for h := range m.snapshots {
+		if h <= maxHeight && h > best {
+			best = h
+		}
+	}
+	if best < 0 {
+		return nil, nil
+	}
+	return m.snapshots[best].state, nil
+}
+
+func (m *MemoryStore) GetChainSnapshots(ctx context.Context) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	heights := m.snapshotHeights()
+	rows := make([]map[string]interface{}, len(heights))
+	for i, h := range heights {
+		s := m.snapshots[h]
+		rows[i] = map[string]interface{}{
+			"height":     h,
+			"hash":       s.hash,
+			"version":    s.version,
+			"utxo_count": s.utxos,
+			"size":       len(s.state),
+			"created_at": s.createdAt,
+		}
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) PruneChainSnapshots(ctx context.Context, keep int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var dropped int64
+	for i, h := range m.snapshotHeights() {
+		if i >= keep {
+			delete(m.snapshots, h)
+			dropped++
+		}
+	}
+	return dropped, nil
+}
+
+// snapshotHeights lists the snapshot heights, newest first. The caller must
+// hold the lock.
+func (m *MemoryStore) snapshotHeights() []int64 {
+	heights := make([]int64, 0, len(m.snapshots))
+	for h := range m.snapshots {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] > heights[j] })
+	return heights
+}
+
+// Logs
+
+func (m *MemoryStore) SaveSystemLog(ctx context.Context, eventType, walletID, ipAddress, details, requestID string, origin LogOrigin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sysLogs = append(m.sysLogs, map[string]interface{}{
+		"id":         int64(len(m.sysLogs) + 1),
+		"event_type": eventType,
+		"wallet_id":  walletID,
+		"ip_address": ipAddress,
+		"details":    details,
+		"request_id": requestID,
+		"country":    origin.Country,
+		"city":       origin.City,
+		"device":     origin.Device,
+		"created_at": time.Now(),
+	})
+	return nil
+}
+
+func (m *MemoryStore) SaveTransactionLog(ctx context.Context, transactionID, action, walletID, blockHash, status, ipAddress, requestID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txLogs = append(m.txLogs, map[string]interface{}{
+		"id":             int64(len(m.txLogs) + 1),
+		"transaction_id": transactionID,
+		"action":         action,
+		"wallet_id":      walletID,
+		"block_hash":     blockHash,
+		"status":         status,
+		"ip_address":     ipAddress,
+		"request_id":     requestID,
+		"created_at":     time.Now(),
+	})
+	return nil
+}
+
+func (m *MemoryStore) GetSystemLogs(ctx context.Context, limit int) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return newestFirst(m.sysLogs, limit, nil), nil
+}
+
+func (m *MemoryStore) GetTransactionLogs(ctx context.Context, walletID string, limit int) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var keep func(map[string]interface{}) bool
+	if walletID != "" {
+		keep = func(row map[string]interface{}) bool { return row["wallet_id"] == walletID }
+	}
+	return newestFirst(m.txLogs, limit, keep), nil
+}
+
+// newestFirst copies up to limit log rows that keep accepts, newest first,
+// without the request ID, which the Postgres queries do not read either
+func newestFirst(logs []map[string]interface{}, limit int, keep func(map[string]interface{}) bool) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for i := len(logs) - 1; i >= 0 && len(rows) < limit; i-- {
+		if keep == nil || keep(logs[i]) {
+			row := copyRow(logs[i])
+			delete(row, "request_id")
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (m *MemoryStore) SystemLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return logPage(m.sysLogs, f, after, limit), nil
+}
+
+func (m *MemoryStore) TransactionLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return logPage(m.txLogs, f, after, limit), nil
+}
+
+// logPage returns up to limit rows matching f after the cursor, oldest first.
+// Rows are appended in ID order, so the ID alone positions the cursor.
+func logPage(logs []map[string]interface{}, f LogFilter, after LogCursor, limit int) []map[string]interface{} {
+	start := sort.Search(len(logs), func(i int) bool { return logs[i]["id"].(int64) > after.ID })
+	rows := []map[string]interface{}{}
+	for _, row := range logs[start:] {
+		if len(rows) == limit {
+			break
+		}
+		if f.Matches(row["created_at"].(time.Time), row["wallet_id"].(string)) {
+			rows = append(rows, copyRow(row))
+		}
+	}
+	return rows
+}
+
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS org_config CASCADE;
+DROP TABLE IF EXISTS org_admins CASCADE;
+DROP TABLE IF EXISTS organizations CASCADE;
+DROP TABLE IF EXISTS wallet_two_factor CASCADE;
+DROP TABLE IF EXISTS api_usage CASCADE;
+DROP TABLE IF EXISTS sessions CASCADE;
+DROP TABLE IF EXISTS wallet_type_requests CASCADE;
+DROP TABLE IF EXISTS kyc_submissions CASCADE;
+DROP TABLE IF EXISTS statements CASCADE;
+DROP TABLE IF EXISTS utxos_archive CASCADE;
+DROP TABLE IF EXISTS chain_snapshots CASCADE;
+DROP TABLE IF EXISTS supply_issuance CASCADE;
+DROP TABLE IF EXISTS faucet_ledger CASCADE;
+DROP TABLE IF EXISTS webhooks CASCADE;
+DROP TABLE IF EXISTS delivery_attempts CASCADE;
+DROP TABLE IF EXISTS deliveries CASCADE;
+DROP TABLE IF EXISTS wallet_events CASCADE;
+DROP TABLE IF EXISTS transaction_logs CASCADE;
+DROP TABLE IF EXISTS system_logs CASCADE;
+DROP TABLE IF EXISTS zakat_deductions CASCADE;
+DROP TABLE IF EXISTS beneficiaries CASCADE;
+DROP TABLE IF EXISTS transactions CASCADE;
+DROP TABLE IF EXISTS blocks CASCADE;
+DROP TABLE IF EXISTS utxos CASCADE;
+DROP TABLE IF EXISTS wallets CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
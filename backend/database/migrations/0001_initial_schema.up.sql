@@ -0,0 +1,283 @@
+-- The schema as InitSchema created it before migrations were versioned. Every
+-- statement is idempotent, so databases created by InitSchema adopt it as is.
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	full_name VARCHAR(255) NOT NULL,
+	cnic VARCHAR(50),
+	is_admin BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS wallets (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id),
+	public_key TEXT NOT NULL,
+	private_key_encrypted TEXT NOT NULL,
+	full_name VARCHAR(255),
+	email VARCHAR(255),
+	is_admin BOOLEAN DEFAULT FALSE,
+	balance BIGINT DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS utxos (
+	id VARCHAR(200) PRIMARY KEY,
+	owner VARCHAR(100) NOT NULL,
+	amount BIGINT NOT NULL,
+	origin_tx VARCHAR(200) NOT NULL,
+	idx INTEGER NOT NULL,
+	spent BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS blocks (
+	idx BIGINT PRIMARY KEY,
+	timestamp BIGINT NOT NULL,
+	previous_hash TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	nonce BIGINT NOT NULL,
+	merkle_root TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id VARCHAR(200) PRIMARY KEY,
+	sender_id VARCHAR(100) NOT NULL,
+	receiver_id VARCHAR(100) NOT NULL,
+	amount BIGINT NOT NULL,
+	note TEXT,
+	timestamp BIGINT NOT NULL,
+	pubkey TEXT NOT NULL,
+	signature TEXT NOT NULL,
+	tx_type VARCHAR(50) DEFAULT 'transfer',
+	block_index BIGINT REFERENCES blocks(idx),
+	status VARCHAR(50) DEFAULT 'pending',
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS beneficiaries (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id),
+	wallet_id VARCHAR(100) NOT NULL,
+	name VARCHAR(255),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS zakat_deductions (
+	id SERIAL PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	amount BIGINT NOT NULL,
+	month INTEGER NOT NULL,
+	year INTEGER NOT NULL,
+	transaction_id VARCHAR(200),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS system_logs (
+	id SERIAL PRIMARY KEY,
+	event_type VARCHAR(100) NOT NULL,
+	wallet_id VARCHAR(100),
+	ip_address VARCHAR(50),
+	details TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS transaction_logs (
+	id SERIAL PRIMARY KEY,
+	transaction_id VARCHAR(200) NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	wallet_id VARCHAR(100) NOT NULL,
+	block_hash TEXT,
+	status VARCHAR(50),
+	ip_address VARCHAR(50),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS wallet_events (
+	seq BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	payload TEXT,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+	id BIGINT PRIMARY KEY,
+	channel VARCHAR(20) NOT NULL,
+	target TEXT NOT NULL,
+	event_type VARCHAR(100),
+	payload TEXT,
+	dedup_key VARCHAR(200),
+	status VARCHAR(20) NOT NULL,
+	attempts INTEGER DEFAULT 0,
+	last_error TEXT,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW(),
+	delivered_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+	delivery_id BIGINT NOT NULL,
+	attempt INTEGER NOT NULL,
+	attempted_at TIMESTAMP NOT NULL,
+	duration_ms BIGINT NOT NULL,
+	error TEXT,
+	PRIMARY KEY (delivery_id, attempt)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	events TEXT NOT NULL,
+	secret_encrypted TEXT NOT NULL,
+	active BOOLEAN DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS faucet_ledger (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	email VARCHAR(255),
+	ip VARCHAR(64),
+	amount BIGINT NOT NULL,
+	utxo_id VARCHAR(200) NOT NULL,
+	source VARCHAR(10) NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS supply_issuance (
+	id BIGSERIAL PRIMARY KEY,
+	source VARCHAR(10) NOT NULL,
+	amount BIGINT NOT NULL,
+	ref VARCHAR(200),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS chain_snapshots (
+	height BIGINT PRIMARY KEY,
+	hash TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	utxo_count INTEGER NOT NULL,
+	state JSONB NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS utxos_archive (
+	id VARCHAR(200) PRIMARY KEY,
+	owner VARCHAR(100) NOT NULL,
+	amount BIGINT NOT NULL,
+	origin_tx VARCHAR(200) NOT NULL,
+	idx INTEGER NOT NULL,
+	spent_height BIGINT,
+	created_at TIMESTAMP,
+	archived_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS statements (
+	id BIGSERIAL PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	period VARCHAR(7) NOT NULL,
+	opening_balance BIGINT NOT NULL,
+	closing_balance BIGINT NOT NULL,
+	total_received BIGINT NOT NULL,
+	total_sent BIGINT NOT NULL,
+	total_fees BIGINT NOT NULL,
+	tx_count INTEGER NOT NULL,
+	email VARCHAR(255),
+	delivery_id BIGINT,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE (wallet_id, period)
+);
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	cnic VARCHAR(15) NOT NULL,
+	document_type VARCHAR(20) NOT NULL,
+	document_ref TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	note TEXT,
+	reviewed_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	reviewed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS wallet_type_requests (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	from_type VARCHAR(20) NOT NULL,
+	to_type VARCHAR(20) NOT NULL,
+	reason TEXT,
+	status VARCHAR(20) NOT NULL,
+	requested_by VARCHAR(100),
+	decided_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	decided_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	email VARCHAR(255) NOT NULL,
+	user_id INTEGER,
+	method VARCHAR(20) NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+	method VARCHAR(10) NOT NULL,
+	route VARCHAR(255) NOT NULL,
+	client VARCHAR(255) NOT NULL,
+	count BIGINT NOT NULL DEFAULT 0,
+	first_seen TIMESTAMP NOT NULL,
+	last_seen TIMESTAMP NOT NULL,
+	PRIMARY KEY (method, route, client)
+);
+
+CREATE TABLE IF NOT EXISTS wallet_two_factor (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	secret_encrypted TEXT NOT NULL,
+	enabled BOOLEAN DEFAULT FALSE,
+	threshold BIGINT NOT NULL,
+	last_step BIGINT DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW(),
+	confirmed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS organizations (
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	created_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS org_admins (
+	org_id VARCHAR(64) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	wallet_id VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (org_id, wallet_id)
+);
+
+CREATE TABLE IF NOT EXISTS org_config (
+	org_id VARCHAR(64) PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+	overrides JSONB NOT NULL DEFAULT '{}',
+	updated_by VARCHAR(100),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_utxos_owner ON utxos(owner);
+CREATE INDEX IF NOT EXISTS idx_utxos_spent ON utxos(spent);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender ON transactions(sender_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver ON transactions(receiver_id);
+CREATE INDEX IF NOT EXISTS idx_system_logs_wallet ON system_logs(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_events_wallet_seq ON wallet_events(wallet_id, seq);
+CREATE INDEX IF NOT EXISTS idx_deliveries_status ON deliveries(status);
+CREATE INDEX IF NOT EXISTS idx_webhooks_wallet ON webhooks(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_faucet_ledger_created ON faucet_ledger(created_at);
+CREATE INDEX IF NOT EXISTS idx_supply_issuance_created ON supply_issuance(created_at);
+CREATE INDEX IF NOT EXISTS idx_utxos_archive_owner ON utxos_archive(owner);
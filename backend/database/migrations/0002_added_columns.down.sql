@@ -0,0 +1,31 @@
+ALTER TABLE deliveries DROP COLUMN IF EXISTS webhook_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS max_daily_amount;
+ALTER TABLE wallets DROP COLUMN IF EXISTS max_tx_amount;
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_reason;
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen;
+ALTER TABLE wallets DROP COLUMN IF EXISTS monthly_statements;
+DROP INDEX IF EXISTS idx_wallets_org;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS org_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS wallet_type;
+DROP INDEX IF EXISTS idx_transactions_anchor_note;
+ALTER TABLE transactions DROP COLUMN IF EXISTS nonce;
+ALTER TABLE blocks DROP COLUMN IF EXISTS body;
+ALTER TABLE blocks DROP COLUMN IF EXISTS version;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee;
+ALTER TABLE transaction_logs DROP COLUMN IF EXISTS request_id;
+ALTER TABLE system_logs DROP COLUMN IF EXISTS request_id;
+ALTER TABLE wallet_events DROP COLUMN IF EXISTS schema_version;
+DROP INDEX IF EXISTS idx_beneficiaries_user_wallet;
+DROP INDEX IF EXISTS idx_beneficiaries_user_alias;
+ALTER TABLE beneficiaries DROP COLUMN IF EXISTS alias;
+ALTER TABLE beneficiaries DROP COLUMN IF EXISTS relationship;
+DROP INDEX IF EXISTS idx_wallets_is_admin;
+DROP INDEX IF EXISTS idx_users_google_id;
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users DROP COLUMN IF EXISTS google_id;
+ALTER TABLE users DROP COLUMN IF EXISTS is_verified;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+ALTER TABLE wallets DROP COLUMN IF EXISTS is_admin;
+ALTER TABLE wallets DROP COLUMN IF EXISTS email;
+ALTER TABLE wallets DROP COLUMN IF EXISTS full_name;
@@ -0,0 +1,33 @@
+-- Columns and indexes InitSchema added to existing tables over time
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS full_name VARCHAR(255);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_verified BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS google_id VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_google_id ON users(google_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_is_admin ON wallets(is_admin);
+ALTER TABLE beneficiaries ADD COLUMN IF NOT EXISTS relationship VARCHAR(100);
+ALTER TABLE beneficiaries ADD COLUMN IF NOT EXISTS alias VARCHAR(32);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_beneficiaries_user_alias ON beneficiaries(user_id, alias) WHERE alias IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_beneficiaries_user_wallet ON beneficiaries(user_id, wallet_id);
+ALTER TABLE wallet_events ADD COLUMN IF NOT EXISTS schema_version INTEGER DEFAULT 1;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
+ALTER TABLE transaction_logs ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee BIGINT DEFAULT 0;
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS body JSONB;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS nonce BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_transactions_anchor_note ON transactions(note) WHERE tx_type = 'anchor';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS wallet_type VARCHAR(20) DEFAULT 'personal';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS org_id VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_id VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_wallets_org ON wallets(org_id);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS monthly_statements BOOLEAN DEFAULT FALSE;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen BOOLEAN DEFAULT FALSE;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_reason TEXT DEFAULT '';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS max_tx_amount BIGINT DEFAULT 0;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS max_daily_amount BIGINT DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS webhook_id BIGINT DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Owners name their wallets, e.g. "Savings"; one user may own several
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS label VARCHAR(64) DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets(user_id);
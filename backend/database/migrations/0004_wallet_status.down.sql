@@ -0,0 +1,3 @@
+ALTER TABLE wallets DROP COLUMN IF EXISTS rotated_to;
+ALTER TABLE wallets DROP COLUMN IF EXISTS rotated_from;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status;
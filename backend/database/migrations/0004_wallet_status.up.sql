@@ -0,0 +1,6 @@
+-- A rotated key retires its wallet in favour of a new one, linked both ways
+-- so history stays queryable; owners may also deactivate an empty wallet
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status VARCHAR(16) DEFAULT 'active';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS rotated_from VARCHAR(64) DEFAULT '';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS rotated_to VARCHAR(64) DEFAULT '';
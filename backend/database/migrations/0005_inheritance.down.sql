@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS inheritance_payouts;
+DROP TABLE IF EXISTS inheritance_rules;
+ALTER TABLE beneficiaries DROP COLUMN IF EXISTS share_percent;
@@ -0,0 +1,28 @@
+-- Beneficiaries with a share are a wallet's nominees; a wallet with an
+-- inactivity rule pays them out once it has sent nothing for that long and an
+-- admin approves
+
+ALTER TABLE beneficiaries ADD COLUMN IF NOT EXISTS share_percent INTEGER DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS inheritance_rules (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	inactivity_months INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS inheritance_payouts (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	balance BIGINT NOT NULL DEFAULT 0,
+	last_activity TIMESTAMP NOT NULL,
+	nominees JSONB NOT NULL DEFAULT '[]',
+	split_txid VARCHAR(200),
+	reason TEXT,
+	decided_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	decided_at TIMESTAMP,
+	paid_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_inheritance_payouts_wallet ON inheritance_payouts(wallet_id);
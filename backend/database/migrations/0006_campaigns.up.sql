@@ -0,0 +1,15 @@
+-- Fundraising campaigns created by admins; donations are on-chain
+-- transactions of type donation, so only the campaigns themselves are stored
+
+CREATE TABLE IF NOT EXISTS campaigns (
+	id BIGINT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	target_amount BIGINT NOT NULL,
+	deadline TIMESTAMP NOT NULL,
+	destination_wallet VARCHAR(100) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	created_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	closed_at TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS zakat_distributions;
+DROP TABLE IF EXISTS charities;
@@ -0,0 +1,21 @@
+-- Charity wallets approved to receive zakat, and each split of the collected
+-- zakat between them
+
+CREATE TABLE IF NOT EXISTS charities (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	weight INTEGER NOT NULL,
+	added_by VARCHAR(100),
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS zakat_distributions (
+	id BIGINT PRIMARY KEY,
+	status VARCHAR(20) NOT NULL,
+	amount BIGINT NOT NULL DEFAULT 0,
+	split_txid VARCHAR(200),
+	shares JSONB NOT NULL DEFAULT '[]',
+	created_at TIMESTAMP DEFAULT NOW(),
+	distributed_at TIMESTAMP
+);
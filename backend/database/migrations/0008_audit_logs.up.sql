@@ -0,0 +1,23 @@
+-- Every state-changing API call, for compliance audits
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id BIGSERIAL PRIMARY KEY,
+	method VARCHAR(10) NOT NULL,
+	path VARCHAR(500) NOT NULL,
+	route VARCHAR(255) NOT NULL,
+	wallet_id VARCHAR(100),
+	actor VARCHAR(100),
+	org_id VARCHAR(64),
+	ip_address VARCHAR(50),
+	user_agent VARCHAR(255),
+	status INTEGER NOT NULL,
+	outcome VARCHAR(20) NOT NULL,
+	error_code VARCHAR(50),
+	latency_ms INTEGER NOT NULL,
+	request_id VARCHAR(64),
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created ON audit_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_wallet ON audit_logs(wallet_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_route ON audit_logs(route, created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS known_origins;
+ALTER TABLE system_logs DROP COLUMN IF EXISTS device;
+ALTER TABLE system_logs DROP COLUMN IF EXISTS city;
+ALTER TABLE system_logs DROP COLUMN IF EXISTS country;
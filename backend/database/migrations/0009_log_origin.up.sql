@@ -0,0 +1,15 @@
+-- Where logins, sends and key exports came from, and the devices and
+-- countries each user has been seen from
+
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS country VARCHAR(8);
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS device VARCHAR(64);
+
+CREATE TABLE IF NOT EXISTS known_origins (
+	email VARCHAR(255) NOT NULL,
+	kind VARCHAR(10) NOT NULL,
+	value VARCHAR(64) NOT NULL,
+	first_seen TIMESTAMP NOT NULL,
+	last_seen TIMESTAMP NOT NULL,
+	PRIMARY KEY (email, kind, value)
+);
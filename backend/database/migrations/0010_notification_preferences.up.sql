@@ -0,0 +1,11 @@
+-- Which wallet events each owner is emailed about
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	incoming_payments BOOLEAN NOT NULL DEFAULT TRUE,
+	min_incoming_amount BIGINT NOT NULL DEFAULT 0,
+	zakat_deductions BOOLEAN NOT NULL DEFAULT TRUE,
+	profile_changes BOOLEAN NOT NULL DEFAULT TRUE,
+	beneficiary_changes BOOLEAN NOT NULL DEFAULT TRUE,
+	updated_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- In-app notifications shown in each wallet's inbox
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(100) NOT NULL,
+	kind VARCHAR(50) NOT NULL,
+	category VARCHAR(20) NOT NULL,
+	title VARCHAR(200) NOT NULL,
+	body TEXT NOT NULL,
+	txid VARCHAR(200),
+	read_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_wallet ON notifications (wallet_id, id DESC);
@@ -0,0 +1,13 @@
+-- Every coin to fiat exchange rate the node used, so amounts can be
+-- converted at the rate of their time
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	id BIGSERIAL PRIMARY KEY,
+	currency VARCHAR(3) NOT NULL,
+	rate DOUBLE PRECISION NOT NULL,
+	source VARCHAR(100) NOT NULL,
+	set_by VARCHAR(100),
+	effective_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_currency ON exchange_rates (currency, effective_at);
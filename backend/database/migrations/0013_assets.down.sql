@@ -0,0 +1,3 @@
+ALTER TABLE utxos_archive DROP COLUMN IF EXISTS asset_id;
+ALTER TABLE utxos DROP COLUMN IF EXISTS asset_id;
+DROP TABLE IF EXISTS assets;
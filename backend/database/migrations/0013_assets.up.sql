@@ -0,0 +1,16 @@
+-- Assets other than the coin that the chain carries, and which asset each
+-- output holds (empty for the coin)
+
+CREATE TABLE IF NOT EXISTS assets (
+	id VARCHAR(16) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	issuer VARCHAR(64) NOT NULL,
+	max_supply BIGINT NOT NULL DEFAULT 0,
+	mintable BOOLEAN NOT NULL DEFAULT FALSE,
+	issued BIGINT NOT NULL DEFAULT 0,
+	created_by VARCHAR(100),
+	created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS asset_id VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE utxos_archive ADD COLUMN IF NOT EXISTS asset_id VARCHAR(16) NOT NULL DEFAULT '';
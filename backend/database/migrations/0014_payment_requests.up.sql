@@ -0,0 +1,18 @@
+-- Requests from one wallet to another for a payment; accepting one sends a
+-- transfer whose note names the request
+
+CREATE TABLE IF NOT EXISTS payment_requests (
+	id BIGINT PRIMARY KEY,
+	requester VARCHAR(64) NOT NULL,
+	payer VARCHAR(64) NOT NULL,
+	amount BIGINT NOT NULL,
+	note TEXT,
+	status VARCHAR(20) NOT NULL,
+	tx_id VARCHAR(64),
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	decided_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer ON payment_requests(payer);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester ON payment_requests(requester);
@@ -0,0 +1,15 @@
+-- Bills split among wallets; each participant's share is a payment request
+-- pointing back to its bill
+
+CREATE TABLE IF NOT EXISTS bills (
+	id BIGINT PRIMARY KEY,
+	creator VARCHAR(64) NOT NULL,
+	total BIGINT NOT NULL,
+	creator_share BIGINT NOT NULL DEFAULT 0,
+	note TEXT,
+	created_at TIMESTAMP NOT NULL,
+	settled_at TIMESTAMP
+);
+
+ALTER TABLE payment_requests ADD COLUMN IF NOT EXISTS bill_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_payment_requests_bill ON payment_requests(bill_id);
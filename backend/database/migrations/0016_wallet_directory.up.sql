@@ -0,0 +1,10 @@
+-- Opt-in email directory: wallets whose owners let others find them by
+-- email. Only a keyed hash of the email is stored.
+
+CREATE TABLE IF NOT EXISTS wallet_directory (
+	wallet_id VARCHAR(64) PRIMARY KEY,
+	email_hash VARCHAR(64) NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_directory_email_hash ON wallet_directory(email_hash);
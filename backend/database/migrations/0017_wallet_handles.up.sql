@@ -0,0 +1,15 @@
+-- Wallet handles (@name): one row per claim, so a wallet's earlier handles
+-- stay on record. A handle and a wallet each have at most one claim that is
+-- not released.
+
+CREATE TABLE IF NOT EXISTS wallet_handles (
+	id BIGINT PRIMARY KEY,
+	wallet_id VARCHAR(64) NOT NULL,
+	handle VARCHAR(32) NOT NULL,
+	claimed_at TIMESTAMP NOT NULL,
+	released_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_handles_active_handle ON wallet_handles(handle) WHERE released_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_handles_active_wallet ON wallet_handles(wallet_id) WHERE released_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_wallet_handles_wallet ON wallet_handles(wallet_id);
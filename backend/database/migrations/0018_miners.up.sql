@@ -0,0 +1,16 @@
+-- Every wallet that has been paid a block's coinbase, with how many blocks it
+-- produced, what they paid it and when it last produced one. Rows are kept up
+-- to date as blocks are mined and emptied with the chain.
+
+CREATE TABLE IF NOT EXISTS miners (
+	wallet_id VARCHAR(100) PRIMARY KEY,
+	blocks_mined BIGINT NOT NULL,
+	total_rewards BIGINT NOT NULL,
+	fees_collected BIGINT NOT NULL,
+	first_block BIGINT NOT NULL,
+	last_block BIGINT NOT NULL,
+	first_mined_at TIMESTAMP NOT NULL,
+	last_mined_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_miners_blocks_mined ON miners(blocks_mined DESC);
@@ -0,0 +1,57 @@
+-- Rescales a database written while amounts were whole coins to units of
+-- 10^-8 coin (blockchain.UnitsPerCoin). Only a database whose genesis block
+-- predates block version 3 is touched; on any other every statement matches
+-- nothing.
+--
+-- The whole-coin blocks themselves cannot be rescaled, as that would break
+-- every hash and signature. They move to whole_coin_blocks, and at the next
+-- start the node begins a new chain that carries the rescaled UTXO set over
+-- as its opening state. Mined transactions stay in transactions as history,
+-- without a block; their blocks' bodies in whole_coin_blocks still list them.
+
+CREATE TEMP TABLE whole_coin ON COMMIT DROP AS SELECT 1 AS found FROM blocks WHERE idx = 0 AND version < 3;
+
+UPDATE wallets SET balance = balance * 100000000, max_tx_amount = max_tx_amount * 100000000, max_daily_amount = max_daily_amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE utxos SET amount = amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE utxos_archive SET amount = amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE transactions SET amount = amount * 100000000, fee = fee * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE zakat_deductions SET amount = amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE faucet_ledger SET amount = amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE supply_issuance SET amount = amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE statements SET opening_balance = opening_balance * 100000000, closing_balance = closing_balance * 100000000, total_received = total_received * 100000000, total_sent = total_sent * 100000000, total_fees = total_fees * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE wallet_two_factor SET threshold = threshold * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE campaigns SET target_amount = target_amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE notification_preferences SET min_incoming_amount = min_incoming_amount * 100000000 WHERE EXISTS (SELECT 1 FROM whole_coin);
+
+-- Payouts and distributions keep per-recipient amounts in JSON
+UPDATE inheritance_payouts SET balance = balance * 100000000,
+	nominees = (SELECT COALESCE(jsonb_agg(CASE WHEN n ? 'amount' THEN jsonb_set(n, '{amount}', to_jsonb((n->>'amount')::numeric * 100000000)) ELSE n END), '[]'::jsonb) FROM jsonb_array_elements(nominees) AS n)
+	WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE zakat_distributions SET amount = amount * 100000000,
+	shares = (SELECT COALESCE(jsonb_agg(CASE WHEN s ? 'amount' THEN jsonb_set(s, '{amount}', to_jsonb((s->>'amount')::numeric * 100000000)) ELSE s END), '[]'::jsonb) FROM jsonb_array_elements(shares) AS s)
+	WHERE EXISTS (SELECT 1 FROM whole_coin);
+
+-- Organization overrides of the faucet grant, nisab and fees
+UPDATE org_config SET overrides = jsonb_set(overrides, '{faucet_amount}', to_jsonb((overrides->>'faucet_amount')::numeric * 100000000)) WHERE overrides ? 'faucet_amount' AND EXISTS (SELECT 1 FROM whole_coin);
+UPDATE org_config SET overrides = jsonb_set(overrides, '{zakat,nisab}', to_jsonb((overrides#>>'{zakat,nisab}')::numeric * 100000000)) WHERE overrides #> '{zakat,nisab}' IS NOT NULL AND EXISTS (SELECT 1 FROM whole_coin);
+UPDATE org_config SET overrides = jsonb_set(overrides, '{fees,transfer}', to_jsonb((overrides#>>'{fees,transfer}')::numeric * 100000000)) WHERE overrides #> '{fees,transfer}' IS NOT NULL AND EXISTS (SELECT 1 FROM whole_coin);
+UPDATE org_config SET overrides = jsonb_set(overrides, '{fees,anchor}', to_jsonb((overrides#>>'{fees,anchor}')::numeric * 100000000)) WHERE overrides #> '{fees,anchor}' IS NOT NULL AND EXISTS (SELECT 1 FROM whole_coin);
+
+-- The whole-coin chain is archived, and with it the snapshots of its state
+CREATE TABLE IF NOT EXISTS whole_coin_blocks (
+	idx BIGINT PRIMARY KEY,
+	version INTEGER NOT NULL,
+	timestamp BIGINT NOT NULL,
+	previous_hash TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	nonce BIGINT NOT NULL,
+	merkle_root TEXT,
+	body JSONB,
+	created_at TIMESTAMP,
+	archived_at TIMESTAMP DEFAULT NOW()
+);
+INSERT INTO whole_coin_blocks (idx, version, timestamp, previous_hash, hash, nonce, merkle_root, body, created_at)
+	SELECT idx, version, timestamp, previous_hash, hash, nonce, merkle_root, body, created_at FROM blocks WHERE EXISTS (SELECT 1 FROM whole_coin);
+UPDATE transactions SET block_index = NULL WHERE block_index IS NOT NULL AND EXISTS (SELECT 1 FROM whole_coin);
+DELETE FROM chain_snapshots WHERE EXISTS (SELECT 1 FROM whole_coin);
+DELETE FROM blocks WHERE EXISTS (SELECT 1 FROM whole_coin);
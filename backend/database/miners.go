@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveMinedBlock adds a block to its miner's row: one more block, its
+// coinbase reward and the fees that reward included
+func (db *DB) SaveMinedBlock(ctx context.Context, walletID string, reward, fees uint64, blockIndex int64, minedAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO miners (wallet_id, blocks_mined, total_rewards, fees_collected, first_block, last_block, first_mined_at, last_mined_at)
+		VALUES ($1, 1, $2, $3, $4, $4, $5, $5)
+		ON CONFLICT (wallet_id) DO UPDATE SET
+			blocks_mined = miners.blocks_mined + 1,
+			total_rewards = miners.total_rewards + EXCLUDED.total_rewards,
+			fees_collected = miners.fees_collected + EXCLUDED.fees_collected,
+			last_block = GREATEST(miners.last_block, EXCLUDED.last_block),
+			last_mined_at = GREATEST(miners.last_mined_at, EXCLUDED.last_mined_at)
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, int64(reward), int64(fees), blockIndex, minedAt)
+	return err
+}
+
+// GetMiners returns every miner's row
+func (db *DB) GetMiners(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `
+		SELECT wallet_id, blocks_mined, total_rewards, fees_collected, first_block, last_block, first_mined_at, last_mined_at
+		FROM miners
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var miners []map[string]interface{}
+	for rows.Next() {
+		var walletID string
+		var blocks, rewards, fees, firstBlock, lastBlock int64
+		var firstMinedAt, lastMinedAt time.Time
+		if err := rows.Scan(&walletID, &blocks, &rewards, &fees, &firstBlock, &lastBlock, &firstMinedAt, &lastMinedAt); err != nil {
+			return nil, err
+		}
+		miners = append(miners, map[string]interface{}{
+			"wallet_id":      walletID,
+			"blocks_mined":   blocks,
+			"total_rewards":  uint64(rewards),
+			"fees_collected": uint64(fees),
+			"first_block":    firstBlock,
+			"last_block":     lastBlock,
+			"first_mined_at": firstMinedAt,
+			"last_mined_at":  lastMinedAt,
+		})
+	}
+	return miners, rows.Err()
+}
+
+// BackfillMiners seeds an empty miners table from the stored coinbase
+// transactions, so tracking starts from the blocks already mined. It does
+// nothing once the table has rows.
+func (db *DB) BackfillMiners(ctx context.Context) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO miners (wallet_id, blocks_mined, total_rewards, fees_collected, first_block, last_block, first_mined_at, last_mined_at)
+		SELECT cb.receiver_id, COUNT(*), SUM(cb.amount),
+			SUM(COALESCE((SELECT SUM(t.fee) FROM transactions t
+				WHERE t.block_index = cb.block_index AND t.tx_type <> 'mining_reward'), 0)),
+			MIN(cb.block_index), MAX(cb.block_index),
+			TO_TIMESTAMP(MIN(cb.timestamp)) AT TIME ZONE 'UTC', TO_TIMESTAMP(MAX(cb.timestamp)) AT TIME ZONE 'UTC'
+		FROM transactions cb
+		WHERE cb.tx_type = 'mining_reward' AND cb.block_index IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM miners)
+		GROUP BY cb.receiver_id
+	`
+	tag, err := db.conn().Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveNotificationPreferences records which events a wallet's owner is
+// emailed about
+func (db *DB) SaveNotificationPreferences(ctx context.Context, walletID string, incomingPayments bool, minIncomingAmount uint64, zakatDeductions, profileChanges, beneficiaryChanges bool, updatedAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO notification_preferences (wallet_id, incoming_payments, min_incoming_amount, zakat_deductions, profile_changes, beneficiary_changes, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET incoming_payments = EXCLUDED.incoming_payments,
+		    min_incoming_amount = EXCLUDED.min_incoming_amount,
+		    zakat_deductions = EXCLUDED.zakat_deductions,
+		    profile_changes = EXCLUDED.profile_changes,
+		    beneficiary_changes = EXCLUDED.beneficiary_changes,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, incomingPayments, int64(minIncomingAmount), zakatDeductions, profileChanges, beneficiaryChanges, updatedAt)
+	return err
+}
+
+// GetNotificationPreferences returns every wallet's notification preferences
+func (db *DB) GetNotificationPreferences(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT wallet_id, incoming_payments, min_incoming_amount, zakat_deductions, profile_changes, beneficiary_changes, updated_at FROM notification_preferences`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []map[string]interface{}
+	for rows.Next() {
+		var walletID string
+		var incomingPayments, zakatDeductions, profileChanges, beneficiaryChanges bool
+		var minIncomingAmount int64
+		var updatedAt time.Time
+
+		if err := rows.Scan(&walletID, &incomingPayments, &minIncomingAmount, &zakatDeductions, &profileChanges, &beneficiaryChanges, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		prefs = append(prefs, map[string]interface{}{
+			"wallet_id":           walletID,
+			"incoming_payments":   incomingPayments,
+			"min_incoming_amount": uint64(minIncomingAmount),
+			"zakat_deductions":    zakatDeductions,
+			"profile_changes":     profileChanges,
+			"beneficiary_changes": beneficiaryChanges,
+			"updated_at":          updatedAt,
+		})
+	}
+	return prefs, rows.Err()
+}
+
+// SaveNotification adds a notification to a wallet's inbox
+func (db *DB) SaveNotification(ctx context.Context, id int64, walletID, kind, category, title, body, txID string, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO notifications (id, wallet_id, kind, category, title, body, txid, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, kind, category, title, body, txID, createdAt)
+	return err
+}
+
+// MarkNotificationsRead marks the given unread notifications of a wallet as
+// read, or all of them when ids is empty
+func (db *DB) MarkNotificationsRead(ctx context.Context, walletID string, ids []int64, readAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	if len(ids) == 0 {
+		_, err := db.conn().Exec(ctx, `UPDATE notifications SET read_at = $1 WHERE wallet_id = $2 AND read_at IS NULL`, readAt, walletID)
+		return err
+	}
+	_, err := db.conn().Exec(ctx, `UPDATE notifications SET read_at = $1 WHERE wallet_id = $2 AND id = ANY($3) AND read_at IS NULL`, readAt, walletID, ids)
+	return err
+}
+
+// GetRecentNotifications returns up to perWallet of the newest notifications
+// of every wallet, oldest first
+func (db *DB) GetRecentNotifications(ctx context.Context, perWallet int) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `
+		SELECT id, wallet_id, kind, category, title, body, COALESCE(txid, ''), read_at, created_at FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY wallet_id ORDER BY id DESC) AS n FROM notifications
+		) recent
+		WHERE n <= $1
+		ORDER BY id
+	`
+	rows, err := db.conn().Query(ctx, query, perWallet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, kind, category, title, body, txID string
+		var readAt *time.Time
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &walletID, &kind, &category, &title, &body, &txID, &readAt, &createdAt); err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, map[string]interface{}{
+			"id":         id,
+			"wallet_id":  walletID,
+			"kind":       kind,
+			"category":   category,
+			"title":      title,
+			"body":       body,
+			"txid":       txID,
+			"read_at":    readAt,
+			"created_at": createdAt,
+		})
+	}
+	return notifications, rows.Err()
+}
+
+// MaxNotificationID returns the highest notification ID ever assigned, so
+// IDs keep increasing across restarts
+func (db *DB) MaxNotificationID(ctx context.Context) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	var id int64
+	err := db.conn().QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM notifications`).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveKnownOrigin records a device or country a user was seen from, or when
+// it was last seen
+func (db *DB) SaveKnownOrigin(ctx context.Context, email, kind, value string, firstSeen, lastSeen time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO known_origins (email, kind, value, first_seen, last_seen)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (email, kind, value) DO UPDATE
+		SET last_seen = GREATEST(known_origins.last_seen, EXCLUDED.last_seen)
+	`
+	_, err := db.conn().Exec(ctx, query, email, kind, value, firstSeen, lastSeen)
+	return err
+}
+
+// GetKnownOrigins returns every device and country users were seen from
+func (db *DB) GetKnownOrigins(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT email, kind, value, first_seen, last_seen FROM known_origins`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var origins []map[string]interface{}
+	for rows.Next() {
+		var email, kind, value string
+		var firstSeen, lastSeen time.Time
+		if err := rows.Scan(&email, &kind, &value, &firstSeen, &lastSeen); err != nil {
+			return nil, err
+		}
+		origins = append(origins, map[string]interface{}{
+			"email":      email,
+			"kind":       kind,
+			"value":      value,
+			"first_seen": firstSeen,
+			"last_seen":  lastSeen,
+		})
+	}
+	return origins, rows.Err()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SavePaymentRequest records a payment request or its new status
+func (db *DB) SavePaymentRequest(ctx context.Context, id int64, requester, payer string, amount uint64, note, status, txID string, billID int64, createdAt, expiresAt time.Time, decidedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO payment_requests (id, requester, payer, amount, note, status, tx_id, bill_id, created_at, expires_at, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8::BIGINT, 0), $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    tx_id = EXCLUDED.tx_id,
+		    decided_at = EXCLUDED.decided_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, requester, payer, int64(amount), note, status, txID, billID, createdAt, expiresAt, decidedAt)
+	return err
+}
+
+// GetPaymentRequests returns every payment request in ID order
+func (db *DB) GetPaymentRequests(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `SELECT id, requester, payer, amount, COALESCE(note, ''), status, COALESCE(tx_id, ''), COALESCE(bill_id, 0), created_at, expires_at, decided_at
+		FROM payment_requests ORDER BY id ASC`
+
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []map[string]interface{}
+	for rows.Next() {
+		var id, amount, billID int64
+		var requester, payer, note, status, txID string
+		var createdAt, expiresAt time.Time
+		var decidedAt *time.Time
+
+		if err := rows.Scan(&id, &requester, &payer, &amount, &note, &status, &txID, &billID, &createdAt, &expiresAt, &decidedAt); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, map[string]interface{}{
+			"id":         id,
+			"requester":  requester,
+			"payer":      payer,
+			"amount":     uint64(amount),
+			"note":       note,
+			"status":     status,
+			"tx_id":      txID,
+			"bill_id":    billID,
+			"created_at": createdAt,
+			"expires_at": expiresAt,
+			"decided_at": decidedAt,
+		})
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveExchangeRate appends a coin to fiat rate to the rate history
+func (db *DB) SaveExchangeRate(ctx context.Context, currency string, rate float64, source, setBy string, effectiveAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO exchange_rates (currency, rate, source, set_by, effective_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`
+	_, err := db.conn().Exec(ctx, query, currency, rate, source, setBy, effectiveAt)
+	return err
+}
+
+// GetExchangeRates returns the whole rate history, oldest first
+func (db *DB) GetExchangeRates(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT currency, rate, source, COALESCE(set_by, ''), effective_at FROM exchange_rates ORDER BY effective_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []map[string]interface{}
+	for rows.Next() {
+		var currency, source, setBy string
+		var rate float64
+		var effectiveAt time.Time
+
+		if err := rows.Scan(&currency, &rate, &source, &setBy, &effectiveAt); err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, map[string]interface{}{
+			"currency":     currency,
+			"rate":         rate,
+			"source":       source,
+			"set_by":       setBy,
+			"effective_at": effectiveAt,
+		})
+	}
+	return rates, rows.Err()
+}
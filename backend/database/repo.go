@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// The repositories below are the storage the core of the node needs: wallets,
+// the chain and the logs. *DB implements them on Postgres, SQLiteStore in a
+// local file and MemoryStore in memory, for tests and for running without a
+// database. Maps returned by the Get methods have the same keys and value
+// types in all three.
+
+// WalletRepo stores wallets and their settings
+type WalletRepo interface {
+	SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic, walletType string) error
+	GetWallet(ctx context.Context, walletID string) (map[string]interface{}, error)
+	GetAllWallets(ctx context.Context) ([]map[string]interface{}, error)
+	UpdateWalletType(ctx context.Context, walletID, walletType string) error
+	UpdateWalletOrg(ctx context.Context, walletID, orgID string) error
+	UpdateWalletStatements(ctx context.Context, walletID string, enabled bool) error
+	UpdateWalletFrozen(ctx context.Context, walletID string, frozen bool, reason string) error
+	UpdateWalletLimits(ctx context.Context, walletID string, maxTx, maxDaily uint64) error
+	UpdateWalletLabel(ctx context.Context, walletID, label string) error
+	UpdateWalletStatus(ctx context.Context, walletID, status, rotatedFrom, rotatedTo string) error
+	DeleteWallet(ctx context.Context, walletID string) error
+}
+
+// ChainRepo stores blocks, transactions, UTXOs and chain snapshots
+type ChainRepo interface {
+	SaveBlock(ctx context.Context, version int, idx, timestamp int64, previousHash, hash string, nonce int64, merkleRoot string, body []byte) error
+	GetBlockBodies(ctx context.Context) ([][]byte, error)
+	SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error
+	GetAllTransactions(ctx context.Context) ([]map[string]interface{}, error)
+	GetTransactionStatus(ctx context.Context, id string) (map[string]interface{}, error)
+	GetWalletNonces(ctx context.Context) (map[string]uint64, error)
+	SaveUTXO(ctx context.Context, id, owner, assetID string, amount uint64, originTx string, idx int, spent bool) error
+	GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error)
+	ArchiveUTXOs(ctx context.Context, ids []string, spentHeights []int64) (int64, error)
+	SaveChainSnapshot(ctx context.Context, height int64, hash string, version, utxoCount int, state []byte, createdAt time.Time) error
+	GetLatestChainSnapshot(ctx context.Context, maxHeight int64) ([]byte, error)
+	GetChainSnapshots(ctx context.Context) ([]map[string]interface{}, error)
+	PruneChainSnapshots(ctx context.Context, keep int) (int64, error)
+	// ResetChain deletes every block, transaction, UTXO and snapshot, the
+	// transaction log and whatever else the backend records about them,
+	// leaving the wallets with no balance
+	ResetChain(ctx context.Context) error
+}
+
+// LogOrigin is where a sensitive event came from: the country and city of
+// its IP address and the caller's device fingerprint. It is empty for other
+// events.
+type LogOrigin struct {
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+	Device  string `json:"device,omitempty"`
+}
+
+// LogRepo stores system and transaction logs
+type LogRepo interface {
+	SaveSystemLog(ctx context.Context, eventType, walletID, ipAddress, details, requestID string, origin LogOrigin) error
+	SaveTransactionLog(ctx context.Context, transactionID, action, walletID, blockHash, status, ipAddress, requestID string) error
+	GetSystemLogs(ctx context.Context, limit int) ([]map[string]interface{}, error)
+	GetTransactionLogs(ctx context.Context, walletID string, limit int) ([]map[string]interface{}, error)
+	SystemLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error)
+	TransactionLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error)
+}
+
+// Store is every repository
+type Store interface {
+	WalletRepo
+	ChainRepo
+	LogRepo
+	// Atomic runs fn with a Store whose writes commit together, or not at all
+	Atomic(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*SQLiteStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// Storage backends selectable with STORAGE_BACKEND
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMemory   = "memory"
+)
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// chainTables are the tables whose rows only make sense for the chain they
+// were written for, in the order ResetChain empties them: transactions
+// reference blocks. Besides the chain itself these are the records that
+// name its transactions, outputs or blocks, or amounts only the chain backs:
+// ledgers, logs, notifications, statements, payouts and distributions,
+// payment requests and the bills they settle, and the assets it issued.
+var chainTables = []string{
+	"transactions", "blocks", "utxos", "utxos_archive", "chain_snapshots",
+	"supply_issuance", "faucet_ledger", "zakat_deductions", "miners",
+	"transaction_logs", "audit_logs", "notifications", "statements",
+	"inheritance_payouts", "zakat_distributions", "payment_requests", "bills",
+	"assets",
+}
+
+// ResetChain empties the chain tables and zeroes every stored balance, in
+// one transaction. Wallets and their settings, users, organizations,
+// campaigns, charities and the system log are kept.
+func (db *DB) ResetChain(ctx context.Context) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	return db.WithTx(ctx, func(tx *DB) error {
+		for _, table := range chainTables {
+			if _, err := tx.conn().Exec(ctx, "DELETE FROM "+table); err != nil {
+				return fmt.Errorf("clear %s: %w", table, err)
+			}
+		}
+		if _, err := tx.conn().Exec(ctx, `UPDATE wallets SET balance = 0 WHERE balance <> 0`); err != nil {
+			return fmt.Errorf("zero balances: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,22 @@
+package database
+
+import "path/filepath"
+
+// A sandbox keeps a node's data apart from the real tables so demos and test
+// runs can share a database with production without polluting it: in its own
+// Postgres schema, or a SQLite file of its own, both named with
+// SandboxPrefix and the sandbox's name.
+
+// SandboxPrefix starts the name of every sandbox's schema and SQLite file
+const SandboxPrefix = "sandbox_"
+
+// SandboxSchema returns the Postgres schema of the named sandbox
+func SandboxSchema(name string) string {
+	return SandboxPrefix + name
+}
+
+// SandboxPath returns the SQLite file of the named sandbox, beside path
+func SandboxPath(path, name string) string {
+	dir, file := filepath.Split(path)
+	return filepath.Join(dir, SandboxPrefix+name+"_"+file)
+}
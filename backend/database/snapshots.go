@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SaveChainSnapshot stores the chain state as of block height, replacing an
+// earlier snapshot of the same block
+func (db *DB) SaveChainSnapshot(ctx context.Context, height int64, hash string, version, utxoCount int, state []byte, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO chain_snapshots (height, hash, version, utxo_count, state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (height) DO UPDATE
+		SET hash = EXCLUDED.hash, version = EXCLUDED.version, utxo_count = EXCLUDED.utxo_count,
+			state = EXCLUDED.state, created_at = EXCLUDED.created_at
+	`
+	_, err := db.conn().Exec(ctx, query, height, hash, version, utxoCount, state, createdAt)
+	return err
+}
+
+// GetLatestChainSnapshot returns the state of the newest snapshot at or below
+// maxHeight, or nil when there is none
+func (db *DB) GetLatestChainSnapshot(ctx context.Context, maxHeight int64) ([]byte, error) {
+	if db == nil || db.Pool == nil {
+		return nil, nil
+	}
+
+	query := `SELECT state FROM chain_snapshots WHERE height <= $1 ORDER BY height DESC LIMIT 1`
+	rows, err := db.conn().Query(ctx, query, maxHeight)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var state []byte
+	if rows.Next() {
+		if err := rows.Scan(&state); err != nil {
+			return nil, err
+		}
+	}
+	return state, rows.Err()
+}
+
+// GetChainSnapshots lists the stored snapshots, newest first, without their
+// state
+func (db *DB) GetChainSnapshots(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	query := `
+		SELECT height, hash, version, utxo_count, octet_length(state::text), created_at
+		FROM chain_snapshots
+		ORDER BY height DESC
+	`
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []map[string]interface{}
+	for rows.Next() {
+		var height int64
+		var hash string
+		var version, utxoCount, size int
+		var createdAt time.Time
+		if err := rows.Scan(&height, &hash, &version, &utxoCount, &size, &createdAt); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, map[string]interface{}{
+			"height":     height,
+			"hash":       hash,
+			"version":    version,
+			"utxo_count": utxoCount,
+			"size":       size,
+			"created_at": createdAt,
+		})
+	}
+	return snapshots, rows.Err()
+}
+
+// PruneChainSnapshots deletes all but the keep newest snapshots
+func (db *DB) PruneChainSnapshots(ctx context.Context, keep int) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	query := `
+		DELETE FROM chain_snapshots
+		WHERE height NOT IN (SELECT height FROM chain_snapshots ORDER BY height DESC LIMIT $1)
+	`
+	tag, err := db.conn().Exec(ctx, query, keep)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetBlockBodies returns the stored blocks as JSON, genesis first. Blocks
+// saved before bodies were stored come back as nil.
+func (db *DB) GetBlockBodies(ctx context.Context) ([][]byte, error) {
+	if db == nil || db.Pool == nil {
+		return nil, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT body FROM blocks ORDER BY idx ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bodies [][]byte
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, rows.Err()
+}
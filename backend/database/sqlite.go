@@ -0,0 +1,1065 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultSQLitePath is the database file used when SQLITE_PATH is unset
+const DefaultSQLitePath = "data/wallet.db"
+
+// SQLiteStore keeps wallets, the chain and the logs in a single SQLite file,
+// so a node persists without an external database. Rows come back with the
+// same keys and types as from Postgres; times are stored as Unix nanoseconds.
+// Wallet balances are not stored: they are the sum of the unspent UTXOs.
+type SQLiteStore struct {
+	db *sql.DB
+	q  sqlQuerier // db, or the transaction inside Atomic
+	tx *sql.Tx
+}
+
+// sqlQuerier runs statements on the database or on a transaction
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// OpenSQLite opens, or creates, the SQLite database at path and its tables.
+// SQLite allows one writer at a time, so the store uses a single connection.
+func OpenSQLite(ctx context.Context, path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStore{db: db, q: db}
+	if err := s.initSchema(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+	return s, nil
+}
+
+// Close closes the database file
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks the database file can still be reached
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteStore) initSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS wallets (
+			wallet_id TEXT PRIMARY KEY,
+			public_key TEXT NOT NULL,
+			private_key_encrypted TEXT NOT NULL,
+			full_name TEXT NOT NULL DEFAULT '',
+			email TEXT NOT NULL DEFAULT '',
+			cnic TEXT NOT NULL DEFAULT '',
+			wallet_type TEXT NOT NULL DEFAULT 'personal',
+			org_id TEXT NOT NULL DEFAULT '',
+			monthly_statements INTEGER NOT NULL DEFAULT 0,
+			frozen INTEGER NOT NULL DEFAULT 0,
+			frozen_reason TEXT NOT NULL DEFAULT '',
+			max_tx_amount INTEGER NOT NULL DEFAULT 0,
+			max_daily_amount INTEGER NOT NULL DEFAULT 0,
+			label TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'active',
+			rotated_from TEXT NOT NULL DEFAULT '',
+			rotated_to TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS blocks (
+			idx INTEGER PRIMARY KEY,
+			version INTEGER NOT NULL,
+			timestamp INTEGER NOT NULL,
+			previous_hash TEXT NOT NULL,
+			hash TEXT NOT NULL,
+			nonce INTEGER NOT NULL,
+			merkle_root TEXT NOT NULL,
+			body BLOB,
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS transactions (
+			id TEXT PRIMARY KEY,
+			sender_id TEXT NOT NULL,
+			receiver_id TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			fee INTEGER NOT NULL DEFAULT 0,
+			nonce INTEGER NOT NULL DEFAULT 0,
+			note TEXT NOT NULL DEFAULT '',
+			timestamp INTEGER NOT NULL,
+			pubkey TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			tx_type TEXT NOT NULL DEFAULT 'transfer',
+			block_index INTEGER,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS utxos (
+			id TEXT PRIMARY KEY,
+			owner TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			origin_tx TEXT NOT NULL,
+			idx INTEGER NOT NULL,
+			spent INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_utxos_owner ON utxos (owner, spent)`,
+		`CREATE TABLE IF NOT EXISTS utxos_archive (
+			id TEXT PRIMARY KEY,
+			owner TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			origin_tx TEXT NOT NULL,
+			idx INTEGER NOT NULL,
+			spent_height INTEGER,
+			created_at INTEGER,
+			archived_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS chain_snapshots (
+			height INTEGER PRIMARY KEY,
+			hash TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			utxo_count INTEGER NOT NULL,
+			state BLOB NOT NULL,
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS system_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			wallet_id TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			details TEXT NOT NULL DEFAULT '',
+			request_id TEXT NOT NULL DEFAULT '',
+			country TEXT NOT NULL DEFAULT '',
+			city TEXT NOT NULL DEFAULT '',
+			device TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_system_logs_created ON system_logs (created_at, id)`,
+		`CREATE TABLE IF NOT EXISTS transaction_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			transaction_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			wallet_id TEXT NOT NULL,
+			block_hash TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			request_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_transaction_logs_created ON transaction_logs (created_at, id)`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			token_hash TEXT PRIMARY KEY,
+			email TEXT NOT NULL,
+			user_id INTEGER,
+			method TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			expires_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS wallet_two_factor (
+			wallet_id TEXT PRIMARY KEY,
+			secret_encrypted TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 0,
+			threshold INTEGER NOT NULL DEFAULT 0,
+			last_step INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			confirmed_at INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY,
+			wallet_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			events TEXT NOT NULL,
+			secret_encrypted TEXT NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS deliveries (
+			id INTEGER PRIMARY KEY,
+			channel TEXT NOT NULL,
+			target TEXT NOT NULL,
+			webhook_id INTEGER NOT NULL DEFAULT 0,
+			event_type TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL DEFAULT '',
+			dedup_key TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			delivered_at INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_deliveries_status ON deliveries (status, id)`,
+		`CREATE TABLE IF NOT EXISTS delivery_attempts (
+			delivery_id INTEGER NOT NULL,
+			attempt INTEGER NOT NULL,
+			attempted_at INTEGER NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (delivery_id, attempt)
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	// Columns added after the tables were first created
+	added := []struct{ table, column, definition string }{
+		{"wallets", "label", "TEXT NOT NULL DEFAULT ''"},
+		{"wallets", "status", "TEXT NOT NULL DEFAULT 'active'"},
+		{"wallets", "rotated_from", "TEXT NOT NULL DEFAULT ''"},
+		{"wallets", "rotated_to", "TEXT NOT NULL DEFAULT ''"},
+		{"system_logs", "country", "TEXT NOT NULL DEFAULT ''"},
+		{"system_logs", "city", "TEXT NOT NULL DEFAULT ''"},
+		{"system_logs", "device", "TEXT NOT NULL DEFAULT ''"},
+		{"utxos", "asset_id", "TEXT NOT NULL DEFAULT ''"},
+		{"utxos_archive", "asset_id", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, c := range added {
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `ALTER TABLE `+c.table+` ADD COLUMN `+c.column+` `+c.definition); err != nil {
+			return err
+		}
+	}
+	return s.rescaleWholeCoins(ctx)
+}
+
+// rescaleWholeCoins converts a file written while amounts were whole coins to
+// units of 10^-8 coin, as migration 0019_amount_units does on Postgres: the
+// stored amounts are scaled, and the whole-coin blocks, which cannot be, move
+// to whole_coin_blocks so the node starts a new chain on the rescaled UTXOs.
+// Files whose genesis block is version 3 or later are left alone.
+func (s *SQLiteStore) rescaleWholeCoins(ctx context.Context) error {
+	var wholeCoin bool
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM blocks WHERE idx = 0 AND version < 3`).Scan(&wholeCoin); err != nil {
+		return err
+	}
+	if !wholeCoin {
+		return nil
+	}
+	return s.atomic(ctx, func(tx *SQLiteStore) error {
+		for _, stmt := range []string{
+			`UPDATE wallets SET max_tx_amount = max_tx_amount * 100000000, max_daily_amount = max_daily_amount * 100000000`,
+			`UPDATE utxos SET amount = amount * 100000000`,
+			`UPDATE utxos_archive SET amount = amount * 100000000`,
+			`UPDATE transactions SET amount = amount * 100000000, fee = fee * 100000000, block_index = NULL`,
+			`CREATE TABLE IF NOT EXISTS whole_coin_blocks AS SELECT * FROM blocks WHERE 0`,
+			`INSERT INTO whole_coin_blocks SELECT * FROM blocks`,
+			`DELETE FROM chain_snapshots`,
+			`DELETE FROM blocks`,
+		} {
+			if _, err := tx.q.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Atomic runs fn with a store whose statements all run in one transaction,
+// committed when fn returns nil and rolled back otherwise. Inside a
+// transaction already, fn joins it.
+func (s *SQLiteStore) Atomic(ctx context.Context, fn func(Store) error) error {
+	return s.atomic(ctx, func(tx *SQLiteStore) error { return fn(tx) })
+}
+
+func (s *SQLiteStore) atomic(ctx context.Context, fn func(tx *SQLiteStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteStore{db: s.db, q: tx, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func nanos(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
+// Wallets
+
+func (s *SQLiteStore) SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic, walletType string) error {
+	query := `
+		INSERT INTO wallets (wallet_id, public_key, private_key_encrypted, full_name, email, cnic, wallet_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET public_key = excluded.public_key,
+		    private_key_encrypted = excluded.private_key_encrypted,
+		    full_name = excluded.full_name,
+		    email = excluded.email,
+		    cnic = excluded.cnic,
+		    wallet_type = excluded.wallet_type
+	`
+	_, err := s.q.ExecContext(ctx, query, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic, walletType, nanos(time.Now()))
+	return err
+}
+
+// walletColumns are the columns scanWallet reads; the balance is derived
+// from the wallet's unspent UTXOs
+const walletColumns = `wallet_id, public_key, private_key_encrypted, full_name, email,
+	COALESCE((SELECT SUM(amount) FROM utxos WHERE owner = wallets.wallet_id AND spent = 0 AND asset_id = ''), 0),
+	created_at, wallet_type, org_id, monthly_statements, frozen, frozen_reason, max_tx_amount, max_daily_amount, label, status, rotated_from, rotated_to`
+
+func scanWallet(row interface{ Scan(...interface{}) error }) (map[string]interface{}, error) {
+	var wid, pubKey, privKey, fullName, email, walletType, orgID, frozenReason, label string
+	var status, rotatedFrom, rotatedTo string
+	var balance, createdAt, maxTx, maxDaily int64
+	var monthly, frozen bool
+	if err := row.Scan(&wid, &pubKey, &privKey, &fullName, &email, &balance, &createdAt, &walletType, &orgID, &monthly, &frozen, &frozenReason, &maxTx, &maxDaily, &label, &status, &rotatedFrom, &rotatedTo); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"wallet_id":             wid,
+		"public_key":            pubKey,
+		"private_key_encrypted": privKey,
+		"full_name":             fullName,
+		"email":                 email,
+		"is_admin":              false,
+		"balance":               balance,
+		"created_at":            fromNanos(createdAt),
+		"wallet_type":           walletType,
+		"org_id":                orgID,
+		"monthly_statements":    monthly,
+		"frozen":                frozen,
+		"frozen_reason":         frozenReason,
+		"max_tx_amount":         uint64(maxTx),
+		"max_daily_amount":      uint64(maxDaily),
+		"label":                 label,
+		"status":                status,
+		"rotated_from":          rotatedFrom,
+		"rotated_to":            rotatedTo,
+	}, nil
+}
+
+func (s *SQLiteStore) GetWallet(ctx context.Context, walletID string) (map[string]interface{}, error) {
+	row, err := scanWallet(s.q.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE wallet_id = ?`, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"org_id", "monthly_statements", "frozen", "frozen_reason", "max_tx_amount", "max_daily_amount", "label", "status", "rotated_from", "rotated_to"} {
+		delete(row, key)
+	}
+	return row, nil
+}
+
+func (s *SQLiteStore) GetAllWallets(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT `+walletColumns+` FROM wallets ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []map[string]interface{}
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, rows.Err()
+}
+
+func (s *SQLiteStore) UpdateWalletType(ctx context.Context, walletID, walletType string) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET wallet_type = ? WHERE wallet_id = ?`, walletType, walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletOrg(ctx context.Context, walletID, orgID string) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET org_id = ? WHERE wallet_id = ?`, orgID, walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletStatements(ctx context.Context, walletID string, enabled bool) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET monthly_statements = ? WHERE wallet_id = ?`, enabled, walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletFrozen(ctx context.Context, walletID string, frozen bool, reason string) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET frozen = ?, frozen_reason = ? WHERE wallet_id = ?`, frozen, reason, walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletLimits(ctx context.Context, walletID string, maxTx, maxDaily uint64) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET max_tx_amount = ?, max_daily_amount = ? WHERE wallet_id = ?`, int64(maxTx), int64(maxDaily), walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletLabel(ctx context.Context, walletID, label string) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET label = ? WHERE wallet_id = ?`, label, walletID)
+	return err
+}
+
+func (s *SQLiteStore) UpdateWalletStatus(ctx context.Context, walletID, status, rotatedFrom, rotatedTo string) error {
+	_, err := s.q.ExecContext(ctx, `UPDATE wallets SET status = ?, rotated_from = ?, rotated_to = ? WHERE wallet_id = ?`, status, rotatedFrom, rotatedTo, walletID)
+	return err
+}
+
+func (s *SQLiteStore) DeleteWallet(ctx context.Context, walletID string) error {
+	_, err := s.q.ExecContext(ctx, `DELETE FROM wallets WHERE wallet_id = ?`, walletID)
+	return err
+}
+
+// Chain
+
+func (s *SQLiteStore) SaveBlock(ctx context.Context, version int, idx, timestamp int64, previousHash, hash string, nonce int64, merkleRoot string, body []byte) error {
+	query := `
+		INSERT INTO blocks (version, idx, timestamp, previous_hash, hash, nonce, merkle_root, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (idx) DO NOTHING
+	`
+	_, err := s.q.ExecContext(ctx, query, version, idx, timestamp, previousHash, hash, nonce, merkleRoot, body, nanos(time.Now()))
+	return err
+}
+
+func (s *SQLiteStore) GetBlockBodies(ctx context.Context) ([][]byte, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT body FROM blocks ORDER BY idx ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bodies [][]byte
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, rows.Err()
+}
+
+func (s *SQLiteStore) SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error {
+	query := `
+		INSERT INTO transactions (id, sender_id, receiver_id, amount, fee, nonce, note, timestamp, pubkey, signature, tx_type, block_index, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET block_index = excluded.block_index,
+		    status = excluded.status
+	`
+	_, err := s.q.ExecContext(ctx, query, id, senderID, receiverID, int64(amount), int64(fee), int64(nonce), note, timestamp, pubkey, signature, txType, blockIndex, status, nanos(time.Now()))
+	return err
+}
+
+func (s *SQLiteStore) GetAllTransactions(ctx context.Context) ([]map[string]interface{}, error) {
+	query := `SELECT id, sender_id, receiver_id, amount, note, timestamp, pubkey, signature, tx_type, block_index, status, created_at FROM transactions ORDER BY timestamp DESC`
+	rows, err := s.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []map[string]interface{}
+	for rows.Next() {
+		var id, senderID, receiverID, note, pubkey, signature, txType, status string
+		var amount, timestamp, createdAt int64
+		var blockIndex sql.NullInt64
+		if err := rows.Scan(&id, &senderID, &receiverID, &amount, &note, &timestamp, &pubkey, &signature, &txType, &blockIndex, &status, &createdAt); err != nil {
+			return nil, err
+		}
+		txs = append(txs, map[string]interface{}{
+			"id":          id,
+			"sender_id":   senderID,
+			"receiver_id": receiverID,
+			"amount":      uint64(amount),
+			"note":        note,
+			"timestamp":   timestamp,
+			"pubkey":      pubkey,
+			"signature":   signature,
+			"tx_type":     txType,
+			"block_index": nullableInt(blockIndex),
+			"status":      status,
+			"created_at":  fromNanos(createdAt),
+		})
+	}
+	return txs, rows.Err()
+}
+
+func nullableInt(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// nullableNanos stores an optional time as Unix nanoseconds or NULL
+func nullableNanos(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	n := nanos(*t)
+	return &n
+}
+
+func fromNullableNanos(n sql.NullInt64) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	t := fromNanos(n.Int64)
+	return &t
+}
+
+func (s *SQLiteStore) GetTransactionStatus(ctx context.Context, id string) (map[string]interface{}, error) {
+	var senderID, receiverID, status string
+	var blockIndex sql.NullInt64
+	err := s.q.QueryRowContext(ctx, `SELECT sender_id, receiver_id, status, block_index FROM transactions WHERE id = ?`, id).Scan(&senderID, &receiverID, &status, &blockIndex)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":          id,
+		"sender_id":   senderID,
+		"receiver_id": receiverID,
+		"status":      status,
+		"block_index": nullableInt(blockIndex),
+	}, nil
+}
+
+func (s *SQLiteStore) GetWalletNonces(ctx context.Context) (map[string]uint64, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT sender_id, MAX(nonce) FROM transactions WHERE nonce > 0 GROUP BY sender_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nonces := map[string]uint64{}
+	for rows.Next() {
+		var walletID string
+		var nonce int64
+		if err := rows.Scan(&walletID, &nonce); err != nil {
+			return nil, err
+		}
+		nonces[walletID] = uint64(nonce)
+	}
+	return nonces, rows.Err()
+}
+
+func (s *SQLiteStore) SaveUTXO(ctx context.Context, id, owner, assetID string, amount uint64, originTx string, idx int, spent bool) error {
+	query := `
+		INSERT INTO utxos (id, owner, amount, origin_tx, idx, spent, created_at, asset_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET spent = excluded.spent
+	`
+	_, err := s.q.ExecContext(ctx, query, id, owner, int64(amount), originTx, idx, spent, nanos(time.Now()), assetID)
+	return err
+}
+
+func (s *SQLiteStore) GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT id, owner, amount, origin_tx, idx, spent, created_at, asset_id FROM utxos ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var utxos []map[string]interface{}
+	for rows.Next() {
+		var id, owner, originTx, assetID string
+		var amount, createdAt int64
+		var idx int
+		var spent bool
+		if err := rows.Scan(&id, &owner, &amount, &originTx, &idx, &spent, &createdAt, &assetID); err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, map[string]interface{}{
+			"id":         id,
+			"owner":      owner,
+			"asset_id":   assetID,
+			"amount":     uint64(amount),
+			"origin_tx":  originTx,
+			"index":      idx,
+			"spent":      spent,
+			"created_at": fromNanos(createdAt),
+		})
+	}
+	return utxos, rows.Err()
+}
+
+func (s *SQLiteStore) ArchiveUTXOs(ctx context.Context, ids []string, spentHeights []int64) (int64, error) {
+	if len(ids) != len(spentHeights) {
+		return 0, fmt.Errorf("%d ids but %d spent heights", len(ids), len(spentHeights))
+	}
+	var moved int64
+	err := s.atomic(ctx, func(tx *SQLiteStore) error {
+		now := nanos(time.Now())
+		for i, id := range ids {
+			res, err := tx.q.ExecContext(ctx, `
+				INSERT INTO utxos_archive (id, owner, amount, origin_tx, idx, spent_height, created_at, archived_at, asset_id)
+				SELECT id, owner, amount, origin_tx, idx, NULLIF(?, 0), created_at, ?, asset_id
+				FROM utxos WHERE id = ? AND spent = 1
+				ON CONFLICT (id) DO NOTHING
+			`, spentHeights[i], now, id)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			moved += n
+			if _, err := tx.q.ExecContext(ctx, `DELETE FROM utxos WHERE id = ? AND spent = 1`, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+func (s *SQLiteStore) ResetChain(ctx context.Context) error {
+	return s.atomic(ctx, func(tx *SQLiteStore) error {
+		for _, table := range []string{"transactions", "blocks", "utxos", "utxos_archive", "chain_snapshots", "transaction_logs"} {
+			if _, err := tx.q.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return fmt.Errorf("clear %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) SaveChainSnapshot(ctx context.Context, height int64, hash string, version, utxoCount int, state []byte, createdAt time.Time) error {
+	query := `
+		INSERT INTO chain_snapshots (height, hash, version, utxo_count, state, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (height) DO UPDATE
+		SET hash = excluded.hash, version = excluded.version, utxo_count = excluded.utxo_count,
+			state = excluded.state, created_at = excluded.created_at
+	`
+	_, err := s.q.ExecContext(ctx, query, height, hash, version, utxoCount, state, nanos(createdAt))
+	return err
+}
+
+func (s *SQLiteStore) GetLatestChainSnapshot(ctx context.Context, maxHeight int64) ([]byte, error) {
+	var state []byte
+	err := s.q.QueryRowContext(ctx, `SELECT state FROM chain_snapshots WHERE height <= ? ORDER BY height DESC LIMIT 1`, maxHeight).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return state, err
+}
+
+func (s *SQLiteStore) GetChainSnapshots(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT height, hash, version, utxo_count, length(state), created_at FROM chain_snapshots ORDER BY height DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []map[string]interface{}
+	for rows.Next() {
+		var height, createdAt int64
+		var hash string
+		var version, utxoCount, size int
+		if err := rows.Scan(&height, &hash, &version, &utxoCount, &size, &createdAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, map[string]interface{}{
+			"height":     height,
+			"hash":       hash,
+			"version":    version,
+			"utxo_count": utxoCount,
+			"size":       size,
+			"created_at": fromNanos(createdAt),
+		})
+	}
+	return snapshots, rows.Err()
+}
+
+func (s *SQLiteStore) PruneChainSnapshots(ctx context.Context, keep int) (int64, error) {
+	res, err := s.q.ExecContext(ctx, `DELETE FROM chain_snapshots WHERE height NOT IN (SELECT height FROM chain_snapshots ORDER BY height DESC LIMIT ?)`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Logs
+
+func (s *SQLiteStore) SaveSystemLog(ctx context.Context, eventType, walletID, ipAddress, details, requestID string, origin LogOrigin) error {
+	query := `INSERT INTO system_logs (event_type, wallet_id, ip_address, details, request_id, country, city, device, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.q.ExecContext(ctx, query, eventType, walletID, ipAddress, details, requestID, origin.Country, origin.City, origin.Device, nanos(time.Now()))
+	return err
+}
+
+func (s *SQLiteStore) SaveTransactionLog(ctx context.Context, transactionID, action, walletID, blockHash, status, ipAddress, requestID string) error {
+	query := `INSERT INTO transaction_logs (transaction_id, action, wallet_id, block_hash, status, ip_address, request_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.q.ExecContext(ctx, query, transactionID, action, walletID, blockHash, status, ipAddress, requestID, nanos(time.Now()))
+	return err
+}
+
+const (
+	systemLogColumns      = `id, event_type, wallet_id, ip_address, details, request_id, country, city, device, created_at`
+	transactionLogColumns = `id, transaction_id, action, wallet_id, block_hash, status, ip_address, request_id, created_at`
+)
+
+func (s *SQLiteStore) GetSystemLogs(ctx context.Context, limit int) ([]map[string]interface{}, error) {
+	query := `SELECT ` + systemLogColumns + ` FROM system_logs ORDER BY created_at DESC, id DESC LIMIT ?`
+	return s.queryLogs(ctx, scanSystemLog, false, query, limit)
+}
+
+func (s *SQLiteStore) GetTransactionLogs(ctx context.Context, walletID string, limit int) ([]map[string]interface{}, error) {
+	if walletID == "" {
+		query := `SELECT ` + transactionLogColumns + ` FROM transaction_logs ORDER BY created_at DESC, id DESC LIMIT ?`
+		return s.queryLogs(ctx, scanTransactionLog, false, query, limit)
+	}
+	query := `SELECT ` + transactionLogColumns + ` FROM transaction_logs WHERE wallet_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`
+	return s.queryLogs(ctx, scanTransactionLog, false, query, walletID, limit)
+}
+
+func (s *SQLiteStore) SystemLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	cond, args := f.sqliteWhere(after)
+	query := `SELECT ` + systemLogColumns + ` FROM system_logs WHERE ` + cond + ` ORDER BY created_at, id LIMIT ?`
+	return s.queryLogs(ctx, scanSystemLog, true, query, append(args, limit)...)
+}
+
+func (s *SQLiteStore) TransactionLogPage(ctx context.Context, f LogFilter, after LogCursor, limit int) ([]map[string]interface{}, error) {
+	cond, args := f.sqliteWhere(after)
+	query := `SELECT ` + transactionLogColumns + ` FROM transaction_logs WHERE ` + cond + ` ORDER BY created_at, id LIMIT ?`
+	return s.queryLogs(ctx, scanTransactionLog, true, query, append(args, limit)...)
+}
+
+// sqliteWhere is where with SQLite placeholders and times in nanoseconds
+func (f LogFilter) sqliteWhere(after LogCursor) (string, []interface{}) {
+	var cursor int64
+	if !after.CreatedAt.IsZero() {
+		cursor = nanos(after.CreatedAt)
+	}
+	cond := "(created_at, id) > (?, ?)"
+	args := []interface{}{cursor, after.ID}
+	if !f.From.IsZero() {
+		cond += " AND created_at >= ?"
+		args = append(args, nanos(f.From))
+	}
+	if !f.To.IsZero() {
+		cond += " AND created_at < ?"
+		args = append(args, nanos(f.To))
+	}
+	if f.WalletID != "" {
+		cond += " AND wallet_id = ?"
+		args = append(args, f.WalletID)
+	}
+	return cond, args
+}
+
+// queryLogs runs a log query, keeping the request ID only when withRequestID
+// is set, as the Postgres log reads do
+func (s *SQLiteStore) queryLogs(ctx context.Context, scan func(*sql.Rows) (map[string]interface{}, error), withRequestID bool, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []map[string]interface{}{}
+	for rows.Next() {
+		row, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !withRequestID {
+			delete(row, "request_id")
+		}
+		logs = append(logs, row)
+	}
+	return logs, rows.Err()
+}
+
+func scanSystemLog(rows *sql.Rows) (map[string]interface{}, error) {
+	var id, createdAt int64
+	var eventType, walletID, ipAddress, details, requestID, country, city, device string
+	if err := rows.Scan(&id, &eventType, &walletID, &ipAddress, &details, &requestID, &country, &city, &device, &createdAt); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":         id,
+		"event_type": eventType,
+		"wallet_id":  walletID,
+		"ip_address": ipAddress,
+		"details":    details,
+		"request_id": requestID,
+		"country":    country,
+		"city":       city,
+		"device":     device,
+		"created_at": fromNanos(createdAt),
+	}, nil
+}
+
+func scanTransactionLog(rows *sql.Rows) (map[string]interface{}, error) {
+	var id, createdAt int64
+	var transactionID, action, walletID, blockHash, status, ipAddress, requestID string
+	if err := rows.Scan(&id, &transactionID, &action, &walletID, &blockHash, &status, &ipAddress, &requestID, &createdAt); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":             id,
+		"transaction_id": transactionID,
+		"action":         action,
+		"wallet_id":      walletID,
+		"block_hash":     blockHash,
+		"status":         status,
+		"ip_address":     ipAddress,
+		"request_id":     requestID,
+		"created_at":     fromNanos(createdAt),
+	}, nil
+}
+
+// Sessions and two-factor enrollments
+
+func (s *SQLiteStore) SaveSession(ctx context.Context, tokenHash, email string, userID int64, method string, createdAt, expiresAt time.Time) error {
+	var user *int64
+	if userID > 0 {
+		user = &userID
+	}
+	query := `INSERT INTO sessions (token_hash, email, user_id, method, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.q.ExecContext(ctx, query, tokenHash, email, user, method, nanos(createdAt), nanos(expiresAt))
+	return err
+}
+
+func (s *SQLiteStore) GetSession(ctx context.Context, tokenHash string) (map[string]interface{}, error) {
+	var email, method string
+	var userID, createdAt, expiresAt int64
+	err := s.q.QueryRowContext(ctx, `SELECT email, COALESCE(user_id, 0), method, created_at, expires_at FROM sessions WHERE token_hash = ?`, tokenHash).
+		Scan(&email, &userID, &method, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"email":      email,
+		"user_id":    userID,
+		"method":     method,
+		"created_at": fromNanos(createdAt),
+		"expires_at": fromNanos(expiresAt),
+	}, nil
+}
+
+// DeleteSession removes a session, and every session that has expired
+func (s *SQLiteStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := s.q.ExecContext(ctx, `DELETE FROM sessions WHERE token_hash = ? OR expires_at < ?`, tokenHash, nanos(time.Now()))
+	return err
+}
+
+func (s *SQLiteStore) SaveTwoFactor(ctx context.Context, walletID, secretEncrypted string, enabled bool, threshold, lastStep uint64, createdAt time.Time, confirmedAt *time.Time) error {
+	query := `
+		INSERT INTO wallet_two_factor (wallet_id, secret_encrypted, enabled, threshold, last_step, created_at, confirmed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET secret_encrypted = excluded.secret_encrypted,
+		    enabled = excluded.enabled,
+		    threshold = excluded.threshold,
+		    last_step = excluded.last_step,
+		    created_at = excluded.created_at,
+		    confirmed_at = excluded.confirmed_at
+	`
+	_, err := s.q.ExecContext(ctx, query, walletID, secretEncrypted, enabled, int64(threshold), int64(lastStep), nanos(createdAt), nullableNanos(confirmedAt))
+	return err
+}
+
+func (s *SQLiteStore) DeleteTwoFactor(ctx context.Context, walletID string) error {
+	_, err := s.q.ExecContext(ctx, `DELETE FROM wallet_two_factor WHERE wallet_id = ?`, walletID)
+	return err
+}
+
+func (s *SQLiteStore) GetTwoFactorEnrollments(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT wallet_id, secret_encrypted, enabled, threshold, last_step, created_at, confirmed_at FROM wallet_two_factor`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enrollments []map[string]interface{}
+	for rows.Next() {
+		var walletID, secret string
+		var enabled bool
+		var threshold, lastStep, createdAt int64
+		var confirmedAt sql.NullInt64
+		if err := rows.Scan(&walletID, &secret, &enabled, &threshold, &lastStep, &createdAt, &confirmedAt); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, map[string]interface{}{
+			"wallet_id":        walletID,
+			"secret_encrypted": secret,
+			"enabled":          enabled,
+			"threshold":        uint64(threshold),
+			"last_step":        uint64(lastStep),
+			"created_at":       fromNanos(createdAt),
+			"confirmed_at":     fromNullableNanos(confirmedAt),
+		})
+	}
+	return enrollments, rows.Err()
+}
+
+// Webhooks and deliveries
+
+func (s *SQLiteStore) SaveWebhook(ctx context.Context, id int64, walletID, url string, events []string, secretEncrypted string, active bool, createdAt time.Time) error {
+	query := `
+		INSERT INTO webhooks (id, wallet_id, url, events, secret_encrypted, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET url = excluded.url,
+		    events = excluded.events,
+		    secret_encrypted = excluded.secret_encrypted,
+		    active = excluded.active,
+		    updated_at = excluded.updated_at
+	`
+	_, err := s.q.ExecContext(ctx, query, id, walletID, url, strings.Join(events, ","), secretEncrypted, active, nanos(createdAt), nanos(time.Now()))
+	return err
+}
+
+func (s *SQLiteStore) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := s.q.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteStore) GetWebhooks(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT id, wallet_id, url, events, secret_encrypted, active, created_at FROM webhooks ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []map[string]interface{}
+	for rows.Next() {
+		var id, createdAt int64
+		var walletID, url, events, secret string
+		var active bool
+		if err := rows.Scan(&id, &walletID, &url, &events, &secret, &active, &createdAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, map[string]interface{}{
+			"id":               id,
+			"wallet_id":        walletID,
+			"url":              url,
+			"events":           strings.Split(events, ","),
+			"secret_encrypted": secret,
+			"active":           active,
+			"created_at":       fromNanos(createdAt),
+		})
+	}
+	return webhooks, rows.Err()
+}
+
+// GetMaxWebhookID returns the highest webhook ID ever stored, including
+// those of deleted webhooks that still have deliveries
+func (s *SQLiteStore) GetMaxWebhookID(ctx context.Context) (int64, error) {
+	var maxID int64
+	err := s.q.QueryRowContext(ctx, `SELECT MAX((SELECT COALESCE(MAX(id), 0) FROM webhooks), (SELECT COALESCE(MAX(webhook_id), 0) FROM deliveries))`).Scan(&maxID)
+	return maxID, err
+}
+
+func (s *SQLiteStore) SaveDelivery(ctx context.Context, id int64, channel, target string, webhookID int64, eventType, payload, dedupKey, status string, attempts int, lastError string, createdAt time.Time, deliveredAt *time.Time) error {
+	query := `
+		INSERT INTO deliveries (id, channel, target, webhook_id, event_type, payload, dedup_key, status, attempts, last_error, created_at, updated_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET status = excluded.status,
+		    attempts = excluded.attempts,
+		    last_error = excluded.last_error,
+		    updated_at = excluded.updated_at,
+		    delivered_at = excluded.delivered_at
+	`
+	_, err := s.q.ExecContext(ctx, query, id, channel, target, webhookID, eventType, payload, dedupKey, status, attempts, lastError, nanos(createdAt), nanos(time.Now()), nullableNanos(deliveredAt))
+	return err
+}
+
+func (s *SQLiteStore) SaveDeliveryAttempt(ctx context.Context, deliveryID int64, attempt int, at time.Time, durationMs int64, errMsg string) error {
+	query := `
+		INSERT INTO delivery_attempts (delivery_id, attempt, attempted_at, duration_ms, error)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (delivery_id, attempt) DO NOTHING
+	`
+	_, err := s.q.ExecContext(ctx, query, deliveryID, attempt, nanos(at), durationMs, errMsg)
+	return err
+}
+
+func (s *SQLiteStore) GetUndeliveredDeliveries(ctx context.Context) ([]map[string]interface{}, error) {
+	query := `SELECT id, channel, target, webhook_id, event_type, payload, dedup_key, status, attempts, last_error, created_at, updated_at, delivered_at
+		FROM deliveries WHERE status <> 'delivered' ORDER BY id ASC`
+	rows, err := s.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []map[string]interface{}
+	for rows.Next() {
+		var id, webhookID, createdAt, updatedAt int64
+		var channel, target, eventType, payload, dedupKey, status, lastError string
+		var attempts int
+		var deliveredAt sql.NullInt64
+		if err := rows.Scan(&id, &channel, &target, &webhookID, &eventType, &payload, &dedupKey, &status, &attempts, &lastError, &createdAt, &updatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, map[string]interface{}{
+			"id":           id,
+			"channel":      channel,
+			"target":       target,
+			"webhook_id":   webhookID,
+			"event_type":   eventType,
+			"payload":      payload,
+			"dedup_key":    dedupKey,
+			"status":       status,
+			"attempts":     attempts,
+			"last_error":   lastError,
+			"created_at":   fromNanos(createdAt),
+			"updated_at":   fromNanos(updatedAt),
+			"delivered_at": fromNullableNanos(deliveredAt),
+		})
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *SQLiteStore) GetMaxDeliveryID(ctx context.Context) (int64, error) {
+	var maxID int64
+	err := s.q.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM deliveries`).Scan(&maxID)
+	return maxID, err
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -11,11 +10,24 @@ import (
 )
 
 type DB struct {
-	Pool *pgxpool.Pool
+	Pool       *pgxpool.Pool
+	tx         pgx.Tx // set on the DB WithTx hands to its callback; see conn
+	adminEmail string // users signing up with it are made admins
 }
 
-func NewDB() (*DB, error) {
-	dbURL := os.Getenv("SUPABASE_DB_URL")
+// DefaultAdminEmail is the admin email used when none is configured
+// (development only)
+const DefaultAdminEmail = "admin@blockchain.com"
+
+// NewDB connects to the Postgres database at dbURL
+func NewDB(dbURL string) (*DB, error) {
+	return NewDBInSchema(dbURL, "")
+}
+
+// NewDBInSchema connects to the Postgres database at dbURL with its tables in
+// schema, created if missing, e.g. a sandbox's; see SandboxSchema. The empty
+// schema is the database's default search path.
+func NewDBInSchema(dbURL, schema string) (*DB, error) {
 	if dbURL == "" {
 		return nil, fmt.Errorf("SUPABASE_DB_URL not set")
 	}
@@ -38,6 +50,12 @@ func NewDB() (*DB, error) {
 	// Transaction poolers reuse connections, causing "prepared statement already exists" errors
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 
+	// Unqualified names resolve in the schema first; public stays on the path
+	// for extensions
+	if schema != "" {
+		config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	}
+
 	// Create context with reasonable timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -52,8 +70,19 @@ func NewDB() (*DB, error) {
 		pool.Close()
 		return nil, fmt.Errorf("unable to ping database: %v", err)
 	}
+	if schema != "" {
+		if _, err := pool.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize()); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("unable to create schema %s: %v", schema, err)
+		}
+	}
+
+	return &DB{Pool: pool, adminEmail: DefaultAdminEmail}, nil
+}
 
-	return &DB{Pool: pool}, nil
+// SetAdminEmail sets the email whose user is made an admin on signup
+func (db *DB) SetAdminEmail(email string) {
+	db.adminEmail = email
 }
 
 func (db *DB) Close() {
@@ -66,131 +95,21 @@ func (db *DB) Ping(ctx context.Context) error {
 	return db.Pool.Ping(ctx)
 }
 
-// InitSchema creates all necessary tables
-// Note: For transaction pooler, we can't use multi-statement execution
-func (db *DB) InitSchema(ctx context.Context) error {
-	// Execute each CREATE TABLE statement separately for transaction pooler compatibility
-	statements := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id SERIAL PRIMARY KEY,
-			email VARCHAR(255) UNIQUE NOT NULL,
-			full_name VARCHAR(255) NOT NULL,
-			cnic VARCHAR(50),
-			is_admin BOOLEAN DEFAULT FALSE,
-			created_at TIMESTAMP DEFAULT NOW(),
-			updated_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS wallets (
-			wallet_id VARCHAR(100) PRIMARY KEY,
-			user_id INTEGER REFERENCES users(id),
-			public_key TEXT NOT NULL,
-			private_key_encrypted TEXT NOT NULL,
-			full_name VARCHAR(255),
-			email VARCHAR(255),
-			is_admin BOOLEAN DEFAULT FALSE,
-			balance BIGINT DEFAULT 0,
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS utxos (
-			id VARCHAR(200) PRIMARY KEY,
-			owner VARCHAR(100) NOT NULL,
-			amount BIGINT NOT NULL,
-			origin_tx VARCHAR(200) NOT NULL,
-			idx INTEGER NOT NULL,
-			spent BOOLEAN DEFAULT FALSE,
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS blocks (
-			idx BIGINT PRIMARY KEY,
-			timestamp BIGINT NOT NULL,
-			previous_hash TEXT NOT NULL,
-			hash TEXT NOT NULL,
-			nonce BIGINT NOT NULL,
-			merkle_root TEXT,
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS transactions (
-			id VARCHAR(200) PRIMARY KEY,
-			sender_id VARCHAR(100) NOT NULL,
-			receiver_id VARCHAR(100) NOT NULL,
-			amount BIGINT NOT NULL,
-			note TEXT,
-			timestamp BIGINT NOT NULL,
-			pubkey TEXT NOT NULL,
-			signature TEXT NOT NULL,
-			tx_type VARCHAR(50) DEFAULT 'transfer',
-			block_index BIGINT REFERENCES blocks(idx),
-			status VARCHAR(50) DEFAULT 'pending',
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS beneficiaries (
-			id SERIAL PRIMARY KEY,
-			user_id INTEGER REFERENCES users(id),
-			wallet_id VARCHAR(100) NOT NULL,
-			name VARCHAR(255),
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS zakat_deductions (
-			id SERIAL PRIMARY KEY,
-			wallet_id VARCHAR(100) NOT NULL,
-			amount BIGINT NOT NULL,
-			month INTEGER NOT NULL,
-			year INTEGER NOT NULL,
-			transaction_id VARCHAR(200),
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS system_logs (
-			id SERIAL PRIMARY KEY,
-			event_type VARCHAR(100) NOT NULL,
-			wallet_id VARCHAR(100),
-			ip_address VARCHAR(50),
-			details TEXT,
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS transaction_logs (
-			id SERIAL PRIMARY KEY,
-			transaction_id VARCHAR(200) NOT NULL,
-			action VARCHAR(50) NOT NULL,
-			wallet_id VARCHAR(100) NOT NULL,
-			block_hash TEXT,
-			status VARCHAR(50),
-			ip_address VARCHAR(50),
-			created_at TIMESTAMP DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_utxos_owner ON utxos(owner)`,
-		`CREATE INDEX IF NOT EXISTS idx_utxos_spent ON utxos(spent)`,
-		`CREATE INDEX IF NOT EXISTS idx_transactions_sender ON transactions(sender_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_transactions_receiver ON transactions(receiver_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_system_logs_wallet ON system_logs(wallet_id)`,
-	}
-
-	// Execute each statement separately
-	for _, stmt := range statements {
-		if _, err := db.Pool.Exec(ctx, stmt); err != nil {
-			return fmt.Errorf("failed to execute schema statement: %v", err)
-		}
+// InitSchema brings the schema up to date by applying the pending migrations
+// and returns the ones it applied. Without autoMigrate (DB_AUTO_MIGRATE=false)
+// it only checks that none are pending, leaving them to cmd/migrate.
+func (db *DB) InitSchema(ctx context.Context, autoMigrate bool) ([]Migration, error) {
+	if autoMigrate {
+		return db.Migrate(ctx)
 	}
-
-	// Migrations: Add missing columns if they don't exist
-	migrations := []string{
-		`ALTER TABLE wallets ADD COLUMN IF NOT EXISTS full_name VARCHAR(255)`,
-		`ALTER TABLE wallets ADD COLUMN IF NOT EXISTS email VARCHAR(255)`,
-		`ALTER TABLE wallets ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE`,
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE`,
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_verified BOOLEAN DEFAULT FALSE`,
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS google_id VARCHAR(255)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_google_id ON users(google_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_wallets_is_admin ON wallets(is_admin)`,
+	pending, err := db.PendingMigrations(ctx)
+	if err != nil {
+		return nil, err
 	}
-	
-	for _, migration := range migrations {
-		if _, err := db.Pool.Exec(ctx, migration); err != nil {
-			return fmt.Errorf("failed to execute migration: %v", err)
-		}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%d pending migrations and DB_AUTO_MIGRATE=false; apply them with go run ./cmd/migrate up", len(pending))
 	}
-
-	return nil
+	return nil, nil
 }
 
 // User persistence methods
@@ -210,7 +129,7 @@ func (db *DB) CreateUser(ctx context.Context, email, fullName, cnic string) (int
 		    updated_at = NOW()
 		RETURNING id
 	`
-	err := db.Pool.QueryRow(ctx, query, email, fullName, cnic).Scan(&userID)
+	err := db.conn().QueryRow(ctx, query, email, fullName, cnic).Scan(&userID)
 	return userID, err
 }
 
@@ -225,7 +144,7 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (map[string]inte
 	var emailVal, fullName, cnic string
 	var createdAt, updatedAt time.Time
 	
-	err := db.Pool.QueryRow(ctx, query, email).Scan(&id, &emailVal, &fullName, &cnic, &createdAt, &updatedAt)
+	err := db.conn().QueryRow(ctx, query, email).Scan(&id, &emailVal, &fullName, &cnic, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +170,7 @@ func (db *DB) UpdateUserProfile(ctx context.Context, walletID, fullName, email,
 		SET full_name = $1, email = $2, cnic = $3, updated_at = NOW()
 		WHERE id = (SELECT user_id FROM wallets WHERE wallet_id = $4)
 	`
-	_, err := db.Pool.Exec(ctx, query, fullName, email, cnic, walletID)
+	_, err := db.conn().Exec(ctx, query, fullName, email, cnic, walletID)
 	if err != nil {
 		return err
 	}
@@ -262,7 +181,7 @@ func (db *DB) UpdateUserProfile(ctx context.Context, walletID, fullName, email,
 		SET full_name = $1, email = $2
 		WHERE wallet_id = $3
 	`
-	_, err = db.Pool.Exec(ctx, walletQuery, fullName, email, walletID)
+	_, err = db.conn().Exec(ctx, walletQuery, fullName, email, walletID)
 	return err
 }
 
@@ -279,7 +198,7 @@ func (db *DB) CheckEmailExists(ctx context.Context, email string) (bool, error)
 	// Check in wallets table
 	var count int
 	query := `SELECT COUNT(*) FROM wallets WHERE LOWER(email) = LOWER($1)`
-	err := db.Pool.QueryRow(ctx, query, email).Scan(&count)
+	err := db.conn().QueryRow(ctx, query, email).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -287,6 +206,153 @@ func (db *DB) CheckEmailExists(ctx context.Context, email string) (bool, error)
 	return count > 0, nil
 }
 
+// GetWalletsByEmail returns the public details of the wallets registered
+// with an email, oldest first; private keys are left out
+func (db *DB) GetWalletsByEmail(ctx context.Context, email string) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return nil, fmt.Errorf("no database connection")
+	}
+	
+	query := `SELECT wallet_id, public_key, full_name, email, COALESCE(wallet_type, 'personal'), COALESCE(org_id, ''), COALESCE(label, ''), COALESCE(status, 'active'), created_at
+		FROM wallets WHERE LOWER(email) = LOWER($1) ORDER BY created_at ASC`
+	
+	rows, err := db.conn().Query(ctx, query, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	wallets := []map[string]interface{}{}
+	for rows.Next() {
+		var wid, pubKey, fullName, emailVal, walletType, orgID, label, status string
+		var createdAt time.Time
+		if err := rows.Scan(&wid, &pubKey, &fullName, &emailVal, &walletType, &orgID, &label, &status, &createdAt); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, map[string]interface{}{
+			"wallet_id":   wid,
+			"public_key":  pubKey,
+			"full_name":   fullName,
+			"email":       emailVal,
+			"wallet_type": walletType,
+			"org_id":      orgID,
+			"label":       label,
+			"status":      status,
+			"created_at":  createdAt,
+		})
+	}
+	return wallets, rows.Err()
+}
+
+// GetUserByGoogleID returns the user linked to a Google account
+func (db *DB) GetUserByGoogleID(ctx context.Context, googleID string) (map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return nil, fmt.Errorf("no database connection")
+	}
+	
+	query := `SELECT id, email, full_name, COALESCE(is_verified, FALSE) FROM users WHERE google_id = $1`
+	
+	var id int64
+	var email, fullName string
+	var verified bool
+	if err := db.conn().QueryRow(ctx, query, googleID).Scan(&id, &email, &fullName, &verified); err != nil {
+		return nil, err
+	}
+	
+	return map[string]interface{}{
+		"id":          id,
+		"email":       email,
+		"full_name":   fullName,
+		"is_verified": verified,
+		"google_id":   googleID,
+	}, nil
+}
+
+// LinkGoogleUser creates a verified user for a Google account, or links the
+// account to the existing user with the same email and marks it verified.
+// It returns pgx.ErrNoRows when that email is already linked to a different
+// Google account.
+func (db *DB) LinkGoogleUser(ctx context.Context, email, fullName, googleID string) (int64, bool, error) {
+	if db == nil || db.Pool == nil {
+		return 0, false, fmt.Errorf("no database connection")
+	}
+	
+	query := `
+		INSERT INTO users (email, full_name, google_id, is_verified)
+		VALUES ($1, $2, $3, TRUE)
+		ON CONFLICT (email) DO UPDATE
+		SET google_id = EXCLUDED.google_id,
+		    is_verified = TRUE,
+		    updated_at = NOW()
+		WHERE users.google_id IS NULL OR users.google_id = EXCLUDED.google_id
+		RETURNING id, (xmax = 0) AS created
+	`
+	var id int64
+	var created bool
+	err := db.conn().QueryRow(ctx, query, email, fullName, googleID).Scan(&id, &created)
+	return id, created, err
+}
+
+// MarkUserVerified flags the user with this email as verified (OTP login)
+func (db *DB) MarkUserVerified(ctx context.Context, email string) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+	
+	var id int64
+	query := `UPDATE users SET is_verified = TRUE, updated_at = NOW() WHERE LOWER(email) = LOWER($1) RETURNING id`
+	err := db.conn().QueryRow(ctx, query, email).Scan(&id)
+	return id, err
+}
+
+// Session persistence methods
+
+func (db *DB) SaveSession(ctx context.Context, tokenHash, email string, userID int64, method string, createdAt, expiresAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	var user *int64
+	if userID > 0 {
+		user = &userID
+	}
+	query := `INSERT INTO sessions (token_hash, email, user_id, method, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.conn().Exec(ctx, query, tokenHash, email, user, method, createdAt, expiresAt)
+	return err
+}
+
+func (db *DB) GetSession(ctx context.Context, tokenHash string) (map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return nil, fmt.Errorf("no database connection")
+	}
+	
+	query := `SELECT email, COALESCE(user_id, 0), method, created_at, expires_at FROM sessions WHERE token_hash = $1`
+	
+	var email, method string
+	var userID int64
+	var createdAt, expiresAt time.Time
+	if err := db.conn().QueryRow(ctx, query, tokenHash).Scan(&email, &userID, &method, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+	
+	return map[string]interface{}{
+		"email":      email,
+		"user_id":    userID,
+		"method":     method,
+		"created_at": createdAt,
+		"expires_at": expiresAt,
+	}, nil
+}
+
+func (db *DB) DeleteSession(ctx context.Context, tokenHash string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `DELETE FROM sessions WHERE token_hash = $1 OR expires_at < NOW()`, tokenHash)
+	return err
+}
+
 // Admin role methods
 
 func (db *DB) IsAdmin(ctx context.Context, walletID string) (bool, error) {
@@ -296,7 +362,7 @@ func (db *DB) IsAdmin(ctx context.Context, walletID string) (bool, error) {
 	
 	var isAdmin bool
 	query := `SELECT COALESCE(is_admin, FALSE) FROM wallets WHERE wallet_id = $1`
-	err := db.Pool.QueryRow(ctx, query, walletID).Scan(&isAdmin)
+	err := db.conn().QueryRow(ctx, query, walletID).Scan(&isAdmin)
 	if err != nil {
 		return false, err
 	}
@@ -310,30 +376,26 @@ func (db *DB) SetAdmin(ctx context.Context, email string, isAdmin bool) error {
 	
 	// Update user table
 	userQuery := `UPDATE users SET is_admin = $1 WHERE email = $2`
-	_, err := db.Pool.Exec(ctx, userQuery, isAdmin, email)
+	_, err := db.conn().Exec(ctx, userQuery, isAdmin, email)
 	if err != nil {
 		return err
 	}
 	
 	// Update wallet table
 	walletQuery := `UPDATE wallets SET is_admin = $1 WHERE email = $2`
-	_, err = db.Pool.Exec(ctx, walletQuery, isAdmin, email)
+	_, err = db.conn().Exec(ctx, walletQuery, isAdmin, email)
 	return err
 }
 
 // Wallet persistence methods
 
-func (db *DB) SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic string) error {
+func (db *DB) SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEncrypted, fullName, email, cnic, walletType string) error {
 	if db == nil || db.Pool == nil {
 		return nil // Skip if no database connection
 	}
 	
 	// Check if this is the designated admin email
-	adminEmail := os.Getenv("ADMIN_EMAIL")
-	if adminEmail == "" {
-		adminEmail = "admin@blockchain.com" // Default admin email
-	}
-	isAdmin := (email == adminEmail)
+	isAdmin := (email == db.adminEmail)
 	
 	// First, create or update user
 	var userID *int64
@@ -351,17 +413,18 @@ func (db *DB) SaveWallet(ctx context.Context, walletID, publicKey, privateKeyEnc
 	}
 	
 	query := `
-		INSERT INTO wallets (wallet_id, user_id, public_key, private_key_encrypted, full_name, email, is_admin, balance)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, 0)
+		INSERT INTO wallets (wallet_id, user_id, public_key, private_key_encrypted, full_name, email, is_admin, balance, wallet_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8)
 		ON CONFLICT (wallet_id) DO UPDATE
 		SET user_id = EXCLUDED.user_id,
 		    public_key = EXCLUDED.public_key,
 		    private_key_encrypted = EXCLUDED.private_key_encrypted,
 		    full_name = EXCLUDED.full_name,
 		    email = EXCLUDED.email,
-		    is_admin = EXCLUDED.is_admin
+		    is_admin = EXCLUDED.is_admin,
+		    wallet_type = EXCLUDED.wallet_type
 	`
-	_, err := db.Pool.Exec(ctx, query, walletID, userID, publicKey, privateKeyEncrypted, fullName, email, isAdmin)
+	_, err := db.conn().Exec(ctx, query, walletID, userID, publicKey, privateKeyEncrypted, fullName, email, isAdmin, walletType)
 	return err
 }
 
@@ -372,14 +435,14 @@ func (db *DB) GetWallet(ctx context.Context, walletID string) (map[string]interf
 		return nil, fmt.Errorf("no database connection")
 	}
 	
-	query := `SELECT wallet_id, public_key, private_key_encrypted, full_name, email, COALESCE(is_admin, FALSE), balance, created_at FROM wallets WHERE wallet_id = $1`
+	query := `SELECT wallet_id, public_key, private_key_encrypted, full_name, email, COALESCE(is_admin, FALSE), balance, created_at, COALESCE(wallet_type, 'personal') FROM wallets WHERE wallet_id = $1`
 	
-	var wid, pubKey, privKey, fullName, email string
+	var wid, pubKey, privKey, fullName, email, walletType string
 	var isAdmin bool
 	var balance int64
 	var createdAt time.Time
 	
-	err := db.Pool.QueryRow(ctx, query, walletID).Scan(&wid, &pubKey, &privKey, &fullName, &email, &isAdmin, &balance, &createdAt)
+	err := db.conn().QueryRow(ctx, query, walletID).Scan(&wid, &pubKey, &privKey, &fullName, &email, &isAdmin, &balance, &createdAt, &walletType)
 	if err != nil {
 		return nil, err
 	}
@@ -393,6 +456,7 @@ func (db *DB) GetWallet(ctx context.Context, walletID string) (map[string]interf
 		"is_admin":              isAdmin,
 		"balance":               balance,
 		"created_at":            createdAt,
+		"wallet_type":           walletType,
 	}, nil
 }
 
@@ -401,9 +465,9 @@ func (db *DB) GetAllWallets(ctx context.Context) ([]map[string]interface{}, erro
 		return []map[string]interface{}{}, nil
 	}
 	
-	query := `SELECT wallet_id, public_key, private_key_encrypted, full_name, email, COALESCE(is_admin, FALSE), balance, created_at FROM wallets ORDER BY created_at DESC`
+	query := `SELECT wallet_id, public_key, private_key_encrypted, full_name, email, COALESCE(is_admin, FALSE), balance, created_at, COALESCE(wallet_type, 'personal'), COALESCE(org_id, ''), COALESCE(monthly_statements, FALSE), COALESCE(frozen, FALSE), COALESCE(frozen_reason, ''), COALESCE(max_tx_amount, 0), COALESCE(max_daily_amount, 0), COALESCE(label, ''), COALESCE(status, 'active'), COALESCE(rotated_from, ''), COALESCE(rotated_to, '') FROM wallets ORDER BY created_at DESC`
 	
-	rows, err := db.Pool.Query(ctx, query)
+	rows, err := db.conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -411,12 +475,13 @@ func (db *DB) GetAllWallets(ctx context.Context) ([]map[string]interface{}, erro
 	
 	var wallets []map[string]interface{}
 	for rows.Next() {
-		var wid, pubKey, privKey, fullName, email string
-		var isAdmin bool
-		var balance int64
+		var wid, pubKey, privKey, fullName, email, walletType, orgID, frozenReason, label string
+		var status, rotatedFrom, rotatedTo string
+		var isAdmin, monthlyStatements, frozen bool
+		var balance, maxTx, maxDaily int64
 		var createdAt time.Time
 		
-		if err := rows.Scan(&wid, &pubKey, &privKey, &fullName, &email, &isAdmin, &balance, &createdAt); err != nil {
+		if err := rows.Scan(&wid, &pubKey, &privKey, &fullName, &email, &isAdmin, &balance, &createdAt, &walletType, &orgID, &monthlyStatements, &frozen, &frozenReason, &maxTx, &maxDaily, &label, &status, &rotatedFrom, &rotatedTo); err != nil {
 			continue
 		}
 		
@@ -429,25 +494,126 @@ func (db *DB) GetAllWallets(ctx context.Context) ([]map[string]interface{}, erro
 			"is_admin":              isAdmin,
 			"balance":               balance,
 			"created_at":            createdAt,
+			"wallet_type":           walletType,
+			"org_id":                orgID,
+			"monthly_statements":    monthlyStatements,
+			"frozen":                frozen,
+			"frozen_reason":         frozenReason,
+			"max_tx_amount":         uint64(maxTx),
+			"max_daily_amount":      uint64(maxDaily),
+			"label":                 label,
+			"status":                status,
+			"rotated_from":          rotatedFrom,
+			"rotated_to":            rotatedTo,
 		})
 	}
 	
 	return wallets, nil
 }
 
+// UpdateWalletType records an approved wallet type change
+func (db *DB) UpdateWalletType(ctx context.Context, walletID, walletType string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET wallet_type = $1 WHERE wallet_id = $2`, walletType, walletID)
+	return err
+}
+
+// UpdateWalletOrg moves a wallet, and the user owning it, into an organization
+func (db *DB) UpdateWalletOrg(ctx context.Context, walletID, orgID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	var org interface{}
+	if orgID != "" {
+		org = orgID
+	}
+	if _, err := db.conn().Exec(ctx, `UPDATE wallets SET org_id = $1 WHERE wallet_id = $2`, org, walletID); err != nil {
+		return err
+	}
+	_, err := db.conn().Exec(ctx, `UPDATE users SET org_id = $1 WHERE id = (SELECT user_id FROM wallets WHERE wallet_id = $2)`, org, walletID)
+	return err
+}
+
+// SaveWalletTypeRequest inserts or updates a wallet type change request
+func (db *DB) SaveWalletTypeRequest(ctx context.Context, id int64, walletID, fromType, toType, reason, status, requestedBy, decidedBy string, createdAt time.Time, decidedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO wallet_type_requests (id, wallet_id, from_type, to_type, reason, status, requested_by, decided_by, created_at, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    decided_by = EXCLUDED.decided_by,
+		    decided_at = EXCLUDED.decided_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, fromType, toType, reason, status, requestedBy, decidedBy, createdAt, decidedAt)
+	return err
+}
+
+// GetWalletTypeRequests returns every wallet type change request in ID order
+func (db *DB) GetWalletTypeRequests(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	query := `SELECT id, wallet_id, from_type, to_type, COALESCE(reason, ''), status, COALESCE(requested_by, ''), COALESCE(decided_by, ''), created_at, decided_at
+		FROM wallet_type_requests ORDER BY id ASC`
+	
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var requests []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, fromType, toType, reason, status, requestedBy, decidedBy string
+		var createdAt time.Time
+		var decidedAt *time.Time
+		
+		if err := rows.Scan(&id, &walletID, &fromType, &toType, &reason, &status, &requestedBy, &decidedBy, &createdAt, &decidedAt); err != nil {
+			continue
+		}
+		
+		requests = append(requests, map[string]interface{}{
+			"id":           id,
+			"wallet_id":    walletID,
+			"from_type":    fromType,
+			"to_type":      toType,
+			"reason":       reason,
+			"status":       status,
+			"requested_by": requestedBy,
+			"decided_by":   decidedBy,
+			"created_at":   createdAt,
+			"decided_at":   decidedAt,
+		})
+	}
+	
+	return requests, nil
+}
+
 // Block persistence methods
 
-func (db *DB) SaveBlock(ctx context.Context, idx, timestamp int64, previousHash, hash string, nonce int64, merkleRoot string) error {
+// SaveBlock stores a block header and, as body, the whole block as JSON so
+// the chain can be restored at startup
+func (db *DB) SaveBlock(ctx context.Context, version int, idx, timestamp int64, previousHash, hash string, nonce int64, merkleRoot string, body []byte) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
 	query := `
-		INSERT INTO blocks (idx, timestamp, previous_hash, hash, nonce, merkle_root)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO blocks (version, idx, timestamp, previous_hash, hash, nonce, merkle_root, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (idx) DO NOTHING
 	`
-	_, err := db.Pool.Exec(ctx, query, idx, timestamp, previousHash, hash, nonce, merkleRoot)
+	_, err := db.conn().Exec(ctx, query, version, idx, timestamp, previousHash, hash, nonce, merkleRoot, body)
 	return err
 }
 
@@ -456,9 +622,9 @@ func (db *DB) GetAllBlocks(ctx context.Context) ([]map[string]interface{}, error
 		return []map[string]interface{}{}, nil
 	}
 	
-	query := `SELECT idx, timestamp, previous_hash, hash, nonce, merkle_root, created_at FROM blocks ORDER BY idx ASC`
+	query := `SELECT version, idx, timestamp, previous_hash, hash, nonce, merkle_root, created_at FROM blocks ORDER BY idx ASC`
 	
-	rows, err := db.Pool.Query(ctx, query)
+	rows, err := db.conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -466,15 +632,17 @@ func (db *DB) GetAllBlocks(ctx context.Context) ([]map[string]interface{}, error
 	
 	var blocks []map[string]interface{}
 	for rows.Next() {
+		var version int
 		var idx, timestamp, nonce int64
 		var previousHash, hash, merkleRoot string
 		var createdAt time.Time
 		
-		if err := rows.Scan(&idx, &timestamp, &previousHash, &hash, &nonce, &merkleRoot, &createdAt); err != nil {
+		if err := rows.Scan(&version, &idx, &timestamp, &previousHash, &hash, &nonce, &merkleRoot, &createdAt); err != nil {
 			continue
 		}
 		
 		blocks = append(blocks, map[string]interface{}{
+			"version":       version,
 			"idx":           idx,
 			"timestamp":     timestamp,
 			"previous_hash": previousHash,
@@ -490,22 +658,46 @@ func (db *DB) GetAllBlocks(ctx context.Context) ([]map[string]interface{}, error
 
 // Transaction persistence methods
 
-func (db *DB) SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error {
+func (db *DB) SaveTransaction(ctx context.Context, id, senderID, receiverID string, amount, fee, nonce uint64, note string, timestamp int64, pubkey, signature string, txType string, blockIndex *int64, status string) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
 	query := `
-		INSERT INTO transactions (id, sender_id, receiver_id, amount, note, timestamp, pubkey, signature, tx_type, block_index, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO transactions (id, sender_id, receiver_id, amount, fee, nonce, note, timestamp, pubkey, signature, tx_type, block_index, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		ON CONFLICT (id) DO UPDATE
 		SET block_index = EXCLUDED.block_index,
 		    status = EXCLUDED.status
 	`
-	_, err := db.Pool.Exec(ctx, query, id, senderID, receiverID, amount, note, timestamp, pubkey, signature, txType, blockIndex, status)
+	_, err := db.conn().Exec(ctx, query, id, senderID, receiverID, amount, fee, nonce, note, timestamp, pubkey, signature, txType, blockIndex, status)
 	return err
 }
 
+// GetWalletNonces returns the highest nonce each wallet has used
+func (db *DB) GetWalletNonces(ctx context.Context) (map[string]uint64, error) {
+	nonces := map[string]uint64{}
+	if db == nil || db.Pool == nil {
+		return nonces, nil
+	}
+	
+	rows, err := db.conn().Query(ctx, `SELECT sender_id, MAX(nonce) FROM transactions WHERE nonce > 0 GROUP BY sender_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var walletID string
+		var nonce uint64
+		if err := rows.Scan(&walletID, &nonce); err != nil {
+			return nil, err
+		}
+		nonces[walletID] = nonce
+	}
+	return nonces, rows.Err()
+}
+
 func (db *DB) GetAllTransactions(ctx context.Context) ([]map[string]interface{}, error) {
 	if db == nil || db.Pool == nil {
 		return []map[string]interface{}{}, nil
@@ -513,7 +705,7 @@ func (db *DB) GetAllTransactions(ctx context.Context) ([]map[string]interface{},
 	
 	query := `SELECT id, sender_id, receiver_id, amount, note, timestamp, pubkey, signature, tx_type, block_index, status, created_at FROM transactions ORDER BY timestamp DESC`
 	
-	rows, err := db.Pool.Query(ctx, query)
+	rows, err := db.conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -550,20 +742,44 @@ func (db *DB) GetAllTransactions(ctx context.Context) ([]map[string]interface{},
 	return txs, nil
 }
 
+// GetTransactionStatus returns the persisted status and block of a
+// transaction, or pgx.ErrNoRows when it was never saved
+func (db *DB) GetTransactionStatus(ctx context.Context, id string) (map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return nil, fmt.Errorf("no database connection")
+	}
+	
+	query := `SELECT sender_id, receiver_id, status, block_index FROM transactions WHERE id = $1`
+	
+	var senderID, receiverID, status string
+	var blockIndex *int64
+	if err := db.conn().QueryRow(ctx, query, id).Scan(&senderID, &receiverID, &status, &blockIndex); err != nil {
+		return nil, err
+	}
+	
+	return map[string]interface{}{
+		"id":          id,
+		"sender_id":   senderID,
+		"receiver_id": receiverID,
+		"status":      status,
+		"block_index": blockIndex,
+	}, nil
+}
+
 // UTXO persistence methods
 
-func (db *DB) SaveUTXO(ctx context.Context, id, owner string, amount uint64, originTx string, idx int, spent bool) error {
+func (db *DB) SaveUTXO(ctx context.Context, id, owner, assetID string, amount uint64, originTx string, idx int, spent bool) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
 	query := `
-		INSERT INTO utxos (id, owner, amount, origin_tx, idx, spent)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO utxos (id, owner, amount, origin_tx, idx, spent, asset_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (id) DO UPDATE
 		SET spent = EXCLUDED.spent
 	`
-	_, err := db.Pool.Exec(ctx, query, id, owner, amount, originTx, idx, spent)
+	_, err := db.conn().Exec(ctx, query, id, owner, amount, originTx, idx, spent, assetID)
 	return err
 }
 
@@ -573,9 +789,9 @@ func (db *DB) GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error)
 	}
 	
 	// Use simple query mode for transaction pooler compatibility
-	query := `SELECT id, owner, amount::bigint, origin_tx, idx, spent, created_at FROM utxos ORDER BY created_at DESC`
+	query := `SELECT id, owner, amount::bigint, origin_tx, idx, spent, created_at, asset_id FROM utxos ORDER BY created_at DESC`
 	
-	rows, err := db.Pool.Query(ctx, query)
+	rows, err := db.conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -583,19 +799,20 @@ func (db *DB) GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error)
 	
 	var utxos []map[string]interface{}
 	for rows.Next() {
-		var id, owner, originTx string
+		var id, owner, originTx, assetID string
 		var amount uint64
 		var idx int
 		var spent bool
 		var createdAt time.Time
 		
-		if err := rows.Scan(&id, &owner, &amount, &originTx, &idx, &spent, &createdAt); err != nil {
+		if err := rows.Scan(&id, &owner, &amount, &originTx, &idx, &spent, &createdAt, &assetID); err != nil {
 			continue
 		}
 		
 		utxos = append(utxos, map[string]interface{}{
 			"id":         id,
 			"owner":      owner,
+			"asset_id":   assetID,
 			"amount":     amount,
 			"origin_tx":  originTx,
 			"index":      idx,
@@ -609,23 +826,24 @@ func (db *DB) GetAllUTXOs(ctx context.Context) ([]map[string]interface{}, error)
 
 // Logging persistence methods
 
-func (db *DB) SaveSystemLog(ctx context.Context, eventType, walletID, ipAddress, details string) error {
+func (db *DB) SaveSystemLog(ctx context.Context, eventType, walletID, ipAddress, details, requestID string, origin LogOrigin) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
-	query := `INSERT INTO system_logs (event_type, wallet_id, ip_address, details) VALUES ($1, $2, $3, $4)`
-	_, err := db.Pool.Exec(ctx, query, eventType, walletID, ipAddress, details)
+	query := `INSERT INTO system_logs (event_type, wallet_id, ip_address, details, request_id, country, city, device)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''))`
+	_, err := db.conn().Exec(ctx, query, eventType, walletID, ipAddress, details, requestID, origin.Country, origin.City, origin.Device)
 	return err
 }
 
-func (db *DB) SaveTransactionLog(ctx context.Context, transactionID, action, walletID, blockHash, status, ipAddress string) error {
+func (db *DB) SaveTransactionLog(ctx context.Context, transactionID, action, walletID, blockHash, status, ipAddress, requestID string) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
-	query := `INSERT INTO transaction_logs (transaction_id, action, wallet_id, block_hash, status, ip_address) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := db.Pool.Exec(ctx, query, transactionID, action, walletID, blockHash, status, ipAddress)
+	query := `INSERT INTO transaction_logs (transaction_id, action, wallet_id, block_hash, status, ip_address, request_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.conn().Exec(ctx, query, transactionID, action, walletID, blockHash, status, ipAddress, requestID)
 	return err
 }
 
@@ -636,7 +854,7 @@ func (db *DB) GetSystemLogs(ctx context.Context, limit int) ([]map[string]interf
 	
 	query := `SELECT id, event_type, wallet_id, ip_address, details, created_at FROM system_logs ORDER BY created_at DESC LIMIT $1`
 	
-	rows, err := db.Pool.Query(ctx, query, limit)
+	rows, err := db.conn().Query(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -676,10 +894,10 @@ func (db *DB) GetTransactionLogs(ctx context.Context, walletID string, limit int
 	
 	if walletID == "" {
 		query = `SELECT id, transaction_id, action, wallet_id, block_hash, status, ip_address, created_at FROM transaction_logs ORDER BY created_at DESC LIMIT $1`
-		rows, err = db.Pool.Query(ctx, query, limit)
+		rows, err = db.conn().Query(ctx, query, limit)
 	} else {
 		query = `SELECT id, transaction_id, action, wallet_id, block_hash, status, ip_address, created_at FROM transaction_logs WHERE wallet_id = $1 ORDER BY created_at DESC LIMIT $2`
-		rows, err = db.Pool.Query(ctx, query, walletID, limit)
+		rows, err = db.conn().Query(ctx, query, walletID, limit)
 	}
 	
 	if err != nil {
@@ -717,138 +935,735 @@ func (db *DB) GetTransactionLogs(ctx context.Context, walletID string, limit int
 	return logs, nil
 }
 
-// Beneficiary persistence methods
+// Wallet event persistence methods
 
-// GetUserIDByWalletID retrieves the numeric user_id from wallets table using wallet_id
-func (db *DB) GetUserIDByWalletID(ctx context.Context, walletID string) (int64, error) {
+func (db *DB) SaveWalletEvent(ctx context.Context, seq uint64, walletID, eventType string, schemaVersion int, payload string, createdAt time.Time) error {
 	if db == nil || db.Pool == nil {
-		return 0, fmt.Errorf("database not connected")
-	}
-	
-	query := `SELECT user_id FROM wallets WHERE wallet_id = $1`
-	var userID int64
-	err := db.Pool.QueryRow(ctx, query, walletID).Scan(&userID)
-	if err != nil {
-		return 0, fmt.Errorf("wallet not found or user_id not set: %v", err)
+		return nil
 	}
 	
-	return userID, nil
+	query := `INSERT INTO wallet_events (seq, wallet_id, event_type, schema_version, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (seq) DO NOTHING`
+	_, err := db.conn().Exec(ctx, query, int64(seq), walletID, eventType, schemaVersion, payload, createdAt)
+	return err
 }
 
-func (db *DB) AddBeneficiary(ctx context.Context, userID int64, walletID, name, relationship string) error {
+// GetRecentWalletEvents returns the latest events across all wallets in ascending seq order
+func (db *DB) GetRecentWalletEvents(ctx context.Context, limit int) ([]map[string]interface{}, error) {
 	if db == nil || db.Pool == nil {
-		return nil
+		return []map[string]interface{}{}, nil
 	}
 	
-	query := `INSERT INTO beneficiaries (user_id, wallet_id, name, relationship) VALUES ($1, $2, $3, $4)`
-	_, err := db.Pool.Exec(ctx, query, userID, walletID, name, relationship)
-	return err
+	query := `SELECT seq, wallet_id, event_type, COALESCE(schema_version, 1), COALESCE(payload, ''), created_at FROM (
+		SELECT * FROM wallet_events ORDER BY seq DESC LIMIT $1
+	) recent ORDER BY seq ASC`
+	return db.queryWalletEvents(ctx, query, limit)
 }
 
-func (db *DB) GetBeneficiaries(ctx context.Context, userID int64) ([]map[string]interface{}, error) {
+// GetWalletEventsSince returns events for one wallet with seq greater than since
+func (db *DB) GetWalletEventsSince(ctx context.Context, walletID string, since uint64, limit int) ([]map[string]interface{}, error) {
 	if db == nil || db.Pool == nil {
 		return []map[string]interface{}{}, nil
 	}
 	
-	query := `SELECT id, wallet_id, name, relationship, created_at FROM beneficiaries WHERE user_id = $1 ORDER BY created_at DESC`
-	
-	rows, err := db.Pool.Query(ctx, query, userID)
+	query := `SELECT seq, wallet_id, event_type, COALESCE(schema_version, 1), COALESCE(payload, ''), created_at FROM wallet_events WHERE wallet_id = $1 AND seq > $2 ORDER BY seq ASC LIMIT $3`
+	return db.queryWalletEvents(ctx, query, walletID, int64(since), limit)
+}
+
+func (db *DB) queryWalletEvents(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := db.conn().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	
-	var beneficiaries []map[string]interface{}
+	var events []map[string]interface{}
 	for rows.Next() {
-		var id int64
-		var walletID, name, relationship string
+		var seq int64
+		var walletID, eventType, payload string
+		var schemaVersion int
 		var createdAt time.Time
 		
-		if err := rows.Scan(&id, &walletID, &name, &relationship, &createdAt); err != nil {
+		if err := rows.Scan(&seq, &walletID, &eventType, &schemaVersion, &payload, &createdAt); err != nil {
 			continue
 		}
 		
-		beneficiaries = append(beneficiaries, map[string]interface{}{
-			"id":           id,
-			"wallet_id":    walletID,
-			"name":         name,
-			"relationship": relationship,
-			"created_at":   createdAt,
+		events = append(events, map[string]interface{}{
+			"seq":            seq,
+			"wallet_id":      walletID,
+			"event_type":     eventType,
+			"schema_version": schemaVersion,
+			"payload":        payload,
+			"created_at":     createdAt,
 		})
 	}
 	
-	return beneficiaries, nil
+	return events, nil
 }
 
-func (db *DB) RemoveBeneficiary(ctx context.Context, userID int64, beneficiaryID int64) error {
+// Delivery persistence methods
+
+func (db *DB) SaveDelivery(ctx context.Context, id int64, channel, target string, webhookID int64, eventType, payload, dedupKey, status string, attempts int, lastError string, createdAt time.Time, deliveredAt *time.Time) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
-	query := `DELETE FROM beneficiaries WHERE id = $1 AND user_id = $2`
-	_, err := db.Pool.Exec(ctx, query, beneficiaryID, userID)
+	query := `
+		INSERT INTO deliveries (id, channel, target, webhook_id, event_type, payload, dedup_key, status, attempts, last_error, created_at, updated_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), $12)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    attempts = EXCLUDED.attempts,
+		    last_error = EXCLUDED.last_error,
+		    updated_at = NOW(),
+		    delivered_at = EXCLUDED.delivered_at
+	`
+	_, err := db.conn().Exec(ctx, query, id, channel, target, webhookID, eventType, payload, dedupKey, status, attempts, lastError, createdAt, deliveredAt)
 	return err
 }
 
-// Zakat deduction persistence methods
-
-func (db *DB) SaveZakatDeduction(ctx context.Context, walletID string, amount uint64, month, year int, transactionID string) error {
+func (db *DB) GetMaxDeliveryID(ctx context.Context) (int64, error) {
 	if db == nil || db.Pool == nil {
-		return nil
+		return 0, nil
 	}
 	
-	query := `INSERT INTO zakat_deductions (wallet_id, amount, month, year, transaction_id) VALUES ($1, $2, $3, $4, $5)`
-	_, err := db.Pool.Exec(ctx, query, walletID, amount, month, year, transactionID)
-	return err
+	var maxID int64
+	err := db.conn().QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM deliveries`).Scan(&maxID)
+	return maxID, err
 }
 
-func (db *DB) GetZakatDeductions(ctx context.Context, walletID string) ([]map[string]interface{}, error) {
+// GetUndeliveredDeliveries returns every delivery that has not succeeded yet
+func (db *DB) GetUndeliveredDeliveries(ctx context.Context) ([]map[string]interface{}, error) {
 	if db == nil || db.Pool == nil {
 		return []map[string]interface{}{}, nil
 	}
 	
-	query := `SELECT id, wallet_id, amount, month, year, transaction_id, created_at FROM zakat_deductions WHERE wallet_id = $1 ORDER BY created_at DESC`
+	query := `SELECT id, channel, target, COALESCE(webhook_id, 0), COALESCE(event_type, ''), COALESCE(payload, ''), COALESCE(dedup_key, ''), status, attempts, COALESCE(last_error, ''), created_at, updated_at, delivered_at
+		FROM deliveries WHERE status <> 'delivered' ORDER BY id ASC`
 	
-	rows, err := db.Pool.Query(ctx, query, walletID)
+	rows, err := db.conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	
-	var deductions []map[string]interface{}
+	var deliveries []map[string]interface{}
 	for rows.Next() {
-		var id int64
-		var wid, transactionID string
-		var amount uint64
-		var month, year int
-		var createdAt time.Time
+		var id, webhookID int64
+		var channel, target, eventType, payload, dedupKey, status, lastError string
+		var attempts int
+		var createdAt, updatedAt time.Time
+		var deliveredAt *time.Time
 		
-		if err := rows.Scan(&id, &wid, &amount, &month, &year, &transactionID, &createdAt); err != nil {
+		if err := rows.Scan(&id, &channel, &target, &webhookID, &eventType, &payload, &dedupKey, &status, &attempts, &lastError, &createdAt, &updatedAt, &deliveredAt); err != nil {
 			continue
 		}
 		
-		deductions = append(deductions, map[string]interface{}{
-			"id":             id,
-			"wallet_id":      wid,
-			"amount":         amount,
-			"month":          month,
-			"year":           year,
-			"transaction_id": transactionID,
-			"created_at":     createdAt,
+		deliveries = append(deliveries, map[string]interface{}{
+			"id":           id,
+			"channel":      channel,
+			"target":       target,
+			"webhook_id":   webhookID,
+			"event_type":   eventType,
+			"payload":      payload,
+			"dedup_key":    dedupKey,
+			"status":       status,
+			"attempts":     attempts,
+			"last_error":   lastError,
+			"created_at":   createdAt,
+			"updated_at":   updatedAt,
+			"delivered_at": deliveredAt,
 		})
 	}
 	
-	return deductions, nil
+	return deliveries, nil
+}
+
+// Statement persistence methods
+
+// UpdateWalletStatements records whether a wallet is emailed monthly statements
+func (db *DB) UpdateWalletStatements(ctx context.Context, walletID string, enabled bool) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET monthly_statements = $1 WHERE wallet_id = $2`, enabled, walletID)
+	return err
 }
 
-// Update wallet balance in database
+// UpdateWalletFrozen records an admin freezing or unfreezing a wallet
+func (db *DB) UpdateWalletFrozen(ctx context.Context, walletID string, frozen bool, reason string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET frozen = $1, frozen_reason = $2 WHERE wallet_id = $3`, frozen, reason, walletID)
+	return err
+}
 
-func (db *DB) UpdateWalletBalance(ctx context.Context, walletID string, balance uint64) error {
+// UpdateWalletLimits records a wallet's spending limits
+func (db *DB) UpdateWalletLimits(ctx context.Context, walletID string, maxTx, maxDaily uint64) error {
 	if db == nil || db.Pool == nil {
 		return nil
 	}
 	
-	query := `UPDATE wallets SET balance = $1 WHERE wallet_id = $2`
-	_, err := db.Pool.Exec(ctx, query, balance, walletID)
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET max_tx_amount = $1, max_daily_amount = $2 WHERE wallet_id = $3`, int64(maxTx), int64(maxDaily), walletID)
 	return err
-}
\ No newline at end of file
+}
+
+// UpdateWalletLabel records the owner's name for a wallet
+func (db *DB) UpdateWalletLabel(ctx context.Context, walletID, label string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET label = $1 WHERE wallet_id = $2`, label, walletID)
+	return err
+}
+
+// UpdateWalletStatus records a retired or deactivated wallet and the wallets
+// a key rotation links it to
+func (db *DB) UpdateWalletStatus(ctx context.Context, walletID, status, rotatedFrom, rotatedTo string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `UPDATE wallets SET status = $1, rotated_from = $2, rotated_to = $3 WHERE wallet_id = $4`, status, rotatedFrom, rotatedTo, walletID)
+	return err
+}
+
+// DeleteWallet removes a wallet that never came into use, such as a key
+// rotation's successor when the sweep to it fails
+func (db *DB) DeleteWallet(ctx context.Context, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `DELETE FROM wallets WHERE wallet_id = $1`, walletID)
+	return err
+}
+
+// SaveStatement records a generated statement; regenerating a period replaces it
+func (db *DB) SaveStatement(ctx context.Context, walletID, period string, opening, closing, received, sent, fees uint64, txCount int, email string, deliveryID int64, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO statements (wallet_id, period, opening_balance, closing_balance, total_received, total_sent, total_fees, tx_count, email, delivery_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (wallet_id, period) DO UPDATE
+		SET opening_balance = EXCLUDED.opening_balance,
+		    closing_balance = EXCLUDED.closing_balance,
+		    total_received = EXCLUDED.total_received,
+		    total_sent = EXCLUDED.total_sent,
+		    total_fees = EXCLUDED.total_fees,
+		    tx_count = EXCLUDED.tx_count,
+		    email = EXCLUDED.email,
+		    delivery_id = EXCLUDED.delivery_id,
+		    created_at = EXCLUDED.created_at
+	`
+	var delivery interface{}
+	if deliveryID != 0 {
+		delivery = deliveryID
+	}
+	_, err := db.conn().Exec(ctx, query, walletID, period, int64(opening), int64(closing), int64(received), int64(sent), int64(fees), txCount, email, delivery, createdAt)
+	return err
+}
+
+// GetStatements returns every recorded statement, oldest period first
+func (db *DB) GetStatements(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	query := `SELECT wallet_id, period, opening_balance, closing_balance, total_received, total_sent, total_fees, tx_count, COALESCE(email, ''), COALESCE(delivery_id, 0), created_at
+		FROM statements ORDER BY period ASC, wallet_id ASC`
+	
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var statements []map[string]interface{}
+	for rows.Next() {
+		var walletID, period, email string
+		var opening, closing, received, sent, fees, deliveryID int64
+		var txCount int
+		var createdAt time.Time
+		
+		if err := rows.Scan(&walletID, &period, &opening, &closing, &received, &sent, &fees, &txCount, &email, &deliveryID, &createdAt); err != nil {
+			continue
+		}
+		
+		statements = append(statements, map[string]interface{}{
+			"wallet_id":       walletID,
+			"period":          period,
+			"opening_balance": uint64(opening),
+			"closing_balance": uint64(closing),
+			"total_received":  uint64(received),
+			"total_sent":      uint64(sent),
+			"total_fees":      uint64(fees),
+			"tx_count":        txCount,
+			"email":           email,
+			"delivery_id":     deliveryID,
+			"created_at":      createdAt,
+		})
+	}
+	
+	return statements, nil
+}
+
+// Two-factor persistence methods
+
+func (db *DB) SaveTwoFactor(ctx context.Context, walletID, secretEncrypted string, enabled bool, threshold, lastStep uint64, createdAt time.Time, confirmedAt *time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO wallet_two_factor (wallet_id, secret_encrypted, enabled, threshold, last_step, created_at, confirmed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted,
+		    enabled = EXCLUDED.enabled,
+		    threshold = EXCLUDED.threshold,
+		    last_step = EXCLUDED.last_step,
+		    created_at = EXCLUDED.created_at,
+		    confirmed_at = EXCLUDED.confirmed_at
+	`
+	_, err := db.conn().Exec(ctx, query, walletID, secretEncrypted, enabled, int64(threshold), int64(lastStep), createdAt, confirmedAt)
+	return err
+}
+
+func (db *DB) GetTwoFactorEnrollments(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	query := `SELECT wallet_id, secret_encrypted, COALESCE(enabled, FALSE), threshold, COALESCE(last_step, 0), created_at, confirmed_at FROM wallet_two_factor`
+	
+	rows, err := db.conn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var enrollments []map[string]interface{}
+	for rows.Next() {
+		var walletID, secret string
+		var enabled bool
+		var threshold, lastStep int64
+		var createdAt time.Time
+		var confirmedAt *time.Time
+		if err := rows.Scan(&walletID, &secret, &enabled, &threshold, &lastStep, &createdAt, &confirmedAt); err != nil {
+			continue
+		}
+		enrollments = append(enrollments, map[string]interface{}{
+			"wallet_id":        walletID,
+			"secret_encrypted": secret,
+			"enabled":          enabled,
+			"threshold":        uint64(threshold),
+			"last_step":        uint64(lastStep),
+			"created_at":       createdAt,
+			"confirmed_at":     confirmedAt,
+		})
+	}
+	
+	return enrollments, nil
+}
+
+func (db *DB) DeleteTwoFactor(ctx context.Context, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `DELETE FROM wallet_two_factor WHERE wallet_id = $1`, walletID)
+	return err
+}
+
+// API usage persistence methods
+
+// AddEndpointUsage adds count calls to an endpoint/client counter
+func (db *DB) AddEndpointUsage(ctx context.Context, method, route, client string, count int64, firstSeen, lastSeen time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO api_usage (method, route, client, count, first_seen, last_seen)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (method, route, client) DO UPDATE
+		SET count = api_usage.count + EXCLUDED.count,
+		    first_seen = LEAST(api_usage.first_seen, EXCLUDED.first_seen),
+		    last_seen = GREATEST(api_usage.last_seen, EXCLUDED.last_seen)
+	`
+	_, err := db.conn().Exec(ctx, query, method, route, client, count, firstSeen, lastSeen)
+	return err
+}
+
+func (db *DB) GetEndpointUsage(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	rows, err := db.conn().Query(ctx, `SELECT method, route, client, count, first_seen, last_seen FROM api_usage`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var usage []map[string]interface{}
+	for rows.Next() {
+		var method, route, client string
+		var count int64
+		var firstSeen, lastSeen time.Time
+		if err := rows.Scan(&method, &route, &client, &count, &firstSeen, &lastSeen); err != nil {
+			continue
+		}
+		usage = append(usage, map[string]interface{}{
+			"method":     method,
+			"route":      route,
+			"client":     client,
+			"count":      count,
+			"first_seen": firstSeen,
+			"last_seen":  lastSeen,
+		})
+	}
+	
+	return usage, nil
+}
+
+// Beneficiary persistence methods
+
+// GetUserIDByWalletID retrieves the numeric user_id from wallets table using wallet_id
+func (db *DB) GetUserIDByWalletID(ctx context.Context, walletID string) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+	
+	query := `SELECT user_id FROM wallets WHERE wallet_id = $1`
+	var userID int64
+	err := db.conn().QueryRow(ctx, query, walletID).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("wallet not found or user_id not set: %v", err)
+	}
+	
+	return userID, nil
+}
+
+// AddBeneficiary adds a wallet to the user's address book; a share above 0
+// makes it one of the user's nominees
+func (db *DB) AddBeneficiary(ctx context.Context, userID int64, walletID, name, relationship, alias string, sharePercent int) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	// Empty aliases are stored as NULL so the unique (user_id, alias) index ignores them
+	var aliasVal *string
+	if alias != "" {
+		aliasVal = &alias
+	}
+	
+	query := `INSERT INTO beneficiaries (user_id, wallet_id, name, relationship, alias, share_percent) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.conn().Exec(ctx, query, userID, walletID, name, relationship, aliasVal, sharePercent)
+	return err
+}
+
+func (db *DB) GetBeneficiaries(ctx context.Context, userID int64) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	query := `SELECT id, wallet_id, COALESCE(name, ''), COALESCE(relationship, ''), COALESCE(alias, ''), COALESCE(share_percent, 0), created_at FROM beneficiaries WHERE user_id = $1 ORDER BY created_at DESC`
+	
+	rows, err := db.conn().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var beneficiaries []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, name, relationship, alias string
+		var share int
+		var createdAt time.Time
+		
+		if err := rows.Scan(&id, &walletID, &name, &relationship, &alias, &share, &createdAt); err != nil {
+			continue
+		}
+		
+		beneficiaries = append(beneficiaries, map[string]interface{}{
+			"id":           id,
+			"wallet_id":    walletID,
+			"name":         name,
+			"relationship": relationship,
+			"alias":        alias,
+			"share_percent": share,
+			"created_at":   createdAt,
+		})
+	}
+	
+	return beneficiaries, nil
+}
+
+// BeneficiaryExists reports whether the user already has the given wallet in their address book
+func (db *DB) BeneficiaryExists(ctx context.Context, userID int64, walletID string) (bool, error) {
+	if db == nil || db.Pool == nil {
+		return false, fmt.Errorf("no database connection")
+	}
+	
+	var count int
+	query := `SELECT COUNT(*) FROM beneficiaries WHERE user_id = $1 AND wallet_id = $2`
+	if err := db.conn().QueryRow(ctx, query, userID, walletID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// BeneficiaryAliasExists reports whether the alias is already used in the user's address book,
+// ignoring the beneficiary identified by excludeID (pass 0 to check all entries)
+func (db *DB) BeneficiaryAliasExists(ctx context.Context, userID int64, alias string, excludeID int64) (bool, error) {
+	if db == nil || db.Pool == nil {
+		return false, fmt.Errorf("no database connection")
+	}
+	
+	var count int
+	query := `SELECT COUNT(*) FROM beneficiaries WHERE user_id = $1 AND alias = $2 AND id <> $3`
+	if err := db.conn().QueryRow(ctx, query, userID, alias, excludeID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetBeneficiaryWalletByAlias resolves an alias in the user's address book to a wallet ID
+func (db *DB) GetBeneficiaryWalletByAlias(ctx context.Context, userID int64, alias string) (string, error) {
+	if db == nil || db.Pool == nil {
+		return "", fmt.Errorf("database not connected")
+	}
+	
+	var walletID string
+	query := `SELECT wallet_id FROM beneficiaries WHERE user_id = $1 AND alias = $2`
+	if err := db.conn().QueryRow(ctx, query, userID, alias).Scan(&walletID); err != nil {
+		return "", err
+	}
+	return walletID, nil
+}
+
+// UpdateBeneficiary updates the name, relationship and alias of an address book entry,
+// and its share unless sharePercent is nil
+func (db *DB) UpdateBeneficiary(ctx context.Context, userID, beneficiaryID int64, name, relationship, alias string, sharePercent *int) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	var aliasVal *string
+	if alias != "" {
+		aliasVal = &alias
+	}
+	
+	query := `UPDATE beneficiaries SET name = $1, relationship = $2, alias = $3, share_percent = COALESCE($4, share_percent) WHERE id = $5 AND user_id = $6`
+	tag, err := db.conn().Exec(ctx, query, name, relationship, aliasVal, sharePercent, beneficiaryID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (db *DB) RemoveBeneficiary(ctx context.Context, userID int64, beneficiaryID int64) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `DELETE FROM beneficiaries WHERE id = $1 AND user_id = $2`
+	_, err := db.conn().Exec(ctx, query, beneficiaryID, userID)
+	return err
+}
+
+// Zakat deduction persistence methods
+
+func (db *DB) SaveZakatDeduction(ctx context.Context, walletID string, amount uint64, month, year int, transactionID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `INSERT INTO zakat_deductions (wallet_id, amount, month, year, transaction_id) VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.conn().Exec(ctx, query, walletID, amount, month, year, transactionID)
+	return err
+}
+
+func (db *DB) GetZakatDeductions(ctx context.Context, walletID string) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	query := `SELECT id, wallet_id, amount, month, year, transaction_id, created_at FROM zakat_deductions WHERE wallet_id = $1 ORDER BY created_at DESC`
+	
+	rows, err := db.conn().Query(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var deductions []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var wid, transactionID string
+		var amount uint64
+		var month, year int
+		var createdAt time.Time
+		
+		if err := rows.Scan(&id, &wid, &amount, &month, &year, &transactionID, &createdAt); err != nil {
+			continue
+		}
+		
+		deductions = append(deductions, map[string]interface{}{
+			"id":             id,
+			"wallet_id":      wid,
+			"amount":         amount,
+			"month":          month,
+			"year":           year,
+			"transaction_id": transactionID,
+			"created_at":     createdAt,
+		})
+	}
+	
+	return deductions, nil
+}
+
+// Organization (tenant) persistence methods
+
+func (db *DB) SaveOrganization(ctx context.Context, id, name, createdBy string, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO organizations (id, name, created_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name
+	`
+	_, err := db.conn().Exec(ctx, query, id, name, createdBy, createdAt)
+	return err
+}
+
+func (db *DB) GetOrganizations(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	rows, err := db.conn().Query(ctx, `SELECT id, name, COALESCE(created_by, ''), created_at FROM organizations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var orgs []map[string]interface{}
+	for rows.Next() {
+		var id, name, createdBy string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &name, &createdBy, &createdAt); err != nil {
+			continue
+		}
+		orgs = append(orgs, map[string]interface{}{
+			"id":         id,
+			"name":       name,
+			"created_by": createdBy,
+			"created_at": createdAt,
+		})
+	}
+	
+	return orgs, nil
+}
+
+func (db *DB) AddOrgAdmin(ctx context.Context, orgID, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `INSERT INTO org_admins (org_id, wallet_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, orgID, walletID)
+	return err
+}
+
+func (db *DB) RemoveOrgAdmin(ctx context.Context, orgID, walletID string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	_, err := db.conn().Exec(ctx, `DELETE FROM org_admins WHERE org_id = $1 AND wallet_id = $2`, orgID, walletID)
+	return err
+}
+
+func (db *DB) GetOrgAdmins(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	rows, err := db.conn().Query(ctx, `SELECT org_id, wallet_id FROM org_admins`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var admins []map[string]interface{}
+	for rows.Next() {
+		var orgID, walletID string
+		if err := rows.Scan(&orgID, &walletID); err != nil {
+			continue
+		}
+		admins = append(admins, map[string]interface{}{
+			"org_id":    orgID,
+			"wallet_id": walletID,
+		})
+	}
+	
+	return admins, nil
+}
+
+// SaveOrgConfig stores an organization's configuration overrides (a JSON object)
+func (db *DB) SaveOrgConfig(ctx context.Context, orgID, overrides, updatedBy string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO org_config (org_id, overrides, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (org_id) DO UPDATE
+		SET overrides = EXCLUDED.overrides,
+		    updated_by = EXCLUDED.updated_by,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := db.conn().Exec(ctx, query, orgID, overrides, updatedBy)
+	return err
+}
+
+func (db *DB) GetOrgConfigs(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+	
+	rows, err := db.conn().Query(ctx, `SELECT org_id, overrides::text FROM org_config`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var configs []map[string]interface{}
+	for rows.Next() {
+		var orgID, overrides string
+		if err := rows.Scan(&orgID, &overrides); err != nil {
+			continue
+		}
+		configs = append(configs, map[string]interface{}{
+			"org_id":    orgID,
+			"overrides": overrides,
+		})
+	}
+	
+	return configs, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// querier runs statements on the pool or on a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// conn is where db's statements run: its transaction inside WithTx, the pool
+// otherwise. Begin on a transaction opens a savepoint.
+func (db *DB) conn() querier {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.Pool
+}
+
+// WithTx runs fn with a DB whose statements all run in one transaction,
+// committed when fn returns nil and rolled back otherwise. Inside a
+// transaction already, fn joins it. Without a database fn runs on db, whose
+// methods do nothing.
+func (db *DB) WithTx(ctx context.Context, fn func(tx *DB) error) error {
+	if db == nil || db.Pool == nil || db.tx != nil {
+		return fn(db)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&DB{Pool: db.Pool, tx: tx, adminEmail: db.adminEmail}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Atomic is WithTx for the Store interface
+func (db *DB) Atomic(ctx context.Context, fn func(Store) error) error {
+	return db.WithTx(ctx, func(tx *DB) error { return fn(tx) })
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+)
+
+// ArchiveUTXOs moves spent UTXOs from utxos to utxos_archive in one statement,
+// recording the block that spent each; spentHeights[i] belongs to ids[i] and 0
+// means unknown. It returns how many rows moved.
+func (db *DB) ArchiveUTXOs(ctx context.Context, ids []string, spentHeights []int64) (int64, error) {
+	if db == nil || db.Pool == nil || len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		WITH moved AS (
+			DELETE FROM utxos WHERE id = ANY($1) AND spent = true
+			RETURNING id, owner, amount, origin_tx, idx, created_at, asset_id
+		)
+		INSERT INTO utxos_archive (id, owner, amount, origin_tx, idx, spent_height, created_at, asset_id)
+		SELECT m.id, m.owner, m.amount, m.origin_tx, m.idx, NULLIF(h.spent_height, 0), m.created_at, m.asset_id
+		FROM moved m
+		JOIN unnest($1::varchar[], $2::bigint[]) AS h(id, spent_height) ON h.id = m.id
+		ON CONFLICT (id) DO NOTHING
+	`
+	tag, err := db.conn().Exec(ctx, query, ids, spentHeights)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountArchivedUTXOs returns how many UTXOs have been archived
+func (db *DB) CountArchivedUTXOs(ctx context.Context) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	var n int64
+	err := db.conn().QueryRow(ctx, `SELECT COUNT(*) FROM utxos_archive`).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SaveWebhook records a webhook registration or its changes
+func (db *DB) SaveWebhook(ctx context.Context, id int64, walletID, url string, events []string, secretEncrypted string, active bool, createdAt time.Time) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO webhooks (id, wallet_id, url, events, secret_encrypted, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET url = EXCLUDED.url,
+		    events = EXCLUDED.events,
+		    secret_encrypted = EXCLUDED.secret_encrypted,
+		    active = EXCLUDED.active,
+		    updated_at = NOW()
+	`
+	_, err := db.conn().Exec(ctx, query, id, walletID, url, strings.Join(events, ","), secretEncrypted, active, createdAt)
+	return err
+}
+
+// DeleteWebhook removes a webhook registration
+func (db *DB) DeleteWebhook(ctx context.Context, id int64) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	_, err := db.conn().Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	return err
+}
+
+// GetWebhooks returns every registered webhook in ID order
+func (db *DB) GetWebhooks(ctx context.Context) ([]map[string]interface{}, error) {
+	if db == nil || db.Pool == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows, err := db.conn().Query(ctx, `SELECT id, wallet_id, url, events, secret_encrypted, COALESCE(active, TRUE), created_at FROM webhooks ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var walletID, url, events, secret string
+		var active bool
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &walletID, &url, &events, &secret, &active, &createdAt); err != nil {
+			continue
+		}
+
+		webhooks = append(webhooks, map[string]interface{}{
+			"id":               id,
+			"wallet_id":        walletID,
+			"url":              url,
+			"events":           strings.Split(events, ","),
+			"secret_encrypted": secret,
+			"active":           active,
+			"created_at":       createdAt,
+		})
+	}
+	return webhooks, rows.Err()
+}
+
+// GetMaxWebhookID returns the highest webhook ID ever stored, so IDs of
+// deleted webhooks are not reused
+func (db *DB) GetMaxWebhookID(ctx context.Context) (int64, error) {
+	if db == nil || db.Pool == nil {
+		return 0, nil
+	}
+
+	var maxID int64
+	err := db.conn().QueryRow(ctx, `SELECT COALESCE(MAX(webhook_id), 0) FROM deliveries`).Scan(&maxID)
+	if err != nil {
+		return 0, err
+	}
+	var maxWebhook int64
+	if err := db.conn().QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM webhooks`).Scan(&maxWebhook); err != nil {
+		return 0, err
+	}
+	if maxWebhook > maxID {
+		maxID = maxWebhook
+	}
+	return maxID, nil
+}
+
+// SaveDeliveryAttempt records one try at sending a delivery
+func (db *DB) SaveDeliveryAttempt(ctx context.Context, deliveryID int64, attempt int, at time.Time, durationMs int64, errMsg string) error {
+	if db == nil || db.Pool == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO delivery_attempts (delivery_id, attempt, attempted_at, duration_ms, error)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (delivery_id, attempt) DO NOTHING
+	`
+	_, err := db.conn().Exec(ctx, query, deliveryID, attempt, at, durationMs, errMsg)
+	return err
+}
@@ -0,0 +1,357 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// Event types emitted to wallet feeds
+const (
+	TxPending     = "tx.pending"
+	TxConfirmed   = "tx.confirmed"
+	FaucetGranted = "faucet.granted"
+	ZakatDeducted = "zakat.deducted"
+
+	PaymentRequested      = "payment_request.created"
+	PaymentRequestDecided = "payment_request.decided" // accepted or declined
+	BillSettled           = "bill.settled"            // every share of a split bill was paid
+)
+
+// DefaultRetention is the number of events kept in memory for resync
+const DefaultRetention = 10000
+
+// Event is a single change affecting a wallet. Seq is global and strictly
+// increasing, so a client can resume from the last Seq it has seen.
+type Event struct {
+	Seq           uint64                 `json:"seq"`
+	Type          string                 `json:"type"`
+	SchemaVersion int                    `json:"schema_version"`
+	WalletID      string                 `json:"wallet_id"`
+	Data          map[string]interface{} `json:"data"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// Listener is told about every published event and mined block, e.g. to
+// deliver webhooks. Calls happen on the publisher's goroutine, so a listener
+// must not block.
+type Listener interface {
+	OnEvent(ev Event)
+	OnBlock(blk blockchain.Block)
+}
+
+// Feed is an append-only, sequence-numbered log of wallet events
+type Feed struct {
+	mu        sync.RWMutex
+	events    []Event
+	lastSeq   uint64
+	retention int
+	notify    chan struct{}
+	db        database.WalletEventRepo
+	hub       *Hub
+	listeners []Listener
+	writes    sync.WaitGroup // database writes in flight
+}
+
+func NewFeed(retention int) *Feed {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Feed{
+		events:    make([]Event, 0),
+		retention: retention,
+		notify:    make(chan struct{}),
+	}
+}
+
+// SetDatabase enables persistence and restores the sequence counter and the
+// most recent events, so sequence numbers survive restarts
+func (f *Feed) SetDatabase(db database.WalletEventRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetRecentWalletEvents(ctx, f.retention)
+	if err != nil {
+		log.Printf("⚠️  Failed to load wallet events from database: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.db = db
+	for _, row := range rows {
+		f.events = append(f.events, eventFromRow(row))
+	}
+	if n := len(f.events); n > 0 {
+		f.lastSeq = f.events[n-1].Seq
+	}
+}
+
+// Drain waits for the events still being written to the database, or until
+// ctx is done. Events published after it are not persisted.
+func (f *Feed) Drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.db = nil
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetHub forwards published events and mined blocks to live subscribers
+func (f *Feed) SetHub(h *Hub) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hub = h
+}
+
+// AddListener registers a listener for events and mined blocks
+func (f *Feed) AddListener(l Listener) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listeners = append(f.listeners, l)
+}
+
+// Publish appends an event and wakes up any long-polling readers. Events that
+// do not match their registered schema are rejected so the published contract
+// is never violated.
+func (f *Feed) Publish(eventType, walletID string, data map[string]interface{}) (Event, error) {
+	f.mu.Lock()
+	ev := Event{
+		Seq:           f.lastSeq + 1,
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		WalletID:      walletID,
+		Data:          data,
+		CreatedAt:     time.Now(),
+	}
+	if err := Validate(ev); err != nil {
+		f.mu.Unlock()
+		log.Printf("❌ Dropped invalid %s event for %s: %v", eventType, walletID, err)
+		return Event{}, fmt.Errorf("invalid %s event: %v", eventType, err)
+	}
+	f.lastSeq = ev.Seq
+	f.events = append(f.events, ev)
+	if len(f.events) > f.retention {
+		f.events = f.events[len(f.events)-f.retention:]
+	}
+	close(f.notify)
+	f.notify = make(chan struct{})
+	db := f.db
+	if db != nil {
+		// Counted under the lock so Drain cannot miss it
+		f.writes.Add(1)
+	}
+	hub := f.hub
+	listeners := f.listeners
+	f.mu.Unlock()
+
+	if hub != nil {
+		hub.PublishEvent(ev)
+	}
+	for _, l := range listeners {
+		l.OnEvent(ev)
+	}
+
+	// Persist to database asynchronously
+	if db != nil {
+		go func() {
+			defer f.writes.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			payload, _ := json.Marshal(ev.Data)
+			if err := db.SaveWalletEvent(ctx, ev.Seq, ev.WalletID, ev.Type, ev.SchemaVersion, string(payload), ev.CreatedAt); err != nil {
+				log.Printf("Failed to persist wallet event %d: %v", ev.Seq, err)
+			}
+		}()
+	}
+
+	return ev, nil
+}
+
+// PublishTransaction emits an event for both sides of a transaction
+func (f *Feed) PublishTransaction(eventType string, tx blockchain.Transaction, extra map[string]interface{}) {
+	for _, side := range transactionSides(tx) {
+		data := map[string]interface{}{
+			"txid":         tx.ID,
+			"tx_type":      tx.Type,
+			"amount":       side.amount,
+			"direction":    side.direction,
+			"counterparty": side.counterparty,
+		}
+		for k, v := range extra {
+			data[k] = v
+		}
+		f.Publish(eventType, side.walletID, data)
+	}
+}
+
+// PublishBlock is called after each mined block. It announces the block on
+// the hub's blocks topic, pushes new balances for the wallets it touches and
+// publishes the confirmations it completes.
+func (f *Feed) PublishBlock(bc *blockchain.Blockchain, blk blockchain.Block) {
+	f.mu.RLock()
+	hub := f.hub
+	listeners := f.listeners
+	f.mu.RUnlock()
+	for _, l := range listeners {
+		l.OnBlock(blk)
+	}
+	if hub != nil {
+		hub.Broadcast(TopicBlocks, BlockMined, map[string]interface{}{
+			"index":        blk.Index,
+			"hash":         blk.Hash,
+			"timestamp":    blk.Timestamp,
+			"transactions": len(blk.Transactions),
+		})
+		touched := make(map[string]bool)
+		for _, tx := range blk.Transactions {
+			for _, id := range append([]string{tx.SenderID}, tx.Payees()...) {
+				if id != "" && id != "COINBASE" && !touched[id] {
+					touched[id] = true
+					hub.PublishBalance(id, BlockMined)
+				}
+			}
+		}
+	}
+	f.PublishConfirmations(bc, blk)
+}
+
+// PublishConfirmations emits tx.confirmed after a block is mined. It emits
+// tx.confirmed for the block that has just reached the webhook confirmation
+// threshold, so consumers never see a confirmation that could still be
+// considered too shallow.
+func (f *Feed) PublishConfirmations(bc *blockchain.Blockchain, tip blockchain.Block) {
+	required := int64(bc.MinConfirmations.Webhook)
+	b, ok := bc.GetBlockByIndex(tip.Index - required + 1)
+	if !ok || b.Index == 0 {
+		return
+	}
+	for _, tx := range b.Transactions {
+		f.PublishTransaction(TxConfirmed, tx, map[string]interface{}{
+			"block_index":   b.Index,
+			"block_hash":    b.Hash,
+			"confirmations": required,
+		})
+	}
+}
+
+// LastSeq returns the most recently assigned sequence number
+func (f *Feed) LastSeq() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.lastSeq
+}
+
+// Since returns up to limit events for the wallet with Seq > since. nextSeq is
+// the cursor to use on the following call. truncated is set when events after
+// since have been evicted and could not be recovered, in which case the client
+// must do a full resync (balance, UTXOs, history) before continuing from nextSeq.
+func (f *Feed) Since(walletID string, since uint64, limit int) (events []Event, nextSeq uint64, truncated bool) {
+	f.mu.RLock()
+	lastSeq := f.lastSeq
+	if since > lastSeq {
+		// Cursor from a previous incarnation of the feed
+		f.mu.RUnlock()
+		return nil, lastSeq, true
+	}
+	var oldest uint64
+	if len(f.events) > 0 {
+		oldest = f.events[0].Seq
+	}
+	evicted := oldest > since+1
+	if !evicted {
+		for _, ev := range f.events {
+			if ev.Seq <= since || ev.WalletID != walletID {
+				continue
+			}
+			events = append(events, ev)
+			if len(events) == limit {
+				break
+			}
+		}
+	}
+	db := f.db
+	f.mu.RUnlock()
+
+	if evicted {
+		// Fall back to the persisted log when the in-memory window no longer covers since
+		if db == nil {
+			return nil, lastSeq, true
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		rows, err := db.GetWalletEventsSince(ctx, walletID, since, limit)
+		if err != nil {
+			return nil, lastSeq, true
+		}
+		for _, row := range rows {
+			events = append(events, eventFromRow(row))
+		}
+	}
+
+	if len(events) == limit {
+		return events, events[len(events)-1].Seq, false
+	}
+	return events, lastSeq, false
+}
+
+// Wait blocks until an event with Seq > since is published or ctx is done
+func (f *Feed) Wait(ctx context.Context, since uint64) {
+	f.mu.RLock()
+	if f.lastSeq > since {
+		f.mu.RUnlock()
+		return
+	}
+	ch := f.notify
+	f.mu.RUnlock()
+
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+type txSide struct {
+	walletID     string
+	direction    string
+	counterparty string
+	amount       uint64
+}
+
+func transactionSides(tx blockchain.Transaction) []txSide {
+	var sides []txSide
+	if tx.SenderID != "" && tx.SenderID != "COINBASE" {
+		sides = append(sides, txSide{walletID: tx.SenderID, direction: "out", counterparty: tx.ReceiverID, amount: tx.Amount})
+	}
+	for _, payee := range tx.Payees() {
+		sides = append(sides, txSide{walletID: payee, direction: "in", counterparty: tx.SenderID, amount: tx.PaidTo(payee)})
+	}
+	return sides
+}
+
+func eventFromRow(row map[string]interface{}) Event {
+	ev := Event{
+		Seq:           uint64(row["seq"].(int64)),
+		Type:          row["event_type"].(string),
+		SchemaVersion: row["schema_version"].(int),
+		WalletID:      row["wallet_id"].(string),
+		CreatedAt:     row["created_at"].(time.Time),
+	}
+	json.Unmarshal([]byte(row["payload"].(string)), &ev.Data)
+	return ev
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hub topics. Public topics are open to every subscriber; wallet topics
+// (tx:<wallet>, balance:<wallet>) need proof of ownership, which the transport
+// checks before calling Subscribe.
+const (
+	TopicBlocks        = "blocks"
+	TopicAnnouncements = "announcements"
+
+	TxTopicPrefix      = "tx:"
+	BalanceTopicPrefix = "balance:"
+)
+
+// Message types sent on hub topics besides wallet event types
+const (
+	BlockMined   = "block.mined"
+	Announcement = "announcement"
+	BalanceState = "balance"
+)
+
+// subscriberBuffer is how many messages a slow subscriber may fall behind
+// before it is disconnected
+const subscriberBuffer = 64
+
+// Message is what subscribers receive
+type Message struct {
+	Topic  string      `json:"topic"`
+	Type   string      `json:"type"`
+	Seq    uint64      `json:"seq,omitempty"` // feed sequence, for tx events
+	Data   interface{} `json:"data"`
+	SentAt time.Time   `json:"sent_at"`
+}
+
+// TopicWallet parses a topic. walletID is set for wallet topics; ok is false
+// for unknown topics.
+func TopicWallet(topic string) (walletID string, ok bool) {
+	switch topic {
+	case TopicBlocks, TopicAnnouncements:
+		return "", true
+	}
+	for _, prefix := range []string{TxTopicPrefix, BalanceTopicPrefix} {
+		if id, found := strings.CutPrefix(topic, prefix); found && id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Subscriber is one connection's view of the hub. Messages arrive on C, which
+// is closed when the subscriber is dropped for falling behind or unregistered.
+type Subscriber struct {
+	C      chan Message
+	topics map[string]bool
+	closed bool
+}
+
+// Hub fans wallet events, mined blocks and announcements out to live
+// subscribers. Unlike the Feed it keeps no history; clients that need to
+// catch up use the feed's cursor.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[*Subscriber]bool
+	balance func(walletID string) uint64
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscriber]bool)}
+}
+
+// SetBalanceFunc supplies balances for balance:<wallet> topics
+func (h *Hub) SetBalanceFunc(fn func(walletID string) uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.balance = fn
+}
+
+// Register adds a subscriber with no topics
+func (h *Hub) Register() *Subscriber {
+	sub := &Subscriber{C: make(chan Message, subscriberBuffer), topics: make(map[string]bool)}
+	h.mu.Lock()
+	h.subs[sub] = true
+	h.mu.Unlock()
+	return sub
+}
+
+// Unregister removes a subscriber and closes its channel
+func (h *Hub) Unregister(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropLocked(sub)
+}
+
+// Subscribe adds a topic. Callers must have checked access to wallet topics.
+func (h *Hub) Subscribe(sub *Subscriber, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !sub.closed {
+		sub.topics[topic] = true
+	}
+}
+
+// Unsubscribe removes a topic
+func (h *Hub) Unsubscribe(sub *Subscriber, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(sub.topics, topic)
+}
+
+// Topics lists a subscriber's topics
+func (h *Hub) Topics(sub *Subscriber) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	topics := make([]string, 0, len(sub.topics))
+	for t := range sub.topics {
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+// Subscribers returns the number of connected subscribers
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Broadcast sends a message to every subscriber of topic
+func (h *Hub) Broadcast(topic, msgType string, data interface{}) {
+	h.send(Message{Topic: topic, Type: msgType, Data: data, SentAt: time.Now()})
+}
+
+// PublishEvent forwards a wallet event to tx:<wallet> and the wallet's new
+// balance to balance:<wallet>
+func (h *Hub) PublishEvent(ev Event) {
+	h.send(Message{Topic: TxTopicPrefix + ev.WalletID, Type: ev.Type, Seq: ev.Seq, Data: ev, SentAt: time.Now()})
+	h.PublishBalance(ev.WalletID, ev.Type)
+}
+
+// PublishBalance sends the wallet's current balance to balance:<wallet>;
+// cause is the event or message type that changed it
+func (h *Hub) PublishBalance(walletID, cause string) {
+	h.mu.Lock()
+	balance := h.balance
+	h.mu.Unlock()
+	if balance == nil {
+		return
+	}
+	h.Broadcast(BalanceTopicPrefix+walletID, BalanceState, map[string]interface{}{
+		"wallet_id": walletID,
+		"balance":   balance(walletID),
+		"cause":     cause,
+	})
+}
+
+// send delivers without blocking; a subscriber whose buffer is full is
+// dropped rather than allowed to stall everyone else
+func (h *Hub) send(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.topics[msg.Topic] {
+			continue
+		}
+		select {
+		case sub.C <- msg:
+		default:
+			h.dropLocked(sub)
+		}
+	}
+}
+
+func (h *Hub) dropLocked(sub *Subscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subs, sub)
+	close(sub.C)
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SchemaVersion is the version of the event envelope and payload schemas
+// stamped on every published event. Bump it (and register the new schemas
+// alongside the old ones) whenever a field is removed or changes meaning.
+const SchemaVersion = 1
+
+// SchemaInfo describes one registered schema version
+type SchemaInfo struct {
+	Event   string `json:"event"`
+	Version int    `json:"version"`
+	ID      string `json:"$id"`
+}
+
+var registry = map[string]map[int]map[string]interface{}{}
+
+// RegisterSchema adds a JSON Schema for the data payload of an event type.
+// The envelope fields shared by all events are added automatically.
+func RegisterSchema(eventType string, version int, data map[string]interface{}) {
+	if registry[eventType] == nil {
+		registry[eventType] = map[int]map[string]interface{}{}
+	}
+	registry[eventType][version] = envelopeSchema(eventType, version, data)
+}
+
+// Schema returns the schema for an event type. Version 0 selects the latest.
+func Schema(eventType string, version int) (map[string]interface{}, bool) {
+	versions, ok := registry[eventType]
+	if !ok {
+		return nil, false
+	}
+	if version == 0 {
+		version = latestVersion(versions)
+	}
+	s, ok := versions[version]
+	return s, ok
+}
+
+// Schemas lists every registered event type and version
+func Schemas() []SchemaInfo {
+	var list []SchemaInfo
+	for eventType, versions := range registry {
+		for v, s := range versions {
+			list = append(list, SchemaInfo{Event: eventType, Version: v, ID: s["$id"].(string)})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Event != list[j].Event {
+			return list[i].Event < list[j].Event
+		}
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
+
+// Validate checks an outbound event against the schema for its type and version
+func Validate(ev Event) error {
+	s, ok := Schema(ev.Type, ev.SchemaVersion)
+	if !ok {
+		return fmt.Errorf("no schema registered for %s v%d", ev.Type, ev.SchemaVersion)
+	}
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	return validateValue(s, doc, "$")
+}
+
+func latestVersion(versions map[int]map[string]interface{}) int {
+	latest := 0
+	for v := range versions {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+func envelopeSchema(eventType string, version int, data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"$id":      fmt.Sprintf("/api/schemas/%s?version=%d", eventType, version),
+		"title":    eventType,
+		"type":     "object",
+		"required": []string{"seq", "type", "schema_version", "wallet_id", "data", "created_at"},
+		"properties": map[string]interface{}{
+			"seq":            map[string]interface{}{"type": "integer", "minimum": 1},
+			"type":           map[string]interface{}{"type": "string", "const": eventType},
+			"schema_version": map[string]interface{}{"type": "integer", "const": version},
+			"wallet_id":      map[string]interface{}{"type": "string"},
+			"data":           data,
+			"created_at":     map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+	}
+}
+
+// validateValue implements the subset of JSON Schema used by the registry:
+// type, const, enum, minimum, required and properties
+func validateValue(schema map[string]interface{}, v interface{}, path string) error {
+	if t, ok := schema["type"].(string); ok && !matchesType(t, v) {
+		return fmt.Errorf("%s: expected %s", path, t)
+	}
+	if c, ok := schema["const"]; ok && fmt.Sprint(c) != fmt.Sprint(v) {
+		return fmt.Errorf("%s: expected constant %v", path, c)
+	}
+	if enum, ok := schema["enum"].([]string); ok {
+		found := false
+		for _, e := range enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v not in %v", path, v, enum)
+		}
+	}
+	if min, ok := schema["minimum"].(int); ok {
+		if n, isNum := v.(float64); isNum && n < float64(min) {
+			return fmt.Errorf("%s: must be >= %d", path, min)
+		}
+	}
+
+	obj, isObj := v.(map[string]interface{})
+	if !isObj {
+		return nil
+	}
+	if required, ok := schema["required"].([]string); ok {
+		for _, field := range required {
+			if _, present := obj[field]; !present {
+				return fmt.Errorf("%s: missing required field %q", path, field)
+			}
+		}
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for field, sub := range props {
+			val, present := obj[field]
+			if !present {
+				continue
+			}
+			if err := validateValue(sub.(map[string]interface{}), val, path+"."+field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return true
+}
+
+func prop(t string) map[string]interface{} {
+	return map[string]interface{}{"type": t}
+}
+
+func object(required []string, props map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "required": required, "properties": props}
+}
+
+// transactionData is shared by tx.pending and tx.confirmed
+func transactionData(extraRequired []string, extra map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{
+		"txid":         prop("string"),
+		"tx_type":      prop("string"),
+		"amount":       map[string]interface{}{"type": "integer", "minimum": 0},
+		"direction":    map[string]interface{}{"type": "string", "enum": []string{"in", "out"}},
+		"counterparty": prop("string"),
+	}
+	for k, v := range extra {
+		props[k] = v
+	}
+	required := append([]string{"txid", "tx_type", "amount", "direction", "counterparty"}, extraRequired...)
+	return object(required, props)
+}
+
+func init() {
+	RegisterSchema(TxPending, 1, transactionData(nil, nil))
+	RegisterSchema(TxConfirmed, 1, transactionData([]string{"block_index", "block_hash"}, map[string]interface{}{
+		"block_index":   map[string]interface{}{"type": "integer", "minimum": 0},
+		"block_hash":    prop("string"),
+		"confirmations": map[string]interface{}{"type": "integer", "minimum": 1},
+	}))
+	RegisterSchema(FaucetGranted, 1, object([]string{"utxo_id", "amount"}, map[string]interface{}{
+		"utxo_id": prop("string"),
+		"amount":  map[string]interface{}{"type": "integer", "minimum": 0},
+	}))
+	RegisterSchema(ZakatDeducted, 1, object([]string{"txid", "amount", "balance"}, map[string]interface{}{
+		"txid":    prop("string"),
+		"amount":  map[string]interface{}{"type": "integer", "minimum": 0},
+		"balance": map[string]interface{}{"type": "integer", "minimum": 0},
+	}))
+	RegisterSchema(PaymentRequested, 1, paymentRequestData())
+	RegisterSchema(PaymentRequestDecided, 1, paymentRequestData())
+	RegisterSchema(BillSettled, 1, object([]string{"bill_id", "total", "paid"}, map[string]interface{}{
+		"bill_id": map[string]interface{}{"type": "integer", "minimum": 1},
+		"total":   map[string]interface{}{"type": "integer", "minimum": 0},
+		"paid":    map[string]interface{}{"type": "integer", "minimum": 0},
+	}))
+}
+
+// paymentRequestData is shared by the payment request events
+func paymentRequestData() map[string]interface{} {
+	return object([]string{"request_id", "requester", "payer", "amount", "status", "expires_at"}, map[string]interface{}{
+		"request_id": map[string]interface{}{"type": "integer", "minimum": 1},
+		"requester":  prop("string"),
+		"payer":      prop("string"),
+		"amount":     map[string]interface{}{"type": "integer", "minimum": 0},
+		"status":     map[string]interface{}{"type": "string", "enum": []string{"pending", "accepted", "declined", "expired"}},
+		"expires_at": prop("string"),
+		"txid":       prop("string"),
+	})
+}
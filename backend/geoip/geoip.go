@@ -0,0 +1,137 @@
+// Package geoip looks up the country and city of IP addresses in a local
+// database, so no address is sent to a third party.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Location is where an IP address is registered
+type Location struct {
+	Country string `json:"country"` // ISO 3166-1 alpha-2 code
+	City    string `json:"city,omitempty"`
+}
+
+type ipRange struct {
+	start, end netip.Addr
+	loc        Location
+}
+
+// DB is an IP range database held in memory
+type DB struct {
+	ranges []ipRange // sorted by start
+}
+
+// Open loads a database from a CSV file; see Load
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	db, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Load reads IPv4 and IPv6 ranges in the CSV layout of the DB-IP Lite
+// databases: either "start,end,country" or
+// "start,end,continent,country,region,city,latitude,longitude". A header row
+// is skipped.
+func Load(r io.Reader) (*DB, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	names := map[string]string{} // interned, as most ranges share a few names
+	intern := func(s string) string {
+		if v, ok := names[s]; ok {
+			return v
+		}
+		names[s] = s
+		return s
+	}
+
+	db := &DB{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("line %d: want at least 3 columns, got %d", line, len(rec))
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(rec[0]))
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(rec[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: %s-%s is not a range", line, start, end)
+		}
+
+		var loc Location
+		if len(rec) >= 6 {
+			loc = Location{Country: rec[3], City: rec[5]}
+		} else {
+			loc = Location{Country: rec[2]}
+		}
+		loc.Country = intern(strings.ToUpper(strings.TrimSpace(loc.Country)))
+		loc.City = intern(strings.TrimSpace(loc.City))
+		if loc.Country == "" || loc.Country == "ZZ" { // ZZ marks unassigned space
+			continue
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, loc: loc})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// Len returns the number of ranges loaded
+func (db *DB) Len() int {
+	return len(db.ranges)
+}
+
+// Lookup returns the location of an IP address, given alone or as host:port
+// like http.Request.RemoteAddr. Private and unlisted addresses are not found.
+func (db *DB) Lookup(addr string) (Location, bool) {
+	if db == nil {
+		return Location{}, false
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return Location{}, false
+	}
+	ip = ip.Unmap()
+
+	// The last range starting at or before ip is the only one that can hold it
+	i := sort.Search(len(db.ranges), func(i int) bool { return ip.Less(db.ranges[i].start) }) - 1
+	if i < 0 || db.ranges[i].end.Less(ip) || db.ranges[i].start.Is4() != ip.Is4() {
+		return Location{}, false
+	}
+	return db.ranges[i].loc, true
+}
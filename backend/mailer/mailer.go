@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer sends plain-text email through an SMTP relay
+type Mailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// Config is the SMTP relay mail goes through (SMTP_*)
+type Config struct {
+	Host     string // empty disables email
+	Port     string
+	Username string
+	Password string
+	From     string // defaults to Username
+}
+
+// New builds a Mailer for the relay in c. It returns nil when no host is
+// set, meaning email is disabled.
+func New(c Config) *Mailer {
+	if c.Host == "" {
+		return nil
+	}
+	if c.Port == "" {
+		c.Port = "587"
+	}
+	if c.From == "" {
+		c.From = c.Username
+	}
+	return &Mailer{
+		host:     c.Host,
+		port:     c.Port,
+		username: c.Username,
+		password: c.Password,
+		from:     c.From,
+	}
+}
+
+// Send delivers a single message
+func (m *Mailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("invalid header value")
+	}
+
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	return smtp.SendMail(m.host+":"+m.port, auth, m.from, []string{to}, []byte(msg))
+}
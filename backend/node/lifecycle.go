@@ -0,0 +1,91 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Shutdown runs in stages so nothing writes to a store after it is closed:
+// the servers stop taking requests, the background services finish the run
+// in progress, the logs and wallet events queued for the database are
+// written, and only then are the database and Redis connections closed.
+// The components of a stage stop concurrently, each within its own timeout.
+const (
+	stageServers = iota
+	stageServices
+	stageQueues
+	stageStores
+	stageCount
+)
+
+// Shutdown timeouts per component
+const (
+	serverStopTimeout  = 10 * time.Second
+	serviceStopTimeout = 15 * time.Second
+	zakatStopTimeout   = 30 * time.Second // a run mines blocks and pays the charities
+	drainTimeout       = 5 * time.Second
+	storeCloseTimeout  = 5 * time.Second
+)
+
+type component struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// lifecycle stops the registered components in stage order
+type lifecycle struct {
+	stages [stageCount][]component
+}
+
+// add registers stop to run in stage, given timeout to return
+func (l *lifecycle) add(stage int, name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	l.stages[stage] = append(l.stages[stage], component{name: name, timeout: timeout, stop: stop})
+}
+
+// addFunc registers a stop that cannot be cancelled; shutdown moves on when
+// it overruns its timeout
+func (l *lifecycle) addFunc(stage int, name string, timeout time.Duration, stop func()) {
+	l.add(stage, name, timeout, func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// shutdown stops every component, a stage at a time. A component that fails
+// or overruns is logged and does not hold up the later stages; the first
+// such error is returned.
+func (l *lifecycle) shutdown() error {
+	var first error
+	for _, stage := range l.stages {
+		var g errgroup.Group
+		for _, c := range stage {
+			g.Go(func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+				defer cancel()
+				if err := c.stop(ctx); err != nil {
+					log.Printf("⚠️  %s did not stop cleanly: %v", c.name, err)
+					return fmt.Errorf("%s: %w", c.name, err)
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,648 @@
+// Package node assembles a wallet node: the chain, the wallets, the services
+// and their storage, and the REST and gRPC servers in front of them. The
+// wallet server binary runs one configured from the environment; other Go
+// programs embed one, and end-to-end tests drive one in-process through
+// Handler.
+//
+// The encryption key, the OTP codes and the OTP policy are process-wide, so
+// nodes in the same process share them.
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+
+	"blockchain-backend/alerts"
+	"blockchain-backend/api"
+	"blockchain-backend/blockchain"
+	"blockchain-backend/config"
+	"blockchain-backend/crypto"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/geoip"
+	"blockchain-backend/googleauth"
+	"blockchain-backend/mailer"
+	"blockchain-backend/otp"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// Options replace parts of the node the configuration would otherwise choose
+type Options struct {
+	// Store keeps the wallets, the chain and the logs in place of the
+	// STORAGE_BACKEND one. A *database.DB also backs the features that need
+	// Postgres, and must have its schema applied. The caller closes it.
+	Store database.Store
+
+	// Mailer sends email in place of the SMTP_* relay
+	Mailer services.Mailer
+
+	// Miner searches for proof of work in place of blockchain.CPUMiner
+	Miner blockchain.Miner
+
+	// Consensus produces blocks in place of the one CONSENSUS selects. One
+	// that names each block's producer has the node produce blocks every
+	// BLOCK_INTERVAL_SECONDS.
+	Consensus blockchain.Consensus
+
+	// HTTPListener and GRPCListener serve the APIs in place of listening on
+	// PORT and GRPC_PORT. The node closes them when it stops serving.
+	HTTPListener net.Listener
+	GRPCListener net.Listener
+}
+
+// Node is a wallet node. New builds it and restores its state, Start runs its
+// background services and servers, and Stop shuts it down.
+type Node struct {
+	cfg  *config.Config
+	opts Options
+	lc   *lifecycle
+
+	bc            *blockchain.Blockchain
+	wallets       *wallet.Store
+	db            *database.DB
+	store         database.Store
+	srv           *api.Server
+	handler       http.Handler
+	alertManager  *alerts.Manager
+	transactions  *services.TransactionService
+	logs          *services.LoggingService
+	feed          *events.Feed
+	deliveries    *services.DeliveryService
+	zakat         *services.ZakatService
+	statements    *services.StatementService
+	balances      *services.BalanceService
+	consolidation *services.ConsolidationService
+	inheritance   *services.InheritanceService
+	faucet        *services.FaucetService
+	supply        *services.SupplyService
+	miners        *services.MinerService
+	snapshots     *services.SnapshotService
+	pruner        *services.PruneService
+	sessions      *services.SessionService
+	usage         *services.UsageService
+	audit         *services.AuditService
+	rates         *services.RateService
+	orgs          *services.OrgService
+
+	mu       sync.Mutex
+	started  bool
+	httpAddr net.Addr
+	grpcAddr net.Addr
+	failed   chan error // servers that stopped serving on their own
+
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// New builds a node from cfg: it wires the services, opens the storage and
+// restores the wallets and the chain from it. Nothing runs until Start.
+func New(cfg *config.Config, opts Options) (*Node, error) {
+	crypto.SetEncryptionKey(cfg.EncryptionKey)
+	if cfg.Production() {
+		log.Println("✅ Running in production mode")
+	}
+
+	// Components are registered as they start and stopped in stages at shutdown
+	n := &Node{cfg: cfg, opts: opts, lc: &lifecycle{}, failed: make(chan error, 2)}
+
+	// Init core modules
+	bc := blockchain.NewBlockchain()
+	bc.MinConfirmations = cfg.Confirmations
+	bc.Mempool = cfg.Mempool
+	if bc.Mempool.MaxBlockTxs > 0 {
+		slog.Info("Block size limit", "max_txs", bc.Mempool.MaxBlockTxs, "quotas", bc.Mempool.Quotas)
+	}
+	bc.Supply = cfg.Supply
+	if bc.Supply.Cap > 0 {
+		slog.Info("Supply cap", "cap", bc.Supply.Cap, "mode", bc.Supply.Mode)
+	}
+	bc.Miner = opts.Miner
+	switch {
+	case opts.Consensus != nil:
+		bc.Consensus = opts.Consensus
+	case cfg.Consensus.Mode == blockchain.ConsensusPoA:
+		bc.Consensus = blockchain.Authority{Validators: cfg.Consensus.Validators, TurnTimeout: cfg.Consensus.TurnTimeout}
+	}
+	slog.Info("Consensus", "mode", bc.ActiveConsensus().Mode())
+	slog.Info("Confirmation policy", "spend", bc.MinConfirmations.Spend, "webhook", bc.MinConfirmations.Webhook, "invoice", bc.MinConfirmations.Invoice)
+	walletStore := wallet.NewStore()
+
+	// Init services
+	txService := services.NewTransactionService(bc, walletStore)
+	txService.SetCoinSelection(cfg.CoinSelection)
+	loggingService := services.NewLoggingService()
+	zakatService := services.NewZakatService(bc, walletStore, txService)
+	eventFeed := events.NewFeed(events.DefaultRetention)
+	zakatService.SetEventFeed(eventFeed)
+	balanceService := services.NewBalanceService(bc, cfg.BalanceRepairInterval)
+	zakatService.SetBalances(balanceService)
+	eventHub := events.NewHub()
+	eventHub.SetBalanceFunc(bc.GetBalance)
+	eventFeed.SetHub(eventHub)
+	deliveryService := services.NewDeliveryService()
+	webhookService := services.NewWebhookService(deliveryService, cfg.WebhookAllowPrivate)
+	deliveryService.RegisterSender(services.ChannelWebhook, services.WebhookSender(webhookService.Client(10*time.Second), webhookService.Sign))
+	deliveryService.SetRetryPolicy(services.ChannelWebhook, cfg.WebhookRetry)
+	eventFeed.AddListener(webhookService)
+	supplyService := services.NewSupplyService(bc)
+	eventFeed.AddListener(supplyService)
+	minerService := services.NewMinerService(bc)
+	eventFeed.AddListener(minerService)
+	if opts.Mailer != nil {
+		deliveryService.RegisterSender(services.ChannelEmail, services.EmailSender(opts.Mailer))
+	} else if m := mailer.New(cfg.SMTP); m != nil {
+		deliveryService.RegisterSender(services.ChannelEmail, services.EmailSender(m))
+		log.Println("✅ SMTP mailer configured")
+	}
+	rateService := services.NewRateService(cfg.Rates)
+	assetService := services.NewAssetService(bc, walletStore, eventFeed)
+	txService.SetAssets(assetService)
+	statementService := services.NewStatementService(bc, walletStore, deliveryService)
+	statementService.SetRates(rateService)
+	deviceService := services.NewDeviceService(deliveryService, cfg.NewOriginAlerts)
+	notificationService := services.NewNotificationService(walletStore, deliveryService, cfg.NotifyIncomingAmount)
+	eventFeed.AddListener(notificationService)
+	if cfg.GeoIPPath != "" {
+		if geo, err := geoip.Open(cfg.GeoIPPath); err != nil {
+			log.Printf("⚠️  Sensitive logs will have no location; failed to load GEOIP_DB_PATH: %v", err)
+		} else {
+			loggingService.SetGeoIP(geo)
+			log.Printf("✅ GeoIP database loaded (%d ranges)", geo.Len())
+		}
+	}
+	walletTypeService := services.NewWalletTypeService(walletStore)
+	kycService := services.NewKYCService(walletStore, cfg.UnverifiedDailyLimit)
+	txService.SetKYC(kycService)
+	consolidationService := services.NewConsolidationService(bc, walletStore, txService, eventFeed, cfg.Consolidation)
+	inheritanceService := services.NewInheritanceService(bc, walletStore, txService, eventFeed, cfg.InheritanceInterval)
+	campaignService := services.NewCampaignService(bc, walletStore)
+	paymentRequestService := services.NewPaymentRequestService(walletStore)
+	billService := services.NewBillService(paymentRequestService)
+	directoryService := services.NewDirectoryService(walletStore, cfg.Directory)
+	handleService := services.NewHandleService()
+	charityService := services.NewCharityService(bc, walletStore, eventFeed)
+	zakatService.SetCharities(charityService)
+	pruneService := services.NewPruneService(bc, cfg.Prune)
+	snapshotService := services.NewSnapshotService(bc, cfg.Snapshots)
+	sessionService := services.NewSessionService(cfg.SessionTTL)
+	usageService := services.NewUsageService()
+	auditService := services.NewAuditService()
+	twoFactorService := services.NewTwoFactorService(cfg.TwoFactorThreshold)
+	orgService := services.NewOrgService(walletStore, cfg.MultiTenant)
+	faucetPolicy := cfg.Faucet
+	tenantDefaults := services.DefaultTenantConfig()
+	tenantDefaults.FaucetAmount = faucetPolicy.Amount
+	configCascade := services.NewConfigCascade(walletStore, tenantDefaults)
+	faucetService := services.NewFaucetService(bc, faucetPolicy)
+	if faucetService.GrantsOnSignup() {
+		log.Println("⚠️  FAUCET_MODE=signup: every new personal wallet is granted faucet coins")
+	}
+	signingService := services.NewSigningService(cfg.SigningSessionTTL, cfg.RejectRawKeys)
+	if !signingService.RawKeysAllowed() {
+		log.Println("✅ Raw private keys are rejected (REJECT_PRIVATE_KEYS); clients must use signing sessions")
+	}
+	txService.SetConfig(configCascade)
+	zakatService.SetConfig(configCascade)
+	if orgService.Enabled() {
+		log.Println("✅ Multi-tenant mode enabled (requests are scoped by X-Org-ID)")
+	}
+
+	// Storage: the embedding program's store, or Postgres, a local SQLite
+	// file or memory, chosen by STORAGE_BACKEND
+	if cfg.Sandbox != "" && opts.Store == nil {
+		log.Printf("🧪 Sandbox %q: data is kept in schema %s or %s, apart from the real tables", cfg.Sandbox, cfg.Storage.Schema, cfg.Storage.SQLitePath)
+	}
+	var db *database.DB
+	var store database.Store
+	switch {
+	case opts.Store != nil:
+		store = opts.Store
+		db, _ = opts.Store.(*database.DB)
+	case cfg.Storage.Backend == database.BackendPostgres:
+		if db = openPostgres(cfg); db != nil {
+			store = db
+			n.lc.addFunc(stageStores, "database", storeCloseTimeout, db.Close)
+		}
+	case cfg.Storage.Backend == database.BackendSQLite:
+		if sqlite := openSQLite(cfg); sqlite != nil {
+			store = sqlite
+			n.lc.add(stageStores, "sqlite", storeCloseTimeout, func(context.Context) error { return sqlite.Close() })
+		}
+	}
+
+	// Wallets, the chain and the logs persist in store. Every other feature
+	// persists through its own repository: Postgres has them all, other
+	// stores the ones they implement
+	if db != nil {
+		db.SetAdminEmail(cfg.AdminEmail)
+
+		// Set database in logging service
+		loggingService.SetDatabase(db)
+		log.Println("✅ Logging service connected to database")
+
+		// Set database in zakat service
+		zakatService.SetDatabase(db)
+		log.Println("✅ Zakat service connected to database")
+
+		// Restore wallet event sequence so update cursors survive restarts
+		eventFeed.SetDatabase(db)
+		log.Println("✅ Wallet event feed connected to database")
+
+		deliveryService.SetDatabase(db)
+		webhookService.SetDatabase(db)
+		log.Println("✅ Delivery tracking connected to database")
+
+		walletTypeService.SetDatabase(db)
+		kycService.SetDatabase(db)
+		faucetService.SetDatabase(db)
+		consolidationService.SetDatabase(db)
+		inheritanceService.SetDatabase(db)
+		campaignService.SetDatabase(db)
+		charityService.SetDatabase(db)
+		pruneService.SetDatabase(db)
+		snapshotService.SetDatabase(db)
+		statementService.SetDatabase(db)
+		balanceService.SetDatabase(db)
+		sessionService.SetDatabase(db)
+		usageService.SetDatabase(db)
+		auditService.SetDatabase(db)
+		deviceService.SetDatabase(db)
+		notificationService.SetDatabase(db)
+		rateService.SetDatabase(db)
+		assetService.SetDatabase(db)
+		paymentRequestService.SetDatabase(db)
+		billService.SetDatabase(db)
+		directoryService.SetDatabase(db)
+		handleService.SetDatabase(db)
+		twoFactorService.SetDatabase(db)
+		orgService.SetDatabase(db)
+		configCascade.SetDatabase(db)
+	} else if store != nil {
+		loggingService.SetDatabase(store)
+		pruneService.SetDatabase(store)
+		snapshotService.SetDatabase(store)
+		if repo, ok := store.(database.SessionRepo); ok {
+			sessionService.SetDatabase(repo)
+		}
+		if repo, ok := store.(database.TwoFactorRepo); ok {
+			twoFactorService.SetDatabase(repo)
+		}
+		if repo, ok := store.(database.DeliveryRepo); ok {
+			deliveryService.SetDatabase(repo)
+			log.Println("✅ Delivery tracking connected to database")
+		}
+		if repo, ok := store.(database.WebhookRepo); ok {
+			webhookService.SetDatabase(repo)
+		}
+	}
+
+	// Logs and wallet events are written asynchronously; they are flushed before the stores close
+	n.lc.add(stageQueues, "system and transaction logs", drainTimeout, loggingService.Drain)
+	n.lc.add(stageQueues, "wallet event feed", drainTimeout, eventFeed.Drain)
+	n.lc.add(stageQueues, "notification inbox", drainTimeout, notificationService.Drain)
+	if store != nil {
+		loadCtx, loadCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := loadState(loadCtx, store, walletStore, bc, snapshotService)
+		loadCancel()
+		if err != nil {
+			n.lc.shutdown()
+			return nil, err
+		}
+		// The issuance history also counts faucet grants the chain does not hold,
+		// so it sets the issued supply after the chain is restored
+		if db != nil {
+			supplyService.SetDatabase(db)
+			minerService.SetDatabase(db)
+		} else {
+			minerService.Rebuild()
+		}
+	} else {
+		log.Println("ℹ️  Running in in-memory mode (no database configured)")
+		// Snapshots and the UTXO archive are kept in memory instead
+		memStore := database.NewMemoryStore()
+		snapshotService.SetDatabase(memStore)
+		pruneService.SetDatabase(memStore)
+	}
+
+	// Operational alerts: stalled mining, database down, mempool backlog, zakat failures
+	alertConfig := cfg.Alerts
+	alertManager := alerts.NewManager(alertConfig.Interval, alerts.BuiltinRules(alertConfig, bc, db, zakatService)...)
+	alertManager.SetNotifier(alerts.DeliveryNotifier(deliveryService, alertConfig))
+
+	// Google login is enabled by GOOGLE_CLIENT_ID
+	var googleVerifier *googleauth.Verifier
+	if cfg.GoogleClientID != "" {
+		googleVerifier = googleauth.NewVerifier(cfg.GoogleClientID)
+		log.Println("✅ Google login enabled")
+	}
+
+	// Create API server
+	srv := api.NewServer(bc, walletStore, txService, loggingService, db, store, eventFeed, deliveryService, walletTypeService, alertManager, usageService, twoFactorService, sessionService, googleVerifier, orgService, configCascade, signingService, eventHub, statementService, balanceService, kycService, webhookService, faucetService, supplyService, pruneService, snapshotService, inheritanceService, campaignService, charityService, zakatService, auditService, deviceService, notificationService, rateService, assetService, paymentRequestService, billService, directoryService, handleService, minerService)
+	srv.SetAdminKey(cfg.AdminAPIKey)
+	srv.SetAdminEmail(cfg.AdminEmail)
+	srv.SetSandbox(cfg.Sandbox, cfg.ChainReset)
+	srv.SetReadinessLimits(api.ReadinessLimits{MaxBlockAge: cfg.Alerts.MaxBlockAge, MempoolThreshold: cfg.Alerts.MempoolThreshold})
+
+	// OTP limits, and the key codes are hashed with
+	otp.SetPolicy(cfg.OTP)
+	if cfg.OTPSecret != "" {
+		otp.SetSecret([]byte(cfg.OTPSecret))
+	} else if cfg.RedisURL != "" {
+		log.Println("⚠️  OTP_SECRET is not set: codes kept in Redis only verify on the instance that sent them, until it restarts")
+	}
+
+	// OTP codes and sessions are shared through Redis when REDIS_URL is set
+	if redisURL := cfg.RedisURL; redisURL != "" {
+		if redisOpts, err := redis.ParseURL(redisURL); err != nil {
+			log.Printf("⚠️  Ignoring invalid REDIS_URL: %v", err)
+		} else {
+			client := redis.NewClient(redisOpts)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := client.Ping(ctx).Err()
+			cancel()
+			if err != nil {
+				log.Printf("❌ Failed to connect to Redis, keeping OTP codes and sessions in memory: %v", err)
+				client.Close()
+			} else {
+				n.lc.add(stageStores, "redis", storeCloseTimeout, func(context.Context) error { return client.Close() })
+				otp.SetStore(otp.NewRedisStore(client))
+				sessionService.SetStore(services.NewRedisSessionStore(client))
+				log.Println("✅ OTP codes and sessions stored in Redis")
+			}
+		}
+	}
+
+	n.bc = bc
+	n.wallets = walletStore
+	n.db = db
+	n.store = store
+	n.srv = srv
+	n.handler = srv.Router()
+	n.alertManager = alertManager
+	n.transactions = txService
+	n.logs = loggingService
+	n.feed = eventFeed
+	n.deliveries = deliveryService
+	n.zakat = zakatService
+	n.statements = statementService
+	n.balances = balanceService
+	n.consolidation = consolidationService
+	n.inheritance = inheritanceService
+	n.faucet = faucetService
+	n.supply = supplyService
+	n.miners = minerService
+	n.snapshots = snapshotService
+	n.pruner = pruneService
+	n.sessions = sessionService
+	n.usage = usageService
+	n.audit = auditService
+	n.rates = rateService
+	n.orgs = orgService
+	return n, nil
+}
+
+// Start runs the background services and starts serving the REST API on
+// PORT and the gRPC API on GRPC_PORT, or on the listeners in Options. A
+// server that fails later is reported on Failed.
+func (n *Node) Start() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.started {
+		return errors.New("node already started")
+	}
+
+	// Bind to 0.0.0.0 for cloud deployments (Render, Heroku, etc.)
+	httpListener, grpcListener := n.opts.HTTPListener, n.opts.GRPCListener
+	if httpListener == nil {
+		l, err := net.Listen("tcp", "0.0.0.0:"+n.cfg.Port)
+		if err != nil {
+			return fmt.Errorf("failed to listen for HTTP: %w", err)
+		}
+		httpListener = l
+	}
+	if grpcListener == nil {
+		l, err := net.Listen("tcp", "0.0.0.0:"+n.cfg.GRPCPort)
+		if err != nil {
+			if n.opts.HTTPListener == nil {
+				httpListener.Close()
+			}
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		grpcListener = l
+	}
+	n.started = true
+	n.httpAddr, n.grpcAddr = httpListener.Addr(), grpcListener.Addr()
+
+	n.alertManager.Start()
+	n.lc.addFunc(stageServices, "alerts", serviceStopTimeout, n.alertManager.Stop)
+
+	// API usage telemetry, flushed to the database every minute
+	n.usage.Start(time.Minute)
+	n.lc.addFunc(stageServices, "usage telemetry", serviceStopTimeout, n.usage.Stop)
+
+	// Audit log of state-changing API calls, written to the database in batches
+	n.audit.Start(services.AuditFlushInterval)
+	n.lc.addFunc(stageQueues, "audit log", drainTimeout, n.audit.Stop)
+
+	// Stored balances are checked against the persisted UTXOs periodically
+	n.balances.Start()
+	n.lc.addFunc(stageServices, "balance repair", serviceStopTimeout, n.balances.Stop)
+
+	// Wallets holding many dust outputs are consolidated when CONSOLIDATE_DUST_THRESHOLD is set
+	n.consolidation.Start()
+	n.lc.addFunc(stageServices, "dust consolidation", serviceStopTimeout, n.consolidation.Stop)
+	n.inheritance.Start()
+	n.lc.addFunc(stageServices, "inheritance checker", serviceStopTimeout, n.inheritance.Stop)
+
+	// The chain state is snapshotted every SNAPSHOT_INTERVAL_MINUTES for fast restarts
+	n.snapshots.Start()
+	n.lc.addFunc(stageServices, "chain snapshots", serviceStopTimeout, n.snapshots.Stop)
+
+	// Spent UTXOs older than PRUNE_KEEP_BLOCKS are archived every PRUNE_INTERVAL_MINUTES when set
+	n.pruner.Start()
+	n.lc.addFunc(stageServices, "UTXO pruning", serviceStopTimeout, n.pruner.Stop)
+
+	// Exchange rates are polled from RATES_FEED_URL when set, or set by admins
+	n.rates.Start()
+	n.lc.addFunc(stageServices, "exchange rate feed", serviceStopTimeout, n.rates.Stop)
+
+	// Start Zakat scheduler
+	// Zakat Rules:
+	// - Only applies to wallets with balance >= 500 (Nisab threshold)
+	// - Deducts 2.5% every 30 days
+	// - Checks every 24 hours (configurable in zakat_service.go)
+	// - For testing, change ticker to 5 * time.Minute in zakat_service.go
+	n.zakat.Start()
+	n.lc.addFunc(stageServices, "zakat scheduler", zakatStopTimeout, n.zakat.Stop)
+
+	// Monthly statements go out on the 1st to wallets that opted in on their profile
+	n.statements.Start()
+	n.lc.addFunc(stageServices, "statement scheduler", serviceStopTimeout, n.statements.Stop)
+
+	// Blocks queued with POST /api/mine are mined by a worker off the request
+	// path, which also produces the validators' blocks under proof of authority
+	if n.bc.NextProducer() != "" {
+		if a, ok := n.bc.ActiveConsensus().(blockchain.Authority); ok {
+			for _, id := range a.Validators {
+				if _, exists := n.wallets.Get(id); !exists {
+					slog.Warn("Validator wallet not found; its turns pass to the next validator until it is created", "wallet_id", id)
+				}
+			}
+		}
+		n.srv.ProduceBlocks(n.cfg.Consensus.BlockInterval)
+	}
+	n.lc.addFunc(stageServices, "mining jobs", serviceStopTimeout, n.srv.StopMining)
+
+	n.lc.addFunc(stageServices, "OTP cleanup", serviceStopTimeout, otp.StartCleanupTask())
+	log.Println("✅ OTP cleanup task started")
+
+	httpServer := &http.Server{
+		Handler:        n.handler,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+	// gRPC API shares the same chain, wallets and services as the REST API
+	grpcServer := n.srv.GRPCServer()
+	n.lc.add(stageServers, "HTTP server", serverStopTimeout, httpServer.Shutdown)
+	n.lc.add(stageServers, "gRPC server", serverStopTimeout, func(ctx context.Context) error {
+		return stopGRPC(ctx, grpcServer)
+	})
+
+	go func() {
+		slog.Info("🚀 gRPC server listening", "addr", n.grpcAddr.String())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			n.failed <- fmt.Errorf("gRPC server: %w", err)
+		}
+	}()
+	go func() {
+		slog.Info("🚀 Blockchain Wallet Server listening", "addr", n.httpAddr.String())
+		log.Println("📡 API endpoints available at /api")
+		if err := httpServer.Serve(httpListener); err != http.ErrServerClosed {
+			n.failed <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPC lets the calls in progress finish, until ctx is done
+func stopGRPC(ctx context.Context, s *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// Cut off the streams still open
+		s.Stop()
+		return ctx.Err()
+	}
+}
+
+// Failed receives the error of a server that stopped serving on its own
+func (n *Node) Failed() <-chan error {
+	return n.failed
+}
+
+// Stop shuts the node down in stages: the servers, the background services,
+// the queued writes and then the stores it opened. It can be called whether
+// or not the node was started, and only the first call does anything.
+func (n *Node) Stop() error {
+	n.stopOnce.Do(func() {
+		n.stopErr = n.lc.shutdown()
+	})
+	return n.stopErr
+}
+
+// Run starts the node and stops it when ctx is done or a server fails
+func (n *Node) Run(ctx context.Context) error {
+	if err := n.Start(); err != nil {
+		n.Stop()
+		return err
+	}
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-n.failed:
+	}
+	log.Println("Shutting down server...")
+	if stopErr := n.Stop(); err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+// Config returns the configuration the node was built with
+func (n *Node) Config() *config.Config { return n.cfg }
+
+// Handler serves the REST API, for in-process callers and httptest
+func (n *Node) Handler() http.Handler { return n.handler }
+
+// Server returns the API server behind Handler
+func (n *Node) Server() *api.Server { return n.srv }
+
+// HTTPAddr returns where the REST API is served, once started
+func (n *Node) HTTPAddr() net.Addr { return n.httpAddr }
+
+// GRPCAddr returns where the gRPC API is served, once started
+func (n *Node) GRPCAddr() net.Addr { return n.grpcAddr }
+
+// Blockchain returns the chain, its pending pool and UTXO set
+func (n *Node) Blockchain() *blockchain.Blockchain { return n.bc }
+
+// Wallets returns the wallets in memory
+func (n *Node) Wallets() *wallet.Store { return n.wallets }
+
+// Store returns where the wallets, the chain and the logs persist, or nil in
+// in-memory mode
+func (n *Node) Store() database.Store { return n.store }
+
+// DB returns the Postgres database, or nil when the node runs without one
+func (n *Node) DB() *database.DB { return n.db }
+
+// Transactions returns the service creating and validating transactions
+func (n *Node) Transactions() *services.TransactionService { return n.transactions }
+
+// Logs returns the system and transaction log
+func (n *Node) Logs() *services.LoggingService { return n.logs }
+
+// Events returns the wallet event feed
+func (n *Node) Events() *events.Feed { return n.feed }
+
+// Deliveries returns the outbound webhook and email queue
+func (n *Node) Deliveries() *services.DeliveryService { return n.deliveries }
+
+// Zakat returns the zakat scheduler
+func (n *Node) Zakat() *services.ZakatService { return n.zakat }
+
+// Faucet returns the faucet
+func (n *Node) Faucet() *services.FaucetService { return n.faucet }
+
+// Supply returns the issuance history
+func (n *Node) Supply() *services.SupplyService { return n.supply }
+
+// Miners returns the blocks each wallet has mined
+func (n *Node) Miners() *services.MinerService { return n.miners }
+
+// Snapshots returns the chain snapshotter
+func (n *Node) Snapshots() *services.SnapshotService { return n.snapshots }
+
+// Sessions returns the login sessions
+func (n *Node) Sessions() *services.SessionService { return n.sessions }
+
+// Orgs returns the tenants of a multi-tenant node
+func (n *Node) Orgs() *services.OrgService { return n.orgs }
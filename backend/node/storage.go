@@ -0,0 +1,176 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/config"
+	"blockchain-backend/database"
+	"blockchain-backend/services"
+	"blockchain-backend/wallet"
+)
+
+// loadState fills memory from store at startup: the wallets, the chain
+// rebuilt from its latest snapshot, the UTXOs the chain does not hold and the
+// wallet nonces. Only a chain this version cannot read is an error; anything
+// else missing is logged and left out.
+func loadState(ctx context.Context, store database.Store, walletStore *wallet.Store, bc *blockchain.Blockchain, snapshots *services.SnapshotService) error {
+	// Load wallets (ignore prepared statement errors from transaction pooler)
+	wallets, err := store.GetAllWallets(ctx)
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		log.Printf("⚠️  Failed to load wallets from database: %v", err)
+	} else if err == nil {
+		for _, w := range wallets {
+			wlt := wallet.Wallet{
+				WalletID:   w["wallet_id"].(string),
+				PublicKey:  w["public_key"].(string),
+				PrivateKey: w["private_key_encrypted"].(string),
+			}
+			if fullName, ok := w["full_name"].(string); ok {
+				wlt.FullName = fullName
+			}
+			if email, ok := w["email"].(string); ok {
+				wlt.Email = email
+			}
+			if walletType, ok := w["wallet_type"].(string); ok {
+				wlt.Type = walletType
+			}
+			if orgID, ok := w["org_id"].(string); ok {
+				wlt.OrgID = orgID
+			}
+			if monthly, ok := w["monthly_statements"].(bool); ok {
+				wlt.MonthlyStatements = monthly
+			}
+			if frozen, ok := w["frozen"].(bool); ok {
+				wlt.Frozen = frozen
+				wlt.FrozenReason, _ = w["frozen_reason"].(string)
+			}
+			wlt.MaxTxAmount, _ = w["max_tx_amount"].(uint64)
+			wlt.MaxDailyAmount, _ = w["max_daily_amount"].(uint64)
+			wlt.Label, _ = w["label"].(string)
+			wlt.Status, _ = w["status"].(string)
+			wlt.RotatedFrom, _ = w["rotated_from"].(string)
+			wlt.RotatedTo, _ = w["rotated_to"].(string)
+			walletStore.Save(wlt)
+		}
+		log.Printf("✅ Loaded %d wallets from database", len(wallets))
+	} else {
+		log.Println("✅ Loaded 0 wallets from database (transaction pooler mode)")
+	}
+
+	// Rebuild the chain from the latest snapshot and the blocks mined after it
+	if restored, err := snapshots.Restore(ctx); errors.Is(err, blockchain.ErrWholeCoinChain) {
+		// Migration 0019_amount_units rescales such a database before it gets here
+		return fmt.Errorf("%w; amounts are now kept in units of 10^-%d coin, so apply migration 0019_amount_units first (go run ./cmd/migrate up)", err, blockchain.Decimals)
+	} else if err != nil {
+		log.Printf("⚠️  Failed to restore the chain, starting from a new genesis block: %v", err)
+	} else if restored.SnapshotHeight >= 0 {
+		log.Printf("✅ Restored %d blocks from the snapshot at block %d, replaying %d", restored.Blocks, restored.SnapshotHeight, restored.Replayed)
+	} else {
+		log.Printf("✅ Restored %d blocks, replaying %d", restored.Blocks, restored.Replayed)
+	}
+
+	// Load UTXOs (ignore prepared statement errors from transaction pooler)
+	utxos, err := store.GetAllUTXOs(ctx)
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		log.Printf("⚠️  Failed to load UTXOs from database: %v", err)
+	} else if err == nil {
+		// The restored chain already holds its outputs; this adds
+		// faucet grants and spends it does not know. Height is
+		// left at 0, so those count as fully confirmed
+		loaded := make([]blockchain.UTXO, 0, len(utxos))
+		for _, u := range utxos {
+			loaded = append(loaded, blockchain.UTXO{
+				ID:       u["id"].(string),
+				Owner:    u["owner"].(string),
+				AssetID:  u["asset_id"].(string),
+				Amount:   u["amount"].(uint64),
+				OriginTx: u["origin_tx"].(string),
+				Index:    u["index"].(int),
+				Spent:    u["spent"].(bool),
+			})
+		}
+		bc.RestoreUTXOs(loaded)
+		log.Printf("✅ Loaded %d UTXOs from database", len(utxos))
+	} else {
+		log.Println("✅ Loaded 0 UTXOs from database (transaction pooler mode)")
+	}
+
+	// Wallet nonces, so signatures from before the restart cannot be replayed
+	if nonces, err := store.GetWalletNonces(ctx); err != nil {
+		log.Printf("⚠️  Failed to load wallet nonces from database: %v", err)
+	} else {
+		for walletID, n := range nonces {
+			bc.RestoreNonce(walletID, n)
+		}
+	}
+	return nil
+}
+
+// openPostgres connects to the Postgres database in cfg, applies the schema
+// and ensures its indexes. It returns nil when any of that fails, and the
+// node runs without the database.
+func openPostgres(cfg *config.Config) *database.DB {
+	log.Println("Attempting to connect to Supabase database...")
+	db, err := database.NewDBInSchema(cfg.Storage.DatabaseURL, cfg.Storage.Schema)
+	if err != nil {
+		log.Printf("❌ Failed to connect to database: %v", err)
+		log.Println("⚠️  Running in in-memory mode")
+		log.Println("💡 Please check:")
+		log.Println("   - Supabase project is active and not paused")
+		log.Println("   - Database URL in .env is correct")
+		log.Println("   - Network connectivity to Supabase")
+		return nil
+	}
+	log.Println("✅ Connected to Supabase database")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Test the connection
+	if err := db.Ping(ctx); err != nil {
+		log.Printf("❌ Database ping failed: %v", err)
+		log.Println("⚠️  Running in in-memory mode")
+		db.Close()
+		return nil
+	}
+	log.Println("✅ Database connection verified")
+	applied, err := db.InitSchema(ctx, cfg.Storage.AutoMigrate)
+	if err != nil {
+		log.Printf("❌ Failed to initialize schema: %v", err)
+		log.Println("⚠️  Running in in-memory mode")
+		db.Close()
+		return nil
+	}
+	for _, m := range applied {
+		log.Printf("✅ Applied schema migration %04d_%s", m.Version, m.Name)
+	}
+	log.Println("✅ Database schema initialized successfully")
+
+	// Composite indexes for the heavy per-wallet endpoints
+	if created, err := db.EnsureIndexes(ctx); err != nil {
+		log.Printf("⚠️  Failed to ensure indexes: %v", err)
+	} else if len(created) > 0 {
+		log.Printf("✅ Created missing indexes: %s", strings.Join(created, ", "))
+	}
+	return db
+}
+
+// openSQLite opens the SQLite file in cfg, or returns nil when it cannot
+func openSQLite(cfg *config.Config) *database.SQLiteStore {
+	path := cfg.Storage.SQLitePath
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sqlite, err := database.OpenSQLite(ctx, path)
+	if err != nil {
+		log.Printf("❌ Failed to open SQLite database %s: %v", path, err)
+		log.Println("⚠️  Running in in-memory mode")
+		return nil
+	}
+	log.Printf("✅ Using SQLite database %s", path)
+	return sqlite
+}
@@ -1,7 +1,12 @@
 package otp
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -9,20 +14,135 @@ import (
 	"time"
 )
 
-// OTPStore stores OTPs temporarily
-type OTPStore struct {
-	mu   sync.RWMutex
-	otps map[string]OTPData
+// Default OTP limits
+const (
+	DefaultCodeTTL         = 5 * time.Minute  // how long a code stays valid
+	DefaultMaxAttempts     = 5                // wrong codes before the code is discarded
+	DefaultLockoutDuration = 15 * time.Minute // how long the email then gets no codes
+	DefaultResendCooldown  = time.Minute      // wait between codes for one email
+	DefaultDailyLimit      = 10               // codes per email per 24 hours
+)
+
+// SendWindow is the period DailyLimit counts codes over, from the first code
+const SendWindow = 24 * time.Hour
+
+// Errors returned when a code is refused
+var (
+	ErrLocked     = errors.New("too many failed verifications")
+	ErrCooldown   = errors.New("a code was sent too recently")
+	ErrDailyLimit = errors.New("too many codes sent today")
+)
+
+// LimitError says which limit refused a code and when it ends
+type LimitError struct {
+	Err   error // ErrLocked, ErrCooldown or ErrDailyLimit
+	Until time.Time
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: try again at %s", e.Err, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Policy is how codes are issued and checked
+type Policy struct {
+	CodeTTL         time.Duration `json:"code_ttl"`
+	MaxAttempts     int           `json:"max_attempts"`
+	LockoutDuration time.Duration `json:"lockout_duration"` // 0 discards the code without a lockout
+	ResendCooldown  time.Duration `json:"resend_cooldown"`  // 0 allows codes back to back
+	DailyLimit      int           `json:"daily_limit"`      // 0 for no limit
+}
+
+// DefaultPolicy returns the default limits
+func DefaultPolicy() Policy {
+	return Policy{
+		CodeTTL:         DefaultCodeTTL,
+		MaxAttempts:     DefaultMaxAttempts,
+		LockoutDuration: DefaultLockoutDuration,
+		ResendCooldown:  DefaultResendCooldown,
+		DailyLimit:      DefaultDailyLimit,
+	}
 }
 
 type OTPData struct {
-	Code      string
+	Hash      string // HMAC of the email and code; the code itself is not kept
+	SentAt    time.Time
 	ExpiresAt time.Time
 	Verified  bool
+	Attempts  int // wrong codes entered for this code
+}
+
+// current holds the codes, limits and hashing key; an in-memory store and
+// a random key unless replaced
+var (
+	mu      sync.RWMutex
+	current Store = NewMemoryStore()
+	policy        = DefaultPolicy()
+	secret        = randomSecret()
+)
+
+func randomSecret() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("otp: generate key: %v", err))
+	}
+	return key
+}
+
+// SetStore replaces where codes are kept, for example with a RedisStore so
+// they survive restarts and are shared by every instance
+func SetStore(s Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+// SetPolicy replaces the limits codes are issued and checked with
+func SetPolicy(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	policy = p
+}
+
+// CurrentPolicy returns the limits codes are issued and checked with
+func CurrentPolicy() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return policy
+}
+
+// SetSecret replaces the key codes are hashed with. Without it a random key
+// is used, so codes stored in Redis only verify on the instance that sent
+// them and do not survive restarts.
+func SetSecret(key []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	secret = append([]byte(nil), key...)
+}
+
+func getStore() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+func storeCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 3*time.Second)
 }
 
-var store = &OTPStore{
-	otps: make(map[string]OTPData),
+// hashCode binds a code to its email, so a stored hash cannot be replayed
+// for another email
+func hashCode(email, code string) string {
+	mu.RLock()
+	mac := hmac.New(sha256.New, secret)
+	mu.RUnlock()
+	mac.Write([]byte(email))
+	mac.Write([]byte{0})
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // GenerateOTP generates a 6-digit OTP
@@ -35,88 +155,168 @@ func GenerateOTP() string {
 	return fmt.Sprintf("%06d", n.Int64())
 }
 
-// StoreOTP stores an OTP for an email
-func StoreOTP(email string) string {
-	store.mu.Lock()
-	defer store.mu.Unlock()
+// StoreOTP stores a new OTP for an email, replacing any earlier one. A
+// locked out email, one sent a code within the resend cooldown or one over
+// its daily limit gets a *LimitError.
+func StoreOTP(email string) (string, error) {
+	ctx, cancel := storeCtx()
+	defer cancel()
+	s := getStore()
+	p := CurrentPolicy()
+	now := time.Now()
+
+	until, err := s.LockedUntil(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if now.Before(until) {
+		return "", &LimitError{Err: ErrLocked, Until: until}
+	}
+
+	sends, err := s.Sends(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if next := sends.Last.Add(p.ResendCooldown); p.ResendCooldown > 0 && now.Before(next) {
+		return "", &LimitError{Err: ErrCooldown, Until: next}
+	}
+	if p.DailyLimit > 0 && sends.Count >= p.DailyLimit && now.Before(sends.WindowEnds) {
+		return "", &LimitError{Err: ErrDailyLimit, Until: sends.WindowEnds}
+	}
 
 	code := GenerateOTP()
-	store.otps[email] = OTPData{
-		Code:      code,
-		ExpiresAt: time.Now().Add(5 * time.Minute), // Valid for 5 minutes
-		Verified:  false,
+	data := OTPData{
+		Hash:      hashCode(email, code),
+		SentAt:    now,
+		ExpiresAt: now.Add(p.CodeTTL),
+	}
+	if err := s.Save(ctx, email, data); err != nil {
+		return "", err
+	}
+	if err := s.RecordSend(ctx, email, now, SendWindow); err != nil {
+		log.Printf("⚠️  Failed to record OTP send for %s: %v", email, err)
 	}
 
-	log.Printf("OTP generated for %s: %s (expires in 5 minutes)", email, code)
-	return code
+	log.Printf("OTP generated for %s (expires in %s)", email, p.CodeTTL)
+	return code, nil
 }
 
-// VerifyOTP verifies an OTP for an email
+// VerifyOTP verifies an OTP for an email. The MaxAttempts-th wrong code
+// discards the code and locks the email out for LockoutDuration.
 func VerifyOTP(email, code string) bool {
-	store.mu.Lock()
-	defer store.mu.Unlock()
+	ctx, cancel := storeCtx()
+	defer cancel()
+	s := getStore()
+	p := CurrentPolicy()
 
-	data, exists := store.otps[email]
+	if until, err := s.LockedUntil(ctx, email); err != nil || time.Now().Before(until) {
+		return false
+	}
+	data, exists, err := s.Load(ctx, email)
+	if err != nil {
+		log.Printf("⚠️  Failed to load OTP for %s: %v", email, err)
+		return false
+	}
 	if !exists {
 		return false
 	}
 
 	if time.Now().After(data.ExpiresAt) {
-		delete(store.otps, email)
+		s.Delete(ctx, email)
 		return false
 	}
 
-	if data.Code != code {
+	if !hmac.Equal([]byte(data.Hash), []byte(hashCode(email, code))) {
+		attempts, err := s.Fail(ctx, email)
+		if err != nil {
+			log.Printf("⚠️  Failed to count OTP attempt for %s: %v", email, err)
+			return false
+		}
+		if attempts >= p.MaxAttempts {
+			s.Delete(ctx, email)
+			if p.LockoutDuration > 0 {
+				if err := s.Lock(ctx, email, time.Now().Add(p.LockoutDuration)); err != nil {
+					log.Printf("⚠️  Failed to lock out %s: %v", email, err)
+				}
+				log.Printf("🔒 OTP locked for %s after %d failed verifications", email, attempts)
+			}
+		}
 		return false
 	}
 
 	// Mark as verified
 	data.Verified = true
-	store.otps[email] = data
+	if err := s.Save(ctx, email, data); err != nil {
+		log.Printf("⚠️  Failed to save OTP for %s: %v", email, err)
+		return false
+	}
 	return true
 }
 
+// LockedUntil returns when an email's lockout ends, and false when it is not
+// locked out
+func LockedUntil(email string) (time.Time, bool) {
+	ctx, cancel := storeCtx()
+	defer cancel()
+	until, err := getStore().LockedUntil(ctx, email)
+	if err != nil || !time.Now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
 // IsVerified checks if an email has been verified
 func IsVerified(email string) bool {
-	store.mu.RLock()
-	defer store.mu.RUnlock()
-
-	data, exists := store.otps[email]
-	if !exists {
+	ctx, cancel := storeCtx()
+	defer cancel()
+	data, exists, err := getStore().Load(ctx, email)
+	if err != nil || !exists {
 		return false
 	}
-
 	return data.Verified
 }
 
 // ClearOTP removes an OTP from storage
 func ClearOTP(email string) {
-	store.mu.Lock()
-	defer store.mu.Unlock()
-	delete(store.otps, email)
+	ctx, cancel := storeCtx()
+	defer cancel()
+	if err := getStore().Delete(ctx, email); err != nil {
+		log.Printf("⚠️  Failed to clear OTP for %s: %v", email, err)
+	}
 }
 
-// CleanupExpired removes expired OTPs (should be run periodically)
+// CleanupExpired removes expired OTPs, lockouts and send counts (should be
+// run periodically)
 func CleanupExpired() {
-	store.mu.Lock()
-	defer store.mu.Unlock()
-
-	now := time.Now()
-	for email, data := range store.otps {
-		if now.After(data.ExpiresAt) {
-			delete(store.otps, email)
-		}
+	ctx, cancel := storeCtx()
+	defer cancel()
+	if err := getStore().Cleanup(ctx); err != nil {
+		log.Printf("⚠️  OTP cleanup failed: %v", err)
 	}
 }
 
-// StartCleanupTask starts a background task to clean expired OTPs
-func StartCleanupTask() {
+// StartCleanupTask starts a background task to clean expired OTPs. The
+// returned stop ends it, waiting for a cleanup in progress.
+func StartCleanupTask() (stop func()) {
+	done := make(chan struct{})
+	var loop sync.WaitGroup
+	loop.Add(1)
 	go func() {
+		defer loop.Done()
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
 
-		for range ticker.C {
-			CleanupExpired()
+		for {
+			select {
+			case <-ticker.C:
+				CleanupExpired()
+			case <-done:
+				return
+			}
 		}
 	}()
+	return func() {
+		close(done)
+		loop.Wait()
+	}
 }
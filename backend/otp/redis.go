@@ -0,0 +1,137 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps codes in Redis, so they survive restarts and every
+// instance behind a load balancer sees the same codes. Each code is a hash
+// expiring with the code; a lockout is a key expiring with the lockout, and
+// the codes sent to an email a hash expiring with its window.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "otp:"}
+}
+
+func (r *RedisStore) codeKey(email string) string { return r.prefix + "code:" + email }
+func (r *RedisStore) lockKey(email string) string { return r.prefix + "lock:" + email }
+func (r *RedisStore) sendsKey(email string) string { return r.prefix + "sends:" + email }
+
+func (r *RedisStore) Save(ctx context.Context, email string, data OTPData) error {
+	key := r.codeKey(email)
+	_, err := r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, key)
+		p.HSet(ctx, key,
+			"hash", data.Hash,
+			"sent_at", data.SentAt.UnixNano(),
+			"expires_at", data.ExpiresAt.UnixNano(),
+			"verified", data.Verified,
+			"attempts", data.Attempts,
+		)
+		p.PExpireAt(ctx, key, data.ExpiresAt)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisStore) Load(ctx context.Context, email string) (OTPData, bool, error) {
+	fields, err := r.client.HGetAll(ctx, r.codeKey(email)).Result()
+	if err != nil {
+		return OTPData{}, false, err
+	}
+	if len(fields) == 0 {
+		return OTPData{}, false, nil
+	}
+	sent, _ := strconv.ParseInt(fields["sent_at"], 10, 64)
+	expires, _ := strconv.ParseInt(fields["expires_at"], 10, 64)
+	verified, _ := strconv.ParseBool(fields["verified"])
+	attempts, _ := strconv.Atoi(fields["attempts"])
+	return OTPData{
+		Hash:      fields["hash"],
+		SentAt:    time.Unix(0, sent),
+		ExpiresAt: time.Unix(0, expires),
+		Verified:  verified,
+		Attempts:  attempts,
+	}, true, nil
+}
+
+func (r *RedisStore) Delete(ctx context.Context, email string) error {
+	return r.client.Del(ctx, r.codeKey(email)).Err()
+}
+
+// failScript counts a wrong code only while the code exists, so a code that
+// just expired is not recreated without its fields
+var failScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+end
+return 0
+`)
+
+func (r *RedisStore) Fail(ctx context.Context, email string) (int, error) {
+	return failScript.Run(ctx, r.client, []string{r.codeKey(email)}).Int()
+}
+
+func (r *RedisStore) Lock(ctx context.Context, email string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return r.client.Set(ctx, r.lockKey(email), until.UnixNano(), ttl).Err()
+}
+
+func (r *RedisStore) LockedUntil(ctx context.Context, email string) (time.Time, error) {
+	n, err := r.client.Get(ctx, r.lockKey(email)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n), nil
+}
+
+func (r *RedisStore) Sends(ctx context.Context, email string) (SendHistory, error) {
+	fields, err := r.client.HGetAll(ctx, r.sendsKey(email)).Result()
+	if err != nil || len(fields) == 0 {
+		return SendHistory{}, err
+	}
+	last, _ := strconv.ParseInt(fields["last"], 10, 64)
+	count, _ := strconv.Atoi(fields["count"])
+	ends, _ := strconv.ParseInt(fields["window_ends"], 10, 64)
+	return SendHistory{
+		Last:       time.Unix(0, last),
+		Count:      count,
+		WindowEnds: time.Unix(0, ends),
+	}, nil
+}
+
+// sendScript counts a code, starting the window with the first one
+var sendScript = redis.NewScript(`
+local n = redis.call('HINCRBY', KEYS[1], 'count', 1)
+redis.call('HSET', KEYS[1], 'last', ARGV[1])
+if n == 1 then
+	redis.call('HSET', KEYS[1], 'window_ends', ARGV[2])
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return n
+`)
+
+func (r *RedisStore) RecordSend(ctx context.Context, email string, at time.Time, window time.Duration) error {
+	return sendScript.Run(ctx, r.client, []string{r.sendsKey(email)},
+		at.UnixNano(), at.Add(window).UnixNano(), window.Milliseconds()).Err()
+}
+
+// Cleanup does nothing: Redis expires codes, lockouts and windows itself
+func (r *RedisStore) Cleanup(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,148 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SendHistory is how many codes an email was sent in its current window
+type SendHistory struct {
+	Last       time.Time // zero when none was sent
+	Count      int
+	WindowEnds time.Time
+}
+
+// Store keeps the OTP of each email, the codes it was sent recently and the
+// lockouts of emails with too many wrong codes
+type Store interface {
+	// Save replaces the email's code
+	Save(ctx context.Context, email string, data OTPData) error
+	// Load returns the email's code, and false when it has none
+	Load(ctx context.Context, email string) (OTPData, bool, error)
+	Delete(ctx context.Context, email string) error
+	// Fail counts a wrong code against the email's code and returns the
+	// attempts so far, 0 when it has no code
+	Fail(ctx context.Context, email string) (int, error)
+	// Lock refuses codes for the email until the given time
+	Lock(ctx context.Context, email string, until time.Time) error
+	// LockedUntil returns when the email's lockout ends, zero when it has none
+	LockedUntil(ctx context.Context, email string) (time.Time, error)
+	// Sends returns the email's codes in its current window
+	Sends(ctx context.Context, email string) (SendHistory, error)
+	// RecordSend counts a code sent at the given time, starting a new window
+	// of the given length when the last one ended
+	RecordSend(ctx context.Context, email string, at time.Time, window time.Duration) error
+	// Cleanup drops expired codes, lockouts and windows; stores that expire entries
+	// themselves do nothing
+	Cleanup(ctx context.Context) error
+}
+
+// MemoryStore keeps codes in this process, so they are lost on restart and
+// not shared between instances
+type MemoryStore struct {
+	mu    sync.Mutex
+	otps  map[string]OTPData
+	locks map[string]time.Time
+	sends map[string]SendHistory
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		otps:  make(map[string]OTPData),
+		locks: make(map[string]time.Time),
+		sends: make(map[string]SendHistory),
+	}
+}
+
+func (m *MemoryStore) Save(ctx context.Context, email string, data OTPData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.otps[email] = data
+	return nil
+}
+
+func (m *MemoryStore) Load(ctx context.Context, email string) (OTPData, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.otps[email]
+	return data, ok, nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.otps, email)
+	return nil
+}
+
+func (m *MemoryStore) Fail(ctx context.Context, email string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.otps[email]
+	if !ok {
+		return 0, nil
+	}
+	data.Attempts++
+	m.otps[email] = data
+	return data.Attempts, nil
+}
+
+func (m *MemoryStore) Lock(ctx context.Context, email string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.locks[email] = until
+	return nil
+}
+
+func (m *MemoryStore) LockedUntil(ctx context.Context, email string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.locks[email], nil
+}
+
+func (m *MemoryStore) Sends(ctx context.Context, email string) (SendHistory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.sends[email]
+	if time.Now().After(h.WindowEnds) {
+		return SendHistory{}, nil
+	}
+	return h, nil
+}
+
+func (m *MemoryStore) RecordSend(ctx context.Context, email string, at time.Time, window time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.sends[email]
+	if at.After(h.WindowEnds) {
+		h = SendHistory{WindowEnds: at.Add(window)}
+	}
+	h.Last = at
+	h.Count++
+	m.sends[email] = h
+	return nil
+}
+
+func (m *MemoryStore) Cleanup(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for email, data := range m.otps {
+		if now.After(data.ExpiresAt) {
+			delete(m.otps, email)
+		}
+	}
+	for email, until := range m.locks {
+		if now.After(until) {
+			delete(m.locks, email)
+		}
+	}
+	for email, h := range m.sends {
+		if now.After(h.WindowEnds) {
+			delete(m.sends, email)
+		}
+	}
+	return nil
+}
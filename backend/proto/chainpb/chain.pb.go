@@ -0,0 +1,959 @@
+// Chain export format, version 1: the protobuf encoding of the files served
+// by GET /api/chain/export?format=protobuf and loaded by
+// POST /api/admin/chain/import. A file is a stream of Record messages, each
+// preceded by its size as a varint (the framing of Go's protodelim and
+// Java's writeDelimitedTo): one header, every block from genesis up, then
+// the snapshot of the tip. A stream without the snapshot was cut short.
+// The JSON encoding holds the same records, one per line.
+//
+// Regenerate the Go code after editing (from the backend directory):
+//   protoc --go_out=. --go_opt=module=blockchain-backend proto/chain.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: proto/chain.proto
+
+package chainpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Record struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Record:
+	//
+	//	*Record_Header
+	//	*Record_Block
+	//	*Record_Snapshot
+	Record        isRecord_Record `protobuf_oneof:"record"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_proto_chain_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Record) GetRecord() isRecord_Record {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Record) GetHeader() *Header {
+	if x != nil {
+		if x, ok := x.Record.(*Record_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *Record) GetBlock() *Block {
+	if x != nil {
+		if x, ok := x.Record.(*Record_Block); ok {
+			return x.Block
+		}
+	}
+	return nil
+}
+
+func (x *Record) GetSnapshot() *Snapshot {
+	if x != nil {
+		if x, ok := x.Record.(*Record_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+type isRecord_Record interface {
+	isRecord_Record()
+}
+
+type Record_Header struct {
+	Header *Header `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type Record_Block struct {
+	Block *Block `protobuf:"bytes,2,opt,name=block,proto3,oneof"`
+}
+
+type Record_Snapshot struct {
+	Snapshot *Snapshot `protobuf:"bytes,3,opt,name=snapshot,proto3,oneof"`
+}
+
+func (*Record_Header) isRecord_Record() {}
+
+func (*Record_Block) isRecord_Record() {}
+
+func (*Record_Snapshot) isRecord_Record() {}
+
+// Header describes the chain that follows
+type Header struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "chain-export"
+	Format string `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	// 1
+	Version int32  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Height  int64  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	TipHash string `protobuf:"bytes,4,opt,name=tip_hash,json=tipHash,proto3" json:"tip_hash,omitempty"`
+	// pow or poa, with the difficulty or the validators the blocks were
+	// produced under
+	Consensus  string   `protobuf:"bytes,5,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	Difficulty string   `protobuf:"bytes,6,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Validators []string `protobuf:"bytes,7,rep,name=validators,proto3" json:"validators,omitempty"`
+	// Unix nanoseconds
+	CreatedAt     int64 `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Header) Reset() {
+	*x = Header{}
+	mi := &file_proto_chain_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Header) ProtoMessage() {}
+
+func (x *Header) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Header.ProtoReflect.Descriptor instead.
+func (*Header) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Header) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Header) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Header) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Header) GetTipHash() string {
+	if x != nil {
+		return x.TipHash
+	}
+	return ""
+}
+
+func (x *Header) GetConsensus() string {
+	if x != nil {
+		return x.Consensus
+	}
+	return ""
+}
+
+func (x *Header) GetDifficulty() string {
+	if x != nil {
+		return x.Difficulty
+	}
+	return ""
+}
+
+func (x *Header) GetValidators() []string {
+	if x != nil {
+		return x.Validators
+	}
+	return nil
+}
+
+func (x *Header) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type Block struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Index         int64                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transactions  []*Transaction         `protobuf:"bytes,4,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	PreviousHash  string                 `protobuf:"bytes,5,opt,name=previous_hash,json=previousHash,proto3" json:"previous_hash,omitempty"`
+	Nonce         int64                  `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Hash          string                 `protobuf:"bytes,7,opt,name=hash,proto3" json:"hash,omitempty"`
+	MerkleRoot    string                 `protobuf:"bytes,8,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	Signer        string                 `protobuf:"bytes,9,opt,name=signer,proto3" json:"signer,omitempty"`
+	Signature     string                 `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_proto_chain_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Block) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Block) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Block) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Block) GetPreviousHash() string {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return ""
+}
+
+func (x *Block) GetNonce() int64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *Block) GetSigner() string {
+	if x != nil {
+		return x.Signer
+	}
+	return ""
+}
+
+func (x *Block) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	SenderId      string                 `protobuf:"bytes,3,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	ReceiverId    string                 `protobuf:"bytes,4,opt,name=receiver_id,json=receiverId,proto3" json:"receiver_id,omitempty"`
+	AssetId       string                 `protobuf:"bytes,5,opt,name=asset_id,json=assetId,proto3" json:"asset_id,omitempty"`
+	Amount        uint64                 `protobuf:"varint,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	Fee           uint64                 `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`
+	Nonce         uint64                 `protobuf:"varint,8,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Note          string                 `protobuf:"bytes,9,opt,name=note,proto3" json:"note,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Pubkey        string                 `protobuf:"bytes,11,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	Signature     string                 `protobuf:"bytes,12,opt,name=signature,proto3" json:"signature,omitempty"`
+	Inputs        []*UTXORef             `protobuf:"bytes,13,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Outputs       []*UTXO                `protobuf:"bytes,14,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	Type          string                 `protobuf:"bytes,15,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_proto_chain_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Transaction) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Transaction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Transaction) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *Transaction) GetReceiverId() string {
+	if x != nil {
+		return x.ReceiverId
+	}
+	return ""
+}
+
+func (x *Transaction) GetAssetId() string {
+	if x != nil {
+		return x.AssetId
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transaction) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Transaction) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Transaction) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *Transaction) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Transaction) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *Transaction) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *Transaction) GetInputs() []*UTXORef {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *Transaction) GetOutputs() []*UTXO {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type UTXORef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Index         int64                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Preimage      string                 `protobuf:"bytes,3,opt,name=preimage,proto3" json:"preimage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UTXORef) Reset() {
+	*x = UTXORef{}
+	mi := &file_proto_chain_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UTXORef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UTXORef) ProtoMessage() {}
+
+func (x *UTXORef) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UTXORef.ProtoReflect.Descriptor instead.
+func (*UTXORef) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UTXORef) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *UTXORef) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *UTXORef) GetPreimage() string {
+	if x != nil {
+		return x.Preimage
+	}
+	return ""
+}
+
+type UTXO struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	AssetId       string                 `protobuf:"bytes,3,opt,name=asset_id,json=assetId,proto3" json:"asset_id,omitempty"`
+	Amount        uint64                 `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	OriginTx      string                 `protobuf:"bytes,5,opt,name=origin_tx,json=originTx,proto3" json:"origin_tx,omitempty"`
+	Index         int64                  `protobuf:"varint,6,opt,name=index,proto3" json:"index,omitempty"`
+	Spent         bool                   `protobuf:"varint,7,opt,name=spent,proto3" json:"spent,omitempty"`
+	Height        int64                  `protobuf:"varint,8,opt,name=height,proto3" json:"height,omitempty"`
+	SpentHeight   int64                  `protobuf:"varint,9,opt,name=spent_height,json=spentHeight,proto3" json:"spent_height,omitempty"`
+	LockTime      int64                  `protobuf:"varint,10,opt,name=lock_time,json=lockTime,proto3" json:"lock_time,omitempty"`
+	HashLock      string                 `protobuf:"bytes,11,opt,name=hash_lock,json=hashLock,proto3" json:"hash_lock,omitempty"`
+	RefundTo      string                 `protobuf:"bytes,12,opt,name=refund_to,json=refundTo,proto3" json:"refund_to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UTXO) Reset() {
+	*x = UTXO{}
+	mi := &file_proto_chain_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UTXO) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UTXO) ProtoMessage() {}
+
+func (x *UTXO) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UTXO.ProtoReflect.Descriptor instead.
+func (*UTXO) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UTXO) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UTXO) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *UTXO) GetAssetId() string {
+	if x != nil {
+		return x.AssetId
+	}
+	return ""
+}
+
+func (x *UTXO) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *UTXO) GetOriginTx() string {
+	if x != nil {
+		return x.OriginTx
+	}
+	return ""
+}
+
+func (x *UTXO) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *UTXO) GetSpent() bool {
+	if x != nil {
+		return x.Spent
+	}
+	return false
+}
+
+func (x *UTXO) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *UTXO) GetSpentHeight() int64 {
+	if x != nil {
+		return x.SpentHeight
+	}
+	return 0
+}
+
+func (x *UTXO) GetLockTime() int64 {
+	if x != nil {
+		return x.LockTime
+	}
+	return 0
+}
+
+func (x *UTXO) GetHashLock() string {
+	if x != nil {
+		return x.HashLock
+	}
+	return ""
+}
+
+func (x *UTXO) GetRefundTo() string {
+	if x != nil {
+		return x.RefundTo
+	}
+	return ""
+}
+
+// Snapshot is the state as of the tip, including the outputs no block
+// creates, such as faucet grants
+type Snapshot struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Version      int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Height       int64                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Hash         string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Issued       uint64                 `protobuf:"varint,4,opt,name=issued,proto3" json:"issued,omitempty"`
+	PrunedHeight int64                  `protobuf:"varint,5,opt,name=pruned_height,json=prunedHeight,proto3" json:"pruned_height,omitempty"`
+	Nonces       map[string]uint64      `protobuf:"bytes,6,rep,name=nonces,proto3" json:"nonces,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Utxos        []*UTXO                `protobuf:"bytes,7,rep,name=utxos,proto3" json:"utxos,omitempty"`
+	// Unix nanoseconds
+	CreatedAt     int64 `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_proto_chain_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chain_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_proto_chain_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Snapshot) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Snapshot) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Snapshot) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Snapshot) GetIssued() uint64 {
+	if x != nil {
+		return x.Issued
+	}
+	return 0
+}
+
+func (x *Snapshot) GetPrunedHeight() int64 {
+	if x != nil {
+		return x.PrunedHeight
+	}
+	return 0
+}
+
+func (x *Snapshot) GetNonces() map[string]uint64 {
+	if x != nil {
+		return x.Nonces
+	}
+	return nil
+}
+
+func (x *Snapshot) GetUtxos() []*UTXO {
+	if x != nil {
+		return x.Utxos
+	}
+	return nil
+}
+
+func (x *Snapshot) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+var File_proto_chain_proto protoreflect.FileDescriptor
+
+const file_proto_chain_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/chain.proto\x12\bchain.v1\"\x99\x01\n" +
+	"\x06Record\x12*\n" +
+	"\x06header\x18\x01 \x01(\v2\x10.chain.v1.HeaderH\x00R\x06header\x12'\n" +
+	"\x05block\x18\x02 \x01(\v2\x0f.chain.v1.BlockH\x00R\x05block\x120\n" +
+	"\bsnapshot\x18\x03 \x01(\v2\x12.chain.v1.SnapshotH\x00R\bsnapshotB\b\n" +
+	"\x06record\"\xea\x01\n" +
+	"\x06Header\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x03R\x06height\x12\x19\n" +
+	"\btip_hash\x18\x04 \x01(\tR\atipHash\x12\x1c\n" +
+	"\tconsensus\x18\x05 \x01(\tR\tconsensus\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\x06 \x01(\tR\n" +
+	"difficulty\x12\x1e\n" +
+	"\n" +
+	"validators\x18\a \x03(\tR\n" +
+	"validators\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\"\xb6\x02\n" +
+	"\x05Block\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x03R\x05index\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x129\n" +
+	"\ftransactions\x18\x04 \x03(\v2\x15.chain.v1.TransactionR\ftransactions\x12#\n" +
+	"\rprevious_hash\x18\x05 \x01(\tR\fpreviousHash\x12\x14\n" +
+	"\x05nonce\x18\x06 \x01(\x03R\x05nonce\x12\x12\n" +
+	"\x04hash\x18\a \x01(\tR\x04hash\x12\x1f\n" +
+	"\vmerkle_root\x18\b \x01(\tR\n" +
+	"merkleRoot\x12\x16\n" +
+	"\x06signer\x18\t \x01(\tR\x06signer\x12\x1c\n" +
+	"\tsignature\x18\n" +
+	" \x01(\tR\tsignature\"\xa1\x03\n" +
+	"\vTransaction\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x1b\n" +
+	"\tsender_id\x18\x03 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vreceiver_id\x18\x04 \x01(\tR\n" +
+	"receiverId\x12\x19\n" +
+	"\basset_id\x18\x05 \x01(\tR\aassetId\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\x04R\x06amount\x12\x10\n" +
+	"\x03fee\x18\a \x01(\x04R\x03fee\x12\x14\n" +
+	"\x05nonce\x18\b \x01(\x04R\x05nonce\x12\x12\n" +
+	"\x04note\x18\t \x01(\tR\x04note\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06pubkey\x18\v \x01(\tR\x06pubkey\x12\x1c\n" +
+	"\tsignature\x18\f \x01(\tR\tsignature\x12)\n" +
+	"\x06inputs\x18\r \x03(\v2\x11.chain.v1.UTXORefR\x06inputs\x12(\n" +
+	"\aoutputs\x18\x0e \x03(\v2\x0e.chain.v1.UTXOR\aoutputs\x12\x12\n" +
+	"\x04type\x18\x0f \x01(\tR\x04type\"O\n" +
+	"\aUTXORef\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x03R\x05index\x12\x1a\n" +
+	"\bpreimage\x18\x03 \x01(\tR\bpreimage\"\xba\x02\n" +
+	"\x04UTXO\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x19\n" +
+	"\basset_id\x18\x03 \x01(\tR\aassetId\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x04R\x06amount\x12\x1b\n" +
+	"\torigin_tx\x18\x05 \x01(\tR\boriginTx\x12\x14\n" +
+	"\x05index\x18\x06 \x01(\x03R\x05index\x12\x14\n" +
+	"\x05spent\x18\a \x01(\bR\x05spent\x12\x16\n" +
+	"\x06height\x18\b \x01(\x03R\x06height\x12!\n" +
+	"\fspent_height\x18\t \x01(\x03R\vspentHeight\x12\x1b\n" +
+	"\tlock_time\x18\n" +
+	" \x01(\x03R\blockTime\x12\x1b\n" +
+	"\thash_lock\x18\v \x01(\tR\bhashLock\x12\x1b\n" +
+	"\trefund_to\x18\f \x01(\tR\brefundTo\"\xc5\x02\n" +
+	"\bSnapshot\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x03R\x06height\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06issued\x18\x04 \x01(\x04R\x06issued\x12#\n" +
+	"\rpruned_height\x18\x05 \x01(\x03R\fprunedHeight\x126\n" +
+	"\x06nonces\x18\x06 \x03(\v2\x1e.chain.v1.Snapshot.NoncesEntryR\x06nonces\x12$\n" +
+	"\x05utxos\x18\a \x03(\v2\x0e.chain.v1.UTXOR\x05utxos\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x1a9\n" +
+	"\vNoncesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01B\"Z blockchain-backend/proto/chainpbb\x06proto3"
+
+var (
+	file_proto_chain_proto_rawDescOnce sync.Once
+	file_proto_chain_proto_rawDescData []byte
+)
+
+func file_proto_chain_proto_rawDescGZIP() []byte {
+	file_proto_chain_proto_rawDescOnce.Do(func() {
+		file_proto_chain_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_chain_proto_rawDesc), len(file_proto_chain_proto_rawDesc)))
+	})
+	return file_proto_chain_proto_rawDescData
+}
+
+var file_proto_chain_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_chain_proto_goTypes = []any{
+	(*Record)(nil),      // 0: chain.v1.Record
+	(*Header)(nil),      // 1: chain.v1.Header
+	(*Block)(nil),       // 2: chain.v1.Block
+	(*Transaction)(nil), // 3: chain.v1.Transaction
+	(*UTXORef)(nil),     // 4: chain.v1.UTXORef
+	(*UTXO)(nil),        // 5: chain.v1.UTXO
+	(*Snapshot)(nil),    // 6: chain.v1.Snapshot
+	nil,                 // 7: chain.v1.Snapshot.NoncesEntry
+}
+var file_proto_chain_proto_depIdxs = []int32{
+	1, // 0: chain.v1.Record.header:type_name -> chain.v1.Header
+	2, // 1: chain.v1.Record.block:type_name -> chain.v1.Block
+	6, // 2: chain.v1.Record.snapshot:type_name -> chain.v1.Snapshot
+	3, // 3: chain.v1.Block.transactions:type_name -> chain.v1.Transaction
+	4, // 4: chain.v1.Transaction.inputs:type_name -> chain.v1.UTXORef
+	5, // 5: chain.v1.Transaction.outputs:type_name -> chain.v1.UTXO
+	7, // 6: chain.v1.Snapshot.nonces:type_name -> chain.v1.Snapshot.NoncesEntry
+	5, // 7: chain.v1.Snapshot.utxos:type_name -> chain.v1.UTXO
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_proto_chain_proto_init() }
+func file_proto_chain_proto_init() {
+	if File_proto_chain_proto != nil {
+		return
+	}
+	file_proto_chain_proto_msgTypes[0].OneofWrappers = []any{
+		(*Record_Header)(nil),
+		(*Record_Block)(nil),
+		(*Record_Snapshot)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_chain_proto_rawDesc), len(file_proto_chain_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_chain_proto_goTypes,
+		DependencyIndexes: file_proto_chain_proto_depIdxs,
+		MessageInfos:      file_proto_chain_proto_msgTypes,
+	}.Build()
+	File_proto_chain_proto = out.File
+	file_proto_chain_proto_goTypes = nil
+	file_proto_chain_proto_depIdxs = nil
+}
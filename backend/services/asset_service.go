@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/wallet"
+)
+
+// Asset symbols are 2 to 10 upper-case letters and digits, starting with a
+// letter
+const (
+	MinAssetSymbolLen = 2
+	MaxAssetSymbolLen = 10
+)
+
+// Errors returned by the asset registry
+var (
+	ErrUnknownAsset       = errors.New("asset not found")
+	ErrAssetExists        = errors.New("an asset with this symbol already exists")
+	ErrInvalidAssetSymbol = fmt.Errorf("symbol must be %d to %d upper-case letters and digits, starting with a letter", MinAssetSymbolLen, MaxAssetSymbolLen)
+	ErrInvalidAssetIssuer = errors.New("issuer wallet not found or inactive")
+	ErrAssetNotMintable   = errors.New("asset has a fixed supply")
+	ErrAssetSupplyCap     = errors.New("issuing this much would exceed the asset's max supply")
+)
+
+// Asset is a token the chain carries besides the coin. Its units are counted
+// like the coin's, 10^8 to one whole token, and move in TxVersionAsset
+// transactions that pay no fee.
+type Asset struct {
+	Symbol    string    `json:"symbol"` // the asset ID outputs and transactions carry
+	Name      string    `json:"name"`
+	Issuer    string    `json:"issuer"`     // wallet new units are issued to
+	MaxSupply uint64    `json:"max_supply"` // 0 means no cap
+	Mintable  bool      `json:"mintable"`   // units can be issued after the initial supply
+	Issued    uint64    `json:"issued"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AssetService is the registry of assets. Issuing units queues an asset_issue
+// transaction paying them to the issuer; the registry holds issuance to each
+// asset's supply rules.
+type AssetService struct {
+	bc   *blockchain.Blockchain
+	ws   *wallet.Store
+	feed *events.Feed
+
+	mu     sync.Mutex // also serializes issuance, so two mints cannot both pass the cap
+	assets map[string]*Asset
+	db     database.AssetRepo
+}
+
+func NewAssetService(bc *blockchain.Blockchain, ws *wallet.Store, feed *events.Feed) *AssetService {
+	return &AssetService{
+		bc:     bc,
+		ws:     ws,
+		feed:   feed,
+		assets: make(map[string]*Asset),
+	}
+}
+
+// Reset forgets every asset after the chain was reset, since the issuance
+// that backed them is gone; the database's are emptied with the chain tables
+func (as *AssetService) Reset() {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.assets = make(map[string]*Asset)
+}
+
+// SetDatabase enables persistence and reloads the registry
+func (as *AssetService) SetDatabase(db database.AssetRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetAssets(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load assets from database: %v", err)
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.db = db
+	for _, row := range rows {
+		a := &Asset{
+			Symbol:    row["symbol"].(string),
+			Name:      row["name"].(string),
+			Issuer:    row["issuer"].(string),
+			MaxSupply: row["max_supply"].(uint64),
+			Mintable:  row["mintable"].(bool),
+			Issued:    row["issued"].(uint64),
+			CreatedBy: row["created_by"].(string),
+			CreatedAt: row["created_at"].(time.Time),
+		}
+		as.assets[a.Symbol] = a
+	}
+}
+
+// NormalizeAssetSymbol upper-cases a symbol and checks its form
+func NormalizeAssetSymbol(symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if len(symbol) < MinAssetSymbolLen || len(symbol) > MaxAssetSymbolLen || symbol[0] < 'A' || symbol[0] > 'Z' {
+		return "", ErrInvalidAssetSymbol
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return "", ErrInvalidAssetSymbol
+		}
+	}
+	return symbol, nil
+}
+
+// Define registers an asset and issues its initial supply to the issuer. An
+// asset that is not mintable keeps the initial supply for good.
+func (as *AssetService) Define(a Asset, initialSupply uint64, createdBy string) (Asset, *blockchain.Transaction, error) {
+	symbol, err := NormalizeAssetSymbol(a.Symbol)
+	if err != nil {
+		return Asset{}, nil, err
+	}
+	if !as.issuerActive(a.Issuer) {
+		return Asset{}, nil, ErrInvalidAssetIssuer
+	}
+	if a.MaxSupply > 0 && initialSupply > a.MaxSupply {
+		return Asset{}, nil, ErrAssetSupplyCap
+	}
+	if initialSupply == 0 && !a.Mintable {
+		return Asset{}, nil, fmt.Errorf("%w and no initial supply", ErrAssetNotMintable)
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if _, exists := as.assets[symbol]; exists {
+		return Asset{}, nil, ErrAssetExists
+	}
+	asset := &Asset{
+		Symbol:    symbol,
+		Name:      strings.TrimSpace(a.Name),
+		Issuer:    a.Issuer,
+		MaxSupply: a.MaxSupply,
+		Mintable:  a.Mintable,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := as.save(asset); err != nil {
+		return Asset{}, nil, err
+	}
+	as.assets[symbol] = asset
+
+	var tx *blockchain.Transaction
+	if initialSupply > 0 {
+		if tx, err = as.issue(asset, initialSupply); err != nil {
+			return *asset, nil, err
+		}
+	}
+	log.Printf("🪙 Asset %s (%s) defined by %s", symbol, asset.Name, createdBy)
+	return *asset, tx, nil
+}
+
+// Mint issues more units of a mintable asset to its issuer, up to its max
+// supply
+func (as *AssetService) Mint(symbol string, amount uint64) (Asset, *blockchain.Transaction, error) {
+	symbol, err := NormalizeAssetSymbol(symbol)
+	if err != nil {
+		return Asset{}, nil, ErrUnknownAsset
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	asset, ok := as.assets[symbol]
+	switch {
+	case !ok:
+		return Asset{}, nil, ErrUnknownAsset
+	case !asset.Mintable:
+		return Asset{}, nil, ErrAssetNotMintable
+	case !as.issuerActive(asset.Issuer):
+		return Asset{}, nil, ErrInvalidAssetIssuer
+	case asset.MaxSupply > 0 && amount > asset.MaxSupply-asset.Issued:
+		return Asset{}, nil, fmt.Errorf("%w: %s of %s issued", ErrAssetSupplyCap, blockchain.FormatAmount(asset.Issued), blockchain.FormatAmount(asset.MaxSupply))
+	}
+	tx, err := as.issue(asset, amount)
+	if err != nil {
+		return Asset{}, nil, err
+	}
+	return *asset, tx, nil
+}
+
+// issue queues an asset_issue transaction paying amount to the issuer and
+// counts it as issued. The caller must hold mu.
+func (as *AssetService) issue(asset *Asset, amount uint64) (*blockchain.Transaction, error) {
+	issued := asset.Issued + amount
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersionAsset,
+		SenderID:   blockchain.AssetIssuer,
+		ReceiverID: asset.Issuer,
+		AssetID:    asset.Symbol,
+		Amount:     amount,
+		Note:       fmt.Sprintf("Issue %s %s (%s issued)", blockchain.FormatAmount(amount), asset.Symbol, blockchain.FormatAmount(issued)),
+		Timestamp:  time.Now().Unix(),
+		PubKey:     "system",
+		Signature:  "system",
+		Inputs:     []blockchain.UTXORef{},
+		Outputs:    []blockchain.UTXO{{Owner: asset.Issuer, Amount: amount, Index: 0}},
+		Type:       "asset_issue",
+	}
+	blockchain.AssignID(tx)
+	if err := as.bc.AddPending(*tx); err != nil {
+		return nil, fmt.Errorf("issuance rejected: %w", err)
+	}
+	as.feed.PublishTransaction(events.TxPending, *tx, nil)
+
+	asset.Issued = issued
+	if err := as.save(asset); err != nil {
+		log.Printf("Failed to persist issuance of asset %s: %v", asset.Symbol, err)
+	}
+	if as.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := as.db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+			log.Printf("Failed to persist asset issuance transaction %s: %v", tx.ID, err)
+		}
+	}
+	log.Printf("🪙 Issued %s %s to %s", blockchain.FormatAmount(amount), asset.Symbol, asset.Issuer)
+	return tx, nil
+}
+
+func (as *AssetService) issuerActive(walletID string) bool {
+	w, ok := as.ws.Get(walletID)
+	return ok && w.Active()
+}
+
+// save stores an asset. The caller must hold mu.
+func (as *AssetService) save(a *Asset) error {
+	if as.db == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := as.db.SaveAsset(ctx, a.Symbol, a.Name, a.Issuer, a.MaxSupply, a.Mintable, a.Issued, a.CreatedBy, a.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save asset: %w", err)
+	}
+	return nil
+}
+
+// Get returns the asset with a symbol, in any case. A nil service knows no
+// assets.
+func (as *AssetService) Get(symbol string) (Asset, bool) {
+	if as == nil {
+		return Asset{}, false
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	a, ok := as.assets[strings.ToUpper(strings.TrimSpace(symbol))]
+	if !ok {
+		return Asset{}, false
+	}
+	return *a, true
+}
+
+// List returns every asset, oldest first
+func (as *AssetService) List() []Asset {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	out := make([]Asset, 0, len(as.assets))
+	for _, a := range as.assets {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// AuditFlushInterval is how often recorded calls are written to audit_logs
+const AuditFlushInterval = 5 * time.Second
+
+// maxAuditEntries bounds the calls kept in memory, and the calls waiting
+// for the database while it is unreachable
+const maxAuditEntries = 10000
+
+// Outcomes of an audited call
+const (
+	AuditSuccess  = "success"  // 2xx or 3xx
+	AuditRejected = "rejected" // 4xx
+	AuditFailed   = "failed"   // 5xx
+)
+
+// AuditOutcome classifies a response status
+func AuditOutcome(status int) string {
+	switch {
+	case status >= 500:
+		return AuditFailed
+	case status >= 400:
+		return AuditRejected
+	default:
+		return AuditSuccess
+	}
+}
+
+// AuditEntry is one state-changing API call
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route"` // route template, e.g. /api/wallet/{wallet}
+	WalletID  string    `json:"wallet_id,omitempty"`
+	Actor     string    `json:"actor,omitempty"` // authenticated X-Wallet-ID, or "admin-key"
+	OrgID     string    `json:"org_id,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Status    int       `json:"status"`
+	Outcome   string    `json:"outcome"` // success, rejected or failed
+	ErrorCode string    `json:"error_code,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e AuditEntry) record() database.AuditRecord {
+	return database.AuditRecord{
+		ID: e.ID, Method: e.Method, Path: e.Path, Route: e.Route, WalletID: e.WalletID, Actor: e.Actor, OrgID: e.OrgID,
+		IPAddress: e.IPAddress, UserAgent: e.UserAgent, Status: e.Status, Outcome: e.Outcome, ErrorCode: e.ErrorCode,
+		LatencyMS: e.LatencyMS, RequestID: e.RequestID, CreatedAt: e.CreatedAt,
+	}
+}
+
+func auditEntry(r database.AuditRecord) AuditEntry {
+	return AuditEntry{
+		ID: r.ID, Method: r.Method, Path: r.Path, Route: r.Route, WalletID: r.WalletID, Actor: r.Actor, OrgID: r.OrgID,
+		IPAddress: r.IPAddress, UserAgent: r.UserAgent, Status: r.Status, Outcome: r.Outcome, ErrorCode: r.ErrorCode,
+		LatencyMS: r.LatencyMS, RequestID: r.RequestID, CreatedAt: r.CreatedAt,
+	}
+}
+
+// AuditService records state-changing API calls. Without a database the
+// newest maxAuditEntries are kept in memory; with one they are written to
+// audit_logs in batches every AuditFlushInterval and queried from there.
+type AuditService struct {
+	mu      sync.Mutex
+	entries []AuditEntry // in-memory mode only, newest last
+	pending []AuditEntry // not yet written to the database
+	nextID  int64
+	db      database.AuditRepo
+	done    chan struct{}
+	loop    sync.WaitGroup // the ticker goroutine; Stop waits for it
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{nextID: 1, done: make(chan struct{})}
+}
+
+// Reset drops the audited calls after the chain was reset, including those
+// not yet written, since their paths name its transactions and blocks
+func (as *AuditService) Reset() {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.entries, as.pending = nil, nil
+}
+
+// SetDatabase writes the calls recorded from now on to audit_logs, and
+// answers queries from it
+func (as *AuditService) SetDatabase(db database.AuditRepo) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.db = db
+}
+
+// Record adds a call to the audit log
+func (as *AuditService) Record(e AuditEntry) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.db == nil {
+		e.ID = as.nextID
+		as.nextID++
+		as.entries = append(as.entries, e)
+		if len(as.entries) > maxAuditEntries {
+			as.entries = as.entries[len(as.entries)-maxAuditEntries:]
+		}
+		return
+	}
+	if len(as.pending) >= maxAuditEntries {
+		log.Printf("⚠️  Audit log queue is full; dropping the oldest call not yet written")
+		as.pending = as.pending[1:]
+	}
+	as.pending = append(as.pending, e)
+}
+
+// Query returns up to limit calls matching the filter, newest first
+func (as *AuditService) Query(ctx context.Context, f database.AuditFilter, limit int) ([]AuditEntry, error) {
+	as.mu.Lock()
+	db := as.db
+	if db == nil {
+		defer as.mu.Unlock()
+		list := []AuditEntry{}
+		for i := len(as.entries) - 1; i >= 0 && len(list) < limit; i-- {
+			if f.Matches(as.entries[i].record()) {
+				list = append(list, as.entries[i])
+			}
+		}
+		return list, nil
+	}
+	as.mu.Unlock()
+
+	records, err := db.AuditLogPage(ctx, f, limit)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]AuditEntry, len(records))
+	for i, r := range records {
+		list[i] = auditEntry(r)
+	}
+	return list, nil
+}
+
+// Start writes recorded calls to the database every interval
+func (as *AuditService) Start(interval time.Duration) {
+	as.loop.Add(1)
+	go func() {
+		defer as.loop.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				as.Flush()
+			case <-as.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop and writes the remaining calls
+func (as *AuditService) Stop() {
+	close(as.done)
+	as.loop.Wait()
+	as.Flush()
+}
+
+// Flush writes the calls recorded since the last flush to the database
+func (as *AuditService) Flush() {
+	as.mu.Lock()
+	db := as.db
+	if db == nil || len(as.pending) == 0 {
+		as.mu.Unlock()
+		return
+	}
+	batch := as.pending
+	as.pending = nil
+	as.mu.Unlock()
+
+	records := make([]database.AuditRecord, len(batch))
+	for i, e := range batch {
+		records[i] = e.record()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.SaveAuditLogs(ctx, records); err != nil {
+		log.Printf("Failed to persist audit log: %v", err)
+		// Keep the unsaved calls for the next flush, ahead of newer ones
+		as.mu.Lock()
+		as.pending = append(batch, as.pending...)
+		if len(as.pending) > maxAuditEntries {
+			as.pending = as.pending[len(as.pending)-maxAuditEntries:]
+		}
+		as.mu.Unlock()
+	}
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// DefaultBalanceRepairInterval is how often stored balances are checked
+// against the persisted UTXOs
+const DefaultBalanceRepairInterval = 10 * time.Minute
+
+// Retry schedule for a balance row locked by another writer. After the last
+// retry the recompute waits for the lock instead.
+var balanceRetryDelays = []time.Duration{10 * time.Millisecond, 40 * time.Millisecond, 160 * time.Millisecond}
+
+// BalanceStats counts balance recomputes and the conflicts they ran into
+type BalanceStats struct {
+	Recomputes    int64  `json:"recomputes"`
+	Changed       int64  `json:"changed"`        // recomputes that moved the stored balance
+	LockConflicts int64  `json:"lock_conflicts"` // attempts that found the row locked by another writer and retried
+	Mismatches    int64  `json:"mismatches"`     // persisted UTXOs disagreed with memory when recomputed
+	Repaired      int64  `json:"repaired"`       // drifted balances fixed by the repair job
+	Failures      int64  `json:"failures"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// BalanceRepairRun summarises one pass of the balance repair job
+type BalanceRepairRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Drifted    int       `json:"drifted"`
+	Repaired   int       `json:"repaired"`
+	Failed     int       `json:"failed"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// BalanceService keeps the balance column of the wallets table in step with
+// the persisted UTXOs. Writers never store a balance they computed
+// themselves; they ask for a recompute, which reads the UTXO total under a
+// row lock, so concurrent mining, zakat and wallet creation cannot leave a
+// stale value behind. A periodic job repairs any balance that drifted anyway.
+type BalanceService struct {
+	bc       *blockchain.Blockchain
+	db       database.BalanceRepo
+	interval time.Duration
+	done     chan struct{}
+	loop     sync.WaitGroup // the ticker goroutine; Stop waits for it
+
+	mu         sync.Mutex
+	stats      BalanceStats
+	lastRepair *BalanceRepairRun
+}
+
+func NewBalanceService(bc *blockchain.Blockchain, interval time.Duration) *BalanceService {
+	return &BalanceService{bc: bc, interval: interval, done: make(chan struct{})}
+}
+
+// SetDatabase enables balance persistence
+func (bs *BalanceService) SetDatabase(db database.BalanceRepo) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.db = db
+}
+
+func (bs *BalanceService) database() database.BalanceRepo {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.db
+}
+
+// Stats returns the recompute counters and the last repair run, if any
+func (bs *BalanceService) Stats() (BalanceStats, *BalanceRepairRun) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	var last *BalanceRepairRun
+	if bs.lastRepair != nil {
+		run := *bs.lastRepair
+		last = &run
+	}
+	return bs.stats, last
+}
+
+func (bs *BalanceService) count(f func(*BalanceStats)) {
+	bs.mu.Lock()
+	f(&bs.stats)
+	bs.mu.Unlock()
+}
+
+// Sync recomputes a wallet's stored balance from its persisted UTXOs. A row
+// locked by another writer is retried with backoff, then waited for. It is a
+// no-op without a database.
+func (bs *BalanceService) Sync(ctx context.Context, walletID string) error {
+	db := bs.database()
+	if db == nil {
+		return nil
+	}
+	return bs.sync(ctx, db, walletID)
+}
+
+// SyncIn is Sync on store, which may be a transaction from Store.Atomic; the
+// new balance then commits with the UTXOs it was computed from. Only Postgres
+// stores balances; the other stores derive them from the UTXOs.
+func (bs *BalanceService) SyncIn(ctx context.Context, store database.Store, walletID string) error {
+	db, ok := store.(database.BalanceRepo)
+	if !ok {
+		return nil
+	}
+	return bs.sync(ctx, db, walletID)
+}
+
+func (bs *BalanceService) sync(ctx context.Context, db database.BalanceRepo, walletID string) error {
+	var previous, current uint64
+	var found bool
+	var err error
+	for attempt := 0; ; attempt++ {
+		nowait := attempt < len(balanceRetryDelays)
+		previous, current, found, err = db.RecomputeWalletBalance(ctx, walletID, nowait)
+		if !errors.Is(err, database.ErrBalanceLocked) {
+			break
+		}
+		bs.count(func(s *BalanceStats) { s.LockConflicts++ })
+		if err = sleepCtx(ctx, balanceRetryDelays[attempt]); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		bs.count(func(s *BalanceStats) { s.Failures++; s.LastError = err.Error() })
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	// Memory is written before the database, so a difference means another
+	// writer's UTXOs have not been persisted yet; its own Sync will follow
+	memory := bs.unspent(walletID)
+	bs.count(func(s *BalanceStats) {
+		s.Recomputes++
+		if current != previous {
+			s.Changed++
+		}
+		if current != memory {
+			s.Mismatches++
+		}
+	})
+	if current != memory {
+		log.Printf("⚠️  Balance of %s is %d in the database but %d in memory", walletID, current, memory)
+	}
+	return nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unspent is the total of a wallet's unspent UTXOs in memory, the figure the
+// persisted balance should converge on
+func (bs *BalanceService) unspent(walletID string) uint64 {
+	var sum uint64
+	for _, u := range bs.bc.GetWalletUTXOs(walletID) {
+		if !u.Spent {
+			sum += u.Amount
+		}
+	}
+	return sum
+}
+
+// Repair recomputes every stored balance that differs from the persisted UTXOs
+func (bs *BalanceService) Repair(ctx context.Context) (run BalanceRepairRun, err error) {
+	run.StartedAt = time.Now()
+	defer func() {
+		run.FinishedAt = time.Now()
+		bs.mu.Lock()
+		bs.lastRepair = &run
+		bs.mu.Unlock()
+	}()
+
+	db := bs.database()
+	if db == nil {
+		return run, nil
+	}
+	rows, err := db.GetBalanceDrift(ctx)
+	if err != nil {
+		run.Failed++
+		run.LastError = err.Error()
+		return run, err
+	}
+	run.Drifted = len(rows)
+	for _, row := range rows {
+		walletID, _ := row["wallet_id"].(string)
+		if err := bs.Sync(ctx, walletID); err != nil {
+			run.Failed++
+			run.LastError = err.Error()
+			continue
+		}
+		run.Repaired++
+	}
+	if run.Repaired > 0 {
+		bs.count(func(s *BalanceStats) { s.Repaired += int64(run.Repaired) })
+		log.Printf("🔧 Repaired %d drifted wallet balances", run.Repaired)
+	}
+	return run, nil
+}
+
+// LedgerEntry is one wallet's side of a reconciliation. The chain's view is
+// the wallet's UTXOs in memory: every output it received is a credit and
+// every one it spent a debit.
+type LedgerEntry struct {
+	WalletID  string   `json:"wallet_id"`
+	Credited  uint64   `json:"credited"`
+	Debited   uint64   `json:"debited"`
+	Balance   uint64   `json:"balance"`             // credited - debited
+	Stored    *uint64  `json:"stored,omitempty"`    // wallets.balance; absent without a database or wallet row
+	Persisted *uint64  `json:"persisted,omitempty"` // total of the wallet's unspent rows in utxos
+	Issues    []string `json:"issues,omitempty"`
+}
+
+// UnbalancedTx is a mined transaction whose inputs do not equal its outputs
+// plus fee, or a coinbase paying more than the reward and the block's fees
+type UnbalancedTx struct {
+	TxID    string `json:"txid"`
+	Block   int64  `json:"block"`
+	Inputs  uint64 `json:"inputs"`
+	Outputs uint64 `json:"outputs"`
+	Fee     uint64 `json:"fee"`
+	Issue   string `json:"issue"`
+}
+
+// Reconciliation compares the chain's ledger with the balances the database
+// stores
+type Reconciliation struct {
+	CheckedAt     time.Time      `json:"checked_at"`
+	Database      bool           `json:"database"`
+	Wallets       int            `json:"wallets"`
+	Credited      uint64         `json:"credited"`
+	Debited       uint64         `json:"debited"`
+	Unspent       uint64         `json:"unspent"`
+	Stored        uint64         `json:"stored"` // sum of wallets.balance
+	Discrepancies []LedgerEntry  `json:"discrepancies"`
+	Unbalanced    []UnbalancedTx `json:"unbalanced_transactions"`
+	Repaired      int            `json:"repaired"`
+	Failed        int            `json:"failed"`
+}
+
+// Reconcile recomputes every wallet's balance from the UTXO set and reports
+// wallets whose stored balance or persisted UTXOs disagree with it, and mined
+// transactions that do not balance
+func (bs *BalanceService) Reconcile(ctx context.Context) (Reconciliation, error) {
+	rec := Reconciliation{CheckedAt: time.Now(), Discrepancies: []LedgerEntry{}, Unbalanced: []UnbalancedTx{}}
+	entries := map[string]*LedgerEntry{}
+	entry := func(walletID string) *LedgerEntry {
+		if e, ok := entries[walletID]; ok {
+			return e
+		}
+		e := &LedgerEntry{WalletID: walletID}
+		entries[walletID] = e
+		return e
+	}
+
+	bs.bc.View(func(v blockchain.View) {
+		for u := range v.UTXOs() {
+			if u.AssetID != "" {
+				continue // balances are in coins
+			}
+			e := entry(u.Owner)
+			e.Credited += u.Amount
+			if u.Spent {
+				e.Debited += u.Amount
+			}
+		}
+		rec.Unbalanced = unbalanced(v)
+	})
+
+	db := bs.database()
+	if db != nil {
+		rows, err := db.GetLedgerBalances(ctx)
+		if err != nil {
+			return rec, err
+		}
+		rec.Database = true
+		for _, row := range rows {
+			walletID, _ := row["wallet_id"].(string)
+			stored, _ := row["stored"].(uint64)
+			persisted, _ := row["persisted"].(uint64)
+			e := entry(walletID)
+			e.Stored, e.Persisted = &stored, &persisted
+			rec.Stored += stored
+		}
+	}
+
+	for _, e := range entries {
+		e.Balance = e.Credited - e.Debited
+		rec.Credited += e.Credited
+		rec.Debited += e.Debited
+		rec.Unspent += e.Balance
+		if e.Stored != nil && *e.Stored != e.Balance {
+			e.Issues = append(e.Issues, fmt.Sprintf("stored balance %d differs from the ledger", *e.Stored))
+		}
+		if e.Persisted != nil && *e.Persisted != e.Balance {
+			e.Issues = append(e.Issues, fmt.Sprintf("persisted UTXOs total %d, not the ledger's", *e.Persisted))
+		}
+		if len(e.Issues) > 0 {
+			rec.Discrepancies = append(rec.Discrepancies, *e)
+		}
+	}
+	rec.Wallets = len(entries)
+	sort.Slice(rec.Discrepancies, func(i, j int) bool {
+		return rec.Discrepancies[i].WalletID < rec.Discrepancies[j].WalletID
+	})
+	return rec, nil
+}
+
+// unbalanced checks every mined transaction's inputs against its outputs and
+// fee
+func unbalanced(v blockchain.View) []UnbalancedTx {
+	found := []UnbalancedTx{}
+	for b := range v.Blocks() {
+		var fees uint64
+		for _, tx := range b.Transactions {
+			fees += tx.Fee
+		}
+		for _, tx := range b.Transactions {
+			u := UnbalancedTx{TxID: tx.ID, Block: b.Index, Fee: tx.Fee}
+			for _, out := range tx.Outputs {
+				u.Outputs += out.Amount
+			}
+			if tx.SenderID == "COINBASE" {
+				if u.Outputs > uint64(blockchain.MiningReward)+fees {
+					u.Issue = "coinbase pays more than the reward and fees"
+					found = append(found, u)
+				}
+				continue
+			}
+			if tx.Type == "asset_issue" || tx.Type == "admin_mint" {
+				continue // creates units from nothing; the asset registry or an admin answers for them
+			}
+			if tx.Type == "admin_burn" {
+				u.Outputs += tx.Amount // the amount burned is destroyed, not lost
+			}
+			missing := false
+			for _, in := range tx.Inputs {
+				spent, ok := v.Output(in.TxID, in.Index)
+				if !ok {
+					missing = true
+					continue
+				}
+				u.Inputs += spent.Amount
+			}
+			switch {
+			case missing && b.Index <= v.PrunedHeight():
+				continue // a pruned faucet grant; its amount is in the archive
+			case missing:
+				u.Issue = "spends a UTXO missing from the set"
+			case u.Inputs != u.Outputs+u.Fee:
+				u.Issue = "inputs do not equal outputs plus fee"
+			default:
+				continue
+			}
+			found = append(found, u)
+		}
+	}
+	return found
+}
+
+// RepairLedger reconciles, then for every wallet that disagrees with the
+// ledger writes its in-memory UTXOs back to the database and recomputes the
+// stored balance from them
+func (bs *BalanceService) RepairLedger(ctx context.Context) (Reconciliation, error) {
+	rec, err := bs.Reconcile(ctx)
+	if err != nil {
+		return rec, err
+	}
+	db := bs.database()
+	if db == nil {
+		return rec, nil
+	}
+
+	for _, e := range rec.Discrepancies {
+		if err := bs.restoreUTXOs(ctx, db, e.WalletID); err != nil {
+			rec.Failed++
+			bs.count(func(s *BalanceStats) { s.Failures++; s.LastError = err.Error() })
+			continue
+		}
+		if e.Stored == nil {
+			rec.Repaired++
+			continue
+		}
+		if err := bs.Sync(ctx, e.WalletID); err != nil {
+			rec.Failed++
+			continue
+		}
+		rec.Repaired++
+	}
+	if rec.Repaired > 0 {
+		bs.count(func(s *BalanceStats) { s.Repaired += int64(rec.Repaired) })
+		log.Printf("🔧 Reconciled %d wallet balances with the ledger", rec.Repaired)
+	}
+	return rec, nil
+}
+
+func (bs *BalanceService) restoreUTXOs(ctx context.Context, db database.BalanceRepo, walletID string) error {
+	owned := bs.bc.GetWalletUTXOs(walletID)
+
+	for _, u := range owned {
+		if err := db.SaveUTXO(ctx, u.ID, u.Owner, u.AssetID, u.Amount, u.OriginTx, u.Index, u.Spent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start runs the repair job every interval until Stop; a zero interval
+// disables it
+func (bs *BalanceService) Start() {
+	if bs.interval <= 0 {
+		return
+	}
+	bs.loop.Add(1)
+	go func() {
+		defer bs.loop.Done()
+		ticker := time.NewTicker(bs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := bs.Repair(ctx); err != nil {
+					log.Printf("⚠️  Balance repair failed: %v", err)
+				}
+				cancel()
+			case <-bs.done:
+				return
+			}
+		}
+	}()
+	log.Printf("✅ Balance repair job started (every %s)", bs.interval)
+}
+
+// Stop ends the repair job, waiting for a repair in progress
+func (bs *BalanceService) Stop() {
+	close(bs.done)
+	bs.loop.Wait()
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
+)
+
+// A batch pays many wallets in one transaction of type batch, one output per
+// payment, so payroll and the like either go through whole or not at all and
+// pay a single transfer fee.
+
+// MaxBatchPayments caps the payments of one batch
+const MaxBatchPayments = 250
+
+// ErrInvalidBatch is returned for a batch whose payments do not fit
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// Payment is one recipient of a batch
+type Payment struct {
+	ReceiverID string `json:"receiver_id"`
+	Amount     uint64 `json:"amount"`
+}
+
+// CreateBatchTransaction creates a signed batch paying each payment from the
+// sender. The sender's spendable balance must cover every payment and the fee
+// before anything is built. Outputs follow the order of payments, with the
+// change last.
+func (ts *TransactionService) CreateBatchTransaction(ctx context.Context, senderID string, payments []Payment, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	if len(payments) == 0 || len(payments) > MaxBatchPayments {
+		return nil, fmt.Errorf("%w: a batch has from 1 to %d payments", ErrInvalidBatch, MaxBatchPayments)
+	}
+	var total uint64
+	seen := make(map[string]bool)
+	for i, p := range payments {
+		switch {
+		case p.ReceiverID == senderID:
+			return nil, fmt.Errorf("payment %d: %w", i, ErrSelfTransfer)
+		case seen[p.ReceiverID]:
+			return nil, fmt.Errorf("%w: payment %d repeats receiver %s", ErrInvalidBatch, i, p.ReceiverID)
+		case validation.Amount(p.Amount) != nil:
+			return nil, fmt.Errorf("%w: payment %d: amount %v", ErrMalformedTransaction, i, validation.Amount(p.Amount))
+		}
+		if _, exists := ts.ws.Get(p.ReceiverID); !exists {
+			return nil, fmt.Errorf("payment %d: %w: %s", i, ErrReceiverNotFound, p.ReceiverID)
+		}
+		seen[p.ReceiverID] = true
+		total += p.Amount
+	}
+	if err := validation.Amount(total); err != nil {
+		return nil, fmt.Errorf("%w: total %v", ErrMalformedTransaction, err)
+	}
+
+	fee := ts.Fees(senderID).Transfer
+	if spendable := ts.SpendableBalance(senderID); spendable < total+fee {
+		return nil, fmt.Errorf("%w: the batch needs %d with its fee, %d is spendable", ErrInsufficientBalance, total+fee, spendable)
+	}
+
+	tx, _, err := ts.prepareTransfer(ctx, senderID, payments[0].ReceiverID, "", total, note, blockchain.Lock{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the single receiver output with the payments, keeping change last
+	outputs := make([]blockchain.UTXO, 0, len(payments)+1)
+	for i, p := range payments {
+		outputs = append(outputs, blockchain.UTXO{Owner: p.ReceiverID, Amount: p.Amount, Index: i})
+	}
+	for _, change := range tx.Outputs[1:] {
+		change.Index = len(outputs)
+		outputs = append(outputs, change)
+	}
+	tx.Outputs = outputs
+	tx.ReceiverID = blockchain.BatchReceiver
+	tx.Type = "batch"
+	return ts.sign(tx, pubKey, privKey)
+}
+
+// SpendableBalance returns the coins a wallet can spend now: confirmed,
+// unlocked outputs, including those a pending transaction already spends
+func (ts *TransactionService) SpendableBalance(walletID string) uint64 {
+	var sum uint64
+	ts.bc.View(func(v blockchain.View) {
+		for _, utxo := range v.OwnedUTXOs(walletID) {
+			if v.Spendable(utxo) {
+				sum += utxo.Amount
+			}
+		}
+	})
+	return sum
+}
+
+// batchTotal is what a batch pays out: its outputs to wallets other than the
+// sender
+func batchTotal(tx *blockchain.Transaction) uint64 {
+	var sum uint64
+	for _, out := range tx.Outputs {
+		if out.Owner != tx.SenderID {
+			sum += out.Amount
+		}
+	}
+	return sum
+}
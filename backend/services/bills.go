@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// A split bill divides a total among wallets and asks each for its share
+// with a payment request. The requests are the record of who has paid; the
+// bill is settled once every one of them is accepted.
+
+// MaxBillParticipants caps the wallets a bill is split among
+const MaxBillParticipants = 50
+
+// MaxBillNoteLength leaves room in each share's request note for the bill
+// reference
+const MaxBillNoteLength = 180
+
+// Bill statuses, read from the bill's payment requests
+const (
+	BillOpen       = "open"       // some shares are still pending
+	BillSettled    = "settled"    // every share was paid
+	BillIncomplete = "incomplete" // nothing is pending, but a share was declined or expired
+)
+
+// Errors returned by the bill service
+var (
+	ErrBillNotFound = errors.New("bill not found")
+	ErrInvalidBill  = errors.New("invalid bill")
+)
+
+// BillShare is one participant's part of a bill and where its request stands
+type BillShare struct {
+	Payer     string `json:"payer"`
+	Amount    uint64 `json:"amount"`
+	RequestID int64  `json:"request_id"`
+	Status    string `json:"status"`
+	TxID      string `json:"txid,omitempty"`
+}
+
+// Bill is a total split among wallets. The creator's own share, if the
+// creator took part, is not requested.
+type Bill struct {
+	ID           int64       `json:"id"`
+	Creator      string      `json:"creator"`
+	Total        uint64      `json:"total"`
+	CreatorShare uint64      `json:"creator_share"`
+	Note         string      `json:"note,omitempty"`
+	Status       string      `json:"status"`
+	Paid         uint64      `json:"paid"`        // accepted shares
+	Outstanding  uint64      `json:"outstanding"` // pending shares
+	CreatedAt    time.Time   `json:"created_at"`
+	SettledAt    *time.Time  `json:"settled_at,omitempty"`
+	Shares       []BillShare `json:"shares"`
+}
+
+// BillParticipant is a wallet a bill is split with. Amount is its share for
+// a custom split and zero for an equal one.
+type BillParticipant struct {
+	WalletID string
+	Amount   uint64
+}
+
+// SplitEqually divides total into n shares that differ by at most one unit,
+// the larger ones first
+func SplitEqually(total uint64, n int) []uint64 {
+	shares := make([]uint64, n)
+	each, rest := total/uint64(n), total%uint64(n)
+	for i := range shares {
+		shares[i] = each
+		if uint64(i) < rest {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+// BillService keeps split bills and issues their payment requests
+type BillService struct {
+	mu       sync.Mutex
+	requests *PaymentRequestService
+	bills    map[int64]*Bill
+	nextID   int64
+	db       database.BillRepo
+}
+
+func NewBillService(requests *PaymentRequestService) *BillService {
+	return &BillService{
+		requests: requests,
+		bills:    make(map[int64]*Bill),
+		nextID:   1,
+	}
+}
+
+// Reset drops every bill along with the payment requests settling it, after
+// the chain was reset
+func (bs *BillService) Reset() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.bills = make(map[int64]*Bill)
+}
+
+// SetDatabase enables persistence and reloads previous bills
+func (bs *BillService) SetDatabase(db database.BillRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetBills(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load bills from database: %v", err)
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.db = db
+	for _, row := range rows {
+		b := &Bill{
+			ID:           row["id"].(int64),
+			Creator:      row["creator"].(string),
+			Total:        row["total"].(uint64),
+			CreatorShare: row["creator_share"].(uint64),
+			Note:         row["note"].(string),
+			CreatedAt:    row["created_at"].(time.Time),
+		}
+		if t, ok := row["settled_at"].(*time.Time); ok {
+			b.SettledAt = t
+		}
+		bs.bills[b.ID] = b
+		if b.ID >= bs.nextID {
+			bs.nextID = b.ID + 1
+		}
+	}
+}
+
+// Create splits total among the participants and requests each share from
+// its wallet. Shares are equal when no participant names an amount, and
+// must add up to total when they all do. With includeCreator an equal split
+// also counts the creator, whose share is not requested. A zero expiresAt
+// gives the requests the default expiry.
+func (bs *BillService) Create(creator string, total uint64, note string, participants []BillParticipant, includeCreator bool, expiresAt time.Time) (Bill, error) {
+	shares, creatorShare, err := splitBill(creator, total, participants, includeCreator)
+	if err != nil {
+		return Bill{}, err
+	}
+	if len(note) > MaxBillNoteLength {
+		return Bill{}, fmt.Errorf("%w: the note is longer than %d bytes", ErrInvalidBill, MaxBillNoteLength)
+	}
+	for _, p := range participants {
+		if err := bs.requests.check(creator, p.WalletID, "", &expiresAt); err != nil {
+			return Bill{}, err
+		}
+	}
+
+	bs.mu.Lock()
+	b := &Bill{
+		ID:           bs.nextID,
+		Creator:      creator,
+		Total:        total,
+		CreatorShare: creatorShare,
+		Note:         note,
+		CreatedAt:    time.Now().UTC(),
+	}
+	bs.nextID++
+	bs.bills[b.ID] = b
+	snapshot := *b
+	bs.mu.Unlock()
+
+	bs.persist(snapshot)
+	requestNote := BillNote(b.ID, note)
+	for i, p := range participants {
+		bs.requests.create(creator, p.WalletID, shares[i], requestNote, expiresAt, b.ID)
+	}
+	return bs.view(snapshot), nil
+}
+
+// splitBill returns each participant's share and the creator's
+func splitBill(creator string, total uint64, participants []BillParticipant, includeCreator bool) ([]uint64, uint64, error) {
+	if len(participants) == 0 || len(participants) > MaxBillParticipants {
+		return nil, 0, fmt.Errorf("%w: a bill is split among 1 to %d wallets", ErrInvalidBill, MaxBillParticipants)
+	}
+	custom := 0
+	seen := make(map[string]bool)
+	for _, p := range participants {
+		switch {
+		case p.WalletID == creator:
+			return nil, 0, fmt.Errorf("%w: the creator cannot be a participant; use include_creator", ErrInvalidBill)
+		case seen[p.WalletID]:
+			return nil, 0, fmt.Errorf("%w: %s appears more than once", ErrInvalidBill, p.WalletID)
+		}
+		seen[p.WalletID] = true
+		if p.Amount > 0 {
+			custom++
+		}
+	}
+
+	if custom == 0 {
+		n := len(participants)
+		if includeCreator {
+			n++
+		}
+		if total < uint64(n) {
+			return nil, 0, fmt.Errorf("%w: the total cannot pay %d shares", ErrInvalidBill, n)
+		}
+		split := SplitEqually(total, n)
+		if includeCreator {
+			// The creator takes the last, possibly smaller, share
+			return split[:len(participants)], split[n-1], nil
+		}
+		return split, 0, nil
+	}
+	if custom != len(participants) {
+		return nil, 0, fmt.Errorf("%w: give every participant an amount, or none for an equal split", ErrInvalidBill)
+	}
+
+	shares := make([]uint64, len(participants))
+	var sum uint64
+	for i, p := range participants {
+		shares[i] = p.Amount
+		sum += p.Amount
+	}
+	switch {
+	case sum > total:
+		return nil, 0, fmt.Errorf("%w: the shares add up to %d, more than the total of %d", ErrInvalidBill, sum, total)
+	case sum < total && !includeCreator:
+		return nil, 0, fmt.Errorf("%w: the shares add up to %d, not the total of %d", ErrInvalidBill, sum, total)
+	}
+	// With include_creator, the creator covers what the shares leave
+	return shares, total - sum, nil
+}
+
+// BillNote returns the note of the payment requests of a bill
+func BillNote(id int64, note string) string {
+	ref := fmt.Sprintf("Bill %d", id)
+	if note != "" {
+		ref += ": " + note
+	}
+	return ref
+}
+
+// Get returns a bill with the state of its shares
+func (bs *BillService) Get(id int64) (Bill, bool) {
+	bs.mu.Lock()
+	b, ok := bs.bills[id]
+	if !ok {
+		bs.mu.Unlock()
+		return Bill{}, false
+	}
+	snapshot := *b
+	bs.mu.Unlock()
+	return bs.view(snapshot), true
+}
+
+// ForWallet returns the bills a wallet created or has a share in, newest
+// first
+func (bs *BillService) ForWallet(walletID string) []Bill {
+	bs.mu.Lock()
+	all := make([]Bill, 0, len(bs.bills))
+	for _, b := range bs.bills {
+		all = append(all, *b)
+	}
+	bs.mu.Unlock()
+
+	var list []Bill
+	for _, b := range all {
+		b = bs.view(b)
+		mine := b.Creator == walletID
+		for _, sh := range b.Shares {
+			mine = mine || sh.Payer == walletID
+		}
+		if mine {
+			list = append(list, b)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// ShareAccepted records that a share of bill id was paid and reports, once,
+// the bill becoming settled by it
+func (bs *BillService) ShareAccepted(id int64) (Bill, bool) {
+	b, ok := bs.Get(id)
+	if !ok || b.Status != BillSettled {
+		return b, false
+	}
+
+	bs.mu.Lock()
+	stored := bs.bills[id]
+	if stored.SettledAt != nil {
+		bs.mu.Unlock()
+		return b, false
+	}
+	now := time.Now().UTC()
+	stored.SettledAt = &now
+	snapshot := *stored
+	bs.mu.Unlock()
+
+	bs.persist(snapshot)
+	b.SettledAt = &now
+	return b, true
+}
+
+// view fills in a bill's shares and status from its payment requests
+func (bs *BillService) view(b Bill) Bill {
+	b.Shares = []BillShare{}
+	b.Paid, b.Outstanding = 0, 0
+	pending, closed := 0, 0
+	for _, pr := range bs.requests.ForBill(b.ID) {
+		b.Shares = append(b.Shares, BillShare{Payer: pr.Payer, Amount: pr.Amount, RequestID: pr.ID, Status: pr.Status, TxID: pr.TxID})
+		switch pr.Status {
+		case PaymentRequestAccepted:
+			b.Paid += pr.Amount
+		case PaymentRequestPending:
+			b.Outstanding += pr.Amount
+			pending++
+		default:
+			closed++
+		}
+	}
+	switch {
+	case pending > 0:
+		b.Status = BillOpen
+	case closed > 0:
+		b.Status = BillIncomplete
+	default:
+		b.Status = BillSettled
+	}
+	return b
+}
+
+func (bs *BillService) persist(b Bill) {
+	bs.mu.Lock()
+	db := bs.db
+	bs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveBill(ctx, b.ID, b.Creator, b.Total, b.CreatorShare, b.Note, b.CreatedAt, b.SettledAt); err != nil {
+		log.Printf("Failed to persist bill %d: %v", b.ID, err)
+	}
+}
+
+// String names the bill in logs
+func (b Bill) String() string {
+	return fmt.Sprintf("bill %d", b.ID)
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// Campaign statuses. An active campaign past its deadline takes no more
+// donations but keeps its status until an admin closes it.
+const (
+	CampaignActive = "active"
+	CampaignClosed = "closed"
+)
+
+// MaxDonationMessageLength leaves room in the note for the campaign reference
+const MaxDonationMessageLength = 200
+
+// Errors returned by the campaign service
+var (
+	ErrCampaignNotFound      = errors.New("campaign not found")
+	ErrCampaignClosed        = errors.New("campaign is closed or past its deadline")
+	ErrCampaignHasDonations  = errors.New("campaign has donations; close it instead")
+	ErrInvalidCampaignWallet = errors.New("destination wallet not found or inactive")
+	ErrDeadlinePassed        = errors.New("deadline must be in the future")
+)
+
+// Campaign raises coins for a destination wallet until its deadline.
+// Donations are transactions of type donation to that wallet whose note
+// starts with the campaign reference, so progress is read from the chain.
+type Campaign struct {
+	ID                int64      `json:"id"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description,omitempty"`
+	TargetAmount      uint64     `json:"target_amount"`
+	Deadline          time.Time  `json:"deadline"`
+	DestinationWallet string     `json:"destination_wallet"`
+	Status            string     `json:"status"`
+	CreatedBy         string     `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty"`
+}
+
+// Open reports whether the campaign takes donations at the given time
+func (c Campaign) Open(now time.Time) bool {
+	return c.Status == CampaignActive && now.Before(c.Deadline)
+}
+
+// CampaignUpdate changes a campaign's details; nil fields are kept
+type CampaignUpdate struct {
+	Name         *string
+	Description  *string
+	TargetAmount *uint64
+	Deadline     *time.Time
+}
+
+// Donation is one donation to a campaign
+type Donation struct {
+	TxID      string `json:"txid"`
+	DonorID   string `json:"donor_id"`
+	Amount    uint64 `json:"amount"`
+	Message   string `json:"message,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Confirmed bool   `json:"confirmed"` // mined; pending otherwise
+}
+
+// CampaignProgress is a campaign with what it has raised
+type CampaignProgress struct {
+	Campaign
+	Open          bool    `json:"open"`
+	Raised        uint64  `json:"raised"`  // mined donations
+	Pending       uint64  `json:"pending"` // donations waiting in the pool
+	Donations     int     `json:"donations"`
+	Donors        int     `json:"donors"`
+	PercentFunded float64 `json:"percent_funded"` // raised against the target, may pass 100
+}
+
+// CampaignTotals sums every campaign's progress, for the system report
+type CampaignTotals struct {
+	Campaigns    int    `json:"campaigns"`
+	Open         int    `json:"open"`
+	TargetAmount uint64 `json:"target_amount"`
+	Raised       uint64 `json:"raised"`
+	Pending      uint64 `json:"pending"`
+	Donations    int    `json:"donations"`
+}
+
+// DonationNote returns the note of a donation to the campaign, carrying the
+// donor's optional message after the reference
+func DonationNote(campaignID int64, message string) string {
+	note := "campaign:" + strconv.FormatInt(campaignID, 10)
+	if message != "" {
+		note += " " + message
+	}
+	return note
+}
+
+// parseDonationNote returns the campaign a donation note names and the
+// donor's message
+func parseDonationNote(note string) (int64, string, bool) {
+	rest, ok := strings.CutPrefix(note, "campaign:")
+	if !ok {
+		return 0, "", false
+	}
+	ref, message, _ := strings.Cut(rest, " ")
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return id, message, true
+}
+
+// CampaignService keeps the fundraising campaigns admins create and reads
+// their donations from the chain
+type CampaignService struct {
+	mu        sync.Mutex
+	bc        *blockchain.Blockchain
+	ws        *wallet.Store
+	campaigns map[int64]*Campaign
+	nextID    int64
+	db        database.CampaignRepo
+}
+
+func NewCampaignService(bc *blockchain.Blockchain, ws *wallet.Store) *CampaignService {
+	return &CampaignService{
+		bc:        bc,
+		ws:        ws,
+		campaigns: make(map[int64]*Campaign),
+		nextID:    1,
+	}
+}
+
+// SetDatabase enables persistence and reloads previous campaigns
+func (cs *CampaignService) SetDatabase(db database.CampaignRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetCampaigns(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load campaigns from database: %v", err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.db = db
+	for _, row := range rows {
+		c := &Campaign{
+			ID:                row["id"].(int64),
+			Name:              row["name"].(string),
+			Description:       row["description"].(string),
+			TargetAmount:      row["target_amount"].(uint64),
+			Deadline:          row["deadline"].(time.Time),
+			DestinationWallet: row["destination_wallet"].(string),
+			Status:            row["status"].(string),
+			CreatedBy:         row["created_by"].(string),
+			CreatedAt:         row["created_at"].(time.Time),
+		}
+		if t, ok := row["closed_at"].(*time.Time); ok {
+			c.ClosedAt = t
+		}
+		cs.campaigns[c.ID] = c
+		if c.ID >= cs.nextID {
+			cs.nextID = c.ID + 1
+		}
+	}
+}
+
+// Create starts a campaign raising coins for an active wallet
+func (cs *CampaignService) Create(name, description string, target uint64, deadline time.Time, destination, createdBy string) (*Campaign, error) {
+	if w, ok := cs.ws.Get(destination); !ok || !w.Active() {
+		return nil, ErrInvalidCampaignWallet
+	}
+	if !deadline.After(time.Now()) {
+		return nil, ErrDeadlinePassed
+	}
+
+	cs.mu.Lock()
+	c := &Campaign{
+		ID:                cs.nextID,
+		Name:              name,
+		Description:       description,
+		TargetAmount:      target,
+		Deadline:          deadline.UTC(),
+		DestinationWallet: destination,
+		Status:            CampaignActive,
+		CreatedBy:         createdBy,
+		CreatedAt:         time.Now(),
+	}
+	cs.nextID++
+	cs.campaigns[c.ID] = c
+	snapshot := *c
+	cs.mu.Unlock()
+
+	cs.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Update changes an active campaign's details. The destination cannot
+// change, since donations already made went to it.
+func (cs *CampaignService) Update(id int64, u CampaignUpdate) (*Campaign, error) {
+	if u.Deadline != nil && !u.Deadline.After(time.Now()) {
+		return nil, ErrDeadlinePassed
+	}
+
+	cs.mu.Lock()
+	c, ok := cs.campaigns[id]
+	if !ok {
+		cs.mu.Unlock()
+		return nil, ErrCampaignNotFound
+	}
+	if c.Status != CampaignActive {
+		cs.mu.Unlock()
+		return nil, ErrCampaignClosed
+	}
+	if u.Name != nil {
+		c.Name = *u.Name
+	}
+	if u.Description != nil {
+		c.Description = *u.Description
+	}
+	if u.TargetAmount != nil {
+		c.TargetAmount = *u.TargetAmount
+	}
+	if u.Deadline != nil {
+		c.Deadline = u.Deadline.UTC()
+	}
+	snapshot := *c
+	cs.mu.Unlock()
+
+	cs.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Close stops a campaign taking donations
+func (cs *CampaignService) Close(id int64) (*Campaign, error) {
+	cs.mu.Lock()
+	c, ok := cs.campaigns[id]
+	if !ok {
+		cs.mu.Unlock()
+		return nil, ErrCampaignNotFound
+	}
+	if c.Status == CampaignClosed {
+		cs.mu.Unlock()
+		return nil, ErrCampaignClosed
+	}
+	now := time.Now()
+	c.Status = CampaignClosed
+	c.ClosedAt = &now
+	snapshot := *c
+	cs.mu.Unlock()
+
+	cs.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Delete removes a campaign nobody has donated to
+func (cs *CampaignService) Delete(id int64) error {
+	c, ok := cs.Get(id)
+	if !ok {
+		return ErrCampaignNotFound
+	}
+	if p := cs.Progress(c); p.Donations > 0 {
+		return ErrCampaignHasDonations
+	}
+
+	cs.mu.Lock()
+	db := cs.db
+	cs.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.DeleteCampaign(ctx, id); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	delete(cs.campaigns, id)
+	cs.mu.Unlock()
+	return nil
+}
+
+// Get returns a campaign
+func (cs *CampaignService) Get(id int64) (Campaign, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, ok := cs.campaigns[id]
+	if !ok {
+		return Campaign{}, false
+	}
+	return *c, true
+}
+
+// List returns every campaign, newest first
+func (cs *CampaignService) List() []Campaign {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	list := make([]Campaign, 0, len(cs.campaigns))
+	for _, c := range cs.campaigns {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// CheckDonation returns the campaign a donation goes to if it is open
+func (cs *CampaignService) CheckDonation(id int64) (Campaign, error) {
+	c, ok := cs.Get(id)
+	if !ok {
+		return Campaign{}, ErrCampaignNotFound
+	}
+	if !c.Open(time.Now()) {
+		return Campaign{}, ErrCampaignClosed
+	}
+	return c, nil
+}
+
+// Progress returns what a campaign has raised
+func (cs *CampaignService) Progress(c Campaign) CampaignProgress {
+	return cs.ProgressAll([]Campaign{c})[0]
+}
+
+// ProgressAll returns what each campaign has raised, reading the chain once
+func (cs *CampaignService) ProgressAll(campaigns []Campaign) []CampaignProgress {
+	byID := make(map[int64]int, len(campaigns))
+	progress := make([]CampaignProgress, len(campaigns))
+	donors := make([]map[string]bool, len(campaigns))
+	now := time.Now()
+	for i, c := range campaigns {
+		byID[c.ID] = i
+		progress[i] = CampaignProgress{Campaign: c, Open: c.Open(now)}
+		donors[i] = make(map[string]bool)
+	}
+
+	cs.eachDonation(func(id int64, d Donation) {
+		i, ok := byID[id]
+		if !ok {
+			return
+		}
+		if d.Confirmed {
+			progress[i].Raised += d.Amount
+		} else {
+			progress[i].Pending += d.Amount
+		}
+		progress[i].Donations++
+		donors[i][d.DonorID] = true
+	})
+
+	for i := range progress {
+		progress[i].Donors = len(donors[i])
+		if t := progress[i].TargetAmount; t > 0 {
+			progress[i].PercentFunded = float64(progress[i].Raised) * 100 / float64(t)
+		}
+	}
+	return progress
+}
+
+// Donations returns a campaign's donations, newest first
+func (cs *CampaignService) Donations(c Campaign) []Donation {
+	donations := make([]Donation, 0)
+	cs.eachDonation(func(id int64, d Donation) {
+		if id == c.ID {
+			donations = append(donations, d)
+		}
+	})
+	sort.SliceStable(donations, func(i, j int) bool { return donations[i].Timestamp > donations[j].Timestamp })
+	return donations
+}
+
+// Totals sums the progress of the given campaigns
+func (cs *CampaignService) Totals(campaigns []Campaign) CampaignTotals {
+	var t CampaignTotals
+	for _, p := range cs.ProgressAll(campaigns) {
+		t.Campaigns++
+		if p.Open {
+			t.Open++
+		}
+		t.TargetAmount += p.TargetAmount
+		t.Raised += p.Raised
+		t.Pending += p.Pending
+		t.Donations += p.Donations
+	}
+	return t
+}
+
+// eachDonation calls fn for every mined and pending donation paid to the
+// destination of the campaign its note names
+func (cs *CampaignService) eachDonation(fn func(campaignID int64, d Donation)) {
+	cs.mu.Lock()
+	destinations := make(map[int64]string, len(cs.campaigns))
+	for id, c := range cs.campaigns {
+		destinations[id] = c.DestinationWallet
+	}
+	cs.mu.Unlock()
+
+	visit := func(tx blockchain.Transaction, confirmed bool) {
+		if tx.Type != "donation" {
+			return
+		}
+		id, message, ok := parseDonationNote(tx.Note)
+		if !ok || destinations[id] != tx.ReceiverID {
+			return
+		}
+		fn(id, Donation{
+			TxID:      tx.ID,
+			DonorID:   tx.SenderID,
+			Amount:    tx.Amount,
+			Message:   message,
+			Timestamp: tx.Timestamp,
+			Confirmed: confirmed,
+		})
+	}
+
+	cs.bc.View(func(v blockchain.View) {
+		for block := range v.Blocks() {
+			for _, tx := range block.Transactions {
+				visit(tx, true)
+			}
+		}
+		for tx := range v.Pending() {
+			visit(tx, false)
+		}
+	})
+}
+
+func (cs *CampaignService) persist(c Campaign) {
+	cs.mu.Lock()
+	db := cs.db
+	cs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveCampaign(ctx, c.ID, c.Name, c.Description, c.TargetAmount, c.Deadline, c.DestinationWallet, c.Status, c.CreatedBy, c.CreatedAt, c.ClosedAt); err != nil {
+		log.Printf("Failed to persist campaign %d: %v", c.ID, err)
+	}
+}
+
+// String names the campaign in logs
+func (c Campaign) String() string {
+	return fmt.Sprintf("campaign %d (%s)", c.ID, c.Name)
+}
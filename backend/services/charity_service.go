@@ -0,0 +1,557 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// ZakatPoolWallet collects zakat deductions until they are distributed
+const ZakatPoolWallet = "ZAKAT_POOL"
+
+// Limits on a charity's weight in the zakat split
+const (
+	MinCharityWeight = 1
+	MaxCharityWeight = 1000
+)
+
+// Zakat distribution statuses
+const (
+	DistributionSplitting = "splitting" // the split is queued; charities are paid once it is spendable
+	DistributionPaid      = "paid"      // every charity's transaction is queued
+	DistributionFailed    = "failed"    // the split was dropped; its coins stay in the pool
+)
+
+// Errors returned by the charity service
+var (
+	ErrCharityNotFound      = errors.New("charity not found")
+	ErrInvalidCharityWallet = errors.New("charity wallet not found or inactive")
+	ErrInvalidWeight        = fmt.Errorf("weight must be from %d to %d", MinCharityWeight, MaxCharityWeight)
+)
+
+// Charity is a wallet approved to receive a weighted share of the zakat pool
+type Charity struct {
+	WalletID  string    `json:"wallet_id"`
+	Name      string    `json:"name"`
+	Weight    int       `json:"weight"`
+	AddedBy   string    `json:"added_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CharityShare is one charity's part of a zakat distribution. The split
+// transaction's output at the share's position in the distribution holds it.
+type CharityShare struct {
+	WalletID string `json:"wallet_id"`
+	Name     string `json:"name"`
+	Weight   int    `json:"weight"`
+	Amount   uint64 `json:"amount"`
+	PayTo    string `json:"pay_to,omitempty"` // the charity's current wallet after key rotations
+	TxID     string `json:"txid,omitempty"`
+	Skipped  string `json:"skipped,omitempty"` // why the share stayed in the pool
+}
+
+// ZakatDistribution splits the zakat collected in the pool between the
+// registered charities by weight. The split is one system transaction with
+// an output per charity; each output is then sent to its charity in a
+// zakat_distribution transaction once it is spendable.
+type ZakatDistribution struct {
+	ID            int64          `json:"id"`
+	Status        string         `json:"status"`
+	Amount        uint64         `json:"amount"`
+	SplitTxID     string         `json:"split_txid"`
+	Shares        []CharityShare `json:"shares"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DistributedAt *time.Time     `json:"distributed_at,omitempty"`
+}
+
+// CharityService keeps the charity registry and distributes the zakat pool
+// between the charities after each zakat run
+type CharityService struct {
+	bc   *blockchain.Blockchain
+	ws   *wallet.Store
+	feed *events.Feed
+
+	// work serialises splits and payouts, which queue transactions
+	work sync.Mutex
+
+	mu            sync.Mutex
+	charities     map[string]*Charity
+	distributions map[int64]*ZakatDistribution
+	nextID        int64
+	db            database.CharityRepo
+}
+
+func NewCharityService(bc *blockchain.Blockchain, ws *wallet.Store, feed *events.Feed) *CharityService {
+	return &CharityService{
+		bc:            bc,
+		ws:            ws,
+		feed:          feed,
+		charities:     make(map[string]*Charity),
+		distributions: make(map[int64]*ZakatDistribution),
+		nextID:        1,
+	}
+}
+
+// Reset drops the zakat distributions after the chain was reset. The
+// charity registry stays.
+func (cs *CharityService) Reset() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.distributions = make(map[int64]*ZakatDistribution)
+}
+
+// SetDatabase enables persistence and reloads the registry and previous
+// distributions
+func (cs *CharityService) SetDatabase(db database.CharityRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	charities, err := db.GetCharities(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load charities from database: %v", err)
+	}
+	distributions, err := db.GetZakatDistributions(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load zakat distributions from database: %v", err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.db = db
+	for _, row := range charities {
+		c := &Charity{
+			WalletID:  row["wallet_id"].(string),
+			Name:      row["name"].(string),
+			Weight:    row["weight"].(int),
+			AddedBy:   row["added_by"].(string),
+			CreatedAt: row["created_at"].(time.Time),
+			UpdatedAt: row["updated_at"].(time.Time),
+		}
+		cs.charities[c.WalletID] = c
+	}
+	for _, row := range distributions {
+		d := &ZakatDistribution{
+			ID:        row["id"].(int64),
+			Status:    row["status"].(string),
+			Amount:    row["amount"].(uint64),
+			SplitTxID: row["split_txid"].(string),
+			CreatedAt: row["created_at"].(time.Time),
+		}
+		if err := json.Unmarshal([]byte(row["shares"].(string)), &d.Shares); err != nil {
+			log.Printf("⚠️  Ignoring unreadable shares of zakat distribution %d: %v", d.ID, err)
+		}
+		if t, ok := row["distributed_at"].(*time.Time); ok {
+			d.DistributedAt = t
+		}
+		cs.distributions[d.ID] = d
+		if d.ID >= cs.nextID {
+			cs.nextID = d.ID + 1
+		}
+	}
+}
+
+// Set registers a charity or changes its name and weight
+func (cs *CharityService) Set(walletID, name string, weight int, actor string) (*Charity, error) {
+	if weight < MinCharityWeight || weight > MaxCharityWeight {
+		return nil, ErrInvalidWeight
+	}
+	if w, ok := cs.ws.Get(walletID); !ok || !w.Active() {
+		return nil, ErrInvalidCharityWallet
+	}
+
+	now := time.Now()
+	cs.mu.Lock()
+	c, ok := cs.charities[walletID]
+	if !ok {
+		c = &Charity{WalletID: walletID, AddedBy: actor, CreatedAt: now}
+		cs.charities[walletID] = c
+	}
+	c.Name, c.Weight, c.UpdatedAt = name, weight, now
+	snapshot := *c
+	db := cs.db
+	cs.mu.Unlock()
+
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.SaveCharity(ctx, snapshot.WalletID, snapshot.Name, snapshot.Weight, snapshot.AddedBy, snapshot.CreatedAt, snapshot.UpdatedAt); err != nil {
+			log.Printf("Failed to persist charity %s: %v", walletID, err)
+		}
+	}
+	return &snapshot, nil
+}
+
+// Remove takes a charity out of the registry. Shares already split for it
+// are still paid.
+func (cs *CharityService) Remove(walletID string) error {
+	cs.mu.Lock()
+	if _, ok := cs.charities[walletID]; !ok {
+		cs.mu.Unlock()
+		return ErrCharityNotFound
+	}
+	delete(cs.charities, walletID)
+	db := cs.db
+	cs.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return db.DeleteCharity(ctx, walletID)
+}
+
+// List returns the registered charities, heaviest first
+func (cs *CharityService) List() []Charity {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	list := make([]Charity, 0, len(cs.charities))
+	for _, c := range cs.charities {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Weight != list[j].Weight {
+			return list[i].Weight > list[j].Weight
+		}
+		return list[i].WalletID < list[j].WalletID
+	})
+	return list
+}
+
+// Distributions returns the zakat distributions, newest first
+func (cs *CharityService) Distributions() []ZakatDistribution {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	list := make([]ZakatDistribution, 0, len(cs.distributions))
+	for _, d := range cs.distributions {
+		list = append(list, cs.copyDistribution(d))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Split queues a system transaction dividing the spendable zakat in the pool
+// between the active charities by weight. It returns nil when there is
+// nothing to split, no charity to split it between, or an earlier split is
+// still being paid out.
+func (cs *CharityService) Split() (*ZakatDistribution, error) {
+	cs.work.Lock()
+	defer cs.work.Unlock()
+
+	cs.mu.Lock()
+	for _, d := range cs.distributions {
+		if d.Status == DistributionSplitting {
+			cs.mu.Unlock()
+			return nil, nil
+		}
+	}
+	cs.mu.Unlock()
+
+	var charities []Charity
+	for _, c := range cs.List() {
+		if w, ok := cs.ws.Get(c.WalletID); ok && w.Active() {
+			charities = append(charities, c)
+		}
+	}
+	if len(charities) == 0 {
+		return nil, nil
+	}
+
+	utxos := cs.collected()
+	if len(utxos) == 0 {
+		return nil, nil
+	}
+	var total uint64
+	for _, u := range utxos {
+		total += u.Amount
+	}
+
+	shares := splitByWeight(total, charities)
+	outputs := make([]blockchain.UTXO, len(shares))
+	for i, s := range shares {
+		outputs[i] = blockchain.UTXO{Owner: ZakatPoolWallet, Amount: s.Amount, Index: i}
+	}
+	tx := buildConsolidation(ZakatPoolWallet, utxos, 0, fmt.Sprintf("Zakat split for %d charities", len(shares)))
+	tx.Outputs = outputs
+	tx.Type = "zakat_split"
+	tx.PubKey = "system"
+	tx.Signature = "system"
+	blockchain.AssignID(tx)
+	if err := cs.bc.AddPending(*tx); err != nil {
+		return nil, err
+	}
+	cs.feed.PublishTransaction(events.TxPending, *tx, nil)
+	cs.persistTx(tx)
+
+	cs.mu.Lock()
+	d := &ZakatDistribution{
+		ID:        cs.nextID,
+		Status:    DistributionSplitting,
+		Amount:    total,
+		SplitTxID: tx.ID,
+		Shares:    shares,
+		CreatedAt: time.Now(),
+	}
+	cs.nextID++
+	cs.distributions[d.ID] = d
+	snapshot := cs.copyDistribution(d)
+	cs.mu.Unlock()
+
+	cs.persist(snapshot)
+	log.Printf("🕌 Zakat distribution %d: %d split between %d charities", d.ID, total, len(shares))
+	return &snapshot, nil
+}
+
+// collected returns the spendable zakat in the pool: outputs of zakat
+// deductions, and of earlier splits whose charity could not be paid. The
+// pool's mining rewards are not zakat and stay put. At most MaxTxInputs are
+// taken, largest first.
+func (cs *CharityService) collected() []blockchain.UTXO {
+	var candidates []blockchain.UTXO
+	cs.bc.View(func(v blockchain.View) {
+		claimed := make(map[string]bool)
+		for tx := range v.Pending() {
+			for _, in := range tx.Inputs {
+				claimed[blockchain.UTXOKey(in.TxID, in.Index)] = true
+			}
+		}
+		for _, u := range v.OwnedUTXOs(ZakatPoolWallet) {
+			if v.Spendable(u) && !claimed[blockchain.UTXOKey(u.OriginTx, u.Index)] {
+				candidates = append(candidates, u)
+			}
+		}
+	})
+
+	var utxos []blockchain.UTXO
+	for _, u := range candidates {
+		loc, ok := cs.bc.GetTransactionByID(u.OriginTx)
+		if ok && (loc.Transaction.Type == "zakat_deduction" || loc.Transaction.Type == "zakat_split") {
+			utxos = append(utxos, u)
+		}
+	}
+	sort.Slice(utxos, func(i, j int) bool { return utxos[i].Amount > utxos[j].Amount })
+	if len(utxos) > validation.MaxTxInputs {
+		utxos = utxos[:validation.MaxTxInputs]
+	}
+	return utxos
+}
+
+// splitByWeight divides total between the charities in proportion to their
+// weights. Rounding leftovers go to the largest remainders, so the shares
+// add up to total; charities whose share rounds to nothing are left out.
+func splitByWeight(total uint64, charities []Charity) []CharityShare {
+	var weights uint64
+	for _, c := range charities {
+		weights += uint64(c.Weight)
+	}
+
+	shares := make([]CharityShare, len(charities))
+	remainders := make([]uint64, len(charities))
+	var assigned uint64
+	for i, c := range charities {
+		w := uint64(c.Weight)
+		// total*w/weights without overflowing for large totals
+		amount := total/weights*w + total%weights*w/weights
+		shares[i] = CharityShare{WalletID: c.WalletID, Name: c.Name, Weight: c.Weight, Amount: amount}
+		remainders[i] = total % weights * w % weights
+		assigned += amount
+	}
+	order := make([]int, len(charities))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; assigned < total; i++ {
+		shares[order[i%len(order)]].Amount++
+		assigned++
+	}
+
+	kept := shares[:0]
+	for _, s := range shares {
+		if s.Amount > 0 {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// Pay sends each charity its output of any split that is now spendable, and
+// returns how many distributions were fully paid
+func (cs *CharityService) Pay() int {
+	cs.work.Lock()
+	defer cs.work.Unlock()
+
+	cs.mu.Lock()
+	var open []int64
+	for id, d := range cs.distributions {
+		if d.Status == DistributionSplitting {
+			open = append(open, id)
+		}
+	}
+	cs.mu.Unlock()
+
+	paid := 0
+	for _, id := range open {
+		if cs.pay(id) {
+			paid++
+		}
+	}
+	return paid
+}
+
+// pay sends the shares of one distribution whose split outputs are
+// spendable, and reports whether every share is now paid or skipped
+func (cs *CharityService) pay(id int64) bool {
+	cs.mu.Lock()
+	d := cs.copyDistribution(cs.distributions[id])
+	cs.mu.Unlock()
+
+	type transfer struct {
+		share int
+		input blockchain.UTXO
+	}
+	var ready []transfer
+	splitPending := false
+	waiting := false
+	cs.bc.View(func(v blockchain.View) {
+		for tx := range v.Pending() {
+			if tx.ID == d.SplitTxID {
+				splitPending = true
+				break
+			}
+		}
+		for i, s := range d.Shares {
+			if s.TxID != "" || s.Skipped != "" {
+				continue
+			}
+			utxo, ok := v.UTXO(blockchain.UTXOKey(d.SplitTxID, i))
+			switch {
+			case !ok:
+				waiting = true
+			case utxo.Spent:
+				d.Shares[i].Skipped = "earmarked output was spent"
+			case !v.Spendable(utxo):
+				waiting = true
+			default:
+				ready = append(ready, transfer{share: i, input: utxo})
+			}
+		}
+	})
+
+	if waiting && !splitPending {
+		if _, found := cs.bc.GetTransactionByID(d.SplitTxID); !found {
+			cs.finish(id, DistributionFailed, d.Shares)
+			log.Printf("⚠️  Zakat distribution %d failed: the split transaction was dropped", id)
+			return false
+		}
+	}
+
+	for _, t := range ready {
+		s := &d.Shares[t.share]
+		s.PayTo = followRotations(cs.ws, s.WalletID)
+		if w, ok := cs.ws.Get(s.PayTo); !ok || !w.Active() {
+			// The output stays in the pool and goes into the next split
+			s.Skipped = "charity wallet is not active"
+			continue
+		}
+		tx := &blockchain.Transaction{
+			Version:    blockchain.TxVersion,
+			SenderID:   ZakatPoolWallet,
+			ReceiverID: s.PayTo,
+			Amount:     s.Amount,
+			Note:       fmt.Sprintf("Zakat distribution %d to %s", id, s.Name),
+			Timestamp:  time.Now().Unix(),
+			PubKey:     "system",
+			Signature:  "system",
+			Inputs:     []blockchain.UTXORef{{TxID: t.input.OriginTx, Index: t.input.Index}},
+			Outputs:    []blockchain.UTXO{{Owner: s.PayTo, Amount: s.Amount, Index: 0}},
+			Type:       "zakat_distribution",
+		}
+		blockchain.AssignID(tx)
+		if err := cs.bc.AddPending(*tx); err != nil {
+			log.Printf("⚠️  Zakat distribution %d to %s rejected: %v", id, s.PayTo, err)
+			waiting = true
+			continue
+		}
+		cs.feed.PublishTransaction(events.TxPending, *tx, nil)
+		cs.persistTx(tx)
+		s.TxID = tx.ID
+	}
+
+	if waiting {
+		cs.finish(id, DistributionSplitting, d.Shares)
+		return false
+	}
+	cs.finish(id, DistributionPaid, d.Shares)
+	log.Printf("🕌 Zakat distribution %d paid to its charities", id)
+	return true
+}
+
+// finish stores a distribution's shares and status
+func (cs *CharityService) finish(id int64, status string, shares []CharityShare) {
+	cs.mu.Lock()
+	stored := cs.distributions[id]
+	stored.Shares = shares
+	stored.Status = status
+	if status == DistributionPaid {
+		now := time.Now()
+		stored.DistributedAt = &now
+	}
+	snapshot := cs.copyDistribution(stored)
+	cs.mu.Unlock()
+
+	cs.persist(snapshot)
+}
+
+// copyDistribution copies d with its own share slice. The caller must hold mu.
+func (cs *CharityService) copyDistribution(d *ZakatDistribution) ZakatDistribution {
+	c := *d
+	c.Shares = append([]CharityShare(nil), d.Shares...)
+	return c
+}
+
+func (cs *CharityService) persist(d ZakatDistribution) {
+	cs.mu.Lock()
+	db := cs.db
+	cs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	shares, err := json.Marshal(d.Shares)
+	if err != nil {
+		log.Printf("Failed to encode shares of zakat distribution %d: %v", d.ID, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveZakatDistribution(ctx, d.ID, d.Status, d.Amount, d.SplitTxID, string(shares), d.CreatedAt, d.DistributedAt); err != nil {
+		log.Printf("Failed to persist zakat distribution %d: %v", d.ID, err)
+	}
+}
+
+func (cs *CharityService) persistTx(tx *blockchain.Transaction) {
+	cs.mu.Lock()
+	db := cs.db
+	cs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+		log.Printf("Failed to persist zakat distribution transaction %s: %v", tx.ID, err)
+	}
+}
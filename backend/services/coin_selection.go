@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
+)
+
+// Coin selection strategies for SelectUTXOs
+const (
+	CoinSelectAuto           = "auto"             // exact match if one exists, otherwise whichever strategy leaves the least change
+	CoinSelectBranchAndBound = "branch_and_bound" // exact match only, no change output
+	CoinSelectSmallestFirst  = "smallest_first"   // spends dust first
+	CoinSelectLargestFirst   = "largest_first"    // fewest inputs
+)
+
+// CoinSelectionStrategies lists the strategies SetCoinSelection accepts
+var CoinSelectionStrategies = []string{CoinSelectAuto, CoinSelectBranchAndBound, CoinSelectSmallestFirst, CoinSelectLargestFirst}
+
+// ErrUnknownCoinSelection is returned for a strategy name not in CoinSelectionStrategies
+var ErrUnknownCoinSelection = errors.New("unknown coin selection strategy")
+
+// bnbMaxTries bounds the branch-and-bound search so wallets with thousands of
+// outputs cannot stall a send
+const bnbMaxTries = 100000
+
+// SelectCoins picks outputs worth at least amount using a strategy and
+// returns them with their total. Branch and bound fails with
+// ErrInsufficientBalance when no set of outputs matches the amount exactly.
+func SelectCoins(available []blockchain.UTXO, amount uint64, strategy string) ([]blockchain.UTXO, uint64, error) {
+	if !slices.Contains(CoinSelectionStrategies, strategy) && strategy != "" {
+		return nil, 0, ErrUnknownCoinSelection
+	}
+	if amount == 0 {
+		return nil, 0, nil
+	}
+
+	var selected []blockchain.UTXO
+	switch strategy {
+	case CoinSelectAuto, "":
+		selected = selectAuto(available, amount)
+	case CoinSelectBranchAndBound:
+		selected = selectBranchAndBound(available, amount)
+	case CoinSelectSmallestFirst:
+		selected = selectSmallestFirst(available, amount)
+	case CoinSelectLargestFirst:
+		selected = selectLargestFirst(available, amount)
+	}
+
+	if selected == nil {
+		if strategy == CoinSelectBranchAndBound && sumUTXOs(available) >= amount {
+			return nil, 0, fmt.Errorf("%w: no outputs add up to exactly %d", ErrInsufficientBalance, amount)
+		}
+		return nil, 0, ErrInsufficientBalance
+	}
+	total := sumUTXOs(selected)
+	return selected, total, nil
+}
+
+// selectAuto prefers an exact match. Failing that it compares the single
+// smallest output covering the amount with largest-first and smallest-first,
+// keeping the one with the least change that fits in a transaction; ties go
+// to fewer inputs.
+func selectAuto(available []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	if exact := selectBranchAndBound(available, amount); exact != nil {
+		return exact
+	}
+
+	var best []blockchain.UTXO
+	var bestChange uint64
+	for _, candidate := range [][]blockchain.UTXO{
+		selectSmallestCovering(available, amount),
+		selectLargestFirst(available, amount),
+		selectSmallestFirst(available, amount),
+	} {
+		if candidate == nil || len(candidate) > validation.MaxTxInputs {
+			continue
+		}
+		change := sumUTXOs(candidate) - amount
+		if best == nil || change < bestChange || (change == bestChange && len(candidate) < len(best)) {
+			best, bestChange = candidate, change
+		}
+	}
+	if best == nil {
+		// Nothing fits in one transaction; the caller reports too many inputs
+		return selectLargestFirst(available, amount)
+	}
+	return best
+}
+
+// selectLargestFirst takes the largest outputs until the amount is covered
+func selectLargestFirst(available []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	return accumulate(sortedUTXOs(available, true), amount)
+}
+
+// selectSmallestFirst takes the smallest outputs until the amount is covered
+func selectSmallestFirst(available []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	return accumulate(sortedUTXOs(available, false), amount)
+}
+
+// selectSmallestCovering returns the smallest single output worth at least
+// the amount, or nil
+func selectSmallestCovering(available []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	for _, utxo := range sortedUTXOs(available, false) {
+		if utxo.Amount >= amount {
+			return []blockchain.UTXO{utxo}
+		}
+	}
+	return nil
+}
+
+// selectBranchAndBound searches depth first, largest outputs first, for a set
+// summing to exactly the amount, so the transaction needs no change output.
+// It returns nil when there is none or the search budget runs out.
+func selectBranchAndBound(available []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	coins := sortedUTXOs(available, true)
+
+	// remaining[i] is the value of coins[i:], to prune branches that cannot
+	// reach the amount
+	remaining := make([]uint64, len(coins)+1)
+	for i := len(coins) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + coins[i].Amount
+	}
+
+	var picked []blockchain.UTXO
+	tries := 0
+	var search func(i int, total uint64) bool
+	search = func(i int, total uint64) bool {
+		if total == amount {
+			return true
+		}
+		if i == len(coins) || total+remaining[i] < amount || len(picked) == validation.MaxTxInputs || tries >= bnbMaxTries {
+			return false
+		}
+		tries++
+
+		if total+coins[i].Amount <= amount {
+			picked = append(picked, coins[i])
+			if search(i+1, total+coins[i].Amount) {
+				return true
+			}
+			picked = picked[:len(picked)-1]
+		}
+
+		// Leaving this coin out also leaves out the equal ones after it;
+		// taking one of those instead would repeat the branch just searched
+		next := i + 1
+		for next < len(coins) && coins[next].Amount == coins[i].Amount {
+			next++
+		}
+		return search(next, total)
+	}
+
+	if !search(0, 0) {
+		return nil
+	}
+	return picked
+}
+
+func accumulate(sorted []blockchain.UTXO, amount uint64) []blockchain.UTXO {
+	var selected []blockchain.UTXO
+	var total uint64
+	for _, utxo := range sorted {
+		if total >= amount {
+			break
+		}
+		selected = append(selected, utxo)
+		total += utxo.Amount
+	}
+	if total < amount {
+		return nil
+	}
+	return selected
+}
+
+// sortedUTXOs returns a copy ordered by amount, ties broken by ID so
+// selection does not depend on map iteration order
+func sortedUTXOs(available []blockchain.UTXO, descending bool) []blockchain.UTXO {
+	sorted := make([]blockchain.UTXO, len(available))
+	copy(sorted, available)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Amount != sorted[j].Amount {
+			if descending {
+				return sorted[i].Amount > sorted[j].Amount
+			}
+			return sorted[i].Amount < sorted[j].Amount
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+func sumUTXOs(utxos []blockchain.UTXO) uint64 {
+	var total uint64
+	for _, utxo := range utxos {
+		total += utxo.Amount
+	}
+	return total
+}
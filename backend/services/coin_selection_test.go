@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"blockchain-backend/blockchain"
+)
+
+// benchmarkUTXOs returns n outputs of mixed sizes, from a cent to five coins
+// in whole cents, the same on every run
+func benchmarkUTXOs(n int) []blockchain.UTXO {
+	rng := rand.New(rand.NewSource(int64(n)))
+	utxos := make([]blockchain.UTXO, n)
+	for i := range utxos {
+		utxos[i] = blockchain.UTXO{
+			ID:       fmt.Sprintf("bench-%d", i),
+			Owner:    "bench",
+			Amount:   uint64(rng.Int63n(500)+1) * (blockchain.UnitsPerCoin / 100),
+			OriginTx: fmt.Sprintf("tx-%d", i),
+		}
+	}
+	return utxos
+}
+
+// BenchmarkSelectCoins runs every strategy over wallets of growing size. The
+// amount is the sum of three of the outputs, so branch and bound has an exact
+// match to find.
+func BenchmarkSelectCoins(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		utxos := benchmarkUTXOs(size)
+		amount := utxos[1].Amount + utxos[size/2].Amount + utxos[size-1].Amount
+		for _, strategy := range CoinSelectionStrategies {
+			b.Run(fmt.Sprintf("%s/%d", strategy, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, _, err := SelectCoins(utxos, amount, strategy); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"text/template"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// ErrInvalidConfig is returned for overrides that do not resolve to a valid configuration
+var ErrInvalidConfig = errors.New("invalid configuration override")
+
+// Email templates an organization can override
+const (
+	EmailOTP = "otp"
+)
+
+// TenantConfig is the configuration in effect for one organization: the
+// deployment defaults with the organization's overrides applied on top
+type TenantConfig struct {
+	FaucetAmount    uint64                   `json:"faucet_amount"` // coins granted to new personal wallets; 0 disables the faucet
+	Zakat           ZakatParams              `json:"zakat"`
+	Fees            FeeSchedule              `json:"fees"`
+	DisplayCurrency string                   `json:"display_currency"` // label clients show next to amounts
+	Branding        Branding                 `json:"branding"`
+	EmailTemplates  map[string]EmailTemplate `json:"email_templates"`
+}
+
+// ZakatParams are the zakat rules applied to an organization's wallets
+type ZakatParams struct {
+	Nisab        uint64  `json:"nisab"`         // minimum balance for eligibility
+	Rate         float64 `json:"rate"`          // share of the balance deducted
+	IntervalDays int     `json:"interval_days"` // days between deductions
+}
+
+// FeeSchedule is the fee charged per transaction type and paid to the miner
+type FeeSchedule struct {
+	Transfer uint64 `json:"transfer"`
+	Anchor   uint64 `json:"anchor"`
+}
+
+// Branding is how clients present the organization
+type Branding struct {
+	DisplayName  string `json:"display_name"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"` // #rrggbb
+}
+
+// EmailTemplate is a text/template pair rendered with EmailData
+type EmailTemplate struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// EmailData is what email templates can refer to
+type EmailData struct {
+	OrgName string // Branding.DisplayName
+	Code    string // one-time code, for EmailOTP
+	Minutes int    // code lifetime in minutes, for EmailOTP
+}
+
+// DefaultTenantConfig is the deployment-wide configuration every
+// organization inherits from
+func DefaultTenantConfig() TenantConfig {
+	return TenantConfig{
+		FaucetAmount: blockchain.FaucetAmount,
+		Zakat: ZakatParams{
+			Nisab:        blockchain.ZakatNisab,
+			Rate:         blockchain.ZakatRate,
+			IntervalDays: blockchain.ZakatIntervalDays,
+		},
+		Fees:            FeeSchedule{Transfer: 0, Anchor: blockchain.AnchorFee},
+		DisplayCurrency: "COIN",
+		Branding:        Branding{DisplayName: "Blockchain Wallet"},
+		EmailTemplates: map[string]EmailTemplate{
+			EmailOTP: {
+				Subject: "Your verification code",
+				Body:    "Your verification code is {{.Code}}. It expires in {{.Minutes}} minutes.",
+			},
+		},
+	}
+}
+
+// Limits on overridable values
+const (
+	MaxFaucetAmount = 1_000_000 * blockchain.UnitsPerCoin
+	maxFee          = 1_000 * blockchain.UnitsPerCoin
+)
+
+var (
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3,8}$`)
+	colorPattern    = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Validate checks that a resolved configuration is usable
+func (c TenantConfig) Validate() error {
+	switch {
+	case c.FaucetAmount > MaxFaucetAmount:
+		return fmt.Errorf("%w: faucet_amount must be at most %d", ErrInvalidConfig, MaxFaucetAmount)
+	case c.Zakat.Rate < 0 || c.Zakat.Rate > 1:
+		return fmt.Errorf("%w: zakat.rate must be between 0 and 1", ErrInvalidConfig)
+	case c.Zakat.IntervalDays < 1:
+		return fmt.Errorf("%w: zakat.interval_days must be at least 1", ErrInvalidConfig)
+	case c.Fees.Transfer > maxFee || c.Fees.Anchor > maxFee:
+		return fmt.Errorf("%w: fees must be at most %d", ErrInvalidConfig, maxFee)
+	case !currencyPattern.MatchString(c.DisplayCurrency):
+		return fmt.Errorf("%w: display_currency must be 3-8 uppercase letters", ErrInvalidConfig)
+	case c.Branding.DisplayName == "":
+		return fmt.Errorf("%w: branding.display_name must not be empty", ErrInvalidConfig)
+	case c.Branding.PrimaryColor != "" && !colorPattern.MatchString(c.Branding.PrimaryColor):
+		return fmt.Errorf("%w: branding.primary_color must look like #1a2b3c", ErrInvalidConfig)
+	}
+	defaults := DefaultTenantConfig().EmailTemplates
+	for name, t := range c.EmailTemplates {
+		if _, known := defaults[name]; !known {
+			return fmt.Errorf("%w: unknown email template %q", ErrInvalidConfig, name)
+		}
+		if _, _, err := t.render(EmailData{}); err != nil {
+			return fmt.Errorf("%w: email template %q: %v", ErrInvalidConfig, name, err)
+		}
+	}
+	return nil
+}
+
+// RenderEmail fills in the named email template
+func (c TenantConfig) RenderEmail(name string, data EmailData) (subject, body string, err error) {
+	t, ok := c.EmailTemplates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+	data.OrgName = c.Branding.DisplayName
+	return t.render(data)
+}
+
+func (t EmailTemplate) render(data EmailData) (string, string, error) {
+	var subject, body bytes.Buffer
+	st, err := template.New("subject").Parse(t.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	bt, err := template.New("body").Parse(t.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if err := st.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := bt.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
+
+// ConfigCascade resolves the configuration of each organization in layers:
+// deployment defaults, then the organization's overrides. Overrides are
+// partial JSON objects, so an organization only stores what it changes.
+// Wallets outside any organization get the defaults.
+type ConfigCascade struct {
+	mu        sync.RWMutex
+	ws        *wallet.Store
+	defaults  TenantConfig
+	overrides map[string]map[string]interface{} // org ID -> partial TenantConfig
+	resolved  map[string]TenantConfig           // org ID -> defaults + overrides
+	db        database.OrgConfigRepo
+}
+
+func NewConfigCascade(ws *wallet.Store, defaults TenantConfig) *ConfigCascade {
+	return &ConfigCascade{
+		ws:        ws,
+		defaults:  defaults,
+		overrides: make(map[string]map[string]interface{}),
+		resolved:  make(map[string]TenantConfig),
+	}
+}
+
+// SetDatabase enables persistence and reloads stored overrides. Overrides
+// that no longer resolve, e.g. after a limit changed, are skipped.
+func (cc *ConfigCascade) SetDatabase(db database.OrgConfigRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetOrgConfigs(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load organization configuration from database: %v", err)
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.db = db
+	for _, row := range rows {
+		orgID, _ := row["org_id"].(string)
+		raw, _ := row["overrides"].(string)
+		var over map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &over); err != nil {
+			log.Printf("⚠️  Ignoring unreadable configuration of organization %s: %v", orgID, err)
+			continue
+		}
+		cfg, err := cc.resolve(over)
+		if err != nil {
+			log.Printf("⚠️  Ignoring configuration of organization %s: %v", orgID, err)
+			continue
+		}
+		cc.overrides[orgID] = over
+		cc.resolved[orgID] = cfg
+	}
+}
+
+// Defaults returns the deployment-wide configuration
+func (cc *ConfigCascade) Defaults() TenantConfig {
+	return cc.defaults
+}
+
+// ForOrg returns the configuration in effect for an organization
+func (cc *ConfigCascade) ForOrg(orgID string) TenantConfig {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	if cfg, ok := cc.resolved[orgID]; ok {
+		return cfg
+	}
+	return cc.defaults
+}
+
+// ForWallet returns the configuration of the wallet's organization
+func (cc *ConfigCascade) ForWallet(walletID string) TenantConfig {
+	w, ok := cc.ws.Get(walletID)
+	if !ok {
+		return cc.defaults
+	}
+	return cc.ForOrg(w.OrgID)
+}
+
+// Overrides returns what the organization changed relative to the defaults
+func (cc *ConfigCascade) Overrides(orgID string) map[string]interface{} {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	if over, ok := cc.overrides[orgID]; ok {
+		return copyJSON(over)
+	}
+	return map[string]interface{}{}
+}
+
+// Update merges patch into the organization's overrides. Nested objects merge
+// key by key and a null value drops the override so the default applies again.
+func (cc *ConfigCascade) Update(orgID string, patch map[string]interface{}, updatedBy string) (TenantConfig, error) {
+	cc.mu.Lock()
+	over := mergePatch(copyJSON(cc.overrides[orgID]), patch)
+	cfg, err := cc.resolve(over)
+	if err != nil {
+		cc.mu.Unlock()
+		return TenantConfig{}, err
+	}
+	cc.overrides[orgID] = over
+	cc.resolved[orgID] = cfg
+	cc.mu.Unlock()
+
+	if cc.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		raw, _ := json.Marshal(over)
+		if err := cc.db.SaveOrgConfig(ctx, orgID, string(raw), updatedBy); err != nil {
+			log.Printf("Failed to persist organization configuration: %v", err)
+		}
+	}
+	return cfg, nil
+}
+
+// resolve applies overrides to the defaults and validates the result
+func (cc *ConfigCascade) resolve(over map[string]interface{}) (TenantConfig, error) {
+	raw, _ := json.Marshal(cc.defaults)
+	var base map[string]interface{}
+	json.Unmarshal(raw, &base)
+
+	merged, _ := json.Marshal(mergePatch(base, over))
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	var cfg TenantConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return TenantConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return TenantConfig{}, err
+	}
+	return cfg, nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to target in place
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]interface{}:
+			sub, _ := target[key].(map[string]interface{})
+			merged := mergePatch(sub, v)
+			if len(merged) == 0 {
+				delete(target, key)
+			} else {
+				target[key] = merged
+			}
+		default:
+			target[key] = v
+		}
+	}
+	return target
+}
+
+func copyJSON(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	raw, _ := json.Marshal(m)
+	var out map[string]interface{}
+	json.Unmarshal(raw, &out)
+	return out
+}
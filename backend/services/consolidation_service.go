@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// Defaults for automatic dust consolidation
+const (
+	DefaultConsolidationMinDust  = 20
+	DefaultConsolidationInterval = time.Hour
+)
+
+// ConsolidationPolicy decides when wallets are swept automatically. Outputs
+// worth less than DustThreshold are dust; a wallet holding at least MinDust
+// of them gets a system consolidation on the next run.
+type ConsolidationPolicy struct {
+	DustThreshold uint64        `json:"dust_threshold"` // 0 turns automatic consolidation off
+	MinDust       int           `json:"min_dust"`
+	Interval      time.Duration `json:"interval"`
+}
+
+// DefaultConsolidationPolicy returns the default policy, with automatic
+// sweeps off
+func DefaultConsolidationPolicy() ConsolidationPolicy {
+	return ConsolidationPolicy{MinDust: DefaultConsolidationMinDust, Interval: DefaultConsolidationInterval}
+}
+
+// ConsolidationRun summarises one automatic sweep
+type ConsolidationRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Wallets    int       `json:"wallets"` // wallets swept
+	Inputs     int       `json:"inputs"`  // dust outputs combined
+}
+
+// ConsolidationService periodically combines wallets' dust outputs so later
+// sends stay small
+type ConsolidationService struct {
+	bc     *blockchain.Blockchain
+	ws     *wallet.Store
+	txSvc  *TransactionService
+	feed   *events.Feed
+	policy ConsolidationPolicy
+	done   chan struct{}
+	loop   sync.WaitGroup // the ticker goroutine; Stop waits for it
+
+	mu sync.Mutex
+	db database.ConsolidationRepo
+}
+
+func NewConsolidationService(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *TransactionService, feed *events.Feed, policy ConsolidationPolicy) *ConsolidationService {
+	return &ConsolidationService{
+		bc:     bc,
+		ws:     ws,
+		txSvc:  txSvc,
+		feed:   feed,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+}
+
+// SetDatabase persists the sweeps as pending transactions
+func (cs *ConsolidationService) SetDatabase(db database.ConsolidationRepo) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.db = db
+}
+
+// Policy returns the automatic consolidation policy
+func (cs *ConsolidationService) Policy() ConsolidationPolicy {
+	return cs.policy
+}
+
+// Sweep queues a system consolidation for every wallet holding at least
+// MinDust dust outputs
+func (cs *ConsolidationService) Sweep() ConsolidationRun {
+	run := ConsolidationRun{StartedAt: time.Now()}
+	for _, w := range cs.ws.GetAll() {
+		dust := cs.txSvc.ConsolidationCandidates(w.WalletID, cs.policy.DustThreshold)
+		if len(dust) < cs.policy.MinDust {
+			continue
+		}
+		if len(dust) > validation.MaxTxInputs {
+			dust = dust[:validation.MaxTxInputs]
+		}
+
+		tx := cs.txSvc.CreateDustSweep(w.WalletID, dust)
+		if err := cs.bc.AddPending(*tx); err != nil {
+			log.Printf("⚠️  Dust sweep for %s rejected: %v", w.WalletID, err)
+			continue
+		}
+		cs.feed.PublishTransaction(events.TxPending, *tx, nil)
+		cs.persist(tx)
+		run.Wallets++
+		run.Inputs += len(dust)
+	}
+	run.FinishedAt = time.Now()
+	if run.Wallets > 0 {
+		log.Printf("🧹 Consolidated %d dust outputs across %d wallets", run.Inputs, run.Wallets)
+	}
+	return run
+}
+
+// Start runs Sweep on the policy's interval; it does nothing without a dust
+// threshold
+func (cs *ConsolidationService) Start() {
+	if cs.policy.DustThreshold == 0 {
+		return
+	}
+	cs.loop.Add(1)
+	go func() {
+		defer cs.loop.Done()
+		ticker := time.NewTicker(cs.policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cs.Sweep()
+			case <-cs.done:
+				return
+			}
+		}
+	}()
+	log.Printf("✅ Dust consolidation started (outputs below %d, at least %d per wallet, every %s)", cs.policy.DustThreshold, cs.policy.MinDust, cs.policy.Interval)
+}
+
+// Stop ends the automatic sweeps, waiting for a sweep in progress
+func (cs *ConsolidationService) Stop() {
+	close(cs.done)
+	cs.loop.Wait()
+}
+
+func (cs *ConsolidationService) persist(tx *blockchain.Transaction) {
+	cs.mu.Lock()
+	db := cs.db
+	cs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+		log.Printf("Failed to persist consolidation %s: %v", tx.ID, err)
+	}
+}
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+	"blockchain-backend/mailer"
+)
+
+// Delivery channels
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryInFlight  = "in_flight"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery records one outbound webhook or email and its outcome. A delivery
+// waiting for a retry is pending with NextAttemptAt set.
+type Delivery struct {
+	ID            int64             `json:"id"`
+	Channel       string            `json:"channel"`
+	Target        string            `json:"target"`
+	WebhookID     int64             `json:"webhook_id,omitempty"` // registered webhook it was sent for, if any
+	EventType     string            `json:"event_type"`
+	Payload       string            `json:"-"` // may contain secrets (e.g. OTP codes), never listed
+	DedupKey      string            `json:"dedup_key"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeliveredAt   *time.Time        `json:"delivered_at,omitempty"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	History       []DeliveryAttempt `json:"history,omitempty"` // attempts made since the process started
+
+	run int // attempts in the current run; Redeliver starts a new one
+}
+
+// DeliveryAttempt records one try at sending a delivery
+type DeliveryAttempt struct {
+	Attempt    int       `json:"attempt"`
+	At         time.Time `json:"at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// RetryPolicy controls how often a channel retries a failed send. The delay
+// doubles after each failure, from BaseDelay up to MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts"` // including the first; 1 disables retries
+	BaseDelay   time.Duration `json:"base_delay"`
+	MaxDelay    time.Duration `json:"max_delay"`
+}
+
+// Default webhook retry policy: 6 attempts over about 2.5 minutes
+const (
+	DefaultWebhookMaxAttempts = 6
+	DefaultWebhookRetryBase   = 5 * time.Second
+	webhookRetryMaxDelay      = time.Hour
+)
+
+// DefaultWebhookRetryPolicy returns the default retry schedule for webhooks
+func DefaultWebhookRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: DefaultWebhookMaxAttempts, BaseDelay: DefaultWebhookRetryBase, MaxDelay: webhookRetryMaxDelay}
+}
+
+// backoff is the wait before the retry that follows the given failed attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// ErrDeliveryAbandoned tells the delivery service not to retry a send, e.g.
+// because the webhook it was for has been deleted
+var ErrDeliveryAbandoned = errors.New("delivery abandoned")
+
+// Sender performs the actual send for a channel
+type Sender func(ctx context.Context, d Delivery) error
+
+// RedeliveryResult reports what happened to one ID in a bulk redeliver request
+type RedeliveryResult struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"` // queued, skipped
+	Reason string `json:"reason,omitempty"`
+}
+
+const deliveryTimeout = 10 * time.Second
+
+// DeliveryService tracks best-effort async sends so failures are visible and
+// can be redelivered instead of vanishing
+type DeliveryService struct {
+	mu         sync.RWMutex
+	deliveries map[int64]*Delivery
+	nextID     int64
+	senders    map[string]Sender
+	retries    map[string]RetryPolicy
+	db         database.DeliveryRepo
+}
+
+func NewDeliveryService() *DeliveryService {
+	return &DeliveryService{
+		deliveries: make(map[int64]*Delivery),
+		nextID:     1,
+		senders:    make(map[string]Sender),
+		retries:    make(map[string]RetryPolicy),
+	}
+}
+
+// SetDatabase enables persistence and reloads undelivered records. Deliveries
+// that were in flight or waiting for a retry when the process stopped are
+// marked failed.
+func (ds *DeliveryService) SetDatabase(db database.DeliveryRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	maxID, err := db.GetMaxDeliveryID(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load delivery counter from database: %v", err)
+	}
+	rows, err := db.GetUndeliveredDeliveries(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load deliveries from database: %v", err)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.db = db
+	if maxID >= ds.nextID {
+		ds.nextID = maxID + 1
+	}
+	for _, row := range rows {
+		d := deliveryFromRow(row)
+		if d.Status == DeliveryInFlight || d.Status == DeliveryPending {
+			d.Status = DeliveryFailed
+			d.LastError = "interrupted by restart"
+		}
+		ds.deliveries[d.ID] = d
+	}
+}
+
+// RegisterSender installs the send function for a channel
+func (ds *DeliveryService) RegisterSender(channel string, sender Sender) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.senders[channel] = sender
+}
+
+// SetRetryPolicy makes failed sends on a channel retry with exponential
+// backoff. Channels without a policy try once.
+func (ds *DeliveryService) SetRetryPolicy(channel string, p RetryPolicy) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.retries[channel] = p
+}
+
+// HasSender reports whether a channel can currently deliver
+func (ds *DeliveryService) HasSender(channel string) bool {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	_, ok := ds.senders[channel]
+	return ok
+}
+
+// Enqueue records a delivery and sends it in the background. If a delivery
+// with the same dedup key was already delivered, nothing is sent again.
+func (ds *DeliveryService) Enqueue(channel, target, eventType, payload, dedupKey string) *Delivery {
+	return ds.enqueue(&Delivery{Channel: channel, Target: target, EventType: eventType, Payload: payload, DedupKey: dedupKey})
+}
+
+// EnqueueWebhook queues a payload for a registered webhook; the sender signs
+// it with the webhook's secret
+func (ds *DeliveryService) EnqueueWebhook(webhookID int64, url, eventType, payload, dedupKey string) *Delivery {
+	return ds.enqueue(&Delivery{Channel: ChannelWebhook, Target: url, WebhookID: webhookID, EventType: eventType, Payload: payload, DedupKey: dedupKey})
+}
+
+func (ds *DeliveryService) enqueue(d *Delivery) *Delivery {
+	dedupKey := d.DedupKey
+	ds.mu.Lock()
+	if dedupKey != "" {
+		for _, existing := range ds.deliveries {
+			if existing.DedupKey == dedupKey && existing.Status == DeliveryDelivered {
+				ds.mu.Unlock()
+				return existing
+			}
+		}
+	}
+	now := time.Now()
+	d.ID = ds.nextID
+	d.Status = DeliveryInFlight
+	d.CreatedAt, d.UpdatedAt = now, now
+	ds.nextID++
+	ds.deliveries[d.ID] = d
+	ds.mu.Unlock()
+
+	ds.persist(*d)
+	go ds.attempt(d.ID)
+	return d
+}
+
+type emailPayload struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// EnqueueEmail queues a plain-text email on the email channel
+func (ds *DeliveryService) EnqueueEmail(to, subject, body, eventType, dedupKey string) *Delivery {
+	payload, _ := json.Marshal(emailPayload{Subject: subject, Body: body})
+	return ds.Enqueue(ChannelEmail, to, eventType, string(payload), dedupKey)
+}
+
+// Mailer sends a plain-text email; *mailer.Mailer is the SMTP one
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+var _ Mailer = (*mailer.Mailer)(nil)
+
+// EmailSender adapts a Mailer to the email delivery channel
+func EmailSender(m Mailer) Sender {
+	return func(ctx context.Context, d Delivery) error {
+		var p emailPayload
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return fmt.Errorf("invalid email payload: %v", err)
+		}
+		return m.Send(d.Target, p.Subject, p.Body)
+	}
+}
+
+// WebhookSigner adds authentication headers to a webhook request
+type WebhookSigner func(d Delivery, req *http.Request) error
+
+// WebhookSender POSTs the delivery payload as JSON to the target URL; any
+// non-2xx response counts as a failure. Deliveries for registered webhooks
+// are passed to sign, if set.
+func WebhookSender(client *http.Client, sign WebhookSigner) Sender {
+	return func(ctx context.Context, d Delivery) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Target, strings.NewReader(d.Payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Event-Type", d.EventType)
+		req.Header.Set("X-Delivery-ID", strconv.FormatInt(d.ID, 10))
+		if d.WebhookID != 0 && sign != nil {
+			if err := sign(d, req); err != nil {
+				return err
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// List returns deliveries filtered by status and channel (empty matches all), newest first
+func (ds *DeliveryService) List(status, channel string, limit int) []Delivery {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	var list []Delivery
+	for _, d := range ds.deliveries {
+		if (status == "" || d.Status == status) && (channel == "" || d.Channel == channel) {
+			list = append(list, *d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// ForWebhook returns a registered webhook's deliveries, newest first
+func (ds *DeliveryService) ForWebhook(webhookID int64, limit int) []Delivery {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	list := make([]Delivery, 0)
+	for _, d := range ds.deliveries {
+		if d.WebhookID == webhookID {
+			list = append(list, *d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// FailedIDs returns the IDs of all failed deliveries
+func (ds *DeliveryService) FailedIDs() []int64 {
+	var ids []int64
+	for _, d := range ds.List(DeliveryFailed, "", 0) {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+// Redeliver re-sends failed deliveries. Each delivery is claimed under the
+// lock before sending, so repeated or concurrent requests never send the same
+// delivery twice, and deliveries whose dedup key has since been delivered are skipped.
+func (ds *DeliveryService) Redeliver(ids []int64) []RedeliveryResult {
+	ds.mu.Lock()
+	delivered := make(map[string]bool)
+	for _, d := range ds.deliveries {
+		if d.DedupKey != "" && d.Status == DeliveryDelivered {
+			delivered[d.DedupKey] = true
+		}
+	}
+
+	seen := make(map[int64]bool)
+	var claimed []Delivery
+	results := make([]RedeliveryResult, 0, len(ids))
+	for _, id := range ids {
+		result := RedeliveryResult{ID: id, Status: "skipped"}
+		d, ok := ds.deliveries[id]
+		switch {
+		case seen[id]:
+			result.Reason = "duplicate id in request"
+		case !ok:
+			result.Reason = "not found"
+		case d.Status != DeliveryFailed:
+			result.Reason = "status is " + d.Status
+		case d.DedupKey != "" && delivered[d.DedupKey]:
+			result.Reason = "already delivered under the same dedup key"
+		default:
+			d.Status = DeliveryInFlight
+			d.UpdatedAt = time.Now()
+			d.run = 0
+			if d.DedupKey != "" {
+				delivered[d.DedupKey] = true
+			}
+			claimed = append(claimed, *d)
+			result.Status = "queued"
+		}
+		seen[id] = true
+		results = append(results, result)
+	}
+	ds.mu.Unlock()
+
+	for _, d := range claimed {
+		ds.persist(d)
+		go ds.attempt(d.ID)
+	}
+	return results
+}
+
+func (ds *DeliveryService) attempt(id int64) {
+	ds.mu.RLock()
+	d := *ds.deliveries[id]
+	sender, ok := ds.senders[d.Channel]
+	ds.mu.RUnlock()
+
+	started := time.Now()
+	var err error
+	if !ok {
+		err = fmt.Errorf("no sender configured for channel %s", d.Channel)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		err = sender(ctx, d)
+		cancel()
+	}
+
+	ds.mu.Lock()
+	rec := ds.deliveries[id]
+	rec.Attempts++
+	rec.run++
+	rec.UpdatedAt = time.Now()
+	rec.NextAttemptAt = nil
+	try := DeliveryAttempt{Attempt: rec.Attempts, At: started, DurationMs: rec.UpdatedAt.Sub(started).Milliseconds()}
+	var retryIn time.Duration
+	if err != nil {
+		try.Error = err.Error()
+		rec.LastError = err.Error()
+		policy := ds.retries[rec.Channel]
+		if rec.run < policy.MaxAttempts && !errors.Is(err, ErrDeliveryAbandoned) {
+			retryIn = policy.backoff(rec.run)
+			next := rec.UpdatedAt.Add(retryIn)
+			rec.Status = DeliveryPending
+			rec.NextAttemptAt = &next
+			log.Printf("⚠️  %s delivery %d to %s failed (attempt %d), retrying in %s: %v", rec.Channel, rec.ID, rec.Target, rec.Attempts, retryIn, err)
+		} else {
+			rec.Status = DeliveryFailed
+			log.Printf("❌ %s delivery %d to %s failed: %v", rec.Channel, rec.ID, rec.Target, err)
+		}
+	} else {
+		rec.Status = DeliveryDelivered
+		rec.LastError = ""
+		now := rec.UpdatedAt
+		rec.DeliveredAt = &now
+	}
+	rec.History = append(rec.History, try)
+	snapshot := *rec
+	ds.mu.Unlock()
+
+	ds.persist(snapshot)
+	ds.persistAttempt(id, try)
+	if retryIn > 0 {
+		time.AfterFunc(retryIn, func() { ds.attempt(id) })
+	}
+}
+
+func (ds *DeliveryService) persist(d Delivery) {
+	ds.mu.RLock()
+	db := ds.db
+	ds.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveDelivery(ctx, d.ID, d.Channel, d.Target, d.WebhookID, d.EventType, d.Payload, d.DedupKey, d.Status, d.Attempts, d.LastError, d.CreatedAt, d.DeliveredAt); err != nil {
+		log.Printf("Failed to persist delivery %d: %v", d.ID, err)
+	}
+}
+
+func (ds *DeliveryService) persistAttempt(id int64, a DeliveryAttempt) {
+	ds.mu.RLock()
+	db := ds.db
+	ds.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveDeliveryAttempt(ctx, id, a.Attempt, a.At, a.DurationMs, a.Error); err != nil {
+		log.Printf("Failed to persist attempt %d of delivery %d: %v", a.Attempt, id, err)
+	}
+}
+
+func deliveryFromRow(row map[string]interface{}) *Delivery {
+	d := &Delivery{
+		ID:        row["id"].(int64),
+		Channel:   row["channel"].(string),
+		Target:    row["target"].(string),
+		WebhookID: row["webhook_id"].(int64),
+		EventType: row["event_type"].(string),
+		Payload:   row["payload"].(string),
+		DedupKey:  row["dedup_key"].(string),
+		Status:    row["status"].(string),
+		Attempts:  row["attempts"].(int),
+		LastError: row["last_error"].(string),
+		CreatedAt: row["created_at"].(time.Time),
+		UpdatedAt: row["updated_at"].(time.Time),
+	}
+	if t, ok := row["delivered_at"].(*time.Time); ok {
+		d.DeliveredAt = t
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// Kinds of origin remembered for each user
+const (
+	OriginDevice  = "device"
+	OriginCountry = "country"
+)
+
+// originRefresh is how stale a known origin's last sighting may get before
+// it is written to the database again
+const originRefresh = time.Hour
+
+type originKey struct {
+	kind, value string
+}
+
+// DeviceService remembers the devices and countries each user signs in,
+// sends and exports keys from, and emails the user when one is new. A
+// user's first sighting is recorded without an alert.
+type DeviceService struct {
+	mu         sync.Mutex
+	known      map[string]map[originKey]time.Time // lower-cased email -> origin -> last seen
+	deliveries *DeliveryService
+	alerts     bool
+	db         database.OriginRepo
+}
+
+func NewDeviceService(deliveries *DeliveryService, alerts bool) *DeviceService {
+	return &DeviceService{
+		known:      make(map[string]map[originKey]time.Time),
+		deliveries: deliveries,
+		alerts:     alerts,
+	}
+}
+
+// SetDatabase enables persistence and reloads the origins already seen
+func (ds *DeviceService) SetDatabase(db database.OriginRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetKnownOrigins(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load known devices from database: %v", err)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.db = db
+	for _, row := range rows {
+		email := row["email"].(string)
+		if ds.known[email] == nil {
+			ds.known[email] = make(map[originKey]time.Time)
+		}
+		ds.known[email][originKey{row["kind"].(string), row["value"].(string)}] = row["last_seen"].(time.Time)
+	}
+}
+
+// Observe records that email acted from origin and reports whether the user
+// was alerted to a new device or country. Parts of the origin that are
+// unknown are not checked.
+func (ds *DeviceService) Observe(email, event, ipAddress string, origin database.LogOrigin) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || (origin.Device == "" && origin.Country == "") {
+		return false
+	}
+	now := time.Now()
+
+	ds.mu.Lock()
+	known := ds.known[email]
+	firstSighting := known == nil
+	if firstSighting {
+		known = make(map[originKey]time.Time)
+		ds.known[email] = known
+	}
+	var fresh, save []originKey
+	for _, key := range []originKey{{OriginDevice, origin.Device}, {OriginCountry, origin.Country}} {
+		if key.value == "" {
+			continue
+		}
+		last, seen := known[key]
+		if !seen {
+			fresh = append(fresh, key)
+		}
+		if !seen || now.Sub(last) >= originRefresh {
+			save = append(save, key)
+		}
+		known[key] = now
+	}
+	db := ds.db
+	ds.mu.Unlock()
+
+	if db != nil && len(save) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		for _, key := range save {
+			if err := db.SaveKnownOrigin(ctx, email, key.kind, key.value, now, now); err != nil {
+				log.Printf("Failed to persist known %s for %s: %v", key.kind, email, err)
+			}
+		}
+	}
+
+	if firstSighting || len(fresh) == 0 || !ds.alerts || ds.deliveries == nil {
+		return false
+	}
+	subject, body := newOriginEmail(event, ipAddress, origin, fresh, now)
+	dedupKey := "new_origin:" + email + ":" + origin.Device + ":" + origin.Country
+	return ds.deliveries.EnqueueEmail(email, subject, body, "security.new_origin", dedupKey) != nil
+}
+
+func newOriginEmail(event, ipAddress string, origin database.LogOrigin, fresh []originKey, at time.Time) (subject, body string) {
+	var what []string
+	for _, key := range fresh {
+		what = append(what, key.kind)
+	}
+	subject = "Your account was used from a new " + strings.Join(what, " and ")
+	if host, _, err := net.SplitHostPort(ipAddress); err == nil {
+		ipAddress = host
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello,\n\n%s.\n\n", subject)
+	fmt.Fprintf(&b, "Activity:  %s\n", strings.ReplaceAll(event, "_", " "))
+	fmt.Fprintf(&b, "Time:      %s\n", at.UTC().Format(time.RFC1123))
+	if origin.Country != "" {
+		place := origin.Country
+		if origin.City != "" {
+			place = origin.City + ", " + origin.Country
+		}
+		fmt.Fprintf(&b, "Location:  %s\n", place)
+	}
+	if origin.Device != "" {
+		fmt.Fprintf(&b, "Device:    %s\n", origin.Device)
+	}
+	if ipAddress != "" {
+		fmt.Fprintf(&b, "IP:        %s\n", ipAddress)
+	}
+	b.WriteString("\nIf this was you, there is nothing to do. If not, secure your account and move your funds to a new wallet.\n")
+	return subject, b.String()
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/crypto"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// The email directory lets a sender find a wallet by its owner's email, but
+// only a wallet whose owner opted in. Emails are kept as an HMAC under
+// ENCRYPTION_KEY, so a leaked table cannot be reversed with a list of
+// addresses; changing the key empties the directory in effect. Lookups are
+// limited per client IP address so the directory cannot be walked.
+
+// Default lookup limit
+const (
+	DefaultDirectoryResolveLimit  = 20 // lookups per IP address per window
+	DefaultDirectoryResolveWindow = time.Hour
+)
+
+// Errors returned by the directory service
+var (
+	ErrNotDiscoverable = errors.New("no discoverable wallet has that email")
+	ErrNoEmail         = errors.New("the wallet has no email")
+	ErrResolveLimit    = errors.New("too many email lookups")
+)
+
+// ResolveLimitError says when a client refused a lookup may look up again
+type ResolveLimitError struct {
+	Until time.Time
+}
+
+func (e *ResolveLimitError) Error() string {
+	return fmt.Sprintf("%v: try again at %s", ErrResolveLimit, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *ResolveLimitError) Unwrap() error {
+	return ErrResolveLimit
+}
+
+// DirectoryPolicy limits email lookups
+type DirectoryPolicy struct {
+	ResolveLimit  int           `json:"resolve_limit"` // per IP address per window; 0 for no limit
+	ResolveWindow time.Duration `json:"resolve_window"`
+}
+
+// DefaultDirectoryPolicy returns the default lookup limit
+func DefaultDirectoryPolicy() DirectoryPolicy {
+	return DirectoryPolicy{ResolveLimit: DefaultDirectoryResolveLimit, ResolveWindow: DefaultDirectoryResolveWindow}
+}
+
+type directoryEntry struct {
+	emailHash string
+	listedAt  time.Time
+}
+
+// DirectoryService keeps the opt-in email directory
+type DirectoryService struct {
+	mu      sync.Mutex
+	ws      *wallet.Store
+	policy  DirectoryPolicy
+	entries map[string]directoryEntry // by wallet ID
+	lookups map[string][]time.Time    // recent lookups by IP address
+	db      database.DirectoryRepo
+}
+
+func NewDirectoryService(ws *wallet.Store, policy DirectoryPolicy) *DirectoryService {
+	return &DirectoryService{
+		ws:      ws,
+		policy:  policy,
+		entries: make(map[string]directoryEntry),
+		lookups: make(map[string][]time.Time),
+	}
+}
+
+// SetDatabase enables persistence and reloads the directory
+func (ds *DirectoryService) SetDatabase(db database.DirectoryRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetDirectoryEntries(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load the email directory from database: %v", err)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.db = db
+	for _, row := range rows {
+		ds.entries[row["wallet_id"].(string)] = directoryEntry{
+			emailHash: row["email_hash"].(string),
+			listedAt:  row["created_at"].(time.Time),
+		}
+	}
+}
+
+// HashEmail returns the keyed hash an email is listed under. Case and
+// surrounding space do not matter.
+func HashEmail(email string) string {
+	mac := hmac.New(sha256.New, []byte(crypto.EncryptionKey()))
+	mac.Write([]byte("directory:" + strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Discoverable reports whether a wallet can be found by its current email
+func (ds *DirectoryService) Discoverable(walletID string) bool {
+	ds.mu.Lock()
+	e, ok := ds.entries[walletID]
+	ds.mu.Unlock()
+	if !ok {
+		return false
+	}
+	w, exists := ds.ws.Get(walletID)
+	return exists && w.Email != "" && HashEmail(w.Email) == e.emailHash
+}
+
+// SetDiscoverable lists or unlists a wallet under its owner's email
+func (ds *DirectoryService) SetDiscoverable(walletID string, on bool) error {
+	w, exists := ds.ws.Get(walletID)
+	if !exists {
+		return ErrSenderNotFound
+	}
+
+	var entry directoryEntry
+	ds.mu.Lock()
+	if on {
+		if w.Email == "" {
+			ds.mu.Unlock()
+			return ErrNoEmail
+		}
+		entry = directoryEntry{emailHash: HashEmail(w.Email), listedAt: time.Now().UTC()}
+		ds.entries[walletID] = entry
+	} else {
+		delete(ds.entries, walletID)
+	}
+	db := ds.db
+	ds.mu.Unlock()
+	if db == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var err error
+	if on {
+		err = db.SaveDirectoryEntry(ctx, walletID, entry.emailHash, entry.listedAt)
+	} else {
+		err = db.DeleteDirectoryEntry(ctx, walletID)
+	}
+	if err != nil {
+		log.Printf("Failed to persist directory entry of %s: %v", walletID, err)
+	}
+	return nil
+}
+
+// Resolve returns the wallet listed under email for a lookup from ip. When
+// several of the owner's wallets are listed, the last one listed wins. A
+// wallet that was unlisted, closed or changed its email is not found, and
+// every lookup, found or not, counts against the client's limit.
+func (ds *DirectoryService) Resolve(email, ip string) (string, error) {
+	hash := HashEmail(email)
+	now := time.Now()
+
+	ds.mu.Lock()
+	if err := ds.countLookupLocked(ip, now); err != nil {
+		ds.mu.Unlock()
+		return "", err
+	}
+	var candidates []string
+	for walletID, e := range ds.entries {
+		if e.emailHash == hash {
+			candidates = append(candidates, walletID)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return ds.entries[candidates[i]].listedAt.After(ds.entries[candidates[j]].listedAt)
+	})
+	ds.mu.Unlock()
+
+	for _, walletID := range candidates {
+		if w, ok := ds.ws.Get(walletID); ok && w.Active() && HashEmail(w.Email) == hash {
+			return walletID, nil
+		}
+	}
+	return "", ErrNotDiscoverable
+}
+
+// countLookupLocked records a lookup from ip, or refuses it when the
+// address is at its limit. The caller must hold the lock.
+func (ds *DirectoryService) countLookupLocked(ip string, now time.Time) error {
+	if ds.policy.ResolveLimit <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-ds.policy.ResolveWindow)
+	recent := ds.lookups[ip]
+	i := 0
+	for i < len(recent) && !recent[i].After(cutoff) {
+		i++
+	}
+	recent = recent[i:]
+	if len(recent) >= ds.policy.ResolveLimit {
+		ds.lookups[ip] = recent
+		return &ResolveLimitError{Until: recent[0].Add(ds.policy.ResolveWindow)}
+	}
+	ds.lookups[ip] = append(recent, now)
+
+	// Forget addresses that have gone quiet
+	for addr, times := range ds.lookups {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(ds.lookups, addr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// Faucet modes
+const (
+	FaucetModeClaim  = "claim"  // wallets claim coins with POST /api/faucet/claim
+	FaucetModeSignup = "signup" // every new personal wallet is granted coins (legacy)
+)
+
+// Faucet ledger sources
+const (
+	FaucetSourceClaim  = "claim"
+	FaucetSourceSignup = "signup"
+)
+
+// Default faucet cooldowns
+const (
+	DefaultFaucetEmailCooldown = 24 * time.Hour
+	DefaultFaucetIPCooldown    = time.Hour
+)
+
+// Errors returned by the faucet service
+var (
+	ErrFaucetDisabled   = errors.New("the faucet is disabled")
+	ErrFaucetIneligible = errors.New("only personal wallets may use the faucet")
+	ErrFaucetCooldown   = errors.New("faucet claimed too recently")
+)
+
+// FaucetCooldownError says which cooldown refused a claim and when it ends
+type FaucetCooldownError struct {
+	Scope string // email or ip
+	Until time.Time
+}
+
+func (e *FaucetCooldownError) Error() string {
+	return fmt.Sprintf("%v: this %s may claim again at %s", ErrFaucetCooldown, e.Scope, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *FaucetCooldownError) Unwrap() error {
+	return ErrFaucetCooldown
+}
+
+// FaucetPolicy is how the faucet hands out coins. The amount is the
+// deployment default; organizations may override it.
+type FaucetPolicy struct {
+	Mode          string        `json:"mode"`
+	Amount        uint64        `json:"amount"`
+	EmailCooldown time.Duration `json:"email_cooldown"`
+	IPCooldown    time.Duration `json:"ip_cooldown"`
+}
+
+// DefaultFaucetPolicy returns the default faucet: claims of
+// blockchain.FaucetAmount with the default cooldowns
+func DefaultFaucetPolicy() FaucetPolicy {
+	return FaucetPolicy{
+		Mode:          FaucetModeClaim,
+		Amount:        blockchain.FaucetAmount,
+		EmailCooldown: DefaultFaucetEmailCooldown,
+		IPCooldown:    DefaultFaucetIPCooldown,
+	}
+}
+
+// FaucetGrant is one entry of the faucet ledger
+type FaucetGrant struct {
+	ID        int64     `json:"id"`
+	WalletID  string    `json:"wallet_id"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	Amount    uint64    `json:"amount"`
+	UTXOID    string    `json:"utxo_id"`
+	Source    string    `json:"source"` // claim or signup
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FaucetService grants faucet coins and keeps the ledger the cooldowns are
+// enforced from
+type FaucetService struct {
+	mu     sync.Mutex
+	bc     *blockchain.Blockchain
+	policy FaucetPolicy
+	grants []FaucetGrant // oldest first
+	nextID int64
+	db     database.FaucetRepo
+}
+
+func NewFaucetService(bc *blockchain.Blockchain, policy FaucetPolicy) *FaucetService {
+	return &FaucetService{bc: bc, policy: policy, nextID: 1}
+}
+
+// Reset forgets the grants after the chain was reset, so wallets may claim
+// again on the new one
+func (fs *FaucetService) Reset() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.grants = nil
+}
+
+// SetDatabase enables persistence and reloads the grants still inside a
+// cooldown window
+func (fs *FaucetService) SetDatabase(db database.FaucetRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	maxID, err := db.GetMaxFaucetGrantID(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load faucet ledger counter from database: %v", err)
+	}
+	window := fs.policy.EmailCooldown
+	if fs.policy.IPCooldown > window {
+		window = fs.policy.IPCooldown
+	}
+	rows, err := db.GetFaucetGrantsSince(ctx, time.Now().Add(-window))
+	if err != nil {
+		log.Printf("⚠️  Failed to load faucet ledger from database: %v", err)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.db = db
+	if maxID >= fs.nextID {
+		fs.nextID = maxID + 1
+	}
+	for _, row := range rows {
+		fs.grants = append(fs.grants, faucetGrantFromRow(row))
+	}
+}
+
+// Policy returns the faucet policy
+func (fs *FaucetService) Policy() FaucetPolicy {
+	return fs.policy
+}
+
+// GrantsOnSignup reports whether new wallets are granted coins when created
+func (fs *FaucetService) GrantsOnSignup() bool {
+	return fs.policy.Mode == FaucetModeSignup
+}
+
+// NextClaim is when the email and IP may next claim; zero when they may now
+func (fs *FaucetService) NextClaim(email, ip string) time.Time {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.cooldownLocked(email, ip, time.Now()); err != nil {
+		return err.Until
+	}
+	return time.Time{}
+}
+
+// Claim grants amount coins to a wallet whose owner verified its email,
+// unless the email or the IP address claimed within its cooldown
+func (fs *FaucetService) Claim(walletID, email, ip string, amount uint64) (FaucetGrant, blockchain.UTXO, error) {
+	if fs.policy.Mode != FaucetModeClaim || amount == 0 {
+		return FaucetGrant{}, blockchain.UTXO{}, ErrFaucetDisabled
+	}
+
+	fs.mu.Lock()
+	if err := fs.cooldownLocked(email, ip, time.Now()); err != nil {
+		fs.mu.Unlock()
+		return FaucetGrant{}, blockchain.UTXO{}, err
+	}
+	grant, utxo := fs.grantLocked(walletID, email, ip, amount, FaucetSourceClaim)
+	fs.mu.Unlock()
+
+	fs.persist(grant)
+	return grant, utxo, nil
+}
+
+// GrantOnSignup grants a new wallet its coins in signup mode. Cooldowns do
+// not apply, but the grant is recorded in the ledger.
+func (fs *FaucetService) GrantOnSignup(walletID, email, ip string, amount uint64) (FaucetGrant, blockchain.UTXO) {
+	fs.mu.Lock()
+	grant, utxo := fs.grantLocked(walletID, email, ip, amount, FaucetSourceSignup)
+	fs.mu.Unlock()
+
+	fs.persist(grant)
+	return grant, utxo
+}
+
+// Ledger returns the most recent grants, newest first. With a database the
+// full ledger is read from it; otherwise grants since startup are listed.
+func (fs *FaucetService) Ledger(ctx context.Context, limit int) ([]FaucetGrant, error) {
+	fs.mu.Lock()
+	db := fs.db
+	list := make([]FaucetGrant, len(fs.grants))
+	copy(list, fs.grants)
+	fs.mu.Unlock()
+
+	if db != nil {
+		rows, err := db.GetFaucetLedger(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		list = list[:0]
+		for _, row := range rows {
+			list = append(list, faucetGrantFromRow(row))
+		}
+		return list, nil
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// cooldownLocked returns the cooldown that still applies to the email or IP
+// address, the one ending last, or nil. The caller must hold the lock.
+func (fs *FaucetService) cooldownLocked(email, ip string, now time.Time) *FaucetCooldownError {
+	var blocked *FaucetCooldownError
+	for _, g := range fs.grants {
+		if g.Source != FaucetSourceClaim {
+			continue
+		}
+		if email != "" && strings.EqualFold(g.Email, email) {
+			if until := g.CreatedAt.Add(fs.policy.EmailCooldown); until.After(now) && (blocked == nil || until.After(blocked.Until)) {
+				blocked = &FaucetCooldownError{Scope: "email", Until: until}
+			}
+		}
+		if ip != "" && g.IP == ip {
+			if until := g.CreatedAt.Add(fs.policy.IPCooldown); until.After(now) && (blocked == nil || until.After(blocked.Until)) {
+				blocked = &FaucetCooldownError{Scope: "ip", Until: until}
+			}
+		}
+	}
+	return blocked
+}
+
+// grantLocked creates the faucet UTXO and records it. The caller must hold
+// the lock.
+func (fs *FaucetService) grantLocked(walletID, email, ip string, amount uint64, source string) (FaucetGrant, blockchain.UTXO) {
+	utxo := fs.bc.CreateFaucetUTXO(walletID, amount)
+	grant := FaucetGrant{
+		ID:        fs.nextID,
+		WalletID:  walletID,
+		Email:     email,
+		IP:        ip,
+		Amount:    amount,
+		UTXOID:    utxo.ID,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+	fs.nextID++
+	fs.grants = append(fs.grants, grant)
+	fs.pruneLocked(grant.CreatedAt)
+	return grant, utxo
+}
+
+// pruneLocked drops grants older than both cooldowns from memory; the
+// database keeps the full ledger. The caller must hold the lock.
+func (fs *FaucetService) pruneLocked(now time.Time) {
+	if fs.db == nil {
+		return
+	}
+	window := fs.policy.EmailCooldown
+	if fs.policy.IPCooldown > window {
+		window = fs.policy.IPCooldown
+	}
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(fs.grants) && fs.grants[i].CreatedAt.Before(cutoff) {
+		i++
+	}
+	fs.grants = fs.grants[i:]
+}
+
+func (fs *FaucetService) persist(g FaucetGrant) {
+	fs.mu.Lock()
+	db := fs.db
+	fs.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveFaucetGrant(ctx, g.ID, g.WalletID, g.Email, g.IP, g.Amount, g.UTXOID, g.Source, g.CreatedAt); err != nil {
+		log.Printf("Failed to persist faucet grant %d: %v", g.ID, err)
+	}
+}
+
+func faucetGrantFromRow(row map[string]interface{}) FaucetGrant {
+	return FaucetGrant{
+		ID:        row["id"].(int64),
+		WalletID:  row["wallet_id"].(string),
+		Email:     row["email"].(string),
+		IP:        row["ip"].(string),
+		Amount:    row["amount"].(uint64),
+		UTXOID:    row["utxo_id"].(string),
+		Source:    row["source"].(string),
+		CreatedAt: row["created_at"].(time.Time),
+	}
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// A handle is a unique, human-readable name for a wallet, written @name,
+// that can be used in place of its wallet ID. Unlike a beneficiary alias,
+// which only its owner's address book knows, a handle means the same wallet
+// to everyone. Each claim is kept, so a wallet's earlier handles stay on
+// record, and a released handle stays with its last wallet for a while so
+// that nobody can take it over to receive payments meant for that wallet.
+
+// Handle rules
+const (
+	MinHandleLength = 3
+	MaxHandleLength = 20
+
+	// HandleReleaseHold is how long only the last holder may reclaim a
+	// handle it gave up
+	HandleReleaseHold = 30 * 24 * time.Hour
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedHandles cannot be claimed, and neither can handles starting with
+// reservedHandlePrefixes, so that no wallet poses as the operator
+var (
+	reservedHandles = map[string]bool{
+		"admin": true, "administrator": true, "root": true, "system": true,
+		"support": true, "help": true, "security": true, "official": true,
+		"staff": true, "mod": true, "moderator": true, "operator": true,
+		"zakat": true, "zakat_pool": true, "faucet": true, "treasury": true,
+		"coinbase": true, "mining": true, "miner": true, "batch": true,
+		"escrow": true, "fees": true, "api": true, "www": true, "mail": true,
+		"wallet": true, "blockchain": true, "bank": true, "null": true,
+		"undefined": true, "anonymous": true, "everyone": true, "all": true,
+	}
+	reservedHandlePrefixes = []string{"admin", "support", "official", "system", "zakat"}
+)
+
+// Errors returned by the handle service
+var (
+	ErrInvalidHandle  = errors.New("invalid handle")
+	ErrHandleReserved = errors.New("handle is reserved")
+	ErrHandleTaken    = errors.New("handle is taken")
+	ErrHandleNotFound = errors.New("handle not found")
+)
+
+// HandleClaim is one wallet's hold of a handle, from when it was claimed to
+// when it was released or moved on
+type HandleClaim struct {
+	ID         int64      `json:"id"`
+	WalletID   string     `json:"wallet_id"`
+	Handle     string     `json:"handle"`
+	ClaimedAt  time.Time  `json:"claimed_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+// NormalizeHandle lowercases a handle and strips a leading "@", then checks
+// it against the handle rules
+func NormalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	switch {
+	case len(handle) < MinHandleLength || len(handle) > MaxHandleLength:
+		return "", fmt.Errorf("%w: use %d to %d characters", ErrInvalidHandle, MinHandleLength, MaxHandleLength)
+	case !handlePattern.MatchString(handle), strings.Contains(handle, "__"), strings.HasSuffix(handle, "_"):
+		return "", fmt.Errorf("%w: start with a letter and use letters, digits and single underscores", ErrInvalidHandle)
+	}
+	if reservedHandles[handle] {
+		return "", ErrHandleReserved
+	}
+	for _, prefix := range reservedHandlePrefixes {
+		if strings.HasPrefix(handle, prefix) {
+			return "", ErrHandleReserved
+		}
+	}
+	return handle, nil
+}
+
+// HandleService keeps wallet handles and their history
+type HandleService struct {
+	mu     sync.Mutex
+	claims []*HandleClaim
+	active map[string]*HandleClaim // by handle
+	nextID int64
+	db     database.HandleRepo
+}
+
+func NewHandleService() *HandleService {
+	return &HandleService{
+		active: make(map[string]*HandleClaim),
+		nextID: 1,
+	}
+}
+
+// SetDatabase enables persistence and reloads the handle claims
+func (hs *HandleService) SetDatabase(db database.HandleRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetHandles(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load wallet handles from database: %v", err)
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.db = db
+	for _, row := range rows {
+		c := &HandleClaim{
+			ID:        row["id"].(int64),
+			WalletID:  row["wallet_id"].(string),
+			Handle:    row["handle"].(string),
+			ClaimedAt: row["claimed_at"].(time.Time),
+		}
+		if t, ok := row["released_at"].(*time.Time); ok && t != nil {
+			c.ReleasedAt = t
+		} else {
+			hs.active[c.Handle] = c
+		}
+		hs.claims = append(hs.claims, c)
+		if c.ID >= hs.nextID {
+			hs.nextID = c.ID + 1
+		}
+	}
+}
+
+// Claim gives handle to the wallet, releasing the wallet's current handle.
+// Claiming the handle the wallet already holds changes nothing.
+func (hs *HandleService) Claim(walletID, handle string) (HandleClaim, error) {
+	handle, err := NormalizeHandle(handle)
+	if err != nil {
+		return HandleClaim{}, err
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if c, ok := hs.active[handle]; ok {
+		if c.WalletID == walletID {
+			return *c, nil
+		}
+		return HandleClaim{}, ErrHandleTaken
+	}
+	if last := hs.lastClaimLocked(handle); last != nil && last.WalletID != walletID && last.ReleasedAt.Add(HandleReleaseHold).After(time.Now()) {
+		return HandleClaim{}, fmt.Errorf("%w: it was released recently and is held for its last wallet", ErrHandleTaken)
+	}
+	return hs.claimLocked(walletID, handle)
+}
+
+// Release gives up the wallet's handle
+func (hs *HandleService) Release(walletID string) (HandleClaim, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	current := hs.currentLocked(walletID)
+	if current == nil {
+		return HandleClaim{}, ErrHandleNotFound
+	}
+	now := time.Now().UTC()
+	if hs.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := hs.db.ReleaseHandle(ctx, walletID, now); err != nil {
+			return HandleClaim{}, err
+		}
+	}
+	current.ReleasedAt = &now
+	delete(hs.active, current.Handle)
+	return *current, nil
+}
+
+// Move passes a wallet's handle to the wallet that replaced it after a key
+// rotation. A wallet without a handle moves nothing.
+func (hs *HandleService) Move(fromWalletID, toWalletID string) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	current := hs.currentLocked(fromWalletID)
+	if current == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	if hs.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := hs.db.ReleaseHandle(ctx, fromWalletID, now); err != nil {
+			return err
+		}
+	}
+	current.ReleasedAt = &now
+	delete(hs.active, current.Handle)
+	_, err := hs.claimLocked(toWalletID, current.Handle)
+	return err
+}
+
+// Resolve returns the wallet holding a handle, written with or without "@"
+func (hs *HandleService) Resolve(handle string) (string, bool) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if c, ok := hs.active[handle]; ok {
+		return c.WalletID, true
+	}
+	return "", false
+}
+
+// Handle returns the wallet's current handle, or ""
+func (hs *HandleService) Handle(walletID string) string {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if c := hs.currentLocked(walletID); c != nil {
+		return c.Handle
+	}
+	return ""
+}
+
+// History returns every handle the wallet has held, newest first
+func (hs *HandleService) History(walletID string) []HandleClaim {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	var list []HandleClaim
+	for i := len(hs.claims) - 1; i >= 0; i-- {
+		if hs.claims[i].WalletID == walletID {
+			list = append(list, *hs.claims[i])
+		}
+	}
+	return list
+}
+
+// claimLocked records a claim, releasing the wallet's current handle. The
+// database is written first so a claim another node won does not take
+// effect here. The caller must hold the lock.
+func (hs *HandleService) claimLocked(walletID, handle string) (HandleClaim, error) {
+	now := time.Now().UTC()
+	c := &HandleClaim{ID: hs.nextID, WalletID: walletID, Handle: handle, ClaimedAt: now}
+	if hs.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := hs.db.ClaimHandle(ctx, c.ID, walletID, handle, now); err != nil {
+			if errors.Is(err, database.ErrHandleTaken) {
+				return HandleClaim{}, ErrHandleTaken
+			}
+			return HandleClaim{}, err
+		}
+	}
+
+	if current := hs.currentLocked(walletID); current != nil {
+		current.ReleasedAt = &now
+		delete(hs.active, current.Handle)
+	}
+	hs.nextID++
+	hs.claims = append(hs.claims, c)
+	hs.active[handle] = c
+	return *c, nil
+}
+
+// currentLocked returns the wallet's unreleased claim, or nil. The caller
+// must hold the lock.
+func (hs *HandleService) currentLocked(walletID string) *HandleClaim {
+	for _, c := range hs.active {
+		if c.WalletID == walletID {
+			return c
+		}
+	}
+	return nil
+}
+
+// lastClaimLocked returns the latest released claim of a handle, or nil.
+// The caller must hold the lock.
+func (hs *HandleService) lastClaimLocked(handle string) *HandleClaim {
+	for i := len(hs.claims) - 1; i >= 0; i-- {
+		if c := hs.claims[i]; c.Handle == handle && c.ReleasedAt != nil {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,749 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/validation"
+	"blockchain-backend/wallet"
+)
+
+// Limits and defaults for inheritance payouts
+const (
+	MinInactivityMonths             = 1
+	MaxInactivityMonths             = 120
+	DefaultInheritanceCheckInterval = time.Hour
+)
+
+// Inheritance payout statuses
+const (
+	PayoutPending   = "pending"   // filed by the checker, waiting for an admin
+	PayoutApproved  = "approved"  // the split is queued; nominees are paid once it confirms
+	PayoutPaid      = "paid"      // every nominee's transaction is queued
+	PayoutRejected  = "rejected"  // closed by an admin
+	PayoutCancelled = "cancelled" // the owner came back, or nothing was left to pay
+)
+
+// Errors returned by the inheritance service
+var (
+	ErrPayoutNotFound = errors.New("inheritance payout not found")
+	ErrPayoutDecided  = errors.New("inheritance payout was already decided")
+	ErrOwnerActive    = errors.New("the wallet sent coins after the payout was filed")
+	ErrNothingToPay   = errors.New("the wallet holds nothing its nominees can be paid")
+	ErrNoNominees     = errors.New("the wallet's owner has no beneficiaries with a share")
+	ErrInvalidMonths  = fmt.Errorf("inactivity months must be from %d to %d", MinInactivityMonths, MaxInactivityMonths)
+)
+
+// InheritanceRule pays a wallet's balance to its owner's nominees once the
+// wallet has sent nothing for InactivityMonths
+type InheritanceRule struct {
+	WalletID         string    `json:"wallet_id"`
+	InactivityMonths int       `json:"inactivity_months"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"` // setting the rule counts as activity
+}
+
+// Nominee is a beneficiary with a share of an inheritance payout
+type Nominee struct {
+	WalletID     string `json:"wallet_id"`
+	Name         string `json:"name,omitempty"`
+	SharePercent int    `json:"share_percent"`
+	PayTo        string `json:"pay_to,omitempty"` // the nominee's current wallet after key rotations
+	Amount       uint64 `json:"amount,omitempty"`
+	TxID         string `json:"txid,omitempty"`
+	Skipped      string `json:"skipped,omitempty"` // why the nominee is not paid
+}
+
+// InheritancePayout distributes an inactive wallet's balance to its
+// nominees. Approval queues a split of the balance into one output per
+// nominee; each output is then sent to its nominee once it is spendable.
+type InheritancePayout struct {
+	ID           int64      `json:"id"`
+	WalletID     string     `json:"wallet_id"`
+	Status       string     `json:"status"`
+	Balance      uint64     `json:"balance"` // spendable when filed, then the amount split out
+	LastActivity time.Time  `json:"last_activity"`
+	Nominees     []Nominee  `json:"nominees"`
+	SplitTxID    string     `json:"split_txid,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	DecidedBy    string     `json:"decided_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+}
+
+// Open reports whether the payout still awaits a decision or its transfers
+func (p InheritancePayout) Open() bool {
+	return p.Status == PayoutPending || p.Status == PayoutApproved
+}
+
+// InheritanceRun summarises one check
+type InheritanceRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Filed      int       `json:"filed"` // payouts filed for admin review
+	Paid       int       `json:"paid"`  // approved payouts whose transfers were queued
+}
+
+// InheritanceService watches wallets with an inactivity rule, files a payout
+// for admin review once one goes quiet, and pays approved payouts out
+type InheritanceService struct {
+	bc       *blockchain.Blockchain
+	ws       *wallet.Store
+	txSvc    *TransactionService
+	feed     *events.Feed
+	interval time.Duration
+	done     chan struct{}
+	loop     sync.WaitGroup // the ticker goroutine; Stop waits for it
+
+	// work serialises checks and decisions, which queue transactions
+	work sync.Mutex
+
+	mu       sync.Mutex
+	rules    map[string]*InheritanceRule
+	payouts  map[int64]*InheritancePayout
+	nextID   int64
+	db       database.InheritanceRepo
+	nominees func(ctx context.Context, walletID string) ([]Nominee, error)
+}
+
+func NewInheritanceService(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *TransactionService, feed *events.Feed, interval time.Duration) *InheritanceService {
+	return &InheritanceService{
+		bc:       bc,
+		ws:       ws,
+		txSvc:    txSvc,
+		feed:     feed,
+		interval: interval,
+		done:     make(chan struct{}),
+		rules:    make(map[string]*InheritanceRule),
+		payouts:  make(map[int64]*InheritancePayout),
+		nextID:   1,
+	}
+}
+
+// Reset drops the payouts after the chain was reset, as their splits were
+// mined on it. The rules stay.
+func (is *InheritanceService) Reset() {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.payouts = make(map[int64]*InheritancePayout)
+}
+
+// SetDatabase enables persistence, reloads rules and payouts, and reads
+// nominees from the beneficiaries table. Without it no wallet has nominees.
+func (is *InheritanceService) SetDatabase(db database.InheritanceRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules, err := db.GetInheritanceRules(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load inheritance rules from database: %v", err)
+	}
+	payouts, err := db.GetInheritancePayouts(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load inheritance payouts from database: %v", err)
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.db = db
+	is.nominees = func(ctx context.Context, walletID string) ([]Nominee, error) {
+		rows, err := db.GetNominees(ctx, walletID)
+		if err != nil {
+			return nil, err
+		}
+		nominees := make([]Nominee, 0, len(rows))
+		for _, row := range rows {
+			nominees = append(nominees, Nominee{
+				WalletID:     row["wallet_id"].(string),
+				Name:         row["name"].(string),
+				SharePercent: row["share_percent"].(int),
+			})
+		}
+		return nominees, nil
+	}
+	for _, row := range rules {
+		rule := &InheritanceRule{
+			WalletID:         row["wallet_id"].(string),
+			InactivityMonths: row["inactivity_months"].(int),
+			CreatedAt:        row["created_at"].(time.Time),
+			UpdatedAt:        row["updated_at"].(time.Time),
+		}
+		is.rules[rule.WalletID] = rule
+	}
+	for _, row := range payouts {
+		p := &InheritancePayout{
+			ID:           row["id"].(int64),
+			WalletID:     row["wallet_id"].(string),
+			Status:       row["status"].(string),
+			Balance:      row["balance"].(uint64),
+			LastActivity: row["last_activity"].(time.Time),
+			SplitTxID:    row["split_txid"].(string),
+			Reason:       row["reason"].(string),
+			DecidedBy:    row["decided_by"].(string),
+			CreatedAt:    row["created_at"].(time.Time),
+		}
+		if err := json.Unmarshal([]byte(row["nominees"].(string)), &p.Nominees); err != nil {
+			log.Printf("⚠️  Ignoring unreadable nominees of inheritance payout %d: %v", p.ID, err)
+		}
+		if t, ok := row["decided_at"].(*time.Time); ok {
+			p.DecidedAt = t
+		}
+		if t, ok := row["paid_at"].(*time.Time); ok {
+			p.PaidAt = t
+		}
+		is.payouts[p.ID] = p
+		if p.ID >= is.nextID {
+			is.nextID = p.ID + 1
+		}
+	}
+}
+
+// Interval returns how often Start runs Check
+func (is *InheritanceService) Interval() time.Duration {
+	return is.interval
+}
+
+// Rule returns the wallet's inactivity rule, and false when it has none
+func (is *InheritanceService) Rule(walletID string) (InheritanceRule, bool) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	rule, ok := is.rules[walletID]
+	if !ok {
+		return InheritanceRule{}, false
+	}
+	return *rule, true
+}
+
+// SetRule sets the wallet's inactivity rule; 0 months removes it
+func (is *InheritanceService) SetRule(walletID string, months int) (*InheritanceRule, error) {
+	if months != 0 && (months < MinInactivityMonths || months > MaxInactivityMonths) {
+		return nil, ErrInvalidMonths
+	}
+	if _, ok := is.ws.Get(walletID); !ok {
+		return nil, ErrWalletNotFound
+	}
+
+	is.mu.Lock()
+	db := is.db
+	now := time.Now()
+	rule := InheritanceRule{WalletID: walletID, InactivityMonths: months, CreatedAt: now, UpdatedAt: now}
+	if existing, ok := is.rules[walletID]; ok {
+		rule.CreatedAt = existing.CreatedAt
+	}
+	is.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if months == 0 {
+		if err := db.DeleteInheritanceRule(ctx, walletID); err != nil {
+			return nil, err
+		}
+		is.mu.Lock()
+		delete(is.rules, walletID)
+		is.mu.Unlock()
+		return nil, nil
+	}
+	if err := db.SaveInheritanceRule(ctx, walletID, months, rule.CreatedAt, rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	is.mu.Lock()
+	is.rules[walletID] = &rule
+	is.mu.Unlock()
+	return &rule, nil
+}
+
+// Nominees returns the beneficiaries with a share in the address book of
+// the wallet's owner
+func (is *InheritanceService) Nominees(ctx context.Context, walletID string) ([]Nominee, error) {
+	is.mu.Lock()
+	lookup := is.nominees
+	is.mu.Unlock()
+	if lookup == nil {
+		return []Nominee{}, nil
+	}
+	return lookup(ctx, walletID)
+}
+
+// LastActivity returns when the wallet last sent coins, mined or pending,
+// or when its rule was last set if that is later. Transactions the system
+// issues from the wallet, such as zakat or these payouts, do not count.
+func (is *InheritanceService) LastActivity(walletID string) time.Time {
+	var last time.Time
+	if rule, ok := is.Rule(walletID); ok {
+		last = rule.UpdatedAt
+	}
+
+	var latest int64
+	sent := func(tx blockchain.Transaction) {
+		if tx.SenderID == walletID && blockchain.LaneOf(tx) != blockchain.LaneSystem && tx.Timestamp > latest {
+			latest = tx.Timestamp
+		}
+	}
+	is.bc.View(func(v blockchain.View) {
+		for tx := range v.Pending() {
+			sent(tx)
+		}
+		for i := v.Height(); i >= 0 && latest == 0; i-- {
+			for _, tx := range v.Block(i).Transactions {
+				sent(tx)
+			}
+		}
+	})
+	if t := time.Unix(latest, 0); latest > 0 && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+// EligibleAt returns when the wallet's balance becomes payable under its
+// rule if it stays inactive, and false when it has no rule
+func (is *InheritanceService) EligibleAt(walletID string) (time.Time, bool) {
+	rule, ok := is.Rule(walletID)
+	if !ok {
+		return time.Time{}, false
+	}
+	return is.LastActivity(walletID).AddDate(0, rule.InactivityMonths, 0), true
+}
+
+// List returns payouts with the given status (empty matches all) for the
+// given wallet (empty matches all), newest first
+func (is *InheritanceService) List(status, walletID string) []InheritancePayout {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	list := make([]InheritancePayout, 0)
+	for _, p := range is.payouts {
+		if (status == "" || p.Status == status) && (walletID == "" || p.WalletID == walletID) {
+			list = append(list, is.copyPayout(p))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Check files a payout for every active wallet that has outlived its rule
+// with a balance and nominees, and queues the transfers of approved payouts
+// whose split has confirmed
+func (is *InheritanceService) Check() InheritanceRun {
+	is.work.Lock()
+	defer is.work.Unlock()
+
+	run := InheritanceRun{StartedAt: time.Now()}
+	is.mu.Lock()
+	rules := make([]InheritanceRule, 0, len(is.rules))
+	for _, rule := range is.rules {
+		rules = append(rules, *rule)
+	}
+	// A wallet gets one payout per stretch of inactivity, so a rejection or
+	// the part outside the nominees' shares is not filed again
+	open := make(map[string]bool)
+	filed := make(map[string]time.Time)
+	var approved []int64
+	for _, p := range is.payouts {
+		if p.Open() {
+			open[p.WalletID] = true
+		}
+		if p.LastActivity.After(filed[p.WalletID]) {
+			filed[p.WalletID] = p.LastActivity
+		}
+		if p.Status == PayoutApproved {
+			approved = append(approved, p.ID)
+		}
+	}
+	is.mu.Unlock()
+
+	for _, rule := range rules {
+		if open[rule.WalletID] {
+			continue
+		}
+		if w, ok := is.ws.Get(rule.WalletID); !ok || !w.Active() {
+			continue
+		}
+		last := is.LastActivity(rule.WalletID)
+		if !last.After(filed[rule.WalletID]) || run.StartedAt.Before(last.AddDate(0, rule.InactivityMonths, 0)) {
+			continue
+		}
+		balance := is.bc.GetBalance(rule.WalletID)
+		if balance == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		nominees, err := is.Nominees(ctx, rule.WalletID)
+		cancel()
+		if err != nil {
+			log.Printf("⚠️  Failed to load nominees of %s: %v", rule.WalletID, err)
+			continue
+		}
+		if len(nominees) == 0 {
+			continue
+		}
+
+		is.mu.Lock()
+		p := &InheritancePayout{
+			ID:           is.nextID,
+			WalletID:     rule.WalletID,
+			Status:       PayoutPending,
+			Balance:      balance,
+			LastActivity: last,
+			Nominees:     nominees,
+			CreatedAt:    time.Now(),
+		}
+		is.nextID++
+		is.payouts[p.ID] = p
+		snapshot := is.copyPayout(p)
+		is.mu.Unlock()
+
+		is.persist(snapshot)
+		run.Filed++
+		log.Printf("⚰️  Inheritance payout %d filed for %s (inactive since %s)", p.ID, rule.WalletID, last.UTC().Format(time.RFC3339))
+	}
+
+	sort.Slice(approved, func(i, j int) bool { return approved[i] < approved[j] })
+	for _, id := range approved {
+		if is.pay(id) {
+			run.Paid++
+		}
+	}
+	run.FinishedAt = time.Now()
+	return run
+}
+
+// Approve re-checks that the wallet is still inactive and queues a system
+// transaction splitting its spendable balance into one output per nominee's
+// share, with the rest back to the wallet. Nominees are paid once it confirms.
+func (is *InheritanceService) Approve(id int64, actor string) (*InheritancePayout, error) {
+	is.work.Lock()
+	defer is.work.Unlock()
+
+	p, err := is.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if is.LastActivity(p.WalletID).After(p.LastActivity) {
+		is.close(id, PayoutCancelled, actor, "the owner sent coins after the payout was filed")
+		return nil, ErrOwnerActive
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	nominees, err := is.Nominees(ctx, p.WalletID)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if len(nominees) == 0 {
+		return nil, ErrNoNominees
+	}
+
+	utxos := is.txSvc.ConsolidationCandidates(p.WalletID, 0)
+	if len(utxos) > validation.MaxTxInputs {
+		// Largest first, so the split moves as much as one transaction can
+		utxos = utxos[len(utxos)-validation.MaxTxInputs:]
+	}
+	var total uint64
+	for _, utxo := range utxos {
+		total += utxo.Amount
+	}
+
+	var outputs []blockchain.UTXO
+	var split uint64
+	paid := 0
+	for i := range nominees {
+		n := &nominees[i]
+		n.PayTo = is.currentWallet(n.WalletID)
+		if w, ok := is.ws.Get(n.PayTo); !ok || !w.Active() {
+			n.Skipped = "nominee wallet is not active"
+			continue
+		}
+		n.Amount = total/100*uint64(n.SharePercent) + total%100*uint64(n.SharePercent)/100
+		if n.Amount == 0 {
+			continue
+		}
+		outputs = append(outputs, blockchain.UTXO{Owner: p.WalletID, Amount: n.Amount, Index: len(outputs)})
+		split += n.Amount
+		paid++
+	}
+	if split == 0 {
+		is.close(id, PayoutCancelled, actor, ErrNothingToPay.Error())
+		return nil, ErrNothingToPay
+	}
+	if rest := total - split; rest > 0 {
+		outputs = append(outputs, blockchain.UTXO{Owner: p.WalletID, Amount: rest, Index: len(outputs)})
+	}
+
+	tx := buildConsolidation(p.WalletID, utxos, 0, fmt.Sprintf("Inheritance split for %d nominees", paid))
+	tx.Outputs = outputs
+	tx.Type = "inheritance_split"
+	tx.PubKey = "system"
+	tx.Signature = "system"
+	blockchain.AssignID(tx)
+	if err := is.bc.AddPending(*tx); err != nil {
+		return nil, err
+	}
+	is.feed.PublishTransaction(events.TxPending, *tx, nil)
+	is.persistTx(tx)
+
+	now := time.Now()
+	is.mu.Lock()
+	stored := is.payouts[id]
+	stored.Status = PayoutApproved
+	stored.Balance = split
+	stored.Nominees = nominees
+	stored.SplitTxID = tx.ID
+	stored.DecidedBy = actor
+	stored.DecidedAt = &now
+	snapshot := is.copyPayout(stored)
+	is.mu.Unlock()
+
+	is.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Reject closes a pending payout without moving any coins
+func (is *InheritanceService) Reject(id int64, actor, reason string) (*InheritancePayout, error) {
+	is.work.Lock()
+	defer is.work.Unlock()
+
+	if _, err := is.pending(id); err != nil {
+		return nil, err
+	}
+	return is.close(id, PayoutRejected, actor, reason), nil
+}
+
+// pending returns a copy of the payout if it awaits a decision
+func (is *InheritanceService) pending(id int64) (InheritancePayout, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	p, ok := is.payouts[id]
+	if !ok {
+		return InheritancePayout{}, ErrPayoutNotFound
+	}
+	if p.Status != PayoutPending {
+		return InheritancePayout{}, ErrPayoutDecided
+	}
+	return is.copyPayout(p), nil
+}
+
+// close ends a payout with the given status and reason
+func (is *InheritanceService) close(id int64, status, actor, reason string) *InheritancePayout {
+	now := time.Now()
+	is.mu.Lock()
+	p := is.payouts[id]
+	p.Status = status
+	p.Reason = reason
+	if p.DecidedAt == nil {
+		p.DecidedBy = actor
+		p.DecidedAt = &now
+	}
+	snapshot := is.copyPayout(p)
+	is.mu.Unlock()
+
+	is.persist(snapshot)
+	return &snapshot
+}
+
+// pay sends each nominee its output of an approved payout's split once the
+// output is spendable, and reports whether the payout is now fully paid.
+// The owner sending coins first cancels it, leaving the outputs in the wallet.
+func (is *InheritanceService) pay(id int64) bool {
+	is.mu.Lock()
+	p := is.copyPayout(is.payouts[id])
+	is.mu.Unlock()
+
+	if is.LastActivity(p.WalletID).After(p.LastActivity) {
+		is.close(id, PayoutCancelled, "system", "the owner sent coins after the payout was approved")
+		return false
+	}
+
+	type transfer struct {
+		nominee int
+		input   blockchain.UTXO
+	}
+	var ready []transfer
+	splitPending := false
+	index := 0
+	waiting := false
+	is.bc.View(func(v blockchain.View) {
+		for tx := range v.Pending() {
+			if tx.ID == p.SplitTxID {
+				splitPending = true
+				break
+			}
+		}
+		// The split gave an output to every nominee with an amount, in
+		// order; nominees skipped since still hold theirs
+		for i, n := range p.Nominees {
+			if n.Amount == 0 {
+				continue
+			}
+			key := blockchain.UTXOKey(p.SplitTxID, index)
+			index++
+			if n.TxID != "" || n.Skipped != "" {
+				continue
+			}
+			utxo, ok := v.UTXO(key)
+			switch {
+			case !ok:
+				waiting = true
+			case utxo.Spent:
+				p.Nominees[i].Skipped = "earmarked output was spent"
+			case !v.Spendable(utxo):
+				waiting = true
+			default:
+				ready = append(ready, transfer{nominee: i, input: utxo})
+			}
+		}
+	})
+
+	if waiting && !splitPending {
+		if _, found := is.bc.GetTransactionByID(p.SplitTxID); !found {
+			is.close(id, PayoutCancelled, "system", "the split transaction was dropped")
+			return false
+		}
+	}
+
+	for _, t := range ready {
+		n := &p.Nominees[t.nominee]
+		n.PayTo = is.currentWallet(n.WalletID)
+		if w, ok := is.ws.Get(n.PayTo); !ok || !w.Active() {
+			n.Skipped = "nominee wallet is not active"
+			continue
+		}
+		tx := &blockchain.Transaction{
+			Version:    blockchain.TxVersion,
+			SenderID:   p.WalletID,
+			ReceiverID: n.PayTo,
+			Amount:     n.Amount,
+			Note:       fmt.Sprintf("Inheritance payout of %d%% from %s", n.SharePercent, p.WalletID),
+			Timestamp:  time.Now().Unix(),
+			PubKey:     "system",
+			Signature:  "system",
+			Inputs:     []blockchain.UTXORef{{TxID: t.input.OriginTx, Index: t.input.Index}},
+			Outputs:    []blockchain.UTXO{{Owner: n.PayTo, Amount: n.Amount, Index: 0}},
+			Type:       "inheritance_payout",
+		}
+		blockchain.AssignID(tx)
+		if err := is.bc.AddPending(*tx); err != nil {
+			log.Printf("⚠️  Inheritance payout %d to %s rejected: %v", id, n.PayTo, err)
+			waiting = true
+			continue
+		}
+		is.feed.PublishTransaction(events.TxPending, *tx, nil)
+		is.persistTx(tx)
+		n.TxID = tx.ID
+	}
+
+	is.mu.Lock()
+	stored := is.payouts[id]
+	stored.Nominees = p.Nominees
+	paid := !waiting
+	if paid {
+		now := time.Now()
+		stored.Status = PayoutPaid
+		stored.PaidAt = &now
+	}
+	snapshot := is.copyPayout(stored)
+	is.mu.Unlock()
+
+	is.persist(snapshot)
+	if paid {
+		log.Printf("⚰️  Inheritance payout %d from %s paid to its nominees", id, p.WalletID)
+	}
+	return paid
+}
+
+// currentWallet follows a wallet's key rotations to the wallet now holding
+// its funds
+func (is *InheritanceService) currentWallet(walletID string) string {
+	return followRotations(is.ws, walletID)
+}
+
+// followRotations follows a wallet's key rotations to its latest wallet
+func followRotations(ws *wallet.Store, walletID string) string {
+	seen := map[string]bool{}
+	for !seen[walletID] {
+		seen[walletID] = true
+		w, ok := ws.Get(walletID)
+		if !ok || w.RotatedTo == "" {
+			break
+		}
+		walletID = w.RotatedTo
+	}
+	return walletID
+}
+
+// Start runs Check on the interval
+func (is *InheritanceService) Start() {
+	is.loop.Add(1)
+	go func() {
+		defer is.loop.Done()
+		ticker := time.NewTicker(is.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				is.Check()
+			case <-is.done:
+				return
+			}
+		}
+	}()
+	log.Printf("✅ Inheritance checker started (every %s)", is.interval)
+}
+
+// Stop ends the periodic checks, waiting for a check in progress
+func (is *InheritanceService) Stop() {
+	close(is.done)
+	is.loop.Wait()
+}
+
+// copyPayout copies p with its own nominee slice. The caller must hold mu.
+func (is *InheritanceService) copyPayout(p *InheritancePayout) InheritancePayout {
+	c := *p
+	c.Nominees = append([]Nominee(nil), p.Nominees...)
+	return c
+}
+
+func (is *InheritanceService) persist(p InheritancePayout) {
+	is.mu.Lock()
+	db := is.db
+	is.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	nominees, err := json.Marshal(p.Nominees)
+	if err != nil {
+		log.Printf("Failed to encode nominees of inheritance payout %d: %v", p.ID, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveInheritancePayout(ctx, p.ID, p.WalletID, p.Status, p.Balance, p.LastActivity, string(nominees), p.SplitTxID, p.Reason, p.DecidedBy, p.CreatedAt, p.DecidedAt, p.PaidAt); err != nil {
+		log.Printf("Failed to persist inheritance payout %d: %v", p.ID, err)
+	}
+}
+
+func (is *InheritanceService) persistTx(tx *blockchain.Transaction) {
+	is.mu.Lock()
+	db := is.db
+	is.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveTransaction(ctx, tx.ID, tx.SenderID, tx.ReceiverID, tx.Amount, tx.Fee, tx.Nonce, tx.Note, tx.Timestamp, tx.PubKey, tx.Signature, tx.Type, nil, "pending"); err != nil {
+		log.Printf("Failed to persist inheritance transaction %s: %v", tx.ID, err)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/events"
+	"blockchain-backend/wallet"
+)
+
+// TestPayAfterSkippedNominee retries a payout whose first nominee was
+// skipped after the split was queued. The other nominees must still be paid
+// from their own outputs of the split.
+func TestPayAfterSkippedNominee(t *testing.T) {
+	bc := blockchain.NewBlockchain()
+	bc.DifficultyPref = "0"
+	ws := wallet.NewStore()
+	for _, id := range []string{"owner", "heir-a", "heir-b", "heir-c"} {
+		ws.Save(wallet.Wallet{WalletID: id})
+	}
+	is := NewInheritanceService(bc, ws, NewTransactionService(bc, ws), events.NewFeed(0), time.Hour)
+
+	// The split as Approve queues it: one output per nominee, then the rest
+	grant := bc.CreateFaucetUTXO("owner", 1000)
+	amounts := []uint64{500, 300, 150}
+	split := buildConsolidation("owner", []blockchain.UTXO{grant}, 0, "Inheritance split for 3 nominees")
+	split.Outputs = nil
+	for i, amount := range amounts {
+		split.Outputs = append(split.Outputs, blockchain.UTXO{Owner: "owner", Amount: amount, Index: i})
+	}
+	split.Outputs = append(split.Outputs, blockchain.UTXO{Owner: "owner", Amount: 50, Index: len(amounts)})
+	split.Type, split.PubKey, split.Signature = "inheritance_split", "system", "system"
+	blockchain.AssignID(split)
+	if err := bc.AddPending(*split); err != nil {
+		t.Fatalf("queue split: %v", err)
+	}
+	bc.Mine(0, "miner")
+
+	// The first nominee's wallet went inactive on an earlier run
+	is.payouts[1] = &InheritancePayout{
+		ID:           1,
+		WalletID:     "owner",
+		Status:       PayoutApproved,
+		LastActivity: time.Now().Add(time.Hour),
+		SplitTxID:    split.ID,
+		Nominees: []Nominee{
+			{WalletID: "heir-a", SharePercent: 50, Amount: amounts[0], Skipped: "nominee wallet is not active"},
+			{WalletID: "heir-b", SharePercent: 30, Amount: amounts[1]},
+			{WalletID: "heir-c", SharePercent: 15, Amount: amounts[2]},
+		},
+	}
+
+	if !is.pay(1) {
+		t.Fatalf("payout not paid: %+v", is.payouts[1].Nominees)
+	}
+	for i, n := range is.payouts[1].Nominees[1:] {
+		loc, ok := bc.GetTransactionByID(n.TxID)
+		if !ok {
+			t.Fatalf("%s: payout transaction %q not queued (skipped: %q)", n.WalletID, n.TxID, n.Skipped)
+		}
+		if in := loc.Transaction.Inputs[0]; in.TxID != split.ID || in.Index != i+1 {
+			t.Errorf("%s paid from %s:%d, want %s:%d", n.WalletID, in.TxID, in.Index, split.ID, i+1)
+		}
+	}
+}
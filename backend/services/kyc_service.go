@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// KYC submission statuses
+const (
+	KYCPending  = "pending"
+	KYCApproved = "approved"
+	KYCRejected = "rejected"
+)
+
+// KYC document types a submission may reference
+var KYCDocumentTypes = []string{"cnic", "passport", "driving_license"}
+
+// DefaultUnverifiedDailyLimit is how many coins a wallet without approved KYC
+// may send per UTC day, fees included
+const DefaultUnverifiedDailyLimit = 1000 * blockchain.UnitsPerCoin
+
+// Errors returned by the KYC service
+var (
+	ErrKYCPending         = errors.New("wallet already has a KYC submission under review")
+	ErrKYCAlreadyVerified = errors.New("wallet is already KYC-verified")
+	ErrKYCNotFound        = errors.New("KYC submission not found")
+	ErrKYCDecided         = errors.New("KYC submission was already reviewed")
+	ErrKYCLimitExceeded   = errors.New("daily send limit for wallets without verified KYC exceeded")
+)
+
+// KYCSubmission is a wallet's identity evidence awaiting or after admin
+// review. DocumentRef points at the uploaded document (a storage key, URL or
+// content hash); the document itself is not kept by the backend.
+type KYCSubmission struct {
+	ID           int64      `json:"id"`
+	WalletID     string     `json:"wallet_id"`
+	CNIC         string     `json:"cnic"`
+	DocumentType string     `json:"document_type"`
+	DocumentRef  string     `json:"document_ref"`
+	Status       string     `json:"status"`
+	Note         string     `json:"note,omitempty"` // reviewer's note, e.g. why it was rejected
+	ReviewedBy   string     `json:"reviewed_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// KYCService holds KYC submissions and decides which wallets are verified.
+// A wallet is verified once any of its submissions is approved.
+type KYCService struct {
+	mu          sync.Mutex
+	ws          *wallet.Store
+	submissions map[int64]*KYCSubmission
+	nextID      int64
+	db          database.KYCRepo
+	dailyLimit  uint64
+}
+
+func NewKYCService(ws *wallet.Store, dailyLimit uint64) *KYCService {
+	return &KYCService{
+		ws:          ws,
+		submissions: make(map[int64]*KYCSubmission),
+		nextID:      1,
+		dailyLimit:  dailyLimit,
+	}
+}
+
+// SetDatabase enables persistence and reloads previous submissions
+func (ks *KYCService) SetDatabase(db database.KYCRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetKYCSubmissions(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load KYC submissions from database: %v", err)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.db = db
+	for _, row := range rows {
+		sub := &KYCSubmission{
+			ID:           row["id"].(int64),
+			WalletID:     row["wallet_id"].(string),
+			CNIC:         row["cnic"].(string),
+			DocumentType: row["document_type"].(string),
+			DocumentRef:  row["document_ref"].(string),
+			Status:       row["status"].(string),
+			Note:         row["note"].(string),
+			ReviewedBy:   row["reviewed_by"].(string),
+			CreatedAt:    row["created_at"].(time.Time),
+		}
+		if t, ok := row["reviewed_at"].(*time.Time); ok {
+			sub.ReviewedAt = t
+		}
+		ks.submissions[sub.ID] = sub
+		if sub.ID >= ks.nextID {
+			ks.nextID = sub.ID + 1
+		}
+	}
+}
+
+// DailyLimit is the most an unverified wallet may send per UTC day; 0 means
+// no limit
+func (ks *KYCService) DailyLimit() uint64 {
+	return ks.dailyLimit
+}
+
+// Submit files identity evidence for review. A wallet may have one
+// submission under review at a time and cannot resubmit once verified.
+func (ks *KYCService) Submit(walletID, cnic, documentType, documentRef string) (*KYCSubmission, error) {
+	if _, ok := ks.ws.Get(walletID); !ok {
+		return nil, ErrWalletNotFound
+	}
+
+	ks.mu.Lock()
+	for _, existing := range ks.submissions {
+		if existing.WalletID != walletID {
+			continue
+		}
+		switch existing.Status {
+		case KYCPending:
+			ks.mu.Unlock()
+			return nil, ErrKYCPending
+		case KYCApproved:
+			ks.mu.Unlock()
+			return nil, ErrKYCAlreadyVerified
+		}
+	}
+	sub := &KYCSubmission{
+		ID:           ks.nextID,
+		WalletID:     walletID,
+		CNIC:         cnic,
+		DocumentType: documentType,
+		DocumentRef:  documentRef,
+		Status:       KYCPending,
+		CreatedAt:    time.Now(),
+	}
+	ks.nextID++
+	ks.submissions[sub.ID] = sub
+	snapshot := *sub
+	ks.mu.Unlock()
+
+	ks.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Verified reports whether the wallet has an approved submission
+func (ks *KYCService) Verified(walletID string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for _, sub := range ks.submissions {
+		if sub.WalletID == walletID && sub.Status == KYCApproved {
+			return true
+		}
+	}
+	return false
+}
+
+// History returns a wallet's submissions, newest first
+func (ks *KYCService) History(walletID string) []KYCSubmission {
+	return ks.list(func(sub *KYCSubmission) bool { return sub.WalletID == walletID })
+}
+
+// List returns submissions with the given status (empty matches all), newest first
+func (ks *KYCService) List(status string) []KYCSubmission {
+	return ks.list(func(sub *KYCSubmission) bool { return status == "" || sub.Status == status })
+}
+
+func (ks *KYCService) list(match func(*KYCSubmission) bool) []KYCSubmission {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	list := make([]KYCSubmission, 0)
+	for _, sub := range ks.submissions {
+		if match(sub) {
+			list = append(list, *sub)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Approve verifies the submission's wallet
+func (ks *KYCService) Approve(id int64, actor, note string) (*KYCSubmission, error) {
+	return ks.review(id, actor, note, KYCApproved)
+}
+
+// Reject closes the submission; the wallet may submit again
+func (ks *KYCService) Reject(id int64, actor, note string) (*KYCSubmission, error) {
+	return ks.review(id, actor, note, KYCRejected)
+}
+
+func (ks *KYCService) review(id int64, actor, note, status string) (*KYCSubmission, error) {
+	ks.mu.Lock()
+	sub, ok := ks.submissions[id]
+	if !ok {
+		ks.mu.Unlock()
+		return nil, ErrKYCNotFound
+	}
+	if sub.Status != KYCPending {
+		ks.mu.Unlock()
+		return nil, ErrKYCDecided
+	}
+	now := time.Now()
+	sub.Status = status
+	sub.Note = note
+	sub.ReviewedBy = actor
+	sub.ReviewedAt = &now
+	snapshot := *sub
+	ks.mu.Unlock()
+
+	ks.persist(snapshot)
+	return &snapshot, nil
+}
+
+func (ks *KYCService) persist(sub KYCSubmission) {
+	ks.mu.Lock()
+	db := ks.db
+	ks.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveKYCSubmission(ctx, sub.ID, sub.WalletID, sub.CNIC, sub.DocumentType, sub.DocumentRef, sub.Status, sub.Note, sub.ReviewedBy, sub.CreatedAt, sub.ReviewedAt); err != nil {
+		log.Printf("Failed to persist KYC submission %d: %v", sub.ID, err)
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/wallet"
+)
+
+// Errors returned when building locked transfers and spending locked outputs
+var (
+	ErrLockInPast    = errors.New("lock time must be in the future")
+	ErrNotHashLocked = errors.New("output has no hash lock")
+)
+
+// CreateLockedTransaction creates a signed transfer paying the receiver under
+// lock; see blockchain.Lock for what each condition means. The change output
+// is not locked.
+func (ts *TransactionService) CreateLockedTransaction(ctx context.Context, senderID, receiverID, assetID string, amount uint64, lock blockchain.Lock, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	tx, _, err := ts.PrepareLockedTransaction(ctx, senderID, receiverID, assetID, amount, lock, note)
+	if err != nil {
+		return nil, err
+	}
+	return ts.sign(tx, pubKey, privKey)
+}
+
+// PrepareLockedTransaction is PrepareAssetTransaction paying the receiver
+// under lock
+func (ts *TransactionService) PrepareLockedTransaction(ctx context.Context, senderID, receiverID, assetID string, amount uint64, lock blockchain.Lock, note string) (*blockchain.Transaction, []blockchain.UTXO, error) {
+	if err := lock.Check(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrMalformedTransaction, err)
+	}
+	if lock.LockTime != 0 && lock.LockTime <= time.Now().Unix() {
+		return nil, nil, ErrLockInPast
+	}
+	return ts.prepareTransfer(ctx, senderID, receiverID, assetID, amount, note, lock)
+}
+
+// CreateTimeLockedTransaction creates a signed transfer the receiver can
+// spend only from unlockAt on, e.g. to vest coins
+func (ts *TransactionService) CreateTimeLockedTransaction(ctx context.Context, senderID, receiverID string, amount uint64, unlockAt int64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	return ts.CreateLockedTransaction(ctx, senderID, receiverID, "", amount, blockchain.Lock{LockTime: unlockAt}, note, pubKey, privKey)
+}
+
+// CreateHashLockedTransaction creates a signed transfer the receiver claims by
+// revealing the preimage of hashLock. From refundAt on the sender may take it
+// back instead; a zero refundAt leaves no way back. Two such transfers under
+// the same hash, the second with the earlier refund time, swap value between
+// two wallets: claiming one reveals the secret that claims the other.
+func (ts *TransactionService) CreateHashLockedTransaction(ctx context.Context, senderID, receiverID string, amount uint64, hashLock string, refundAt int64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	lock := blockchain.Lock{HashLock: hashLock, LockTime: refundAt}
+	if refundAt != 0 {
+		lock.RefundTo = senderID
+	}
+	return ts.CreateLockedTransaction(ctx, senderID, receiverID, "", amount, lock, note, pubKey, privKey)
+}
+
+// CreateClaimTransaction creates a signed transaction by which the owner of a
+// hash-locked output spends it to themselves, revealing the preimage. The
+// claim pays the usual transfer fee out of the output, or none for an asset.
+func (ts *TransactionService) CreateClaimTransaction(walletID, utxoID, preimage, pubKey, privKey string) (*blockchain.Transaction, error) {
+	return ts.createUnlock(walletID, utxoID, preimage, "htlc_claim", pubKey, privKey)
+}
+
+// CreateRefundTransaction creates a signed transaction by which the refund
+// wallet of an expired hash lock takes the output back
+func (ts *TransactionService) CreateRefundTransaction(walletID, utxoID, pubKey, privKey string) (*blockchain.Transaction, error) {
+	return ts.createUnlock(walletID, utxoID, "", "htlc_refund", pubKey, privKey)
+}
+
+func (ts *TransactionService) createUnlock(walletID, utxoID, preimage, txType, pubKey, privKey string) (*blockchain.Transaction, error) {
+	if _, exists := ts.ws.Get(walletID); !exists {
+		return nil, ErrSenderNotFound
+	}
+
+	var utxo blockchain.UTXO
+	var exists, confirmed bool
+	ts.bc.View(func(v blockchain.View) {
+		utxo, exists = v.UTXO(utxoID)
+		confirmed = exists && v.Confirmed(utxo)
+	})
+	switch {
+	case !exists:
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrUnknownInput, utxoID)
+	case utxo.Spent:
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrInputSpent, utxoID)
+	case utxo.HashLock == "":
+		return nil, ErrNotHashLocked
+	case !confirmed:
+		return nil, fmt.Errorf("%w: the output is not confirmed yet", ErrWalletBusy)
+	}
+	if err := utxo.Unlock(walletID, preimage, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+
+	var fee uint64
+	if utxo.AssetID == "" {
+		fee = ts.Fees(walletID).Transfer
+	}
+	if utxo.Amount <= fee {
+		return nil, fmt.Errorf("%w: the output does not cover the fee of %d", ErrInsufficientBalance, fee)
+	}
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersionLock,
+		SenderID:   walletID,
+		ReceiverID: walletID,
+		AssetID:    utxo.AssetID,
+		Amount:     utxo.Amount - fee,
+		Fee:        fee,
+		Nonce:      ts.bc.GetNonce(walletID) + 1,
+		Note:       "Unlock of " + utxoID,
+		Timestamp:  time.Now().Unix(),
+		Inputs:     []blockchain.UTXORef{{TxID: utxo.OriginTx, Index: utxo.Index, Preimage: preimage}},
+		Outputs:    []blockchain.UTXO{{Owner: walletID, Amount: utxo.Amount - fee}},
+		Type:       txType,
+	}
+	return ts.sign(tx, pubKey, privKey)
+}
+
+// sign assigns a transaction its ID and signs it with the sender's key
+func (ts *TransactionService) sign(tx *blockchain.Transaction, pubKey, privKey string) (*blockchain.Transaction, error) {
+	tx.PubKey = pubKey
+	blockchain.AssignID(tx)
+
+	signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	tx.Signature = signature
+	return tx, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// LogExportBatch is how many rows a log export reads at a time
+const LogExportBatch = 1000
+
+// ExportSystemLogs passes the system log entries matching f to emit, oldest
+// first, one page at a time. The next page is read only after emit returns,
+// so a slow consumer slows the export down instead of piling rows up in
+// memory. With a database the persisted log is exported, otherwise the
+// in-memory one.
+func (ls *LoggingService) ExportSystemLogs(ctx context.Context, f database.LogFilter, emit func([]LogEntry) error) error {
+	ls.mu.RLock()
+	db := ls.db
+	ls.mu.RUnlock()
+
+	var after database.LogCursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var page []LogEntry
+		if db != nil {
+			rows, err := db.SystemLogPage(ctx, f, after, LogExportBatch)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				page = append(page, logEntryFromRow(row))
+			}
+		} else {
+			page = ls.systemLogPage(f, after.ID)
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		if err := emit(page); err != nil {
+			return err
+		}
+		if len(page) < LogExportBatch {
+			return nil
+		}
+		last := page[len(page)-1]
+		after = database.LogCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// ExportTransactionLogs is ExportSystemLogs for the transaction log
+func (ls *LoggingService) ExportTransactionLogs(ctx context.Context, f database.LogFilter, emit func([]TransactionLog) error) error {
+	ls.mu.RLock()
+	db := ls.db
+	ls.mu.RUnlock()
+
+	var after database.LogCursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var page []TransactionLog
+		if db != nil {
+			rows, err := db.TransactionLogPage(ctx, f, after, LogExportBatch)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				page = append(page, transactionLogFromRow(row))
+			}
+		} else {
+			page = ls.transactionLogPage(f, after.ID)
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		if err := emit(page); err != nil {
+			return err
+		}
+		if len(page) < LogExportBatch {
+			return nil
+		}
+		last := page[len(page)-1]
+		after = database.LogCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// systemLogPage returns the next page of in-memory entries after afterID.
+// Entries are appended in ID order, so the start is found by binary search.
+func (ls *LoggingService) systemLogPage(f database.LogFilter, afterID int64) []LogEntry {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	start := sort.Search(len(ls.systemLogs), func(i int) bool { return ls.systemLogs[i].ID > afterID })
+	var page []LogEntry
+	for _, l := range ls.systemLogs[start:] {
+		if !f.Matches(l.CreatedAt, l.WalletID) {
+			continue
+		}
+		page = append(page, l)
+		if len(page) == LogExportBatch {
+			break
+		}
+	}
+	return page
+}
+
+func (ls *LoggingService) transactionLogPage(f database.LogFilter, afterID int64) []TransactionLog {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	start := sort.Search(len(ls.transactionLogs), func(i int) bool { return ls.transactionLogs[i].ID > afterID })
+	var page []TransactionLog
+	for _, l := range ls.transactionLogs[start:] {
+		if !f.Matches(l.CreatedAt, l.WalletID) {
+			continue
+		}
+		page = append(page, l)
+		if len(page) == LogExportBatch {
+			break
+		}
+	}
+	return page
+}
+
+func logEntryFromRow(row map[string]interface{}) LogEntry {
+	var l LogEntry
+	l.ID, _ = row["id"].(int64)
+	l.EventType, _ = row["event_type"].(string)
+	l.WalletID, _ = row["wallet_id"].(string)
+	l.IPAddress, _ = row["ip_address"].(string)
+	l.Details, _ = row["details"].(string)
+	l.RequestID, _ = row["request_id"].(string)
+	l.Country, _ = row["country"].(string)
+	l.City, _ = row["city"].(string)
+	l.Device, _ = row["device"].(string)
+	l.CreatedAt, _ = row["created_at"].(time.Time)
+	return l
+}
+
+func transactionLogFromRow(row map[string]interface{}) TransactionLog {
+	var l TransactionLog
+	l.ID, _ = row["id"].(int64)
+	l.TransactionID, _ = row["transaction_id"].(string)
+	l.Action, _ = row["action"].(string)
+	l.WalletID, _ = row["wallet_id"].(string)
+	l.BlockHash, _ = row["block_hash"].(string)
+	l.Status, _ = row["status"].(string)
+	l.IPAddress, _ = row["ip_address"].(string)
+	l.RequestID, _ = row["request_id"].(string)
+	l.CreatedAt, _ = row["created_at"].(time.Time)
+	return l
+}
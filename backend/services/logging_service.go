@@ -2,19 +2,54 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 	
 	"blockchain-backend/database"
+	"blockchain-backend/geoip"
 )
 
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the HTTP request being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID stored in ctx, or "" outside a request
+func RequestIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type deviceKey struct{}
+
+// WithDevice returns a context carrying the fingerprint of the caller's device
+func WithDevice(ctx context.Context, device string) context.Context {
+	return context.WithValue(ctx, deviceKey{}, device)
+}
+
+// DeviceFrom returns the device fingerprint stored in ctx, or ""
+func DeviceFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	device, _ := ctx.Value(deviceKey{}).(string)
+	return device
+}
+
 type LogEntry struct {
 	ID        int64     `json:"id"`
 	EventType string    `json:"event_type"`
 	WalletID  string    `json:"wallet_id,omitempty"`
 	IPAddress string    `json:"ip_address,omitempty"`
 	Details   string    `json:"details"`
+	RequestID string    `json:"request_id,omitempty"`
+	database.LogOrigin          // sensitive events only; see LogSensitiveCtx
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -26,6 +61,7 @@ type TransactionLog struct {
 	BlockHash     string    `json:"block_hash,omitempty"`
 	Status        string    `json:"status"`
 	IPAddress     string    `json:"ip_address,omitempty"`
+	RequestID     string    `json:"request_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -35,7 +71,9 @@ type LoggingService struct {
 	transactionLogs []TransactionLog
 	logCounter     int64
 	txLogCounter   int64
-	db             *database.DB
+	db             database.LogRepo
+	writes         sync.WaitGroup // database writes in flight
+	geo            *geoip.DB      // nil leaves sensitive events without a location
 }
 
 func NewLoggingService() *LoggingService {
@@ -48,22 +86,95 @@ func NewLoggingService() *LoggingService {
 	}
 }
 
-func (ls *LoggingService) SetDatabase(db *database.DB) {
+// ResetTransactionLogs drops the transaction log kept in memory after the
+// chain was reset; the store's is emptied with the chain. System logs stay.
+func (ls *LoggingService) ResetTransactionLogs() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.transactionLogs = make([]TransactionLog, 0)
+}
+
+// SetDatabase persists logs in db, Postgres or a database.MemoryStore
+func (ls *LoggingService) SetDatabase(db database.LogRepo) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
 	ls.db = db
 }
 
+// SetGeoIP locates the IP addresses of sensitive events in geo
+func (ls *LoggingService) SetGeoIP(geo *geoip.DB) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.geo = geo
+}
+
+// Origin returns where a request came from: the location of its IP address
+// and the device fingerprint carried by ctx
+func (ls *LoggingService) Origin(ctx context.Context, ipAddress string) database.LogOrigin {
+	ls.mu.RLock()
+	geo := ls.geo
+	ls.mu.RUnlock()
+
+	origin := database.LogOrigin{Device: DeviceFrom(ctx)}
+	if loc, ok := geo.Lookup(ipAddress); ok {
+		origin.Country, origin.City = loc.Country, loc.City
+		if len(origin.City) > 100 {
+			origin.City = origin.City[:100]
+		}
+	}
+	return origin
+}
+
+// Drain waits for the logs still being written to the database, or until
+// ctx is done. Logs recorded after it are kept in memory only.
+func (ls *LoggingService) Drain(ctx context.Context) error {
+	ls.mu.Lock()
+	ls.db = nil
+	ls.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ls.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (ls *LoggingService) LogSystem(eventType, walletID, ipAddress, details string) {
+	ls.LogSystemCtx(context.Background(), eventType, walletID, ipAddress, details)
+}
+
+// LogSystemCtx records a system event, tagging it with the request ID carried by ctx
+func (ls *LoggingService) LogSystemCtx(ctx context.Context, eventType, walletID, ipAddress, details string) {
+	ls.logSystem(ctx, eventType, walletID, ipAddress, details, database.LogOrigin{})
+}
+
+// LogSensitiveCtx records a login, send or key export with where it came
+// from, which it returns
+func (ls *LoggingService) LogSensitiveCtx(ctx context.Context, eventType, walletID, ipAddress, details string) database.LogOrigin {
+	origin := ls.Origin(ctx, ipAddress)
+	ls.logSystem(ctx, eventType, walletID, ipAddress, details, origin)
+	return origin
+}
+
+func (ls *LoggingService) logSystem(ctx context.Context, eventType, walletID, ipAddress, details string, origin database.LogOrigin) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
 
+	requestID := RequestIDFrom(ctx)
 	entry := LogEntry{
 		ID:        ls.logCounter,
 		EventType: eventType,
 		WalletID:  walletID,
 		IPAddress: ipAddress,
 		Details:   details,
+		RequestID: requestID,
+		LogOrigin: origin,
 		CreatedAt: time.Now(),
 	}
 
@@ -72,21 +183,38 @@ func (ls *LoggingService) LogSystem(eventType, walletID, ipAddress, details stri
 
 	// Persist to database asynchronously
 	if ls.db != nil {
+		ls.writes.Add(1)
 		go func() {
+			defer ls.writes.Done()
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
-			ls.db.SaveSystemLog(ctx, eventType, walletID, ipAddress, details)
+			ls.db.SaveSystemLog(ctx, eventType, walletID, ipAddress, details, requestID, origin)
 		}()
 	}
 
-	// Also print to console for debugging
-	fmt.Printf("[SYSTEM LOG] %s - %s: %s\n", eventType, walletID, details)
+	attrs := []any{
+		"event_type", eventType,
+		"wallet_id", walletID,
+		"ip_address", ipAddress,
+		"details", details,
+		"request_id", requestID,
+	}
+	if origin != (database.LogOrigin{}) {
+		attrs = append(attrs, "country", origin.Country, "city", origin.City, "device", origin.Device)
+	}
+	slog.Info("system event", attrs...)
 }
 
 func (ls *LoggingService) LogTransaction(txID, action, walletID, blockHash, status, ipAddress string) {
+	ls.LogTransactionCtx(context.Background(), txID, action, walletID, blockHash, status, ipAddress)
+}
+
+// LogTransactionCtx records a transaction event, tagging it with the request ID carried by ctx
+func (ls *LoggingService) LogTransactionCtx(ctx context.Context, txID, action, walletID, blockHash, status, ipAddress string) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
 
+	requestID := RequestIDFrom(ctx)
 	entry := TransactionLog{
 		ID:            ls.txLogCounter,
 		TransactionID: txID,
@@ -95,6 +223,7 @@ func (ls *LoggingService) LogTransaction(txID, action, walletID, blockHash, stat
 		BlockHash:     blockHash,
 		Status:        status,
 		IPAddress:     ipAddress,
+		RequestID:     requestID,
 		CreatedAt:     time.Now(),
 	}
 
@@ -103,14 +232,23 @@ func (ls *LoggingService) LogTransaction(txID, action, walletID, blockHash, stat
 
 	// Persist to database asynchronously
 	if ls.db != nil {
+		ls.writes.Add(1)
 		go func() {
+			defer ls.writes.Done()
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
-			ls.db.SaveTransactionLog(ctx, txID, action, walletID, blockHash, status, ipAddress)
+			ls.db.SaveTransactionLog(ctx, txID, action, walletID, blockHash, status, ipAddress, requestID)
 		}()
 	}
 
-	fmt.Printf("[TX LOG] %s - %s: %s (Status: %s)\n", action, txID, walletID, status)
+	slog.Info("transaction event",
+		"action", action,
+		"transaction_id", txID,
+		"wallet_id", walletID,
+		"block_hash", blockHash,
+		"status", status,
+		"request_id", requestID,
+	)
 }
 
 func (ls *LoggingService) GetSystemLogs(limit int) []LogEntry {
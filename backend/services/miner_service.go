@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+)
+
+// Leaderboard orders
+const (
+	RankByBlocks  = "blocks"
+	RankByRewards = "rewards"
+)
+
+// MinerStats is what one wallet has mined
+type MinerStats struct {
+	WalletID      string    `json:"wallet_id"`
+	BlocksMined   int64     `json:"blocks_mined"`
+	TotalRewards  uint64    `json:"total_rewards"`  // coinbase payouts, fees included
+	FeesCollected uint64    `json:"fees_collected"` // the part of the rewards paid by fees
+	FirstBlock    int64     `json:"first_block"`
+	LastBlock     int64     `json:"last_block"`
+	FirstMinedAt  time.Time `json:"first_mined_at"`
+	LastMinedAt   time.Time `json:"last_mined_at"` // last activity
+}
+
+// MinerService tracks which wallets mine blocks. It listens on the wallet
+// event feed for mined blocks and keeps the miners table in step; without
+// the database the figures are rebuilt from the chain at startup.
+type MinerService struct {
+	mu     sync.Mutex
+	bc     *blockchain.Blockchain
+	miners map[string]*MinerStats
+	db     database.MinerRepo
+}
+
+func NewMinerService(bc *blockchain.Blockchain) *MinerService {
+	return &MinerService{bc: bc, miners: make(map[string]*MinerStats)}
+}
+
+// SetDatabase enables persistence and restores the miners. A database from
+// before miner tracking is backfilled from its coinbase transactions.
+func (ms *MinerService) SetDatabase(db database.MinerRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if n, err := db.BackfillMiners(ctx); err != nil {
+		log.Printf("⚠️  Failed to backfill miners: %v", err)
+	} else if n > 0 {
+		log.Printf("✅ Backfilled %d miners from stored coinbase transactions", n)
+	}
+	rows, err := db.GetMiners(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load miners from database: %v", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.db = db
+	for _, row := range rows {
+		m := &MinerStats{
+			WalletID:      row["wallet_id"].(string),
+			BlocksMined:   row["blocks_mined"].(int64),
+			TotalRewards:  row["total_rewards"].(uint64),
+			FeesCollected: row["fees_collected"].(uint64),
+			FirstBlock:    row["first_block"].(int64),
+			LastBlock:     row["last_block"].(int64),
+			FirstMinedAt:  row["first_mined_at"].(time.Time).UTC(),
+			LastMinedAt:   row["last_mined_at"].(time.Time).UTC(),
+		}
+		ms.miners[m.WalletID] = m
+	}
+}
+
+// Rebuild recounts the miners from the chain, for nodes without the database
+func (ms *MinerService) Rebuild() {
+	chain := ms.bc.GetChain()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.miners = make(map[string]*MinerStats)
+	for _, blk := range chain {
+		if walletID, reward, fees, ok := coinbaseOf(blk); ok {
+			ms.addLocked(walletID, reward, fees, blk)
+		}
+	}
+}
+
+// Reset forgets the miners kept in memory, after the chain was reset; the
+// database's are emptied with the chain tables
+func (ms *MinerService) Reset() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.miners = make(map[string]*MinerStats)
+}
+
+// OnEvent implements events.Listener; miners only follow blocks
+func (ms *MinerService) OnEvent(events.Event) {}
+
+// OnBlock credits a block to the wallet its coinbase pays
+func (ms *MinerService) OnBlock(blk blockchain.Block) {
+	walletID, reward, fees, ok := coinbaseOf(blk)
+	if !ok {
+		return
+	}
+
+	ms.mu.Lock()
+	ms.addLocked(walletID, reward, fees, blk)
+	db := ms.db
+	ms.mu.Unlock()
+
+	if db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveMinedBlock(ctx, walletID, reward, fees, blk.Index, time.Unix(blk.Timestamp, 0).UTC()); err != nil {
+		log.Printf("Failed to persist block #%d for miner %s: %v", blk.Index, walletID, err)
+	}
+}
+
+// Get returns what the wallet has mined
+func (ms *MinerService) Get(walletID string) (MinerStats, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	m, ok := ms.miners[walletID]
+	if !ok {
+		return MinerStats{}, false
+	}
+	return *m, true
+}
+
+// List returns every miner, most blocks first
+func (ms *MinerService) List() []MinerStats {
+	return ms.Ranked(RankByBlocks)
+}
+
+// Ranked returns every miner ordered by RankByBlocks or RankByRewards, the
+// other figure breaking ties, then the earliest to reach it
+func (ms *MinerService) Ranked(by string) []MinerStats {
+	ms.mu.Lock()
+	list := make([]MinerStats, 0, len(ms.miners))
+	for _, m := range ms.miners {
+		list = append(list, *m)
+	}
+	ms.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if by == RankByRewards && a.TotalRewards != b.TotalRewards {
+			return a.TotalRewards > b.TotalRewards
+		}
+		if a.BlocksMined != b.BlocksMined {
+			return a.BlocksMined > b.BlocksMined
+		}
+		if a.TotalRewards != b.TotalRewards {
+			return a.TotalRewards > b.TotalRewards
+		}
+		return a.LastBlock < b.LastBlock
+	})
+	return list
+}
+
+// addLocked credits a block to its miner. The caller must hold the lock.
+func (ms *MinerService) addLocked(walletID string, reward, fees uint64, blk blockchain.Block) {
+	minedAt := time.Unix(blk.Timestamp, 0).UTC()
+	m, ok := ms.miners[walletID]
+	if !ok {
+		m = &MinerStats{WalletID: walletID, FirstBlock: blk.Index, FirstMinedAt: minedAt}
+		ms.miners[walletID] = m
+	}
+	m.BlocksMined++
+	m.TotalRewards += reward
+	m.FeesCollected += fees
+	if blk.Index >= m.LastBlock {
+		m.LastBlock, m.LastMinedAt = blk.Index, minedAt
+	}
+}
+
+// coinbaseOf returns the wallet a block's coinbase pays, the reward and the
+// fees in it; false for the genesis block, which has none
+func coinbaseOf(blk blockchain.Block) (walletID string, reward, fees uint64, ok bool) {
+	for _, tx := range blk.Transactions {
+		if tx.Type == "mining_reward" {
+			walletID, reward, ok = tx.ReceiverID, tx.Amount, true
+		} else {
+			fees += tx.Fee
+		}
+	}
+	return walletID, reward, fees, ok
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
+)
+
+// Admins correct balances and seed test environments with two system
+// transactions: admin_mint pays new coins to a wallet from
+// blockchain.MintIssuer, and admin_burn spends a wallet's outputs, destroys
+// its amount and returns the change. The chain is the record: adjustments are
+// read back from those transactions.
+
+// Supply adjustment kinds
+const (
+	AdjustmentMint = "mint"
+	AdjustmentBurn = "burn"
+)
+
+// SupplyAdjustment is one admin mint or burn
+type SupplyAdjustment struct {
+	TxID      string    `json:"txid"`
+	Kind      string    `json:"kind"` // mint or burn
+	WalletID  string    `json:"wallet_id"`
+	Amount    uint64    `json:"amount"`
+	Note      string    `json:"note"` // who made it and why
+	CreatedAt time.Time `json:"created_at"`
+	Pending   bool      `json:"pending"` // not mined yet
+}
+
+// CreateMintTransaction creates a system transaction paying amount new coins
+// to the wallet
+func (ts *TransactionService) CreateMintTransaction(walletID string, amount uint64, note string) (*blockchain.Transaction, error) {
+	if err := validation.Amount(amount); err != nil {
+		return nil, fmt.Errorf("%w: amount %v", ErrMalformedTransaction, err)
+	}
+	if _, exists := ts.ws.Get(walletID); !exists {
+		return nil, ErrReceiverNotFound
+	}
+
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersion,
+		SenderID:   blockchain.MintIssuer,
+		ReceiverID: walletID,
+		Amount:     amount,
+		Note:       note,
+		Timestamp:  time.Now().Unix(),
+		PubKey:     "system",
+		Signature:  "system",
+		Inputs:     []blockchain.UTXORef{},
+		Outputs:    []blockchain.UTXO{{Owner: walletID, Amount: amount, Index: 0}},
+		Type:       "admin_mint",
+	}
+	blockchain.AssignID(tx)
+	return tx, nil
+}
+
+// CreateBurnTransaction creates a system transaction destroying amount of the
+// wallet's coins, selected as for a send; the change returns to the wallet
+func (ts *TransactionService) CreateBurnTransaction(walletID string, amount uint64, note string) (*blockchain.Transaction, error) {
+	if err := validation.Amount(amount); err != nil {
+		return nil, fmt.Errorf("%w: amount %v", ErrMalformedTransaction, err)
+	}
+	if _, exists := ts.ws.Get(walletID); !exists {
+		return nil, ErrSenderNotFound
+	}
+
+	selected, total, err := ts.SelectUTXOs(walletID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) > validation.MaxTxInputs {
+		return nil, ErrTooManyInputs
+	}
+	inputs := make([]blockchain.UTXORef, 0, len(selected))
+	for _, u := range selected {
+		inputs = append(inputs, blockchain.UTXORef{TxID: u.OriginTx, Index: u.Index})
+	}
+	outputs := []blockchain.UTXO{}
+	if change := total - amount; change > 0 {
+		outputs = append(outputs, blockchain.UTXO{Owner: walletID, Amount: change, Index: 0})
+	}
+
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersion,
+		SenderID:   walletID,
+		ReceiverID: blockchain.BurnReceiver,
+		Amount:     amount,
+		Note:       note,
+		Timestamp:  time.Now().Unix(),
+		PubKey:     "system",
+		Signature:  "system",
+		Inputs:     inputs,
+		Outputs:    outputs,
+		Type:       "admin_burn",
+	}
+	blockchain.AssignID(tx)
+	return tx, nil
+}
+
+// SupplyAdjustmentOf returns the adjustment an admin_mint or admin_burn
+// transaction makes, and false for any other transaction
+func SupplyAdjustmentOf(tx blockchain.Transaction, pending bool) (SupplyAdjustment, bool) {
+	a := SupplyAdjustment{TxID: tx.ID, Amount: tx.Amount, Note: tx.Note, CreatedAt: time.Unix(tx.Timestamp, 0).UTC(), Pending: pending}
+	switch tx.Type {
+	case "admin_mint":
+		a.Kind, a.WalletID = AdjustmentMint, tx.ReceiverID
+	case "admin_burn":
+		a.Kind, a.WalletID = AdjustmentBurn, tx.SenderID
+	default:
+		return SupplyAdjustment{}, false
+	}
+	return a, true
+}
+
+// SupplyAdjustments returns every admin mint and burn, mined or pending,
+// newest first
+func (ts *TransactionService) SupplyAdjustments() []SupplyAdjustment {
+	var adjustments []SupplyAdjustment
+	add := func(tx blockchain.Transaction, pending bool) {
+		if a, ok := SupplyAdjustmentOf(tx, pending); ok {
+			adjustments = append(adjustments, a)
+		}
+	}
+	ts.bc.View(func(v blockchain.View) {
+		for b := range v.Blocks() {
+			for _, tx := range b.Transactions {
+				add(tx, false)
+			}
+		}
+		for tx := range v.Pending() {
+			add(tx, true)
+		}
+	})
+
+	sort.SliceStable(adjustments, func(i, j int) bool { return adjustments[i].CreatedAt.After(adjustments[j].CreatedAt) })
+	return adjustments
+}
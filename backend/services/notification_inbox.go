@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// maxInboxNotifications is how many notifications each wallet's inbox
+// keeps; older ones drop out of it but stay in the database
+const maxInboxNotifications = 200
+
+// Notification categories, for the frontend to pick an icon or filter on
+const (
+	CategoryTransaction = "transaction"
+	CategoryZakat       = "zakat"
+	CategorySecurity    = "security"
+)
+
+var notificationCategory = map[string]string{
+	NotifyPaymentReceived:  CategoryTransaction,
+	NotifyPaymentSent:      CategoryTransaction,
+	NotifyZakatDeducted:    CategoryZakat,
+	NotifyProfileChanged:   CategorySecurity,
+	NotifyBeneficiaryAdded: CategorySecurity,
+	NotifyNewOrigin:        CategorySecurity,
+}
+
+// Notification is an entry in a wallet's in-app inbox
+type Notification struct {
+	ID        int64      `json:"id"`
+	WalletID  string     `json:"wallet_id"`
+	Kind      string     `json:"kind"`
+	Category  string     `json:"category"` // transaction, zakat or security
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	TxID      string     `json:"txid,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // unset while unread
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Inbox returns up to limit of the wallet's notifications, newest first and
+// with an ID below before when it is set, and how many are unread
+func (ns *NotificationService) Inbox(walletID string, unreadOnly bool, before int64, limit int) ([]Notification, int) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+
+	inbox := ns.inbox[walletID]
+	list := []Notification{}
+	unread := 0
+	for i := len(inbox) - 1; i >= 0; i-- {
+		n := inbox[i]
+		if n.ReadAt == nil {
+			unread++
+		}
+		if len(list) == limit || (before > 0 && n.ID >= before) || (unreadOnly && n.ReadAt != nil) {
+			continue
+		}
+		list = append(list, n)
+	}
+	return list, unread
+}
+
+// MarkRead marks the given notifications of a wallet as read, or all of them
+// when ids is empty, and returns how many were unread
+func (ns *NotificationService) MarkRead(walletID string, ids []int64) (int, error) {
+	now := time.Now()
+
+	ns.mu.RLock()
+	db := ns.db
+	ns.mu.RUnlock()
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.MarkNotificationsRead(ctx, walletID, ids, now); err != nil {
+			return 0, err
+		}
+	}
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	marked := 0
+	for i, n := range ns.inbox[walletID] {
+		if n.ReadAt == nil && (len(ids) == 0 || wanted[n.ID]) {
+			ns.inbox[walletID][i].ReadAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// file adds a notification to the inbox of walletID
+func (ns *NotificationService) file(walletID, kind string, data notificationData) {
+	title, err := render(kind+".subject", data)
+	if err != nil {
+		return
+	}
+	body, err := render(kind+".inbox", data)
+	if err != nil {
+		return
+	}
+
+	ns.mu.Lock()
+	n := Notification{
+		ID:        ns.nextID,
+		WalletID:  walletID,
+		Kind:      kind,
+		Category:  notificationCategory[kind],
+		Title:     title,
+		Body:      body,
+		TxID:      data.TxID,
+		CreatedAt: data.Time,
+	}
+	ns.nextID++
+	inbox := append(ns.inbox[walletID], n)
+	if len(inbox) > maxInboxNotifications {
+		inbox = inbox[len(inbox)-maxInboxNotifications:]
+	}
+	ns.inbox[walletID] = inbox
+	db := ns.db
+	if db != nil {
+		ns.writes.Add(1)
+	}
+	ns.mu.Unlock()
+
+	// Persist asynchronously; feed listeners must not block
+	if db != nil {
+		go func() {
+			defer ns.writes.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if err := db.SaveNotification(ctx, n.ID, n.WalletID, n.Kind, n.Category, n.Title, n.Body, n.TxID, n.CreatedAt); err != nil {
+				log.Printf("Failed to persist notification %d: %v", n.ID, err)
+			}
+		}()
+	}
+}
+
+// Drain waits for the notifications still being written to the database,
+// or until ctx is done. Notifications filed after it are not persisted.
+func (ns *NotificationService) Drain(ctx context.Context) error {
+	ns.mu.Lock()
+	ns.db = nil
+	ns.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ns.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+	"blockchain-backend/wallet"
+)
+
+// DefaultNotifyIncomingThreshold is the smallest incoming payment emailed to
+// owners who have not chosen their own threshold
+const DefaultNotifyIncomingThreshold = 100 * blockchain.UnitsPerCoin
+
+// Notification kinds. Each has an inbox template; those with a preference
+// also have an email template.
+const (
+	NotifyPaymentReceived  = "payment_received"
+	NotifyPaymentSent      = "payment_sent" // inbox only
+	NotifyZakatDeducted    = "zakat_deducted"
+	NotifyProfileChanged   = "profile_changed"
+	NotifyBeneficiaryAdded = "beneficiary_added"
+	NotifyNewOrigin        = "new_origin"   // inbox only; the device service emails it
+	NotifyBillSettled      = "bill_settled" // inbox only
+)
+
+// unnotifiedTxTypes move coins between an owner's own wallets or into system
+// wallets, so they are not payments to tell the owner about
+var unnotifiedTxTypes = map[string]bool{
+	"mining_reward":     true,
+	"consolidation":     true,
+	"key_rotation":      true,
+	"htlc_claim":        true,
+	"htlc_refund":       true,
+	"zakat_split":       true,
+	"inheritance_split": true,
+}
+
+// NotificationPreferences are the wallet events a wallet's owner is emailed
+// about. Wallets whose owner never changed them get every notification and
+// the node's incoming payment threshold.
+type NotificationPreferences struct {
+	WalletID           string     `json:"wallet_id"`
+	IncomingPayments   bool       `json:"incoming_payments"`
+	MinIncomingAmount  uint64     `json:"min_incoming_amount"` // smaller payments are not emailed
+	ZakatDeductions    bool       `json:"zakat_deductions"`
+	ProfileChanges     bool       `json:"profile_changes"`
+	BeneficiaryChanges bool       `json:"beneficiary_changes"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"` // unset while the defaults apply
+}
+
+// notificationData fills the email templates
+type notificationData struct {
+	Name         string
+	WalletID     string
+	Amount       uint64
+	Balance      uint64
+	TxID         string
+	Counterparty string
+	Changes      []string
+	Beneficiary  string
+	Activity     string
+	Location     string
+	Device       string
+	Bill         string
+	Time         time.Time
+}
+
+// notificationTemplates holds per kind a subject, which is also the inbox
+// title, an inbox line and, for emailed kinds, an email body
+var notificationTemplates = template.Must(template.New("notifications").Funcs(template.FuncMap{"join": strings.Join, "coins": blockchain.FormatAmount}).Parse(`
+{{define "footer"}}
+You can choose which emails you get with PUT /api/notifications/preferences.
+{{end}}
+
+{{define "payment_received.subject"}}You received {{coins .Amount}} coins{{end}}
+{{define "payment_received.inbox"}}{{coins .Amount}} coins from {{.Counterparty}} were confirmed.{{end}}
+{{define "payment_received.body"}}Hello {{.Name}},
+
+Your wallet {{.WalletID}} received {{coins .Amount}} coins from {{.Counterparty}}.
+
+Transaction: {{.TxID}}
+Time:        {{.Time.UTC.Format "2006-01-02 15:04 MST"}}
+{{template "footer"}}{{end}}
+
+{{define "zakat_deducted.subject"}}Zakat of {{coins .Amount}} coins was deducted{{end}}
+{{define "zakat_deducted.inbox"}}Your balance after zakat is {{coins .Balance}} coins.{{end}}
+{{define "zakat_deducted.body"}}Hello {{.Name}},
+
+Zakat of {{coins .Amount}} coins was deducted from your wallet {{.WalletID}}, leaving a balance of {{coins .Balance}}.
+
+Transaction: {{.TxID}}
+Time:        {{.Time.UTC.Format "2006-01-02 15:04 MST"}}
+{{template "footer"}}{{end}}
+
+{{define "profile_changed.subject"}}Your wallet profile was changed{{end}}
+{{define "profile_changed.inbox"}}Changed: {{join .Changes ", "}}. If this was not you, secure your account.{{end}}
+{{define "profile_changed.body"}}Hello {{.Name}},
+
+The profile of your wallet {{.WalletID}} was changed: {{join .Changes ", "}}.
+
+Time: {{.Time.UTC.Format "2006-01-02 15:04 MST"}}
+
+If you did not make this change, secure your account and move your funds to a new wallet.
+{{template "footer"}}{{end}}
+
+{{define "beneficiary_added.subject"}}A beneficiary was added to your wallet{{end}}
+{{define "beneficiary_added.inbox"}}{{.Beneficiary}} ({{.Counterparty}}) was added to your beneficiaries. If this was not you, secure your account.{{end}}
+{{define "beneficiary_added.body"}}Hello {{.Name}},
+
+{{.Beneficiary}} ({{.Counterparty}}) was added as a beneficiary of your wallet {{.WalletID}}.
+
+Time: {{.Time.UTC.Format "2006-01-02 15:04 MST"}}
+
+If you did not add them, remove the beneficiary and secure your account.
+{{template "footer"}}{{end}}
+
+{{define "payment_sent.subject"}}Your payment of {{coins .Amount}} coins was confirmed{{end}}
+{{define "payment_sent.inbox"}}{{coins .Amount}} coins to {{.Counterparty}} were confirmed.{{end}}
+
+{{define "bill_settled.subject"}}Your split bill was settled{{end}}
+{{define "bill_settled.inbox"}}Everyone paid their share of {{.Bill}}, {{coins .Amount}} coins in all.{{end}}
+
+{{define "new_origin.subject"}}Your account was used from a new device or location{{end}}
+{{define "new_origin.inbox"}}Account activity ({{.Activity}}){{with .Location}} from {{.}}{{end}}{{with .Device}} on device {{.}}{{end}}. If this was not you, secure your account.{{end}}
+`))
+
+// NotificationService emails wallet owners about incoming payments, zakat
+// deductions, profile changes and new beneficiaries, as their preferences
+// allow, and files these and other account events in each wallet's in-app
+// inbox. It listens to the event feed for payments and zakat; the API tells
+// it about the rest.
+type NotificationService struct {
+	mu         sync.RWMutex
+	prefs      map[string]NotificationPreferences // wallets whose owner changed the defaults
+	inbox      map[string][]Notification          // per wallet, oldest first
+	nextID     int64
+	threshold  uint64
+	ws         *wallet.Store
+	deliveries *DeliveryService
+	db         database.NotificationRepo
+	writes     sync.WaitGroup // database writes in flight
+}
+
+func NewNotificationService(ws *wallet.Store, deliveries *DeliveryService, threshold uint64) *NotificationService {
+	return &NotificationService{
+		prefs:      make(map[string]NotificationPreferences),
+		inbox:      make(map[string][]Notification),
+		nextID:     1,
+		threshold:  threshold,
+		ws:         ws,
+		deliveries: deliveries,
+	}
+}
+
+// Reset empties every inbox after the chain was reset, as the notifications
+// name its transactions. Preferences are kept.
+func (ns *NotificationService) Reset() {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.inbox = make(map[string][]Notification)
+}
+
+// SetDatabase enables persistence and reloads saved preferences and the
+// newest notifications of each inbox
+func (ns *NotificationService) SetDatabase(db database.NotificationRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetNotificationPreferences(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load notification preferences from database: %v", err)
+	}
+	recent, err := db.GetRecentNotifications(ctx, maxInboxNotifications)
+	if err != nil {
+		log.Printf("⚠️  Failed to load notifications from database: %v", err)
+	}
+	maxID, err := db.MaxNotificationID(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load notifications from database: %v", err)
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.db = db
+	for _, row := range rows {
+		updatedAt := row["updated_at"].(time.Time)
+		p := NotificationPreferences{
+			WalletID:           row["wallet_id"].(string),
+			IncomingPayments:   row["incoming_payments"].(bool),
+			MinIncomingAmount:  row["min_incoming_amount"].(uint64),
+			ZakatDeductions:    row["zakat_deductions"].(bool),
+			ProfileChanges:     row["profile_changes"].(bool),
+			BeneficiaryChanges: row["beneficiary_changes"].(bool),
+			UpdatedAt:          &updatedAt,
+		}
+		ns.prefs[p.WalletID] = p
+	}
+	for _, row := range recent {
+		n := Notification{
+			ID:        row["id"].(int64),
+			WalletID:  row["wallet_id"].(string),
+			Kind:      row["kind"].(string),
+			Category:  row["category"].(string),
+			Title:     row["title"].(string),
+			Body:      row["body"].(string),
+			TxID:      row["txid"].(string),
+			ReadAt:    row["read_at"].(*time.Time),
+			CreatedAt: row["created_at"].(time.Time),
+		}
+		ns.inbox[n.WalletID] = append(ns.inbox[n.WalletID], n)
+	}
+	if maxID >= ns.nextID {
+		ns.nextID = maxID + 1
+	}
+}
+
+// Preferences returns the wallet's notification preferences
+func (ns *NotificationService) Preferences(walletID string) NotificationPreferences {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if p, ok := ns.prefs[walletID]; ok {
+		return p
+	}
+	return NotificationPreferences{
+		WalletID:           walletID,
+		IncomingPayments:   true,
+		MinIncomingAmount:  ns.threshold,
+		ZakatDeductions:    true,
+		ProfileChanges:     true,
+		BeneficiaryChanges: true,
+	}
+}
+
+// SetPreferences replaces the wallet's notification preferences
+func (ns *NotificationService) SetPreferences(p NotificationPreferences) (NotificationPreferences, error) {
+	now := time.Now()
+	p.UpdatedAt = &now
+
+	ns.mu.RLock()
+	db := ns.db
+	ns.mu.RUnlock()
+	// Persist first so a restart cannot bring back an unwanted email
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.SaveNotificationPreferences(ctx, p.WalletID, p.IncomingPayments, p.MinIncomingAmount, p.ZakatDeductions, p.ProfileChanges, p.BeneficiaryChanges, now); err != nil {
+			return NotificationPreferences{}, err
+		}
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.prefs[p.WalletID] = p
+	return p, nil
+}
+
+// OnEvent files confirmed payments and zakat deductions in the inbox, and
+// emails incoming payments and zakat deductions the owner wants to hear about
+func (ns *NotificationService) OnEvent(ev events.Event) {
+	txID, _ := ev.Data["txid"].(string)
+	amount, _ := ev.Data["amount"].(uint64)
+	prefs := ns.Preferences(ev.WalletID)
+
+	switch ev.Type {
+	case events.TxConfirmed:
+		txType, _ := ev.Data["tx_type"].(string)
+		if unnotifiedTxTypes[txType] {
+			return
+		}
+		counterparty, _ := ev.Data["counterparty"].(string)
+		data := notificationData{Amount: amount, TxID: txID, Counterparty: counterparty, Time: ev.CreatedAt}
+		if ev.Data["direction"] != "in" {
+			ns.notify(ev.WalletID, NotifyPaymentSent, data, false, "")
+			return
+		}
+		ns.notify(ev.WalletID, NotifyPaymentReceived, data, prefs.IncomingPayments && amount >= prefs.MinIncomingAmount,
+			"notify:"+NotifyPaymentReceived+":"+ev.WalletID+":"+txID)
+	case events.ZakatDeducted:
+		balance, _ := ev.Data["balance"].(uint64)
+		ns.notify(ev.WalletID, NotifyZakatDeducted, notificationData{Amount: amount, Balance: balance, TxID: txID, Time: ev.CreatedAt}, prefs.ZakatDeductions,
+			"notify:"+NotifyZakatDeducted+":"+ev.WalletID+":"+txID)
+	}
+}
+
+// OnBlock is part of events.Listener; blocks are not notified
+func (ns *NotificationService) OnBlock(blockchain.Block) {}
+
+// ProfileChanged tells the owner what changed in the wallet's profile. A
+// changed email is told to both addresses, so a hijacked account cannot
+// move its notifications away unnoticed.
+func (ns *NotificationService) ProfileChanged(before, after wallet.Wallet) {
+	var changes []string
+	if before.FullName != after.FullName {
+		changes = append(changes, "name")
+	}
+	if !strings.EqualFold(before.Email, after.Email) {
+		changes = append(changes, "email")
+	}
+	if before.CNIC != after.CNIC {
+		changes = append(changes, "CNIC")
+	}
+	if before.MonthlyStatements != after.MonthlyStatements {
+		changes = append(changes, "monthly statements")
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	data := notificationData{Name: after.FullName, WalletID: after.WalletID, Changes: changes, Time: time.Now()}
+	ns.file(after.WalletID, NotifyProfileChanged, data)
+	if !ns.Preferences(after.WalletID).ProfileChanges {
+		return
+	}
+	ns.send(before.Email, NotifyProfileChanged, data, "")
+	if !strings.EqualFold(before.Email, after.Email) {
+		ns.send(after.Email, NotifyProfileChanged, data, "")
+	}
+}
+
+// BeneficiaryAdded tells the owner of walletID about a new beneficiary
+func (ns *NotificationService) BeneficiaryAdded(walletID, beneficiaryWallet, beneficiaryName string) {
+	if beneficiaryName == "" {
+		beneficiaryName = "A wallet"
+	}
+	ns.notify(walletID, NotifyBeneficiaryAdded, notificationData{Counterparty: beneficiaryWallet, Beneficiary: beneficiaryName, Time: time.Now()},
+		ns.Preferences(walletID).BeneficiaryChanges, "")
+}
+
+// BillSettled tells a bill's creator that every share was paid
+func (ns *NotificationService) BillSettled(walletID string, bill Bill) {
+	ns.notify(walletID, NotifyBillSettled, notificationData{Bill: bill.String(), Amount: bill.Paid, Time: time.Now()}, false, "")
+}
+
+// NewOrigin files a new device or country alert in the inbox of walletID,
+// or of every wallet registered with email when walletID is empty. The
+// device service emails the alert.
+func (ns *NotificationService) NewOrigin(walletID, email, event string, origin database.LogOrigin) {
+	data := notificationData{Activity: strings.ReplaceAll(event, "_", " "), Location: origin.Country, Device: origin.Device, Time: time.Now()}
+	if origin.City != "" {
+		data.Location = origin.City + ", " + origin.Country
+	}
+	if walletID != "" {
+		ns.file(walletID, NotifyNewOrigin, data)
+		return
+	}
+	for _, w := range ns.ws.GetAll() {
+		if email != "" && strings.EqualFold(w.Email, email) {
+			ns.file(w.WalletID, NotifyNewOrigin, data)
+		}
+	}
+}
+
+// notify files a notification in the inbox of walletID and, when email is
+// set, emails it to the owner
+func (ns *NotificationService) notify(walletID, kind string, data notificationData, email bool, dedupKey string) {
+	w, ok := ns.ws.Get(walletID)
+	if !ok {
+		return
+	}
+	data.Name, data.WalletID = w.FullName, w.WalletID
+	ns.file(walletID, kind, data)
+	if email {
+		ns.send(w.Email, kind, data, dedupKey)
+	}
+}
+
+func (ns *NotificationService) send(to, kind string, data notificationData, dedupKey string) {
+	if to == "" || ns.deliveries == nil {
+		return
+	}
+	if data.Name == "" {
+		data.Name = "there"
+	}
+	subject, err := render(kind+".subject", data)
+	if err != nil {
+		return
+	}
+	body, err := render(kind+".body", data)
+	if err != nil {
+		return
+	}
+	ns.deliveries.EnqueueEmail(to, subject, body, "notification."+kind, dedupKey)
+}
+
+func render(name string, data notificationData) (string, error) {
+	var b bytes.Buffer
+	if err := notificationTemplates.ExecuteTemplate(&b, name, data); err != nil {
+		log.Printf("❌ Failed to render %s notification: %v", name, err)
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// Tenancy modes, selected with TENANCY_MODE
+const (
+	TenancySingle = "single"
+	TenancyMulti  = "multi"
+)
+
+// Errors returned by the organization service
+var (
+	ErrTenancyDisabled = errors.New("multi-tenant mode is disabled; set TENANCY_MODE=multi")
+	ErrInvalidOrgID    = errors.New("organization ID must be 2-64 lowercase letters, digits or dashes")
+	ErrOrgExists       = errors.New("organization already exists")
+	ErrOrgNotFound     = errors.New("organization not found")
+	ErrNotOrgMember    = errors.New("wallet does not belong to this organization")
+)
+
+var orgIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,63}$`)
+
+type orgKey struct{}
+
+// WithOrg returns a context scoped to an organization
+func WithOrg(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrgFrom returns the organization stored in ctx, or "" when unscoped
+func OrgFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(orgKey{}).(string)
+	return id
+}
+
+// Organization is a tenant: an independent set of users and wallets, such as
+// one classroom or company, sharing the deployment with others
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Admins    []string  `json:"admins"`  // wallet IDs allowed to manage the organization
+	Wallets   int       `json:"wallets"` // member wallet count
+}
+
+// OrgService keeps organizations and their admins. Membership itself is the
+// OrgID recorded on each wallet.
+type OrgService struct {
+	mu      sync.RWMutex
+	enabled bool
+	ws      *wallet.Store
+	orgs    map[string]*Organization
+	admins  map[string]map[string]bool // org ID -> admin wallet IDs
+	db      database.OrgRepo
+}
+
+func NewOrgService(ws *wallet.Store, enabled bool) *OrgService {
+	return &OrgService{
+		enabled: enabled,
+		ws:      ws,
+		orgs:    make(map[string]*Organization),
+		admins:  make(map[string]map[string]bool),
+	}
+}
+
+// Enabled reports whether requests are partitioned by organization
+func (ors *OrgService) Enabled() bool {
+	return ors.enabled
+}
+
+// SetDatabase enables persistence and reloads organizations and their admins
+func (ors *OrgService) SetDatabase(db database.OrgRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orgs, err := db.GetOrganizations(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load organizations from database: %v", err)
+	}
+	admins, err := db.GetOrgAdmins(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load organization admins from database: %v", err)
+	}
+
+	ors.mu.Lock()
+	defer ors.mu.Unlock()
+	ors.db = db
+	for _, row := range orgs {
+		org := &Organization{}
+		org.ID, _ = row["id"].(string)
+		org.Name, _ = row["name"].(string)
+		org.CreatedBy, _ = row["created_by"].(string)
+		org.CreatedAt, _ = row["created_at"].(time.Time)
+		ors.orgs[org.ID] = org
+	}
+	for _, row := range admins {
+		orgID, _ := row["org_id"].(string)
+		walletID, _ := row["wallet_id"].(string)
+		if ors.admins[orgID] == nil {
+			ors.admins[orgID] = make(map[string]bool)
+		}
+		ors.admins[orgID][walletID] = true
+	}
+}
+
+// Create registers a new organization
+func (ors *OrgService) Create(id, name, createdBy string) (Organization, error) {
+	if !ors.enabled {
+		return Organization{}, ErrTenancyDisabled
+	}
+	if !orgIDPattern.MatchString(id) {
+		return Organization{}, ErrInvalidOrgID
+	}
+	if name == "" {
+		name = id
+	}
+
+	ors.mu.Lock()
+	if _, exists := ors.orgs[id]; exists {
+		ors.mu.Unlock()
+		return Organization{}, ErrOrgExists
+	}
+	org := &Organization{ID: id, Name: name, CreatedBy: createdBy, CreatedAt: time.Now()}
+	ors.orgs[id] = org
+	ors.mu.Unlock()
+
+	if ors.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ors.db.SaveOrganization(ctx, org.ID, org.Name, org.CreatedBy, org.CreatedAt); err != nil {
+			log.Printf("Failed to persist organization: %v", err)
+		}
+	}
+	return ors.snapshot(id), nil
+}
+
+// Exists reports whether the organization is registered
+func (ors *OrgService) Exists(id string) bool {
+	ors.mu.RLock()
+	defer ors.mu.RUnlock()
+	_, ok := ors.orgs[id]
+	return ok
+}
+
+// Get returns an organization with its admins and wallet count
+func (ors *OrgService) Get(id string) (Organization, bool) {
+	if !ors.Exists(id) {
+		return Organization{}, false
+	}
+	return ors.snapshot(id), true
+}
+
+// List returns every organization, ordered by ID
+func (ors *OrgService) List() []Organization {
+	ors.mu.RLock()
+	ids := make([]string, 0, len(ors.orgs))
+	for id := range ors.orgs {
+		ids = append(ids, id)
+	}
+	ors.mu.RUnlock()
+
+	sort.Strings(ids)
+	list := make([]Organization, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, ors.snapshot(id))
+	}
+	return list
+}
+
+// Wallets returns the organization's member wallets, ordered by ID
+func (ors *OrgService) Wallets(orgID string) []wallet.Wallet {
+	var list []wallet.Wallet
+	for _, w := range ors.ws.GetAll() {
+		if w.OrgID == orgID {
+			list = append(list, w)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].WalletID < list[j].WalletID })
+	return list
+}
+
+// OrgsOf returns the organizations where the email owns a wallet, ordered by
+// ID
+func (ors *OrgService) OrgsOf(email string) []string {
+	seen := make(map[string]bool)
+	var list []string
+	for _, w := range ors.ws.GetAll() {
+		if w.OrgID == "" || w.Email == "" || !strings.EqualFold(w.Email, email) || seen[w.OrgID] {
+			continue
+		}
+		seen[w.OrgID] = true
+		list = append(list, w.OrgID)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// InOrg reports whether a stored wallet belongs to the organization. System
+// pseudo-wallets such as COINBASE belong to none.
+func (ors *OrgService) InOrg(walletID, orgID string) bool {
+	w, ok := ors.ws.Get(walletID)
+	return ok && orgID != "" && w.OrgID == orgID
+}
+
+// Assign moves a wallet into an organization. Admin rights in its previous
+// organization are dropped.
+func (ors *OrgService) Assign(walletID, orgID string) error {
+	if !ors.enabled {
+		return ErrTenancyDisabled
+	}
+	if !ors.Exists(orgID) {
+		return ErrOrgNotFound
+	}
+	w, ok := ors.ws.Get(walletID)
+	if !ok {
+		return ErrWalletNotFound
+	}
+	if w.OrgID == orgID {
+		return nil
+	}
+	if err := ors.ws.SetOrg(walletID, orgID); err != nil {
+		return err
+	}
+	if w.OrgID != "" {
+		ors.RemoveAdmin(w.OrgID, walletID)
+	}
+
+	if ors.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ors.db.UpdateWalletOrg(ctx, walletID, orgID); err != nil {
+			log.Printf("Failed to persist wallet organization: %v", err)
+		}
+	}
+	return nil
+}
+
+// AddAdmin lets a member wallet manage its organization
+func (ors *OrgService) AddAdmin(orgID, walletID string) error {
+	if !ors.Exists(orgID) {
+		return ErrOrgNotFound
+	}
+	if !ors.InOrg(walletID, orgID) {
+		return ErrNotOrgMember
+	}
+
+	ors.mu.Lock()
+	if ors.admins[orgID] == nil {
+		ors.admins[orgID] = make(map[string]bool)
+	}
+	ors.admins[orgID][walletID] = true
+	ors.mu.Unlock()
+
+	if ors.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ors.db.AddOrgAdmin(ctx, orgID, walletID); err != nil {
+			log.Printf("Failed to persist organization admin: %v", err)
+		}
+	}
+	return nil
+}
+
+// RemoveAdmin revokes a wallet's admin rights in the organization
+func (ors *OrgService) RemoveAdmin(orgID, walletID string) {
+	ors.mu.Lock()
+	delete(ors.admins[orgID], walletID)
+	ors.mu.Unlock()
+
+	if ors.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ors.db.RemoveOrgAdmin(ctx, orgID, walletID); err != nil {
+			log.Printf("Failed to delete organization admin: %v", err)
+		}
+	}
+}
+
+// IsAdmin reports whether the wallet administers the organization
+func (ors *OrgService) IsAdmin(orgID, walletID string) bool {
+	ors.mu.RLock()
+	defer ors.mu.RUnlock()
+	return ors.admins[orgID][walletID]
+}
+
+func (ors *OrgService) snapshot(id string) Organization {
+	ors.mu.RLock()
+	org := *ors.orgs[id]
+	org.Admins = make([]string, 0, len(ors.admins[id]))
+	for walletID := range ors.admins[id] {
+		org.Admins = append(org.Admins, walletID)
+	}
+	ors.mu.RUnlock()
+
+	sort.Strings(org.Admins)
+	org.Wallets = len(ors.Wallets(id))
+	return org
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// Payment request statuses. A pending request past its expiry reads as
+// expired and can no longer be accepted.
+const (
+	PaymentRequestPending  = "pending"
+	PaymentRequestAccepted = "accepted"
+	PaymentRequestDeclined = "declined"
+	PaymentRequestExpired  = "expired"
+)
+
+// Payment requests expire after DefaultPaymentRequestTTL unless they say
+// otherwise, and never later than MaxPaymentRequestTTL
+const (
+	DefaultPaymentRequestTTL = 7 * 24 * time.Hour
+	MaxPaymentRequestTTL     = 90 * 24 * time.Hour
+)
+
+// MaxPaymentRequestNoteLength leaves room in the payment's note for the
+// request reference
+const MaxPaymentRequestNoteLength = 200
+
+// Errors returned by the payment request service
+var (
+	ErrPaymentRequestNotFound = errors.New("payment request not found")
+	ErrPaymentRequestClosed   = errors.New("payment request is no longer pending")
+	ErrPaymentRequestBusy     = errors.New("payment request is already being paid")
+	ErrInvalidPaymentRequest  = errors.New("invalid payment request")
+	ErrPaymentRequestWallet   = errors.New("wallet not found or inactive")
+)
+
+// PaymentRequest is one wallet asking another for an amount. Accepting it
+// sends a transfer from the payer whose note starts with the request
+// reference, so the payment on the chain points back to the request.
+type PaymentRequest struct {
+	ID        int64      `json:"id"`
+	Requester string     `json:"requester"` // the wallet to be paid
+	Payer     string     `json:"payer"`
+	Amount    uint64     `json:"amount"`
+	Note      string     `json:"note,omitempty"`
+	Status    string     `json:"status"`
+	TxID      string     `json:"txid,omitempty"`    // the payment, once accepted
+	BillID    int64      `json:"bill_id,omitempty"` // the split bill the request is a share of
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// PaymentRequestNote returns the note of the payment answering a request,
+// carrying the request's note after the reference
+func PaymentRequestNote(id int64, note string) string {
+	ref := "payreq:" + strconv.FormatInt(id, 10)
+	if note != "" {
+		ref += " " + note
+	}
+	return ref
+}
+
+// PaymentRequestService keeps the payment requests between wallets
+type PaymentRequestService struct {
+	mu        sync.Mutex
+	ws        *wallet.Store
+	requests  map[int64]*PaymentRequest
+	accepting map[int64]bool // requests whose payment is being sent
+	nextID    int64
+	db        database.PaymentRequestRepo
+}
+
+func NewPaymentRequestService(ws *wallet.Store) *PaymentRequestService {
+	return &PaymentRequestService{
+		ws:        ws,
+		requests:  make(map[int64]*PaymentRequest),
+		accepting: make(map[int64]bool),
+		nextID:    1,
+	}
+}
+
+// Reset drops every payment request after the chain was reset; a paid one
+// names a transaction that no longer exists
+func (ps *PaymentRequestService) Reset() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.requests = make(map[int64]*PaymentRequest)
+	ps.accepting = make(map[int64]bool)
+}
+
+// SetDatabase enables persistence and reloads previous requests
+func (ps *PaymentRequestService) SetDatabase(db database.PaymentRequestRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetPaymentRequests(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load payment requests from database: %v", err)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.db = db
+	for _, row := range rows {
+		pr := &PaymentRequest{
+			ID:        row["id"].(int64),
+			Requester: row["requester"].(string),
+			Payer:     row["payer"].(string),
+			Amount:    row["amount"].(uint64),
+			Note:      row["note"].(string),
+			Status:    row["status"].(string),
+			TxID:      row["tx_id"].(string),
+			BillID:    row["bill_id"].(int64),
+			CreatedAt: row["created_at"].(time.Time),
+			ExpiresAt: row["expires_at"].(time.Time),
+		}
+		if t, ok := row["decided_at"].(*time.Time); ok {
+			pr.DecidedAt = t
+		}
+		ps.requests[pr.ID] = pr
+		if pr.ID >= ps.nextID {
+			ps.nextID = pr.ID + 1
+		}
+	}
+}
+
+// Create records a request from requester for payer to pay amount. A zero
+// expiresAt expires it after DefaultPaymentRequestTTL.
+func (ps *PaymentRequestService) Create(requester, payer string, amount uint64, note string, expiresAt time.Time) (*PaymentRequest, error) {
+	if err := ps.check(requester, payer, note, &expiresAt); err != nil {
+		return nil, err
+	}
+	return ps.create(requester, payer, amount, note, expiresAt, 0), nil
+}
+
+// check validates a request before it is created, defaulting a zero
+// expiresAt
+func (ps *PaymentRequestService) check(requester, payer, note string, expiresAt *time.Time) error {
+	now := time.Now()
+	switch {
+	case requester == payer:
+		return fmt.Errorf("%w: a wallet cannot request a payment from itself", ErrInvalidPaymentRequest)
+	case len(note) > MaxPaymentRequestNoteLength:
+		return fmt.Errorf("%w: the note is longer than %d bytes", ErrInvalidPaymentRequest, MaxPaymentRequestNoteLength)
+	case expiresAt.IsZero():
+		*expiresAt = now.Add(DefaultPaymentRequestTTL)
+	case !expiresAt.After(now):
+		return fmt.Errorf("%w: it must expire in the future", ErrInvalidPaymentRequest)
+	case expiresAt.After(now.Add(MaxPaymentRequestTTL)):
+		return fmt.Errorf("%w: requests expire within %d days", ErrInvalidPaymentRequest, int(MaxPaymentRequestTTL.Hours()/24))
+	}
+	for _, id := range []string{requester, payer} {
+		if w, ok := ps.ws.Get(id); !ok || !w.Active() {
+			return fmt.Errorf("%w: %s", ErrPaymentRequestWallet, id)
+		}
+	}
+	return nil
+}
+
+// create records a checked request
+func (ps *PaymentRequestService) create(requester, payer string, amount uint64, note string, expiresAt time.Time, billID int64) *PaymentRequest {
+	ps.mu.Lock()
+	pr := &PaymentRequest{
+		ID:        ps.nextID,
+		Requester: requester,
+		Payer:     payer,
+		Amount:    amount,
+		Note:      note,
+		Status:    PaymentRequestPending,
+		BillID:    billID,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	}
+	ps.nextID++
+	ps.requests[pr.ID] = pr
+	snapshot := *pr
+	ps.mu.Unlock()
+
+	ps.persist(snapshot)
+	return &snapshot
+}
+
+// Get returns a payment request
+func (ps *PaymentRequestService) Get(id int64) (PaymentRequest, bool) {
+	ps.mu.Lock()
+	pr, ok := ps.requests[id]
+	if !ok {
+		ps.mu.Unlock()
+		return PaymentRequest{}, false
+	}
+	expired := ps.expire(pr, time.Now())
+	snapshot := *pr
+	ps.mu.Unlock()
+
+	if expired {
+		ps.persist(snapshot)
+	}
+	return snapshot, true
+}
+
+// ForWallet returns the requests a wallet made (outgoing), was asked to pay
+// (incoming) or both (an empty direction), newest first. A non-empty status
+// keeps only requests in it.
+func (ps *PaymentRequestService) ForWallet(walletID, direction, status string) []PaymentRequest {
+	now := time.Now()
+	var list, expired []PaymentRequest
+	ps.mu.Lock()
+	for _, pr := range ps.requests {
+		if ps.expire(pr, now) {
+			expired = append(expired, *pr)
+		}
+		incoming, outgoing := pr.Payer == walletID, pr.Requester == walletID
+		if (direction == "incoming" && !incoming) || (direction == "outgoing" && !outgoing) || (!incoming && !outgoing) {
+			continue
+		}
+		if status != "" && pr.Status != status {
+			continue
+		}
+		list = append(list, *pr)
+	}
+	ps.mu.Unlock()
+
+	for _, pr := range expired {
+		ps.persist(pr)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// ForBill returns the requests of a split bill in the order they were made
+func (ps *PaymentRequestService) ForBill(billID int64) []PaymentRequest {
+	now := time.Now()
+	var list, expired []PaymentRequest
+	ps.mu.Lock()
+	for _, pr := range ps.requests {
+		if pr.BillID != billID {
+			continue
+		}
+		if ps.expire(pr, now) {
+			expired = append(expired, *pr)
+		}
+		list = append(list, *pr)
+	}
+	ps.mu.Unlock()
+
+	for _, pr := range expired {
+		ps.persist(pr)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// BeginAccept reserves a pending request while its payment is sent, so it
+// cannot be paid twice. The caller must have checked that the payer is
+// answering, and must finish with CompleteAccept or CancelAccept.
+func (ps *PaymentRequestService) BeginAccept(id int64) (PaymentRequest, error) {
+	ps.mu.Lock()
+	pr, ok := ps.requests[id]
+	if !ok {
+		ps.mu.Unlock()
+		return PaymentRequest{}, ErrPaymentRequestNotFound
+	}
+	expired := ps.expire(pr, time.Now())
+	snapshot := *pr
+	var err error
+	switch {
+	case pr.Status != PaymentRequestPending:
+		err = fmt.Errorf("%w: it is %s", ErrPaymentRequestClosed, pr.Status)
+	case ps.accepting[id]:
+		err = ErrPaymentRequestBusy
+	default:
+		ps.accepting[id] = true
+	}
+	ps.mu.Unlock()
+
+	if expired {
+		ps.persist(snapshot)
+	}
+	return snapshot, err
+}
+
+// CompleteAccept marks a request reserved by BeginAccept as paid by txID
+func (ps *PaymentRequestService) CompleteAccept(id int64, txID string) PaymentRequest {
+	return ps.decide(id, PaymentRequestAccepted, txID)
+}
+
+// CancelAccept releases a request reserved by BeginAccept whose payment
+// failed, leaving it pending
+func (ps *PaymentRequestService) CancelAccept(id int64) {
+	ps.mu.Lock()
+	delete(ps.accepting, id)
+	ps.mu.Unlock()
+}
+
+// Decline closes a pending request the payer refused
+func (ps *PaymentRequestService) Decline(id int64) (PaymentRequest, error) {
+	if _, err := ps.BeginAccept(id); err != nil {
+		return PaymentRequest{}, err
+	}
+	return ps.decide(id, PaymentRequestDeclined, ""), nil
+}
+
+// decide closes a request reserved by BeginAccept with status
+func (ps *PaymentRequestService) decide(id int64, status, txID string) PaymentRequest {
+	now := time.Now().UTC()
+	ps.mu.Lock()
+	pr := ps.requests[id]
+	delete(ps.accepting, id)
+	pr.Status = status
+	pr.TxID = txID
+	pr.DecidedAt = &now
+	snapshot := *pr
+	ps.mu.Unlock()
+
+	ps.persist(snapshot)
+	return snapshot
+}
+
+// expire marks a pending request past its expiry as expired and reports
+// whether it did. The caller must hold ps.mu.
+func (ps *PaymentRequestService) expire(pr *PaymentRequest, now time.Time) bool {
+	if pr.Status != PaymentRequestPending || ps.accepting[pr.ID] || now.Before(pr.ExpiresAt) {
+		return false
+	}
+	pr.Status = PaymentRequestExpired
+	return true
+}
+
+func (ps *PaymentRequestService) persist(pr PaymentRequest) {
+	ps.mu.Lock()
+	db := ps.db
+	ps.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SavePaymentRequest(ctx, pr.ID, pr.Requester, pr.Payer, pr.Amount, pr.Note, pr.Status, pr.TxID, pr.BillID, pr.CreatedAt, pr.ExpiresAt, pr.DecidedAt); err != nil {
+		log.Printf("Failed to persist payment request %d: %v", pr.ID, err)
+	}
+}
+
+// String names the request in logs
+func (pr PaymentRequest) String() string {
+	return fmt.Sprintf("payment request %d", pr.ID)
+}
+
+// ValidPaymentRequestStatus reports whether status names a request status
+func ValidPaymentRequestStatus(status string) bool {
+	switch status {
+	case PaymentRequestPending, PaymentRequestAccepted, PaymentRequestDeclined, PaymentRequestExpired:
+		return true
+	}
+	return false
+}
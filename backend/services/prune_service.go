@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// DefaultPruneKeepBlocks is how many recent blocks keep their spent UTXOs
+const DefaultPruneKeepBlocks = 100
+
+// PrunePolicy decides when spent UTXOs are archived. A UTXO spent more than
+// KeepBlocks blocks below the tip is moved to the archive table and dropped
+// from memory.
+type PrunePolicy struct {
+	KeepBlocks int64         `json:"keep_blocks"`
+	Interval   time.Duration `json:"interval"` // 0 turns automatic pruning off
+}
+
+// DefaultPrunePolicy keeps DefaultPruneKeepBlocks, with automatic pruning off
+func DefaultPrunePolicy() PrunePolicy {
+	return PrunePolicy{KeepBlocks: DefaultPruneKeepBlocks}
+}
+
+// PruneRun summarises one pruning pass
+type PruneRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	KeepBlocks int64     `json:"keep_blocks"`
+	Horizon    int64     `json:"horizon"`  // highest spending block pruned
+	Pruned     int       `json:"pruned"`   // UTXOs dropped from memory
+	Archived   int64     `json:"archived"` // rows moved to the archive table
+}
+
+// PruneService moves long-spent UTXOs out of memory and the utxos table into
+// utxos_archive, so the live set grows with unspent outputs only
+type PruneService struct {
+	bc      *blockchain.Blockchain
+	done    chan struct{}
+	loop    sync.WaitGroup // the ticker goroutine; Stop waits for it
+	changed chan struct{}
+
+	mu      sync.Mutex
+	db      database.ChainRepo
+	policy  PrunePolicy
+	lastRun *PruneRun
+	running sync.Mutex // one pass at a time
+}
+
+func NewPruneService(bc *blockchain.Blockchain, policy PrunePolicy) *PruneService {
+	return &PruneService{
+		bc:      bc,
+		policy:  policy,
+		done:    make(chan struct{}),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// SetDatabase archives pruned UTXOs in db, Postgres or a
+// database.MemoryStore. Without one, pruning only drops them from memory.
+func (ps *PruneService) SetDatabase(db database.ChainRepo) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.db = db
+}
+
+// Policy returns the pruning policy
+func (ps *PruneService) Policy() PrunePolicy {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.policy
+}
+
+// SetPolicy replaces the pruning policy; a running schedule picks up the new
+// interval
+func (ps *PruneService) SetPolicy(p PrunePolicy) {
+	ps.mu.Lock()
+	ps.policy = p
+	ps.mu.Unlock()
+	select {
+	case ps.changed <- struct{}{}:
+	default:
+	}
+}
+
+// LastRun returns the most recent pass, or nil before the first
+func (ps *PruneService) LastRun() *PruneRun {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.lastRun == nil {
+		return nil
+	}
+	run := *ps.lastRun
+	return &run
+}
+
+// Prune archives and drops UTXOs spent more than keepBlocks blocks below the
+// tip. The archive is written first; UTXOs stay in memory when it fails.
+func (ps *PruneService) Prune(ctx context.Context, keepBlocks int64) (PruneRun, error) {
+	ps.running.Lock()
+	defer ps.running.Unlock()
+
+	ps.mu.Lock()
+	db := ps.db
+	ps.mu.Unlock()
+
+	run := PruneRun{StartedAt: time.Now(), KeepBlocks: keepBlocks}
+	horizon, prunable := ps.bc.PrunableUTXOs(keepBlocks)
+	run.Horizon = horizon
+
+	ids := make([]string, len(prunable))
+	heights := make([]int64, len(prunable))
+	for i, u := range prunable {
+		ids[i] = u.ID
+		heights[i] = u.SpentHeight
+	}
+	if db != nil && len(ids) > 0 {
+		archived, err := db.ArchiveUTXOs(ctx, ids, heights)
+		if err != nil {
+			return run, fmt.Errorf("archive spent UTXOs: %w", err)
+		}
+		run.Archived = archived
+	}
+	run.Pruned = ps.bc.Prune(horizon, ids)
+	run.FinishedAt = time.Now()
+
+	ps.mu.Lock()
+	ps.lastRun = &run
+	ps.mu.Unlock()
+	if run.Pruned > 0 {
+		log.Printf("✂️  Pruned %d spent UTXOs up to block %d (%d archived)", run.Pruned, run.Horizon, run.Archived)
+	}
+	return run, nil
+}
+
+// Start runs Prune on the policy's interval, following later policy changes;
+// nothing runs while the interval is 0
+func (ps *PruneService) Start() {
+	ps.loop.Add(1)
+	go func() {
+		defer ps.loop.Done()
+		var tick <-chan time.Time
+		var ticker *time.Ticker
+		reset := func() {
+			if ticker != nil {
+				ticker.Stop()
+				ticker, tick = nil, nil
+			}
+			if interval := ps.Policy().Interval; interval > 0 {
+				ticker = time.NewTicker(interval)
+				tick = ticker.C
+			}
+		}
+		reset()
+		defer func() {
+			if ticker != nil {
+				ticker.Stop()
+			}
+		}()
+		for {
+			select {
+			case <-tick:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := ps.Prune(ctx, ps.Policy().KeepBlocks); err != nil {
+					log.Printf("⚠️  UTXO pruning failed: %v", err)
+				}
+				cancel()
+			case <-ps.changed:
+				reset()
+			case <-ps.done:
+				return
+			}
+		}
+	}()
+	if p := ps.Policy(); p.Interval > 0 {
+		log.Printf("✅ UTXO pruning started (keeping %d blocks, every %s)", p.KeepBlocks, p.Interval)
+	}
+}
+
+// Stop ends the automatic pruning, waiting for a pass in progress
+func (ps *PruneService) Stop() {
+	close(ps.done)
+	ps.loop.Wait()
+}
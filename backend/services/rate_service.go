@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// Fiat currencies coins are priced in
+const (
+	CurrencyPKR = "PKR"
+	CurrencyUSD = "USD"
+)
+
+// FiatCurrencies lists the currencies rates are kept for
+var FiatCurrencies = []string{CurrencyPKR, CurrencyUSD}
+
+// Where a rate came from
+const (
+	RateSourceAdmin = "admin"
+	RateSourceFeed  = "feed"
+)
+
+// DefaultRateRefresh is how often the rate feed is polled
+const DefaultRateRefresh = 15 * time.Minute
+
+// Errors returned by the rate service
+var (
+	ErrUnknownCurrency = fmt.Errorf("currency must be one of %s", strings.Join(FiatCurrencies, ", "))
+	ErrInvalidRate     = errors.New("rate must be a positive number")
+)
+
+// RatePolicy decides where rates come from. Without a feed URL rates are only
+// set by admins.
+type RatePolicy struct {
+	FeedURL string        `json:"feed_url,omitempty"`
+	Refresh time.Duration `json:"refresh"`
+}
+
+// DefaultRatePolicy has no feed, with DefaultRateRefresh once one is set
+func DefaultRatePolicy() RatePolicy {
+	return RatePolicy{Refresh: DefaultRateRefresh}
+}
+
+// Rate is the fiat price of one coin from EffectiveAt until the next rate
+type Rate struct {
+	Currency    string    `json:"currency"`
+	Rate        float64   `json:"rate"`
+	Source      string    `json:"source"`
+	SetBy       string    `json:"set_by,omitempty"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// FiatAmounts is an amount of coins in each currency with a known rate,
+// rounded to cents
+type FiatAmounts map[string]float64
+
+// RateService keeps the history of coin to fiat rates, set by admins or
+// polled from a feed, so amounts can be priced as of when they moved
+type RateService struct {
+	policy RatePolicy
+	client *http.Client
+	done   chan struct{}
+	loop   sync.WaitGroup // the feed goroutine; Stop waits for it
+
+	mu        sync.RWMutex
+	history   map[string][]Rate // currency -> rates, oldest first
+	lastFetch time.Time
+	lastError string
+	db        database.RateRepo
+}
+
+func NewRateService(policy RatePolicy) *RateService {
+	return &RateService{
+		policy:  policy,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		done:    make(chan struct{}),
+		history: make(map[string][]Rate),
+	}
+}
+
+// SetDatabase enables persistence and reloads the rate history
+func (rs *RateService) SetDatabase(db database.RateRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetExchangeRates(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load exchange rates from database: %v", err)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.db = db
+	for _, row := range rows {
+		r := Rate{
+			Currency:    row["currency"].(string),
+			Rate:        row["rate"].(float64),
+			Source:      row["source"].(string),
+			SetBy:       row["set_by"].(string),
+			EffectiveAt: row["effective_at"].(time.Time),
+		}
+		rs.history[r.Currency] = append(rs.history[r.Currency], r)
+	}
+	for _, rates := range rs.history {
+		sort.SliceStable(rates, func(i, j int) bool { return rates[i].EffectiveAt.Before(rates[j].EffectiveAt) })
+	}
+}
+
+// NormalizeCurrency upper-cases a currency code and checks it is supported
+func NormalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	for _, c := range FiatCurrencies {
+		if c == currency {
+			return c, nil
+		}
+	}
+	return "", ErrUnknownCurrency
+}
+
+// Set records a new rate for currency, effective now
+func (rs *RateService) Set(currency string, rate float64, source, setBy string) (Rate, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return Rate{}, err
+	}
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return Rate{}, ErrInvalidRate
+	}
+	r := Rate{Currency: currency, Rate: rate, Source: source, SetBy: setBy, EffectiveAt: time.Now().UTC()}
+
+	rs.mu.RLock()
+	db := rs.db
+	rs.mu.RUnlock()
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.SaveExchangeRate(ctx, r.Currency, r.Rate, r.Source, r.SetBy, r.EffectiveAt); err != nil {
+			return Rate{}, fmt.Errorf("failed to save rate: %w", err)
+		}
+	}
+
+	rs.mu.Lock()
+	rs.history[currency] = append(rs.history[currency], r)
+	rs.mu.Unlock()
+	return r, nil
+}
+
+// Rates returns the current rate of each currency that has one
+func (rs *RateService) Rates() []Rate {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	var out []Rate
+	for _, c := range FiatCurrencies {
+		if rates := rs.history[c]; len(rates) > 0 {
+			out = append(out, rates[len(rates)-1])
+		}
+	}
+	return out
+}
+
+// History returns the rates of currency that took effect in [from, to),
+// oldest first. Zero times leave that end open.
+func (rs *RateService) History(currency string, from, to time.Time) []Rate {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	out := []Rate{}
+	for _, r := range rs.history[currency] {
+		if (!from.IsZero() && r.EffectiveAt.Before(from)) || (!to.IsZero() && !r.EffectiveAt.Before(to)) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// At returns the rate of currency in effect at t. Before the first rate is
+// set there is none.
+func (rs *RateService) At(currency string, t time.Time) (Rate, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	rates := rs.history[currency]
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].EffectiveAt.After(t) })
+	if i == 0 {
+		return Rate{}, false
+	}
+	return rates[i-1], true
+}
+
+// ConvertAt prices an amount of units in each currency at the rates in
+// effect at t. It returns nil when no currency had a rate then, as does a nil
+// service.
+func (rs *RateService) ConvertAt(amount uint64, t time.Time) FiatAmounts {
+	if rs == nil {
+		return nil
+	}
+	var out FiatAmounts
+	for _, c := range FiatCurrencies {
+		r, ok := rs.At(c, t)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(FiatAmounts)
+		}
+		out[c] = math.Round(float64(amount)/float64(blockchain.UnitsPerCoin)*r.Rate*100) / 100
+	}
+	return out
+}
+
+// Convert prices amount at the current rates
+func (rs *RateService) Convert(amount uint64) FiatAmounts {
+	return rs.ConvertAt(amount, time.Now())
+}
+
+// FeedStatus reports when the feed was last polled and, if that failed, why
+func (rs *RateService) FeedStatus() (lastFetch time.Time, lastError string) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.lastFetch, rs.lastError
+}
+
+// Start polls the rate feed, if one is configured, right away and then every
+// Refresh
+func (rs *RateService) Start() {
+	if rs.policy.FeedURL == "" {
+		return
+	}
+	rs.loop.Add(1)
+	go func() {
+		defer rs.loop.Done()
+		ticker := time.NewTicker(rs.policy.Refresh)
+		defer ticker.Stop()
+		for {
+			rs.poll()
+			select {
+			case <-ticker.C:
+			case <-rs.done:
+				return
+			}
+		}
+	}()
+	log.Printf("💱 Exchange rate feed started (every %v)", rs.policy.Refresh)
+}
+
+// Stop ends the feed polling
+func (rs *RateService) Stop() {
+	close(rs.done)
+	rs.loop.Wait()
+}
+
+func (rs *RateService) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	rates, err := rs.fetch(ctx)
+
+	rs.mu.Lock()
+	rs.lastFetch = time.Now().UTC()
+	rs.lastError = ""
+	if err != nil {
+		rs.lastError = err.Error()
+	}
+	rs.mu.Unlock()
+	if err != nil {
+		log.Printf("⚠️  Exchange rate feed failed: %v", err)
+		return
+	}
+
+	for _, c := range FiatCurrencies {
+		rate, ok := rates[c]
+		if !ok {
+			continue
+		}
+		if cur, ok := rs.At(c, time.Now()); ok && cur.Rate == rate {
+			continue // only changes are recorded
+		}
+		if _, err := rs.Set(c, rate, RateSourceFeed, ""); err != nil {
+			log.Printf("⚠️  Exchange rate feed gave %s %v: %v", c, rate, err)
+		}
+	}
+}
+
+// fetch reads the feed: a JSON object of currency codes to the fiat price of
+// one coin, either bare or under a "rates" key
+func (rs *RateService) fetch(ctx context.Context) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rs.policy.FeedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil && len(wrapped.Rates) > 0 {
+		return upperKeys(wrapped.Rates), nil
+	}
+	var bare map[string]float64
+	if err := json.Unmarshal(body, &bare); err != nil {
+		return nil, fmt.Errorf("feed response is not a map of rates: %w", err)
+	}
+	return upperKeys(bare), nil
+}
+
+func upperKeys(m map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(m))
+	for k, v := range m {
+		out[strings.ToUpper(k)] = v
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// Login methods recorded on sessions
+const (
+	LoginOTP    = "otp"
+	LoginGoogle = "google"
+)
+
+// DefaultSessionTTL is how long a session token stays valid
+const DefaultSessionTTL = 24 * time.Hour
+
+// Session is an authenticated login. Only a hash of the bearer token is kept.
+type Session struct {
+	TokenHash string    `json:"-"`
+	Email     string    `json:"email"`
+	UserID    int64     `json:"user_id,omitempty"`
+	Method    string    `json:"method"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionService issues and validates the bearer tokens returned by OTP and
+// Google login
+type SessionService struct {
+	mu    sync.RWMutex
+	store SessionStore
+	ttl   time.Duration
+	db    database.SessionRepo
+}
+
+func NewSessionService(ttl time.Duration) *SessionService {
+	return &SessionService{
+		store: newMemorySessionStore(),
+		ttl:   ttl,
+	}
+}
+
+// SetDatabase persists sessions so they survive restarts; sessions missing
+// from memory are looked up in the database on first use
+func (ss *SessionService) SetDatabase(db database.SessionRepo) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.db = db
+}
+
+// SetStore keeps live sessions in store instead of memory, for example a
+// RedisSessionStore shared by every instance
+func (ss *SessionService) SetStore(store SessionStore) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.store = store
+}
+
+// Issue creates a session and returns its bearer token, which is shown once
+func (ss *SessionService) Issue(email string, userID int64, method string) (string, Session, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Session{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now()
+	sess := Session{
+		TokenHash: hashToken(token),
+		Email:     email,
+		UserID:    userID,
+		Method:    method,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ss.ttl),
+	}
+
+	ss.mu.RLock()
+	store, db := ss.store, ss.db
+	ss.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Put(ctx, sess); err != nil {
+		return "", Session{}, err
+	}
+	if db != nil {
+		if err := db.SaveSession(ctx, sess.TokenHash, email, userID, method, sess.CreatedAt, sess.ExpiresAt); err != nil {
+			log.Printf("Failed to persist session: %v", err)
+		}
+	}
+	return token, sess, nil
+}
+
+// Validate returns the session for a bearer token if it exists and has not expired
+func (ss *SessionService) Validate(token string) (Session, bool) {
+	if token == "" {
+		return Session{}, false
+	}
+	h := hashToken(token)
+
+	ss.mu.RLock()
+	store, db := ss.store, ss.db
+	ss.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sess, ok, err := store.Get(ctx, h)
+	if err != nil {
+		log.Printf("Failed to read session: %v", err)
+	}
+	if !ok && db != nil {
+		row, err := db.GetSession(ctx, h)
+		if err != nil {
+			return Session{}, false
+		}
+		sess = sessionFromRow(h, row)
+		if err := store.Put(ctx, sess); err != nil {
+			log.Printf("Failed to cache session: %v", err)
+		}
+	} else if !ok {
+		return Session{}, false
+	}
+
+	if time.Now().After(sess.ExpiresAt) {
+		ss.Revoke(token)
+		return Session{}, false
+	}
+	return sess, true
+}
+
+// Revoke ends a session (logout)
+func (ss *SessionService) Revoke(token string) {
+	h := hashToken(token)
+	ss.mu.RLock()
+	store, db := ss.store, ss.db
+	ss.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Delete(ctx, h); err != nil {
+		log.Printf("Failed to delete session: %v", err)
+	}
+	if db != nil {
+		if err := db.DeleteSession(ctx, h); err != nil {
+			log.Printf("Failed to delete session: %v", err)
+		}
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionFromRow(h string, row map[string]interface{}) Session {
+	sess := Session{TokenHash: h}
+	sess.Email, _ = row["email"].(string)
+	sess.UserID, _ = row["user_id"].(int64)
+	sess.Method, _ = row["method"].(string)
+	sess.CreatedAt, _ = row["created_at"].(time.Time)
+	sess.ExpiresAt, _ = row["expires_at"].(time.Time)
+	return sess
+}
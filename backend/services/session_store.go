@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionStore holds the live sessions by token hash
+type SessionStore interface {
+	Put(ctx context.Context, sess Session) error
+	// Get returns a session, and false when the store does not hold it
+	Get(ctx context.Context, tokenHash string) (Session, bool, error)
+	Delete(ctx context.Context, tokenHash string) error
+}
+
+// memorySessionStore keeps sessions in this process
+type memorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (m *memorySessionStore) Put(ctx context.Context, sess Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[sess.TokenHash] = sess
+	return nil
+}
+
+func (m *memorySessionStore) Get(ctx context.Context, tokenHash string) (Session, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sess, ok := m.sessions[tokenHash]
+	return sess, ok, nil
+}
+
+func (m *memorySessionStore) Delete(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, tokenHash)
+	return nil
+}
+
+// RedisSessionStore keeps sessions in Redis, expiring with the session, so a
+// logout on one instance ends the session on all of them
+type RedisSessionStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{client: client, prefix: "session:"}
+}
+
+func (r *RedisSessionStore) Put(ctx context.Context, sess Session) error {
+	ttl := time.Until(sess.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	payload, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.prefix+sess.TokenHash, payload, ttl).Err()
+}
+
+func (r *RedisSessionStore) Get(ctx context.Context, tokenHash string) (Session, bool, error) {
+	payload, err := r.client.Get(ctx, r.prefix+tokenHash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Session{}, false, nil
+	}
+	if err != nil {
+		return Session{}, false, err
+	}
+	var sess Session
+	if err := json.Unmarshal(payload, &sess); err != nil {
+		return Session{}, false, err
+	}
+	sess.TokenHash = tokenHash
+	return sess, true, nil
+}
+
+func (r *RedisSessionStore) Delete(ctx context.Context, tokenHash string) error {
+	return r.client.Del(ctx, r.prefix+tokenHash).Err()
+}
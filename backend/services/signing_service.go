@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// How a signing session was authorized
+const (
+	SigningAuthEmailOTP = "email_otp"
+	SigningAuthTOTP     = "totp"
+)
+
+// Signing session lifetimes
+const (
+	DefaultSigningSessionTTL = 5 * time.Minute
+	MaxSigningSessionTTL     = 30 * time.Minute
+)
+
+// Errors returned by the signing service
+var (
+	ErrSigningTokenInvalid  = errors.New("signing token is invalid or expired")
+	ErrSigningTokenWallet   = errors.New("signing token was issued for a different wallet")
+	ErrSigningLimitExceeded = errors.New("amount exceeds the signing session's remaining spend limit")
+	ErrRawKeyRejected       = errors.New("private_key is no longer accepted; create a signing session and send signing_token instead")
+)
+
+// SigningSession lets the server sign for one wallet with its stored,
+// encrypted key, so the client never sends the private key itself. Sessions
+// are short-lived, can cap how much they spend, and are kept in memory only:
+// a restart ends them all. Only a hash of the token is kept.
+type SigningSession struct {
+	TokenHash  string    `json:"-"`
+	WalletID   string    `json:"wallet_id"`
+	AuthMethod string    `json:"auth_method"`
+	SpendLimit uint64    `json:"spend_limit,omitempty"` // 0 means no cap
+	Spent      uint64    `json:"spent"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Remaining is what the session may still spend; ok is false when uncapped
+func (ss SigningSession) Remaining() (remaining uint64, ok bool) {
+	if ss.SpendLimit == 0 {
+		return 0, false
+	}
+	return ss.SpendLimit - ss.Spent, true
+}
+
+// SigningService issues signing tokens and decides whether requests may still
+// carry raw private keys
+type SigningService struct {
+	mu            sync.Mutex
+	sessions      map[string]*SigningSession // by token hash
+	ttl           time.Duration
+	rejectRawKeys bool
+}
+
+func NewSigningService(ttl time.Duration, rejectRawKeys bool) *SigningService {
+	return &SigningService{
+		sessions:      make(map[string]*SigningSession),
+		ttl:           ttl,
+		rejectRawKeys: rejectRawKeys,
+	}
+}
+
+// RawKeysAllowed reports whether requests may still carry private_key
+func (ss *SigningService) RawKeysAllowed() bool {
+	return !ss.rejectRawKeys
+}
+
+// TTL is the lifetime of new sessions unless the caller asks for less
+func (ss *SigningService) TTL() time.Duration {
+	return ss.ttl
+}
+
+// Issue creates a session for a wallet whose owner has just passed an OTP or
+// TOTP check and returns its token, which is shown once. ttl is clamped to the
+// service's lifetime; 0 uses it.
+func (ss *SigningService) Issue(walletID, authMethod string, spendLimit uint64, ttl time.Duration) (string, SigningSession, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", SigningSession{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	if ttl <= 0 || ttl > ss.ttl {
+		ttl = ss.ttl
+	}
+	now := time.Now()
+	sess := &SigningSession{
+		TokenHash:  hashToken(token),
+		WalletID:   walletID,
+		AuthMethod: authMethod,
+		SpendLimit: spendLimit,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.pruneLocked(now)
+	ss.sessions[sess.TokenHash] = sess
+	return token, *sess, nil
+}
+
+// Reserve checks that token may sign a spend of amount for walletID and counts
+// it against the session's limit. Call Release if the spend then fails.
+func (ss *SigningService) Reserve(token, walletID string, amount uint64) (SigningSession, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	sess, ok := ss.sessions[hashToken(token)]
+	if !ok || time.Now().After(sess.ExpiresAt) {
+		return SigningSession{}, ErrSigningTokenInvalid
+	}
+	if sess.WalletID != walletID {
+		return SigningSession{}, ErrSigningTokenWallet
+	}
+	if remaining, capped := sess.Remaining(); capped && amount > remaining {
+		return SigningSession{}, ErrSigningLimitExceeded
+	}
+	sess.Spent += amount
+	return *sess, nil
+}
+
+// Release returns a reserved amount to the session after a failed spend
+func (ss *SigningService) Release(token string, amount uint64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if sess, ok := ss.sessions[hashToken(token)]; ok && sess.Spent >= amount {
+		sess.Spent -= amount
+	}
+}
+
+// Get returns the session for a token if it has not expired
+func (ss *SigningService) Get(token string) (SigningSession, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	sess, ok := ss.sessions[hashToken(token)]
+	if !ok || time.Now().After(sess.ExpiresAt) {
+		return SigningSession{}, false
+	}
+	return *sess, true
+}
+
+// Revoke ends a session before it expires
+func (ss *SigningService) Revoke(token string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	h := hashToken(token)
+	_, ok := ss.sessions[h]
+	delete(ss.sessions, h)
+	return ok
+}
+
+// pruneLocked drops expired sessions
+func (ss *SigningService) pruneLocked(now time.Time) {
+	for h, sess := range ss.sessions {
+		if now.After(sess.ExpiresAt) {
+			delete(ss.sessions, h)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"blockchain-backend/blockchain"
+)
+
+// A simulated send is prepared and validated as a real one would be, but it
+// is never signed, reserves nothing and does not reach the pending pool, so
+// clients can preview a send and tests can check one without spending.
+
+// Simulation is what a send would do if it were made now
+type Simulation struct {
+	Transaction   *blockchain.Transaction // unsigned
+	SelectedUTXOs []blockchain.UTXO
+	Change        *blockchain.UTXO // nil when the inputs match the amount and fee
+	Balance       uint64           // sender's spendable balance of the sent asset
+	BalanceAfter  uint64           // that balance once the send is made
+	LimitExceeded bool             // over the sender's own spending limits, so it needs an override code
+}
+
+// SimulateTransfer prepares the transfer PrepareLockedTransaction would and
+// validates it as the pending pool would, without the signature. Errors are
+// the ones a real send would fail with.
+func (ts *TransactionService) SimulateTransfer(ctx context.Context, senderID, receiverID, assetID string, amount uint64, lock blockchain.Lock, note string) (*Simulation, error) {
+	tx, selected, err := ts.PrepareLockedTransaction(ctx, senderID, receiverID, assetID, amount, lock, note)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{Transaction: tx, SelectedUTXOs: selected}
+	err = ts.validate(ctx, tx, false, true)
+	if errors.Is(err, ErrSpendingLimitExceeded) {
+		sim.LimitExceeded = true
+		err = ts.validate(ctx, tx, true, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, out := range tx.Outputs {
+		if out.Owner == senderID {
+			change := out
+			change.ID = blockchain.UTXOKey(tx.ID, i)
+			sim.Change = &change
+		}
+	}
+	sim.Balance = ts.bc.GetAssetBalance(senderID, tx.AssetID)
+	if spend := tx.Amount + tx.Fee; spend < sim.Balance {
+		sim.BalanceAfter = sim.Balance - spend
+	}
+	return sim, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+)
+
+// Defaults for periodic chain snapshots
+const (
+	DefaultSnapshotInterval = time.Hour
+	DefaultSnapshotKeep     = 3
+)
+
+// SnapshotPolicy decides how often the chain state is snapshotted and how
+// many snapshots are kept
+type SnapshotPolicy struct {
+	Interval time.Duration `json:"interval"` // 0 turns periodic snapshots off
+	Keep     int           `json:"keep"`
+}
+
+// DefaultSnapshotPolicy returns the default snapshot interval and retention
+func DefaultSnapshotPolicy() SnapshotPolicy {
+	return SnapshotPolicy{Interval: DefaultSnapshotInterval, Keep: DefaultSnapshotKeep}
+}
+
+// SnapshotInfo describes a stored snapshot
+type SnapshotInfo struct {
+	Height    int64     `json:"height"`
+	Hash      string    `json:"hash"`
+	Version   int       `json:"version"`
+	UTXOs     int       `json:"utxos"`
+	Size      int       `json:"size"` // bytes of stored state
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChainRestore reports how the chain was rebuilt at startup
+type ChainRestore struct {
+	Blocks         int   `json:"blocks"`          // stored blocks, genesis included
+	SnapshotHeight int64 `json:"snapshot_height"` // -1 when every block was replayed
+	Replayed       int   `json:"replayed"`
+}
+
+// ErrNoDatabase is returned by features that need the database without one
+var ErrNoDatabase = errors.New("database not connected")
+
+// SnapshotService writes chain state snapshots to the database and rebuilds
+// the chain from the latest one at startup, replaying only the blocks mined
+// after it
+type SnapshotService struct {
+	bc     *blockchain.Blockchain
+	policy SnapshotPolicy
+	done   chan struct{}
+	loop   sync.WaitGroup // the ticker goroutine; Stop waits for it
+
+	mu   sync.Mutex
+	db   database.ChainRepo
+	last int64 // height of the newest snapshot written by this process
+}
+
+func NewSnapshotService(bc *blockchain.Blockchain, policy SnapshotPolicy) *SnapshotService {
+	return &SnapshotService{bc: bc, policy: policy, done: make(chan struct{}), last: -1}
+}
+
+// SetDatabase stores snapshots and reads blocks in db, Postgres or a
+// database.MemoryStore
+func (ss *SnapshotService) SetDatabase(db database.ChainRepo) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.db = db
+}
+
+// Policy returns the snapshot policy
+func (ss *SnapshotService) Policy() SnapshotPolicy {
+	return ss.policy
+}
+
+func (ss *SnapshotService) database() database.ChainRepo {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.db
+}
+
+// Create snapshots the state at the current tip and drops the snapshots
+// beyond the policy's Keep
+func (ss *SnapshotService) Create(ctx context.Context) (SnapshotInfo, error) {
+	db := ss.database()
+	if db == nil {
+		return SnapshotInfo{}, ErrNoDatabase
+	}
+
+	snap := ss.bc.Snapshot()
+	state, err := json.Marshal(snap)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	if err := db.SaveChainSnapshot(ctx, snap.Height, snap.Hash, snap.Version, len(snap.UTXOs), state, snap.CreatedAt); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("save snapshot: %w", err)
+	}
+	if _, err := db.PruneChainSnapshots(ctx, ss.policy.Keep); err != nil {
+		log.Printf("⚠️  Failed to drop old chain snapshots: %v", err)
+	}
+
+	ss.mu.Lock()
+	ss.last = snap.Height
+	ss.mu.Unlock()
+	log.Printf("📸 Chain snapshot at block %d (%d UTXOs)", snap.Height, len(snap.UTXOs))
+	return SnapshotInfo{
+		Height:    snap.Height,
+		Hash:      snap.Hash,
+		Version:   snap.Version,
+		UTXOs:     len(snap.UTXOs),
+		Size:      len(state),
+		CreatedAt: snap.CreatedAt,
+	}, nil
+}
+
+// List returns the stored snapshots, newest first
+func (ss *SnapshotService) List(ctx context.Context) ([]SnapshotInfo, error) {
+	db := ss.database()
+	if db == nil {
+		return nil, ErrNoDatabase
+	}
+
+	rows, err := db.GetChainSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]SnapshotInfo, 0, len(rows))
+	for _, row := range rows {
+		info := SnapshotInfo{}
+		info.Height, _ = row["height"].(int64)
+		info.Hash, _ = row["hash"].(string)
+		info.Version, _ = row["version"].(int)
+		info.UTXOs, _ = row["utxo_count"].(int)
+		info.Size, _ = row["size"].(int)
+		info.CreatedAt, _ = row["created_at"].(time.Time)
+		snapshots = append(snapshots, info)
+	}
+	return snapshots, nil
+}
+
+// Restore rebuilds the chain from the stored blocks and the latest snapshot
+// they reach. A snapshot that does not fit is skipped and every block is
+// replayed. With no stored blocks the fresh genesis block is saved, so the
+// blocks mined from now on can be restored after a restart.
+func (ss *SnapshotService) Restore(ctx context.Context) (ChainRestore, error) {
+	db := ss.database()
+	if db == nil {
+		return ChainRestore{}, ErrNoDatabase
+	}
+
+	bodies, err := db.GetBlockBodies(ctx)
+	if err != nil {
+		return ChainRestore{}, fmt.Errorf("load blocks: %w", err)
+	}
+	if len(bodies) == 0 {
+		genesis := ss.bc.LastBlock()
+		body, err := json.Marshal(genesis)
+		if err != nil {
+			return ChainRestore{}, err
+		}
+		if err := db.SaveBlock(ctx, genesis.Version, genesis.Index, genesis.Timestamp, genesis.PreviousHash, genesis.Hash, genesis.Nonce, genesis.MerkleRoot, body); err != nil {
+			return ChainRestore{}, fmt.Errorf("save genesis block: %w", err)
+		}
+		return ChainRestore{Blocks: 1, SnapshotHeight: -1}, nil
+	}
+
+	blocks := make([]blockchain.Block, len(bodies))
+	for i, body := range bodies {
+		if body == nil {
+			return ChainRestore{}, fmt.Errorf("block at position %d was stored without its body", i)
+		}
+		if err := json.Unmarshal(body, &blocks[i]); err != nil {
+			return ChainRestore{}, fmt.Errorf("decode block at position %d: %w", i, err)
+		}
+	}
+
+	result := ChainRestore{Blocks: len(blocks), SnapshotHeight: -1}
+	var snap *blockchain.Snapshot
+	if state, err := db.GetLatestChainSnapshot(ctx, int64(len(blocks)-1)); err != nil {
+		log.Printf("⚠️  Failed to load chain snapshot, replaying every block: %v", err)
+	} else if state != nil {
+		snap = &blockchain.Snapshot{}
+		if err := json.Unmarshal(state, snap); err != nil {
+			log.Printf("⚠️  Failed to decode chain snapshot, replaying every block: %v", err)
+			snap = nil
+		}
+	}
+
+	if snap != nil {
+		replayed, err := ss.bc.Restore(snap, blocks)
+		if err == nil {
+			result.SnapshotHeight, result.Replayed = snap.Height, replayed
+			ss.mu.Lock()
+			ss.last = snap.Height
+			ss.mu.Unlock()
+			return result, nil
+		}
+		log.Printf("⚠️  Chain snapshot at block %d not usable, replaying every block: %v", snap.Height, err)
+	}
+	replayed, err := ss.bc.Restore(nil, blocks)
+	if err != nil {
+		return ChainRestore{}, err
+	}
+	result.Replayed = replayed
+	return result, nil
+}
+
+// Start snapshots the chain on the policy's interval, skipping runs when no
+// block was mined since the last snapshot; nothing runs while the interval
+// is 0
+func (ss *SnapshotService) Start() {
+	if ss.policy.Interval <= 0 {
+		return
+	}
+	ss.loop.Add(1)
+	go func() {
+		defer ss.loop.Done()
+		ticker := time.NewTicker(ss.policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ss.mu.Lock()
+				last, db := ss.last, ss.db
+				ss.mu.Unlock()
+				if db == nil || ss.bc.Height() <= last {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := ss.Create(ctx); err != nil {
+					log.Printf("⚠️  Chain snapshot failed: %v", err)
+				}
+				cancel()
+			case <-ss.done:
+				return
+			}
+		}
+	}()
+	log.Printf("✅ Chain snapshots started (every %s, keeping %d)", ss.policy.Interval, ss.policy.Keep)
+}
+
+// Stop ends the periodic snapshots, waiting for one in progress
+func (ss *SnapshotService) Stop() {
+	close(ss.done)
+	ss.loop.Wait()
+}
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// PeriodLayout is how statement periods are written: a calendar month in UTC
+const PeriodLayout = "2006-01"
+
+// statementEmailLines caps the transactions listed in a statement email; the
+// full list is available from the API
+const statementEmailLines = 100
+
+// Errors returned by the statement service
+var (
+	ErrInvalidPeriod  = errors.New("period must be a month written as YYYY-MM")
+	ErrFuturePeriod   = errors.New("period has not started yet")
+	ErrPeriodNotEnded = errors.New("period has not ended yet")
+)
+
+// StatementLine is one balance movement on a statement
+type StatementLine struct {
+	TxID         string      `json:"tx_id"`
+	Type         string      `json:"type"`
+	Time         time.Time   `json:"time"`
+	Direction    string      `json:"direction"` // in or out
+	Counterparty string      `json:"counterparty,omitempty"`
+	Amount       uint64      `json:"amount"`
+	Fee          uint64      `json:"fee,omitempty"`
+	AmountFiat   FiatAmounts `json:"amount_fiat,omitempty"` // at the rates of the time
+	Note         string      `json:"note,omitempty"`
+}
+
+// net is the line's effect on the wallet balance
+func (l StatementLine) net() int64 {
+	if l.Direction == "in" {
+		return int64(l.Amount)
+	}
+	return -int64(l.Amount + l.Fee)
+}
+
+// Statement summarises a wallet's balance movements over one month. History
+// entries carry the totals only; Lines is filled in when a statement is
+// generated on request.
+type Statement struct {
+	WalletID       string          `json:"wallet_id"`
+	Period         string          `json:"period"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance uint64          `json:"opening_balance"`
+	ClosingBalance uint64          `json:"closing_balance"`
+	TotalReceived  uint64          `json:"total_received"`
+	TotalSent      uint64          `json:"total_sent"`
+	TotalFees      uint64          `json:"total_fees"`
+	TxCount        int             `json:"tx_count"`
+	OpeningFiat    FiatAmounts     `json:"opening_balance_fiat,omitempty"` // at the rates of From
+	ClosingFiat    FiatAmounts     `json:"closing_balance_fiat,omitempty"` // at the rates of To, or now
+	Lines          []StatementLine `json:"transactions,omitempty"`
+	Email          string          `json:"email,omitempty"`
+	DeliveryID     int64           `json:"delivery_id,omitempty"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+	Decimals       int             `json:"decimals"` // amounts are in units of 10^-decimals coin
+}
+
+// StatementRun summarises one pass of the monthly statement job
+type StatementRun struct {
+	Period     string    `json:"period"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	OptedIn    int       `json:"opted_in"`
+	Sent       int       `json:"sent"`
+	Skipped    int       `json:"skipped"`  // already emailed for the period
+	NoEmail    int       `json:"no_email"` // opted in without an email address
+}
+
+// StatementService emails opted-in wallets a statement for the previous month
+// on the 1st of each month and keeps a history of what was sent
+type StatementService struct {
+	bc         *blockchain.Blockchain
+	ws         *wallet.Store
+	deliveries *DeliveryService
+	rates      *RateService
+	db         database.StatementRepo
+	ticker     *time.Ticker
+	done       chan bool
+	loop       sync.WaitGroup // the ticker goroutine; Stop waits for it
+
+	mu      sync.RWMutex
+	history map[string][]Statement // wallet ID -> statements, oldest period first
+	lastRun *StatementRun
+}
+
+func NewStatementService(bc *blockchain.Blockchain, ws *wallet.Store, deliveries *DeliveryService) *StatementService {
+	return &StatementService{
+		bc:         bc,
+		ws:         ws,
+		deliveries: deliveries,
+		done:       make(chan bool),
+		history:    make(map[string][]Statement),
+	}
+}
+
+// Reset drops the statements after the chain was reset; their balances are
+// of the old chain
+func (ss *StatementService) Reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.history = make(map[string][]Statement)
+	ss.lastRun = nil
+}
+
+// SetRates prices generated statements in fiat at the rates of the time
+func (ss *StatementService) SetRates(rates *RateService) {
+	ss.rates = rates
+}
+
+// SetDatabase enables persistence and reloads the statement history
+func (ss *StatementService) SetDatabase(db database.StatementRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetStatements(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load statements from database: %v", err)
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.db = db
+	for _, row := range rows {
+		st := statementFromRow(row)
+		if from, err := time.Parse(PeriodLayout, st.Period); err == nil {
+			st.From, st.To = from, from.AddDate(0, 1, 0)
+		}
+		ss.history[st.WalletID] = append(ss.history[st.WalletID], st)
+	}
+}
+
+func statementFromRow(row map[string]interface{}) Statement {
+	var st Statement
+	st.WalletID, _ = row["wallet_id"].(string)
+	st.Period, _ = row["period"].(string)
+	st.OpeningBalance, _ = row["opening_balance"].(uint64)
+	st.ClosingBalance, _ = row["closing_balance"].(uint64)
+	st.TotalReceived, _ = row["total_received"].(uint64)
+	st.TotalSent, _ = row["total_sent"].(uint64)
+	st.TotalFees, _ = row["total_fees"].(uint64)
+	st.TxCount, _ = row["tx_count"].(int)
+	st.Email, _ = row["email"].(string)
+	st.DeliveryID, _ = row["delivery_id"].(int64)
+	st.GeneratedAt, _ = row["created_at"].(time.Time)
+	return st
+}
+
+// LastRun returns the most recent statement run, if any has completed
+func (ss *StatementService) LastRun() (StatementRun, bool) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if ss.lastRun == nil {
+		return StatementRun{}, false
+	}
+	return *ss.lastRun, true
+}
+
+// Start begins the statement scheduler. It checks hourly and sends the
+// previous month's statements once the 1st has begun in UTC.
+func (ss *StatementService) Start() {
+	ss.ticker = time.NewTicker(time.Hour)
+
+	ss.loop.Add(1)
+	go func() {
+		defer ss.loop.Done()
+		for {
+			select {
+			case <-ss.ticker.C:
+				ss.checkSchedule(time.Now())
+			case <-ss.done:
+				return
+			}
+		}
+	}()
+
+	log.Println("✅ Statement scheduler started (emails opted-in wallets on the 1st of each month)")
+}
+
+// Stop stops the statement scheduler, waiting for a run in progress
+func (ss *StatementService) Stop() {
+	if ss.ticker != nil {
+		ss.ticker.Stop()
+	}
+	close(ss.done)
+	ss.loop.Wait()
+	log.Println("Statement scheduler stopped")
+}
+
+func (ss *StatementService) checkSchedule(now time.Time) {
+	now = now.UTC()
+	if now.Day() != 1 {
+		return
+	}
+	period := PreviousPeriod(now)
+	if run, ok := ss.LastRun(); ok && run.Period == period {
+		return
+	}
+	if _, err := ss.RunMonthly(period); err != nil {
+		log.Printf("⚠️  Monthly statements for %s failed: %v", period, err)
+	}
+}
+
+// PreviousPeriod is the month before the one containing t, in UTC
+func PreviousPeriod(t time.Time) string {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(PeriodLayout)
+}
+
+// ParsePeriod returns the start and end of a YYYY-MM period in UTC
+func ParsePeriod(period string) (from, to time.Time, err error) {
+	from, err = time.Parse(PeriodLayout, period)
+	if err != nil || from.Format(PeriodLayout) != period {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// History returns the statements recorded for a wallet, newest period first
+func (ss *StatementService) History(walletID string) []Statement {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	list := ss.history[walletID]
+	out := make([]Statement, len(list))
+	for i, st := range list {
+		out[len(list)-1-i] = st
+	}
+	return out
+}
+
+// Generate builds a wallet's statement for a period from the chain. The
+// current month may be requested; it runs up to now.
+func (ss *StatementService) Generate(walletID, period string) (Statement, error) {
+	from, to, err := ParsePeriod(period)
+	if err != nil {
+		return Statement{}, err
+	}
+	now := time.Now().UTC()
+	if !from.Before(now) {
+		return Statement{}, ErrFuturePeriod
+	}
+	if _, ok := ss.ws.Get(walletID); !ok {
+		return Statement{}, ErrWalletNotFound
+	}
+
+	lines, current := ss.movements(walletID)
+	st := Statement{WalletID: walletID, Period: period, From: from, To: to, GeneratedAt: now, Decimals: blockchain.Decimals}
+
+	// The chain is not reloaded on restart while UTXOs are, so balances are
+	// worked back from the current unspent total rather than summed forward
+	closing := current
+	for _, l := range lines {
+		if !l.Time.Before(to) {
+			closing -= l.net()
+		}
+	}
+	opening := closing
+	for _, l := range lines {
+		if l.Time.Before(from) || !l.Time.Before(to) {
+			continue
+		}
+		opening -= l.net()
+		st.Lines = append(st.Lines, l)
+		if l.Direction == "in" {
+			st.TotalReceived += l.Amount
+		} else {
+			st.TotalSent += l.Amount
+			st.TotalFees += l.Fee
+		}
+	}
+	st.TxCount = len(st.Lines)
+	st.OpeningBalance, st.ClosingBalance = clampBalance(opening), clampBalance(closing)
+
+	closedAt := to
+	if now.Before(to) {
+		closedAt = now
+	}
+	st.OpeningFiat = ss.rates.ConvertAt(st.OpeningBalance, from)
+	st.ClosingFiat = ss.rates.ConvertAt(st.ClosingBalance, closedAt)
+	for i := range st.Lines {
+		st.Lines[i].AmountFiat = ss.rates.ConvertAt(st.Lines[i].Amount, st.Lines[i].Time)
+	}
+	return st, nil
+}
+
+func clampBalance(v int64) uint64 {
+	if v < 0 {
+		return 0
+	}
+	return uint64(v)
+}
+
+// movements lists every mined transaction and faucet grant that moved the
+// wallet's balance, in time order, together with its unspent total
+func (ss *StatementService) movements(walletID string) ([]StatementLine, int64) {
+	var lines []StatementLine
+	var unspent int64
+	ss.bc.View(func(v blockchain.View) {
+		for _, u := range v.OwnedUTXOs(walletID) {
+			if !u.Spent {
+				unspent += int64(u.Amount)
+			}
+			// Faucet grants are not chain transactions; their origin is
+			// faucet-<wallet>-<unix seconds>
+			if strings.HasPrefix(u.OriginTx, "faucet-") {
+				ts := u.OriginTx[strings.LastIndex(u.OriginTx, "-")+1:]
+				if sec, err := strconv.ParseInt(ts, 10, 64); err == nil {
+					lines = append(lines, StatementLine{TxID: u.OriginTx, Type: "faucet", Time: time.Unix(sec, 0).UTC(), Direction: "in", Amount: u.Amount})
+				}
+			}
+		}
+		for block := range v.Blocks() {
+			at := time.Unix(block.Timestamp, 0).UTC()
+			for _, tx := range block.Transactions {
+				if tx.AssetID != "" {
+					continue // statements are in coins
+				}
+				if tx.Pays(walletID) {
+					lines = append(lines, StatementLine{TxID: tx.ID, Type: tx.Type, Time: at, Direction: "in", Counterparty: tx.SenderID, Amount: tx.PaidTo(walletID), Note: tx.Note})
+				}
+				if tx.SenderID == walletID {
+					lines = append(lines, StatementLine{TxID: tx.ID, Type: tx.Type, Time: at, Direction: "out", Counterparty: tx.ReceiverID, Amount: tx.Amount, Fee: tx.Fee, Note: tx.Note})
+				}
+			}
+		}
+	})
+
+	// Within a second a faucet grant goes first, as nothing can be spent before it
+	sort.SliceStable(lines, func(i, j int) bool {
+		if !lines[i].Time.Equal(lines[j].Time) {
+			return lines[i].Time.Before(lines[j].Time)
+		}
+		return lines[i].Type == "faucet" && lines[j].Type != "faucet"
+	})
+	return lines, unspent
+}
+
+// RunMonthly emails every opted-in wallet with an email address its
+// statement for a finished period. Wallets already emailed for the period
+// are skipped, so a run can be repeated safely.
+func (ss *StatementService) RunMonthly(period string) (StatementRun, error) {
+	_, to, err := ParsePeriod(period)
+	if err != nil {
+		return StatementRun{}, err
+	}
+	if to.After(time.Now()) {
+		return StatementRun{}, ErrPeriodNotEnded
+	}
+
+	run := StatementRun{Period: period, StartedAt: time.Now()}
+	log.Printf("📄 Sending monthly statements for %s...", period)
+	for _, w := range ss.ws.GetAll() {
+		if !w.MonthlyStatements {
+			continue
+		}
+		run.OptedIn++
+		if w.Email == "" {
+			run.NoEmail++
+			continue
+		}
+		if ss.sent(w.WalletID, period) {
+			run.Skipped++
+			continue
+		}
+
+		st, err := ss.Generate(w.WalletID, period)
+		if err != nil {
+			log.Printf("⚠️  Statement for %s (%s) failed: %v", w.WalletID, period, err)
+			continue
+		}
+		st.Email = w.Email
+		subject, body := statementEmail(w, st)
+		if d := ss.deliveries.EnqueueEmail(w.Email, subject, body, "statement.monthly", "statement:"+w.WalletID+":"+period); d != nil {
+			st.DeliveryID = d.ID
+		}
+		ss.record(st)
+		run.Sent++
+	}
+	run.FinishedAt = time.Now()
+
+	ss.mu.Lock()
+	ss.lastRun = &run
+	ss.mu.Unlock()
+	log.Printf("✅ Statements for %s: %d sent, %d already sent, %d without email", period, run.Sent, run.Skipped, run.NoEmail)
+	return run, nil
+}
+
+// sent reports whether a wallet was already emailed its statement for a period
+func (ss *StatementService) sent(walletID, period string) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	for _, st := range ss.history[walletID] {
+		if st.Period == period && st.DeliveryID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// record adds a statement's totals to the history, replacing any earlier
+// statement for the same period
+func (ss *StatementService) record(st Statement) {
+	st.Lines = nil
+	ss.mu.Lock()
+	list := ss.history[st.WalletID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Period >= st.Period })
+	if i < len(list) && list[i].Period == st.Period {
+		list[i] = st
+	} else {
+		list = append(list, Statement{})
+		copy(list[i+1:], list[i:])
+		list[i] = st
+	}
+	ss.history[st.WalletID] = list
+	db := ss.db
+	ss.mu.Unlock()
+
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := db.SaveStatement(ctx, st.WalletID, st.Period, st.OpeningBalance, st.ClosingBalance, st.TotalReceived, st.TotalSent, st.TotalFees, st.TxCount, st.Email, st.DeliveryID, st.GeneratedAt); err != nil {
+			log.Printf("⚠️  Failed to save statement for %s (%s): %v", st.WalletID, st.Period, err)
+		}
+	}
+}
+
+// statementEmail renders a statement as a plain text email
+func statementEmail(w wallet.Wallet, st Statement) (subject, body string) {
+	month := st.From.Format("January 2006")
+	subject = "Your wallet statement for " + month
+
+	var b strings.Builder
+	name := w.FullName
+	if name == "" {
+		name = "there"
+	}
+	fmt.Fprintf(&b, "Hello %s,\n\nHere is your statement for %s.\n\n", name, month)
+	fmt.Fprintf(&b, "Wallet:           %s\n", st.WalletID)
+	fmt.Fprintf(&b, "Opening balance:  %s\n", blockchain.FormatAmount(st.OpeningBalance))
+	fmt.Fprintf(&b, "Received:         +%s\n", blockchain.FormatAmount(st.TotalReceived))
+	fmt.Fprintf(&b, "Sent:             -%s\n", blockchain.FormatAmount(st.TotalSent))
+	fmt.Fprintf(&b, "Fees:             -%s\n", blockchain.FormatAmount(st.TotalFees))
+	fmt.Fprintf(&b, "Closing balance:  %s\n", blockchain.FormatAmount(st.ClosingBalance))
+	for _, c := range FiatCurrencies {
+		if v, ok := st.ClosingFiat[c]; ok {
+			fmt.Fprintf(&b, "                  ≈ %.2f %s\n", v, c)
+		}
+	}
+
+	if len(st.Lines) == 0 {
+		b.WriteString("\nNo transactions this month.\n")
+	} else {
+		fmt.Fprintf(&b, "\nTransactions (%d)\n", st.TxCount)
+		for i, l := range st.Lines {
+			if i == statementEmailLines {
+				fmt.Fprintf(&b, "... and %d more\n", len(st.Lines)-i)
+				break
+			}
+			sign := "+"
+			party := "from " + l.Counterparty
+			if l.Direction == "out" {
+				sign, party = "-", "to "+l.Counterparty
+			}
+			if l.Counterparty == "" {
+				party = ""
+			}
+			fmt.Fprintf(&b, "%s  %-8s %s%s", l.Time.Format("2006-01-02 15:04"), l.Type, sign, blockchain.FormatAmount(l.Amount))
+			if l.Fee > 0 {
+				fmt.Fprintf(&b, " (fee %s)", blockchain.FormatAmount(l.Fee))
+			}
+			if party != "" {
+				b.WriteString("  " + party)
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\nYou receive this email because monthly statements are enabled on your profile. Turn them off there to stop them.\n")
+	return subject, b.String()
+}
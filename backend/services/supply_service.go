@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+)
+
+// Issuance sources
+const (
+	IssuedByMining = "mining" // block subsidies; fees are not issuance
+	IssuedByFaucet = "faucet"
+	IssuedByMint   = "mint"
+)
+
+// SupplyBurn is the history source of coins destroyed by admin burns. Burns
+// are not issuance: they lower the circulating supply, not the issued count.
+const SupplyBurn = "burn"
+
+// SupplyDay is what was issued on one UTC day
+type SupplyDay struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	Mining     uint64 `json:"mining"`
+	Faucet     uint64 `json:"faucet"`
+	Mint       uint64 `json:"mint"`
+	Total      uint64 `json:"total"`
+	Burned     uint64 `json:"burned"`
+	Cumulative uint64 `json:"cumulative"` // issued up to the end of the day
+}
+
+func (d *SupplyDay) add(source string, amount uint64) {
+	switch source {
+	case IssuedByMining:
+		d.Mining += amount
+	case IssuedByFaucet:
+		d.Faucet += amount
+	case IssuedByMint:
+		d.Mint += amount
+	case SupplyBurn:
+		d.Burned += amount
+		return
+	}
+	d.Total += amount
+}
+
+// SupplyReport is the monetary supply and its recent issuance
+type SupplyReport struct {
+	Issued       uint64                  `json:"issued"`
+	Circulating  uint64                  `json:"circulating"` // unspent outputs
+	Policy       blockchain.SupplyPolicy `json:"policy"`
+	Remaining    *uint64                 `json:"remaining,omitempty"` // below the cap; only with a cap
+	NextSubsidy  uint64                  `json:"next_block_subsidy"`
+	IssuedBy     map[string]uint64       `json:"issued_by"`
+	Burned       uint64                  `json:"burned"`  // destroyed by admin burns
+	History      []SupplyDay             `json:"history"` // oldest first
+	HistoryStart string                  `json:"history_start,omitempty"`
+	Decimals     int                     `json:"decimals"` // amounts are in units of 10^-decimals coin
+}
+
+// SupplyService records every coin issued, per source and per UTC day. It
+// listens on the wallet event feed for faucet grants and mined blocks; mints
+// and burns are recorded by their caller.
+type SupplyService struct {
+	mu   sync.Mutex
+	bc   *blockchain.Blockchain
+	days map[string]*SupplyDay
+	db   database.SupplyRepo
+}
+
+func NewSupplyService(bc *blockchain.Blockchain) *SupplyService {
+	return &SupplyService{bc: bc, days: make(map[string]*SupplyDay)}
+}
+
+// SetDatabase enables persistence and restores the issuance history and the
+// chain's issued count. A database from before supply tracking is backfilled
+// from its faucet and coinbase outputs, which overstates mining by any fees
+// those blocks paid.
+func (ss *SupplyService) SetDatabase(db database.SupplyRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if n, err := db.BackfillSupplyIssuance(ctx); err != nil {
+		log.Printf("⚠️  Failed to backfill supply issuance: %v", err)
+	} else if n > 0 {
+		log.Printf("✅ Backfilled %d issuance records from stored UTXOs", n)
+	}
+	rows, err := db.GetSupplyIssuanceByDay(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load supply history from database: %v", err)
+	}
+
+	ss.mu.Lock()
+	ss.db = db
+	var total uint64
+	for _, row := range rows {
+		amount, source := row["amount"].(uint64), row["source"].(string)
+		ss.dayLocked(row["date"].(string)).add(source, amount)
+		if source != SupplyBurn {
+			total += amount
+		}
+	}
+	ss.mu.Unlock()
+
+	if err == nil {
+		ss.bc.SetIssued(total)
+	}
+}
+
+// Record adds issued coins to the history, or burned ones for SupplyBurn
+func (ss *SupplyService) Record(source string, amount uint64, ref string) {
+	if amount == 0 {
+		return
+	}
+	now := time.Now().UTC()
+
+	ss.mu.Lock()
+	ss.dayLocked(now.Format("2006-01-02")).add(source, amount)
+	db := ss.db
+	ss.mu.Unlock()
+
+	if db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveSupplyIssuance(ctx, source, amount, ref, now); err != nil {
+		log.Printf("Failed to persist %d %s issuance (%s): %v", amount, source, ref, err)
+	}
+}
+
+// Reset forgets the issuance history kept in memory, after the chain was
+// reset; the database's is emptied with the chain tables
+func (ss *SupplyService) Reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.days = make(map[string]*SupplyDay)
+}
+
+// OnEvent records faucet grants
+func (ss *SupplyService) OnEvent(ev events.Event) {
+	if ev.Type != events.FaucetGranted {
+		return
+	}
+	amount, _ := ev.Data["amount"].(uint64)
+	ref, _ := ev.Data["utxo_id"].(string)
+	ss.Record(IssuedByFaucet, amount, ref)
+}
+
+// OnBlock records a block's subsidy: the coinbase less the fees it collected
+func (ss *SupplyService) OnBlock(blk blockchain.Block) {
+	var coinbase, fees uint64
+	for _, tx := range blk.Transactions {
+		if tx.Type == "mining_reward" {
+			coinbase += tx.Amount
+		} else {
+			fees += tx.Fee
+		}
+	}
+	if coinbase > fees {
+		ss.Record(IssuedByMining, coinbase-fees, blk.Hash)
+	}
+}
+
+// Report returns the supply figures and the issuance of the last days UTC
+// days (all of them when days is 0)
+func (ss *SupplyService) Report(days int) SupplyReport {
+	report := SupplyReport{
+		Decimals:    blockchain.Decimals,
+		Issued:      ss.bc.Issued(),
+		Circulating: ss.bc.CirculatingSupply(),
+		Policy:      ss.bc.Supply,
+		NextSubsidy: ss.bc.NextSubsidy(),
+		IssuedBy:    map[string]uint64{IssuedByMining: 0, IssuedByFaucet: 0, IssuedByMint: 0},
+		History:     make([]SupplyDay, 0),
+	}
+	if report.Policy.Cap > 0 {
+		remaining := uint64(0)
+		if report.Issued < report.Policy.Cap {
+			remaining = report.Policy.Cap - report.Issued
+		}
+		report.Remaining = &remaining
+	}
+
+	ss.mu.Lock()
+	history := make([]SupplyDay, 0, len(ss.days))
+	for _, d := range ss.days {
+		history = append(history, *d)
+	}
+	ss.mu.Unlock()
+	sort.Slice(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+
+	var cumulative uint64
+	for i := range history {
+		cumulative += history[i].Total
+		history[i].Cumulative = cumulative
+		report.IssuedBy[IssuedByMining] += history[i].Mining
+		report.IssuedBy[IssuedByFaucet] += history[i].Faucet
+		report.IssuedBy[IssuedByMint] += history[i].Mint
+		report.Burned += history[i].Burned
+	}
+	if days > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -days+1).Format("2006-01-02")
+		i := sort.Search(len(history), func(i int) bool { return history[i].Date >= cutoff })
+		history = history[i:]
+	}
+	report.History = append(report.History, history...)
+	if len(report.History) > 0 {
+		report.HistoryStart = report.History[0].Date
+	}
+	return report
+}
+
+// dayLocked returns the day's totals, creating them. The caller must hold the
+// lock.
+func (ss *SupplyService) dayLocked(date string) *SupplyDay {
+	d, ok := ss.days[date]
+	if !ok {
+		d = &SupplyDay{Date: date}
+		ss.days[date] = d
+	}
+	return d
+}
@@ -1,81 +1,280 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"time"
 
 	"blockchain-backend/blockchain"
+	"blockchain-backend/validation"
 	"blockchain-backend/wallet"
 )
 
+// Errors returned by transaction construction, so callers can tell them apart
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrSenderNotFound      = errors.New("sender wallet does not exist")
+	ErrReceiverNotFound    = errors.New("receiver wallet does not exist")
+
+	ErrMalformedTransaction = errors.New("malformed transaction")
+	ErrStaleTransaction     = errors.New("transaction timestamp is outside the accepted window")
+	ErrDuplicateTransaction = errors.New("transaction signature was already submitted")
+	ErrNonceUsed            = blockchain.ErrNonceUsed
+
+	ErrSelfTransfer  = errors.New("sender and receiver must be different wallets")
+	ErrWalletFrozen  = errors.New("sender wallet is frozen")
+
+	ErrSpendingLimitExceeded = errors.New("spending limit exceeded")
+	ErrTooManyInputs = fmt.Errorf("transaction would spend more than %d outputs; consolidate the wallet's UTXOs first", validation.MaxTxInputs)
+
+	ErrNothingToConsolidate = errors.New("wallet has fewer than two spendable outputs to consolidate")
+	ErrNothingToSweep       = errors.New("wallet has no spendable outputs to sweep")
+	ErrNotRetired           = errors.New("wallet was not retired by a key rotation")
+
+	ErrWalletInactive = errors.New("wallet is retired or deactivated")
+	ErrWalletBusy     = errors.New("wallet has pending transactions or unconfirmed outputs")
+)
+
+// Externally signed transactions must be submitted within this window of
+// their timestamp, leaving time to carry the payload to an offline signer
+const (
+	SignedTxMaxAge     = 24 * time.Hour
+	SignedTxFutureSkew = 5 * time.Minute
+)
+
 type TransactionService struct {
-	bc *blockchain.Blockchain
-	ws *wallet.Store
+	bc            *blockchain.Blockchain
+	ws            *wallet.Store
+	config        *ConfigCascade
+	kyc           *KYCService
+	assets        *AssetService
+	coinSelection string
 }
 
 func NewTransactionService(bc *blockchain.Blockchain, ws *wallet.Store) *TransactionService {
-	return &TransactionService{bc: bc, ws: ws}
+	return &TransactionService{bc: bc, ws: ws, coinSelection: CoinSelectAuto}
 }
 
-// SelectUTXOs selects UTXOs for a transaction using a greedy algorithm
-func (ts *TransactionService) SelectUTXOs(walletID string, amount uint64) ([]blockchain.UTXO, uint64, error) {
-	ts.bc.RLock()
-	defer ts.bc.RUnlock()
+// SetCoinSelection sets the strategy SelectUTXOs uses
+func (ts *TransactionService) SetCoinSelection(strategy string) error {
+	if !slices.Contains(CoinSelectionStrategies, strategy) {
+		return ErrUnknownCoinSelection
+	}
+	ts.coinSelection = strategy
+	return nil
+}
 
-	var available []blockchain.UTXO
-	for _, utxo := range ts.bc.UTXOs {
-		if utxo.Owner == walletID && !utxo.Spent {
-			available = append(available, utxo)
+// CoinSelection returns the strategy SelectUTXOs uses
+func (ts *TransactionService) CoinSelection() string {
+	return ts.coinSelection
+}
+
+// SetKYC caps what wallets without verified KYC may send per day
+func (ts *TransactionService) SetKYC(kyc *KYCService) {
+	ts.kyc = kyc
+}
+
+// SetAssets lets transfers move the assets the registry defines besides the
+// coin
+func (ts *TransactionService) SetAssets(assets *AssetService) {
+	ts.assets = assets
+}
+
+// SentToday is what a wallet has sent since UTC midnight, fees included,
+// counting pending transactions
+func (ts *TransactionService) SentToday(walletID string) uint64 {
+	return ts.SentSince(walletID, startOfDayUTC(time.Now()))
+}
+
+// SentSince is what a wallet has sent since a time, fees included, counting
+// pending transactions
+func (ts *TransactionService) SentSince(walletID string, since time.Time) uint64 {
+	var sent uint64
+	ts.bc.View(func(v blockchain.View) { sent = sentSince(v, walletID, since) })
+	return sent
+}
+
+func startOfDayUTC(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// sentSince sums the amounts and fees of a wallet's own transactions, mined
+// or pending, since a time. System-issued zakat deductions, consolidations
+// back to the wallet itself and key rotation sweeps are not sends.
+func sentSince(v blockchain.View, walletID string, since time.Time) uint64 {
+	cutoff := since.Unix()
+	var sent uint64
+	count := func(tx blockchain.Transaction) {
+		if tx.SenderID == walletID && tx.Timestamp >= cutoff && tx.AssetID == "" && blockchain.LaneOf(tx) != blockchain.LaneSystem && tx.SenderID != tx.ReceiverID && tx.Type != "key_rotation" {
+			sent += tx.Amount + tx.Fee
+		}
+	}
+	for i := v.Height(); i >= 0 && v.Block(i).Timestamp >= cutoff; i-- {
+		for _, tx := range v.Block(i).Transactions {
+			count(tx)
 		}
 	}
+	for tx := range v.Pending() {
+		count(tx)
+	}
+	return sent
+}
 
-	// Sort by amount descending for greedy selection
-	sort.Slice(available, func(i, j int) bool {
-		return available[i].Amount > available[j].Amount
-	})
+// SetConfig charges fees from the sender's organization fee schedule instead
+// of the deployment defaults
+func (ts *TransactionService) SetConfig(config *ConfigCascade) {
+	ts.config = config
+}
 
-	var selected []blockchain.UTXO
-	var total uint64 = 0
+// Fees returns the fee schedule that applies to a sender
+func (ts *TransactionService) Fees(senderID string) FeeSchedule {
+	if ts.config == nil {
+		return DefaultTenantConfig().Fees
+	}
+	return ts.config.ForWallet(senderID).Fees
+}
+
+// SelectUTXOs selects UTXOs for a transaction with the service's coin
+// selection strategy
+func (ts *TransactionService) SelectUTXOs(walletID string, amount uint64) ([]blockchain.UTXO, uint64, error) {
+	return ts.SelectUTXOsWith(walletID, amount, ts.coinSelection)
+}
 
-	for _, utxo := range available {
-		if total >= amount {
-			break
+// SelectUTXOsWith selects UTXOs for a transaction with the given strategy
+func (ts *TransactionService) SelectUTXOsWith(walletID string, amount uint64, strategy string) ([]blockchain.UTXO, uint64, error) {
+	return ts.selectAssetUTXOs(walletID, "", amount, strategy)
+}
+
+// SelectAssetUTXOs selects UTXOs of an asset, the coin for an empty asset ID,
+// with the service's coin selection strategy
+func (ts *TransactionService) SelectAssetUTXOs(walletID, assetID string, amount uint64) ([]blockchain.UTXO, uint64, error) {
+	return ts.selectAssetUTXOs(walletID, assetID, amount, ts.coinSelection)
+}
+
+func (ts *TransactionService) selectAssetUTXOs(walletID, assetID string, amount uint64, strategy string) ([]blockchain.UTXO, uint64, error) {
+	var available []blockchain.UTXO
+	ts.bc.View(func(v blockchain.View) {
+		for _, utxo := range v.OwnedAssetUTXOs(walletID, assetID) {
+			if v.Spendable(utxo) {
+				available = append(available, utxo)
+			}
 		}
-		selected = append(selected, utxo)
-		total += utxo.Amount
+	})
+
+	return SelectCoins(available, amount, strategy)
+}
+
+// CreateTransaction creates a properly structured transaction with UTXOs
+func (ts *TransactionService) CreateTransaction(ctx context.Context, senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	return ts.CreateAssetTransaction(ctx, senderID, receiverID, "", amount, note, pubKey, privKey)
+}
+
+// CreateAssetTransaction is CreateTransaction for an asset; the empty asset
+// ID is the coin
+func (ts *TransactionService) CreateAssetTransaction(ctx context.Context, senderID, receiverID, assetID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	tx, _, err := ts.PrepareAssetTransaction(ctx, senderID, receiverID, assetID, amount, note)
+	if err != nil {
+		return nil, err
+	}
+	tx.PubKey = pubKey
+	blockchain.AssignID(tx)
+
+	signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
+	tx.Signature = signature
+	return tx, nil
+}
 
-	if total < amount {
-		return nil, 0, errors.New("insufficient balance")
+// CreateDonation creates a signed transfer of type donation. Its note names
+// the campaign it counts towards; see DonationNote.
+func (ts *TransactionService) CreateDonation(ctx context.Context, senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	tx, _, err := ts.PrepareTransaction(ctx, senderID, receiverID, amount, note)
+	if err != nil {
+		return nil, err
+	}
+	tx.Type = "donation"
+	tx.PubKey = pubKey
+	blockchain.AssignID(tx)
+
+	signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
+	tx.Signature = signature
+	return tx, nil
+}
 
-	return selected, total, nil
+// PrepareTransaction builds an unsigned transfer: UTXOs are selected and the
+// outputs laid out, but nothing is reserved. The sender signs SigningPayload,
+// either here or offline, before the transaction can be submitted.
+func (ts *TransactionService) PrepareTransaction(ctx context.Context, senderID, receiverID string, amount uint64, note string) (*blockchain.Transaction, []blockchain.UTXO, error) {
+	return ts.PrepareAssetTransaction(ctx, senderID, receiverID, "", amount, note)
 }
 
-// CreateTransaction creates a properly structured transaction with UTXOs
-func (ts *TransactionService) CreateTransaction(senderID, receiverID string, amount uint64, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+// PrepareAssetTransaction is PrepareTransaction for an asset. Transfers of an
+// asset other than the coin select only that asset's UTXOs, pay no fee and
+// are TxVersionAsset, so the signature covers the asset ID.
+func (ts *TransactionService) PrepareAssetTransaction(ctx context.Context, senderID, receiverID, assetID string, amount uint64, note string) (*blockchain.Transaction, []blockchain.UTXO, error) {
+	return ts.prepareTransfer(ctx, senderID, receiverID, assetID, amount, note, blockchain.Lock{})
+}
+
+// prepareTransfer builds the unsigned transfer of PrepareAssetTransaction,
+// paying the receiver under lock. Locked transfers are TxVersionLock. It
+// gives up with ctx's error when ctx is done before the UTXOs are selected.
+func (ts *TransactionService) prepareTransfer(ctx context.Context, senderID, receiverID, assetID string, amount uint64, note string, lock blockchain.Lock) (*blockchain.Transaction, []blockchain.UTXO, error) {
+	if senderID == receiverID {
+		return nil, nil, ErrSelfTransfer
+	}
+	if err := validation.Amount(amount); err != nil {
+		return nil, nil, fmt.Errorf("%w: amount %v", ErrMalformedTransaction, err)
+	}
+	if err := validation.Note(note); err != nil {
+		return nil, nil, fmt.Errorf("%w: note %v", ErrMalformedTransaction, err)
+	}
+
 	// Validate sender wallet exists
-	_, exists := ts.ws.Get(senderID)
+	sender, exists := ts.ws.Get(senderID)
 	if !exists {
-		return nil, errors.New("sender wallet does not exist")
+		return nil, nil, ErrSenderNotFound
 	}
 
 	// Validate receiver wallet exists
 	_, exists = ts.ws.Get(receiverID)
 	if !exists {
-		return nil, errors.New("receiver wallet does not exist")
+		return nil, nil, ErrReceiverNotFound
 	}
 
-	// Select UTXOs
-	selectedUTXOs, total, err := ts.SelectUTXOs(senderID, amount)
+	version, fee := blockchain.TxVersion, ts.Fees(senderID).Transfer
+	if assetID != "" {
+		asset, ok := ts.assets.Get(assetID)
+		if !ok {
+			return nil, nil, ErrUnknownAsset
+		}
+		assetID, version, fee = asset.Symbol, blockchain.TxVersionAsset, 0
+	}
+	if !lock.IsZero() {
+		version = blockchain.TxVersionLock
+	}
+
+	// Select UTXOs covering the amount and the transfer fee. Selection waits
+	// for the chain while a block is mined, so the client may be gone by then.
+	selectedUTXOs, total, err := ts.SelectAssetUTXOs(senderID, assetID, amount+fee)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(selectedUTXOs) > validation.MaxTxInputs {
+		return nil, nil, ErrTooManyInputs
 	}
 
-	// Create transaction ID
-	txID := fmt.Sprintf("tx-%d", time.Now().UnixNano())
 	timestamp := time.Now().Unix()
 
 	// Build inputs
@@ -94,57 +293,229 @@ func (ts *TransactionService) CreateTransaction(senderID, receiverID string, amo
 	outputs = append(outputs, blockchain.UTXO{
 		Owner:    receiverID,
 		Amount:   amount,
-		OriginTx: txID,
 		Index:    0,
 		Spent:    false,
+		Lock:     lock,
 	})
 
 	// Change output to sender
-	change := total - amount
+	change := total - amount - fee
 	if change > 0 {
 		outputs = append(outputs, blockchain.UTXO{
 			Owner:    senderID,
 			Amount:   change,
-			OriginTx: txID,
 			Index:    1,
 			Spent:    false,
 		})
 	}
 
-	// Create signature payload
-	payload := wallet.MarshalPayload(senderID, receiverID, amount, timestamp, note)
-	signature, err := wallet.SignWithPriv(privKey, payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
-	}
-
 	tx := &blockchain.Transaction{
-		ID:         txID,
+		Version:    version,
 		SenderID:   senderID,
 		ReceiverID: receiverID,
+		AssetID:    assetID,
 		Amount:     amount,
+		Fee:        fee,
+		Nonce:      ts.bc.GetNonce(senderID) + 1,
 		Note:       note,
 		Timestamp:  timestamp,
-		PubKey:     pubKey,
-		Signature:  signature,
+		PubKey:     sender.PublicKey,
 		Inputs:     inputs,
 		Outputs:    outputs,
 		Type:       "transfer",
 	}
+	blockchain.AssignID(tx)
 
-	return tx, nil
+	return tx, selectedUTXOs, nil
 }
 
-// ValidateTransaction validates a transaction signature and inputs
-func (ts *TransactionService) ValidateTransaction(tx *blockchain.Transaction) error {
-	// Verify signature
-	payload := wallet.MarshalPayload(tx.SenderID, tx.ReceiverID, tx.Amount, tx.Timestamp, tx.Note)
-	valid, err := wallet.VerifySignature(tx.PubKey, payload, tx.Signature)
-	if err != nil {
-		return fmt.Errorf("signature verification error: %v", err)
+// SigningPayload returns the bytes a transaction's signature covers
+func SigningPayload(tx *blockchain.Transaction) []byte {
+	return blockchain.SigningPayload(*tx)
+}
+
+// AcceptSignedTransaction checks a transfer built and signed outside the
+// server. A version 2 transaction is identified by its content hash, so an ID
+// sent along with it must match; legacy transactions get a server-assigned
+// ID. Legacy transactions are still
+// accepted, but their signature covers sender, receiver, amount, timestamp and
+// note only, so for every version the outputs must pay exactly the amount to
+// the receiver, under a lock if the transaction is TxVersionLock, and the rest
+// back to the sender, and the fee must be the one the sender's fee schedule
+// charges.
+func (ts *TransactionService) AcceptSignedTransaction(ctx context.Context, tx *blockchain.Transaction, now time.Time) (*blockchain.Transaction, error) {
+	if _, exists := ts.ws.Get(tx.SenderID); !exists {
+		return nil, ErrSenderNotFound
+	}
+	if _, exists := ts.ws.Get(tx.ReceiverID); !exists {
+		return nil, ErrReceiverNotFound
+	}
+	if tx.Type != "" && tx.Type != "transfer" {
+		return nil, fmt.Errorf("%w: only transfer transactions can be submitted", ErrMalformedTransaction)
+	}
+	if tx.SenderID == tx.ReceiverID {
+		return nil, ErrSelfTransfer
+	}
+	if err := validation.Amount(tx.Amount); err != nil {
+		return nil, fmt.Errorf("%w: amount %v", ErrMalformedTransaction, err)
+	}
+	if err := validation.Note(tx.Note); err != nil {
+		return nil, fmt.Errorf("%w: note %v", ErrMalformedTransaction, err)
+	}
+	if tx.AssetID != "" && tx.Fee != 0 {
+		return nil, fmt.Errorf("%w: asset transfers pay no fee", ErrMalformedTransaction)
+	}
+	if fee := ts.Fees(tx.SenderID).Transfer; tx.AssetID == "" && tx.Fee != fee {
+		return nil, fmt.Errorf("%w: fee must be %d as set by the fee schedule", ErrMalformedTransaction, fee)
+	}
+	if len(tx.Inputs) == 0 {
+		return nil, fmt.Errorf("%w: no inputs", ErrMalformedTransaction)
+	}
+	if len(tx.Inputs) > validation.MaxTxInputs {
+		return nil, ErrTooManyInputs
+	}
+	if len(tx.Outputs) == 0 || len(tx.Outputs) > 2 {
+		return nil, fmt.Errorf("%w: expected a receiver output and at most one change output", ErrMalformedTransaction)
+	}
+	if tx.Outputs[0].Owner != tx.ReceiverID || tx.Outputs[0].Amount != tx.Amount {
+		return nil, fmt.Errorf("%w: first output must pay the amount to the receiver", ErrMalformedTransaction)
+	}
+	if len(tx.Outputs) == 2 && (tx.Outputs[1].Owner != tx.SenderID || tx.Outputs[1].Amount == 0 || !tx.Outputs[1].Lock.IsZero()) {
+		return nil, fmt.Errorf("%w: second output must return change to the sender", ErrMalformedTransaction)
+	}
+	total := tx.Fee
+	for i, out := range tx.Outputs {
+		// The change output is the client's too, so it is held to the same limit
+		if err := validation.Amount(out.Amount); err != nil {
+			return nil, fmt.Errorf("%w: output %d amount %v", ErrMalformedTransaction, i, err)
+		}
+		var ok bool
+		if total, ok = blockchain.AddAmounts(total, out.Amount); !ok {
+			return nil, fmt.Errorf("%w: outputs and fee overflow", ErrMalformedTransaction)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		key := blockchain.UTXOKey(in.TxID, in.Index)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: input %s listed twice", ErrMalformedTransaction, key)
+		}
+		seen[key] = true
+	}
+
+	signedAt := time.Unix(tx.Timestamp, 0)
+	if signedAt.Before(now.Add(-SignedTxMaxAge)) || signedAt.After(now.Add(SignedTxFutureSkew)) {
+		return nil, ErrStaleTransaction
 	}
-	if !valid {
-		return errors.New("invalid signature")
+	if ts.signatureSeen(tx.Signature) {
+		return nil, ErrDuplicateTransaction
+	}
+
+	accepted := *tx
+	accepted.Type = "transfer"
+	accepted.Inputs = append([]blockchain.UTXORef(nil), tx.Inputs...)
+	accepted.Outputs = make([]blockchain.UTXO, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		accepted.Outputs[i] = blockchain.UTXO{Owner: out.Owner, Amount: out.Amount, Lock: out.Lock}
+	}
+	if accepted.Version >= blockchain.TxVersion {
+		blockchain.AssignID(&accepted)
+		if tx.ID != "" && tx.ID != accepted.ID {
+			return nil, fmt.Errorf("%w: id %s does not match the content hash %s", ErrMalformedTransaction, tx.ID, accepted.ID)
+		}
+	} else {
+		accepted.ID = fmt.Sprintf("tx-%d", now.UnixNano())
+		for i := range accepted.Outputs {
+			accepted.Outputs[i].OriginTx = accepted.ID
+			accepted.Outputs[i].Index = i
+		}
+	}
+
+	if err := ts.ValidateTransaction(ctx, &accepted); err != nil {
+		return nil, err
+	}
+	return &accepted, nil
+}
+
+// signatureSeen reports whether a pending or confirmed transaction carries the
+// signature, which would make a resubmission a replay
+func (ts *TransactionService) signatureSeen(signature string) bool {
+	for _, tx := range ts.bc.GetPending() {
+		if tx.Signature == signature {
+			return true
+		}
+	}
+	seen := false
+	ts.bc.View(func(v blockchain.View) {
+		for b := range v.Blocks() {
+			for _, tx := range b.Transactions {
+				if tx.Signature == signature {
+					seen = true
+					return
+				}
+			}
+		}
+	})
+	return seen
+}
+
+// ValidateTransaction validates a transaction signature and inputs. It
+// returns ctx's error when ctx is done before the inputs are checked.
+func (ts *TransactionService) ValidateTransaction(ctx context.Context, tx *blockchain.Transaction) error {
+	return ts.validate(ctx, tx, false, false)
+}
+
+// ValidateTransactionOverridingLimits validates like ValidateTransaction but
+// lets the transaction exceed the sender's own spending limits. Callers must
+// first confirm the override, e.g. with an emailed OTP.
+func (ts *TransactionService) ValidateTransactionOverridingLimits(ctx context.Context, tx *blockchain.Transaction) error {
+	return ts.validate(ctx, tx, true, false)
+}
+
+// validate checks a transaction for the pending pool. Unsigned transactions,
+// being simulated, skip only the signature check.
+func (ts *TransactionService) validate(ctx context.Context, tx *blockchain.Transaction, overrideLimits, unsigned bool) error {
+	if tx.Version > blockchain.TxVersionLock {
+		return fmt.Errorf("%w: unknown transaction version %d", ErrMalformedTransaction, tx.Version)
+	}
+	if tx.Version >= blockchain.TxVersion && tx.ID != blockchain.TxID(*tx) {
+		return fmt.Errorf("%w: id does not match the transaction's content hash", ErrMalformedTransaction)
+	}
+	if tx.AssetID != "" {
+		if tx.Version < blockchain.TxVersionAsset {
+			return fmt.Errorf("%w: asset transfers must be version %d or later", ErrMalformedTransaction, blockchain.TxVersionAsset)
+		}
+		if _, ok := ts.assets.Get(tx.AssetID); !ok {
+			return ErrUnknownAsset
+		}
+		if tx.Fee != 0 {
+			return fmt.Errorf("%w: asset transfers pay no fee", ErrMalformedTransaction)
+		}
+	}
+	for _, out := range tx.Outputs {
+		if !out.Lock.IsZero() && tx.Version < blockchain.TxVersionLock {
+			return fmt.Errorf("%w: locked outputs need version %d", ErrMalformedTransaction, blockchain.TxVersionLock)
+		}
+		if err := out.Lock.Check(); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedTransaction, err)
+		}
+	}
+	for _, in := range tx.Inputs {
+		if in.Preimage != "" && tx.Version < blockchain.TxVersionLock {
+			return fmt.Errorf("%w: preimages need version %d", ErrMalformedTransaction, blockchain.TxVersionLock)
+		}
+	}
+
+	// Verify signature
+	if !unsigned {
+		payload := SigningPayload(tx)
+		valid, err := wallet.VerifySignature(tx.PubKey, payload, tx.Signature)
+		if err != nil {
+			return fmt.Errorf("signature verification error: %v", err)
+		}
+		if !valid {
+			return errors.New("invalid signature")
+		}
 	}
 
 	// Verify sender's public key matches wallet
@@ -156,46 +527,441 @@ func (ts *TransactionService) ValidateTransaction(tx *blockchain.Transaction) er
 		return errors.New("public key does not match sender wallet ID")
 	}
 
-	// Verify UTXOs are unspent and owned by sender
-	ts.bc.RLock()
-	defer ts.bc.RUnlock()
+	// Frozen wallets keep receiving but cannot spend
+	if w, ok := ts.ws.Get(tx.SenderID); ok && w.Frozen {
+		return ErrWalletFrozen
+	}
+
+	// Retired and deactivated wallets neither send nor receive, except the
+	// sweep of a rotated key to the wallet that replaced it
+	if w, ok := ts.ws.Get(tx.SenderID); ok && !w.Active() && !(tx.Type == "key_rotation" && w.RotatedTo != "" && tx.ReceiverID == ts.Successor(tx.SenderID)) {
+		return fmt.Errorf("%w: %s is %s", ErrWalletInactive, tx.SenderID, w.Status)
+	}
+	for _, payee := range tx.Payees() {
+		if w, ok := ts.ws.Get(payee); ok && !w.Active() {
+			return fmt.Errorf("%w: %s is %s", ErrWalletInactive, payee, w.Status)
+		}
+	}
+	if tx.ReceiverID == blockchain.BatchReceiver && tx.Amount != batchTotal(tx) {
+		return fmt.Errorf("%w: a batch's amount must be the sum of its payments", ErrMalformedTransaction)
+	}
+
+	// Verify UTXOs are unspent and owned by sender. The view waits while a
+	// block is mined; a client gone by then gets nothing.
+	ts.bc.View(func(v blockchain.View) { err = ts.validateSpend(ctx, v, tx, overrideLimits) })
+	return err
+}
+
+// validateSpend checks a transaction's nonce, inputs and totals against the
+// chain, then the sender's limits
+func (ts *TransactionService) validateSpend(ctx context.Context, v blockchain.View, tx *blockchain.Transaction, overrideLimits bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// A nonce at or below the wallet's last one replays or races an earlier
+	// transaction; legacy transactions do not sign their nonce
+	if tx.Version >= blockchain.TxVersion {
+		if last := v.Nonce(tx.SenderID); tx.Nonce <= last {
+			return fmt.Errorf("%w: nonce %d, the wallet's last nonce is %d", ErrNonceUsed, tx.Nonce, last)
+		}
+	}
 
 	for _, input := range tx.Inputs {
-		utxoKey := fmt.Sprintf("%s:%d", input.TxID, input.Index)
-		utxo, exists := ts.bc.UTXOs[utxoKey]
+		utxoKey := blockchain.UTXOKey(input.TxID, input.Index)
+		utxo, exists := v.UTXO(utxoKey)
 		if !exists {
 			return fmt.Errorf("UTXO %s not found", utxoKey)
 		}
 		if utxo.Spent {
 			return fmt.Errorf("UTXO %s already spent (double-spend attempt)", utxoKey)
 		}
-		if utxo.Owner != tx.SenderID {
+		if err := utxo.Unlock(tx.SenderID, input.Preimage, time.Now().Unix()); errors.Is(err, blockchain.ErrInputNotOwned) {
 			return fmt.Errorf("UTXO %s not owned by sender", utxoKey)
+		} else if err != nil {
+			return fmt.Errorf("UTXO %s: %w", utxoKey, err)
+		}
+		if utxo.AssetID != tx.AssetID {
+			return fmt.Errorf("UTXO %s is not of the transaction's asset", utxoKey)
+		}
+		if !v.Confirmed(utxo) {
+			return fmt.Errorf("UTXO %s has %d confirmations, %d required to spend", utxoKey, v.UTXOConfirmations(utxo), ts.bc.MinConfirmations.Spend)
 		}
 	}
 
-	// Verify input amounts match output amounts
+	// Verify input amounts match output amounts, without letting the sums wrap
 	var inputTotal uint64 = 0
 	for _, input := range tx.Inputs {
-		utxoKey := fmt.Sprintf("%s:%d", input.TxID, input.Index)
-		utxo := ts.bc.UTXOs[utxoKey]
-		inputTotal += utxo.Amount
+		utxoKey := blockchain.UTXOKey(input.TxID, input.Index)
+		utxo, _ := v.UTXO(utxoKey)
+		var ok bool
+		if inputTotal, ok = blockchain.AddAmounts(inputTotal, utxo.Amount); !ok {
+			return fmt.Errorf("input total overflows")
+		}
 	}
 
 	var outputTotal uint64 = 0
-	for _, output := range tx.Outputs {
-		outputTotal += output.Amount
+	for i, output := range tx.Outputs {
+		if err := validation.Amount(output.Amount); err != nil {
+			return fmt.Errorf("%w: output %d amount %v", ErrMalformedTransaction, i, err)
+		}
+		var ok bool
+		if outputTotal, ok = blockchain.AddAmounts(outputTotal, output.Amount); !ok {
+			return fmt.Errorf("output total overflows")
+		}
+	}
+
+	if spent, ok := blockchain.AddAmounts(outputTotal, tx.Fee); !ok || inputTotal != spent {
+		return fmt.Errorf("input total (%d) does not match output total (%d) plus fee (%d)", inputTotal, outputTotal, tx.Fee)
+	}
+
+	// Consolidations and unlocks keep the coins in the wallet, and key
+	// rotations move them to the owner's new wallet, so no send limit applies.
+	// Limits are in coins, so asset transfers are not held to them either.
+	if tx.SenderID == tx.ReceiverID || tx.Type == "key_rotation" || tx.AssetID != "" {
+		return nil
 	}
 
-	if inputTotal != outputTotal {
-		return fmt.Errorf("input total (%d) does not match output total (%d)", inputTotal, outputTotal)
+	if !overrideLimits {
+		if err := ts.checkSpendingLimits(v, tx); err != nil {
+			return err
+		}
+	}
+
+	// Wallets without verified KYC have a daily send cap
+	if ts.kyc != nil && ts.kyc.DailyLimit() > 0 && !ts.kyc.Verified(tx.SenderID) {
+		limit := ts.kyc.DailyLimit()
+		sent := sentSince(v, tx.SenderID, startOfDayUTC(time.Now()))
+		if sent+tx.Amount+tx.Fee > limit {
+			remaining := uint64(0)
+			if sent < limit {
+				remaining = limit - sent
+			}
+			return fmt.Errorf("%w: %d of %d sent today, %d remaining", ErrKYCLimitExceeded, sent, limit, remaining)
+		}
 	}
 
 	return nil
 }
 
-// CreateZakatTransaction creates a system zakat deduction transaction
-func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmount uint64) (*blockchain.Transaction, error) {
+// checkSpendingLimits applies the limits a wallet set for itself: a maximum
+// per transaction and per rolling 24 hours, fees included
+func (ts *TransactionService) checkSpendingLimits(v blockchain.View, tx *blockchain.Transaction) error {
+	w, ok := ts.ws.Get(tx.SenderID)
+	if !ok {
+		return nil
+	}
+	spend := tx.Amount + tx.Fee
+	if w.MaxTxAmount > 0 && spend > w.MaxTxAmount {
+		return fmt.Errorf("%w: %d is above the per-transaction limit of %d", ErrSpendingLimitExceeded, spend, w.MaxTxAmount)
+	}
+	if w.MaxDailyAmount > 0 {
+		sent := sentSince(v, tx.SenderID, time.Now().Add(-24*time.Hour))
+		if sent+spend > w.MaxDailyAmount {
+			return fmt.Errorf("%w: %d sent in the last 24 hours, %d more is above the limit of %d", ErrSpendingLimitExceeded, sent, spend, w.MaxDailyAmount)
+		}
+	}
+	return nil
+}
+
+// ConsolidationCandidates returns a wallet's spendable outputs that no
+// pending transaction spends yet, smallest first. A non-zero below keeps only
+// outputs worth less than it.
+func (ts *TransactionService) ConsolidationCandidates(walletID string, below uint64) []blockchain.UTXO {
+	return ts.assetCandidates(walletID, "", below)
+}
+
+// assetCandidates is ConsolidationCandidates for an asset; the empty asset ID
+// is the coin
+func (ts *TransactionService) assetCandidates(walletID, assetID string, below uint64) []blockchain.UTXO {
+	var candidates []blockchain.UTXO
+	ts.bc.View(func(v blockchain.View) {
+		reserved := make(map[string]bool)
+		for tx := range v.Pending() {
+			for _, in := range tx.Inputs {
+				reserved[blockchain.UTXOKey(in.TxID, in.Index)] = true
+			}
+		}
+		for _, utxo := range v.OwnedAssetUTXOs(walletID, assetID) {
+			if utxo.Spent || reserved[utxo.ID] || !v.Spendable(utxo) {
+				continue
+			}
+			if below > 0 && utxo.Amount >= below {
+				continue
+			}
+			candidates = append(candidates, utxo)
+		}
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Amount != candidates[j].Amount {
+			return candidates[i].Amount < candidates[j].Amount
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	return candidates
+}
+
+// CreateConsolidation builds a signed self-transfer that combines up to
+// maxInputs of the wallet's smallest outputs (those below the given amount,
+// if non-zero) into one. The sender pays the usual transfer fee.
+func (ts *TransactionService) CreateConsolidation(walletID string, maxInputs int, below uint64, pubKey, privKey string) (*blockchain.Transaction, error) {
+	if _, exists := ts.ws.Get(walletID); !exists {
+		return nil, ErrSenderNotFound
+	}
+	if maxInputs <= 0 || maxInputs > validation.MaxTxInputs {
+		maxInputs = validation.MaxTxInputs
+	}
+
+	utxos := ts.ConsolidationCandidates(walletID, below)
+	if len(utxos) > maxInputs {
+		utxos = utxos[:maxInputs]
+	}
+	if len(utxos) < 2 {
+		return nil, ErrNothingToConsolidate
+	}
+
+	fee := ts.Fees(walletID).Transfer
+	tx := buildConsolidation(walletID, utxos, fee, fmt.Sprintf("Consolidation of %d outputs", len(utxos)))
+	if tx.Amount == 0 {
+		return nil, fmt.Errorf("%w: the outputs do not cover the fee of %d", ErrInsufficientBalance, fee)
+	}
+	tx.Nonce = ts.bc.GetNonce(walletID) + 1
+	tx.PubKey = pubKey
+	blockchain.AssignID(tx)
+
+	signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	tx.Signature = signature
+	return tx, nil
+}
+
+// Settled reports whether no pending transaction sends from or to the wallet
+// and all its unspent outputs, of the coin and of every asset, are
+// spendable, so a sweep would move everything it holds. Outputs waiting for
+// their lock time do not count: they cannot move before it however long the
+// wallet waits, and CreateRetiredSweep carries them over once they unlock.
+func (ts *TransactionService) Settled(walletID string) bool {
+	settled := true
+	now := time.Now().Unix()
+	ts.bc.View(func(v blockchain.View) {
+		for tx := range v.Pending() {
+			if tx.SenderID == walletID || tx.Pays(walletID) {
+				settled = false
+				return
+			}
+		}
+		for _, assetID := range append([]string{""}, v.HeldAssets(walletID)...) {
+			for _, utxo := range v.OwnedAssetUTXOs(walletID, assetID) {
+				if !utxo.Spent && !v.Spendable(utxo) && (utxo.HashLock != "" || utxo.LockTime <= now) {
+					settled = false
+					return
+				}
+			}
+		}
+	})
+	return settled
+}
+
+// CreateKeyRotation builds the signed transactions sweeping every output of
+// a wallet to the wallet replacing its key: one for the coin, paying the
+// usual transfer fee, then one fee-free transfer per asset the wallet holds,
+// in asset order with consecutive nonces. It returns none when the wallet
+// holds nothing to sweep.
+func (ts *TransactionService) CreateKeyRotation(walletID, newWalletID, pubKey, privKey string) ([]*blockchain.Transaction, error) {
+	if _, exists := ts.ws.Get(walletID); !exists {
+		return nil, ErrSenderNotFound
+	}
+	if !ts.Settled(walletID) {
+		return nil, ErrWalletBusy
+	}
+
+	var assets []string
+	ts.bc.View(func(v blockchain.View) {
+		assets = v.HeldAssets(walletID)
+	})
+
+	var sweeps []*blockchain.Transaction
+	nonce := ts.bc.GetNonce(walletID)
+	for _, assetID := range append([]string{""}, assets...) {
+		utxos := ts.assetCandidates(walletID, assetID, 0)
+		if len(utxos) == 0 {
+			continue
+		}
+		if len(utxos) > validation.MaxTxInputs {
+			return nil, ErrTooManyInputs
+		}
+
+		var fee uint64
+		if assetID == "" {
+			fee = ts.Fees(walletID).Transfer
+		}
+		tx := buildConsolidation(walletID, utxos, fee, "Key rotation to "+newWalletID)
+		if tx.Amount == 0 {
+			return nil, fmt.Errorf("%w: the outputs do not cover the fee of %d", ErrInsufficientBalance, fee)
+		}
+		if assetID != "" {
+			tx.Version, tx.AssetID = blockchain.TxVersionAsset, assetID
+		}
+		nonce++
+		tx.ReceiverID = newWalletID
+		tx.Outputs[0].Owner = newWalletID
+		tx.Type = "key_rotation"
+		tx.Nonce = nonce
+		tx.PubKey = pubKey
+		blockchain.AssignID(tx)
+
+		signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+		tx.Signature = signature
+		sweeps = append(sweeps, tx)
+	}
+	return sweeps, nil
+}
+
+// CreateRetiredSweep is CreateKeyRotation for a wallet already retired: it
+// sweeps the outputs that were still time-locked at the rotation, once they
+// unlock, to the newest key of the wallet's lineage
+func (ts *TransactionService) CreateRetiredSweep(walletID, pubKey, privKey string) ([]*blockchain.Transaction, error) {
+	w, exists := ts.ws.Get(walletID)
+	if !exists {
+		return nil, ErrSenderNotFound
+	}
+	if w.Status != wallet.StatusRetired || w.RotatedTo == "" {
+		return nil, ErrNotRetired
+	}
+	sweeps, err := ts.CreateKeyRotation(walletID, ts.Successor(walletID), pubKey, privKey)
+	if err == nil && len(sweeps) == 0 {
+		err = ErrNothingToSweep
+	}
+	return sweeps, err
+}
+
+// Successor follows a wallet's key rotations forward to the newest key. A
+// wallet never rotated is its own successor.
+func (ts *TransactionService) Successor(walletID string) string {
+	seen := map[string]bool{walletID: true}
+	for {
+		w, ok := ts.ws.Get(walletID)
+		if !ok || w.RotatedTo == "" || seen[w.RotatedTo] {
+			return walletID
+		}
+		walletID = w.RotatedTo
+		seen[walletID] = true
+	}
+}
+
+// CreateDustSweep builds a system consolidation of a wallet's dust outputs.
+// Like zakat it carries no wallet signature; it pays no fee and returns every
+// coin to the wallet.
+func (ts *TransactionService) CreateDustSweep(walletID string, utxos []blockchain.UTXO) *blockchain.Transaction {
+	tx := buildConsolidation(walletID, utxos, 0, fmt.Sprintf("Automatic consolidation of %d dust outputs", len(utxos)))
+	tx.PubKey = "system"
+	tx.Signature = "system"
+	blockchain.AssignID(tx)
+	return tx
+}
+
+// buildConsolidation lays out a self-transfer of utxos less the fee into a
+// single output. Amount is 0 when the fee takes everything. The caller
+// assigns the ID once the transaction is complete.
+func buildConsolidation(walletID string, utxos []blockchain.UTXO, fee uint64, note string) *blockchain.Transaction {
+	var inputs []blockchain.UTXORef
+	var total uint64
+	for _, utxo := range utxos {
+		inputs = append(inputs, blockchain.UTXORef{
+			TxID:  utxo.OriginTx,
+			Index: utxo.Index,
+		})
+		total += utxo.Amount
+	}
+
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersion,
+		SenderID:   walletID,
+		ReceiverID: walletID,
+		Fee:        fee,
+		Note:       note,
+		Timestamp:  time.Now().Unix(),
+		Inputs:     inputs,
+		Type:       "consolidation",
+	}
+	if total > fee {
+		tx.Amount = total - fee
+		tx.Outputs = []blockchain.UTXO{{
+			Owner:    walletID,
+			Amount:   tx.Amount,
+			Index:    0,
+			Spent:    false,
+		}}
+	}
+	return tx
+}
+
+// CreateAnchorTransaction creates a signed transaction recording a document hash
+// on-chain. It transfers no value; the sender only pays the anchor fee.
+func (ts *TransactionService) CreateAnchorTransaction(senderID, docHash, pubKey, privKey string) (*blockchain.Transaction, error) {
+	if _, exists := ts.ws.Get(senderID); !exists {
+		return nil, ErrSenderNotFound
+	}
+
+	fee := ts.Fees(senderID).Anchor
+	selectedUTXOs, total, err := ts.SelectUTXOs(senderID, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	var inputs []blockchain.UTXORef
+	for _, utxo := range selectedUTXOs {
+		inputs = append(inputs, blockchain.UTXORef{
+			TxID:  utxo.OriginTx,
+			Index: utxo.Index,
+		})
+	}
+
+	// Everything except the fee comes back as change
+	var outputs []blockchain.UTXO
+	if change := total - fee; change > 0 {
+		outputs = append(outputs, blockchain.UTXO{
+			Owner:    senderID,
+			Amount:   change,
+			Index:    0,
+			Spent:    false,
+		})
+	}
+
+	// The document hash travels in the note so the signature covers it
+	tx := &blockchain.Transaction{
+		Version:    blockchain.TxVersion,
+		SenderID:   senderID,
+		ReceiverID: blockchain.AnchorReceiver,
+		Amount:     0,
+		Fee:        fee,
+		Nonce:      ts.bc.GetNonce(senderID) + 1,
+		Note:       docHash,
+		Timestamp:  timestamp,
+		PubKey:     pubKey,
+		Inputs:     inputs,
+		Outputs:    outputs,
+		Type:       "anchor",
+	}
+	blockchain.AssignID(tx)
+	signature, err := wallet.SignWithPriv(privKey, SigningPayload(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	tx.Signature = signature
+	return tx, nil
+}
+
+// CreateZakatTransaction creates a system zakat deduction transaction; rate
+// is the one the amount was worked out with, and is shown in the note
+func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmount uint64, rate float64) (*blockchain.Transaction, error) {
 	zakatPoolWallet := "ZAKAT_POOL"
 	
 	// Select UTXOs for zakat
@@ -204,7 +970,6 @@ func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmoun
 		return nil, err
 	}
 
-	txID := fmt.Sprintf("zakat-%d", time.Now().UnixNano())
 	timestamp := time.Now().Unix()
 
 	var inputs []blockchain.UTXORef
@@ -221,7 +986,6 @@ func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmoun
 	outputs = append(outputs, blockchain.UTXO{
 		Owner:    zakatPoolWallet,
 		Amount:   zakatAmount,
-		OriginTx: txID,
 		Index:    0,
 		Spent:    false,
 	})
@@ -232,18 +996,17 @@ func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmoun
 		outputs = append(outputs, blockchain.UTXO{
 			Owner:    walletID,
 			Amount:   change,
-			OriginTx: txID,
 			Index:    1,
 			Spent:    false,
 		})
 	}
 
 	tx := &blockchain.Transaction{
-		ID:         txID,
+		Version:    blockchain.TxVersion,
 		SenderID:   walletID,
 		ReceiverID: zakatPoolWallet,
 		Amount:     zakatAmount,
-		Note:       "Monthly Zakat Deduction (2.5%)",
+		Note:       fmt.Sprintf("Monthly Zakat Deduction (%g%%)", math.Round(rate*zakatRateScale)/(zakatRateScale/100)),
 		Timestamp:  timestamp,
 		PubKey:     "system",
 		Signature:  "system",
@@ -251,6 +1014,7 @@ func (ts *TransactionService) CreateZakatTransaction(walletID string, zakatAmoun
 		Outputs:    outputs,
 		Type:       "zakat_deduction",
 	}
+	blockchain.AssignID(tx)
 
 	return tx, nil
 }
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/crypto"
+	"blockchain-backend/database"
+	"blockchain-backend/otp"
+)
+
+// DefaultTwoFactorThreshold is the send amount above which a TOTP code is
+// required, until the wallet owner sets their own
+const DefaultTwoFactorThreshold = 100 * blockchain.UnitsPerCoin
+
+// Errors returned by the two-factor service
+var (
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrNoEnrollment        = errors.New("no pending two-factor enrollment; call enroll first")
+	ErrInvalidTOTP         = errors.New("invalid or reused authenticator code")
+	ErrTOTPRequired        = errors.New("an authenticator code (totp_code) is required for this amount")
+)
+
+// TwoFactor is a wallet's authenticator app enrollment. The TOTP secret is
+// stored encrypted and never returned after enrollment.
+type TwoFactor struct {
+	WalletID        string     `json:"wallet_id"`
+	SecretEncrypted string     `json:"-"`
+	Enabled         bool       `json:"enabled"`   // false until the first code is verified
+	Threshold       uint64     `json:"threshold"` // sends above this amount need a code
+	CreatedAt       time.Time  `json:"created_at"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	lastStep        uint64     // last accepted time step, so a code works only once
+}
+
+// TwoFactorService manages TOTP enrollment and checks codes on high-value sends
+type TwoFactorService struct {
+	mu               sync.Mutex
+	enrollments      map[string]*TwoFactor // by wallet ID
+	defaultThreshold uint64
+	db               database.TwoFactorRepo
+}
+
+func NewTwoFactorService(defaultThreshold uint64) *TwoFactorService {
+	return &TwoFactorService{
+		enrollments:      make(map[string]*TwoFactor),
+		defaultThreshold: defaultThreshold,
+	}
+}
+
+// SetDatabase enables persistence and reloads enrollments
+func (tfs *TwoFactorService) SetDatabase(db database.TwoFactorRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetTwoFactorEnrollments(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load two-factor enrollments from database: %v", err)
+	}
+
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+	tfs.db = db
+	for _, row := range rows {
+		tf := &TwoFactor{}
+		tf.WalletID, _ = row["wallet_id"].(string)
+		tf.SecretEncrypted, _ = row["secret_encrypted"].(string)
+		tf.Enabled, _ = row["enabled"].(bool)
+		tf.Threshold, _ = row["threshold"].(uint64)
+		tf.CreatedAt, _ = row["created_at"].(time.Time)
+		tf.ConfirmedAt, _ = row["confirmed_at"].(*time.Time)
+		tf.lastStep, _ = row["last_step"].(uint64)
+		tfs.enrollments[tf.WalletID] = tf
+	}
+}
+
+// Get returns a wallet's enrollment
+func (tfs *TwoFactorService) Get(walletID string) (TwoFactor, bool) {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+	tf, ok := tfs.enrollments[walletID]
+	if !ok {
+		return TwoFactor{}, false
+	}
+	return *tf, true
+}
+
+// Enroll starts (or restarts) enrollment and returns the plain secret to show
+// to the user once. 2FA is not enforced until Confirm succeeds.
+func (tfs *TwoFactorService) Enroll(walletID string) (string, error) {
+	secret, err := otp.GenerateTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := crypto.EncryptSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+	if tf, ok := tfs.enrollments[walletID]; ok && tf.Enabled {
+		return "", ErrTwoFactorEnabled
+	}
+	tf := &TwoFactor{
+		WalletID:        walletID,
+		SecretEncrypted: encrypted,
+		Threshold:       tfs.defaultThreshold,
+		CreatedAt:       time.Now(),
+	}
+	tfs.enrollments[walletID] = tf
+	tfs.persist(tf)
+	return secret, nil
+}
+
+// Confirm verifies the first code from the authenticator app and turns 2FA on
+func (tfs *TwoFactorService) Confirm(walletID, code string) (TwoFactor, error) {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+
+	tf, ok := tfs.enrollments[walletID]
+	if !ok {
+		return TwoFactor{}, ErrNoEnrollment
+	}
+	if tf.Enabled {
+		return TwoFactor{}, ErrTwoFactorEnabled
+	}
+	if err := tfs.checkLocked(tf, code); err != nil {
+		return TwoFactor{}, err
+	}
+	now := time.Now()
+	tf.Enabled = true
+	tf.ConfirmedAt = &now
+	tfs.persist(tf)
+	return *tf, nil
+}
+
+// Verify checks a code for an enabled wallet, consuming it
+func (tfs *TwoFactorService) Verify(walletID, code string) error {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+
+	tf, ok := tfs.enrollments[walletID]
+	if !ok || !tf.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := tfs.checkLocked(tf, code); err != nil {
+		return err
+	}
+	tfs.persist(tf)
+	return nil
+}
+
+// RequireForSend returns nil when a send of amount may proceed: 2FA is off,
+// the amount is within the threshold, or code is valid
+func (tfs *TwoFactorService) RequireForSend(walletID string, amount uint64, code string) error {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+
+	tf, ok := tfs.enrollments[walletID]
+	if !ok || !tf.Enabled || amount <= tf.Threshold {
+		return nil
+	}
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	if err := tfs.checkLocked(tf, code); err != nil {
+		return err
+	}
+	tfs.persist(tf)
+	return nil
+}
+
+// SetThreshold changes the amount above which sends need a code; it needs a
+// valid code itself so a stolen private key alone cannot lift the limit
+func (tfs *TwoFactorService) SetThreshold(walletID string, threshold uint64, code string) (TwoFactor, error) {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+
+	tf, ok := tfs.enrollments[walletID]
+	if !ok || !tf.Enabled {
+		return TwoFactor{}, ErrTwoFactorNotEnabled
+	}
+	if err := tfs.checkLocked(tf, code); err != nil {
+		return TwoFactor{}, err
+	}
+	tf.Threshold = threshold
+	tfs.persist(tf)
+	return *tf, nil
+}
+
+// Disable removes the enrollment after checking a code
+func (tfs *TwoFactorService) Disable(walletID, code string) error {
+	tfs.mu.Lock()
+	defer tfs.mu.Unlock()
+
+	tf, ok := tfs.enrollments[walletID]
+	if !ok || !tf.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := tfs.checkLocked(tf, code); err != nil {
+		return err
+	}
+	delete(tfs.enrollments, walletID)
+
+	if tfs.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tfs.db.DeleteTwoFactor(ctx, walletID); err != nil {
+			log.Printf("Failed to delete two-factor enrollment: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkLocked validates code and records its time step; codes from the same
+// or an earlier step are rejected as replays
+func (tfs *TwoFactorService) checkLocked(tf *TwoFactor, code string) error {
+	secret, err := crypto.DecryptSecret(tf.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+	step, ok := otp.ValidateTOTP(secret, code, time.Now())
+	if !ok || step <= tf.lastStep {
+		return ErrInvalidTOTP
+	}
+	tf.lastStep = step
+	return nil
+}
+
+func (tfs *TwoFactorService) persist(tf *TwoFactor) {
+	if tfs.db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tfs.db.SaveTwoFactor(ctx, tf.WalletID, tf.SecretEncrypted, tf.Enabled, tf.Threshold, tf.lastStep, tf.CreatedAt, tf.ConfirmedAt); err != nil {
+		log.Printf("Failed to persist two-factor enrollment: %v", err)
+	}
+}
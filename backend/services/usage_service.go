@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+)
+
+// maxUsageKeys bounds the number of distinct (route, client) counters so
+// arbitrary user agents cannot grow the store without limit
+const maxUsageKeys = 10000
+
+// OtherClient is the client recorded once maxUsageKeys is reached
+const OtherClient = "other"
+
+// UsageKey identifies one endpoint as called by one client
+type UsageKey struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`  // route template, e.g. /api/wallet/{wallet}
+	Client string `json:"client"` // "api_key:<fingerprint>" or "ua:<user agent>"
+}
+
+// Usage counts calls to an endpoint by one client
+type Usage struct {
+	UsageKey
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// UsageService counts API calls per endpoint and client, so deprecated routes
+// can be removed once nobody depends on them
+type UsageService struct {
+	mu      sync.Mutex
+	usage   map[UsageKey]*Usage
+	pending map[UsageKey]int64 // counts not yet flushed to the database
+	db      database.UsageRepo
+	done    chan struct{}
+	loop    sync.WaitGroup // the ticker goroutine; Stop waits for it
+}
+
+func NewUsageService() *UsageService {
+	return &UsageService{
+		usage:   make(map[UsageKey]*Usage),
+		pending: make(map[UsageKey]int64),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetDatabase loads the counts recorded by earlier runs and persists new ones
+// on every flush
+func (us *UsageService) SetDatabase(db database.UsageRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetEndpointUsage(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load API usage from database: %v", err)
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.db = db
+	for _, row := range rows {
+		u := &Usage{}
+		u.Method, _ = row["method"].(string)
+		u.Route, _ = row["route"].(string)
+		u.Client, _ = row["client"].(string)
+		u.Count, _ = row["count"].(int64)
+		u.FirstSeen, _ = row["first_seen"].(time.Time)
+		u.LastSeen, _ = row["last_seen"].(time.Time)
+		if existing, ok := us.usage[u.UsageKey]; ok {
+			u.Count += existing.Count
+			u.FirstSeen = existing.FirstSeen
+			u.LastSeen = existing.LastSeen
+		}
+		us.usage[u.UsageKey] = u
+	}
+}
+
+// Record counts one call
+func (us *UsageService) Record(method, route, client string) {
+	now := time.Now()
+	key := UsageKey{Method: method, Route: route, Client: client}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	u, ok := us.usage[key]
+	if !ok && len(us.usage) >= maxUsageKeys {
+		key.Client = OtherClient
+		u, ok = us.usage[key]
+	}
+	if !ok {
+		u = &Usage{UsageKey: key, FirstSeen: now}
+		us.usage[key] = u
+	}
+	u.Count++
+	u.LastSeen = now
+	us.pending[key]++
+}
+
+// List returns the counters matching filter, most used first. A nil filter
+// matches everything.
+func (us *UsageService) List(filter func(Usage) bool) []Usage {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	list := make([]Usage, 0, len(us.usage))
+	for _, u := range us.usage {
+		if filter == nil || filter(*u) {
+			list = append(list, *u)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].LastSeen.After(list[j].LastSeen)
+	})
+	return list
+}
+
+// Start flushes new counts to the database every interval
+func (us *UsageService) Start(interval time.Duration) {
+	us.loop.Add(1)
+	go func() {
+		defer us.loop.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				us.Flush()
+			case <-us.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop and writes the remaining counts
+func (us *UsageService) Stop() {
+	close(us.done)
+	us.loop.Wait()
+	us.Flush()
+}
+
+// Flush adds the counts recorded since the last flush to the database
+func (us *UsageService) Flush() {
+	us.mu.Lock()
+	db := us.db
+	if db == nil || len(us.pending) == 0 {
+		us.mu.Unlock()
+		return
+	}
+	batch := make([]Usage, 0, len(us.pending))
+	for key, n := range us.pending {
+		u := us.usage[key]
+		batch = append(batch, Usage{UsageKey: key, Count: n, FirstSeen: u.FirstSeen, LastSeen: u.LastSeen})
+	}
+	us.pending = make(map[UsageKey]int64)
+	us.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i, u := range batch {
+		if err := db.AddEndpointUsage(ctx, u.Method, u.Route, u.Client, u.Count, u.FirstSeen, u.LastSeen); err != nil {
+			log.Printf("Failed to persist API usage: %v", err)
+			// Keep the unsaved counts for the next flush
+			us.mu.Lock()
+			for _, rest := range batch[i:] {
+				us.pending[rest.UsageKey] += rest.Count
+			}
+			us.mu.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"blockchain-backend/blockchain"
+)
+
+// A vesting grant pays its total to the beneficiary in one transaction of
+// type vesting, split into equal tranches time-locked one month apart; the
+// last tranche takes the remainder. The chain is the record: schedules are
+// read back from the grant transactions and the state of their outputs.
+
+// MaxVestingTranches caps the tranches of a grant, ten years of months
+const MaxVestingTranches = 120
+
+// ErrInvalidVesting is returned for a grant whose tranches do not fit
+var ErrInvalidVesting = errors.New("invalid vesting grant")
+
+// Tranche states
+const (
+	TrancheLocked   = "locked"
+	TrancheUnlocked = "unlocked"
+	TrancheSpent    = "spent"
+)
+
+// VestingTranche is one time-locked output of a grant
+type VestingTranche struct {
+	UTXOID   string    `json:"utxo_id"`
+	Amount   uint64    `json:"amount"`
+	UnlockAt time.Time `json:"unlock_at"`
+	Status   string    `json:"status"`
+}
+
+// VestingGrant is a grant and where each of its tranches stands
+type VestingGrant struct {
+	TxID        string           `json:"txid"`
+	Grantor     string           `json:"grantor"`
+	Beneficiary string           `json:"beneficiary"`
+	Total       uint64           `json:"total"`
+	Released    uint64           `json:"released"` // unlocked tranches, spent or not
+	Locked      uint64           `json:"locked"`
+	Note        string           `json:"note,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Pending     bool             `json:"pending"` // not mined yet
+	NextUnlock  *time.Time       `json:"next_unlock,omitempty"`
+	Tranches    []VestingTranche `json:"tranches"`
+}
+
+// VestingUnlockTimes returns when each of n monthly tranches unlocks, the
+// first one month after start
+func VestingUnlockTimes(start time.Time, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = start.AddDate(0, i+1, 0)
+	}
+	return times
+}
+
+// CreateVestingGrant creates a signed vesting transaction paying total to the
+// beneficiary in tranches monthly tranches, the first one month after start.
+// Change returns to the grantor unlocked.
+func (ts *TransactionService) CreateVestingGrant(ctx context.Context, grantorID, beneficiaryID string, total uint64, tranches int, start time.Time, note, pubKey, privKey string) (*blockchain.Transaction, error) {
+	switch {
+	case tranches < 1 || tranches > MaxVestingTranches:
+		return nil, fmt.Errorf("%w: tranches must be from 1 to %d", ErrInvalidVesting, MaxVestingTranches)
+	case total < uint64(tranches):
+		return nil, fmt.Errorf("%w: the total cannot pay %d tranches", ErrInvalidVesting, tranches)
+	}
+	unlocks := VestingUnlockTimes(start, tranches)
+	if !unlocks[0].After(time.Now()) {
+		return nil, fmt.Errorf("%w: the first tranche must unlock in the future", ErrLockInPast)
+	}
+
+	tx, _, err := ts.prepareTransfer(ctx, grantorID, beneficiaryID, "", total, note, blockchain.Lock{LockTime: unlocks[0].Unix()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the single receiver output with the tranches, keeping change last
+	each := total / uint64(tranches)
+	outputs := make([]blockchain.UTXO, 0, tranches+1)
+	for i, at := range unlocks {
+		amount := each
+		if i == tranches-1 {
+			amount = total - each*uint64(tranches-1)
+		}
+		outputs = append(outputs, blockchain.UTXO{Owner: beneficiaryID, Amount: amount, Lock: blockchain.Lock{LockTime: at.Unix()}})
+	}
+	tx.Outputs = append(outputs, tx.Outputs[1:]...)
+	tx.Type = "vesting"
+	return ts.sign(tx, pubKey, privKey)
+}
+
+// VestingGrants returns the grants a wallet gave or received, or every grant
+// for an empty wallet ID, newest first
+func (ts *TransactionService) VestingGrants(walletID string) []VestingGrant {
+	now := time.Now()
+	var grants []VestingGrant
+	add := func(v blockchain.View, tx blockchain.Transaction, pending bool) {
+		if tx.Type != "vesting" || (walletID != "" && tx.SenderID != walletID && tx.ReceiverID != walletID) {
+			return
+		}
+		g := VestingGrant{
+			TxID:        tx.ID,
+			Grantor:     tx.SenderID,
+			Beneficiary: tx.ReceiverID,
+			Note:        tx.Note,
+			CreatedAt:   time.Unix(tx.Timestamp, 0).UTC(),
+			Pending:     pending,
+		}
+		for i, out := range tx.Outputs {
+			if out.Owner != tx.ReceiverID || out.LockTime == 0 {
+				continue
+			}
+			t := VestingTranche{UTXOID: blockchain.UTXOKey(tx.ID, i), Amount: out.Amount, UnlockAt: time.Unix(out.LockTime, 0).UTC(), Status: TrancheLocked}
+			if u, ok := v.Output(tx.ID, i); ok && u.Spent {
+				t.Status = TrancheSpent
+			} else if !t.UnlockAt.After(now) {
+				t.Status = TrancheUnlocked
+			}
+			g.Total += t.Amount
+			if t.Status == TrancheLocked {
+				g.Locked += t.Amount
+				if g.NextUnlock == nil {
+					g.NextUnlock = &t.UnlockAt
+				}
+			} else {
+				g.Released += t.Amount
+			}
+			g.Tranches = append(g.Tranches, t)
+		}
+		grants = append(grants, g)
+	}
+	ts.bc.View(func(v blockchain.View) {
+		for b := range v.Blocks() {
+			for _, tx := range b.Transactions {
+				add(v, tx, false)
+			}
+		}
+		for tx := range v.Pending() {
+			add(v, tx, true)
+		}
+	})
+
+	sort.SliceStable(grants, func(i, j int) bool { return grants[i].CreatedAt.After(grants[j].CreatedAt) })
+	return grants
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain-backend/database"
+	"blockchain-backend/wallet"
+)
+
+// Type change request statuses
+const (
+	TypeChangePending  = "pending"
+	TypeChangeApproved = "approved"
+	TypeChangeRejected = "rejected"
+)
+
+// Errors returned by the wallet type service
+var (
+	ErrWalletNotFound       = errors.New("wallet not found")
+	ErrInvalidWalletType    = errors.New("invalid wallet type")
+	ErrTypeUnchanged        = errors.New("wallet already has this type")
+	ErrTypeChangePending    = errors.New("wallet already has a pending type change request")
+	ErrTypeChangeNotFound   = errors.New("type change request not found")
+	ErrTypeChangeDecided    = errors.New("type change request was already decided")
+	ErrSystemTypeRestricted = errors.New("only admins may request the system type")
+)
+
+// TypeChangeRequest asks an admin to move a wallet to a different type
+type TypeChangeRequest struct {
+	ID          int64      `json:"id"`
+	WalletID    string     `json:"wallet_id"`
+	FromType    string     `json:"from_type"`
+	ToType      string     `json:"to_type"`
+	Reason      string     `json:"reason,omitempty"`
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requested_by"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+}
+
+// WalletTypeService gates wallet type changes behind admin approval
+type WalletTypeService struct {
+	mu       sync.Mutex
+	ws       *wallet.Store
+	requests map[int64]*TypeChangeRequest
+	nextID   int64
+	db       database.WalletTypeRepo
+}
+
+func NewWalletTypeService(ws *wallet.Store) *WalletTypeService {
+	return &WalletTypeService{
+		ws:       ws,
+		requests: make(map[int64]*TypeChangeRequest),
+		nextID:   1,
+	}
+}
+
+// SetDatabase enables persistence and reloads previous requests
+func (wts *WalletTypeService) SetDatabase(db database.WalletTypeRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := db.GetWalletTypeRequests(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load wallet type requests from database: %v", err)
+	}
+
+	wts.mu.Lock()
+	defer wts.mu.Unlock()
+	wts.db = db
+	for _, row := range rows {
+		req := &TypeChangeRequest{
+			ID:          row["id"].(int64),
+			WalletID:    row["wallet_id"].(string),
+			FromType:    row["from_type"].(string),
+			ToType:      row["to_type"].(string),
+			Reason:      row["reason"].(string),
+			Status:      row["status"].(string),
+			RequestedBy: row["requested_by"].(string),
+			DecidedBy:   row["decided_by"].(string),
+			CreatedAt:   row["created_at"].(time.Time),
+		}
+		if t, ok := row["decided_at"].(*time.Time); ok {
+			req.DecidedAt = t
+		}
+		wts.requests[req.ID] = req
+		if req.ID >= wts.nextID {
+			wts.nextID = req.ID + 1
+		}
+	}
+}
+
+// RequestChange files a type change for admin review. A wallet may have at
+// most one pending request at a time.
+func (wts *WalletTypeService) RequestChange(walletID, toType, reason, requestedBy string, byAdmin bool) (*TypeChangeRequest, error) {
+	if !wallet.ValidType(toType) {
+		return nil, ErrInvalidWalletType
+	}
+	if toType == wallet.TypeSystem && !byAdmin {
+		return nil, ErrSystemTypeRestricted
+	}
+	w, ok := wts.ws.Get(walletID)
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	if w.TypeOrDefault() == toType {
+		return nil, ErrTypeUnchanged
+	}
+
+	wts.mu.Lock()
+	for _, existing := range wts.requests {
+		if existing.WalletID == walletID && existing.Status == TypeChangePending {
+			wts.mu.Unlock()
+			return nil, ErrTypeChangePending
+		}
+	}
+	req := &TypeChangeRequest{
+		ID:          wts.nextID,
+		WalletID:    walletID,
+		FromType:    w.TypeOrDefault(),
+		ToType:      toType,
+		Reason:      reason,
+		Status:      TypeChangePending,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now(),
+	}
+	wts.nextID++
+	wts.requests[req.ID] = req
+	snapshot := *req
+	wts.mu.Unlock()
+
+	wts.persist(snapshot)
+	return &snapshot, nil
+}
+
+// List returns requests with the given status (empty matches all), newest first
+func (wts *WalletTypeService) List(status string) []TypeChangeRequest {
+	wts.mu.Lock()
+	defer wts.mu.Unlock()
+
+	list := make([]TypeChangeRequest, 0)
+	for _, req := range wts.requests {
+		if status == "" || req.Status == status {
+			list = append(list, *req)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// Approve applies the requested type to the wallet
+func (wts *WalletTypeService) Approve(id int64, actor string) (*TypeChangeRequest, error) {
+	return wts.decide(id, actor, TypeChangeApproved)
+}
+
+// Reject closes the request without changing the wallet
+func (wts *WalletTypeService) Reject(id int64, actor string) (*TypeChangeRequest, error) {
+	return wts.decide(id, actor, TypeChangeRejected)
+}
+
+func (wts *WalletTypeService) decide(id int64, actor, status string) (*TypeChangeRequest, error) {
+	wts.mu.Lock()
+	req, ok := wts.requests[id]
+	if !ok {
+		wts.mu.Unlock()
+		return nil, ErrTypeChangeNotFound
+	}
+	if req.Status != TypeChangePending {
+		wts.mu.Unlock()
+		return nil, ErrTypeChangeDecided
+	}
+	if status == TypeChangeApproved {
+		if err := wts.ws.SetType(req.WalletID, req.ToType); err != nil {
+			wts.mu.Unlock()
+			return nil, err
+		}
+	}
+	now := time.Now()
+	req.Status = status
+	req.DecidedBy = actor
+	req.DecidedAt = &now
+	snapshot := *req
+	db := wts.db
+	wts.mu.Unlock()
+
+	wts.persist(snapshot)
+	if status == TypeChangeApproved && db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.UpdateWalletType(ctx, snapshot.WalletID, snapshot.ToType); err != nil {
+			log.Printf("Failed to persist wallet type for %s: %v", snapshot.WalletID, err)
+		}
+	}
+	return &snapshot, nil
+}
+
+func (wts *WalletTypeService) persist(req TypeChangeRequest) {
+	wts.mu.Lock()
+	db := wts.db
+	wts.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveWalletTypeRequest(ctx, req.ID, req.WalletID, req.FromType, req.ToType, req.Reason, req.Status, req.RequestedBy, req.DecidedBy, req.CreatedAt, req.DecidedAt); err != nil {
+		log.Printf("Failed to persist wallet type request %d: %v", req.ID, err)
+	}
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/crypto"
+	"blockchain-backend/database"
+	"blockchain-backend/events"
+)
+
+// Webhook event types a registration may subscribe to
+const (
+	WebhookTxConfirmed   = "transaction.confirmed"
+	WebhookZakatDeducted = "zakat.deducted"
+	WebhookBlockMined    = "block.mined"
+)
+
+// WebhookEvents lists the event types webhooks can subscribe to
+var WebhookEvents = []string{WebhookTxConfirmed, WebhookZakatDeducted, WebhookBlockMined}
+
+// webhookEventOf maps wallet feed events to the webhook events they trigger
+var webhookEventOf = map[string]string{
+	events.TxConfirmed:   WebhookTxConfirmed,
+	events.ZakatDeducted: WebhookZakatDeducted,
+}
+
+// MaxWebhooksPerWallet bounds how many webhooks one wallet may register
+const MaxWebhooksPerWallet = 10
+
+// Errors returned by the webhook service
+var (
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrWebhookLimit         = fmt.Errorf("a wallet may register at most %d webhooks", MaxWebhooksPerWallet)
+	ErrWebhookURLNotAllowed = errors.New("webhook URL points at a private or loopback address")
+)
+
+// Webhook is a URL a wallet registered to receive events. The signing secret
+// is shown once, when the webhook is created.
+type Webhook struct {
+	ID              int64     `json:"id"`
+	WalletID        string    `json:"wallet_id"`
+	URL             string    `json:"url"`
+	Events          []string  `json:"events"`
+	Active          bool      `json:"active"`
+	SecretEncrypted string    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook. ID is stable across
+// retries, so receivers can ignore duplicates.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	WalletID  string      `json:"wallet_id,omitempty"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// WebhookService keeps webhook registrations and turns feed events and mined
+// blocks into signed deliveries
+type WebhookService struct {
+	mu           sync.RWMutex
+	webhooks     map[int64]*Webhook
+	nextID       int64
+	deliveries   *DeliveryService
+	db           database.WebhookRepo
+	allowPrivate bool
+}
+
+func NewWebhookService(deliveries *DeliveryService, allowPrivate bool) *WebhookService {
+	return &WebhookService{
+		webhooks:     make(map[int64]*Webhook),
+		nextID:       1,
+		deliveries:   deliveries,
+		allowPrivate: allowPrivate,
+	}
+}
+
+// SetDatabase enables persistence and reloads registered webhooks
+func (whs *WebhookService) SetDatabase(db database.WebhookRepo) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	maxID, err := db.GetMaxWebhookID(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load webhook counter from database: %v", err)
+	}
+	rows, err := db.GetWebhooks(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load webhooks from database: %v", err)
+	}
+
+	whs.mu.Lock()
+	defer whs.mu.Unlock()
+	whs.db = db
+	if maxID >= whs.nextID {
+		whs.nextID = maxID + 1
+	}
+	for _, row := range rows {
+		wh := &Webhook{
+			ID:              row["id"].(int64),
+			WalletID:        row["wallet_id"].(string),
+			URL:             row["url"].(string),
+			Events:          row["events"].([]string),
+			Active:          row["active"].(bool),
+			SecretEncrypted: row["secret_encrypted"].(string),
+			CreatedAt:       row["created_at"].(time.Time),
+		}
+		whs.webhooks[wh.ID] = wh
+	}
+}
+
+// Create registers a webhook and returns it with its signing secret
+func (whs *WebhookService) Create(walletID, rawURL string, eventTypes []string) (*Webhook, string, error) {
+	if err := whs.checkURL(rawURL); err != nil {
+		return nil, "", err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	secret := "whsec_" + hex.EncodeToString(buf)
+	encrypted, err := crypto.EncryptSecret(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	whs.mu.Lock()
+	count := 0
+	for _, wh := range whs.webhooks {
+		if wh.WalletID == walletID {
+			count++
+		}
+	}
+	if count >= MaxWebhooksPerWallet {
+		whs.mu.Unlock()
+		return nil, "", ErrWebhookLimit
+	}
+	wh := &Webhook{
+		ID:              whs.nextID,
+		WalletID:        walletID,
+		URL:             rawURL,
+		Events:          eventTypes,
+		Active:          true,
+		SecretEncrypted: encrypted,
+		CreatedAt:       time.Now(),
+	}
+	whs.nextID++
+	whs.webhooks[wh.ID] = wh
+	snapshot := *wh
+	whs.mu.Unlock()
+
+	whs.persist(snapshot)
+	return &snapshot, secret, nil
+}
+
+// Update changes a webhook; an empty URL or event list and a nil active flag
+// keep the current value
+func (whs *WebhookService) Update(id int64, rawURL string, eventTypes []string, active *bool) (*Webhook, error) {
+	if rawURL != "" {
+		if err := whs.checkURL(rawURL); err != nil {
+			return nil, err
+		}
+	}
+
+	whs.mu.Lock()
+	wh, ok := whs.webhooks[id]
+	if !ok {
+		whs.mu.Unlock()
+		return nil, ErrWebhookNotFound
+	}
+	if rawURL != "" {
+		wh.URL = rawURL
+	}
+	if len(eventTypes) > 0 {
+		wh.Events = eventTypes
+	}
+	if active != nil {
+		wh.Active = *active
+	}
+	snapshot := *wh
+	whs.mu.Unlock()
+
+	whs.persist(snapshot)
+	return &snapshot, nil
+}
+
+// Delete removes a webhook. Deliveries still waiting for a retry are
+// abandoned.
+func (whs *WebhookService) Delete(id int64) error {
+	whs.mu.Lock()
+	if _, ok := whs.webhooks[id]; !ok {
+		whs.mu.Unlock()
+		return ErrWebhookNotFound
+	}
+	delete(whs.webhooks, id)
+	db := whs.db
+	whs.mu.Unlock()
+
+	if db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := db.DeleteWebhook(ctx, id); err != nil {
+			log.Printf("Failed to delete webhook %d from database: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Get returns a webhook by ID
+func (whs *WebhookService) Get(id int64) (Webhook, error) {
+	whs.mu.RLock()
+	defer whs.mu.RUnlock()
+	wh, ok := whs.webhooks[id]
+	if !ok {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return *wh, nil
+}
+
+// List returns a wallet's webhooks in creation order
+func (whs *WebhookService) List(walletID string) []Webhook {
+	whs.mu.RLock()
+	defer whs.mu.RUnlock()
+
+	list := make([]Webhook, 0)
+	for _, wh := range whs.webhooks {
+		if wh.WalletID == walletID {
+			list = append(list, *wh)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// OnEvent delivers a wallet feed event to the wallet's subscribed webhooks
+func (whs *WebhookService) OnEvent(ev events.Event) {
+	eventType, ok := webhookEventOf[ev.Type]
+	if !ok {
+		return
+	}
+	whs.dispatch(eventType, ev.WalletID, WebhookPayload{
+		ID:        "evt_" + strconv.FormatUint(ev.Seq, 10),
+		Type:      eventType,
+		WalletID:  ev.WalletID,
+		Data:      ev.Data,
+		CreatedAt: ev.CreatedAt,
+	})
+}
+
+// OnBlock delivers a mined block to every webhook subscribed to block.mined
+func (whs *WebhookService) OnBlock(blk blockchain.Block) {
+	whs.dispatch(WebhookBlockMined, "", WebhookPayload{
+		ID:   "blk_" + blk.Hash,
+		Type: WebhookBlockMined,
+		Data: map[string]interface{}{
+			"index":         blk.Index,
+			"hash":          blk.Hash,
+			"previous_hash": blk.PreviousHash,
+			"timestamp":     blk.Timestamp,
+			"transactions":  len(blk.Transactions),
+		},
+		CreatedAt: time.Now(),
+	})
+}
+
+// dispatch queues the payload for each active webhook subscribed to the
+// event; walletID limits it to that wallet's webhooks when set
+func (whs *WebhookService) dispatch(eventType, walletID string, payload WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("❌ Failed to encode %s webhook payload: %v", eventType, err)
+		return
+	}
+
+	whs.mu.RLock()
+	var targets []Webhook
+	for _, wh := range whs.webhooks {
+		if wh.Active && (walletID == "" || wh.WalletID == walletID) && slices.Contains(wh.Events, eventType) {
+			targets = append(targets, *wh)
+		}
+	}
+	whs.mu.RUnlock()
+
+	for _, wh := range targets {
+		dedupKey := fmt.Sprintf("webhook:%d:%s", wh.ID, payload.ID)
+		whs.deliveries.EnqueueWebhook(wh.ID, wh.URL, eventType, string(body), dedupKey)
+	}
+}
+
+// Sign adds the webhook signature headers to a delivery's request. The
+// signature is HMAC-SHA256 over "<timestamp>.<body>" with the webhook's
+// secret, hex encoded as X-Webhook-Signature: sha256=<hex>.
+func (whs *WebhookService) Sign(d Delivery, req *http.Request) error {
+	whs.mu.RLock()
+	wh, ok := whs.webhooks[d.WebhookID]
+	var encrypted string
+	if ok {
+		encrypted = wh.SecretEncrypted
+	}
+	whs.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: webhook %d was deleted", ErrDeliveryAbandoned, d.WebhookID)
+	}
+	secret, err := crypto.DecryptSecret(encrypted)
+	if err != nil {
+		return fmt.Errorf("%w: cannot decrypt webhook secret: %v", ErrDeliveryAbandoned, err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + d.Payload))
+	req.Header.Set("X-Webhook-ID", strconv.FormatInt(d.WebhookID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// checkURL refuses webhook targets on loopback, private or link-local
+// addresses unless they are explicitly allowed, so registrations cannot be
+// used to probe the server's own network. Names are checked as written;
+// they are not resolved, so Client checks the address actually dialed.
+func (whs *WebhookService) checkURL(rawURL string) error {
+	if whs.allowPrivate {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return ErrWebhookURLNotAllowed
+	}
+	if ip := net.ParseIP(host); ip != nil && internalIP(ip) {
+		return ErrWebhookURLNotAllowed
+	}
+	return nil
+}
+
+// Client returns the HTTP client webhooks are delivered with. Unless private
+// URLs are allowed, it refuses to connect to internal addresses after DNS
+// resolution, so a public name that resolves to one is caught too, and it
+// does not follow redirects: a 3xx response counts as a failed attempt.
+func (whs *WebhookService) Client(timeout time.Duration) *http.Client {
+	if whs.allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookURLNotAllowed, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the target, hiding its address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// internalIP reports addresses on the server's own host or network
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+func (whs *WebhookService) persist(wh Webhook) {
+	whs.mu.RLock()
+	db := whs.db
+	whs.mu.RUnlock()
+	if db == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.SaveWebhook(ctx, wh.ID, wh.WalletID, wh.URL, wh.Events, wh.SecretEncrypted, wh.Active, wh.CreatedAt); err != nil {
+		log.Printf("Failed to persist webhook %d: %v", wh.ID, err)
+	}
+}
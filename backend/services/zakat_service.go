@@ -2,23 +2,69 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
+	"sort"
+	"sync"
 	"time"
 
 	"blockchain-backend/blockchain"
 	"blockchain-backend/database"
+	"blockchain-backend/events"
 	"blockchain-backend/wallet"
 )
 
+// zakatRateScale is the precision zakat rates are applied with: millionths
+const zakatRateScale = 1_000_000
+
 type ZakatService struct {
 	bc              *blockchain.Blockchain
 	ws              *wallet.Store
 	txSvc           *TransactionService
-	db              *database.DB
+	db              database.ZakatRepo
+	feed            *events.Feed
 	ticker          *time.Ticker
 	done            chan bool
+	cancel          context.CancelFunc   // cancels the run in progress; set by Start
+	loop            sync.WaitGroup       // the ticker goroutine; Stop waits for it
+	mu              sync.Mutex           // guards lastProcessed
 	lastProcessed   map[string]time.Time // Track last zakat deduction per wallet
 	nisabThreshold  uint64               // Minimum balance for zakat eligibility
+	config          *ConfigCascade       // per-organization zakat parameters
+	balances        *BalanceService
+	runMu           sync.RWMutex
+	lastRun         *ZakatRun
+	nextRun         time.Time
+	charities       *CharityService
+}
+
+// ZakatRun summarises one pass of the zakat scheduler
+type ZakatRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Eligible   int       `json:"eligible"`
+	Processed  int       `json:"processed"`
+	Failed     int       `json:"failed"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// LastRun returns the most recent zakat run, if any has completed
+func (zs *ZakatService) LastRun() (ZakatRun, bool) {
+	zs.runMu.RLock()
+	defer zs.runMu.RUnlock()
+	if zs.lastRun == nil {
+		return ZakatRun{}, false
+	}
+	return *zs.lastRun, true
+}
+
+// NextRun returns when the scheduler runs next, and false when it is not
+// running. A time in the past means the scheduler missed its tick.
+func (zs *ZakatService) NextRun() (time.Time, bool) {
+	zs.runMu.RLock()
+	defer zs.runMu.RUnlock()
+	return zs.nextRun, !zs.nextRun.IsZero()
 }
 
 func NewZakatService(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *TransactionService) *ZakatService {
@@ -33,21 +79,65 @@ func NewZakatService(bc *blockchain.Blockchain, ws *wallet.Store, txSvc *Transac
 	}
 }
 
-func (zs *ZakatService) SetDatabase(db *database.DB) {
+func (zs *ZakatService) SetDatabase(db database.ZakatRepo) {
 	zs.db = db
 }
 
+// Reset forgets when each wallet last paid, after the chain was reset and
+// its deductions with it
+func (zs *ZakatService) Reset() {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+	zs.lastProcessed = make(map[string]time.Time)
+}
+
+// SetConfig applies each wallet's organization zakat parameters instead of
+// the deployment defaults
+func (zs *ZakatService) SetConfig(config *ConfigCascade) {
+	zs.config = config
+}
+
+// paramsFor returns the zakat rules that apply to a wallet
+func (zs *ZakatService) paramsFor(walletID string) ZakatParams {
+	if zs.config == nil {
+		return ZakatParams{Nisab: zs.nisabThreshold, Rate: blockchain.ZakatRate, IntervalDays: blockchain.ZakatIntervalDays}
+	}
+	return zs.config.ForWallet(walletID).Zakat
+}
+
+// SetBalances recomputes stored balances after each zakat block
+func (zs *ZakatService) SetBalances(balances *BalanceService) {
+	zs.balances = balances
+}
+
+// SetCharities distributes the zakat pool between the registered charities
+// after each run
+func (zs *ZakatService) SetCharities(charities *CharityService) {
+	zs.charities = charities
+}
+
+// SetEventFeed publishes zakat deductions and the resulting block to wallet feeds
+func (zs *ZakatService) SetEventFeed(feed *events.Feed) {
+	zs.feed = feed
+}
+
 // Start begins the zakat scheduler
 func (zs *ZakatService) Start() {
 	// Run monthly - check every 24 hours and process if 30 days have passed
 	// For testing, you can change to 5 * time.Minute
-	zs.ticker = time.NewTicker(24 * time.Hour)
+	zs.ticker = time.NewTicker(ZakatCheckInterval)
+	zs.setNextRun(time.Now().Add(ZakatCheckInterval))
+	ctx, cancel := context.WithCancel(context.Background())
+	zs.cancel = cancel
 	
+	zs.loop.Add(1)
 	go func() {
+		defer zs.loop.Done()
 		for {
 			select {
 			case <-zs.ticker.C:
-				zs.ProcessMonthlyZakat()
+				zs.setNextRun(time.Now().Add(ZakatCheckInterval))
+				zs.ProcessMonthlyZakat(ctx)
 			case <-zs.done:
 				return
 			}
@@ -57,17 +147,154 @@ func (zs *ZakatService) Start() {
 	log.Println("✅ Zakat scheduler started (checks every 24 hours, applies monthly if balance >= 500)")
 }
 
-// Stop stops the zakat scheduler
+// Stop stops the zakat scheduler, cancelling a run in progress and waiting
+// for it to return
 func (zs *ZakatService) Stop() {
 	if zs.ticker != nil {
 		zs.ticker.Stop()
 	}
-	zs.done <- true
+	if zs.cancel != nil {
+		zs.cancel()
+	}
+	close(zs.done)
+	zs.loop.Wait()
+	zs.setNextRun(time.Time{})
 	log.Println("Zakat scheduler stopped")
 }
 
-// ProcessMonthlyZakat processes zakat deduction for all wallets
-func (zs *ZakatService) ProcessMonthlyZakat() {
+// ZakatCheckInterval is how often the scheduler runs
+const ZakatCheckInterval = 24 * time.Hour
+
+// Reasons the zakat run deducts nothing from a wallet
+const (
+	ZakatExemptType   = "exempt wallet type"
+	ZakatWithinPeriod = "deducted within the interval"
+	ZakatBelowNisab   = "balance below nisab"
+)
+
+// ZakatAssessment is what a zakat run deducts from one wallet
+type ZakatAssessment struct {
+	WalletID       string     `json:"wallet_id"`
+	Balance        uint64     `json:"balance"` // spendable
+	Nisab          uint64     `json:"nisab"`
+	Rate           float64    `json:"rate"`
+	Amount         uint64     `json:"amount"`
+	Skipped        string     `json:"skipped,omitempty"` // why nothing is deducted
+	LastDeductedAt *time.Time `json:"last_deducted_at,omitempty"`
+	NextDueAt      *time.Time `json:"next_due_at,omitempty"` // end of the interval since the last deduction
+}
+
+// ZakatPreview is what the next zakat run would deduct, computed without
+// creating any transaction
+type ZakatPreview struct {
+	AssessedAt  time.Time         `json:"assessed_at"` // the time intervals are checked against: the next run, or now
+	NextRunAt   *time.Time        `json:"next_run_at,omitempty"`
+	Wallets     int               `json:"wallets"`
+	Eligible    int               `json:"eligible"` // at or above nisab and outside the interval
+	Due         int               `json:"due"`      // eligible with a non-zero amount
+	Total       uint64            `json:"total"`
+	Assessments []ZakatAssessment `json:"assessments"`
+}
+
+func (zs *ZakatService) setNextRun(t time.Time) {
+	zs.runMu.Lock()
+	zs.nextRun = t
+	zs.runMu.Unlock()
+}
+
+// previewTime returns when the next run happens, or now when the scheduler
+// is not running
+func (zs *ZakatService) previewTime() (time.Time, *time.Time) {
+	zs.runMu.RLock()
+	next := zs.nextRun
+	zs.runMu.RUnlock()
+	now := time.Now()
+	if next.After(now) {
+		return next, &next
+	}
+	return now, nil
+}
+
+// assess works out what a run at the given time deducts from a wallet
+func (zs *ZakatService) assess(w wallet.Wallet, at time.Time) ZakatAssessment {
+	params := zs.paramsFor(w.WalletID)
+	a := ZakatAssessment{WalletID: w.WalletID, Nisab: params.Nisab, Rate: params.Rate}
+
+	// Charities, institutions and system wallets are zakat-exempt
+	if w.ZakatExempt() {
+		a.Skipped = ZakatExemptType
+		return a
+	}
+
+	// Check if required interval has passed since last deduction
+	zs.mu.Lock()
+	lastProcessed, exists := zs.lastProcessed[w.WalletID]
+	zs.mu.Unlock()
+	if exists {
+		due := lastProcessed.AddDate(0, 0, params.IntervalDays)
+		a.LastDeductedAt, a.NextDueAt = &lastProcessed, &due
+		if at.Sub(lastProcessed).Hours()/24 < float64(params.IntervalDays) {
+			a.Skipped = ZakatWithinPeriod
+			return a
+		}
+	}
+
+	// Check Nisab threshold (minimum balance for zakat eligibility)
+	a.Balance = zs.bc.GetBalance(w.WalletID)
+	if a.Balance < params.Nisab {
+		a.Skipped = ZakatBelowNisab
+		return a
+	}
+
+	// Calculate zakat (2.5% unless the organization changed the rate). The
+	// rate is taken in millionths so the amount is worked out in integers.
+	a.Amount = blockchain.MulDiv(a.Balance, uint64(math.Round(params.Rate*zakatRateScale)), zakatRateScale)
+	return a
+}
+
+// Assess returns what the next run would deduct from a wallet
+func (zs *ZakatService) Assess(walletID string) (ZakatAssessment, bool) {
+	w, ok := zs.ws.Get(walletID)
+	if !ok {
+		return ZakatAssessment{}, false
+	}
+	at, _ := zs.previewTime()
+	return zs.assess(w, at), true
+}
+
+// Preview returns what the next run would deduct from every wallet include
+// accepts, without creating any transaction
+func (zs *ZakatService) Preview(include func(walletID string) bool) ZakatPreview {
+	at, next := zs.previewTime()
+	p := ZakatPreview{AssessedAt: at, NextRunAt: next, Assessments: []ZakatAssessment{}}
+	for _, w := range zs.ws.GetAll() {
+		if w.WalletID == ZakatPoolWallet || w.WalletID == "COINBASE" || !include(w.WalletID) {
+			continue
+		}
+		a := zs.assess(w, at)
+		p.Wallets++
+		if a.Skipped == "" {
+			p.Eligible++
+			if a.Amount > 0 {
+				p.Due++
+				p.Total += a.Amount
+			}
+		}
+		p.Assessments = append(p.Assessments, a)
+	}
+	sort.Slice(p.Assessments, func(i, j int) bool {
+		if p.Assessments[i].Amount != p.Assessments[j].Amount {
+			return p.Assessments[i].Amount > p.Assessments[j].Amount
+		}
+		return p.Assessments[i].WalletID < p.Assessments[j].WalletID
+	})
+	return p
+}
+
+// ProcessMonthlyZakat processes zakat deduction for all wallets. When ctx is
+// done it deducts from no further wallet and mines nothing; the deductions
+// already queued are mined with the next block.
+func (zs *ZakatService) ProcessMonthlyZakat(ctx context.Context) {
 	log.Println("🕌 Checking for Zakat eligibility...")
 
 	// Get all wallets
@@ -75,99 +302,157 @@ func (zs *ZakatService) ProcessMonthlyZakat() {
 	now := time.Now()
 	eligibleCount := 0
 	processedCount := 0
+	run := ZakatRun{StartedAt: now}
+	defer func() {
+		run.Eligible, run.Processed, run.FinishedAt = eligibleCount, processedCount, time.Now()
+		zs.runMu.Lock()
+		zs.lastRun = &run
+		zs.runMu.Unlock()
+	}()
 	
 	for _, w := range wallets {
-		// Skip system wallets
-		if w.WalletID == "ZAKAT_POOL" || w.WalletID == "COINBASE" {
-			continue
+		if ctx.Err() != nil {
+			log.Printf("⚠️  Zakat run cancelled after %d wallets: %v", processedCount, ctx.Err())
+			run.LastError = ctx.Err().Error()
+			return
 		}
-
-		// Check if already processed this month
-		lastProcessed, exists := zs.lastProcessed[w.WalletID]
-		if exists {
-			// Check if required interval has passed since last deduction
-			daysSinceLastDeduction := now.Sub(lastProcessed).Hours() / 24
-			if daysSinceLastDeduction < blockchain.ZakatIntervalDays {
-				continue
-			}
+		a := zs.assess(w, now)
+		if a.Skipped == ZakatBelowNisab {
+			log.Printf("Wallet %s balance (%s) is below Nisab threshold (%s), skipping zakat", 
+				w.WalletID[:16], blockchain.FormatAmount(a.Balance), blockchain.FormatAmount(a.Nisab))
 		}
-
-		balance := zs.bc.GetBalance(w.WalletID)
-		
-		// Check Nisab threshold (minimum balance for zakat eligibility)
-		if balance < zs.nisabThreshold {
-			log.Printf("Wallet %s balance (%d) is below Nisab threshold (%d), skipping zakat", 
-				w.WalletID[:16], balance, zs.nisabThreshold)
+		if a.Skipped != "" {
 			continue
 		}
 
 		eligibleCount++
-
-		// Calculate 2.5% zakat
-		zakatAmount := uint64(float64(balance) * blockchain.ZakatRate)
-		if zakatAmount == 0 {
+		if a.Amount == 0 {
 			continue
 		}
+		zakatAmount, balance := a.Amount, a.Balance
 
 		// Create zakat transaction
-		tx, err := zs.txSvc.CreateZakatTransaction(w.WalletID, zakatAmount)
+		tx, err := zs.txSvc.CreateZakatTransaction(w.WalletID, zakatAmount, a.Rate)
 		if err != nil {
 			log.Printf("❌ Failed to create zakat transaction for %s: %v", w.WalletID[:16], err)
+			run.Failed++
+			run.LastError = err.Error()
 			continue
 		}
 
 		// Add to pending transactions
-		zs.bc.AddPending(*tx)
+		if err := zs.bc.AddPending(*tx); err != nil {
+			log.Printf("❌ Zakat transaction for %s rejected: %v", w.WalletID[:16], err)
+			run.Failed++
+			run.LastError = err.Error()
+			continue
+		}
+		if zs.feed != nil {
+			zs.feed.Publish(events.ZakatDeducted, w.WalletID, map[string]interface{}{
+				"txid":    tx.ID,
+				"amount":  zakatAmount,
+				"balance": balance,
+			})
+		}
 		
 		// Update last processed time
+		zs.mu.Lock()
 		zs.lastProcessed[w.WalletID] = now
+		zs.mu.Unlock()
 		
-		// Persist zakat deduction to database
+		// Persist zakat deduction to database; the deduction is queued, so
+		// the record is written even when the run is cancelled
 		if zs.db != nil {
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
 			
-			if err := zs.db.SaveZakatDeduction(ctx, w.WalletID, zakatAmount, int(now.Month()), now.Year(), tx.ID); err != nil {
+			if err := zs.db.SaveZakatDeduction(dbCtx, w.WalletID, zakatAmount, int(now.Month()), now.Year(), tx.ID); err != nil {
 				log.Printf("❌ Failed to save zakat deduction to database for %s: %v", w.WalletID[:16], err)
+				run.Failed++
+				run.LastError = err.Error()
 			}
 			cancel()
 		}
 		
 		processedCount++
-		log.Printf("✅ Zakat deduction created for wallet %s: %d coins (2.5%% of %d)", w.WalletID[:16], zakatAmount, balance)
+		log.Printf("✅ Zakat deduction created for wallet %s: %s coins (%g%% of %s)", w.WalletID[:16], blockchain.FormatAmount(zakatAmount), a.Rate*100, blockchain.FormatAmount(balance))
 	}
 	
 	log.Printf("📊 Zakat summary: %d eligible wallets, %d processed", eligibleCount, processedCount)
 
 	// Mine a block with zakat transactions
 	if len(zs.bc.GetPending()) > 0 {
-		block := zs.bc.Mine(0, "ZAKAT_POOL")
-		log.Printf("Mined zakat block #%d with hash %s, mining reward goes to ZAKAT_POOL", block.Index, block.Hash)
-		
-		// Update wallet balances in database after mining
-		if zs.db != nil {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-			
-			// Collect all affected wallets from the mined block
-			affectedWallets := make(map[string]bool)
-			for _, tx := range block.Transactions {
-				if tx.SenderID != "COINBASE" && tx.SenderID != "" {
-					affectedWallets[tx.SenderID] = true
-				}
-				if tx.ReceiverID != "" {
-					affectedWallets[tx.ReceiverID] = true
-				}
-			}
-			
-			// Update balance for all affected wallets
-			for walletID := range affectedWallets {
-				balance := zs.bc.GetBalance(walletID)
-				if err := zs.db.UpdateWalletBalance(ctx, walletID, balance); err != nil {
-					log.Printf("Failed to update balance in database for %s: %v", walletID, err)
-				} else {
-					log.Printf("Updated database balance for %s: %d coins", walletID, balance)
-				}
+		block, _, err := zs.bc.MineContext(ctx, 0, "ZAKAT_POOL")
+		if errors.Is(err, blockchain.ErrNotProducer) {
+			// Under proof of authority the validators mine them on their turn
+			log.Printf("Zakat transactions wait for the next validator block: %v", err)
+		} else if err != nil {
+			log.Printf("⚠️  Zakat block not mined: %v", err)
+			run.LastError = err.Error()
+			return
+		} else {
+			log.Printf("Mined zakat block #%d with hash %s, mining reward goes to ZAKAT_POOL", block.Index, block.Hash)
+			if zs.feed != nil {
+				zs.feed.PublishBlock(zs.bc, block)
 			}
+			zs.syncBalances(ctx, block)
+		}
+	}
+
+	// Split the collected zakat between the registered charities. The split
+	// is mined here; each charity's transfer goes out with the next block.
+	zs.distribute(ctx)
+}
+
+// distribute queues and mines a split of the zakat pool between the
+// charities, then pays out the splits that are spendable
+func (zs *ZakatService) distribute(ctx context.Context) {
+	if zs.charities == nil {
+		return
+	}
+	d, err := zs.charities.Split()
+	if err != nil {
+		log.Printf("❌ Failed to split the zakat pool between charities: %v", err)
+	} else if d != nil {
+		block, _, err := zs.bc.MineContext(ctx, 0, ZakatPoolWallet)
+		if err != nil {
+			// The split stays pending and is mined with the next block
+			log.Printf("⚠️  Zakat split block for distribution %d not mined: %v", d.ID, err)
+			return
+		}
+		log.Printf("Mined zakat split block #%d for distribution %d", block.Index, d.ID)
+		if zs.feed != nil {
+			zs.feed.PublishBlock(zs.bc, block)
+		}
+		zs.syncBalances(ctx, block)
+	}
+	zs.charities.Pay()
+}
+
+// syncBalances recomputes the stored balance of every wallet a zakat block
+// touched. The block is already on the chain, so cancelling ctx does not stop
+// it.
+func (zs *ZakatService) syncBalances(ctx context.Context, block blockchain.Block) {
+	if zs.db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	// Collect all affected wallets from the mined block
+	affectedWallets := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		if tx.SenderID != "COINBASE" && tx.SenderID != "" {
+			affectedWallets[tx.SenderID] = true
+		}
+		for _, payee := range tx.Payees() {
+			affectedWallets[payee] = true
+		}
+	}
+
+	// Recompute the stored balance of all affected wallets
+	for walletID := range affectedWallets {
+		if err := zs.balances.Sync(ctx, walletID); err != nil {
+			log.Printf("Failed to update balance in database for %s: %v", walletID, err)
 		}
 	}
 }
@@ -0,0 +1,316 @@
+// Package spec states, as code, how this backend serializes, hashes and signs,
+// so that other clients (mobile, JS) can interoperate with it byte for byte.
+// The functions here are reference implementations written from the rules in
+// spec/README.md, independently of the backend code they describe; vectors.json
+// holds test vectors for them, and Check confirms that the reference functions
+// and the backend both reproduce every vector.
+package spec
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Payload is what a transfer or anchor signature covers. Anchors use
+// receiver ANCHOR, amount 0 and the document hash as the note.
+type Payload struct {
+	Sender    string
+	Receiver  string
+	Amount    uint64
+	Timestamp int64
+	Note      string
+}
+
+// CanonicalPayload serializes a payload for signing: a JSON object with the
+// keys amount, note, receiver, sender, timestamp in that order, no whitespace,
+// integers in decimal and strings escaped as described by canonicalString.
+func CanonicalPayload(p Payload) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(`{"amount":`)
+	b.WriteString(strconv.FormatUint(p.Amount, 10))
+	for _, field := range []struct{ key, value string }{
+		{"note", p.Note},
+		{"receiver", p.Receiver},
+		{"sender", p.Sender},
+	} {
+		s, err := canonicalString(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.key, err)
+		}
+		b.WriteString(`,"` + field.key + `":`)
+		b.WriteString(s)
+	}
+	b.WriteString(`,"timestamp":`)
+	b.WriteString(strconv.FormatInt(p.Timestamp, 10))
+	b.WriteString("}")
+	return []byte(b.String()), nil
+}
+
+// canonicalString quotes s. Only `"`, `\`, newline, tab, `<`, `>`, `&`, U+2028
+// and U+2029 are escaped; every other character is written as UTF-8. Text
+// with other control characters or invalid UTF-8 is outside the spec, as the
+// API rejects it.
+func canonicalString(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("not valid UTF-8")
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '<', '>', '&', '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			if unicode.IsControl(r) {
+				return "", fmt.Errorf("control character %U", r)
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
+// WalletID is the first 40 hex digits of SHA-256 over the raw 32-byte public key
+func WalletID(pub ed25519.PublicKey) string {
+	h := sha256.Sum256(pub)
+	return hex.EncodeToString(h[:])[:40]
+}
+
+// MerkleRoot hashes each transaction ID (its UTF-8 bytes) with SHA-256, then
+// repeatedly hashes the concatenated lowercase hex of adjacent pairs. An odd
+// hash at the end of a level is carried up unchanged, not duplicated. A block
+// without transactions has an empty root.
+func MerkleRoot(txIDs []string) string {
+	if len(txIDs) == 0 {
+		return ""
+	}
+	level := make([]string, len(txIDs))
+	for i, id := range txIDs {
+		level[i] = sha256Hex(id)
+	}
+	for len(level) > 1 {
+		var next []string
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, sha256Hex(level[i]+level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// MerkleStep is one step of a merkle proof path: a sibling hash and the side
+// ("left" or "right") it is hashed on
+type MerkleStep struct {
+	Hash string `json:"hash"`
+	Side string `json:"side"`
+}
+
+// VerifyMerkleProof hashes the leaf of txID with each step of path in turn
+// and compares the result with the merkle root
+func VerifyMerkleProof(txID string, path []MerkleStep, root string) bool {
+	h := sha256Hex(txID)
+	for _, step := range path {
+		switch step.Side {
+		case "left":
+			h = sha256Hex(step.Hash + h)
+		case "right":
+			h = sha256Hex(h + step.Hash)
+		default:
+			return false
+		}
+	}
+	return h == root
+}
+
+// BlockHeader is the part of a version 2 or 3 block its hash covers
+type BlockHeader struct {
+	Version      uint32
+	Index        int64
+	Timestamp    int64
+	PreviousHash string
+	MerkleRoot   string
+	Nonce        int64
+}
+
+// EncodeHeader writes version as a big-endian uint32, index and timestamp as
+// big-endian int64s, previous hash and merkle root as strings (see putString)
+// and nonce as a big-endian int64
+func EncodeHeader(h BlockHeader) []byte {
+	b := binary.BigEndian.AppendUint32(nil, h.Version)
+	b = binary.BigEndian.AppendUint64(b, uint64(h.Index))
+	b = binary.BigEndian.AppendUint64(b, uint64(h.Timestamp))
+	b = putString(b, h.PreviousHash)
+	b = putString(b, h.MerkleRoot)
+	return binary.BigEndian.AppendUint64(b, uint64(h.Nonce))
+}
+
+// BlockHash is the lowercase hex SHA-256 of EncodeHeader
+func BlockHash(h BlockHeader) string {
+	sum := sha256.Sum256(EncodeHeader(h))
+	return hex.EncodeToString(sum[:])
+}
+
+// Transaction is a transaction as the binary encoding sees it. Version is 1
+// for legacy transactions and 2 for those signing SigningPayload.
+type Transaction struct {
+	Version   uint32
+	ID        string
+	Type      string
+	Sender    string
+	Receiver  string
+	Amount    uint64
+	Fee       uint64
+	Nonce     uint64
+	Timestamp int64
+	Note      string
+	PubKey    string
+	Signature string
+	Inputs    []Input
+	Outputs   []Output
+}
+
+// Input references the output at Index of transaction TxID
+type Input struct {
+	TxID  string `json:"txid"`
+	Index uint32 `json:"index"`
+}
+
+// Output pays Amount to Owner
+type Output struct {
+	Owner  string `json:"owner"`
+	Amount uint64 `json:"amount"`
+}
+
+// EncodeTransaction writes the version as a big-endian uint32, then id, type,
+// sender, receiver, amount, fee, nonce, timestamp, note, public key and
+// signature, then the inputs and the outputs, each list as a big-endian uint32
+// count followed by its items
+func EncodeTransaction(tx Transaction) []byte {
+	b := binary.BigEndian.AppendUint32(nil, tx.Version)
+	for _, s := range []string{tx.ID, tx.Type, tx.Sender, tx.Receiver} {
+		b = putString(b, s)
+	}
+	b = binary.BigEndian.AppendUint64(b, tx.Amount)
+	b = binary.BigEndian.AppendUint64(b, tx.Fee)
+	b = binary.BigEndian.AppendUint64(b, tx.Nonce)
+	b = binary.BigEndian.AppendUint64(b, uint64(tx.Timestamp))
+	for _, s := range []string{tx.Note, tx.PubKey, tx.Signature} {
+		b = putString(b, s)
+	}
+	return putInputsOutputs(b, tx)
+}
+
+// SigningPayload is what a transaction's signature covers. Version 2 signs
+// the transaction's encoding without the id and signature strings; version 1
+// signs CanonicalPayload of sender, receiver, amount, timestamp and note.
+func SigningPayload(tx Transaction) ([]byte, error) {
+	if tx.Version == 1 {
+		return CanonicalPayload(Payload{Sender: tx.Sender, Receiver: tx.Receiver, Amount: tx.Amount, Timestamp: tx.Timestamp, Note: tx.Note})
+	}
+	b := binary.BigEndian.AppendUint32(nil, tx.Version)
+	for _, s := range []string{tx.Type, tx.Sender, tx.Receiver} {
+		b = putString(b, s)
+	}
+	b = binary.BigEndian.AppendUint64(b, tx.Amount)
+	b = binary.BigEndian.AppendUint64(b, tx.Fee)
+	b = binary.BigEndian.AppendUint64(b, tx.Nonce)
+	b = binary.BigEndian.AppendUint64(b, uint64(tx.Timestamp))
+	b = putString(b, tx.Note)
+	b = putString(b, tx.PubKey)
+	return putInputsOutputs(b, tx), nil
+}
+
+// TxID is a version 2 transaction's ID: the lowercase hex SHA-256 of its
+// SigningPayload. Version 1 transactions carry server-assigned IDs that
+// cannot be recomputed.
+func TxID(tx Transaction) (string, error) {
+	if tx.Version == 1 {
+		return "", fmt.Errorf("version 1 transaction IDs are not derived from content")
+	}
+	payload, err := SigningPayload(tx)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func putInputsOutputs(b []byte, tx Transaction) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		b = putString(b, in.TxID)
+		b = binary.BigEndian.AppendUint32(b, in.Index)
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		b = putString(b, out.Owner)
+		b = binary.BigEndian.AppendUint64(b, out.Amount)
+	}
+	return b
+}
+
+// putString appends the byte length of s as a big-endian uint32, then s
+func putString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// LegacyBlockHeader is the part of a version 1 block its hash covers
+type LegacyBlockHeader struct {
+	Index        int64
+	Timestamp    int64
+	TxIDs        []string
+	PreviousHash string
+	Nonce        int64
+}
+
+// LegacyBlockPreimage joins index, timestamp, the transaction IDs sorted
+// bytewise and joined with commas, previous hash and nonce with "|". The
+// merkle root is not part of it.
+func LegacyBlockPreimage(h LegacyBlockHeader) string {
+	ids := append([]string(nil), h.TxIDs...)
+	sort.Strings(ids)
+	return strings.Join([]string{
+		strconv.FormatInt(h.Index, 10),
+		strconv.FormatInt(h.Timestamp, 10),
+		strings.Join(ids, ","),
+		h.PreviousHash,
+		strconv.FormatInt(h.Nonce, 10),
+	}, "|")
+}
+
+// LegacyBlockHash is the lowercase hex SHA-256 of LegacyBlockPreimage
+func LegacyBlockHash(h LegacyBlockHeader) string {
+	return sha256Hex(LegacyBlockPreimage(h))
+}
+
+// UTXOKey names the output at index of transaction txID
+func UTXOKey(txID string, index int) string {
+	return txID + ":" + strconv.Itoa(index)
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,396 @@
+package spec
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/wallet"
+)
+
+// VectorsVersion changes whenever an existing vector's expected output does,
+// which means the wire format changed and older clients no longer interoperate
+const VectorsVersion = 5
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+// Vectors is the published test vector file. Inputs and expected outputs sit
+// side by side; an implementation computes the outputs from the inputs and
+// compares.
+type Vectors struct {
+	Version  int             `json:"version"`
+	Keys     []KeyVector     `json:"keys"`
+	Payloads []PayloadVector `json:"payloads"`
+	Merkle   []MerkleVector  `json:"merkle_roots"`
+	Blocks   []BlockVector   `json:"blocks"`
+	Txs      []TxVector      `json:"transactions"`
+}
+
+// KeyVector derives a keypair and wallet ID from a 32-byte ed25519 seed.
+// private_key is the 64-byte seed||public form the API accepts.
+type KeyVector struct {
+	Name       string `json:"name"`
+	Seed       string `json:"seed"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+	WalletID   string `json:"wallet_id"`
+}
+
+// PayloadVector serializes a payload and signs it with a key from Keys.
+// Ed25519 signatures are deterministic, so the signature must match exactly.
+type PayloadVector struct {
+	Name       string `json:"name"`
+	Key        string `json:"key"`
+	Sender     string `json:"sender"`
+	Receiver   string `json:"receiver"`
+	Amount     uint64 `json:"amount"`
+	Timestamp  int64  `json:"timestamp"`
+	Note       string `json:"note"`
+	Payload    string `json:"payload"`
+	PayloadHex string `json:"payload_hex"`
+	Signature  string `json:"signature"`
+}
+
+// MerkleVector computes the merkle root of transaction IDs in block order
+type MerkleVector struct {
+	Name  string   `json:"name"`
+	TxIDs []string `json:"tx_ids"`
+	Root  string   `json:"root"`
+}
+
+// BlockVector hashes a block header. Version 2 blocks hash the binary header
+// (preimage_hex), whose merkle root is computed from tx_ids; version 1
+// blocks hash the text preimage.
+type BlockVector struct {
+	Name         string   `json:"name"`
+	Version      uint32   `json:"version"`
+	Index        int64    `json:"index"`
+	Timestamp    int64    `json:"timestamp"`
+	TxIDs        []string `json:"tx_ids"`
+	PreviousHash string   `json:"previous_hash"`
+	Nonce        int64    `json:"nonce"`
+	MerkleRoot   string   `json:"merkle_root,omitempty"`
+	Preimage     string   `json:"preimage,omitempty"`
+	PreimageHex  string   `json:"preimage_hex,omitempty"`
+	Hash         string   `json:"hash"`
+}
+
+// TxVector encodes a transaction. Vectors naming a key from Keys also sign
+// it: signing_payload_hex and signature are then outputs. For version 2 the
+// id is an output too, the hash of the signing payload.
+type TxVector struct {
+	Name      string   `json:"name"`
+	Key       string   `json:"key,omitempty"`
+	Version   uint32   `json:"version"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Sender    string   `json:"sender_id"`
+	Receiver  string   `json:"receiver_id"`
+	Amount    uint64   `json:"amount"`
+	Fee       uint64   `json:"fee"`
+	Nonce     uint64   `json:"nonce"`
+	Timestamp int64    `json:"timestamp"`
+	Note      string   `json:"note"`
+	PubKey    string   `json:"pubkey"`
+	Signature string   `json:"signature"`
+	Inputs    []Input  `json:"inputs"`
+	Outputs   []Output `json:"outputs"`
+
+	SigningPayloadHex string `json:"signing_payload_hex,omitempty"`
+	Encoding          string `json:"encoding_hex"`
+}
+
+func (t TxVector) transaction() Transaction {
+	return Transaction{Version: t.Version, ID: t.ID, Type: t.Type, Sender: t.Sender, Receiver: t.Receiver, Amount: t.Amount, Fee: t.Fee, Nonce: t.Nonce,
+		Timestamp: t.Timestamp, Note: t.Note, PubKey: t.PubKey, Signature: t.Signature, Inputs: t.Inputs, Outputs: t.Outputs}
+}
+
+// backend converts the vector to the backend's transaction type
+func (t TxVector) backend() blockchain.Transaction {
+	tx := blockchain.Transaction{Version: int(t.Version), ID: t.ID, Type: t.Type, SenderID: t.Sender, ReceiverID: t.Receiver, Amount: t.Amount, Fee: t.Fee, Nonce: t.Nonce,
+		Timestamp: t.Timestamp, Note: t.Note, PubKey: t.PubKey, Signature: t.Signature}
+	for _, in := range t.Inputs {
+		tx.Inputs = append(tx.Inputs, blockchain.UTXORef{TxID: in.TxID, Index: int(in.Index)})
+	}
+	for i, out := range t.Outputs {
+		tx.Outputs = append(tx.Outputs, blockchain.UTXO{Owner: out.Owner, Amount: out.Amount, OriginTx: t.ID, Index: i})
+	}
+	return tx
+}
+
+// Mismatch is one output an implementation got wrong
+type Mismatch struct {
+	Impl   string `json:"impl"` // spec (the reference functions) or backend
+	Vector string `json:"vector"`
+	Field  string `json:"field"`
+	Want   string `json:"want"`
+	Got    string `json:"got"`
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s.%s: want %q, got %q", m.Impl, m.Vector, m.Field, m.Want, m.Got)
+}
+
+// Load returns the published vectors
+func Load() (Vectors, error) {
+	var v Vectors
+	if err := json.Unmarshal(vectorsJSON, &v); err != nil {
+		return Vectors{}, fmt.Errorf("vectors.json: %w", err)
+	}
+	return v, nil
+}
+
+// seed derives a key seed from a name, so the vectors need no randomness
+func seed(name string) []byte {
+	h := sha256.Sum256([]byte("wallet-spec-seed:" + name))
+	return h[:]
+}
+
+// Generate computes the vectors from their inputs with the reference
+// functions. Its output, indented, is vectors.json.
+func Generate() (Vectors, error) {
+	v := Vectors{Version: VectorsVersion}
+	keys := map[string]ed25519.PrivateKey{}
+	for _, name := range []string{"alice", "bob"} {
+		priv := ed25519.NewKeyFromSeed(seed(name))
+		pub := priv.Public().(ed25519.PublicKey)
+		keys[name] = priv
+		v.Keys = append(v.Keys, KeyVector{
+			Name:       name,
+			Seed:       hex.EncodeToString(seed(name)),
+			PublicKey:  hex.EncodeToString(pub),
+			PrivateKey: hex.EncodeToString(priv),
+			WalletID:   WalletID(pub),
+		})
+	}
+	alice, bob := v.Keys[0].WalletID, v.Keys[1].WalletID
+
+	payloads := []PayloadVector{
+		{Name: "transfer", Key: "alice", Sender: alice, Receiver: bob, Amount: 250, Timestamp: 1767225600, Note: "Lunch"},
+		{Name: "empty note", Key: "bob", Sender: bob, Receiver: alice, Amount: 1, Timestamp: 1767225601},
+		{Name: "escaped characters", Key: "alice", Sender: alice, Receiver: bob, Amount: 42, Timestamp: 1767225602, Note: "Rent for <March> & \"April\" \\ paid"},
+		{Name: "newline and tab", Key: "alice", Sender: alice, Receiver: bob, Amount: 7, Timestamp: 1767225603, Note: "line one\nline two\tindented"},
+		{Name: "non-ASCII", Key: "bob", Sender: bob, Receiver: alice, Amount: 99, Timestamp: 1767225604, Note: "Zakat ✓ رمضان 日本 😀"},
+		{Name: "line separators", Key: "bob", Sender: bob, Receiver: alice, Amount: 3, Timestamp: 1767225605, Note: "a\u2028b\u2029c"},
+		{Name: "largest amount", Key: "alice", Sender: alice, Receiver: bob, Amount: 1<<53 - 1, Timestamp: 1767225606, Note: "max"},
+		{Name: "anchor", Key: "alice", Sender: alice, Receiver: blockchain.AnchorReceiver, Amount: 0, Timestamp: 1767225607, Note: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+	}
+	for _, p := range payloads {
+		payload, err := CanonicalPayload(Payload{Sender: p.Sender, Receiver: p.Receiver, Amount: p.Amount, Timestamp: p.Timestamp, Note: p.Note})
+		if err != nil {
+			return Vectors{}, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		p.Payload = string(payload)
+		p.PayloadHex = hex.EncodeToString(payload)
+		p.Signature = hex.EncodeToString(ed25519.Sign(keys[p.Key], payload))
+		v.Payloads = append(v.Payloads, p)
+	}
+
+	for _, m := range []MerkleVector{
+		{Name: "empty", TxIDs: []string{}},
+		{Name: "one", TxIDs: []string{"coinbase-1700000000000000000"}},
+		{Name: "two", TxIDs: []string{"coinbase-1700000000000000000", "tx-1700000000000000001"}},
+		{Name: "three, odd hash carried up", TxIDs: []string{"coinbase-1700000000000000000", "tx-1700000000000000001", "tx-1700000000000000002"}},
+		{Name: "five", TxIDs: []string{"coinbase-1700000000000000000", "tx-1700000000000000001", "tx-1700000000000000002", "anchor-1700000000000000003", "zakat-1700000000000000004"}},
+	} {
+		m.Root = MerkleRoot(m.TxIDs)
+		v.Merkle = append(v.Merkle, m)
+	}
+
+	for _, b := range []BlockVector{
+		{Name: "genesis", Version: 2, Index: 0, Timestamp: 1767225600, TxIDs: []string{}, PreviousHash: "0", Nonce: 0},
+		{Name: "mined", Version: 2, Index: 1, Timestamp: 1767225660, TxIDs: []string{"coinbase-1-1767225660", "tx-1700000000000000001"}, PreviousHash: "8a2c1b0e6f3d4a5b9c7e8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e", Nonce: 123456},
+		{Name: "negative timestamp", Version: 2, Index: 7, Timestamp: -1, TxIDs: []string{"coinbase-7--1"}, PreviousHash: "00000abc", Nonce: 1 << 40},
+		{Name: "legacy genesis", Version: 1, Index: 0, Timestamp: 1767225600, TxIDs: []string{}, PreviousHash: "0", Nonce: 0},
+		{Name: "legacy mined", Version: 1, Index: 1, Timestamp: 1767225660, TxIDs: []string{"tx-1700000000000000001", "coinbase-1700000000000000000"}, PreviousHash: "8a2c1b0e6f3d4a5b9c7e8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e", Nonce: 123456},
+		{Name: "legacy unsorted IDs", Version: 1, Index: 2, Timestamp: 1767225720, TxIDs: []string{"zakat-3", "anchor-2", "coinbase-1", "tx-10", "tx-9"}, PreviousHash: "00000abc", Nonce: 987654321},
+	} {
+		if b.Version == 1 {
+			h := LegacyBlockHeader{Index: b.Index, Timestamp: b.Timestamp, TxIDs: b.TxIDs, PreviousHash: b.PreviousHash, Nonce: b.Nonce}
+			b.Preimage = LegacyBlockPreimage(h)
+			b.Hash = LegacyBlockHash(h)
+		} else {
+			b.MerkleRoot = MerkleRoot(b.TxIDs)
+			h := BlockHeader{Version: b.Version, Index: b.Index, Timestamp: b.Timestamp, PreviousHash: b.PreviousHash, MerkleRoot: b.MerkleRoot, Nonce: b.Nonce}
+			b.PreimageHex = hex.EncodeToString(EncodeHeader(h))
+			b.Hash = BlockHash(h)
+		}
+		v.Blocks = append(v.Blocks, b)
+	}
+
+	coinbase := TxVector{Name: "coinbase", Version: 2, Type: "mining_reward", Sender: "COINBASE", Receiver: alice, Amount: 50, Timestamp: 1767225660,
+		Note: "Mining reward for block #1", PubKey: "SYSTEM", Signature: "COINBASE", Inputs: []Input{}, Outputs: []Output{{Owner: alice, Amount: 50}}}
+	coinbaseID, err := TxID(coinbase.transaction())
+	if err != nil {
+		return Vectors{}, err
+	}
+	for _, t := range []TxVector{
+		coinbase,
+		{Name: "transfer with change", Key: "alice", Version: 2, Type: "transfer", Sender: alice, Receiver: bob, Amount: 30, Fee: 1, Nonce: 1, Timestamp: 1767225700,
+			Note: "Zakat ✓ رمضان", PubKey: v.Keys[0].PublicKey,
+			Inputs:  []Input{{TxID: coinbaseID, Index: 0}},
+			Outputs: []Output{{Owner: bob, Amount: 30}, {Owner: alice, Amount: 19}}},
+		{Name: "legacy transfer", Key: "alice", Version: 1, ID: "tx-1700000000000000002", Type: "transfer", Sender: alice, Receiver: bob, Amount: 250, Timestamp: 1767225600,
+			Note: "Lunch", PubKey: v.Keys[0].PublicKey,
+			Inputs:  []Input{{TxID: "coinbase-1-1767225660", Index: 0}},
+			Outputs: []Output{{Owner: bob, Amount: 250}}},
+	} {
+		if t.Key != "" {
+			payload, err := SigningPayload(t.transaction())
+			if err != nil {
+				return Vectors{}, fmt.Errorf("%s: %w", t.Name, err)
+			}
+			t.SigningPayloadHex = hex.EncodeToString(payload)
+			t.Signature = hex.EncodeToString(ed25519.Sign(keys[t.Key], payload))
+		}
+		if t.Version != 1 {
+			id, err := TxID(t.transaction())
+			if err != nil {
+				return Vectors{}, fmt.Errorf("%s: %w", t.Name, err)
+			}
+			t.ID = id
+		}
+		t.Encoding = hex.EncodeToString(EncodeTransaction(t.transaction()))
+		v.Txs = append(v.Txs, t)
+	}
+	return v, nil
+}
+
+// Check recomputes every vector with both the reference functions and the
+// backend's own code and reports each output that differs. Another client can
+// run it on a vectors file it produced by filling in the outputs itself.
+func Check(v Vectors) []Mismatch {
+	var out []Mismatch
+	diff := func(impl, vector, field, want, got string) {
+		if want != got {
+			out = append(out, Mismatch{Impl: impl, Vector: vector, Field: field, Want: want, Got: got})
+		}
+	}
+
+	keys := map[string]string{}
+	for _, k := range v.Keys {
+		s, err := hex.DecodeString(k.Seed)
+		if err != nil || len(s) != ed25519.SeedSize {
+			diff("spec", "keys/"+k.Name, "seed", "32-byte hex seed", k.Seed)
+			continue
+		}
+		priv := ed25519.NewKeyFromSeed(s)
+		pub := priv.Public().(ed25519.PublicKey)
+		keys[k.Name] = hex.EncodeToString(priv)
+		diff("spec", "keys/"+k.Name, "public_key", k.PublicKey, hex.EncodeToString(pub))
+		diff("spec", "keys/"+k.Name, "private_key", k.PrivateKey, hex.EncodeToString(priv))
+		diff("spec", "keys/"+k.Name, "wallet_id", k.WalletID, WalletID(pub))
+		id, _ := wallet.WalletIDFromPub(k.PublicKey)
+		diff("backend", "keys/"+k.Name, "wallet_id", k.WalletID, id)
+	}
+
+	for _, p := range v.Payloads {
+		name := "payloads/" + p.Name
+		payload, err := CanonicalPayload(Payload{Sender: p.Sender, Receiver: p.Receiver, Amount: p.Amount, Timestamp: p.Timestamp, Note: p.Note})
+		if err != nil {
+			diff("spec", name, "payload", p.Payload, err.Error())
+		} else {
+			diff("spec", name, "payload", p.Payload, string(payload))
+			diff("spec", name, "payload_hex", p.PayloadHex, hex.EncodeToString(payload))
+		}
+		backend := wallet.MarshalPayload(p.Sender, p.Receiver, p.Amount, p.Timestamp, p.Note)
+		diff("backend", name, "payload", p.Payload, string(backend))
+
+		priv, ok := keys[p.Key]
+		if !ok {
+			diff("spec", name, "key", "a name from keys", p.Key)
+			continue
+		}
+		sig, err := wallet.SignWithPriv(priv, backend)
+		if err != nil {
+			sig = err.Error()
+		}
+		diff("backend", name, "signature", p.Signature, sig)
+		var pubHex string
+		for _, k := range v.Keys {
+			if k.Name == p.Key {
+				pubHex = k.PublicKey
+			}
+		}
+		if valid, _ := wallet.VerifySignature(pubHex, []byte(p.Payload), p.Signature); !valid {
+			diff("backend", name, "signature", "a signature that verifies", p.Signature)
+		}
+	}
+
+	for _, m := range v.Merkle {
+		diff("spec", "merkle_roots/"+m.Name, "root", m.Root, MerkleRoot(m.TxIDs))
+		txs := make([]blockchain.Transaction, len(m.TxIDs))
+		for i, id := range m.TxIDs {
+			txs[i].ID = id
+		}
+		diff("backend", "merkle_roots/"+m.Name, "root", m.Root, blockchain.MerkleRoot(txs))
+	}
+
+	for _, b := range v.Blocks {
+		name := "blocks/" + b.Name
+		if b.Version == 1 {
+			h := LegacyBlockHeader{Index: b.Index, Timestamp: b.Timestamp, TxIDs: b.TxIDs, PreviousHash: b.PreviousHash, Nonce: b.Nonce}
+			diff("spec", name, "preimage", b.Preimage, LegacyBlockPreimage(h))
+			diff("spec", name, "hash", b.Hash, LegacyBlockHash(h))
+		} else {
+			diff("spec", name, "merkle_root", b.MerkleRoot, MerkleRoot(b.TxIDs))
+			h := BlockHeader{Version: b.Version, Index: b.Index, Timestamp: b.Timestamp, PreviousHash: b.PreviousHash, MerkleRoot: b.MerkleRoot, Nonce: b.Nonce}
+			diff("spec", name, "preimage_hex", b.PreimageHex, hex.EncodeToString(EncodeHeader(h)))
+			diff("spec", name, "hash", b.Hash, BlockHash(h))
+		}
+		blk := blockchain.Block{Version: int(b.Version), Index: b.Index, Timestamp: b.Timestamp, PreviousHash: b.PreviousHash, Nonce: b.Nonce}
+		for _, id := range b.TxIDs {
+			blk.Transactions = append(blk.Transactions, blockchain.Transaction{ID: id})
+		}
+		blk.MerkleRoot = blockchain.MerkleRoot(blk.Transactions)
+		if b.Version != 1 {
+			diff("backend", name, "preimage_hex", b.PreimageHex, hex.EncodeToString(blockchain.EncodeHeader(blk.Header())))
+		}
+		diff("backend", name, "hash", b.Hash, blockchain.HashBlock(blk))
+	}
+
+	for _, t := range v.Txs {
+		name := "transactions/" + t.Name
+		if t.Key != "" {
+			if payload, err := SigningPayload(t.transaction()); err != nil {
+				diff("spec", name, "signing_payload_hex", t.SigningPayloadHex, err.Error())
+			} else {
+				diff("spec", name, "signing_payload_hex", t.SigningPayloadHex, hex.EncodeToString(payload))
+			}
+			payload := blockchain.SigningPayload(t.backend())
+			diff("backend", name, "signing_payload_hex", t.SigningPayloadHex, hex.EncodeToString(payload))
+			if priv, ok := keys[t.Key]; ok {
+				sig, err := wallet.SignWithPriv(priv, payload)
+				if err != nil {
+					sig = err.Error()
+				}
+				diff("backend", name, "signature", t.Signature, sig)
+			} else {
+				diff("spec", name, "key", "a name from keys", t.Key)
+			}
+		}
+		if t.Version != 1 {
+			if id, err := TxID(t.transaction()); err != nil {
+				diff("spec", name, "id", t.ID, err.Error())
+			} else {
+				diff("spec", name, "id", t.ID, id)
+			}
+			diff("backend", name, "id", t.ID, blockchain.TxID(t.backend()))
+		}
+		diff("spec", name, "encoding_hex", t.Encoding, hex.EncodeToString(EncodeTransaction(t.transaction())))
+		encoded := blockchain.EncodeTransaction(t.backend())
+		diff("backend", name, "encoding_hex", t.Encoding, hex.EncodeToString(encoded))
+		decoded, err := blockchain.DecodeTransaction(encoded)
+		if err != nil {
+			diff("backend", name, "decoded", "a transaction", err.Error())
+			continue
+		}
+		diff("backend", name, "decoded", t.Encoding, hex.EncodeToString(blockchain.EncodeTransaction(decoded)))
+	}
+	return out
+}
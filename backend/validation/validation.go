@@ -0,0 +1,226 @@
+// Package validation checks and normalizes the fields of incoming requests.
+// Each check returns an error whose message is written for the client, and
+// Errors collects them per field so a response can report every problem at
+// once instead of the first one found.
+package validation
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on request fields
+const (
+	MaxNoteLength     = 256 // bytes; the note is signed and stored on-chain
+	MaxNameLength     = 100
+	MaxLabelLength    = 64 // wallet labels; matches the label column
+	MaxEmailLength    = 254
+	MaxWalletIDLength = 64
+	MaxTextLength     = 500 // free-text fields such as reasons and relationships
+	MaxURLLength      = 2048
+
+	// MaxAmount keeps amounts exact in JSON clients that use float64 numbers
+	// and leaves headroom so sums of amounts and fees cannot overflow
+	MaxAmount uint64 = 1<<53 - 1
+
+	// MaxTxInputs bounds the size of a transaction
+	MaxTxInputs = 500
+)
+
+var (
+	walletIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	cnicPattern     = regexp.MustCompile(`^(\d{5})-?(\d{7})-?(\d)$`)
+	digitsPattern   = regexp.MustCompile(`^\d{6}$`)
+)
+
+// FieldError is one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors collects field errors. It is an error itself; use Err to get nil
+// when nothing was added.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add records a problem with field
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Check records err, if any, against field
+func (e *Errors) Check(field string, err error) {
+	if err != nil {
+		e.Add(field, err.Error())
+	}
+}
+
+// Required records field as missing when value is blank
+func (e *Errors) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		e.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// Err returns e as an error, or nil when it is empty
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Amount checks a coin amount: positive and at most MaxAmount
+func Amount(amount uint64) error {
+	switch {
+	case amount == 0:
+		return fmt.Errorf("must be positive")
+	case amount > MaxAmount:
+		return fmt.Errorf("must be at most %d", MaxAmount)
+	}
+	return nil
+}
+
+// Note checks a transaction note. Notes are signed as they are, so they are
+// never rewritten, only rejected.
+func Note(note string) error {
+	if len(note) > MaxNoteLength {
+		return fmt.Errorf("must be at most %d bytes", MaxNoteLength)
+	}
+	return Text(note)
+}
+
+// Text checks that free text is valid UTF-8 without control characters
+// other than newlines and tabs
+func Text(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("must be valid UTF-8")
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return fmt.Errorf("must not contain control characters")
+		}
+	}
+	return nil
+}
+
+// Name checks a person's or beneficiary's display name
+func Name(name string) error {
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return fmt.Errorf("must be at most %d characters", MaxNameLength)
+	}
+	if strings.ContainsAny(name, "\n\t") {
+		return fmt.Errorf("must be a single line")
+	}
+	return Text(name)
+}
+
+// Label checks the owner's name for a wallet, such as "Savings"
+func Label(label string) error {
+	if utf8.RuneCountInString(label) > MaxLabelLength {
+		return fmt.Errorf("must be at most %d characters", MaxLabelLength)
+	}
+	if strings.ContainsAny(label, "\n\t") {
+		return fmt.Errorf("must be a single line")
+	}
+	return Text(label)
+}
+
+// Email checks a bare email address (no display name)
+func Email(email string) error {
+	if len(email) > MaxEmailLength {
+		return fmt.Errorf("must be at most %d characters", MaxEmailLength)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return fmt.Errorf("must be a valid email address")
+	}
+	return nil
+}
+
+// CNIC checks a Pakistani national identity card number, with or without
+// dashes, and returns it in the dashed form 12345-1234567-1
+func CNIC(cnic string) (string, error) {
+	m := cnicPattern.FindStringSubmatch(cnic)
+	if m == nil {
+		return "", fmt.Errorf("must be 13 digits, formatted 12345-1234567-1")
+	}
+	return m[1] + "-" + m[2] + "-" + m[3], nil
+}
+
+// WalletID checks the shape of a wallet ID. IDs derived from keys are 40 hex
+// characters; system wallets such as ZAKAT_POOL use names.
+func WalletID(id string) error {
+	if len(id) > MaxWalletIDLength || !walletIDPattern.MatchString(id) {
+		return fmt.Errorf("must be a wallet ID (letters, digits, _ or -, at most %d characters)", MaxWalletIDLength)
+	}
+	return nil
+}
+
+// PublicKey checks a hex-encoded ed25519 public key
+func PublicKey(pubHex string) error {
+	if b, err := hex.DecodeString(pubHex); err != nil || len(b) != 32 {
+		return fmt.Errorf("must be a 64-character hex ed25519 public key")
+	}
+	return nil
+}
+
+// PrivateKey checks a private key given either as 128 hex characters or in
+// the server's encrypted (base64) form
+func PrivateKey(key string) error {
+	if b, err := hex.DecodeString(key); err == nil {
+		if len(b) != 64 {
+			return fmt.Errorf("must be a 128-character hex ed25519 private key")
+		}
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(key); err != nil {
+		return fmt.Errorf("must be a 128-character hex ed25519 private key")
+	}
+	return nil
+}
+
+// URL checks an absolute http or https URL, such as a webhook endpoint
+func URL(raw string) error {
+	if len(raw) > MaxURLLength {
+		return fmt.Errorf("must be at most %d characters", MaxURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("must not contain credentials")
+	}
+	return nil
+}
+
+// Code checks a 6-digit one-time or authenticator code
+func Code(code string) error {
+	if !digitsPattern.MatchString(code) {
+		return fmt.Errorf("must be 6 digits")
+	}
+	return nil
+}
+
+// Clean trims surrounding whitespace; use it on fields that are not signed
+func Clean(s string) string {
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,123 @@
+// Package offline signs transactions away from the node, for air-gapped
+// wallets. A transaction prepared by GET /api/transactions/prepare is carried
+// to the offline machine, signed there with Sign or SignJSON and carried back
+// to POST /api/transactions/submit-signed; the private key never touches a
+// networked machine. Signing follows the node's own signer exactly: the
+// signature covers blockchain.SigningPayload and the ID is blockchain.TxID,
+// both computed with the signer's public key in place.
+//
+// It lives apart from package wallet because it needs the transaction
+// encoding of package blockchain, which itself imports wallet.
+package offline
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"blockchain-backend/blockchain"
+	"blockchain-backend/wallet"
+)
+
+var (
+	// ErrInvalidKey is returned for a private key that is not a hex ed25519 key
+	ErrInvalidKey = errors.New("invalid private key")
+	// ErrWrongKey is returned when the key does not belong to the sender
+	ErrWrongKey = errors.New("private key does not belong to the sender")
+	// ErrAlreadySigned is returned for a transaction that carries a signature
+	ErrAlreadySigned = errors.New("transaction is already signed")
+	// ErrIDMismatch is returned when a transaction's ID is not its content
+	// hash, e.g. because it was changed after it was prepared
+	ErrIDMismatch = errors.New("transaction id does not match its content")
+)
+
+// PublicKey returns the hex public key of a hex ed25519 private key
+func PublicKey(privHex string) (string, error) {
+	priv, err := hex.DecodeString(privHex)
+	if err != nil || len(priv) != ed25519.PrivateKeySize {
+		return "", ErrInvalidKey
+	}
+	return hex.EncodeToString(ed25519.PrivateKey(priv).Public().(ed25519.PublicKey)), nil
+}
+
+// Sign returns tx signed with privHex. The key must be the sender's. A
+// prepared transaction keeps its ID, which is checked against its content;
+// one without an ID gets it assigned. Legacy transactions, which the node
+// identifies itself, are signed as they are.
+func Sign(tx blockchain.Transaction, privHex string) (*blockchain.Transaction, error) {
+	if tx.Signature != "" {
+		return nil, ErrAlreadySigned
+	}
+	pub, err := PublicKey(privHex)
+	if err != nil {
+		return nil, err
+	}
+	if id, _ := wallet.WalletIDFromPub(pub); id != tx.SenderID {
+		return nil, ErrWrongKey
+	}
+	if tx.PubKey != "" && tx.PubKey != pub {
+		return nil, fmt.Errorf("%w: the transaction names another public key", ErrWrongKey)
+	}
+	tx.PubKey = pub
+
+	if tx.Version >= blockchain.TxVersion {
+		prepared := tx.ID
+		blockchain.AssignID(&tx)
+		if prepared != "" && prepared != tx.ID {
+			return nil, ErrIDMismatch
+		}
+	}
+
+	signature, err := wallet.SignWithPriv(privHex, blockchain.SigningPayload(tx))
+	if err != nil {
+		return nil, err
+	}
+	tx.Signature = signature
+	return &tx, nil
+}
+
+// Verify checks a signed transaction's signature against its sender, as the
+// node does before anything else
+func Verify(tx blockchain.Transaction) error {
+	if tx.Version >= blockchain.TxVersion && tx.ID != blockchain.TxID(tx) {
+		return ErrIDMismatch
+	}
+	valid, err := wallet.VerifySignature(tx.PubKey, blockchain.SigningPayload(tx), tx.Signature)
+	if err != nil {
+		return fmt.Errorf("signature verification error: %v", err)
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	if id, err := wallet.WalletIDFromPub(tx.PubKey); err != nil || id != tx.SenderID {
+		return errors.New("public key does not match sender wallet ID")
+	}
+	return nil
+}
+
+// SignJSON signs the transaction in unsigned, which may be a bare
+// transaction or the whole answer of GET /api/transactions/prepare, and
+// returns the body POST /api/transactions/submit-signed takes
+func SignJSON(unsigned []byte, privHex string) ([]byte, error) {
+	var wrapped struct {
+		Transaction *blockchain.Transaction `json:"transaction"`
+	}
+	if err := json.Unmarshal(unsigned, &wrapped); err != nil {
+		return nil, fmt.Errorf("not a transaction: %w", err)
+	}
+	tx := wrapped.Transaction
+	if tx == nil {
+		tx = &blockchain.Transaction{}
+		if err := json.Unmarshal(unsigned, tx); err != nil {
+			return nil, fmt.Errorf("not a transaction: %w", err)
+		}
+	}
+
+	signed, err := Sign(*tx, privHex)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(map[string]*blockchain.Transaction{"transaction": signed}, "", "  ")
+}
@@ -7,10 +7,31 @@ import (
     "encoding/hex"
     "encoding/json"
     "errors"
-    "os"
     "sync"
 )
 
+// Wallet types
+const (
+    TypePersonal    = "personal"
+    TypeMerchant    = "merchant"
+    TypeInstitution = "institution"
+    TypeCharity     = "charity"
+    TypeSystem      = "system"
+)
+
+// Types lists every valid wallet type
+var Types = []string{TypePersonal, TypeMerchant, TypeInstitution, TypeCharity, TypeSystem}
+
+// ValidType reports whether t is a known wallet type
+func ValidType(t string) bool {
+    for _, v := range Types {
+        if v == t {
+            return true
+        }
+    }
+    return false
+}
+
 type Wallet struct {
     WalletID   string `json:"wallet_id"`
     PublicKey  string `json:"public_key"`
@@ -18,6 +39,63 @@ type Wallet struct {
     FullName   string `json:"full_name,omitempty"`
     Email      string `json:"email,omitempty"`
     CNIC       string `json:"cnic,omitempty"`
+    Type       string `json:"type"`
+    OrgID      string `json:"org_id,omitempty"` // owning organization in multi-tenant mode
+    MonthlyStatements bool `json:"monthly_statements"` // email a statement on the 1st of each month
+    Frozen       bool   `json:"frozen,omitempty"` // set by an admin; a frozen wallet can receive but not send
+    FrozenReason string `json:"frozen_reason,omitempty"`
+    MaxTxAmount    uint64 `json:"max_tx_amount,omitempty"`    // per transaction, fee included; 0 for no limit
+    MaxDailyAmount uint64 `json:"max_daily_amount,omitempty"` // per rolling 24 hours, fees included; 0 for no limit
+    Label string `json:"label,omitempty"` // the owner's name for the wallet, e.g. "Savings"
+    Status      string `json:"status,omitempty"`       // StatusActive, StatusRetired or StatusDeactivated; empty is active
+    RotatedFrom string `json:"rotated_from,omitempty"` // the wallet whose key this one replaced
+    RotatedTo   string `json:"rotated_to,omitempty"`   // the wallet that replaced this one's key
+}
+
+// Wallet statuses. A retired wallet's key was rotated to a new wallet, and
+// a deactivated one was closed by its owner; neither sends nor receives, but
+// their history stays queryable.
+const (
+    StatusActive      = "active"
+    StatusRetired     = "retired"
+    StatusDeactivated = "deactivated"
+)
+
+// StatusOrDefault returns the wallet status, treating records saved before
+// statuses existed as active
+func (w Wallet) StatusOrDefault() string {
+    if w.Status == "" {
+        return StatusActive
+    }
+    return w.Status
+}
+
+// Active reports whether the wallet may send and receive
+func (w Wallet) Active() bool {
+    return w.StatusOrDefault() == StatusActive
+}
+
+// TypeOrDefault returns the wallet type, treating records saved before types existed as personal
+func (w Wallet) TypeOrDefault() string {
+    if w.Type == "" {
+        return TypePersonal
+    }
+    return w.Type
+}
+
+// ZakatExempt reports whether zakat is skipped for the wallet. Charities and
+// institutions hold funds on behalf of others, and system wallets are internal.
+func (w Wallet) ZakatExempt() bool {
+    switch w.TypeOrDefault() {
+    case TypeCharity, TypeInstitution, TypeSystem:
+        return true
+    }
+    return false
+}
+
+// FaucetEligible reports whether the wallet may receive faucet coins
+func (w Wallet) FaucetEligible() bool {
+    return w.TypeOrDefault() == TypePersonal && w.Active()
 }
 
 type Store struct {
@@ -35,6 +113,98 @@ func (s *Store) Save(w Wallet) {
     s.wallets[w.WalletID] = w
 }
 
+// Delete removes a stored wallet
+func (s *Store) Delete(walletID string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.wallets, walletID)
+}
+
+// SetType changes the type of a stored wallet
+func (s *Store) SetType(walletID, walletType string) error {
+    if !ValidType(walletType) {
+        return errors.New("invalid wallet type")
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    w.Type = walletType
+    s.wallets[walletID] = w
+    return nil
+}
+
+// SetOrg moves a stored wallet into an organization ("" removes it from one)
+func (s *Store) SetOrg(walletID, orgID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    w.OrgID = orgID
+    s.wallets[walletID] = w
+    return nil
+}
+
+// SetFrozen freezes or unfreezes a stored wallet; unfreezing clears the reason
+func (s *Store) SetFrozen(walletID string, frozen bool, reason string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    if !frozen {
+        reason = ""
+    }
+    w.Frozen, w.FrozenReason = frozen, reason
+    s.wallets[walletID] = w
+    return nil
+}
+
+// SetLimits replaces a stored wallet's spending limits (0 removes a limit)
+func (s *Store) SetLimits(walletID string, maxTx, maxDaily uint64) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    w.MaxTxAmount, w.MaxDailyAmount = maxTx, maxDaily
+    s.wallets[walletID] = w
+    return nil
+}
+
+// SetLabel renames a stored wallet for its owner ("" removes the label)
+func (s *Store) SetLabel(walletID, label string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    w.Label = label
+    s.wallets[walletID] = w
+    return nil
+}
+
+// SetStatus retires or deactivates a stored wallet, recording the wallets a
+// key rotation links it to
+func (s *Store) SetStatus(walletID, status, rotatedFrom, rotatedTo string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    w, ok := s.wallets[walletID]
+    if !ok {
+        return errors.New("wallet not found")
+    }
+    w.Status, w.RotatedFrom, w.RotatedTo = status, rotatedFrom, rotatedTo
+    s.wallets[walletID] = w
+    return nil
+}
+
 func (s *Store) Get(walletID string) (Wallet, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -71,17 +241,14 @@ func (s *Store) CreateFromPub(pubHex, privHex, name, email, cnic string) (Wallet
     if err != nil { return Wallet{}, err }
     
     // Encrypt private key using AES-256
-    encryptionKey := os.Getenv("ENCRYPTION_KEY")
-    if encryptionKey == "" {
-        encryptionKey = "DefaultKey12345678901234567890" // Fallback (32 chars)
-    }
+    encryptionKey := crypto.EncryptionKey()
     
     encryptedPrivKey, err := crypto.EncryptPrivateKey(privHex, encryptionKey)
     if err != nil {
         return Wallet{}, err
     }
     
-    w := Wallet{WalletID: wid, PublicKey: pubHex, PrivateKey: encryptedPrivKey, FullName: name, Email: email, CNIC: cnic}
+    w := Wallet{WalletID: wid, PublicKey: pubHex, PrivateKey: encryptedPrivKey, FullName: name, Email: email, CNIC: cnic, Type: TypePersonal}
     s.Save(w)
     return w, nil
 }
@@ -89,6 +256,7 @@ func (s *Store) CreateFromPub(pubHex, privHex, name, email, cnic string) (Wallet
 func VerifySignature(pubHex string, message []byte, sigHex string) (bool, error) {
     pub, err := hex.DecodeString(pubHex)
     if err != nil { return false, err }
+    if len(pub) != ed25519.PublicKeySize { return false, errors.New("invalid public key size") }
     sig, err := hex.DecodeString(sigHex)
     if err != nil { return false, err }
     ok := ed25519.Verify(pub, message, sig)
@@ -105,10 +273,7 @@ func SignWithPriv(privHex string, payload []byte) (string, error) {
 
 // DecryptPrivateKey decrypts an encrypted private key
 func DecryptPrivateKey(encryptedPrivKey string) (string, error) {
-    encryptionKey := os.Getenv("ENCRYPTION_KEY")
-    if encryptionKey == "" {
-        encryptionKey = "DefaultKey12345678901234567890" // Fallback (32 chars)
-    }
+    encryptionKey := crypto.EncryptionKey()
     return crypto.DecryptPrivateKey(encryptedPrivKey, encryptionKey)
 }
 
@@ -0,0 +1,23 @@
+// Amounts travel as whole numbers of the smallest unit; one coin is
+// 10^DECIMALS units. Responses also carry `decimals`.
+export const DECIMALS = 8;
+const UNITS_PER_COIN = 10n ** BigInt(DECIMALS);
+
+// Shows units as coins, e.g. 150000000 -> "1.5", with thousands separators
+export const formatAmount = (units) => {
+  const n = BigInt(Math.trunc(Number(units) || 0));
+  const whole = (n / UNITS_PER_COIN).toLocaleString();
+  const frac = (n % UNITS_PER_COIN).toString().padStart(DECIMALS, '0').replace(/0+$/, '');
+  return frac ? `${whole}.${frac}` : whole;
+};
+
+// Reads a coin amount typed by the user, e.g. "1.5", as units. Returns NaN
+// for anything that is not a non-negative number with at most DECIMALS places.
+export const parseAmount = (text) => {
+  const match = /^(\d*)(?:\.(\d*))?$/.exec(String(text).trim());
+  if (!match || (match[1] === '' && !match[2]) || (match[2] || '').length > DECIMALS) {
+    return NaN;
+  }
+  const units = BigInt(match[1] || '0') * UNITS_PER_COIN + BigInt((match[2] || '').padEnd(DECIMALS, '0'));
+  return Number(units);
+};
@@ -1,6 +1,21 @@
 // Use environment variable for API base URL, fallback to proxy in development
 const API_BASE = import.meta.env.VITE_API_URL || '/api';
 
+// Turns a {"error":{"code","message","request_id"}} response into an Error
+// carrying the machine-readable code
+const apiError = async (res) => {
+  const text = await res.text();
+  try {
+    const { error } = JSON.parse(text);
+    const err = new Error(`${error.message} (request_id: ${error.request_id})`);
+    err.code = error.code;
+    err.status = res.status;
+    return err;
+  } catch {
+    return new Error(text);
+  }
+};
+
 export const api = {
   // Wallet operations
   generateKeypair: async () => {
@@ -15,8 +30,7 @@ export const api = {
       body: JSON.stringify(data),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -31,6 +45,72 @@ export const api = {
     return res.json();
   },
 
+  // Email directory: lookups only find wallets whose owners opted in
+  resolveEmail: async (email) => {
+    const res = await fetch(`${API_BASE}/resolve`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ email }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getDirectorySetting: async (walletId) => {
+    const res = await fetch(`${API_BASE}/directory?wallet_id=${walletId}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  setDirectorySetting: async (walletId, privateKey, discoverable) => {
+    const res = await fetch(`${API_BASE}/directory`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ wallet_id: walletId, private_key: privateKey, discoverable }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Handles: global @names for wallets, usable as receiver_id
+  getWalletHandle: async (walletId) => {
+    const res = await fetch(`${API_BASE}/wallet/${walletId}/alias`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  claimHandle: async (walletId, privateKey, handle) => {
+    const res = await fetch(`${API_BASE}/alias`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ wallet_id: walletId, private_key: privateKey, handle }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // QR code image URLs, for <img src>; the server renders the payment URI
+  walletQRUrl: (walletId, { amount, note, format = 'svg', size } = {}) => {
+    const params = new URLSearchParams({ format });
+    if (amount) params.set('amount', amount);
+    if (note) params.set('note', note);
+    if (size) params.set('size', size);
+    return `${API_BASE}/wallet/${walletId}/qr?${params}`;
+  },
+
+  invoiceQRUrl: (requestId, format = 'svg') =>
+    `${API_BASE}/invoices/${requestId}/qr?format=${format}`,
+
   // Transaction operations
   sendTransaction: async (data) => {
     const res = await fetch(`${API_BASE}/send`, {
@@ -39,8 +119,73 @@ export const api = {
       body: JSON.stringify(data),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Preview a send (same body, nothing signed or queued): inputs, change, fee, balance_after
+  simulateSend: async (data) => {
+    const res = await fetch(`${API_BASE}/send/simulate`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(data),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Signing sessions: pass signing_token to sendTransaction instead of private_key.
+  // Give totpCode for wallets with 2FA, otherwise a code from sendOTP.
+  createSigningSession: async (walletId, { otpCode, totpCode, spendLimit, ttlSeconds } = {}) => {
+    const res = await fetch(`${API_BASE}/signing-sessions`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({
+        wallet_id: walletId,
+        otp_code: otpCode,
+        totp_code: totpCode,
+        spend_limit: spendLimit,
+        ttl_seconds: ttlSeconds,
+      }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  revokeSigningSession: async (signingToken) => {
+    const res = await fetch(`${API_BASE}/signing-sessions/current`, {
+      method: 'DELETE',
+      headers: { 'X-Signing-Token': signingToken },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Cold wallets: sign the bytes of prepared.signing_payload_hex offline, then submit
+  prepareTransaction: async (senderId, receiverId, amount, note = '') => {
+    const params = new URLSearchParams({ sender_id: senderId, receiver_id: receiverId, amount, note });
+    const res = await fetch(`${API_BASE}/transactions/prepare?${params}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  submitSignedTransaction: async (transaction, totpCode) => {
+    const res = await fetch(`${API_BASE}/transactions/submit-signed`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ transaction, totp_code: totpCode }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -55,7 +200,171 @@ export const api = {
     return res.json();
   },
 
+  getKYC: async (walletId) => {
+    const res = await fetch(`${API_BASE}/kyc/${walletId}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  submitKYC: async (walletId, cnic, documentType, documentRef) => {
+    const res = await fetch(`${API_BASE}/kyc/${walletId}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ cnic, document_type: documentType, document_ref: documentRef }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getLimits: async (walletId) => {
+    const res = await fetch(`${API_BASE}/limits/${walletId}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  setLimits: async (walletId, privateKey, maxTxAmount, maxDailyAmount, otpCode) => {
+    const res = await fetch(`${API_BASE}/limits/${walletId}`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({
+        private_key: privateKey,
+        max_tx_amount: maxTxAmount,
+        max_daily_amount: maxDailyAmount,
+        otp_code: otpCode,
+      }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getFaucetStatus: async (walletId) => {
+    const query = walletId ? `?wallet_id=${encodeURIComponent(walletId)}` : '';
+    const res = await fetch(`${API_BASE}/faucet${query}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Proves the wallet's email with an OTP code, or a login session token
+  claimFaucet: async (walletId, otpCode, sessionToken) => {
+    const headers = { 'Content-Type': 'application/json' };
+    if (sessionToken) {
+      headers.Authorization = `Bearer ${sessionToken}`;
+    }
+    const res = await fetch(`${API_BASE}/faucet/claim`, {
+      method: 'POST',
+      headers,
+      body: JSON.stringify({ wallet_id: walletId, otp_code: otpCode }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getWebhooks: async (walletId) => {
+    const res = await fetch(`${API_BASE}/webhooks?wallet_id=${encodeURIComponent(walletId)}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  createWebhook: async (walletId, privateKey, url, events) => {
+    const res = await fetch(`${API_BASE}/webhooks`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ wallet_id: walletId, private_key: privateKey, url, events }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  deleteWebhook: async (webhookId, privateKey) => {
+    const res = await fetch(`${API_BASE}/webhooks/${webhookId}`, {
+      method: 'DELETE',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ private_key: privateKey }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getWebhookDeliveries: async (webhookId) => {
+    const res = await fetch(`${API_BASE}/webhooks/${webhookId}/deliveries`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getSupply: async (days) => {
+    const query = days !== undefined ? `?days=${days}` : '';
+    const res = await fetch(`${API_BASE}/supply${query}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getMinerLeaderboard: async (by = 'blocks', limit = 10) => {
+    const res = await fetch(`${API_BASE}/miners/leaderboard?by=${by}&limit=${limit}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getMempool: async () => {
+    const res = await fetch(`${API_BASE}/mempool`);
+    return res.json();
+  },
+
+  getTransaction: async (txid) => {
+    const res = await fetch(`${API_BASE}/transaction/${encodeURIComponent(txid)}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getTransactionProof: async (txid) => {
+    const res = await fetch(`${API_BASE}/transaction/${encodeURIComponent(txid)}/proof`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getTransactionStatus: async (txid) => {
+    const res = await fetch(`${API_BASE}/transaction/${encodeURIComponent(txid)}/status`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getMempoolStats: async () => {
+    const res = await fetch(`${API_BASE}/mempool/stats`);
+    return res.json();
+  },
+
   // Blockchain operations
+  // Mining runs as a background job: queue it, then poll the job until the
+  // block is mined
   mine: async (minerWalletId) => {
     const res = await fetch(`${API_BASE}/mine`, {
       method: 'POST',
@@ -63,8 +372,25 @@ export const api = {
       body: JSON.stringify({ miner_wallet_id: minerWalletId }),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
+    }
+    let job = await res.json();
+    while (job.status === 'queued' || job.status === 'running') {
+      await new Promise((resolve) => setTimeout(resolve, 1000));
+      job = await api.getMineJob(job.id);
+    }
+    if (job.status === 'failed') {
+      const err = new Error(`${job.error.message} (request_id: ${job.error.request_id})`);
+      err.code = job.error.code;
+      throw err;
+    }
+    return job.block;
+  },
+
+  getMineJob: async (jobId) => {
+    const res = await fetch(`${API_BASE}/mine/jobs/${jobId}`);
+    if (!res.ok) {
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -74,17 +400,56 @@ export const api = {
     return res.json();
   },
 
+  // Block headers only, for verifying transaction proofs without full blocks
+  getHeaders: async (from, to) => {
+    const params = new URLSearchParams();
+    if (from !== undefined) params.set('from', from);
+    if (to !== undefined) params.set('to', to);
+    const res = await fetch(`${API_BASE}/headers?${params}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Block by height or hash, with confirmations, totals and neighbour links
   getBlock: async (index) => {
     const res = await fetch(`${API_BASE}/block/${index}`);
     return res.json();
   },
 
+  // Universal search: block index or hash, transaction ID or wallet ID
+  search: async (query) => {
+    const res = await fetch(`${API_BASE}/search?q=${encodeURIComponent(query)}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
   // UTXO operations
   getUTXOs: async (walletId) => {
     const res = await fetch(`${API_BASE}/utxos/${walletId}`);
     return res.json();
   },
 
+  // Combine the wallet's smallest outputs into one
+  consolidateUTXOs: async (walletId, { signingToken, maxInputs, below } = {}) => {
+    const res = await fetch(`${API_BASE}/wallet/${walletId}/consolidate`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({
+        signing_token: signingToken,
+        max_inputs: maxInputs,
+        below,
+      }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
   // Logs and reports
   getSystemLogs: async (limit = 100) => {
     const res = await fetch(`${API_BASE}/logs/system?limit=${limit}`);
@@ -111,6 +476,28 @@ export const api = {
     return res.json();
   },
 
+  // Statements need the wallet owner's login session
+  getStatements: async (walletId, sessionToken) => {
+    const res = await fetch(`${API_BASE}/statements/${walletId}`, {
+      headers: { Authorization: `Bearer ${sessionToken}` },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // period is a month written as YYYY-MM
+  getStatement: async (walletId, period, sessionToken) => {
+    const res = await fetch(`${API_BASE}/statements/${walletId}/${period}`, {
+      headers: { Authorization: `Bearer ${sessionToken}` },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
   // Beneficiary operations
   getBeneficiaries: async (userId) => {
     const res = await fetch(`${API_BASE}/beneficiaries/${userId}`);
@@ -133,8 +520,7 @@ export const api = {
       body: JSON.stringify(data),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -144,8 +530,7 @@ export const api = {
       method: 'DELETE',
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -158,8 +543,7 @@ export const api = {
       body: JSON.stringify(data),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -172,8 +556,7 @@ export const api = {
       body: JSON.stringify({ email }),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
     }
     return res.json();
   },
@@ -185,12 +568,164 @@ export const api = {
       body: JSON.stringify({ email, code }),
     });
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(error);
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Wallet backups
+  exportWallet: async (walletId, privateKey, passphrase) => {
+    const res = await fetch(`${API_BASE}/wallet/${walletId}/export`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ private_key: privateKey, passphrase }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  importWallet: async (backup, passphrase) => {
+    const res = await fetch(`${API_BASE}/wallet/import`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ backup, passphrase }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Authenticator app 2FA (sendTransaction takes totp_code above the threshold)
+  enroll2FA: async (walletId, privateKey) => {
+    const res = await fetch(`${API_BASE}/2fa/enroll`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ wallet_id: walletId, private_key: privateKey }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  verify2FA: async (walletId, code) => {
+    const res = await fetch(`${API_BASE}/2fa/verify`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ wallet_id: walletId, code }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  get2FAStatus: async (walletId) => {
+    const res = await fetch(`${API_BASE}/2fa/${walletId}`);
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Login sessions (OTP verify and Google login both return session_token)
+  googleConfig: async () => {
+    const res = await fetch(`${API_BASE}/auth/google/config`);
+    return res.json();
+  },
+
+  googleLogin: async (idToken) => {
+    const res = await fetch(`${API_BASE}/auth/google`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ id_token: idToken }),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
     }
     return res.json();
   },
 
+  logout: async (sessionToken) => {
+    const res = await fetch(`${API_BASE}/auth/logout`, {
+      method: 'POST',
+      headers: { Authorization: `Bearer ${sessionToken}` },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Live updates: opens /api/ws. Subscribe with
+  // ws.send(JSON.stringify({ action: 'subscribe', topic: `balance:${walletId}` }));
+  // wallet topics use the session token given here.
+  openEventSocket: (sessionToken) => {
+    const url = new URL(`${API_BASE}/ws`, window.location.href);
+    url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+    if (sessionToken) {
+      url.searchParams.set('session_token', sessionToken);
+    }
+    return new WebSocket(url);
+  },
+
+  // Organizations (multi-tenant mode; other calls then need an X-Org-ID header)
+  getOrg: async (orgId) => {
+    const res = await fetch(`${API_BASE}/org`, { headers: { 'X-Org-ID': orgId } });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Org admin calls name the admin wallet and prove it with its email's session token
+  getOrgWallets: async (orgId, adminWalletId, sessionToken) => {
+    const res = await fetch(`${API_BASE}/org/wallets`, {
+      headers: { 'X-Org-ID': orgId, 'X-Wallet-ID': adminWalletId, Authorization: `Bearer ${sessionToken}` },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  getOrgConfig: async (orgId, adminWalletId, sessionToken) => {
+    const res = await fetch(`${API_BASE}/org/config`, {
+      headers: { 'X-Org-ID': orgId, 'X-Wallet-ID': adminWalletId, Authorization: `Bearer ${sessionToken}` },
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // patch is a JSON merge patch: null restores a default
+  updateOrgConfig: async (orgId, adminWalletId, sessionToken, patch) => {
+    const res = await fetch(`${API_BASE}/org/config`, {
+      method: 'PATCH',
+      headers: {
+        'Content-Type': 'application/json',
+        'X-Org-ID': orgId,
+        'X-Wallet-ID': adminWalletId,
+        Authorization: `Bearer ${sessionToken}`,
+      },
+      body: JSON.stringify(patch),
+    });
+    if (!res.ok) {
+      throw await apiError(res);
+    }
+    return res.json();
+  },
+
+  // Features and the faucet, fee, currency and branding settings in effect
+  getCapabilities: async (orgId) => {
+    const res = await fetch(`${API_BASE}/capabilities`, orgId ? { headers: { 'X-Org-ID': orgId } } : {});
+    return res.json();
+  },
+
   // Admin operations
   checkAdmin: async (walletId) => {
     const res = await fetch(`${API_BASE}/admin/check/${walletId}`);
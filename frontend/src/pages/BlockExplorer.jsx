@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { api } from '../api/client';
+import { formatAmount } from '../api/amounts';
 import { useWallet } from '../context/WalletContext';
 
 export default function BlockExplorer() {
@@ -12,6 +13,8 @@ export default function BlockExplorer() {
   const [loading, setLoading] = useState(true);
   const [mining, setMining] = useState(false);
   const [systemReport, setSystemReport] = useState(null);
+  const [leaderboard, setLeaderboard] = useState(null);
+  const [rankBy, setRankBy] = useState('blocks');
   const [activeTab, setActiveTab] = useState('blocks');
   const [searchQuery, setSearchQuery] = useState('');
   const [filteredBlocks, setFilteredBlocks] = useState([]);
@@ -25,6 +28,12 @@ export default function BlockExplorer() {
     }
   }, [autoRefresh]);
 
+  useEffect(() => {
+    api.getMinerLeaderboard(rankBy)
+      .then(setLeaderboard)
+      .catch(err => console.error('Failed to load leaderboard:', err));
+  }, [rankBy, blocks.length]);
+
   useEffect(() => {
     // Ensure loading completes even if no data
     if (blocks.length >= 0) {
@@ -247,6 +256,7 @@ export default function BlockExplorer() {
           { key: 'blocks', label: 'Blocks', count: blocks.length },
           { key: 'transactions', label: 'All Transactions', count: transactions.length },
           { key: 'pending', label: 'Pending', count: pendingTransactions.length },
+          { key: 'miners', label: 'Miners', count: leaderboard?.entries.length ?? 0 },
         ].map(tab => (
           <button
             key={tab.key}
@@ -372,7 +382,7 @@ export default function BlockExplorer() {
                         </div>
                         <div className="text-right">
                           <p className="text-sm text-gray-600">Amount</p>
-                          <p className="font-bold text-lg text-green-600">{tx.amount} 💰</p>
+                          <p className="font-bold text-lg text-green-600">{formatAmount(tx.amount)} 💰</p>
                         </div>
                         <span className={`px-3 py-1 rounded-full text-xs font-semibold ${getTransactionTypeColor(tx.type)}`}>
                           {tx.type.replace(/_/g, ' ').toUpperCase()}
@@ -425,7 +435,71 @@ export default function BlockExplorer() {
                         </div>
                         <div className="text-right">
                           <p className="text-sm text-gray-600">Amount</p>
-                          <p className="font-bold text-lg text-orange-600">{tx.amount} 💰</p>
+                          <p className="font-bold text-lg text-orange-600">{formatAmount(tx.amount)} 💰</p>
+                        </div>
+                      </div>
+                    </div>
+                  </div>
+                ))}
+              </div>
+            )}
+          </div>
+        )}
+
+        {/* Mining Leaderboard */}
+        {activeTab === 'miners' && (
+          <div className="p-6">
+            <div className="flex items-center justify-between mb-4">
+              <h3 className="text-xl font-bold text-gray-900">🏆 Mining Leaderboard</h3>
+              <select
+                value={rankBy}
+                onChange={(e) => setRankBy(e.target.value)}
+                className="px-3 py-2 border border-gray-300 rounded-lg text-sm"
+              >
+                <option value="blocks">By blocks mined</option>
+                <option value="rewards">By rewards</option>
+              </select>
+            </div>
+            {!leaderboard || leaderboard.entries.length === 0 ? (
+              <div className="text-center py-12">
+                <p className="text-gray-500 text-lg">No blocks mined yet</p>
+              </div>
+            ) : (
+              <div className="space-y-3">
+                {leaderboard.entries.map(entry => (
+                  <div
+                    key={entry.wallet_id}
+                    className="border border-gray-200 rounded-lg p-4 flex items-center justify-between gap-4"
+                  >
+                    <div className="flex items-center gap-3">
+                      <span className="text-2xl w-10 text-center">
+                        {['🥇', '🥈', '🥉'][entry.rank - 1] || `#${entry.rank}`}
+                      </span>
+                      <div>
+                        <p className="font-semibold text-gray-900">
+                          {entry.handle ? `@${entry.handle}` : formatHash(entry.wallet_id, 8)}
+                        </p>
+                        <p className="text-sm text-gray-500">
+                          Last block {new Date(entry.last_mined_at).toLocaleString()}
+                        </p>
+                      </div>
+                    </div>
+                    <div className="flex items-center gap-6 text-right">
+                      <div>
+                        <p className="text-sm text-gray-600">Blocks</p>
+                        <p className="font-bold text-lg">{entry.blocks_mined}</p>
+                      </div>
+                      <div>
+                        <p className="text-sm text-gray-600">Rewards</p>
+                        <p className="font-bold text-lg text-green-600">{formatAmount(entry.total_rewards)} 💰</p>
+                      </div>
+                      <div className="w-24">
+                        <p className="text-sm text-gray-600">Share</p>
+                        <div className="h-2 bg-gray-200 rounded-full mt-2">
+                          <div
+                            className="h-2 bg-gradient-to-r from-indigo-600 to-purple-600 rounded-full"
+                            style={{ width: `${Math.round(entry.share * 100)}%` }}
+                          />
                         </div>
                       </div>
                     </div>
@@ -530,7 +604,7 @@ export default function BlockExplorer() {
                         </div>
                         <div>
                           <p className="text-xs text-gray-500 font-semibold">Amount</p>
-                          <p className="text-sm font-bold text-green-600">{tx.amount} 💰</p>
+                          <p className="text-sm font-bold text-green-600">{formatAmount(tx.amount)} 💰</p>
                         </div>
                         {tx.note && (
                           <div>
@@ -600,7 +674,7 @@ export default function BlockExplorer() {
 
               <div className="bg-green-50 p-4 rounded-lg border border-green-200">
                 <p className="text-xs text-gray-500 font-semibold mb-1">Amount</p>
-                <p className="text-2xl font-bold text-green-600">{selectedTransaction.amount} 💰</p>
+                <p className="text-2xl font-bold text-green-600">{formatAmount(selectedTransaction.amount)} 💰</p>
               </div>
 
               {selectedTransaction.note && (
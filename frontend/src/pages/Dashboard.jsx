@@ -1,10 +1,12 @@
 import React, { useState, useEffect } from 'react';
 import { api } from '../api/client';
+import { formatAmount } from '../api/amounts';
 import { useWallet } from '../context/WalletContext';
 
 export default function Dashboard() {
   const { currentWallet } = useWallet();
   const [balance, setBalance] = useState(0);
+  const [assets, setAssets] = useState({});
   const [utxos, setUtxos] = useState([]);
   const [report, setReport] = useState(null);
   const [loading, setLoading] = useState(true);
@@ -36,12 +38,14 @@ export default function Dashboard() {
       console.log('Report:', reportData);
       
       setBalance(balData.balance || 0);
+      setAssets(balData.assets || {});
       setUtxos(Array.isArray(utxoData) ? utxoData : []);
       setReport(reportData || {});
     } catch (err) {
       console.error('Failed to load dashboard data:', err);
       console.error('Error details:', err.message);
       setBalance(0);
+      setAssets({});
       setUtxos([]);
       setReport({});
     } finally {
@@ -90,11 +94,20 @@ export default function Dashboard() {
         <div className="absolute bottom-0 left-0 w-48 h-48 bg-white opacity-5 rounded-full -ml-24 -mb-24"></div>
         <div className="relative z-10">
           <p className="text-xl opacity-90 font-semibold uppercase tracking-wide">Total Balance</p>
-          <p className="text-6xl font-bold mt-4 tabular-nums">{balance.toLocaleString()}</p>
+          <p className="text-6xl font-bold mt-4 tabular-nums">{formatAmount(balance)}</p>
           <p className="text-base opacity-80 mt-2">Blockchain Units</p>
+          {Object.keys(assets).length > 0 && (
+            <div className="mt-4 flex flex-wrap gap-3">
+              {Object.entries(assets).map(([symbol, amount]) => (
+                <span key={symbol} className="bg-white bg-opacity-20 rounded-lg px-3 py-1 font-semibold tabular-nums">
+                  {formatAmount(amount)} {symbol}
+                </span>
+              ))}
+            </div>
+          )}
           <div className="mt-6 pt-6 border-t border-white border-opacity-30">
             <p className="text-base opacity-90 mb-2">Net Balance (Received - Sent)</p>
-            <p className="text-3xl font-bold">{netBalance.toLocaleString()} units</p>
+            <p className="text-3xl font-bold">{formatAmount(netBalance)} coins</p>
           </div>
         </div>
       </div>
@@ -106,7 +119,7 @@ export default function Dashboard() {
             <div>
               <p className="text-green-700 text-sm font-bold uppercase tracking-wide">Total Received</p>
               <p className="text-4xl font-bold text-green-900 mt-3">
-                {(report?.total_received || 0).toLocaleString()}
+                {formatAmount(report?.total_received)}
               </p>
               <p className="text-sm text-green-600 mt-2">{report?.received_count || 0} transactions</p>
             </div>
@@ -118,7 +131,7 @@ export default function Dashboard() {
             <div>
               <p className="text-red-700 text-sm font-bold uppercase tracking-wide">Total Sent</p>
               <p className="text-4xl font-bold text-red-900 mt-3">
-                {(report?.total_sent || 0).toLocaleString()}
+                {formatAmount(report?.total_sent)}
               </p>
               <p className="text-sm text-red-600 mt-2">{report?.sent_count || 0} transactions</p>
             </div>
@@ -142,7 +155,7 @@ export default function Dashboard() {
             <div>
               <p className="text-purple-700 text-sm font-bold uppercase tracking-wide">Average TX Value</p>
               <p className="text-4xl font-bold text-purple-900 mt-3">
-                {report?.sent_count ? ((report?.total_sent || 0) / (report?.sent_count || 1)).toFixed(2) : '0'}
+                {report?.sent_count ? formatAmount(Math.floor((report?.total_sent || 0) / report.sent_count)) : '0'}
               </p>
               <p className="text-sm text-purple-600 mt-2">Per transaction</p>
             </div>
@@ -173,6 +186,11 @@ export default function Dashboard() {
             <code className="block bg-gray-100 p-3 rounded text-sm break-all font-mono text-blue-600">
               {currentWallet.wallet_id}
             </code>
+            <img
+              src={api.walletQRUrl(currentWallet.wallet_id, { size: 192 })}
+              alt="QR code of your wallet address"
+              className="mt-3 w-48 h-48 border border-gray-200 rounded"
+            />
           </div>
           {currentWallet.public_key && (
             <div className="bg-gray-50 p-4 rounded-lg border border-gray-200 md:col-span-2">
@@ -224,7 +242,7 @@ export default function Dashboard() {
                     </td>
                     <td className="px-4 py-3 font-mono text-gray-600">#{utxo.index}</td>
                     <td className="px-4 py-3 text-right font-bold text-green-600">
-                      {utxo.amount || 0} 💰
+                      {formatAmount(utxo.amount)} 💰
                     </td>
                   </tr>
                 ))}
@@ -233,7 +251,7 @@ export default function Dashboard() {
           </div>
           <div className="mt-4 pt-4 border-t border-gray-200">
             <p className="text-sm text-gray-600">
-              Total UTXO Value: <span className="font-bold text-green-600">{balance} units</span>
+              Total UTXO Value: <span className="font-bold text-green-600">{formatAmount(balance)} coins</span>
             </p>
           </div>
         </div>
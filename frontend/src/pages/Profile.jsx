@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useWallet } from '../context/WalletContext';
 import { api } from '../api/client';
+import { formatAmount } from '../api/amounts';
 
 export default function Profile() {
   const { currentWallet, privateKey, login } = useWallet();
@@ -8,6 +9,8 @@ export default function Profile() {
   const [utxos, setUTXOs] = useState([]);
   const [loading, setLoading] = useState(true);
   const [editing, setEditing] = useState(false);
+  const [discoverable, setDiscoverable] = useState(false);
+  const [handle, setHandle] = useState('');
   const [formData, setFormData] = useState({
     full_name: '',
     email: '',
@@ -35,6 +38,12 @@ export default function Profile() {
 
       const utxoData = await api.getUTXOs(currentWallet.wallet_id);
       setUTXOs(Array.isArray(utxoData) ? utxoData : []);
+
+      const directory = await api.getDirectorySetting(currentWallet.wallet_id);
+      setDiscoverable(directory.discoverable);
+
+      const handleData = await api.getWalletHandle(currentWallet.wallet_id);
+      setHandle(handleData.handle);
     } catch (err) {
       console.error('Failed to load profile data', err);
       setBalance(0);
@@ -49,6 +58,26 @@ export default function Profile() {
     alert(`${label} copied to clipboard!`);
   };
 
+  const toggleDiscoverable = async () => {
+    try {
+      const result = await api.setDirectorySetting(currentWallet.wallet_id, privateKey, !discoverable);
+      setDiscoverable(result.discoverable);
+    } catch (err) {
+      alert('Failed to change directory listing: ' + err.message);
+    }
+  };
+
+  const changeHandle = async () => {
+    const next = prompt('Choose a handle others can send to (3-20 letters, digits or _)', handle);
+    if (!next) return;
+    try {
+      const claim = await api.claimHandle(currentWallet.wallet_id, privateKey, next);
+      setHandle(claim.handle);
+    } catch (err) {
+      alert('Failed to claim handle: ' + err.message);
+    }
+  };
+
   const handleUpdateProfile = async (e) => {
     e.preventDefault();
     setLoading(true);
@@ -172,7 +201,7 @@ export default function Profile() {
             {!editing && (
               <div className="text-right">
                 <p className="text-sm text-indigo-200 uppercase tracking-wide">Current Balance</p>
-                <p className="text-5xl font-bold mt-2">{balance}</p>
+                <p className="text-5xl font-bold mt-2">{formatAmount(balance)}</p>
                 <p className="text-indigo-100 mt-1">Coins</p>
               </div>
             )}
@@ -224,6 +253,21 @@ export default function Profile() {
             </div>
           </div>
 
+          <div className="flex items-center gap-2 text-sm text-gray-700">
+            <span className="font-medium">Handle:</span>
+            <span className="font-mono">{handle ? `@${handle}` : 'none'}</span>
+            <button onClick={changeHandle} className="px-3 py-1 bg-indigo-600 text-white rounded hover:bg-indigo-700 transition">
+              {handle ? 'Change' : 'Claim'}
+            </button>
+          </div>
+
+          {currentWallet.email && (
+            <label className="flex items-center gap-2 text-sm text-gray-700">
+              <input type="checkbox" checked={discoverable} onChange={toggleDiscoverable} />
+              Let others find this wallet by my email ({currentWallet.email})
+            </label>
+          )}
+
           <div className="bg-red-50 border border-red-200 rounded p-4">
             <p className="text-sm text-red-800">
               <span className="font-bold">⚠️ Security Note:</span> Never share your private key with anyone.
@@ -254,7 +298,7 @@ export default function Profile() {
                   </p>
                 </div>
                 <div className="text-right">
-                  <p className="text-lg font-bold text-green-600">{utxo.amount} Coins</p>
+                  <p className="text-lg font-bold text-green-600">{formatAmount(utxo.amount)} Coins</p>
                   <p className="text-xs text-gray-500">
                     {utxo.spent ? '❌ Spent' : '✅ Unspent'}
                   </p>
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { api } from '../api/client';
+import { formatAmount } from '../api/amounts';
 import { useWallet } from '../context/WalletContext';
 
 export default function Reports() {
@@ -58,13 +59,13 @@ export default function Reports() {
           <div className="border rounded-lg p-4">
             <h4 className="text-gray-600 text-sm mb-1">Current Balance</h4>
             <p className="text-3xl font-bold text-blue-600">
-              {walletReport?.balance?.toLocaleString() || 0}
+              {formatAmount(walletReport?.balance)}
             </p>
           </div>
           <div className="border rounded-lg p-4">
             <h4 className="text-gray-600 text-sm mb-1">Total Sent</h4>
             <p className="text-3xl font-bold text-red-600">
-              {walletReport?.total_sent?.toLocaleString() || 0}
+              {formatAmount(walletReport?.total_sent)}
             </p>
             <p className="text-xs text-gray-500 mt-1">
               {walletReport?.sent_count || 0} transactions
@@ -73,7 +74,7 @@ export default function Reports() {
           <div className="border rounded-lg p-4">
             <h4 className="text-gray-600 text-sm mb-1">Total Received</h4>
             <p className="text-3xl font-bold text-green-600">
-              {walletReport?.total_received?.toLocaleString() || 0}
+              {formatAmount(walletReport?.total_received)}
             </p>
             <p className="text-xs text-gray-500 mt-1">
               {walletReport?.received_count || 0} transactions
@@ -88,7 +89,7 @@ export default function Reports() {
           </p>
           <p className="text-sm text-purple-700">
             Next deduction amount: ~{' '}
-            {Math.floor((walletReport?.balance || 0) * 0.025).toLocaleString()} units
+            {formatAmount(Math.floor((walletReport?.balance || 0) * 0.025))} coins
           </p>
         </div>
       </div>
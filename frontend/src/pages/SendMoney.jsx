@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { api } from '../api/client';
+import { formatAmount, parseAmount } from '../api/amounts';
 import { useWallet } from '../context/WalletContext';
 import { useNavigation } from '../context/NavigationContext';
 
@@ -25,21 +26,54 @@ export default function SendMoney() {
   const [loading, setLoading] = useState(false);
   const [result, setResult] = useState(null);
   const [error, setError] = useState('');
+  const [preview, setPreview] = useState(null);
+
+  // The send the form describes, or null after reporting a bad amount
+  const buildSend = async () => {
+    const amount = parseAmount(formData.amount);
+    if (!(amount > 0)) {
+      setError('Enter an amount of coins with at most 8 decimal places');
+      return null;
+    }
+    // An email is sent to the wallet its owner listed in the directory
+    let receiverId = formData.receiverId.trim();
+    if (receiverId.includes('@') && !receiverId.startsWith('@')) {
+      receiverId = (await api.resolveEmail(receiverId)).wallet_id;
+    }
+    return {
+      sender_id: currentWallet.wallet_id,
+      receiver_id: receiverId,
+      amount,
+      note: formData.note,
+    };
+  };
+
+  const handlePreview = async () => {
+    setError('');
+    setPreview(null);
+    try {
+      const send = await buildSend();
+      if (send) {
+        setPreview(await api.simulateSend(send));
+      }
+    } catch (err) {
+      setError(err.message || 'Preview failed');
+    }
+  };
 
   const handleSubmit = async (e) => {
     e.preventDefault();
     setLoading(true);
     setError('');
     setResult(null);
+    setPreview(null);
 
     try {
-      const response = await api.sendTransaction({
-        sender_id: currentWallet.wallet_id,
-        receiver_id: formData.receiverId,
-        amount: parseInt(formData.amount),
-        note: formData.note,
-        private_key: privateKey,
-      });
+      const send = await buildSend();
+      if (!send) {
+        return;
+      }
+      const response = await api.sendTransaction({ ...send, private_key: privateKey });
       setResult(response);
       setFormData({ receiverId: '', amount: '', note: '' });
     } catch (err) {
@@ -62,7 +96,7 @@ export default function SendMoney() {
         <form onSubmit={handleSubmit} className="space-y-6">
           <div>
             <label className="block text-sm font-bold text-gray-700 mb-2 uppercase tracking-wide">
-              Receiver Wallet ID *
+              Receiver Wallet ID, @Handle or Email *
             </label>
             <input
               type="text"
@@ -71,7 +105,7 @@ export default function SendMoney() {
                 setFormData({ ...formData, receiverId: e.target.value })
               }
               className="w-full px-4 py-3 border-2 border-gray-300 rounded-xl focus:outline-none focus:border-indigo-500 focus:ring-2 focus:ring-indigo-200 transition-all duration-200 font-mono text-sm"
-              placeholder="Enter receiver's wallet ID"
+              placeholder="Enter receiver's wallet ID, @handle or email"
               required
             />
           </div>
@@ -89,7 +123,8 @@ export default function SendMoney() {
                 }
                 className="w-full px-4 py-3 border-2 border-gray-300 rounded-xl focus:outline-none focus:border-indigo-500 focus:ring-2 focus:ring-indigo-200 transition-all duration-200 text-lg font-semibold"
                 placeholder="0.00"
-                min="1"
+                min="0.00000001"
+                step="0.00000001"
                 required
               />
               <span className="absolute right-4 top-1/2 -translate-y-1/2 text-gray-500 font-semibold">
@@ -120,6 +155,17 @@ export default function SendMoney() {
             </div>
           )}
 
+          {preview && (
+            <div className="p-4 bg-indigo-50 border-2 border-indigo-200 rounded-xl text-sm text-indigo-900 space-y-1">
+              <p className="font-bold">Preview</p>
+              <p>Fee: {formatAmount(preview.fee)} coins</p>
+              <p>Spends {preview.selected_utxos.length} output(s){preview.change ? `, ${formatAmount(preview.change.amount)} coins back as change` : ''}</p>
+              <p>Balance after: {formatAmount(preview.balance_after)} coins</p>
+              {preview.totp_required && <p className="text-amber-700">This amount needs an authenticator code.</p>}
+              {preview.limit_otp_required && <p className="text-amber-700">This send is over your spending limits.</p>}
+            </div>
+          )}
+
           {result && (
             <div className="p-5 bg-green-50 border-2 border-green-400 rounded-xl">
               <p className="font-bold text-lg text-green-900 mb-2">Transaction Created Successfully!</p>
@@ -135,6 +181,15 @@ export default function SendMoney() {
             </div>
           )}
 
+          <button
+            type="button"
+            onClick={handlePreview}
+            disabled={loading}
+            className="w-full py-3 border-2 border-indigo-300 text-indigo-700 rounded-xl font-bold transition-all duration-200 hover:bg-indigo-50 disabled:opacity-50 disabled:cursor-not-allowed"
+          >
+            Preview
+          </button>
+
           <button
             type="submit"
             disabled={loading}
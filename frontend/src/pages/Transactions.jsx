@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { api } from '../api/client';
+import { formatAmount } from '../api/amounts';
 import { useWallet } from '../context/WalletContext';
 
 export default function Transactions() {
@@ -156,7 +157,7 @@ export default function Transactions() {
                         }`}
                       >
                         {isSent ? '-' : '+'}
-                        {tx.amount.toLocaleString()}
+                        {formatAmount(tx.amount)}
                       </p>
                       <p className="text-xs text-gray-500 mt-1">
                         TX: {tx.id.substring(0, 12)}...